@@ -0,0 +1,193 @@
+package benchmarks
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// 所有场景共用的测试数据
+var (
+	benchMsg      = "request completed"
+	benchErr      = errors.New("connection reset by peer")
+	benchDuration = 1500 * time.Microsecond
+	benchJSON     = `{"user":{"id":42,"name":"alice"},"roles":["admin","dev"]}`
+)
+
+// discardWriter 丢弃所有输出的 logm.Writer
+type discardWriter struct{}
+
+func (discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (discardWriter) Close() error                { return nil }
+func (discardWriter) Sync() error                 { return nil }
+
+// newLogm 创建输出到 io.Discard 的 logm logger
+func newLogm(f logm.Formatter) *slog.Logger {
+	return logm.New(
+		logm.WithFormatter(f),
+		logm.WithWriter(discardWriter{}),
+		logm.WithLevel("INFO"),
+	)
+}
+
+// slogLoggers 基于 slog.Handler 的 logger（logm 与标准库）
+func slogLoggers() map[string]*slog.Logger {
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	return map[string]*slog.Logger{
+		"logm/JSON":      newLogm(formatter.JSON()),
+		"logm/Text":      newLogm(formatter.Text()),
+		"logm/ColorText": newLogm(formatter.ColorText(formatter.WithColor(false))),
+		"logm/ColorJSON": newLogm(formatter.ColorJSON(formatter.WithColor(false))),
+		"slog/JSON":      slog.New(slog.NewJSONHandler(io.Discard, opts)),
+		"slog/Text":      slog.New(slog.NewTextHandler(io.Discard, opts)),
+	}
+}
+
+// newZap 创建输出到 io.Discard 的 zap logger（生产环境 JSON 编码）
+func newZap() *zap.Logger {
+	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	core := zapcore.NewCore(enc, zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+	return zap.New(core)
+}
+
+// newZerolog 创建输出到 io.Discard 的 zerolog logger
+func newZerolog() zerolog.Logger {
+	return zerolog.New(io.Discard).Level(zerolog.InfoLevel).With().Timestamp().Logger()
+}
+
+func BenchmarkDisabled(b *testing.B) {
+	for name, logger := range slogLoggers() {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				logger.Debug(benchMsg, "status", 200)
+			}
+		})
+	}
+	b.Run("zap", func(b *testing.B) {
+		logger := newZap()
+		b.ReportAllocs()
+		for b.Loop() {
+			logger.Debug(benchMsg, zap.Int("status", 200))
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		logger := newZerolog()
+		b.ReportAllocs()
+		for b.Loop() {
+			logger.Debug().Int("status", 200).Msg(benchMsg)
+		}
+	})
+}
+
+func BenchmarkFields(b *testing.B) {
+	for name, logger := range slogLoggers() {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				logger.Info(benchMsg,
+					slog.String("method", "GET"),
+					slog.String("path", "/api/users"),
+					slog.Int("status", 200),
+					slog.Duration("elapsed", benchDuration),
+					slog.Bool("cached", false),
+					slog.Any("error", benchErr),
+				)
+			}
+		})
+	}
+	b.Run("zap", func(b *testing.B) {
+		logger := newZap()
+		b.ReportAllocs()
+		for b.Loop() {
+			logger.Info(benchMsg,
+				zap.String("method", "GET"),
+				zap.String("path", "/api/users"),
+				zap.Int("status", 200),
+				zap.Duration("elapsed", benchDuration),
+				zap.Bool("cached", false),
+				zap.Error(benchErr),
+			)
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		logger := newZerolog()
+		b.ReportAllocs()
+		for b.Loop() {
+			logger.Info().
+				Str("method", "GET").
+				Str("path", "/api/users").
+				Int("status", 200).
+				Dur("elapsed", benchDuration).
+				Bool("cached", false).
+				Err(benchErr).
+				Msg(benchMsg)
+		}
+	})
+}
+
+func BenchmarkWithContext(b *testing.B) {
+	contextArgs := []any{
+		"service", "api", "version", "1.2.3", "region", "cn-east",
+		"host", "web-01", "pid", 1234, "env", "prod",
+		"request_id", "req-12345", "user_id", 42, "tenant", "acme", "trace", true,
+	}
+
+	for name, logger := range slogLoggers() {
+		b.Run(name, func(b *testing.B) {
+			logger := logger.With(contextArgs...)
+			b.ReportAllocs()
+			for b.Loop() {
+				logger.Info(benchMsg)
+			}
+		})
+	}
+	b.Run("zap", func(b *testing.B) {
+		logger := newZap().Sugar().With(contextArgs...).Desugar()
+		b.ReportAllocs()
+		for b.Loop() {
+			logger.Info(benchMsg)
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		logger := newZerolog().With().Fields(contextArgs).Logger()
+		b.ReportAllocs()
+		for b.Loop() {
+			logger.Info().Msg(benchMsg)
+		}
+	})
+}
+
+func BenchmarkJSONString(b *testing.B) {
+	for name, logger := range slogLoggers() {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				logger.Info(benchMsg, "payload", benchJSON)
+			}
+		})
+	}
+	b.Run("zap", func(b *testing.B) {
+		logger := newZap()
+		b.ReportAllocs()
+		for b.Loop() {
+			logger.Info(benchMsg, zap.String("payload", benchJSON))
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		logger := newZerolog()
+		b.ReportAllocs()
+		for b.Loop() {
+			logger.Info().Str("payload", benchJSON).Msg(benchMsg)
+		}
+	})
+}
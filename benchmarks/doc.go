@@ -0,0 +1,14 @@
+// Package benchmarks 对比 logm 与 slog 内置 Handler、zap、zerolog 的性能。
+//
+// 独立 module，避免第三方日志库进入 logm 的依赖。所有 logger 输出到 io.Discard，
+// 使用相同的消息和字段，用于量化 JSON 展开、彩色输出等特性的开销：
+//
+//	cd benchmarks
+//	go test -bench . -benchmem
+//
+// 场景：
+//   - Disabled: 级别被过滤的日志
+//   - Fields: 每条日志携带 6 个不同类型的字段
+//   - WithContext: 通过 With 预置 10 个字段，每条日志不带字段
+//   - JSONString: 字段值为 JSON 字符串（ColorText 会展开为平铺字段）
+package benchmarks
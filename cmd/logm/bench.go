@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// pipelineFlags 描述要驱动的日志管道
+type pipelineFlags struct {
+	env    bool
+	format string
+	output string
+	async  int
+	source bool
+}
+
+// register 注册管道参数，defaultOutput 为 --output 的默认值
+func (p *pipelineFlags) register(fs *flag.FlagSet, defaultOutput string) {
+	fs.BoolVar(&p.env, "env", false, "build the pipeline from LOGM_* variables like PresetFromEnv; --format, --output and --source are ignored")
+	fs.StringVar(&p.format, "format", "json", "formatter: json, text, color_text, color_json")
+	fs.StringVar(&p.output, "output", defaultOutput, "writer: discard, stdout, stderr or a file path")
+	fs.IntVar(&p.async, "async", 0, "wrap the writer in writer.Async with this buffer size (0 writes synchronously)")
+	fs.BoolVar(&p.source, "source", false, "record the caller source location")
+}
+
+// describe 返回管道的简短描述
+func (p *pipelineFlags) describe() string {
+	s := p.format + " → " + p.output
+	if p.env {
+		s = "PresetFromEnv"
+	}
+	if p.async > 0 {
+		s += fmt.Sprintf(" (async %d)", p.async)
+	}
+	return s
+}
+
+// handler 按参数创建 Handler，stdout 为 --output stdout 时的输出目标
+func (p *pipelineFlags) handler(stdout io.Writer) (*logm.Handler, error) {
+	var opts []logm.Option
+	if p.env {
+		opts = logm.PresetFromEnv()
+	} else {
+		f, err := namedFormatter(p.format)
+		if err != nil {
+			return nil, err
+		}
+		var w logm.Writer
+		switch p.output {
+		case "discard":
+			w = discardWriter{}
+		case "stdout":
+			w = nopCloser{stdout}
+		case "stderr":
+			w = writer.Stderr()
+		default:
+			w = writer.File(p.output)
+		}
+		if p.async > 0 {
+			w = writer.Async(w, p.async)
+		}
+		opts = []logm.Option{
+			logm.WithLevel("DEBUG"),
+			logm.WithFormatter(f),
+			logm.WithWriter(w),
+			logm.WithAddSource(p.source),
+		}
+	}
+	h, ok := logm.New(opts...).Handler().(*logm.Handler)
+	if !ok {
+		return nil, fmt.Errorf("unexpected handler type")
+	}
+	return h, nil
+}
+
+// namedFormatter 按 LOGM_FORMAT 的名称创建格式化器
+func namedFormatter(name string) (formatter.Formatter, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return formatter.JSON(formatter.WithTimeFormat("rfc3339ms")), nil
+	case "text":
+		return formatter.Text(formatter.WithTimeFormat("rfc3339ms")), nil
+	case "color_text":
+		return formatter.ColorText(), nil
+	case "color_json":
+		return formatter.ColorJSON(), nil
+	default:
+		return nil, fmt.Errorf("invalid --format %q (json, text, color_text, color_json)", name)
+	}
+}
+
+// discardWriter 丢弃所有输出，用于测量格式化和管道本身的开销
+type discardWriter struct{}
+
+func (discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (discardWriter) Close() error                { return nil }
+func (discardWriter) Sync() error                 { return nil }
+
+// nopCloser 将 io.Writer 适配为不关闭底层输出的 Writer
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+func (nopCloser) Sync() error  { return nil }
+
+// benchReport logm bench 的结果
+type benchReport struct {
+	Pipeline      string        `json:"pipeline"`
+	Workers       int           `json:"workers"`
+	Fields        int           `json:"fields"`
+	Records       uint64        `json:"records"`
+	Emit          time.Duration `json:"emit_ns"`
+	Total         time.Duration `json:"total_ns"`
+	RecordsPerSec float64       `json:"records_per_sec"`
+	BytesWritten  uint64        `json:"bytes_written"`
+	P50           time.Duration `json:"p50_ns"`
+	P99           time.Duration `json:"p99_ns"`
+	P999          time.Duration `json:"p999_ns"`
+	Max           time.Duration `json:"max_ns"`
+	Dropped       uint64        `json:"dropped"`
+	WriteErrors   uint64        `json:"write_errors"`
+}
+
+// latencySamples 每个 worker 保留的延迟样本数，超出后按蓄水池抽样
+const latencySamples = 1 << 16
+
+// runBench 实现 logm bench
+func runBench(c *cli, args []string) error {
+	fs := c.flagSet("bench", "")
+	var pf pipelineFlags
+	pf.register(fs, "discard")
+	records := fs.Int("records", 100000, "number of records to emit; ignored when --duration is set")
+	duration := fs.Duration("duration", 0, "emit records for this long instead of a fixed count")
+	workers := fs.Int("workers", 1, "number of goroutines logging concurrently")
+	fields := fs.Int("fields", 10, "attributes per record")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *workers < 1 || (*duration <= 0 && *records < 1) {
+		return fmt.Errorf("invalid --workers or --records, both must be positive")
+	}
+	h, err := pf.handler(c.stdout)
+	if err != nil {
+		return err
+	}
+
+	logger := slog.New(h)
+	var (
+		count    atomic.Uint64
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		samples  []time.Duration
+		maxLat   time.Duration
+		deadline time.Time
+	)
+	if *duration > 0 {
+		deadline = time.Now().Add(*duration)
+	}
+	start := time.Now()
+	for w := range *workers {
+		wg.Go(func() {
+			s := newSynth(uint64(w)+1, *fields)
+			local := make([]time.Duration, 0, latencySamples)
+			var seen int
+			var localMax time.Duration
+			ctx := context.Background()
+			for c.ctx.Err() == nil {
+				if deadline.IsZero() {
+					if count.Add(1) > uint64(*records) {
+						break
+					}
+				} else if time.Now().After(deadline) {
+					break
+				} else {
+					count.Add(1)
+				}
+				level, msg, attrs := s.record()
+				t0 := time.Now()
+				logger.LogAttrs(ctx, level, msg, attrs...)
+				lat := time.Since(t0)
+
+				localMax = max(localMax, lat)
+				if seen++; len(local) < latencySamples {
+					local = append(local, lat)
+				} else if i := s.rng.IntN(seen); i < latencySamples {
+					local[i] = lat
+				}
+			}
+			mu.Lock()
+			samples = append(samples, local...)
+			maxLat = max(maxLat, localMax)
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	emit := time.Since(start)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	dropped, shutdownErr := h.Shutdown(ctx)
+	total := time.Since(start)
+	stats := h.Stats()
+
+	n := min(count.Load(), uint64(*records))
+	if *duration > 0 {
+		n = count.Load()
+	}
+	slices.Sort(samples)
+	r := benchReport{
+		Pipeline:      pf.describe(),
+		Workers:       *workers,
+		Fields:        *fields,
+		Records:       n,
+		Emit:          emit,
+		Total:         total,
+		RecordsPerSec: float64(n) / total.Seconds(),
+		BytesWritten:  stats.BytesWritten,
+		P50:           percentile(samples, 0.50),
+		P99:           percentile(samples, 0.99),
+		P999:          percentile(samples, 0.999),
+		Max:           maxLat,
+		Dropped:       max(dropped, stats.Dropped),
+	}
+	for _, ws := range stats.Writers {
+		r.WriteErrors += ws.WriteErrors
+	}
+
+	// 报告写入 stderr，避免与 --output stdout 的日志混在一起
+	if *asJSON {
+		data, _ := json.MarshalIndent(r, "", "  ")
+		fmt.Fprintln(c.stderr, string(data))
+	} else {
+		r.print(c.stderr)
+	}
+	if shutdownErr != nil {
+		return fmt.Errorf("flush: %w", shutdownErr)
+	}
+	return nil
+}
+
+// print 以文本形式输出报告
+func (r *benchReport) print(w io.Writer) {
+	fmt.Fprintf(w, "pipeline  %s, %d worker(s), %d fields\n", r.Pipeline, r.Workers, r.Fields)
+	fmt.Fprintf(w, "records   %d in %s (emit %s, flush %s)\n", r.Records, round(r.Total), round(r.Emit), round(r.Total-r.Emit))
+	fmt.Fprintf(w, "rate      %.0f records/s, %.1f MB/s\n", r.RecordsPerSec, float64(r.BytesWritten)/1e6/r.Total.Seconds())
+	fmt.Fprintf(w, "latency   p50 %s  p99 %s  p99.9 %s  max %s\n", round(r.P50), round(r.P99), round(r.P999), round(r.Max))
+	fmt.Fprintf(w, "dropped   %d\n", r.Dropped)
+	fmt.Fprintf(w, "errors    %d\n", r.WriteErrors)
+}
+
+// percentile 返回已排序样本的 p 分位数
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[min(int(float64(len(sorted))*p), len(sorted)-1)]
+}
+
+// round 按数量级保留三位有效数字左右
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	case d >= time.Microsecond:
+		return d.Round(10 * time.Nanosecond)
+	default:
+		return d
+	}
+}
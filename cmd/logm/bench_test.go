@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBench_Report(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "bench", "--records", "500", "--workers", "4", "--fields", "5")
+	require.Equal(t, 0, code, stderr)
+	assert.Empty(t, stdout)
+	for _, want := range []string{"json → discard, 4 worker(s), 5 fields", "records   500 in", "records/s", "p99", "dropped   0"} {
+		assert.Contains(t, stderr, want)
+	}
+}
+
+func TestBench_JSONReportAndFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bench.log")
+	_, stderr, code := runCLI(t, "", "bench", "--records", "200", "--output", path, "--async", "64", "--json")
+	require.Equal(t, 0, code, stderr)
+
+	var r benchReport
+	require.NoError(t, json.Unmarshal([]byte(stderr), &r))
+	assert.Equal(t, uint64(200), r.Records)
+	assert.Positive(t, r.BytesWritten)
+	assert.Positive(t, r.RecordsPerSec)
+	assert.LessOrEqual(t, r.P50, r.P99)
+	assert.LessOrEqual(t, r.P99, r.Max)
+	assert.Zero(t, r.WriteErrors)
+}
+
+func TestBench_StdoutOutput(t *testing.T) {
+	stdout, _, code := runCLI(t, "", "bench", "--records", "20", "--output", "stdout", "--format", "text")
+	require.Equal(t, 0, code)
+	assert.Len(t, strings.Split(strings.TrimSpace(stdout), "\n"), 20)
+}
+
+func TestBench_InvalidFlags(t *testing.T) {
+	_, stderr, code := runCLI(t, "", "bench", "--format", "xml")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "invalid --format")
+
+	_, _, code = runCLI(t, "", "bench", "--workers", "0")
+	assert.Equal(t, 1, code)
+}
+
+func TestPercentile(t *testing.T) {
+	assert.Zero(t, percentile(nil, 0.99))
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, time.Duration(6), percentile(sorted, 0.5))
+	assert.Equal(t, time.Duration(10), percentile(sorted, 0.99))
+}
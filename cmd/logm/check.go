@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/redact"
+)
+
+// checker 收集 logm check 发现的问题
+type checker struct {
+	errors   int
+	warnings int
+	out      *strings.Builder
+}
+
+// errorf 记录导致检查失败的问题
+func (k *checker) errorf(source, format string, args ...any) {
+	k.errors++
+	fmt.Fprintf(k.out, "%s: error: %s\n", source, fmt.Sprintf(format, args...))
+}
+
+// okf 记录通过的检查，logm doctor 用于输出完整报告
+func (k *checker) okf(source, format string, args ...any) {
+	fmt.Fprintf(k.out, "%s: ok: %s\n", source, fmt.Sprintf(format, args...))
+}
+
+// warnf 记录不影响运行、但很可能不是预期的问题
+func (k *checker) warnf(source, format string, args ...any) {
+	k.warnings++
+	fmt.Fprintf(k.out, "%s: warning: %s\n", source, fmt.Sprintf(format, args...))
+}
+
+// runCheck 实现 logm check
+func runCheck(c *cli, args []string) error {
+	fs := c.flagSet("check", "[remote-config.json|url...]")
+	env := fs.Bool("env", false, "check the LOGM_* environment variables read by PresetFromEnv")
+	probe := fs.Bool("probe", false, "fetch http(s) URLs to check that remote config endpoints are reachable")
+	timeout := fs.Duration("timeout", 5*time.Second, "timeout for each --probe request")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !*env && fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	k := &checker{out: &strings.Builder{}}
+	if *env {
+		k.checkEnv(os.Environ())
+	}
+	for _, target := range fs.Args() {
+		if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+			if !*probe {
+				k.warnf(target, "not fetched; pass --probe to check the endpoint")
+				continue
+			}
+			k.probeRemote(c.ctx, target, *timeout)
+			continue
+		}
+		data, err := os.ReadFile(target)
+		if err != nil {
+			k.errorf(target, "%v", err)
+			continue
+		}
+		k.checkRemoteConfig(target, data, time.Now())
+	}
+
+	fmt.Fprint(c.stdout, k.out.String())
+	fmt.Fprintf(c.stdout, "%d error(s), %d warning(s)\n", k.errors, k.warnings)
+	if k.errors > 0 {
+		return errFailed
+	}
+	return nil
+}
+
+// remoteConfigKeys RemoteConfig 和 SampleRule 的 JSON 键
+var (
+	remoteConfigKeys = []string{"level", "sampling", "redaction", "expires_at"}
+	sampleRuleKeys   = []string{"first", "thereafter"}
+)
+
+// checkRemoteConfig 校验 logm.RemoteConfig 格式的 JSON
+func (k *checker) checkRemoteConfig(source string, data []byte, now time.Time) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		k.errorf(source, "invalid JSON: %v", err)
+		return
+	}
+	k.checkKeys(source, "", raw, remoteConfigKeys)
+
+	var sampling map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw["sampling"], &sampling); err == nil {
+		for level, rule := range sampling {
+			k.checkKeys(source, "sampling."+level+".", rule, sampleRuleKeys)
+		}
+	}
+
+	var cfg logm.RemoteConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		k.errorf(source, "%v", err)
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		k.errorf(source, "%s (use DEBUG, INFO, WARN or ERROR)", strings.TrimPrefix(err.Error(), "logm: remote config: "))
+	}
+	for level, rule := range cfg.Sampling {
+		if rule.First < 0 || rule.Thereafter < 0 {
+			k.errorf(source, "sampling.%s: first and thereafter must not be negative", level)
+		}
+	}
+	if len(cfg.Redaction) > 0 {
+		if _, err := redact.Load(bytes.NewReader(cfg.Redaction)); err != nil {
+			k.errorf(source, "redaction: %s", strings.TrimPrefix(err.Error(), "redact: "))
+		}
+	}
+	if !cfg.ExpiresAt.IsZero() && !cfg.ExpiresAt.After(now) {
+		k.warnf(source, "expires_at %s is in the past; the override will not be applied", cfg.ExpiresAt.Format(time.RFC3339))
+	}
+}
+
+// checkKeys 报告 raw 中不在 known 里的键，并给出最接近的键名
+func (k *checker) checkKeys(source, prefix string, raw map[string]json.RawMessage, known []string) {
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if slices.Contains(known, key) {
+			continue
+		}
+		if s := suggest(key, known); s != "" {
+			k.errorf(source, "unknown key %q, did you mean %q?", prefix+key, prefix+s)
+		} else {
+			k.errorf(source, "unknown key %q (known: %s)", prefix+key, strings.Join(known, ", "))
+		}
+	}
+}
+
+// probeRemote 通过 HTTPSource 获取远程配置并校验
+func (k *checker) probeRemote(ctx context.Context, url string, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cfg, err := logm.HTTPSource(url).Fetch(ctx)
+	if err != nil {
+		k.errorf(url, "unreachable: %v", err)
+		return
+	}
+	if cfg == nil {
+		return // 404/204：当前没有覆盖
+	}
+	data, _ := json.Marshal(cfg)
+	k.checkRemoteConfig(url, data, time.Now())
+}
+
+// envVars PresetFromEnv 读取的环境变量
+var envVars = []string{"LOGM_ENV", "LOGM_LEVEL", "LOGM_FORMAT", "LOGM_OUTPUT", "LOGM_SOURCE", "LOGM_TIME_FORMAT"}
+
+// timeFormats WithTimeFormat 的预置格式
+var timeFormats = []string{"time", "timems", "datetime", "rfc3339", "rfc3339ms", "unix", "unixms", "unixnano", "unixfloat"}
+
+// checkEnv 校验 environ 中的 LOGM_* 变量
+func (k *checker) checkEnv(environ []string) {
+	vars := make(map[string]string)
+	for _, kv := range environ {
+		if name, value, _ := strings.Cut(kv, "="); strings.HasPrefix(name, "LOGM_") {
+			vars[name] = value
+		}
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		value := vars[name]
+		switch name {
+		case "LOGM_ENV":
+			k.checkChoice(name, value, "dev", "development", "prod", "production")
+		case "LOGM_LEVEL":
+			k.checkChoice(name, value, "DEBUG", "INFO", "WARN", "WARNING", "ERROR")
+		case "LOGM_FORMAT":
+			k.checkChoice(name, value, "json", "text", "color_text", "color_json")
+		case "LOGM_SOURCE":
+			k.checkChoice(name, value, "true", "false", "1", "0")
+		case "LOGM_TIME_FORMAT":
+			if !slices.Contains(timeFormats, value) && time.Unix(0, 0).Format(value) == value {
+				k.warnf(name, "%q is neither a preset (%s) nor a Go time layout", value, strings.Join(timeFormats, ", "))
+			}
+		case "LOGM_OUTPUT":
+			if value != "" && value != "stdout" && value != "stderr" {
+				if err := checkWritable(value); err != nil {
+					k.errorf(name, "%v; logs would be dropped", err)
+				}
+			}
+		default:
+			if s := suggest(name, envVars); s != "" {
+				k.warnf(name, "unknown variable, did you mean %s?", s)
+			} else {
+				k.warnf(name, "unknown variable, ignored by PresetFromEnv")
+			}
+		}
+	}
+}
+
+// checkChoice 校验取值是否在 choices 中（大小写不敏感），空值表示使用默认值
+func (k *checker) checkChoice(name, value string, choices ...string) {
+	if value == "" || slices.ContainsFunc(choices, func(c string) bool { return strings.EqualFold(c, value) }) {
+		return
+	}
+	k.errorf(name, "invalid value %q (use %s)", value, strings.Join(choices, ", "))
+}
+
+// checkWritable 检查能否以追加方式写入 path，文件不存在时检查目录是否可写，不修改已有文件
+func checkWritable(path string) error {
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+	dir := filepath.Dir(path)
+	f, err := os.CreateTemp(dir, ".logm-check-*")
+	if err != nil {
+		return fmt.Errorf("cannot create %s: %w", path, err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// suggest 返回 known 中与 s 编辑距离最近且足够接近的值，没有时返回空
+func suggest(s string, known []string) string {
+	best, bestDist := "", max(len(s)/3, 2)+1
+	for _, k := range known {
+		if d := editDistance(strings.ToLower(s), strings.ToLower(k)); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance 计算 Levenshtein 编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck_RemoteConfig(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	require.NoError(t, os.WriteFile(good, []byte(`{"level":"debug","sampling":{"INFO":{"first":10,"thereafter":100}},"redaction":{"rules":[{"keys":["*token*"],"action":"mask"}]}}`), 0o600))
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`{
+		"lvl": "DEBUG",
+		"level": "TRACE",
+		"sampling": {"INFO": {"frist": 1, "thereafter": -1}},
+		"redaction": {"rules": [{"keys": ["token"], "action": "shred"}]},
+		"expires_at": "2020-01-01T00:00:00Z"
+	}`), 0o600))
+
+	stdout, _, code := runCLI(t, "", "check", good)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "0 error(s), 0 warning(s)\n", stdout)
+
+	stdout, stderr, code := runCLI(t, "", "check", bad, filepath.Join(dir, "missing.json"))
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stderr)
+	for _, want := range []string{
+		bad + `: error: unknown key "lvl", did you mean "level"?`,
+		bad + `: error: unknown key "sampling.INFO.frist", did you mean "sampling.INFO.first"?`,
+		bad + `: error: unknown level "TRACE" (use DEBUG, INFO, WARN or ERROR)`,
+		bad + `: error: sampling.INFO: first and thereafter must not be negative`,
+		bad + `: error: redaction: rule 0 (): `,
+		bad + `: warning: expires_at 2020-01-01T00:00:00Z is in the past`,
+		"missing.json: error:",
+		"6 error(s), 1 warning(s)",
+	} {
+		assert.Contains(t, stdout, want)
+	}
+}
+
+func TestCheck_Probe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"level":"LOUD"}`))
+	}))
+	defer srv.Close()
+
+	stdout, _, code := runCLI(t, "", "check", srv.URL)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "pass --probe")
+
+	stdout, _, code = runCLI(t, "", "check", "--probe", srv.URL+"/missing")
+	assert.Equal(t, 0, code, stdout)
+
+	stdout, _, code = runCLI(t, "", "check", "--probe", srv.URL)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout, `unknown level "LOUD"`)
+
+	srv.Close()
+	stdout, _, code = runCLI(t, "", "check", "--probe", "--timeout", "1s", srv.URL)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout, "error: unreachable")
+}
+
+func TestCheck_Env(t *testing.T) {
+	dir := t.TempDir()
+	k := &checker{out: &strings.Builder{}}
+	k.checkEnv([]string{
+		"PATH=/bin",
+		"LOGM_LEVEL=trace",
+		"LOGM_FORMAT=JSON",
+		"LOGM_SOURCE=yes",
+		"LOGM_TIME_FORMAT=hh:mm",
+		"LOGM_OUTPUT=" + filepath.Join(dir, "missing", "app.log"),
+		"LOGM_LEVLE=DEBUG",
+		"LOGM_ENV=prod",
+	})
+	out := k.out.String()
+	assert.Equal(t, 3, k.errors, out)
+	assert.Equal(t, 2, k.warnings, out)
+	for _, want := range []string{
+		`LOGM_LEVEL: error: invalid value "trace"`,
+		`LOGM_SOURCE: error: invalid value "yes"`,
+		`LOGM_OUTPUT: error: cannot create`,
+		`LOGM_LEVLE: warning: unknown variable, did you mean LOGM_LEVEL?`,
+		`LOGM_TIME_FORMAT: warning: "hh:mm" is neither a preset`,
+	} {
+		assert.Contains(t, out, want)
+	}
+
+	k = &checker{out: &strings.Builder{}}
+	k.checkEnv([]string{"LOGM_OUTPUT=" + filepath.Join(dir, "app.log"), "LOGM_TIME_FORMAT=2006-01-02T15:04"})
+	assert.Equal(t, 0, k.errors+k.warnings, k.out.String())
+	assert.NoFileExists(t, filepath.Join(dir, "app.log"))
+
+	t.Setenv("LOGM_LEVEL", "WARN")
+	stdout, _, code := runCLI(t, "", "check", "--env")
+	assert.Equal(t, 0, code, stdout)
+}
+
+func TestSuggest(t *testing.T) {
+	assert.Equal(t, "level", suggest("levle", []string{"level", "sampling"}))
+	assert.Empty(t, suggest("unrelated", []string{"level", "sampling"}))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+}
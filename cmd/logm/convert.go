@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/grok"
+)
+
+// convertFlags convert 的参数
+type convertFlags struct {
+	from       string
+	to         string
+	timeFormat string
+	timezone   string
+	onError    string
+	types      bool
+	splitDots  bool
+}
+
+// runConvert 实现 logm convert
+func runConvert(c *cli, args []string) error {
+	fs := c.flagSet("convert", "[file...]")
+	var cf convertFlags
+	var ff filterFlags
+	fs.StringVar(&cf.from, "from", "auto", "input format: text, json, grok (lines matching --grok), auto")
+	fs.StringVar(&cf.to, "to", "json", "output format: json, text, color")
+	fs.StringVar(&cf.timeFormat, "time-format", "rfc3339ms", "output time format: rfc3339, rfc3339ms, datetime, unix, unixms, ... or a Go layout")
+	fs.StringVar(&cf.timezone, "timezone", "", "write times in this zone (default local)")
+	fs.StringVar(&cf.onError, "on-error", "skip", "lines that cannot be parsed: skip, keep (emit with the line as msg), fail")
+	fs.BoolVar(&cf.types, "types", true, "turn text values that look like numbers or booleans into typed values")
+	fs.BoolVar(&cf.splitDots, "split-dots", true, "turn flattened keys like db.table back into nested groups")
+	ff.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := ff.compile(time.Now()); err != nil {
+		return err
+	}
+	f, err := cf.formatter()
+	if err != nil {
+		return err
+	}
+	accept, err := cf.acceptor(ff.parser)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(c.stdout)
+	defer out.Flush()
+	var skipped, lineNo int
+	err = c.readLines(fs.Args(), func(line []byte, idle bool) error {
+		lineNo++
+		if len(bytes.TrimSpace(line)) == 0 {
+			return nil
+		}
+		e, err := accept(line)
+		if err != nil {
+			switch cf.onError {
+			case "fail":
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			case "keep":
+				e = &formatter.Entry{Level: slog.LevelInfo, Message: string(line)}
+			default:
+				skipped++
+				return nil
+			}
+		}
+		if !ff.match(e) {
+			return nil
+		}
+		data, err := f.Format(cf.record(e))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		_, err = out.Write(data)
+		return err
+	})
+	if skipped > 0 {
+		fmt.Fprintf(c.stderr, "logm convert: skipped %d unrecognized lines\n", skipped)
+	}
+	return err
+}
+
+// formatter 创建输出格式化器，source 保留完整路径
+func (cf *convertFlags) formatter() (formatter.Formatter, error) {
+	opts := []formatter.Option{
+		formatter.WithTimeFormat(cf.timeFormat),
+		formatter.WithSourceDepth(math.MaxInt),
+	}
+	if cf.timezone != "" {
+		if _, err := time.LoadLocation(cf.timezone); err != nil {
+			return nil, fmt.Errorf("invalid --timezone: %w", err)
+		}
+		opts = append(opts, formatter.WithTimezone(cf.timezone))
+	}
+	switch strings.ToLower(cf.to) {
+	case "json":
+		return formatter.JSON(opts...), nil
+	case "text":
+		return formatter.Text(opts...), nil
+	case "color":
+		return formatter.ColorText(opts...), nil
+	default:
+		return nil, fmt.Errorf("invalid --to %q (json, text, color)", cf.to)
+	}
+}
+
+// acceptor 返回按 --from 解析一行的函数，auto 在 logm 格式无法解析时使用 --grok 模式
+func (cf *convertFlags) acceptor(parser *grok.Parser) (func(line []byte) (*formatter.Entry, error), error) {
+	switch cf.onError {
+	case "skip", "keep", "fail":
+	default:
+		return nil, fmt.Errorf("invalid --on-error %q (skip, keep, fail)", cf.onError)
+	}
+	from := strings.ToLower(cf.from)
+	switch from {
+	case "auto", "json", "text":
+	case "grok":
+		if parser == nil {
+			return nil, fmt.Errorf("--from grok needs at least one --grok pattern")
+		}
+		return parser.Parse, nil
+	default:
+		return nil, fmt.Errorf("invalid --from %q (text, json, grok, auto)", cf.from)
+	}
+	return func(line []byte) (*formatter.Entry, error) {
+		line = bytes.TrimLeft(line, " \t")
+		isJSON := len(line) > 0 && line[0] == '{'
+		if (from == "json" && !isJSON) || (from == "text" && isJSON) {
+			return nil, fmt.Errorf("%w: not %s", formatter.ErrUnrecognized, cf.from)
+		}
+		e, err := formatter.ParseLine(line)
+		if err != nil && from == "auto" && parser != nil {
+			return parser.Parse(line)
+		}
+		return e, err
+	}, nil
+}
+
+// record 将解析的日志转换为输出记录，按参数还原类型和分组
+func (cf *convertFlags) record(e *formatter.Entry) *formatter.Record {
+	r := e.Record()
+	if cf.types {
+		r.Attrs = inferTypes(r.Attrs)
+	}
+	if cf.splitDots {
+		r.Attrs = nestDotted(r.Attrs)
+	}
+	return r
+}
+
+// inferTypes 将字符串值中的 JSON 数字和布尔值转换为对应类型
+func inferTypes(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		out[i] = a
+		switch a.Value.Kind() {
+		case slog.KindGroup:
+			out[i].Value = slog.GroupValue(inferTypes(a.Value.Group())...)
+		case slog.KindString:
+			out[i].Value = inferValue(a.Value.String())
+		default:
+		}
+	}
+	return out
+}
+
+// inferValue 识别 JSON 语法的数字（不含前导零）和 true/false，其余保持字符串
+func inferValue(s string) slog.Value {
+	switch s {
+	case "true":
+		return slog.BoolValue(true)
+	case "false":
+		return slog.BoolValue(false)
+	default:
+	}
+	if s == "" || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) || !json.Valid([]byte(s)) {
+		return slog.StringValue(s)
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return slog.Int64Value(n)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return slog.Float64Value(f)
+	}
+	return slog.StringValue(s)
+}
+
+// nestDotted 将 a.b=v 形式的键还原为嵌套分组，分组位置取第一次出现的位置
+func nestDotted(attrs []slog.Attr) []slog.Attr {
+	var out []slog.Attr
+	index := make(map[string]int)         // 分组键 → out 下标
+	children := make(map[int][]slog.Attr) // out 下标 → 分组的子属性
+	for _, a := range attrs {
+		key, sub := a.Key, []slog.Attr(nil)
+		switch head, rest, dotted := strings.Cut(a.Key, "."); {
+		case a.Value.Kind() == slog.KindGroup && a.Key != "":
+			sub = a.Value.Group()
+		case dotted && head != "" && rest != "":
+			key, sub = head, []slog.Attr{{Key: rest, Value: a.Value}}
+		default:
+			out = append(out, a)
+			continue
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(out)
+			index[key] = i
+			out = append(out, slog.Attr{Key: key})
+		}
+		children[i] = append(children[i], sub...)
+	}
+	for i, sub := range children {
+		out[i].Value = slog.GroupValue(nestDotted(sub)...)
+	}
+	return out
+}
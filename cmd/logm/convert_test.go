@@ -0,0 +1,105 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+func TestConvert_TextToJSON(t *testing.T) {
+	r := formatter.NewRecord(slog.LevelWarn, "slow query").
+		WithTime(time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.UTC)).
+		WithSource("/app/internal/db/db.go", 42).
+		With("rows", 12, "ratio", 0.25, "cached", false, "code", "007").
+		WithAttr(slog.Group("db", slog.String("table", "users"), slog.Group("pool", slog.Int("size", 4))))
+
+	text, err := formatter.Text(formatter.WithTimeFormat("rfc3339ms"), formatter.WithSourceDepth(10)).Format(r)
+	require.NoError(t, err)
+	want, err := formatter.JSON(formatter.WithTimeFormat("rfc3339ms"), formatter.WithSourceDepth(10)).Format(r)
+	require.NoError(t, err)
+
+	stdout, stderr, code := runCLI(t, string(text)+"garbage\n", "convert", "--from", "text", "--to", "json", "--timezone", "UTC")
+	require.Equal(t, 0, code, stderr)
+	assert.JSONEq(t, string(want), stdout)
+	assert.Contains(t, stderr, "skipped 1 unrecognized lines")
+}
+
+func TestConvert_JSONToText(t *testing.T) {
+	input := `{"time":"2024-01-15T10:30:45Z","level":"INFO","msg":"hi","user":{"id":1}}`
+	stdout, _, code := runCLI(t, input, "convert", "--to", "text", "--time-format", "rfc3339", "--timezone", "UTC")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "time=2024-01-15T10:30:45Z level=INFO msg=hi user.id=1\n", stdout)
+}
+
+func TestConvert_Options(t *testing.T) {
+	input := "time=2024-01-15T10:30:45Z level=INFO msg=hi a.b=1\nnot a log\n"
+
+	stdout, _, code := runCLI(t, input, "convert", "--types=false", "--split-dots=false", "--timezone", "UTC")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, `"a.b":"1"`)
+
+	stdout, _, code = runCLI(t, input, "convert", "--on-error", "keep")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, `"msg":"not a log"`)
+
+	_, stderr, code := runCLI(t, input, "convert", "--on-error", "fail")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "line 2")
+
+	// --from json 拒绝 Text 行
+	stdout, _, code = runCLI(t, input, "convert", "--from", "json")
+	require.Equal(t, 0, code)
+	assert.Empty(t, stdout)
+
+	for _, args := range [][]string{{"--from", "xml"}, {"--to", "xml"}, {"--on-error", "ignore"}} {
+		_, stderr, code := runCLI(t, "", append([]string{"convert"}, args...)...)
+		assert.Equal(t, 1, code, args)
+		assert.Contains(t, stderr, "invalid", args)
+	}
+}
+
+func TestInferValue(t *testing.T) {
+	for s, want := range map[string]slog.Value{
+		"42":    slog.Int64Value(42),
+		"-1.5":  slog.Float64Value(-1.5),
+		"1e3":   slog.Float64Value(1000),
+		"true":  slog.BoolValue(true),
+		"007":   slog.StringValue("007"),
+		"NaN":   slog.StringValue("NaN"),
+		"12abc": slog.StringValue("12abc"),
+		"":      slog.StringValue(""),
+	} {
+		assert.True(t, want.Equal(inferValue(s)), s)
+	}
+}
+
+func TestNestDotted(t *testing.T) {
+	got := nestDotted([]slog.Attr{
+		slog.String("a.x", "1"),
+		slog.String("b", "2"),
+		slog.Group("a", slog.String("y", "3")),
+		slog.String("a.z.k", "4"),
+		slog.String(".hidden", "5"),
+	})
+	want := []slog.Attr{
+		slog.Group("a", slog.String("x", "1"), slog.String("y", "3"), slog.Group("z", slog.String("k", "4"))),
+		slog.String("b", "2"),
+		slog.String(".hidden", "5"),
+	}
+	assert.Equal(t, fmtAttrs(want), fmtAttrs(got))
+}
+
+// fmtAttrs 将属性格式化为文本以便比较
+func fmtAttrs(attrs []slog.Attr) string {
+	parts := make([]string, len(attrs))
+	for i, a := range attrs {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// doctorFlags doctor 的参数
+type doctorFlags struct {
+	timezones listFlag
+	paths     listFlag
+	timeout   time.Duration
+	tls       writer.TLSConfig
+}
+
+// runDoctor 实现 logm doctor
+func runDoctor(c *cli, args []string) error {
+	fs := c.flagSet("doctor", "[sink...]")
+	var df doctorFlags
+	fs.Var(&df.timezones, "timezone", "also check that this time zone loads, repeatable ("+logm.DefaultTimezone+", the logm default, is always checked)")
+	fs.Var(&df.paths, "path", "also check that this log file path is writable, repeatable (LOGM_OUTPUT is always checked)")
+	fs.DurationVar(&df.timeout, "timeout", 5*time.Second, "timeout for each sink connection")
+	fs.StringVar(&df.tls.CAFile, "tls-ca", "", "CA certificate for tls:// sinks")
+	fs.StringVar(&df.tls.CertFile, "tls-cert", "", "client certificate for tls:// sinks")
+	fs.StringVar(&df.tls.KeyFile, "tls-key", "", "client key for tls:// sinks")
+	fs.StringVar(&df.tls.ServerName, "tls-server-name", "", "server name to verify for tls:// sinks")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	k := &checker{out: &strings.Builder{}}
+	k.checkTimezones(c, append(listFlag{logm.DefaultTimezone}, df.timezones...))
+	k.checkPaths(c, df.paths)
+	k.checkTerminal(c)
+	for _, sink := range fs.Args() {
+		k.checkSink(c.ctx, sink, &df)
+	}
+
+	fmt.Fprint(c.stdout, k.out.String())
+	fmt.Fprintf(c.stdout, "%d error(s), %d warning(s)\n", k.errors, k.warnings)
+	if k.errors > 0 {
+		return errFailed
+	}
+	return nil
+}
+
+// checkTimezones 检查时区数据库，加载失败时 logm 静默使用本地时区
+func (k *checker) checkTimezones(c *cli, names []string) {
+	if dir := c.getenv("ZONEINFO"); dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			k.errorf("timezone", "ZONEINFO=%s: %v", dir, err)
+		}
+	}
+	for _, name := range names {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			k.errorf("timezone", "%s: %v; logm falls back to the local zone (%s). Install tzdata or import _ \"time/tzdata\"", name, err, time.Local)
+			continue
+		}
+		k.okf("timezone", "%s loaded (now %s)", name, time.Now().In(loc).Format("15:04 MST"))
+	}
+	if tz := c.getenv("TZ"); tz != "" {
+		if _, err := time.LoadLocation(strings.TrimPrefix(tz, ":")); err != nil {
+			k.warnf("timezone", "TZ=%s cannot be loaded, the local zone is UTC", tz)
+			return
+		}
+	}
+	k.okf("timezone", "local zone is %s", time.Now().Format("MST -07:00"))
+}
+
+// checkPaths 检查日志文件路径是否可写
+func (k *checker) checkPaths(c *cli, paths []string) {
+	if out := c.getenv("LOGM_OUTPUT"); out != "" && out != "stdout" && out != "stderr" {
+		paths = append([]string{out}, paths...)
+	}
+	if len(paths) == 0 {
+		k.okf("output", "no file outputs configured (LOGM_OUTPUT unset)")
+	}
+	for _, path := range paths {
+		if err := checkWritable(path); err != nil {
+			k.errorf("output", "%v; logs would be dropped", err)
+			continue
+		}
+		k.okf("output", "%s is writable", path)
+	}
+}
+
+// checkTerminal 检查终端的颜色支持，与 --color auto 和 color_* 格式的效果对应
+func (k *checker) checkTerminal(c *cli) {
+	term := c.getenv("TERM")
+	switch {
+	case !c.isTTY:
+		k.warnf("terminal", "stdout is not a terminal; --color auto prints plain text, LOGM_FORMAT=color_text writes escape codes into the output")
+	case c.getenv("NO_COLOR") != "":
+		k.warnf("terminal", "NO_COLOR is set; --color auto prints plain text")
+	case term == "" || term == "dumb":
+		k.warnf("terminal", "TERM=%q may not render ANSI colors", term)
+	default:
+		depth := "16 colors"
+		if ct := c.getenv("COLORTERM"); ct == "truecolor" || ct == "24bit" {
+			depth = "true color"
+		} else if strings.Contains(term, "256color") {
+			depth = "256 colors"
+		}
+		k.okf("terminal", "TERM=%s supports colors (%s)", term, depth)
+	}
+}
+
+// checkSink 检查网络目标是否可达：http(s):// 发送 HEAD 请求，tcp:// 和 tls:// 建立连接，
+// 没有协议的 host:port 按 tcp 处理
+func (k *checker) checkSink(ctx context.Context, sink string, df *doctorFlags) {
+	ctx, cancel := context.WithTimeout(ctx, df.timeout)
+	defer cancel()
+
+	target := sink
+	if !strings.Contains(target, "://") {
+		target = "tcp://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		k.errorf(sink, "invalid sink, want http(s)://..., tcp://host:port, tls://host:port or host:port")
+		return
+	}
+
+	start := time.Now()
+	switch u.Scheme {
+	case "http", "https":
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+		if err != nil {
+			k.errorf(sink, "%v", err)
+			return
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			k.errorf(sink, "unreachable: %v", err)
+			return
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			k.warnf(sink, "reachable but returned %s", resp.Status)
+			return
+		}
+		k.okf(sink, "reachable in %s (%s)", round(time.Since(start)), resp.Status)
+	case "tcp", "tls":
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", u.Host)
+		if err != nil {
+			k.errorf(sink, "unreachable: %v", err)
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		if u.Scheme == "tls" {
+			cfg, err := df.tls.Build()
+			if err != nil {
+				k.errorf(sink, "%v", err)
+				return
+			}
+			if cfg.ServerName == "" {
+				cfg.ServerName = u.Hostname()
+			}
+			if err := tls.Client(conn, cfg).HandshakeContext(ctx); err != nil {
+				k.errorf(sink, "TLS handshake failed: %v", err)
+				return
+			}
+		}
+		k.okf(sink, "reachable in %s", round(time.Since(start)))
+	default:
+		k.errorf(sink, "unsupported scheme %q (http, https, tcp, tls)", u.Scheme)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoctor_Report(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	dir := t.TempDir()
+	stdout, _, code := runCLI(t, "", "doctor",
+		"--timezone", "UTC",
+		"--path", filepath.Join(dir, "app.log"),
+		srv.URL, ln.Addr().String(),
+	)
+	assert.Equal(t, 0, code, stdout)
+	for _, want := range []string{
+		"timezone: ok: Asia/Shanghai loaded",
+		"timezone: ok: UTC loaded",
+		"output: ok: " + filepath.Join(dir, "app.log") + " is writable",
+		"terminal: warning: stdout is not a terminal",
+		srv.URL + ": ok: reachable",
+		ln.Addr().String() + ": ok: reachable",
+		"0 error(s), 1 warning(s)",
+	} {
+		assert.Contains(t, stdout, want)
+	}
+}
+
+func TestDoctor_Failures(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	stdout, _, code := runCLI(t, "", "doctor",
+		"--timezone", "Mars/Olympus_Mons",
+		"--path", filepath.Join(t.TempDir(), "missing", "app.log"),
+		"tcp://"+addr, "udp://"+addr, "::bad",
+	)
+	assert.Equal(t, 1, code)
+	for _, want := range []string{
+		"timezone: error: Mars/Olympus_Mons:",
+		"falls back to the local zone",
+		"output: error: cannot create",
+		"tcp://" + addr + ": error: unreachable",
+		`udp://` + addr + `: error: unsupported scheme "udp"`,
+		"::bad: error: invalid sink",
+		"5 error(s)",
+	} {
+		assert.Contains(t, stdout, want)
+	}
+}
+
+func TestCheckTerminal(t *testing.T) {
+	env := map[string]string{"TERM": "xterm-256color"}
+	c := &cli{isTTY: true, getenv: func(k string) string { return env[k] }}
+	report := func() string {
+		k := &checker{out: &strings.Builder{}}
+		k.checkTerminal(c)
+		return k.out.String()
+	}
+	assert.Contains(t, report(), "ok: TERM=xterm-256color supports colors (256 colors)")
+
+	env["COLORTERM"] = "truecolor"
+	assert.Contains(t, report(), "(true color)")
+
+	env["TERM"] = "dumb"
+	assert.Contains(t, report(), `warning: TERM="dumb"`)
+
+	env["NO_COLOR"] = "1"
+	assert.Contains(t, report(), "warning: NO_COLOR is set")
+}
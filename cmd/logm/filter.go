@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/grok"
+)
+
+// filterFlags 解析日志行和按解析出的字段过滤日志的参数
+type filterFlags struct {
+	level    string
+	since    string
+	until    string
+	where    listFlag
+	grep     string
+	invert   bool
+	grok     listFlag
+	grokFile string
+
+	// 由 compile 生成
+	active   bool
+	minLevel slog.Level
+	from, to time.Time
+	conds    []whereCond
+	re       *regexp.Regexp
+	parser   *grok.Parser // 解析非 logm 格式的行，没有 --grok 时为 nil
+}
+
+// whereCond 一个 --where 条件
+type whereCond struct {
+	key, value string
+	negate     bool
+}
+
+// listFlag 可重复的字符串参数
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(s string) error { *l = append(*l, s); return nil }
+
+// register 注册过滤参数
+func (f *filterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.level, "level", "", "only show records at or above this level: debug, info, warn, error")
+	fs.StringVar(&f.since, "since", "", "only show records at or after this time (RFC3339, \"2006-01-02 15:04:05\" or a duration like 15m)")
+	fs.StringVar(&f.until, "until", "", "only show records before this time, same forms as --since")
+	fs.Var(&f.where, "where", "only show records whose field equals value: key=value or key!=value, repeatable; nested keys use dots (db.table)")
+	fs.StringVar(&f.grep, "grep", "", "only show records whose message or field values match this regular expression")
+	fs.BoolVar(&f.invert, "v", false, "invert the match, showing records the filters reject")
+	fs.Var(&f.grok, "grok", "parse lines that are not logm output with this grok pattern, e.g. '%{TIMESTAMP_ISO8601:time} %{LOGLEVEL:level} %{GREEDYDATA:msg}'; repeatable, the first match wins")
+	fs.StringVar(&f.grokFile, "grok-patterns", "", "load extra grok pattern definitions (\"NAME regexp\" per line) for --grok")
+}
+
+// compile 校验并解析参数，now 用于相对时间
+func (f *filterFlags) compile(now time.Time) error {
+	var err error
+	if f.level != "" {
+		var ok bool
+		if f.minLevel, ok = formatter.ParseLevelName(f.level); !ok {
+			return fmt.Errorf("invalid --level %q", f.level)
+		}
+	} else {
+		f.minLevel = slog.Level(math.MinInt)
+	}
+	if f.from, err = parseTimeFlag("since", f.since, now); err != nil {
+		return err
+	}
+	if f.to, err = parseTimeFlag("until", f.until, now); err != nil {
+		return err
+	}
+	for _, w := range f.where {
+		key, value, ok := strings.Cut(w, "=")
+		negate := strings.HasSuffix(key, "!")
+		key = strings.TrimSuffix(key, "!")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid --where %q, want key=value or key!=value", w)
+		}
+		f.conds = append(f.conds, whereCond{key: key, value: value, negate: negate})
+	}
+	if f.grep != "" {
+		if f.re, err = regexp.Compile(f.grep); err != nil {
+			return fmt.Errorf("invalid --grep: %w", err)
+		}
+	}
+	if len(f.grok) > 0 {
+		var opts []grok.Option
+		if f.grokFile != "" {
+			opts = append(opts, grok.WithPatternFile(f.grokFile))
+		}
+		if f.parser, err = grok.New(f.grok, opts...); err != nil {
+			return fmt.Errorf("invalid --grok: %w", err)
+		}
+	}
+	f.active = f.level != "" || !f.from.IsZero() || !f.to.IsZero() || len(f.conds) > 0 || f.re != nil || f.invert
+	return nil
+}
+
+// parseTimeFlag 解析绝对时间或相对 now 的时长
+func parseTimeFlag(name, s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d.Abs()), nil
+	}
+	if t, ok := formatter.ParseTime(s); ok {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --%s %q", name, s)
+}
+
+// match 判断日志是否通过过滤，e 为 nil 表示无法解析的行
+func (f *filterFlags) match(e *formatter.Entry) bool {
+	if !f.active {
+		return true
+	}
+	if e == nil {
+		// 无法解析的行没有字段可供比较，只在反向匹配时输出
+		return f.invert
+	}
+	return f.matchEntry(e) != f.invert
+}
+
+// matchEntry 判断解析的日志是否满足全部条件
+func (f *filterFlags) matchEntry(e *formatter.Entry) bool {
+	if e.Level < f.minLevel {
+		return false
+	}
+	if !f.from.IsZero() || !f.to.IsZero() {
+		if e.Time.IsZero() || e.Time.Before(f.from) || (!f.to.IsZero() && !e.Time.Before(f.to)) {
+			return false
+		}
+	}
+	for _, c := range f.conds {
+		v, ok := entryField(e, c.key)
+		if (ok && v == c.value) == c.negate {
+			return false
+		}
+	}
+	if f.re != nil && !f.grepEntry(e) {
+		return false
+	}
+	return true
+}
+
+// grepEntry 在消息和字段值中查找 --grep
+func (f *filterFlags) grepEntry(e *formatter.Entry) bool {
+	if f.re.MatchString(e.Message) {
+		return true
+	}
+	found := false
+	walkAttrs(e.Attrs, "", func(_, value string) bool {
+		found = f.re.MatchString(value)
+		return !found
+	})
+	return found
+}
+
+// entryField 返回字段的文本值，key 为 time、level、msg、source 时返回内置字段
+func entryField(e *formatter.Entry, key string) (string, bool) {
+	switch key {
+	case "level":
+		return formatter.LevelName(e.Level), true
+	case "msg":
+		return e.Message, true
+	case "source":
+		return e.Source, e.Source != ""
+	case "time":
+		return e.Time.Format(time.RFC3339Nano), !e.Time.IsZero()
+	default:
+	}
+	var value string
+	found := false
+	walkAttrs(e.Attrs, "", func(k, v string) bool {
+		if k == key {
+			value, found = v, true
+		}
+		return !found
+	})
+	return value, found
+}
+
+// walkAttrs 以 a.b.c 形式的完整键遍历叶子字段，fn 返回 false 时停止
+func walkAttrs(attrs []slog.Attr, prefix string, fn func(key, value string) bool) bool {
+	for _, a := range attrs {
+		key := prefix + a.Key
+		if a.Value.Kind() == slog.KindGroup {
+			if !walkAttrs(a.Value.Group(), key+".", fn) {
+				return false
+			}
+			continue
+		}
+		if !fn(key, attrText(a.Value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// attrText 返回字段值的文本，数组输出为 [a,b]
+func attrText(v slog.Value) string {
+	if list, ok := v.Any().([]any); ok {
+		parts := make([]string, len(list))
+		for i, x := range list {
+			parts[i] = fmt.Sprint(x)
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	}
+	return v.String()
+}
+
+// runFilter 实现 logm filter，原样输出通过过滤的行
+func runFilter(c *cli, args []string) error {
+	fs := c.flagSet("filter", "[file...]")
+	var ff filterFlags
+	ff.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := ff.compile(time.Now()); err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(c.stdout)
+	defer out.Flush()
+	return c.readLines(fs.Args(), func(line []byte, idle bool) error {
+		e, _, _ := ff.parseLine(line)
+		if ff.match(e) {
+			_, _ = out.Write(line)
+			if err := out.WriteByte('\n'); err != nil {
+				return err
+			}
+		}
+		if idle {
+			return out.Flush()
+		}
+		return nil
+	})
+}
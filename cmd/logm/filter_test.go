@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var filterInput = strings.Join([]string{
+	`{"time":"2024-01-15T10:00:00Z","level":"DEBUG","msg":"cache miss","key":"u:1"}`,
+	`{"time":"2024-01-15T10:05:00Z","level":"INFO","msg":"login","user":{"id":42,"name":"alice"}}`,
+	`{"time":"2024-01-15T10:10:00Z","level":"ERROR","msg":"query failed","error":"connection refused","tags":["db","pg"]}`,
+	`time=2024-01-15T10:15:00Z level=WARN msg="slow request" user.id=42 path=/api`,
+	`not a log line`,
+}, "\n")
+
+// filterLines 执行 logm filter，返回输出的消息
+func filterLines(t *testing.T, args ...string) []string {
+	t.Helper()
+	stdout, stderr, code := runCLI(t, filterInput, append([]string{"filter"}, args...)...)
+	require.Equal(t, 0, code, stderr)
+	var msgs []string
+	for line := range strings.Lines(stdout) {
+		e, _, err := (&filterFlags{}).parseLine([]byte(line))
+		if err != nil {
+			msgs = append(msgs, strings.TrimSpace(line))
+			continue
+		}
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+func TestFilter(t *testing.T) {
+	all := []string{"cache miss", "login", "query failed", "slow request", "not a log line"}
+	assert.Equal(t, all, filterLines(t))
+
+	assert.Equal(t, []string{"query failed", "slow request"}, filterLines(t, "--level", "warn"))
+	assert.Equal(t, []string{"login", "query failed"},
+		filterLines(t, "--since", "2024-01-15T10:05:00Z", "--until", "2024-01-15T10:15:00Z"))
+	assert.Equal(t, []string{"login", "slow request"}, filterLines(t, "--where", "user.id=42"))
+	assert.Equal(t, []string{"slow request"}, filterLines(t, "--where", "user.id=42", "--where", "level=WARN"))
+	assert.Equal(t, []string{"cache miss", "query failed", "slow request"}, filterLines(t, "--where", "msg!=login"))
+	assert.Equal(t, []string{"query failed"}, filterLines(t, "--where", "tags=[db,pg]"))
+	assert.Equal(t, []string{"login", "query failed"}, filterLines(t, "--grep", "refused|alice"))
+	assert.Equal(t, []string{"cache miss", "slow request", "not a log line"}, filterLines(t, "-v", "--grep", "refused|alice"))
+}
+
+func TestFilter_Pretty(t *testing.T) {
+	stdout, _, code := runCLI(t, filterInput, "pretty", "--color=never", "--timezone=UTC", "--level=error")
+	require.Equal(t, 0, code)
+	assert.Equal(t, `2024-01-15 10:10:00 ERROR query failed error="connection refused" tags[0]="db" tags[1]="pg"`+"\n", stdout)
+}
+
+func TestFilter_InvalidFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"--level", "loud"},
+		{"--since", "yesterday"},
+		{"--where", "novalue"},
+		{"--where", "=x"},
+		{"--grep", "("},
+	} {
+		_, stderr, code := runCLI(t, "", append([]string{"filter"}, args...)...)
+		assert.Equal(t, 1, code, args)
+		assert.Contains(t, stderr, "invalid", args)
+	}
+}
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	got, err := parseTimeFlag("since", "15m", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-15*time.Minute), got)
+
+	got, err = parseTimeFlag("since", "2024-01-14", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 14, 0, 0, 0, 0, time.Local), got)
+
+	got, err = parseTimeFlag("since", "", now)
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+}
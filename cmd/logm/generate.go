@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// generateTick 限速时的发送间隔
+const generateTick = 10 * time.Millisecond
+
+// runGenerate 实现 logm generate
+func runGenerate(c *cli, args []string) error {
+	fs := c.flagSet("generate", "")
+	var pf pipelineFlags
+	pf.register(fs, "stdout")
+	rate := fs.Int("rate", 1000, "records per second (0 writes as fast as possible)")
+	count := fs.Int("count", 0, "stop after this many records (0 runs until interrupted or --duration)")
+	duration := fs.Duration("duration", 0, "stop after this long")
+	fields := fs.Int("fields", 10, "attributes per record")
+	seed := fs.Uint64("seed", 0, "random seed; the same seed produces the same values (0 uses the current time)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *rate < 0 || *count < 0 || *fields < 0 {
+		return fmt.Errorf("--rate, --count and --fields must not be negative")
+	}
+	h, err := pf.handler(c.stdout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, _ = h.Shutdown(ctx)
+	}()
+
+	if *seed == 0 {
+		*seed = uint64(time.Now().UnixNano())
+	}
+	ctx := c.ctx
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	logger := slog.New(h)
+	s := newSynth(*seed, *fields)
+	emit := func() {
+		level, msg, attrs := s.record()
+		logger.LogAttrs(context.Background(), level, msg, attrs...)
+	}
+
+	var sent int
+	done := func() bool { return *count > 0 && sent >= *count }
+	if *rate == 0 {
+		for ctx.Err() == nil && !done() {
+			emit()
+			sent++
+		}
+		return nil
+	}
+
+	// 按已过去的时间计算应发送的条数，补齐后等待下一个周期，避免 sleep 误差累积
+	ticker := time.NewTicker(generateTick)
+	defer ticker.Stop()
+	start := time.Now()
+	for !done() {
+		due := int(time.Since(start).Seconds() * float64(*rate))
+		for sent < due && !done() {
+			emit()
+			sent++
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
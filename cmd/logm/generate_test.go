@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+func TestGenerate_Count(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "generate", "--rate", "0", "--count", "200", "--fields", "20", "--seed", "7")
+	require.Equal(t, 0, code, stderr)
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 200)
+	levels := map[string]int{}
+	for _, line := range lines {
+		e, err := formatter.ParseLine([]byte(line))
+		require.NoError(t, err, line)
+		levels[formatter.LevelName(e.Level)]++
+		assert.NotEmpty(t, e.Message)
+		assert.GreaterOrEqual(t, len(e.Attrs), 20, line)
+	}
+	assert.Greater(t, levels["INFO"], levels["WARN"])
+	assert.Contains(t, lines[0], `"field_19":`)
+
+	again, _, _ := runCLI(t, "", "generate", "--rate", "0", "--count", "200", "--fields", "20", "--seed", "7")
+	assert.Equal(t, stripTimes(stdout), stripTimes(again), "same seed produces the same records")
+}
+
+func TestGenerate_Rate(t *testing.T) {
+	start := time.Now()
+	stdout, _, code := runCLI(t, "", "generate", "--rate", "200", "--count", "40", "--format", "text")
+	require.Equal(t, 0, code)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	assert.Len(t, strings.Split(strings.TrimSpace(stdout), "\n"), 40)
+}
+
+func TestGenerate_Duration(t *testing.T) {
+	stdout, _, code := runCLI(t, "", "generate", "--rate", "1000", "--duration", "100ms")
+	require.Equal(t, 0, code)
+	n := len(strings.Split(strings.TrimSpace(stdout), "\n"))
+	assert.InDelta(t, 100, n, 60)
+}
+
+// stripTimes 去掉 JSON 日志的 time 字段，用于比较内容
+func stripTimes(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if start := strings.Index(line, `"time":"`); start >= 0 {
+			end := strings.Index(line[start+8:], `"`)
+			lines[i] = line[:start] + line[start+8+end+1:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
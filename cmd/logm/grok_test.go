@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const legacyInput = `2024-01-15 10:00:00,120 [INFO] app.worker started job=7
+2024-01-15 10:00:05,450 [ERROR] app.db connection refused job=7
+{"time":"2024-01-15T10:00:06Z","level":"WARN","msg":"retrying"}
+garbage that matches nothing
+`
+
+const legacyPattern = `^%{TIMESTAMP_ISO8601:time} \[%{LOGLEVEL:level}\] %{NOTSPACE:logger} %{DATA:msg} job=%{INT:job:int}$`
+
+func TestGrok_Convert(t *testing.T) {
+	stdout, stderr, code := runCLI(t, legacyInput, "convert", "--grok", legacyPattern, "--timezone", "UTC")
+	require.Equal(t, 0, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"level":"INFO","msg":"started","logger":"app.worker","job":7`)
+	assert.Contains(t, lines[1], `"level":"ERROR","msg":"connection refused"`)
+	assert.Contains(t, lines[2], `"msg":"retrying"`)
+	assert.Contains(t, stderr, "skipped 1 unrecognized lines")
+
+	stdout, _, code = runCLI(t, legacyInput, "convert", "--from", "grok", "--grok", legacyPattern)
+	require.Equal(t, 0, code)
+	assert.Equal(t, 2, strings.Count(stdout, "\n"))
+}
+
+func TestGrok_FilterAndPatternFile(t *testing.T) {
+	defs := filepath.Join(t.TempDir(), "patterns")
+	require.NoError(t, os.WriteFile(defs, []byte("LEGACY %{TIMESTAMP_ISO8601:time} \\[%{LOGLEVEL:level}\\] %{NOTSPACE:logger} %{GREEDYDATA:msg}\n"), 0o600))
+
+	stdout, stderr, code := runCLI(t, legacyInput, "filter", "--grok-patterns", defs, "--grok", "^%{LEGACY}$", "--level", "warn")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "2024-01-15 10:00:05,450 [ERROR] app.db connection refused job=7\n"+
+		`{"time":"2024-01-15T10:00:06Z","level":"WARN","msg":"retrying"}`+"\n", stdout)
+
+	stdout, _, code = runCLI(t, legacyInput, "filter", "--grok", "^%{LEGACY}$", "--grok-patterns", defs, "--where", "logger=app.worker")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "2024-01-15 10:00:00,120 [INFO] app.worker started job=7\n", stdout)
+}
+
+func TestGrok_Errors(t *testing.T) {
+	_, stderr, code := runCLI(t, "", "filter", "--grok", "%{NOPE}")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "invalid --grok")
+
+	_, stderr, code = runCLI(t, "", "convert", "--from", "grok")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "needs at least one --grok")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// readLines 依次读取 files 中的行并调用 fn，files 为空时读取 stdin。
+//
+// 行不含结尾的换行。idle 为 true 表示暂时没有更多缓冲的输入，
+// 调用方应在此时刷新输出，使 kubectl logs -f 等管道输入及时显示。
+func (c *cli) readLines(files []string, fn func(line []byte, idle bool) error) error {
+	if len(files) == 0 {
+		return scanLines(c.stdin, fn)
+	}
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = scanLines(f, fn)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanLines 逐行读取 r，不限制行长
+func scanLines(r io.Reader, fn func(line []byte, idle bool) error) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			line = bytes.TrimRight(line, "\r\n")
+			if ferr := fn(line, br.Buffered() == 0); ferr != nil {
+				return ferr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseLine 解析一行日志，返回行首不属于日志的前缀。
+//
+// kubectl logs --prefix 和 docker compose logs 会在 JSON 前加上 "[pod/name] " 或 "svc  | "，
+// 整行无法解析时从第一个 '{' 开始重试，仍无法解析时使用 --grok 模式。
+func (f *filterFlags) parseLine(line []byte) (e *formatter.Entry, prefix []byte, err error) {
+	e, err = formatter.ParseLine(line)
+	if err == nil {
+		return e, nil, nil
+	}
+	if i := bytes.IndexByte(line, '{'); i > 0 {
+		if e, perr := formatter.ParseLine(line[i:]); perr == nil {
+			return e, line[:i], nil
+		}
+	}
+	if f.parser != nil {
+		if e, perr := f.parser.Parse(line); perr == nil {
+			return e, nil, nil
+		}
+	}
+	return nil, nil, err
+}
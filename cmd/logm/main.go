@@ -0,0 +1,182 @@
+// Command logm 是查看和处理 logm 日志的命令行工具。
+//
+// 用法：
+//
+//	logm <command> [flags] [file...]
+//
+// 命令：
+//   - pretty: 将 JSON 或 Text 日志渲染为彩色文本，如 kubectl logs app | logm pretty
+//   - filter: 按级别、时间和字段过滤，原样输出匹配的行
+//   - tail: 显示日志文件的最后几行，-f 持续跟踪轮转的文件，多个文件按时间合并
+//   - convert: 在 Text 和 JSON 格式之间转换，如 logm convert --from text --to json old.log
+//   - check: 发布前校验远程配置文件和 LOGM_* 环境变量，--probe 检查远程配置地址是否可达
+//   - doctor: 检查运行环境：时区数据库、日志文件路径、终端颜色支持和网络目标的连通性
+//   - bench: 用模拟日志压测配置的管道，报告吞吐量、延迟分位数和丢弃数
+//   - generate: 按指定速率输出模拟的结构化日志，如 logm generate --rate 5000 --format json --fields 20
+//   - schema: 输出预设配置的日志字段 JSON Schema，--format ecs 标注对应的 ECS 字段
+//
+// pretty、filter、tail 和 convert 支持相同的过滤参数：
+//
+//	logm pretty --level warn --since 15m --where user.id=42 --grep 'timeout|refused' app.log
+//
+// 旧应用的非结构化日志可通过 --grok 模式解析后参与过滤和转换：
+//
+//	logm convert --grok '%{TIMESTAMP_ISO8601:time} \[%{LOGLEVEL:level}\] %{GREEDYDATA:msg}' legacy.log
+//
+// 没有指定文件时从标准输入读取。
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// cli 命令运行环境，测试中替换为内存缓冲
+type cli struct {
+	ctx    context.Context // 收到中断信号时结束
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+	isTTY  bool // stdout 是否为终端
+}
+
+// command 子命令
+type command struct {
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+// commands 按名称索引的子命令
+var commands = map[string]command{
+	"pretty":   {"render JSON or Text logs as colored text", runPretty},
+	"filter":   {"print log lines matching level, time and field filters", runFilter},
+	"tail":     {"show the last lines of log files and follow them", runTail},
+	"convert":  {"convert logs between text and JSON formats", runConvert},
+	"check":    {"validate remote config files and LOGM_* environment variables", runCheck},
+	"doctor":   {"check time zones, log paths, terminal colors and sink connectivity", runDoctor},
+	"bench":    {"measure the throughput and latency of a logging pipeline", runBench},
+	"generate": {"write realistic synthetic logs at a fixed rate", runGenerate},
+	"schema":   {"print a JSON Schema of the fields a preset produces", runSchema},
+}
+
+// 已向用户输出原因的错误，main 只设置退出码
+var (
+	errUsage  = errors.New("usage")  // 参数错误，已输出用法
+	errFailed = errors.New("failed") // 命令发现问题，已输出详情
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	c := &cli{
+		ctx:    ctx,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		getenv: os.Getenv,
+		isTTY:  isTerminal(os.Stdout),
+	}
+	code := c.main(os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// main 执行子命令，返回进程退出码
+func (c *cli) main(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		c.usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(c.stderr, "logm: unknown command %q\n", args[0])
+		c.usage()
+		return 2
+	}
+	if err := cmd.run(c, args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if !errors.Is(err, errUsage) && !errors.Is(err, errFailed) {
+			fmt.Fprintf(c.stderr, "logm %s: %v\n", args[0], err)
+		}
+		return exitCode(err)
+	}
+	return 0
+}
+
+// exitCode 返回错误对应的退出码，参数错误为 2
+func exitCode(err error) int {
+	if errors.Is(err, errUsage) {
+		return 2
+	}
+	return 1
+}
+
+// usage 输出命令列表
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "usage: logm <command> [flags] [file...]")
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(c.stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+// flagSet 创建子命令的参数集，错误输出到 stderr
+func (c *cli) flagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet("logm "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: logm %s [flags] %s\n\nflags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags 解析参数，解析失败时返回 errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// colorEnabled 按 --color 的取值和 NO_COLOR 环境变量决定是否输出颜色
+func (c *cli) colorEnabled(mode string) (bool, error) {
+	switch strings.ToLower(mode) {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		return c.isTTY && c.getenv("NO_COLOR") == "", nil
+	default:
+		return false, fmt.Errorf("invalid --color %q (auto, always, never)", mode)
+	}
+}
+
+// isTerminal 判断 f 是否为字符设备
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runCLI 以 stdin 为输入执行命令，返回输出和退出码
+func runCLI(t *testing.T, stdin string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	c := &cli{
+		ctx:    t.Context(),
+		stdin:  strings.NewReader(stdin),
+		stdout: &out,
+		stderr: &errOut,
+		getenv: func(string) string { return "" },
+	}
+	code = c.main(args)
+	return out.String(), errOut.String(), code
+}
+
+func TestCLI_Usage(t *testing.T) {
+	_, stderr, code := runCLI(t, "")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "pretty")
+
+	_, stderr, code = runCLI(t, "", "nope")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "nope"`)
+
+	_, _, code = runCLI(t, "", "help")
+	assert.Equal(t, 0, code)
+
+	_, stderr, code = runCLI(t, "", "pretty", "--bogus")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "usage: logm pretty")
+}
+
+func TestColorEnabled(t *testing.T) {
+	env := map[string]string{}
+	c := &cli{isTTY: true, getenv: func(k string) string { return env[k] }}
+
+	on, err := c.colorEnabled("auto")
+	assert.NoError(t, err)
+	assert.True(t, on)
+
+	env["NO_COLOR"] = "1"
+	on, _ = c.colorEnabled("auto")
+	assert.False(t, on)
+	on, _ = c.colorEnabled("always")
+	assert.True(t, on)
+
+	_, err = c.colorEnabled("sometimes")
+	assert.Error(t, err)
+}
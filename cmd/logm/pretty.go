@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// prettyFlags pretty 的渲染参数
+type prettyFlags struct {
+	color       string
+	theme       string
+	timeFormat  string
+	timezone    string
+	sourceClip  string
+	sourceDepth int
+	multiLine   bool
+	highlight   listFlag
+}
+
+// register 注册渲染相关的参数，供其他输出彩色文本的命令复用
+func (p *prettyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.color, "color", "auto", "colorize output: auto, always, never (auto honors NO_COLOR)")
+	fs.StringVar(&p.theme, "theme", "default", "color theme: default, light")
+	fs.StringVar(&p.timeFormat, "time-format", "datetime", "time format: time, timems, datetime, rfc3339, rfc3339ms or a Go layout")
+	fs.StringVar(&p.timezone, "timezone", "", "display time in this zone (default local)")
+	fs.StringVar(&p.sourceClip, "source-clip", "", "strip this prefix (and the project directory after it) from source paths")
+	fs.IntVar(&p.sourceDepth, "source-depth", 3, "keep the last N path elements of source paths")
+	fs.BoolVar(&p.multiLine, "multiline", true, "print multi-line values such as stack traces on continuation lines")
+	fs.Var(&p.highlight, "highlight", "highlight matches of this regular expression, repeatable; --grep matches are highlighted too")
+}
+
+// renderer 渲染解析后的日志行
+type renderer struct {
+	f          formatter.Formatter
+	highlights []*regexp.Regexp // 仅在输出颜色时生效
+}
+
+// renderer 按参数创建渲染器，ff 的 --grep 同样高亮
+func (p *prettyFlags) renderer(c *cli, ff *filterFlags) (*renderer, error) {
+	color, err := c.colorEnabled(p.color)
+	if err != nil {
+		return nil, err
+	}
+	f, err := p.formatter(color)
+	if err != nil {
+		return nil, err
+	}
+	r := &renderer{f: f}
+	if !color {
+		return r, nil
+	}
+	for _, expr := range p.highlight {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --highlight: %w", err)
+		}
+		r.highlights = append(r.highlights, re)
+	}
+	if ff != nil && ff.re != nil {
+		r.highlights = append(r.highlights, ff.re)
+	}
+	return r, nil
+}
+
+// formatter 按参数创建 ColorText 格式化器
+func (p *prettyFlags) formatter(color bool) (formatter.Formatter, error) {
+	var scheme *formatter.ColorScheme
+	switch strings.ToLower(p.theme) {
+	case "default", "":
+		scheme = formatter.DefaultScheme()
+	case "light":
+		scheme = formatter.LightScheme()
+	default:
+		return nil, fmt.Errorf("invalid --theme %q (default, light)", p.theme)
+	}
+
+	opts := []formatter.Option{
+		formatter.WithColor(color),
+		formatter.WithColorScheme(scheme),
+		formatter.WithTimeFormat(p.timeFormat),
+		formatter.WithSourceClip(p.sourceClip),
+		formatter.WithSourceDepth(p.sourceDepth),
+	}
+	if p.timezone != "" {
+		// WithTimezone 无法加载时静默回退到本地时区，命令行中应报错
+		if _, err := time.LoadLocation(p.timezone); err != nil {
+			return nil, fmt.Errorf("invalid --timezone: %w", err)
+		}
+		opts = append(opts, formatter.WithTimezone(p.timezone))
+	}
+	if p.multiLine {
+		opts = append(opts, formatter.WithMultiLine())
+	}
+	return formatter.ColorText(opts...), nil
+}
+
+// runPretty 实现 logm pretty
+func runPretty(c *cli, args []string) error {
+	fs := c.flagSet("pretty", "[file...]")
+	var pf prettyFlags
+	var ff filterFlags
+	pf.register(fs)
+	ff.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := ff.compile(time.Now()); err != nil {
+		return err
+	}
+	r, err := pf.renderer(c, &ff)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(c.stdout)
+	defer out.Flush()
+	return c.readLines(fs.Args(), func(line []byte, idle bool) error {
+		e, prefix, _ := ff.parseLine(line)
+		if !ff.match(e) {
+			return nil
+		}
+		if _, err := out.Write(r.render(line, e, prefix)); err != nil {
+			return err
+		}
+		if idle {
+			return out.Flush()
+		}
+		return nil
+	})
+}
+
+// render 渲染一行日志并加上换行，e 为 nil 或无法格式化时原样输出
+func (r *renderer) render(line []byte, e *formatter.Entry, prefix []byte) []byte {
+	var data []byte
+	if e != nil {
+		if formatted, err := r.f.Format(e.Record()); err == nil {
+			data = append(prefix[:len(prefix):len(prefix)], formatted...)
+		}
+	}
+	if data == nil {
+		data = append(line[:len(line):len(line)], '\n')
+	}
+	if len(r.highlights) > 0 {
+		data = highlight(data, r.highlights)
+	}
+	return data
+}
+
+// highlightOn 反色显示匹配的文本
+const highlightOn = "\033[7m"
+
+// highlight 以反色标出 ANSI 转义序列之外的文本中 res 的匹配，匹配结束后恢复之前的颜色
+func highlight(data []byte, res []*regexp.Regexp) []byte {
+	out := make([]byte, 0, len(data)+64)
+	var active []byte // 自上次 reset 以来生效的转义序列
+	for len(data) > 0 {
+		if data[0] == '\033' {
+			end := bytes.IndexByte(data, 'm')
+			if end < 0 {
+				break
+			}
+			seq := data[:end+1]
+			if string(seq) == formatter.ColorReset {
+				active = active[:0]
+			} else {
+				active = append(active, seq...)
+			}
+			out = append(out, seq...)
+			data = data[end+1:]
+			continue
+		}
+
+		end := bytes.IndexByte(data, '\033')
+		if end < 0 {
+			end = len(data)
+		}
+		text := data[:end]
+		last := 0
+		for _, m := range matchRanges(text, res) {
+			out = append(out, text[last:m[0]]...)
+			out = append(out, highlightOn...)
+			out = append(out, text[m[0]:m[1]]...)
+			out = append(out, formatter.ColorReset...)
+			out = append(out, active...)
+			last = m[1]
+		}
+		out = append(out, text[last:]...)
+		data = data[end:]
+	}
+	return append(out, data...)
+}
+
+// matchRanges 返回 res 在 text 中的非空匹配，按位置排序并合并重叠部分
+func matchRanges(text []byte, res []*regexp.Regexp) [][2]int {
+	var ranges [][2]int
+	for _, re := range res {
+		for _, m := range re.FindAllIndex(text, -1) {
+			if m[1] > m[0] {
+				ranges = append(ranges, [2]int{m[0], m[1]})
+			}
+		}
+	}
+	slices.SortFunc(ranges, func(a, b [2]int) int { return a[0] - b[0] })
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], r[1])
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
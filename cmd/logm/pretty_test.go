@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+func TestPretty(t *testing.T) {
+	input := strings.Join([]string{
+		`{"time":"2024-01-15T10:30:45.123Z","level":"ERROR","msg":"query failed","source":"/workspace/app/pkg/db/db.go:42","db":{"table":"users"},"rows":0}`,
+		`time=2024-01-15T10:30:46Z level=INFO msg=ready port=8080`,
+		`[pod/api-1/app] {"time":"2024-01-15T10:30:47Z","level":"WARN","msg":"slow"}`,
+		`panic: not a log line`,
+	}, "\n")
+
+	stdout, stderr, code := runCLI(t, input, "pretty", "--color=never", "--timezone=UTC", "--source-clip=/workspace/")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, strings.Join([]string{
+		`2024-01-15 10:30:45 ERROR query failed db.table="users" rows=0 pkg/db/db.go:42`,
+		`2024-01-15 10:30:46 INFO ready port="8080"`,
+		`[pod/api-1/app] 2024-01-15 10:30:47 WARN slow`,
+		`panic: not a log line`,
+		``,
+	}, "\n"), stdout)
+}
+
+func TestPretty_SlogJSONHandler(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true})).Error("boom", "err", "a\nb")
+
+	stdout, _, code := runCLI(t, buf.String(), "pretty", "--color=always", "--theme=light")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, formatter.ColorRed+formatter.ColorBold+"ERROR")
+	assert.Contains(t, stdout, formatter.ColorBlue+"cmd/logm/pretty_test.go:")
+	// 多行值输出在续行中
+	assert.Contains(t, stdout, "    "+formatter.ColorBlue+"err"+formatter.ColorReset+":\n")
+}
+
+func TestPretty_Files(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	require.NoError(t, os.WriteFile(a, []byte(`{"level":"INFO","msg":"from a"}`+"\n"), 0o600))
+
+	stdout, _, code := runCLI(t, "", "pretty", "--color=never", a)
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "INFO from a")
+
+	_, stderr, code := runCLI(t, "", "pretty", filepath.Join(dir, "missing.log"))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "missing.log")
+}
+
+func TestPretty_InvalidFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"--color=sometimes"},
+		{"--theme=neon"},
+		{"--timezone=Mars/Olympus"},
+	} {
+		_, stderr, code := runCLI(t, "", append([]string{"pretty"}, args...)...)
+		assert.Equal(t, 1, code, args)
+		assert.Contains(t, stderr, "invalid", args)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	res := compileAll(t, "time(out)?", "out")
+	const on, reset = highlightOn, formatter.ColorReset
+
+	assert.Equal(t, "a "+on+"timeout"+reset+" b", string(highlight([]byte("a timeout b"), res)))
+
+	// 匹配结束后恢复之前的颜色，转义序列本身不参与匹配
+	in := formatter.ColorGreen + "timeout" + reset + " x=" + formatter.ColorRed + "out" + reset
+	want := formatter.ColorGreen + on + "timeout" + reset + formatter.ColorGreen + reset +
+		" x=" + formatter.ColorRed + on + "out" + reset + formatter.ColorRed + reset
+	assert.Equal(t, want, string(highlight([]byte(in), res)))
+}
+
+func TestPretty_HighlightGrep(t *testing.T) {
+	stdout, _, code := runCLI(t, `{"level":"ERROR","msg":"dial timeout"}`, "pretty", "--color=always", "--grep", "timeout")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "dial "+highlightOn+"timeout"+formatter.ColorReset)
+}
+
+// compileAll 编译测试用的正则表达式
+func compileAll(t *testing.T, exprs ...string) []*regexp.Regexp {
+	t.Helper()
+	res := make([]*regexp.Regexp, len(exprs))
+	for i, expr := range exprs {
+		res[i] = regexp.MustCompile(expr)
+	}
+	return res
+}
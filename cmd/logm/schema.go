@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+)
+
+// runSchema 实现 logm schema。
+//
+// 命令行无法得知应用注册的事件和拦截器，需要完整约定的应用直接调用 logm.Schema 输出。
+func runSchema(c *cli, args []string) error {
+	fs := c.flagSet("schema", "")
+	format := fs.String("format", "json", "schema dialect: json (logm field names) or ecs (annotated with Elastic Common Schema fields)")
+	preset := fs.String("preset", "env", "configuration to describe: env (PresetFromEnv), dev, prod")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	var opts []logm.Option
+	switch *preset {
+	case "env":
+		opts = logm.PresetFromEnv()
+	case "dev":
+		opts = logm.PresetDev()
+	case "prod":
+		opts = logm.PresetProd()
+	default:
+		return fmt.Errorf("invalid --preset %q (env, dev, prod)", *preset)
+	}
+
+	s, err := logm.Schema(logm.SchemaDialect(*format), opts...)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.stdout, string(data))
+	return err
+}
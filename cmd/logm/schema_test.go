@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_Presets(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "schema", "--preset", "prod")
+	require.Equal(t, 0, code, stderr)
+	var s map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &s))
+	assert.Equal(t, "object", s["type"])
+	assert.Subset(t, s["required"], []any{"time", "level", "msg"})
+
+	stdout, _, code = runCLI(t, "", "schema", "--preset", "dev", "--format", "ecs")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, `"x-ecs-field": "@timestamp"`)
+}
+
+func TestSchema_InvalidFlags(t *testing.T) {
+	_, stderr, code := runCLI(t, "", "schema", "--format", "avro")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "unknown schema dialect")
+
+	_, stderr, code = runCLI(t, "", "schema", "--preset", "staging")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "invalid --preset")
+}
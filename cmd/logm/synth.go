@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// synth 生成模拟 HTTP 服务的结构化日志，供 bench 和 generate 使用
+type synth struct {
+	rng    *rand.Rand
+	fields int
+}
+
+// newSynth 创建生成器，fields 为每条日志的属性数
+func newSynth(seed uint64, fields int) *synth {
+	return &synth{rng: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)), fields: max(fields, 0)}
+}
+
+// synthField 一个字段的生成函数
+type synthField struct {
+	key string
+	gen func(s *synth) slog.Value
+}
+
+// 按常见程度排列的字段，--fields 超出时追加 field_NN
+var synthFields = []synthField{
+	{"method", func(s *synth) slog.Value {
+		return slog.StringValue(pick(s, "GET", "GET", "GET", "POST", "PUT", "DELETE"))
+	}},
+	{"path", func(s *synth) slog.Value {
+		return slog.StringValue(pick(s, "/api/users", "/api/orders", "/api/orders/"+strconv.Itoa(s.rng.IntN(10000)), "/healthz", "/api/login", "/static/app.js"))
+	}},
+	{"status", func(s *synth) slog.Value {
+		return slog.IntValue(pick(s, 200, 200, 200, 200, 201, 204, 301, 400, 401, 404, 500, 503))
+	}},
+	{"duration", func(s *synth) slog.Value {
+		// 对数正态分布，中位数约 20ms，带长尾
+		return slog.DurationValue(time.Duration(20e6 * expNorm(s)))
+	}},
+	{"request_id", func(s *synth) slog.Value { return slog.StringValue(fmt.Sprintf("%016x", s.rng.Uint64())) }},
+	{"user_id", func(s *synth) slog.Value { return slog.Int64Value(int64(s.rng.IntN(100000))) }},
+	{"bytes", func(s *synth) slog.Value { return slog.Int64Value(int64(s.rng.IntN(64 * 1024))) }},
+	{"remote_addr", func(s *synth) slog.Value {
+		return slog.StringValue(fmt.Sprintf("10.%d.%d.%d", s.rng.IntN(256), s.rng.IntN(256), s.rng.IntN(256)))
+	}},
+	{"user_agent", func(s *synth) slog.Value {
+		return slog.StringValue(pick(s, "Mozilla/5.0 (X11; Linux x86_64)", "curl/8.5.0", "Go-http-client/2.0", "okhttp/4.12.0"))
+	}},
+	{"region", func(s *synth) slog.Value {
+		return slog.StringValue(pick(s, "us-east-1", "eu-west-1", "ap-southeast-1"))
+	}},
+	{"cache_hit", func(s *synth) slog.Value { return slog.BoolValue(s.rng.IntN(4) != 0) }},
+	{"db", func(s *synth) slog.Value {
+		return slog.GroupValue(
+			slog.String("table", pick(s, "users", "orders", "payments")),
+			slog.Int("rows", s.rng.IntN(500)),
+		)
+	}},
+	{"trace_id", func(s *synth) slog.Value {
+		return slog.StringValue(fmt.Sprintf("%016x%016x", s.rng.Uint64(), s.rng.Uint64()))
+	}},
+	{"retry", func(s *synth) slog.Value { return slog.IntValue(pick(s, 0, 0, 0, 0, 1, 2)) }},
+	{"ratio", func(s *synth) slog.Value { return slog.Float64Value(float64(s.rng.IntN(1000)) / 1000) }},
+}
+
+// 各级别的消息
+var synthMessages = map[slog.Level][]string{
+	slog.LevelDebug: {"cache lookup", "query plan", "retrying request"},
+	slog.LevelInfo:  {"request completed", "user logged in", "order created", "job finished"},
+	slog.LevelWarn:  {"slow request", "retry budget low", "deprecated endpoint called"},
+	slog.LevelError: {"request failed", "database timeout", "payment declined"},
+}
+
+// 模拟的错误
+var synthErrors = []error{
+	errors.New("context deadline exceeded"),
+	errors.New("dial tcp 10.0.3.7:5432: connect: connection refused"),
+	errors.New("upstream returned 503"),
+}
+
+// level 按 15% DEBUG、70% INFO、10% WARN、5% ERROR 选择级别
+func (s *synth) level() slog.Level {
+	switch n := s.rng.IntN(100); {
+	case n < 15:
+		return slog.LevelDebug
+	case n < 85:
+		return slog.LevelInfo
+	case n < 95:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// record 生成一条日志的级别、消息和属性，ERROR 日志带 error 属性
+func (s *synth) record() (slog.Level, string, []slog.Attr) {
+	level := s.level()
+	msg := pick(s, synthMessages[level]...)
+	attrs := make([]slog.Attr, 0, s.fields+1)
+	for i := range s.fields {
+		if i < len(synthFields) {
+			f := synthFields[i]
+			attrs = append(attrs, slog.Attr{Key: f.key, Value: f.gen(s)})
+			continue
+		}
+		attrs = append(attrs, slog.String(fmt.Sprintf("field_%02d", i), fmt.Sprintf("value-%d", s.rng.IntN(1000))))
+	}
+	if level >= slog.LevelError {
+		attrs = append(attrs, slog.Any("error", pick(s, synthErrors...)))
+	}
+	return level, msg, attrs
+}
+
+// pick 随机选择一个值
+func pick[T any](s *synth, values ...T) T {
+	return values[s.rng.IntN(len(values))]
+}
+
+// expNorm 返回对数正态分布的随机数，中位数为 1，限制在 [e^-3, e^4] 内避免极端值
+func expNorm(s *synth) float64 {
+	return math.Exp(min(max(s.rng.NormFloat64(), -3), 4))
+}
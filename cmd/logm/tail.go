@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/agent"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// runTail 实现 logm tail
+func runTail(c *cli, args []string) error {
+	fs := c.flagSet("tail", "file...")
+	var pf prettyFlags
+	var ff filterFlags
+	follow := fs.Bool("f", false, "keep reading appended lines, following files across rename and truncate rotation")
+	lines := fs.Int("n", 10, "show the last N lines of each file first")
+	interval := fs.Duration("interval", 250*time.Millisecond, "how often to check the files in follow mode")
+	pf.register(fs)
+	ff.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if err := ff.compile(time.Now()); err != nil {
+		return err
+	}
+	r, err := pf.renderer(c, &ff)
+	if err != nil {
+		return err
+	}
+	if !*follow {
+		// 跟踪模式下等待文件出现，与 tail -F 一致
+		for _, path := range files {
+			if _, err := os.Stat(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	col := &lineCollector{}
+	a, err := agent.New(col,
+		agent.WithFiles(files...),
+		agent.WithTailLines(*lines),
+		agent.WithPollInterval(*interval),
+		agent.WithRawLevel(),
+	)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	out := bufio.NewWriter(c.stdout)
+	defer out.Flush()
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	last := make(map[string]time.Time) // 每个文件最近一行的时间，跨轮次保留
+	for {
+		a.Poll(c.ctx)
+		for _, l := range mergeLines(col.take(), last, ff.parseLine) {
+			if !ff.match(l.entry) {
+				continue
+			}
+			if _, err := out.Write(r.render(l.line, l.entry, l.prefix)); err != nil {
+				return err
+			}
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		if !*follow {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-c.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// sourceLine 读取的一行及其所在文件
+type sourceLine struct {
+	path string
+	line []byte
+}
+
+// lineCollector 收集 Agent 一轮读取的行
+type lineCollector struct {
+	mu    sync.Mutex
+	lines []sourceLine
+}
+
+func (l *lineCollector) Write(p []byte) (int, error) {
+	return l.WriteSource("", slog.LevelInfo, p)
+}
+
+// WriteSource 实现 agent.SourceWriter，记录行所在的文件
+func (l *lineCollector) WriteSource(path string, _ slog.Level, p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, sourceLine{path: path, line: bytes.TrimRight(bytes.Clone(p), "\r\n")})
+	return len(p), nil
+}
+
+func (l *lineCollector) Close() error { return nil }
+func (l *lineCollector) Sync() error  { return nil }
+
+// take 取出收集的行
+func (l *lineCollector) take() []sourceLine {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lines := l.lines
+	l.lines = nil
+	return lines
+}
+
+// tailLine 解析后的一行
+type tailLine struct {
+	line   []byte
+	entry  *formatter.Entry
+	prefix []byte
+	time   time.Time
+}
+
+// mergeLines 解析并按时间稳定排序多个文件的行。
+//
+// 无法解析或没有时间的行沿用同一文件前一行的时间，跟随前一行输出；
+// last 记录每个文件最近一行的时间，由调用方跨轮次保留，续行跨两轮读取时同样跟随。
+func mergeLines(lines []sourceLine, last map[string]time.Time, parse func([]byte) (*formatter.Entry, []byte, error)) []tailLine {
+	out := make([]tailLine, len(lines))
+	for i, l := range lines {
+		e, prefix, _ := parse(l.line)
+		if e != nil && !e.Time.IsZero() {
+			last[l.path] = e.Time
+		}
+		out[i] = tailLine{line: l.line, entry: e, prefix: prefix, time: last[l.path]}
+	}
+	slices.SortStableFunc(out, func(a, b tailLine) int { return a.time.Compare(b.time) })
+	return out
+}
+
+var (
+	_ writer.Writer      = (*lineCollector)(nil)
+	_ agent.SourceWriter = (*lineCollector)(nil)
+)
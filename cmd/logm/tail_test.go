@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer 并发安全的输出缓冲
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func writeLog(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	for _, line := range lines {
+		_, err = f.WriteString(line + "\n")
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+}
+
+func TestTail_Merge(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	b := filepath.Join(dir, "b.log")
+	writeLog(t, a,
+		`{"time":"2024-01-15T10:00:00Z","level":"INFO","msg":"a0"}`,
+		`{"time":"2024-01-15T10:00:01Z","level":"INFO","msg":"a1"}`,
+		`{"time":"2024-01-15T10:00:03Z","level":"INFO","msg":"a3"}`,
+		`  continuation of a3`,
+	)
+	writeLog(t, b,
+		`{"time":"2024-01-15T10:00:02Z","level":"WARN","msg":"b2"}`,
+		`{"time":"2024-01-15T10:00:04Z","level":"ERROR","msg":"b4"}`,
+	)
+
+	stdout, stderr, code := runCLI(t, "", "tail", "-n", "3", "--color=never", "--timezone=UTC", "--time-format=time", a, b)
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, strings.Join([]string{
+		"10:00:01 INFO a1",
+		"10:00:02 WARN b2",
+		"10:00:03 INFO a3",
+		"  continuation of a3",
+		"10:00:04 ERROR b4",
+		"",
+	}, "\n"), stdout)
+
+	_, _, code = runCLI(t, "", "tail", filepath.Join(dir, "missing.log"))
+	assert.Equal(t, 1, code)
+	_, _, code = runCLI(t, "", "tail")
+	assert.Equal(t, 2, code)
+}
+
+func TestTail_Follow(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	writeLog(t, path, `{"level":"INFO","msg":"old"}`, `{"level":"INFO","msg":"last"}`)
+
+	var out syncBuffer
+	ctx, cancel := context.WithCancel(t.Context())
+	c := &cli{ctx: ctx, stdout: &out, stderr: &out, getenv: func(string) string { return "" }}
+	done := make(chan int)
+	go func() {
+		done <- c.main([]string{"tail", "-f", "-n", "1", "--interval", "5ms", "--color=never", "--level=info", path})
+	}()
+
+	waitFor := func(s string) {
+		t.Helper()
+		assert.Eventually(t, func() bool { return strings.Contains(out.String(), s) }, 2*time.Second, 5*time.Millisecond, s)
+	}
+	waitFor("INFO last")
+
+	writeLog(t, path, `{"level":"DEBUG","msg":"hidden"}`, `{"level":"WARN","msg":"appended"}`)
+	waitFor("WARN appended")
+
+	// 改名轮转后跟踪新文件
+	require.NoError(t, os.Rename(path, path+".1"))
+	writeLog(t, path, `{"level":"ERROR","msg":"rotated"}`)
+	waitFor("ERROR rotated")
+
+	cancel()
+	assert.Equal(t, 0, <-done)
+	assert.NotContains(t, out.String(), "old")
+	assert.NotContains(t, out.String(), "hidden")
+}
+
+func TestMergeLines_PerFileTime(t *testing.T) {
+	parse := func(b []byte) (*formatter.Entry, []byte, error) {
+		e, err := formatter.ParseLine(b)
+		return e, nil, err
+	}
+	texts := func(lines []tailLine) []string {
+		out := make([]string, len(lines))
+		for i, l := range lines {
+			out[i] = string(l.line)
+		}
+		return out
+	}
+	last := make(map[string]time.Time)
+
+	// b.log 开头的续行不沿用 a.log 最后一行的时间
+	got := mergeLines([]sourceLine{
+		{path: "a.log", line: []byte(`{"time":"2024-01-15T10:00:03Z","level":"INFO","msg":"a3"}`)},
+		{path: "b.log", line: []byte(`  tail of an earlier b entry`)},
+		{path: "b.log", line: []byte(`{"time":"2024-01-15T10:00:01Z","level":"INFO","msg":"b1"}`)},
+	}, last, parse)
+	assert.Equal(t, []string{
+		`  tail of an earlier b entry`,
+		`{"time":"2024-01-15T10:00:01Z","level":"INFO","msg":"b1"}`,
+		`{"time":"2024-01-15T10:00:03Z","level":"INFO","msg":"a3"}`,
+	}, texts(got))
+
+	// 下一轮读到的续行跟随同一文件上一轮的最后一行
+	got = mergeLines([]sourceLine{
+		{path: "a.log", line: []byte(`  continuation of a3`)},
+		{path: "b.log", line: []byte(`{"time":"2024-01-15T10:00:02Z","level":"INFO","msg":"b2"}`)},
+	}, last, parse)
+	assert.Equal(t, []string{
+		`{"time":"2024-01-15T10:00:02Z","level":"INFO","msg":"b2"}`,
+		`  continuation of a3`,
+	}, texts(got))
+}
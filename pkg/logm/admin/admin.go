@@ -0,0 +1,243 @@
+// Package admin 提供日志系统的运行时管理接口。
+//
+// Handler 返回可挂载到已有路由下的 http.Handler：
+//
+//	mux.Handle("/admin/logm/", http.StripPrefix("/admin/logm",
+//	    admin.Handler(admin.WithAuth(admin.BearerToken(os.Getenv("ADMIN_TOKEN"))))))
+//
+// 端点：
+//
+//	GET  /level   当前级别 {"level":"INFO"}
+//	PUT  /level   设置级别，请求体为 {"level":"DEBUG"} 或纯文本 DEBUG
+//	GET  /config  生效配置，见 logm.ConfigInfo
+//	GET  /stats   运行统计和 Writer 健康状态，见 logm.HandlerStats、logm.WriterStatus
+//	POST /flush   刷新所有 Writer 缓冲区
+//	POST /rotate  轮转所有文件 Writer
+//	GET  /logs/stream?level=WARN&attr=key=value
+//	              以 Server-Sent Events 推送实时日志（拦截器之后的记录，JSON 格式），
+//	              浏览器可直接用 EventSource 订阅；attr 可重复，键为点分路径
+//	GET  /logs/recent?level=ERROR&n=200
+//	              从内存返回最近的匹配日志（JSON 数组），需要 WithRecent
+//	GET  /debug   管道内部状态，默认纯文本，?format=json 输出 JSON，见 DebugPage
+//
+// gRPC 服务见 adminpb 模块的 RegisterAdminServer，与 HTTP 接口共用同一个 Service。
+// adminpb 是独立的 Go 模块，本模块不依赖 gRPC。
+//
+// 默认管理 logm.Init 初始化的全局日志系统，WithControl 可指定其他 Handler。
+// 管理接口可以改变日志输出，暴露到内网之外时应配置 WithAuth。
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// errMethod 请求方法不受支持
+var errMethod = errors.New("method not allowed")
+
+// maxBodySize 请求体的最大字节数
+const maxBodySize = 4 << 10
+
+// Control 管理接口操作的日志系统，*logm.Handler 实现了该接口。
+type Control interface {
+	Level() slog.Level
+	SetLevel(level slog.Level)
+	Config() logm.ConfigInfo
+	Stats() logm.HandlerStats
+	Status() []logm.WriterStatus
+	Sync() error
+	Rotate() error
+	Observe(fn logm.ObserverFunc) (cancel func())
+	DebugState() logm.DebugState
+}
+
+// Global 返回操作全局日志系统的 Control，调用时才查找全局 Handler，
+// 之后重新 Init 同样生效。
+func Global() Control {
+	return global{}
+}
+
+// global 全局日志系统的 Control
+type global struct{}
+
+func (global) Level() slog.Level           { return logm.GetLevelVar().Level() }
+func (global) SetLevel(level slog.Level)   { logm.GetLevelVar().Set(level) }
+func (global) Config() logm.ConfigInfo     { return logm.Config() }
+func (global) Stats() logm.HandlerStats    { return logm.Stats() }
+func (global) Status() []logm.WriterStatus { return logm.Status() }
+func (global) Sync() error                 { return logm.Sync() }
+func (global) Rotate() error               { return logm.Rotate() }
+
+func (global) Observe(fn logm.ObserverFunc) (cancel func()) { return logm.Observe(fn) }
+func (global) DebugState() logm.DebugState                  { return logm.GetDebugState() }
+
+// Option 配置选项函数
+type Option func(*options)
+
+// options 内部配置
+type options struct {
+	control    Control
+	auth       func(http.Handler) http.Handler
+	maxStreams int
+	ring       *writer.RingWriter
+}
+
+// WithControl 指定管理的日志系统，默认为 Global()。
+func WithControl(c Control) Option {
+	return func(o *options) {
+		o.control = c
+	}
+}
+
+// WithAuth 设置认证中间件，所有端点都经过该中间件。
+func WithAuth(mw func(http.Handler) http.Handler) Option {
+	return func(o *options) {
+		o.auth = mw
+	}
+}
+
+// WithMaxStreams 设置 /logs/stream 的最大并发连接数，超过时返回 503，默认 8，<= 0 表示不限制。
+func WithMaxStreams(n int) Option {
+	return func(o *options) {
+		o.maxStreams = n
+	}
+}
+
+// BearerToken 返回校验 Authorization: Bearer <token> 的认证中间件，token 为空时拒绝所有请求。
+func BearerToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="logm"`)
+				writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Handler 返回管理接口的 http.Handler，路由见包文档。
+func Handler(opts ...Option) http.Handler {
+	o := &options{control: Global(), maxStreams: 8}
+	for _, opt := range opts {
+		opt(o)
+	}
+	a := &api{svc: NewService(o.control), maxStreams: o.maxStreams, ring: o.ring}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /level", a.getLevel)
+	mux.HandleFunc("PUT /level", a.putLevel)
+	mux.HandleFunc("GET /config", a.getConfig)
+	mux.HandleFunc("GET /stats", a.getStats)
+	mux.HandleFunc("POST /flush", a.flush)
+	mux.HandleFunc("POST /rotate", a.rotate)
+	mux.HandleFunc("GET /logs/stream", a.stream)
+	mux.HandleFunc("GET /logs/recent", a.recent)
+	mux.HandleFunc("GET /debug", a.debug)
+
+	if o.auth != nil {
+		return o.auth(mux)
+	}
+	return mux
+}
+
+// api 管理接口的请求处理
+type api struct {
+	svc  *Service
+	ring *writer.RingWriter
+
+	maxStreams int
+	streams    atomic.Int64
+}
+
+// levelBody /level 的请求和响应
+type levelBody struct {
+	Level    string `json:"level"`
+	Previous string `json:"previous,omitempty"`
+}
+
+func (a *api) getLevel(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, levelBody{Level: a.svc.Level(r.Context())})
+}
+
+func (a *api) putLevel(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	name := strings.TrimSpace(string(data))
+	if strings.HasPrefix(name, "{") {
+		var body levelBody
+		if err := json.Unmarshal(data, &body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		name = body.Level
+	}
+	level, prev, err := a.svc.SetLevel(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, levelBody{Level: level, Previous: prev})
+}
+
+func (a *api) getConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, a.svc.GetConfig(r.Context()))
+}
+
+// statsBody /stats 的响应
+type statsBody struct {
+	Stats  logm.HandlerStats
+	Status []logm.WriterStatus
+}
+
+func (a *api) getStats(w http.ResponseWriter, r *http.Request) {
+	stats, status := a.svc.Stats(r.Context())
+	writeJSON(w, statsBody{Stats: stats, Status: status})
+}
+
+func (a *api) flush(w http.ResponseWriter, r *http.Request) {
+	if err := a.svc.Flush(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *api) rotate(w http.ResponseWriter, r *http.Request) {
+	if err := a.svc.Rotate(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeError 输出 {"error": "..."} 错误响应
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+var _ Control = (*logm.Handler)(nil)
@@ -0,0 +1,196 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bufWriter 写入内存的 Writer
+type bufWriter struct {
+	bytes.Buffer
+}
+
+func (w *bufWriter) Sync() error  { return nil }
+func (w *bufWriter) Close() error { return nil }
+
+// newControl 创建写入内存的独立 Handler
+func newControl(t *testing.T) *logm.Handler {
+	t.Helper()
+	h := logm.NewHandler(&logm.HandlerConfig{
+		LevelVar:  &slog.LevelVar{},
+		Formatter: formatter.JSON(),
+		Writers:   []logm.Writer{&bufWriter{}},
+	})
+	t.Cleanup(func() { _ = h.Close() })
+	return h
+}
+
+func serve(h http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &m), rec.Body.String())
+	return m
+}
+
+func TestHandler_Level(t *testing.T) {
+	c := newControl(t)
+	h := Handler(WithControl(c))
+
+	rec := serve(h, http.MethodGet, "/level", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "INFO", decode(t, rec)["level"])
+
+	rec = serve(h, http.MethodPut, "/level", `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, map[string]any{"level": "DEBUG", "previous": "INFO"}, decode(t, rec))
+	assert.Equal(t, slog.LevelDebug, c.Level())
+
+	rec = serve(h, http.MethodPut, "/level", "ERROR\n")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, slog.LevelError, c.Level())
+
+	rec = serve(h, http.MethodPut, "/level", "verbose")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, decode(t, rec)["error"], "unknown level")
+	assert.Equal(t, slog.LevelError, c.Level())
+
+	rec = serve(h, http.MethodPut, "/level", `{"level":`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(h, http.MethodPost, "/level", "debug")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestHandler_ConfigAndStats(t *testing.T) {
+	c := newControl(t)
+	h := Handler(WithControl(c))
+	slog.New(c).Info("hello")
+
+	rec := serve(h, http.MethodGet, "/config", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var cfg logm.ConfigInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	assert.Equal(t, c.Config(), cfg)
+
+	rec = serve(h, http.MethodGet, "/stats", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body statsBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, uint64(1), body.Stats.Records["INFO"])
+	require.Len(t, body.Status, 1)
+	assert.Equal(t, "admin.bufWriter#0", body.Status[0].Name)
+}
+
+// stubControl 可注入错误的 Control
+type stubControl struct {
+	*logm.Handler
+	err             error
+	synced, rotated int
+}
+
+func (s *stubControl) Sync() error   { s.synced++; return s.err }
+func (s *stubControl) Rotate() error { s.rotated++; return s.err }
+
+// countingControl 统计活动观察者数的 Control
+type countingControl struct {
+	*logm.Handler
+	mu sync.Mutex
+	n  int
+}
+
+func (c *countingControl) Observe(fn logm.ObserverFunc) func() {
+	c.mu.Lock()
+	c.n++
+	c.mu.Unlock()
+	cancel := c.Handler.Observe(fn)
+	return func() {
+		cancel()
+		c.mu.Lock()
+		c.n--
+		c.mu.Unlock()
+	}
+}
+
+func (c *countingControl) active() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+func TestHandler_FlushRotate(t *testing.T) {
+	s := &stubControl{Handler: newControl(t)}
+	h := Handler(WithControl(s))
+
+	assert.Equal(t, http.StatusNoContent, serve(h, http.MethodPost, "/flush", "").Code)
+	assert.Equal(t, http.StatusNoContent, serve(h, http.MethodPost, "/rotate", "").Code)
+	assert.Equal(t, 1, s.synced)
+	assert.Equal(t, 1, s.rotated)
+
+	s.err = errors.New("disk full")
+	rec := serve(h, http.MethodPost, "/rotate", "")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "disk full", decode(t, rec)["error"])
+
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(h, http.MethodGet, "/flush", "").Code)
+}
+
+func TestBearerToken(t *testing.T) {
+	h := Handler(WithControl(newControl(t)), WithAuth(BearerToken("s3cret")))
+
+	rec := serve(h, http.MethodGet, "/level", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+
+	assert.Equal(t, http.StatusUnauthorized, serve(h, http.MethodGet, "/level", "", "Authorization", "Bearer wrong").Code)
+	assert.Equal(t, http.StatusOK, serve(h, http.MethodGet, "/level", "", "Authorization", "Bearer s3cret").Code)
+
+	// 空 token 拒绝所有请求
+	h = Handler(WithControl(newControl(t)), WithAuth(BearerToken("")))
+	assert.Equal(t, http.StatusUnauthorized, serve(h, http.MethodGet, "/level", "", "Authorization", "Bearer ").Code)
+}
+
+func TestHandler_Mount(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/logm/", http.StripPrefix("/admin/logm", Handler(WithControl(newControl(t)))))
+
+	rec := serve(mux, http.MethodGet, "/admin/logm/level", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "INFO", decode(t, rec)["level"])
+}
+
+func TestHandler_Global(t *testing.T) {
+	w := &bufWriter{}
+	require.NoError(t, logm.Init(logm.WithLevel("warn"), logm.WithWriter(w)))
+	defer func() { _ = logm.Close() }()
+
+	h := Handler()
+	rec := serve(h, http.MethodPut, "/level", "info")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "WARN", decode(t, rec)["previous"])
+	assert.Equal(t, "INFO", logm.Config().Level)
+
+	logm.Info("visible")
+	assert.Contains(t, w.String(), "visible")
+}
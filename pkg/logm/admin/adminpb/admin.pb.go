@@ -0,0 +1,546 @@
+// 日志系统 gRPC 管理服务，与 HTTP 管理接口操作同一个 admin.Control。
+//
+// 生成代码位于同目录的 adminpb 模块，修改后在本目录执行 go generate 重新生成，
+// 需要 protoc、protoc-gen-go 和 protoc-gen-go-grpc。
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: admin.proto
+
+package adminpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SetLevelRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// DEBUG、INFO、WARN 或 ERROR，不区分大小写
+	Level         string `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetLevelRequest) Reset() {
+	*x = SetLevelRequest{}
+	mi := &file_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetLevelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLevelRequest) ProtoMessage() {}
+
+func (x *SetLevelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLevelRequest.ProtoReflect.Descriptor instead.
+func (*SetLevelRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SetLevelRequest) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+type SetLevelResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Level         string                 `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	Previous      string                 `protobuf:"bytes,2,opt,name=previous,proto3" json:"previous,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetLevelResponse) Reset() {
+	*x = SetLevelResponse{}
+	mi := &file_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetLevelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLevelResponse) ProtoMessage() {}
+
+func (x *SetLevelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLevelResponse.ProtoReflect.Descriptor instead.
+func (*SetLevelResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SetLevelResponse) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *SetLevelResponse) GetPrevious() string {
+	if x != nil {
+		return x.Previous
+	}
+	return ""
+}
+
+type GetConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConfigRequest) Reset() {
+	*x = GetConfigRequest{}
+	mi := &file_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigRequest) ProtoMessage() {}
+
+func (x *GetConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetConfigRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{2}
+}
+
+// 字段与 logm.ConfigInfo 一致
+type GetConfigResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Level           string                 `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	Formatter       string                 `protobuf:"bytes,2,opt,name=formatter,proto3" json:"formatter,omitempty"`
+	Writers         []string               `protobuf:"bytes,3,rep,name=writers,proto3" json:"writers,omitempty"`
+	Interceptors    int32                  `protobuf:"varint,4,opt,name=interceptors,proto3" json:"interceptors,omitempty"`
+	AddSource       bool                   `protobuf:"varint,5,opt,name=add_source,json=addSource,proto3" json:"add_source,omitempty"`
+	TimeFormat      string                 `protobuf:"bytes,6,opt,name=time_format,json=timeFormat,proto3" json:"time_format,omitempty"`
+	Timezone        string                 `protobuf:"bytes,7,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	MaxRecordSize   int64                  `protobuf:"varint,8,opt,name=max_record_size,json=maxRecordSize,proto3" json:"max_record_size,omitempty"`
+	OversizePolicy  string                 `protobuf:"bytes,9,opt,name=oversize_policy,json=oversizePolicy,proto3" json:"oversize_policy,omitempty"`
+	MaxAttrs        int32                  `protobuf:"varint,10,opt,name=max_attrs,json=maxAttrs,proto3" json:"max_attrs,omitempty"`
+	DuplicateKeys   string                 `protobuf:"bytes,11,opt,name=duplicate_keys,json=duplicateKeys,proto3" json:"duplicate_keys,omitempty"`
+	Sanitize        bool                   `protobuf:"varint,12,opt,name=sanitize,proto3" json:"sanitize,omitempty"`
+	MessageTemplate bool                   `protobuf:"varint,13,opt,name=message_template,json=messageTemplate,proto3" json:"message_template,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetConfigResponse) Reset() {
+	*x = GetConfigResponse{}
+	mi := &file_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigResponse) ProtoMessage() {}
+
+func (x *GetConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigResponse.ProtoReflect.Descriptor instead.
+func (*GetConfigResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetConfigResponse) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *GetConfigResponse) GetFormatter() string {
+	if x != nil {
+		return x.Formatter
+	}
+	return ""
+}
+
+func (x *GetConfigResponse) GetWriters() []string {
+	if x != nil {
+		return x.Writers
+	}
+	return nil
+}
+
+func (x *GetConfigResponse) GetInterceptors() int32 {
+	if x != nil {
+		return x.Interceptors
+	}
+	return 0
+}
+
+func (x *GetConfigResponse) GetAddSource() bool {
+	if x != nil {
+		return x.AddSource
+	}
+	return false
+}
+
+func (x *GetConfigResponse) GetTimeFormat() string {
+	if x != nil {
+		return x.TimeFormat
+	}
+	return ""
+}
+
+func (x *GetConfigResponse) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *GetConfigResponse) GetMaxRecordSize() int64 {
+	if x != nil {
+		return x.MaxRecordSize
+	}
+	return 0
+}
+
+func (x *GetConfigResponse) GetOversizePolicy() string {
+	if x != nil {
+		return x.OversizePolicy
+	}
+	return ""
+}
+
+func (x *GetConfigResponse) GetMaxAttrs() int32 {
+	if x != nil {
+		return x.MaxAttrs
+	}
+	return 0
+}
+
+func (x *GetConfigResponse) GetDuplicateKeys() string {
+	if x != nil {
+		return x.DuplicateKeys
+	}
+	return ""
+}
+
+func (x *GetConfigResponse) GetSanitize() bool {
+	if x != nil {
+		return x.Sanitize
+	}
+	return false
+}
+
+func (x *GetConfigResponse) GetMessageTemplate() bool {
+	if x != nil {
+		return x.MessageTemplate
+	}
+	return false
+}
+
+type FlushRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlushRequest) Reset() {
+	*x = FlushRequest{}
+	mi := &file_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlushRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlushRequest) ProtoMessage() {}
+
+func (x *FlushRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlushRequest.ProtoReflect.Descriptor instead.
+func (*FlushRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{4}
+}
+
+type FlushResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlushResponse) Reset() {
+	*x = FlushResponse{}
+	mi := &file_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlushResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlushResponse) ProtoMessage() {}
+
+func (x *FlushResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlushResponse.ProtoReflect.Descriptor instead.
+func (*FlushResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{5}
+}
+
+type RotateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateRequest) Reset() {
+	*x = RotateRequest{}
+	mi := &file_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateRequest) ProtoMessage() {}
+
+func (x *RotateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateRequest.ProtoReflect.Descriptor instead.
+func (*RotateRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{6}
+}
+
+type RotateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateResponse) Reset() {
+	*x = RotateResponse{}
+	mi := &file_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateResponse) ProtoMessage() {}
+
+func (x *RotateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateResponse.ProtoReflect.Descriptor instead.
+func (*RotateResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{7}
+}
+
+var File_admin_proto protoreflect.FileDescriptor
+
+const file_admin_proto_rawDesc = "" +
+	"\n" +
+	"\vadmin.proto\x12\rlogm.admin.v1\"'\n" +
+	"\x0fSetLevelRequest\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\"D\n" +
+	"\x10SetLevelResponse\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\x12\x1a\n" +
+	"\bprevious\x18\x02 \x01(\tR\bprevious\"\x12\n" +
+	"\x10GetConfigRequest\"\xbd\x03\n" +
+	"\x11GetConfigResponse\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\x12\x1c\n" +
+	"\tformatter\x18\x02 \x01(\tR\tformatter\x12\x18\n" +
+	"\awriters\x18\x03 \x03(\tR\awriters\x12\"\n" +
+	"\finterceptors\x18\x04 \x01(\x05R\finterceptors\x12\x1d\n" +
+	"\n" +
+	"add_source\x18\x05 \x01(\bR\taddSource\x12\x1f\n" +
+	"\vtime_format\x18\x06 \x01(\tR\n" +
+	"timeFormat\x12\x1a\n" +
+	"\btimezone\x18\a \x01(\tR\btimezone\x12&\n" +
+	"\x0fmax_record_size\x18\b \x01(\x03R\rmaxRecordSize\x12'\n" +
+	"\x0foversize_policy\x18\t \x01(\tR\x0eoversizePolicy\x12\x1b\n" +
+	"\tmax_attrs\x18\n" +
+	" \x01(\x05R\bmaxAttrs\x12%\n" +
+	"\x0eduplicate_keys\x18\v \x01(\tR\rduplicateKeys\x12\x1a\n" +
+	"\bsanitize\x18\f \x01(\bR\bsanitize\x12)\n" +
+	"\x10message_template\x18\r \x01(\bR\x0fmessageTemplate\"\x0e\n" +
+	"\fFlushRequest\"\x0f\n" +
+	"\rFlushResponse\"\x0f\n" +
+	"\rRotateRequest\"\x10\n" +
+	"\x0eRotateResponse2\xb3\x02\n" +
+	"\tLogmAdmin\x12K\n" +
+	"\bSetLevel\x12\x1e.logm.admin.v1.SetLevelRequest\x1a\x1f.logm.admin.v1.SetLevelResponse\x12N\n" +
+	"\tGetConfig\x12\x1f.logm.admin.v1.GetConfigRequest\x1a .logm.admin.v1.GetConfigResponse\x12B\n" +
+	"\x05Flush\x12\x1b.logm.admin.v1.FlushRequest\x1a\x1c.logm.admin.v1.FlushResponse\x12E\n" +
+	"\x06Rotate\x12\x1c.logm.admin.v1.RotateRequest\x1a\x1d.logm.admin.v1.RotateResponseB>Z<github.com/lwmacct/251219-go-pkg-logm/pkg/logm/admin/adminpbb\x06proto3"
+
+var (
+	file_admin_proto_rawDescOnce sync.Once
+	file_admin_proto_rawDescData []byte
+)
+
+func file_admin_proto_rawDescGZIP() []byte {
+	file_admin_proto_rawDescOnce.Do(func() {
+		file_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)))
+	})
+	return file_admin_proto_rawDescData
+}
+
+var file_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_admin_proto_goTypes = []any{
+	(*SetLevelRequest)(nil),   // 0: logm.admin.v1.SetLevelRequest
+	(*SetLevelResponse)(nil),  // 1: logm.admin.v1.SetLevelResponse
+	(*GetConfigRequest)(nil),  // 2: logm.admin.v1.GetConfigRequest
+	(*GetConfigResponse)(nil), // 3: logm.admin.v1.GetConfigResponse
+	(*FlushRequest)(nil),      // 4: logm.admin.v1.FlushRequest
+	(*FlushResponse)(nil),     // 5: logm.admin.v1.FlushResponse
+	(*RotateRequest)(nil),     // 6: logm.admin.v1.RotateRequest
+	(*RotateResponse)(nil),    // 7: logm.admin.v1.RotateResponse
+}
+var file_admin_proto_depIdxs = []int32{
+	0, // 0: logm.admin.v1.LogmAdmin.SetLevel:input_type -> logm.admin.v1.SetLevelRequest
+	2, // 1: logm.admin.v1.LogmAdmin.GetConfig:input_type -> logm.admin.v1.GetConfigRequest
+	4, // 2: logm.admin.v1.LogmAdmin.Flush:input_type -> logm.admin.v1.FlushRequest
+	6, // 3: logm.admin.v1.LogmAdmin.Rotate:input_type -> logm.admin.v1.RotateRequest
+	1, // 4: logm.admin.v1.LogmAdmin.SetLevel:output_type -> logm.admin.v1.SetLevelResponse
+	3, // 5: logm.admin.v1.LogmAdmin.GetConfig:output_type -> logm.admin.v1.GetConfigResponse
+	5, // 6: logm.admin.v1.LogmAdmin.Flush:output_type -> logm.admin.v1.FlushResponse
+	7, // 7: logm.admin.v1.LogmAdmin.Rotate:output_type -> logm.admin.v1.RotateResponse
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_admin_proto_init() }
+func file_admin_proto_init() {
+	if File_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_admin_proto_goTypes,
+		DependencyIndexes: file_admin_proto_depIdxs,
+		MessageInfos:      file_admin_proto_msgTypes,
+	}.Build()
+	File_admin_proto = out.File
+	file_admin_proto_goTypes = nil
+	file_admin_proto_depIdxs = nil
+}
@@ -0,0 +1,248 @@
+// 日志系统 gRPC 管理服务，与 HTTP 管理接口操作同一个 admin.Control。
+//
+// 生成代码位于同目录的 adminpb 模块，修改后在本目录执行 go generate 重新生成，
+// 需要 protoc、protoc-gen-go 和 protoc-gen-go-grpc。
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: admin.proto
+
+package adminpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LogmAdmin_SetLevel_FullMethodName  = "/logm.admin.v1.LogmAdmin/SetLevel"
+	LogmAdmin_GetConfig_FullMethodName = "/logm.admin.v1.LogmAdmin/GetConfig"
+	LogmAdmin_Flush_FullMethodName     = "/logm.admin.v1.LogmAdmin/Flush"
+	LogmAdmin_Rotate_FullMethodName    = "/logm.admin.v1.LogmAdmin/Rotate"
+)
+
+// LogmAdminClient is the client API for LogmAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LogmAdminClient interface {
+	// SetLevel 设置级别，返回设置前后的级别
+	SetLevel(ctx context.Context, in *SetLevelRequest, opts ...grpc.CallOption) (*SetLevelResponse, error)
+	// GetConfig 返回生效配置
+	GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigResponse, error)
+	// Flush 刷新所有 Writer 缓冲区
+	Flush(ctx context.Context, in *FlushRequest, opts ...grpc.CallOption) (*FlushResponse, error)
+	// Rotate 轮转所有文件 Writer
+	Rotate(ctx context.Context, in *RotateRequest, opts ...grpc.CallOption) (*RotateResponse, error)
+}
+
+type logmAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLogmAdminClient(cc grpc.ClientConnInterface) LogmAdminClient {
+	return &logmAdminClient{cc}
+}
+
+func (c *logmAdminClient) SetLevel(ctx context.Context, in *SetLevelRequest, opts ...grpc.CallOption) (*SetLevelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetLevelResponse)
+	err := c.cc.Invoke(ctx, LogmAdmin_SetLevel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logmAdminClient) GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetConfigResponse)
+	err := c.cc.Invoke(ctx, LogmAdmin_GetConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logmAdminClient) Flush(ctx context.Context, in *FlushRequest, opts ...grpc.CallOption) (*FlushResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FlushResponse)
+	err := c.cc.Invoke(ctx, LogmAdmin_Flush_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logmAdminClient) Rotate(ctx context.Context, in *RotateRequest, opts ...grpc.CallOption) (*RotateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RotateResponse)
+	err := c.cc.Invoke(ctx, LogmAdmin_Rotate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LogmAdminServer is the server API for LogmAdmin service.
+// All implementations must embed UnimplementedLogmAdminServer
+// for forward compatibility.
+type LogmAdminServer interface {
+	// SetLevel 设置级别，返回设置前后的级别
+	SetLevel(context.Context, *SetLevelRequest) (*SetLevelResponse, error)
+	// GetConfig 返回生效配置
+	GetConfig(context.Context, *GetConfigRequest) (*GetConfigResponse, error)
+	// Flush 刷新所有 Writer 缓冲区
+	Flush(context.Context, *FlushRequest) (*FlushResponse, error)
+	// Rotate 轮转所有文件 Writer
+	Rotate(context.Context, *RotateRequest) (*RotateResponse, error)
+	mustEmbedUnimplementedLogmAdminServer()
+}
+
+// UnimplementedLogmAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLogmAdminServer struct{}
+
+func (UnimplementedLogmAdminServer) SetLevel(context.Context, *SetLevelRequest) (*SetLevelResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetLevel not implemented")
+}
+func (UnimplementedLogmAdminServer) GetConfig(context.Context, *GetConfigRequest) (*GetConfigResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetConfig not implemented")
+}
+func (UnimplementedLogmAdminServer) Flush(context.Context, *FlushRequest) (*FlushResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Flush not implemented")
+}
+func (UnimplementedLogmAdminServer) Rotate(context.Context, *RotateRequest) (*RotateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Rotate not implemented")
+}
+func (UnimplementedLogmAdminServer) mustEmbedUnimplementedLogmAdminServer() {}
+func (UnimplementedLogmAdminServer) testEmbeddedByValue()                   {}
+
+// UnsafeLogmAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LogmAdminServer will
+// result in compilation errors.
+type UnsafeLogmAdminServer interface {
+	mustEmbedUnimplementedLogmAdminServer()
+}
+
+func RegisterLogmAdminServer(s grpc.ServiceRegistrar, srv LogmAdminServer) {
+	// If the following call panics, it indicates UnimplementedLogmAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LogmAdmin_ServiceDesc, srv)
+}
+
+func _LogmAdmin_SetLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogmAdminServer).SetLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogmAdmin_SetLevel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogmAdminServer).SetLevel(ctx, req.(*SetLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogmAdmin_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogmAdminServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogmAdmin_GetConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogmAdminServer).GetConfig(ctx, req.(*GetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogmAdmin_Flush_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlushRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogmAdminServer).Flush(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogmAdmin_Flush_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogmAdminServer).Flush(ctx, req.(*FlushRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogmAdmin_Rotate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogmAdminServer).Rotate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogmAdmin_Rotate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogmAdminServer).Rotate(ctx, req.(*RotateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LogmAdmin_ServiceDesc is the grpc.ServiceDesc for LogmAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LogmAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "logm.admin.v1.LogmAdmin",
+	HandlerType: (*LogmAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetLevel",
+			Handler:    _LogmAdmin_SetLevel_Handler,
+		},
+		{
+			MethodName: "GetConfig",
+			Handler:    _LogmAdmin_GetConfig_Handler,
+		},
+		{
+			MethodName: "Flush",
+			Handler:    _LogmAdmin_Flush_Handler,
+		},
+		{
+			MethodName: "Rotate",
+			Handler:    _LogmAdmin_Rotate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin.proto",
+}
@@ -0,0 +1,9 @@
+// Package adminpb 日志系统管理服务的 gRPC 生成代码和注册函数。
+//
+// 与主模块分开发布，使不使用 gRPC 的程序不引入 gRPC 依赖：
+//
+//	srv := grpc.NewServer()
+//	adminpb.RegisterAdminServer(srv, admin.NewService(nil))
+package adminpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative admin.proto
@@ -0,0 +1,22 @@
+module github.com/lwmacct/251219-go-pkg-logm/pkg/logm/admin/adminpb
+
+go 1.25.0
+
+require (
+	github.com/lwmacct/251219-go-pkg-logm v0.0.0
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/lwmacct/251219-go-pkg-logm => ../../../..
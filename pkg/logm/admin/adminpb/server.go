@@ -0,0 +1,79 @@
+package adminpb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/admin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RegisterAdminServer 将 svc 注册为 s 上的 LogmAdmin 服务，svc 为 nil 时管理全局日志系统。
+func RegisterAdminServer(s grpc.ServiceRegistrar, svc *admin.Service) {
+	if svc == nil {
+		svc = admin.NewService(nil)
+	}
+	RegisterLogmAdminServer(s, &server{svc: svc})
+}
+
+// server 将 LogmAdmin 请求委托给 admin.Service
+type server struct {
+	UnimplementedLogmAdminServer
+
+	svc *admin.Service
+}
+
+// SetLevel 实现 LogmAdminServer
+func (s *server) SetLevel(ctx context.Context, req *SetLevelRequest) (*SetLevelResponse, error) {
+	level, prev, err := s.svc.SetLevel(ctx, req.GetLevel())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &SetLevelResponse{Level: level, Previous: prev}, nil
+}
+
+// GetConfig 实现 LogmAdminServer
+func (s *server) GetConfig(ctx context.Context, _ *GetConfigRequest) (*GetConfigResponse, error) {
+	c := s.svc.GetConfig(ctx)
+	return &GetConfigResponse{
+		Level:           c.Level,
+		Formatter:       c.Formatter,
+		Writers:         c.Writers,
+		Interceptors:    int32(c.Interceptors), //nolint:gosec // G115: 拦截器数量不会超出 int32
+		AddSource:       c.AddSource,
+		TimeFormat:      c.TimeFormat,
+		Timezone:        c.Timezone,
+		MaxRecordSize:   int64(c.MaxRecordSize),
+		OversizePolicy:  c.OversizePolicy,
+		MaxAttrs:        int32(c.MaxAttrs), //nolint:gosec // G115: 属性数上限不会超出 int32
+		DuplicateKeys:   c.DuplicateKeys,
+		Sanitize:        c.Sanitize,
+		MessageTemplate: c.MessageTemplate,
+	}, nil
+}
+
+// Flush 实现 LogmAdminServer
+func (s *server) Flush(ctx context.Context, _ *FlushRequest) (*FlushResponse, error) {
+	if err := s.svc.Flush(ctx); err != nil {
+		return nil, toStatus(err)
+	}
+	return &FlushResponse{}, nil
+}
+
+// Rotate 实现 LogmAdminServer
+func (s *server) Rotate(ctx context.Context, _ *RotateRequest) (*RotateResponse, error) {
+	if err := s.svc.Rotate(ctx); err != nil {
+		return nil, toStatus(err)
+	}
+	return &RotateResponse{}, nil
+}
+
+// toStatus 将 Service 的错误映射为 gRPC 状态
+func toStatus(err error) error {
+	if errors.Is(err, admin.ErrInvalidArgument) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
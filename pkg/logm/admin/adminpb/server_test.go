@@ -0,0 +1,79 @@
+package adminpb
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/admin"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// bufWriter 记录写入内容的 Writer
+type bufWriter struct {
+	bytes.Buffer
+}
+
+func (w *bufWriter) Close() error { return nil }
+func (w *bufWriter) Sync() error  { return nil }
+
+// newClient 启动注册了 h 的 gRPC 服务，返回连接到它的客户端
+func newClient(t *testing.T, h *logm.Handler) LogmAdminClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterAdminServer(srv, admin.NewService(h))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return NewLogmAdminClient(conn)
+}
+
+func TestRegisterAdminServer(t *testing.T) {
+	h := logm.NewHandler(&logm.HandlerConfig{
+		LevelVar:  &slog.LevelVar{},
+		Formatter: formatter.JSON(),
+		Writers:   []logm.Writer{&bufWriter{}},
+	})
+	t.Cleanup(func() { _ = h.Close() })
+	client := newClient(t, h)
+	ctx := t.Context()
+
+	resp, err := client.SetLevel(ctx, &SetLevelRequest{Level: "warn"})
+	require.NoError(t, err)
+	assert.Equal(t, "WARN", resp.GetLevel())
+	assert.Equal(t, "INFO", resp.GetPrevious())
+	assert.Equal(t, slog.LevelWarn, h.Level())
+
+	_, err = client.SetLevel(ctx, &SetLevelRequest{Level: "loud"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	cfg, err := client.GetConfig(ctx, &GetConfigRequest{})
+	require.NoError(t, err)
+	want := h.Config()
+	assert.Equal(t, want.Level, cfg.GetLevel())
+	assert.Equal(t, want.Formatter, cfg.GetFormatter())
+	assert.Equal(t, want.Writers, cfg.GetWriters())
+	assert.Equal(t, want.Timezone, cfg.GetTimezone())
+
+	_, err = client.Flush(ctx, &FlushRequest{})
+	require.NoError(t, err)
+	_, err = client.Rotate(ctx, &RotateRequest{})
+	require.NoError(t, err)
+}
@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DebugPage 返回只读的管道状态页面，类似 net/http/pprof 的调试入口：
+//
+//	http.Handle("/debug/logm", admin.DebugPage())
+//
+// 页面列出生效的格式化器、Writer 状态和队列深度、拦截器、命名级别规则和丢弃计数，
+// 默认输出纯文本，?format=json 或 Accept: application/json 时输出 logm.DebugState 的 JSON。
+// 只接受 WithControl 和 WithAuth 选项。
+func DebugPage(opts ...Option) http.Handler {
+	o := &options{control: Global()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	a := &api{svc: NewService(o.control)}
+	h := http.Handler(http.HandlerFunc(a.debug))
+	if o.auth != nil {
+		h = o.auth(h)
+	}
+	return h
+}
+
+// debug 输出管道内部状态
+func (a *api) debug(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, errMethod)
+		return
+	}
+	state := a.svc.control.DebugState()
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		writeJSON(w, state)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_ = state.WriteText(w)
+}
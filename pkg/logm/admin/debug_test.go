@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugPage(t *testing.T) {
+	c := newControl(t)
+	for _, tc := range []struct {
+		h    http.Handler
+		path string
+	}{
+		{Handler(WithControl(c)), "/debug"},
+		{DebugPage(WithControl(c)), "/debug/logm"},
+	} {
+		h, path := tc.h, tc.path
+		rec := serve(h, http.MethodGet, path, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), "formatter=formatter.JSONFormatter")
+		assert.Contains(t, rec.Body.String(), "admin.bufWriter#0")
+
+		rec = serve(h, http.MethodGet, path+"?format=json", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var state logm.DebugState
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+		assert.Equal(t, c.DebugState().Config, state.Config)
+
+		rec = serve(h, http.MethodGet, path, "", "Accept", "application/json")
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	}
+
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(DebugPage(WithControl(c)), http.MethodPost, "/", "").Code)
+	h := DebugPage(WithControl(c), WithAuth(BearerToken("t")))
+	assert.Equal(t, http.StatusUnauthorized, serve(h, http.MethodGet, "/", "").Code)
+}
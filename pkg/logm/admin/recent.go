@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// defaultRecent /logs/recent 默认返回的条数
+const defaultRecent = 100
+
+// WithRecent 启用 GET /logs/recent，从 ring 中返回最近的日志。
+//
+// ring 需要作为 Writer 加入被管理的日志系统，并使用 JSON 或 Text 格式化器：
+//
+//	ring := writer.Ring(5000)
+//	logm.Init(logm.WithWriter(writer.Stdout()), logm.WithWriter(ring))
+//	mux.Handle("/admin/logm/", http.StripPrefix("/admin/logm", admin.Handler(admin.WithRecent(ring))))
+func WithRecent(ring *writer.RingWriter) Option {
+	return func(o *options) {
+		o.ring = ring
+	}
+}
+
+// recent 返回环形缓冲中最近的匹配日志
+//
+// 查询参数：n 为最多返回的条数（默认 100），level 和 attr 与 /logs/stream 相同。
+// 响应为 JSON 数组，按时间从旧到新排列，每条日志统一转换为 JSON 格式化器的输出。
+func (a *api) recent(w http.ResponseWriter, r *http.Request) {
+	if a.ring == nil {
+		writeError(w, http.StatusNotFound, errors.New("recent logs not enabled, see admin.WithRecent"))
+		return
+	}
+	filter, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	n := defaultRecent
+	if s := r.URL.Query().Get("n"); s != "" {
+		if n, err = strconv.Atoi(s); err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid n %q, want a positive integer", s))
+			return
+		}
+	}
+
+	// 从新到旧扫描，凑够 n 条即停止
+	f := formatter.JSON()
+	var out [][]byte
+	lines := a.ring.Records()
+	for i := len(lines) - 1; i >= 0 && len(out) < n; i-- {
+		e, err := formatter.ParseLine(lines[i])
+		if err != nil {
+			continue
+		}
+		rec := e.Record()
+		if !filter.match(rec) {
+			continue
+		}
+		data, err := f.Format(rec)
+		if err != nil {
+			continue
+		}
+		out = append(out, bytes.TrimRight(data, "\n"))
+	}
+	slices.Reverse(out)
+
+	w.Header().Set("Content-Type", "application/json")
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, data := range out {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		buf.Write(data)
+	}
+	buf.WriteString("]\n")
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecent(t *testing.T) {
+	for _, f := range []formatter.Formatter{formatter.JSON(), formatter.Text()} {
+		ring := writer.Ring(100)
+		c := logm.NewHandler(&logm.HandlerConfig{
+			LevelVar:  &slog.LevelVar{},
+			Formatter: f,
+			Writers:   []logm.Writer{ring},
+		})
+		logger := slog.New(c)
+		for i := range 5 {
+			logger.Error("boom", "i", i, "svc", "api")
+			logger.Info("fine", "i", i)
+		}
+		logger.Error("other", "svc", "db")
+		h := Handler(WithControl(c), WithRecent(ring))
+
+		rec := serve(h, http.MethodGet, "/logs/recent?level=error&n=3&attr=svc=api", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var got []map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got), rec.Body.String())
+		require.Len(t, got, 3)
+		for k, want := range []string{"2", "3", "4"} {
+			assert.Equal(t, "boom", got[k]["msg"])
+			assert.Equal(t, "ERROR", got[k]["level"])
+			assert.Equal(t, want, fmt.Sprint(got[k]["i"]))
+		}
+
+		// 默认返回全部（不超过 100 条）
+		rec = serve(h, http.MethodGet, "/logs/recent", "")
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Len(t, got, 11)
+		assert.Equal(t, "other", got[10]["msg"])
+		_ = c.Close()
+	}
+}
+
+func TestRecent_Errors(t *testing.T) {
+	h := Handler(WithControl(newControl(t)))
+	assert.Equal(t, http.StatusNotFound, serve(h, http.MethodGet, "/logs/recent", "").Code)
+
+	h = Handler(WithControl(newControl(t)), WithRecent(writer.Ring(10)))
+	rec := serve(h, http.MethodGet, "/logs/recent", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, "[]", rec.Body.String())
+	assert.Equal(t, http.StatusBadRequest, serve(h, http.MethodGet, "/logs/recent?n=0", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(h, http.MethodGet, "/logs/recent?n=x", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(h, http.MethodGet, "/logs/recent?level=x", "").Code)
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// ErrInvalidArgument 请求参数无效，gRPC 适配层应映射为 codes.InvalidArgument
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// Service 与传输无关的管理服务，对应 adminpb/admin.proto 中的 LogmAdmin。
+//
+// 本模块不依赖 gRPC，独立的 adminpb 模块提供生成代码和注册函数：
+//
+//	srv := grpc.NewServer()
+//	adminpb.RegisterAdminServer(srv, admin.NewService(nil))
+//
+// HTTP 管理接口使用同一个 Service，两种入口的行为一致。
+type Service struct {
+	control Control
+}
+
+// NewService 创建管理服务，c 为 nil 时使用 Global()。
+func NewService(c Control) *Service {
+	if c == nil {
+		c = Global()
+	}
+	return &Service{control: c}
+}
+
+// Level 返回当前级别名称。
+func (s *Service) Level(_ context.Context) string {
+	return formatter.LevelName(s.control.Level())
+}
+
+// SetLevel 按名称设置级别，返回设置后和设置前的级别名称。
+// 名称无法识别时返回包装 ErrInvalidArgument 的错误，级别不变。
+func (s *Service) SetLevel(_ context.Context, name string) (level, previous string, err error) {
+	l, ok := formatter.ParseLevelName(name)
+	if !ok {
+		return "", "", fmt.Errorf("%w: unknown level %q (use DEBUG, INFO, WARN or ERROR)", ErrInvalidArgument, name)
+	}
+	prev := s.control.Level()
+	s.control.SetLevel(l)
+	return formatter.LevelName(l), formatter.LevelName(prev), nil
+}
+
+// GetConfig 返回生效配置。
+func (s *Service) GetConfig(_ context.Context) logm.ConfigInfo {
+	return s.control.Config()
+}
+
+// Stats 返回运行统计和 Writer 健康状态。
+func (s *Service) Stats(_ context.Context) (logm.HandlerStats, []logm.WriterStatus) {
+	return s.control.Stats(), s.control.Status()
+}
+
+// Flush 刷新所有 Writer 缓冲区。
+func (s *Service) Flush(_ context.Context) error {
+	return s.control.Sync()
+}
+
+// Rotate 轮转所有文件 Writer。
+func (s *Service) Rotate(_ context.Context) error {
+	return s.control.Rotate()
+}
@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_SetLevel(t *testing.T) {
+	c := newControl(t)
+	s := NewService(c)
+	ctx := context.Background()
+
+	level, prev, err := s.SetLevel(ctx, "warn")
+	require.NoError(t, err)
+	assert.Equal(t, "WARN", level)
+	assert.Equal(t, "INFO", prev)
+	assert.Equal(t, slog.LevelWarn, c.Level())
+	assert.Equal(t, "WARN", s.Level(ctx))
+
+	_, _, err = s.SetLevel(ctx, "loud")
+	require.ErrorIs(t, err, ErrInvalidArgument)
+	assert.Equal(t, slog.LevelWarn, c.Level())
+}
+
+func TestService_Control(t *testing.T) {
+	st := &stubControl{Handler: newControl(t)}
+	s := NewService(st)
+	ctx := context.Background()
+
+	assert.Equal(t, st.Config(), s.GetConfig(ctx))
+	require.NoError(t, s.Flush(ctx))
+	require.NoError(t, s.Rotate(ctx))
+	assert.Equal(t, 1, st.synced)
+	assert.Equal(t, 1, st.rotated)
+
+	stats, status := s.Stats(ctx)
+	assert.Zero(t, stats.Records["INFO"])
+	assert.Len(t, status, 1)
+}
+
+func TestNewService_Global(t *testing.T) {
+	assert.Equal(t, Global(), NewService(nil).control)
+}
+
+// TestProto_GetConfigResponseMatchesConfigInfo 保证 admin.proto 的 GetConfigResponse
+// 与 logm.ConfigInfo 的字段按顺序一一对应，ConfigInfo 增减字段时提醒同步修改 proto
+func TestProto_GetConfigResponseMatchesConfigInfo(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("adminpb", "admin.proto"))
+	require.NoError(t, err)
+	body := regexp.MustCompile(`(?s)message GetConfigResponse \{(.*?)\n\}`).FindSubmatch(data)
+	require.NotNil(t, body, "GetConfigResponse not found in admin.proto")
+
+	var got []string
+	for _, m := range regexp.MustCompile(`(?m)^\s*((?:repeated )?\w+) (\w+) = (\d+);`).FindAllSubmatch(body[1], -1) {
+		assert.Equal(t, strconv.Itoa(len(got)+1), string(m[3]), "field %s is not numbered in order", m[2])
+		got = append(got, string(m[1])+" "+string(m[2]))
+	}
+
+	protoTypes := map[reflect.Kind]string{
+		reflect.String: "string",
+		reflect.Bool:   "bool",
+		reflect.Int:    "int32|int64",
+	}
+	typ := reflect.TypeFor[logm.ConfigInfo]()
+	require.Len(t, got, typ.NumField())
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		kind, prefix := field.Type.Kind(), ""
+		if kind == reflect.Slice {
+			kind, prefix = field.Type.Elem().Kind(), "repeated "
+		}
+		want, ok := protoTypes[kind]
+		require.True(t, ok, "unsupported ConfigInfo field type %s", field.Type)
+		pattern := "^" + prefix + "(" + want + ") " + snakeCase(field.Name) + "$"
+		assert.Regexp(t, pattern, got[i], "ConfigInfo.%s", field.Name)
+	}
+}
+
+// snakeCase 将 Go 字段名转换为 proto 字段名，如 MaxRecordSize 转换为 max_record_size
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+const (
+	// streamBuffer 每个连接缓冲的日志条数，客户端跟不上时丢弃新日志
+	streamBuffer = 256
+	// streamPing 心跳间隔，防止代理因空闲断开连接
+	streamPing = 15 * time.Second
+)
+
+// recordFilter 按级别和属性过滤日志
+type recordFilter struct {
+	level slog.Level
+	attrs []attrMatch
+}
+
+// attrMatch 属性条件，key 为点分路径，如 http.status
+type attrMatch struct {
+	key   string
+	value string
+}
+
+// parseFilter 解析 ?level=WARN&attr=key=value 查询参数，attr 可重复，条件之间为“且”
+func parseFilter(r *http.Request) (recordFilter, error) {
+	q := r.URL.Query()
+	f := recordFilter{level: slog.LevelDebug - 4}
+	if name := q.Get("level"); name != "" {
+		level, ok := formatter.ParseLevelName(name)
+		if !ok {
+			return f, fmt.Errorf("unknown level %q (use DEBUG, INFO, WARN or ERROR)", name)
+		}
+		f.level = level
+	}
+	for _, s := range q["attr"] {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return f, fmt.Errorf("invalid attr filter %q, want key=value", s)
+		}
+		f.attrs = append(f.attrs, attrMatch{key: key, value: value})
+	}
+	return f, nil
+}
+
+// match 判断日志是否满足所有条件
+func (f recordFilter) match(r *logm.Record) bool {
+	if r.Level < f.level {
+		return false
+	}
+	for _, m := range f.attrs {
+		v, ok := lookup(r, m.key)
+		if !ok || v.String() != m.value {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup 按点分路径查找属性，路径包含记录所在的分组
+func lookup(r *logm.Record, path string) (slog.Value, bool) {
+	for _, g := range r.Groups {
+		rest, ok := strings.CutPrefix(path, g+".")
+		if !ok {
+			return slog.Value{}, false
+		}
+		path = rest
+	}
+	attrs := r.Attrs
+	for {
+		key, rest, nested := strings.Cut(path, ".")
+		found := false
+		for _, a := range attrs {
+			if a.Key != key {
+				continue
+			}
+			v := a.Value.Resolve()
+			if !nested {
+				return v, true
+			}
+			if v.Kind() == slog.KindGroup {
+				attrs, path, found = v.Group(), rest, true
+			}
+			break
+		}
+		if !found {
+			return slog.Value{}, false
+		}
+	}
+}
+
+// stream 以 Server-Sent Events 推送实时日志
+//
+// 每条日志为一个 data 事件，内容为单行 JSON；缓冲区满时丢弃日志，
+// 之后发送 dropped 事件告知丢弃数。
+func (a *api) stream(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if a.maxStreams > 0 && a.streams.Add(1) > int64(a.maxStreams) {
+		a.streams.Add(-1)
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("too many streams (max %d)", a.maxStreams))
+		return
+	}
+	defer a.streams.Add(-1)
+
+	rc := http.NewResponseController(w)
+	ch := make(chan []byte, streamBuffer)
+	var dropped atomic.Uint64
+	f := formatter.JSON()
+	cancel := a.svc.control.Observe(func(rec logm.Record) {
+		if !filter.match(&rec) {
+			return
+		}
+		data, err := f.Format(&rec)
+		if err != nil {
+			return
+		}
+		select {
+		case ch <- bytes.TrimRight(data, "\n"):
+		default:
+			dropped.Add(1)
+		}
+	})
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if rc.Flush() != nil {
+		return
+	}
+
+	ping := time.NewTicker(streamPing)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-ch:
+			if n := dropped.Swap(0); n > 0 {
+				_, err = fmt.Fprintf(w, "event: dropped\ndata: {\"dropped\":%d}\n\n", n)
+			}
+			if err == nil {
+				_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+			}
+		case <-ping.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		}
+		if err != nil || rc.Flush() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openStream 连接 /logs/stream，返回逐行读取的 Scanner，连接在测试结束时关闭
+func openStream(t *testing.T, srv *httptest.Server, query string) *bufio.Scanner {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/logs/stream"+query, nil)
+	require.NoError(t, err)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return bufio.NewScanner(resp.Body)
+}
+
+// nextData 读取下一个 data 事件
+func nextData(t *testing.T, sc *bufio.Scanner) map[string]any {
+	t.Helper()
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(data), &m), data)
+		return m
+	}
+	t.Fatalf("stream closed: %v", sc.Err())
+	return nil
+}
+
+func TestStream_Filter(t *testing.T) {
+	c := newControl(t)
+	c.SetLevel(slog.LevelDebug)
+	srv := httptest.NewServer(Handler(WithControl(c)))
+	t.Cleanup(srv.Close)
+
+	sc := openStream(t, srv, "?level=warn&attr=http.status=500")
+	logger := slog.New(c)
+	logger.Error("wrong status", slog.Group("http", slog.Int("status", 404)))
+	logger.Info("low level", slog.Group("http", slog.Int("status", 500)))
+	logger.Warn("match", slog.Group("http", slog.Int("status", 500)), "user", "alice")
+
+	m := nextData(t, sc)
+	assert.Equal(t, "match", m["msg"])
+	assert.Equal(t, "alice", m["user"])
+
+	// 分组 logger 的属性路径包含分组名
+	sc = openStream(t, srv, "?attr=req.id=42")
+	logger.WithGroup("req").Info("grouped", "id", 42)
+	assert.Equal(t, "grouped", nextData(t, sc)["msg"])
+}
+
+func TestStream_BadRequest(t *testing.T) {
+	h := Handler(WithControl(newControl(t)))
+	assert.Equal(t, http.StatusBadRequest, serve(h, http.MethodGet, "/logs/stream?level=loud", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(h, http.MethodGet, "/logs/stream?attr=novalue", "").Code)
+}
+
+func TestStream_MaxStreams(t *testing.T) {
+	srv := httptest.NewServer(Handler(WithControl(newControl(t)), WithMaxStreams(1)))
+	t.Cleanup(srv.Close)
+
+	openStream(t, srv, "")
+	resp, err := srv.Client().Get(srv.URL + "/logs/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestStream_Unsubscribe(t *testing.T) {
+	c := &countingControl{Handler: newControl(t)}
+	srv := httptest.NewServer(Handler(WithControl(c)))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/logs/stream", nil)
+	require.NoError(t, err)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.active())
+
+	cancel()
+	_ = resp.Body.Close()
+	assert.Eventually(t, func() bool { return c.active() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestLookup(t *testing.T) {
+	r := &logm.Record{
+		Attrs: []slog.Attr{
+			slog.String("user", "alice"),
+			slog.Group("http", slog.Int("status", 200), slog.Group("req", slog.String("method", "GET"))),
+		},
+	}
+	v, ok := lookup(r, "http.req.method")
+	require.True(t, ok)
+	assert.Equal(t, "GET", v.String())
+	_, ok = lookup(r, "http.missing")
+	assert.False(t, ok)
+	_, ok = lookup(r, "user.name")
+	assert.False(t, ok)
+}
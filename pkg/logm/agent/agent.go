@@ -0,0 +1,456 @@
+// Package agent 提供轻量的日志转发代理。
+//
+// Agent 跟踪其他进程写入的日志文件，将新增的行通过 logm 的 Writer 转发出去，
+// 没有部署 sidecar 的主机也能用同一套输出目标采集遗留应用的日志：
+//
+//	a, err := agent.New(collector,
+//	    agent.WithFiles("/var/log/legacy/*.log"),
+//	    agent.WithCheckpoint("/var/lib/logm/agent.json"),
+//	)
+//	if err != nil {
+//	    return err
+//	}
+//	err = a.Run(ctx)
+//
+// 读取位置定期保存到检查点文件，重启后从上次的位置继续，支持按改名轮转（rename）
+// 和原地截断轮转（copytruncate）。只转发以换行结尾的完整行，写入失败的行在下一轮重试，
+// 投递语义为至少一次。
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// DefaultPollInterval 默认的轮询间隔
+const DefaultPollInterval = time.Second
+
+// 文件指纹和读取的参数
+const (
+	fingerprintSize = 1024
+	readChunkSize   = 64 * 1024
+	maxLineSize     = 1024 * 1024 // 超过后强制按行转发，避免无换行的文件占满内存
+)
+
+// Agent 日志转发代理，跟踪匹配的文件并转发新增的行。
+type Agent struct {
+	target     writer.Writer
+	patterns   []string
+	checkpoint string
+	interval   time.Duration
+	startAtEnd bool
+	tailLines  int
+	parseLevel bool
+	started    bool
+
+	files     map[string]*tailFile // 按路径索引，仅 Run 的协程访问
+	forwarded atomic.Uint64
+	failures  atomic.Uint64
+}
+
+// SourceWriter 需要区分行来源的 target 实现该接口，Agent 转发时调用 WriteSource，
+// 传入行所在文件的路径，不再调用 Write 或 WriteLevel。
+type SourceWriter interface {
+	WriteSource(path string, level slog.Level, line []byte) (n int, err error)
+}
+
+// Option Agent 配置选项
+type Option func(*Agent)
+
+// WithFiles 设置跟踪的文件，支持 filepath.Glob 模式，每轮轮询重新匹配以发现新文件。
+//
+// 模式不应匹配轮转后的备份名（如 app.log.1），否则备份会作为新文件被再次转发。
+func WithFiles(patterns ...string) Option {
+	return func(a *Agent) {
+		a.patterns = append(a.patterns, patterns...)
+	}
+}
+
+// WithCheckpoint 设置保存读取位置的检查点文件，为空时不保存，重启后按 WithStartAtEnd 处理。
+func WithCheckpoint(path string) Option {
+	return func(a *Agent) {
+		a.checkpoint = path
+	}
+}
+
+// WithPollInterval 设置轮询间隔，默认 DefaultPollInterval。
+func WithPollInterval(d time.Duration) Option {
+	return func(a *Agent) {
+		if d > 0 {
+			a.interval = d
+		}
+	}
+}
+
+// WithStartAtEnd 没有检查点记录的文件从末尾开始跟踪，默认从头读取。
+//
+// 首次部署到已有大量历史日志的主机时可以避免重复转发旧日志；
+// 启动后新出现的文件总是从头读取。
+func WithStartAtEnd() Option {
+	return func(a *Agent) {
+		a.startAtEnd = true
+	}
+}
+
+// WithTailLines 没有检查点记录的文件从倒数第 n 行开始跟踪，n 为 0 时等同于 WithStartAtEnd。
+//
+// 与 tail -n 相同，用于先显示最近的若干行再持续跟踪。
+func WithTailLines(n int) Option {
+	return func(a *Agent) {
+		a.startAtEnd = true
+		a.tailLines = max(n, 0)
+	}
+}
+
+// WithRawLevel 不解析日志行，全部以 INFO 级别写入 target。
+//
+// 默认通过 formatter.ParseLine 识别 logm 格式的级别，供 PerLevel 等按级别路由的 Writer 使用。
+func WithRawLevel() Option {
+	return func(a *Agent) {
+		a.parseLevel = false
+	}
+}
+
+// New 创建 Agent，加载检查点。至少需要一个 WithFiles 模式。
+func New(target writer.Writer, opts ...Option) (*Agent, error) {
+	a := &Agent{
+		target:     target,
+		interval:   DefaultPollInterval,
+		parseLevel: true,
+		files:      make(map[string]*tailFile),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if len(a.patterns) == 0 {
+		return nil, errors.New("agent: no files to follow")
+	}
+	for _, p := range a.patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("agent: bad pattern %q: %w", p, err)
+		}
+	}
+	if err := a.loadCheckpoint(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Run 持续跟踪文件直到 ctx 结束，返回前保存检查点并关闭打开的文件，不关闭 target。
+func (a *Agent) Run(ctx context.Context) error {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	defer a.closeFiles()
+
+	for {
+		a.Poll(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Poll 执行一轮匹配和读取并保存检查点，返回时本轮读到的完整行都已写入 target。
+//
+// 供需要在每轮之后处理输出的调用方自行控制轮询节奏，如按时间合并多个文件的输出；
+// 不能与 Run 同时调用，结束后调用 Close 关闭打开的文件。
+func (a *Agent) Poll(ctx context.Context) {
+	a.poll(ctx, !a.started)
+	a.started = true
+	a.saveCheckpoint()
+}
+
+// Close 关闭打开的文件，不关闭 target。Run 返回前会自动关闭。
+func (a *Agent) Close() error {
+	a.closeFiles()
+	return nil
+}
+
+// Forwarded 返回转发成功的行数。
+func (a *Agent) Forwarded() uint64 {
+	return a.forwarded.Load()
+}
+
+// Failures 返回转发失败的次数，失败的行在下一轮重试。
+func (a *Agent) Failures() uint64 {
+	return a.failures.Load()
+}
+
+// tailFile 一个被跟踪的文件
+type tailFile struct {
+	path        string
+	f           *os.File
+	info        fs.FileInfo // 打开的文件信息，用于发现改名轮转
+	offset      int64       // 已转发的位置
+	fingerprint uint32      // 文件开头 fpLen 字节的 CRC32，用于重启后识别同一文件
+	fpLen       int
+	partial     []byte // 尚未以换行结尾的内容
+}
+
+// poll 匹配文件并读取新增内容
+func (a *Agent) poll(ctx context.Context, first bool) {
+	seen := make(map[string]bool)
+	for _, pattern := range a.patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			if ctx.Err() != nil {
+				return
+			}
+			a.follow(path, first)
+		}
+	}
+
+	// 文件被删除或不再匹配时，读完已打开的句柄后停止跟踪
+	for path, t := range a.files {
+		if !seen[path] {
+			if t.f != nil {
+				a.drain(t)
+				_ = t.f.Close()
+			}
+			delete(a.files, path)
+		}
+	}
+}
+
+// follow 处理单个文件的轮转并读取新增内容
+func (a *Agent) follow(path string, first bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+
+	t := a.files[path]
+	fresh := false // 没有检查点记录、按 WithStartAtEnd 定位的文件
+	if t == nil {
+		t = &tailFile{path: path}
+		a.files[path] = t
+		if a.startAtEnd && first {
+			t.offset, fresh = info.Size(), true
+		}
+	}
+
+	if t.f != nil && !os.SameFile(t.info, info) {
+		// 改名轮转：读完旧文件剩余内容后切换到新文件
+		a.drain(t)
+		_ = t.f.Close()
+		*t = tailFile{path: path}
+	}
+
+	if t.f == nil {
+		f, err := os.Open(path)
+		if err != nil {
+			selflog.Printf("agent", "open %s: %v", path, err)
+			return
+		}
+		t.f, t.info = f, info
+		if fresh && a.tailLines > 0 {
+			t.offset = lastLinesOffset(f, info.Size(), a.tailLines)
+		}
+		if !t.resume(info.Size()) {
+			// 检查点记录的是已轮转走的文件
+			t.offset, t.fingerprint, t.fpLen = 0, 0, 0
+		}
+	}
+
+	if info.Size() < t.offset {
+		// 原地截断轮转
+		t.offset, t.partial, t.fpLen = 0, nil, 0
+	}
+	a.drain(t)
+}
+
+// lastLinesOffset 返回 f 中倒数第 n 个完整行的起始位置，不足 n 行时返回 0
+func lastLinesOffset(f *os.File, size int64, n int) int64 {
+	buf := make([]byte, readChunkSize)
+	end := size
+	// 最后一个字节是换行时不计入，未写完的行从其开头读取
+	if end > 0 {
+		if _, err := f.ReadAt(buf[:1], end-1); err == nil && buf[0] == '\n' {
+			end--
+		}
+	}
+	for pos := end; pos > 0; {
+		chunk := min(pos, int64(len(buf)))
+		pos -= chunk
+		if _, err := f.ReadAt(buf[:chunk], pos); err != nil {
+			return 0
+		}
+		for i := chunk - 1; i >= 0; i-- {
+			if buf[i] != '\n' {
+				continue
+			}
+			if n--; n == 0 {
+				return pos + i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// resume 校验检查点记录的文件是否仍是当前文件
+func (t *tailFile) resume(size int64) bool {
+	if t.offset == 0 {
+		return true
+	}
+	if size < t.offset {
+		return false
+	}
+	if t.fpLen == 0 {
+		// 从末尾开始跟踪的新文件
+		return true
+	}
+	fp, n := t.readFingerprint(t.fpLen)
+	return n == t.fpLen && fp == t.fingerprint
+}
+
+// readFingerprint 计算文件开头 n 字节的 CRC32
+func (t *tailFile) readFingerprint(n int) (uint32, int) {
+	buf := make([]byte, n)
+	read, _ := t.f.ReadAt(buf, 0)
+	return crc32.ChecksumIEEE(buf[:read]), read
+}
+
+// drain 读取并转发 t 中所有完整的新行，写入失败时停止并保留位置
+func (a *Agent) drain(t *tailFile) {
+	buf := make([]byte, readChunkSize)
+	for {
+		n, err := t.f.ReadAt(buf, t.offset+int64(len(t.partial)))
+		if n > 0 {
+			t.partial = append(t.partial, buf[:n]...)
+			if !a.forward(t) {
+				return
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				selflog.Printf("agent", "read %s: %v", t.path, err)
+			}
+			return
+		}
+	}
+}
+
+// forward 转发 t.partial 中的完整行并推进位置，写入失败时返回 false
+func (a *Agent) forward(t *tailFile) bool {
+	for {
+		i := bytes.IndexByte(t.partial, '\n')
+		if i < 0 {
+			if len(t.partial) < maxLineSize {
+				return true
+			}
+			i = len(t.partial) - 1
+		}
+		line := t.partial[:i+1]
+
+		level := slog.LevelInfo
+		if a.parseLevel {
+			if e, err := formatter.ParseLine(line); err == nil {
+				level = e.Level
+			}
+		}
+		if err := a.write(t.path, level, line); err != nil {
+			a.failures.Add(1)
+			selflog.Printf("agent", "forward %s: %v", t.path, err)
+			t.partial = nil // 下一轮从 offset 重新读取
+			return false
+		}
+		a.forwarded.Add(1)
+
+		t.offset += int64(len(line))
+		t.partial = t.partial[len(line):]
+		if t.fpLen < fingerprintSize {
+			t.fingerprint, t.fpLen = t.readFingerprint(int(min(t.offset, fingerprintSize)))
+		}
+	}
+}
+
+// write 将一行写入 target，target 实现 SourceWriter 时附带来源路径
+func (a *Agent) write(path string, level slog.Level, line []byte) error {
+	var err error
+	if sw, ok := a.target.(SourceWriter); ok {
+		_, err = sw.WriteSource(path, level, line)
+	} else {
+		_, err = writer.WriteLevel(a.target, level, line)
+	}
+	return err
+}
+
+// closeFiles 关闭所有打开的文件
+func (a *Agent) closeFiles() {
+	for _, t := range a.files {
+		if t.f != nil {
+			_ = t.f.Close()
+			t.f = nil
+		}
+	}
+}
+
+// checkpointEntry 检查点中一个文件的记录
+type checkpointEntry struct {
+	Offset      int64  `json:"offset"`
+	Fingerprint uint32 `json:"fingerprint"`
+	FPLen       int    `json:"fp_len"`
+}
+
+// loadCheckpoint 从检查点文件恢复读取位置
+func (a *Agent) loadCheckpoint() error {
+	if a.checkpoint == "" {
+		return nil
+	}
+	data, err := os.ReadFile(a.checkpoint)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("agent: read checkpoint: %w", err)
+	}
+	var entries map[string]checkpointEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("agent: read checkpoint: %w", err)
+	}
+	for path, e := range entries {
+		a.files[path] = &tailFile{path: path, offset: e.Offset, fingerprint: e.Fingerprint, fpLen: e.FPLen}
+	}
+	return nil
+}
+
+// saveCheckpoint 原子地保存读取位置
+func (a *Agent) saveCheckpoint() {
+	if a.checkpoint == "" {
+		return
+	}
+	entries := make(map[string]checkpointEntry, len(a.files))
+	for path, t := range a.files {
+		entries[path] = checkpointEntry{Offset: t.offset, Fingerprint: t.fingerprint, FPLen: t.fpLen}
+	}
+	data, _ := json.Marshal(entries)
+	tmp := a.checkpoint + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		selflog.Printf("agent", "save checkpoint: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, a.checkpoint); err != nil {
+		selflog.Printf("agent", "save checkpoint: %v", err)
+	}
+}
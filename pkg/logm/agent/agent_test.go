@@ -0,0 +1,264 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder 记录转发的行，fail 为 true 时写入失败
+type recorder struct {
+	mu    sync.Mutex
+	lines []string
+	fail  bool
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	return r.WriteLevel(slog.LevelInfo, p)
+}
+
+func (r *recorder) WriteLevel(level slog.Level, p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail {
+		return 0, errors.New("collector unavailable")
+	}
+	r.lines = append(r.lines, level.String()+":"+string(p))
+	return len(p), nil
+}
+
+func (r *recorder) Close() error { return nil }
+func (r *recorder) Sync() error  { return nil }
+
+func (r *recorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := r.lines
+	r.lines = nil
+	return lines
+}
+
+func appendFile(t *testing.T, path, s string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(s)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestAgent_Follow(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, `{"level":"ERROR","msg":"boom"}`+"\nplain line\npart")
+
+	rec := &recorder{}
+	a, err := New(rec, WithFiles(filepath.Join(dir, "*.log")))
+	require.NoError(t, err)
+
+	a.poll(t.Context(), true)
+	assert.Equal(t, []string{`ERROR:{"level":"ERROR","msg":"boom"}` + "\n", "INFO:plain line\n"}, rec.take())
+
+	// 不完整的行等到换行写入后再转发
+	appendFile(t, path, "ial\n")
+	a.poll(t.Context(), false)
+	assert.Equal(t, []string{"INFO:partial\n"}, rec.take())
+	assert.Equal(t, uint64(3), a.Forwarded())
+	a.closeFiles()
+}
+
+// sourceRecorder 按来源文件记录转发的行
+type sourceRecorder struct {
+	recorder
+	sources []string
+}
+
+func (r *sourceRecorder) WriteSource(path string, level slog.Level, p []byte) (int, error) {
+	r.sources = append(r.sources, filepath.Base(path))
+	return r.WriteLevel(level, p)
+}
+
+func TestAgent_SourceWriter(t *testing.T) {
+	dir := t.TempDir()
+	appendFile(t, filepath.Join(dir, "a.log"), "a1\na2\n")
+	appendFile(t, filepath.Join(dir, "b.log"), "b1\n")
+
+	rec := &sourceRecorder{}
+	a, err := New(rec, WithFiles(filepath.Join(dir, "*.log")))
+	require.NoError(t, err)
+
+	a.poll(t.Context(), true)
+	assert.Equal(t, []string{"INFO:a1\n", "INFO:a2\n", "INFO:b1\n"}, rec.take())
+	assert.Equal(t, []string{"a.log", "a.log", "b.log"}, rec.sources)
+}
+
+func TestAgent_Checkpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	cp := filepath.Join(dir, "agent.json")
+	appendFile(t, path, "a\nb\n")
+
+	rec := &recorder{}
+	a, err := New(rec, WithFiles(path), WithCheckpoint(cp))
+	require.NoError(t, err)
+	a.poll(t.Context(), true)
+	a.saveCheckpoint()
+	a.closeFiles()
+	assert.Len(t, rec.take(), 2)
+
+	// 重启后从检查点继续
+	appendFile(t, path, "c\n")
+	a, err = New(rec, WithFiles(path), WithCheckpoint(cp))
+	require.NoError(t, err)
+	a.poll(t.Context(), true)
+	a.closeFiles()
+	assert.Equal(t, []string{"INFO:c\n"}, rec.take())
+
+	// 检查点之后文件被替换为内容不同的新文件，从头读取
+	require.NoError(t, os.Remove(path))
+	appendFile(t, path, "new file longer than before\n")
+	a, err = New(rec, WithFiles(path), WithCheckpoint(cp))
+	require.NoError(t, err)
+	a.poll(t.Context(), true)
+	a.closeFiles()
+	assert.Equal(t, []string{"INFO:new file longer than before\n"}, rec.take())
+}
+
+func TestAgent_RenameRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "1\n")
+
+	rec := &recorder{}
+	a, err := New(rec, WithFiles(path))
+	require.NoError(t, err)
+	defer a.closeFiles()
+	a.poll(t.Context(), true)
+
+	// 轮转前写入的最后几行在切换到新文件前读完
+	appendFile(t, path, "2\n")
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendFile(t, path, "3\n")
+	a.poll(t.Context(), false)
+	assert.Equal(t, []string{"INFO:1\n", "INFO:2\n", "INFO:3\n"}, rec.take())
+}
+
+func TestAgent_CopyTruncate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "old line one\nold line two\n")
+
+	rec := &recorder{}
+	a, err := New(rec, WithFiles(path))
+	require.NoError(t, err)
+	defer a.closeFiles()
+	a.poll(t.Context(), true)
+	rec.take()
+
+	require.NoError(t, os.Truncate(path, 0))
+	appendFile(t, path, "new\n")
+	a.poll(t.Context(), false)
+	assert.Equal(t, []string{"INFO:new\n"}, rec.take())
+}
+
+func TestAgent_RetryAndStartAtEnd(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "history\n")
+
+	rec := &recorder{fail: true}
+	a, err := New(rec, WithFiles(path), WithStartAtEnd(), WithRawLevel())
+	require.NoError(t, err)
+	defer a.closeFiles()
+	a.poll(t.Context(), true)
+
+	appendFile(t, path, `{"level":"WARN","msg":"x"}`+"\n")
+	a.poll(t.Context(), false)
+	assert.Equal(t, uint64(1), a.Failures())
+
+	rec.mu.Lock()
+	rec.fail = false
+	rec.mu.Unlock()
+	a.poll(t.Context(), false)
+	assert.Equal(t, []string{`INFO:{"level":"WARN","msg":"x"}` + "\n"}, rec.take())
+}
+
+func TestAgent_Run(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	cp := filepath.Join(dir, "agent.json")
+	appendFile(t, path, "a\n")
+
+	rec := &recorder{}
+	a, err := New(rec, WithFiles(path), WithCheckpoint(cp), WithPollInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() { done <- a.Run(ctx) }()
+
+	appendFile(t, path, "b\n")
+	assert.Eventually(t, func() bool { return a.Forwarded() == 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	assert.FileExists(t, cp)
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := New(&recorder{})
+	require.Error(t, err)
+
+	_, err = New(&recorder{}, WithFiles("[bad"))
+	require.Error(t, err)
+
+	cp := filepath.Join(t.TempDir(), "agent.json")
+	require.NoError(t, os.WriteFile(cp, []byte("not json"), 0o600))
+	_, err = New(&recorder{}, WithFiles("*.log"), WithCheckpoint(cp))
+	require.Error(t, err)
+}
+
+func TestAgent_TailLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "1\n2\n3\n4\n")
+	short := filepath.Join(dir, "short.log")
+	appendFile(t, short, "only\n")
+
+	rec := &recorder{}
+	a, err := New(rec, WithFiles(path, short), WithTailLines(2))
+	require.NoError(t, err)
+	defer a.Close()
+
+	a.Poll(t.Context())
+	assert.Equal(t, []string{"INFO:3\n", "INFO:4\n", "INFO:only\n"}, rec.take())
+
+	appendFile(t, path, "5\n")
+	a.Poll(t.Context())
+	assert.Equal(t, []string{"INFO:5\n"}, rec.take())
+}
+
+func TestLastLinesOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	content := strings.Repeat("x", readChunkSize) + "\na\nb\nc"
+	appendFile(t, path, content)
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	size := int64(len(content))
+	assert.Equal(t, size-len64("b\nc"), lastLinesOffset(f, size, 2))
+	assert.Equal(t, int64(0), lastLinesOffset(f, size, 4))
+	assert.Equal(t, int64(0), lastLinesOffset(f, size, 100))
+	assert.Equal(t, size-1-len64("a\nb\n"), lastLinesOffset(f, size-1, 2))
+}
+
+func len64(s string) int64 { return int64(len(s)) }
@@ -0,0 +1,285 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+const (
+	// DefaultAlertWindow StartAlert 的默认滑动窗口
+	DefaultAlertWindow = time.Minute
+	// DefaultAlertThreshold StartAlert 的默认阈值
+	DefaultAlertThreshold = 10
+	// DefaultAlertCooldown StartAlert 的默认冷却时间
+	DefaultAlertCooldown = 5 * time.Minute
+)
+
+// alertBuckets 滑动窗口的分桶数
+const alertBuckets = 60
+
+// AlertEvent 告警状态变化
+type AlertEvent struct {
+	// Firing 为 true 表示超过阈值，false 表示恢复
+	Firing bool `json:"firing"`
+	// Count 窗口内匹配的日志条数
+	Count int `json:"count"`
+	// Threshold 触发阈值
+	Threshold int `json:"threshold"`
+	// Window 滑动窗口
+	Window time.Duration `json:"window"`
+	// Level 匹配的最低级别
+	Level string `json:"level"`
+	// Message 最近一条匹配日志的消息
+	Message string `json:"message,omitempty"`
+	// Time 状态变化的时间
+	Time time.Time `json:"time"`
+}
+
+// AlertFunc 接收告警状态变化，在后台 goroutine 中调用。
+type AlertFunc func(e AlertEvent)
+
+// AlertWebhook 返回以 JSON POST 告警事件到 url 的 AlertFunc，请求失败通过 logm 自诊断输出报告。
+//
+// opts 配置 HTTP 客户端，如 WithTLS。
+func AlertWebhook(url string, opts ...HTTPOption) AlertFunc {
+	client := httpClient(opts)
+	return func(e AlertEvent) {
+		data, _ := json.Marshal(e)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			selflog.Printf("alert", "alert webhook: %v", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			selflog.Printf("alert", "alert webhook: %v", err)
+			return
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= http.StatusMultipleChoices {
+			selflog.Printf("alert", "alert webhook: unexpected status %s", resp.Status)
+		}
+	}
+}
+
+// AlertOption 告警配置选项
+type AlertOption func(*alerter)
+
+// WithAlertLevel 设置计入的最低级别，默认 ERROR。
+func WithAlertLevel(level slog.Level) AlertOption {
+	return func(a *alerter) {
+		a.level = level
+	}
+}
+
+// WithAlertWindow 设置滑动窗口，默认 DefaultAlertWindow。
+func WithAlertWindow(window time.Duration) AlertOption {
+	return func(a *alerter) {
+		if window > 0 {
+			a.window = window
+		}
+	}
+}
+
+// WithAlertThreshold 设置阈值，窗口内匹配的日志达到 n 条时触发，默认 DefaultAlertThreshold。
+func WithAlertThreshold(n int) AlertOption {
+	return func(a *alerter) {
+		if n > 0 {
+			a.threshold = n
+		}
+	}
+}
+
+// WithAlertCooldown 设置两次触发之间的最小间隔，默认 DefaultAlertCooldown，0 表示不限制。
+func WithAlertCooldown(d time.Duration) AlertOption {
+	return func(a *alerter) {
+		if d >= 0 {
+			a.cooldown = d
+		}
+	}
+}
+
+// WithAlertHandler 设置观察的 Handler，默认观察全局 logger 输出的日志。
+func WithAlertHandler(h *Handler) AlertOption {
+	return func(a *alerter) {
+		a.handler = h
+	}
+}
+
+// StartAlert 统计滑动窗口内的 ERROR 日志条数，达到阈值和恢复时调用 fn，
+// 没有接入完整监控的服务可借此获得基本的告警：
+//
+//	defer logm.StartAlert(logm.AlertWebhook("https://hooks.example.com/alert"),
+//	    logm.WithAlertThreshold(20), logm.WithAlertWindow(5*time.Minute))()
+//
+// 窗口内的条数降到阈值以下时发送恢复通知。触发后冷却时间内不会再次触发，
+// 期间恢复又超过阈值的，冷却结束后若仍超过阈值再触发，避免告警抖动。
+// 统计通过观察者完成，日志调用方只做一次计数；fn 在后台 goroutine 中串行调用，
+// 可以执行网络请求。返回的 stop 函数用于停止统计。
+func StartAlert(fn AlertFunc, opts ...AlertOption) (stop func()) {
+	a := newAlerter(opts...)
+	var cancel func()
+	if a.handler != nil {
+		cancel = a.handler.Observe(a.observe)
+	} else {
+		cancel = Observe(a.observe)
+	}
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		// 无新日志时也需要定期检查恢复
+		ticker := time.NewTicker(a.bucketWidth())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.wake:
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			if e, ok := a.evaluate(time.Now()); ok {
+				fn(e)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			close(done)
+			<-exited
+		})
+	}
+}
+
+// alerter 滑动窗口计数和告警状态
+type alerter struct {
+	level     slog.Level
+	window    time.Duration
+	threshold int
+	cooldown  time.Duration
+	handler   *Handler
+	wake      chan struct{}
+
+	mu      sync.Mutex
+	slots   [alertBuckets]int64 // 每个桶对应的时间片序号
+	counts  [alertBuckets]int
+	message string
+
+	firing   bool
+	lastFire time.Time
+}
+
+// newAlerter 创建带默认配置的 alerter
+func newAlerter(opts ...AlertOption) *alerter {
+	a := &alerter{
+		level:     slog.LevelError,
+		window:    DefaultAlertWindow,
+		threshold: DefaultAlertThreshold,
+		cooldown:  DefaultAlertCooldown,
+		wake:      make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// bucketWidth 返回每个桶的时长
+func (a *alerter) bucketWidth() time.Duration {
+	return max(a.window/alertBuckets, time.Millisecond)
+}
+
+// observe 观察者，计数并唤醒后台检查
+func (a *alerter) observe(r Record) {
+	if r.Level < a.level {
+		return
+	}
+	a.record(time.Now(), r.Message)
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// record 在 now 所在的桶中计数
+func (a *alerter) record(now time.Time, msg string) {
+	slot := now.UnixNano() / int64(a.bucketWidth())
+	i := slot % alertBuckets
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.slots[i] != slot {
+		a.slots[i] = slot
+		a.counts[i] = 0
+	}
+	a.counts[i]++
+	a.message = msg
+}
+
+// count 返回截至 now 的窗口内计数
+func (a *alerter) count(now time.Time) int {
+	slot := now.UnixNano() / int64(a.bucketWidth())
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := 0
+	for i, s := range a.slots {
+		if s > slot-alertBuckets && s <= slot {
+			n += a.counts[i]
+		}
+	}
+	return n
+}
+
+// evaluate 检查状态是否变化，变化时返回对应的事件
+func (a *alerter) evaluate(now time.Time) (AlertEvent, bool) {
+	n := a.count(now)
+	switch {
+	case !a.firing && n >= a.threshold:
+		if !a.lastFire.IsZero() && now.Sub(a.lastFire) < a.cooldown {
+			return AlertEvent{}, false
+		}
+		a.firing = true
+		a.lastFire = now
+	case a.firing && n < a.threshold:
+		a.firing = false
+	default:
+		return AlertEvent{}, false
+	}
+
+	a.mu.Lock()
+	msg := a.message
+	a.mu.Unlock()
+	return AlertEvent{
+		Firing:    a.firing,
+		Count:     n,
+		Threshold: a.threshold,
+		Window:    a.window,
+		Level:     LevelString(a.level),
+		Message:   msg,
+		Time:      now,
+	}, true
+}
+
+// String 返回告警事件的简短描述
+func (e AlertEvent) String() string {
+	if e.Firing {
+		return fmt.Sprintf("logm alert firing: %d %s logs in %s (threshold %d)", e.Count, e.Level, e.Window, e.Threshold)
+	}
+	return fmt.Sprintf("logm alert resolved: %d %s logs in %s (threshold %d)", e.Count, e.Level, e.Window, e.Threshold)
+}
@@ -0,0 +1,126 @@
+package logm
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlerter_FireAndRecover(t *testing.T) {
+	a := newAlerter(WithAlertWindow(time.Minute), WithAlertThreshold(3), WithAlertCooldown(0))
+	now := time.Unix(1705314600, 0)
+
+	a.record(now, "db timeout")
+	a.record(now.Add(10*time.Second), "db timeout")
+	_, ok := a.evaluate(now.Add(10 * time.Second))
+	assert.False(t, ok)
+
+	a.record(now.Add(20*time.Second), "db down")
+	e, ok := a.evaluate(now.Add(20 * time.Second))
+	require.True(t, ok)
+	assert.Equal(t, AlertEvent{
+		Firing: true, Count: 3, Threshold: 3, Window: time.Minute,
+		Level: "ERROR", Message: "db down", Time: now.Add(20 * time.Second),
+	}, e)
+	assert.Contains(t, e.String(), "firing: 3 ERROR logs in 1m0s")
+
+	// 仍超过阈值时不重复通知
+	_, ok = a.evaluate(now.Add(30 * time.Second))
+	assert.False(t, ok)
+
+	// 第一条滑出窗口后恢复
+	e, ok = a.evaluate(now.Add(61 * time.Second))
+	require.True(t, ok)
+	assert.False(t, e.Firing)
+	assert.Equal(t, 2, e.Count)
+}
+
+func TestAlerter_Cooldown(t *testing.T) {
+	a := newAlerter(WithAlertWindow(time.Minute), WithAlertThreshold(1), WithAlertCooldown(10*time.Minute))
+	now := time.Unix(1705314600, 0)
+
+	a.record(now, "boom")
+	e, ok := a.evaluate(now)
+	require.True(t, ok)
+	assert.True(t, e.Firing)
+
+	e, ok = a.evaluate(now.Add(2 * time.Minute))
+	require.True(t, ok)
+	assert.False(t, e.Firing)
+
+	// 冷却期内再次超过阈值不触发
+	a.record(now.Add(3*time.Minute), "boom")
+	_, ok = a.evaluate(now.Add(3 * time.Minute))
+	assert.False(t, ok)
+
+	a.record(now.Add(10*time.Minute), "boom")
+	e, ok = a.evaluate(now.Add(10 * time.Minute))
+	require.True(t, ok)
+	assert.True(t, e.Firing)
+}
+
+func TestStartAlert(t *testing.T) {
+	h := NewHandler(&HandlerConfig{Formatter: formatter.Text(), Writers: []Writer{&testWriter{buf: &bytes.Buffer{}}}})
+	logger := slog.New(h)
+
+	var mu sync.Mutex
+	var events []AlertEvent
+	stop := StartAlert(func(e AlertEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}, WithAlertHandler(h), WithAlertLevel(slog.LevelWarn), WithAlertThreshold(2),
+		WithAlertWindow(300*time.Millisecond), WithAlertCooldown(0))
+
+	logger.Info("ignored")
+	logger.Warn("slow")
+	logger.Error("failed")
+
+	snapshot := func() []AlertEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]AlertEvent(nil), events...)
+	}
+	assert.Eventually(t, func() bool { return len(snapshot()) == 2 }, 2*time.Second, 10*time.Millisecond)
+	stop()
+	stop()
+
+	got := snapshot()
+	require.Len(t, got, 2)
+	assert.True(t, got[0].Firing)
+	assert.Equal(t, "failed", got[0].Message)
+	assert.Equal(t, "WARN", got[0].Level)
+	assert.False(t, got[1].Firing)
+
+	// 停止后不再统计
+	logger.Error("after stop")
+	logger.Error("after stop")
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, snapshot(), 2)
+}
+
+func TestAlertWebhook(t *testing.T) {
+	got := make(chan AlertEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var e AlertEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		got <- e
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	want := AlertEvent{Firing: true, Count: 12, Threshold: 10, Window: time.Minute, Level: "ERROR", Time: time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)}
+	AlertWebhook(srv.URL)(want)
+	assert.Equal(t, want, <-got)
+}
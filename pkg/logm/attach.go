@@ -0,0 +1,151 @@
+package logm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+)
+
+// attachedWriter AttachWriter 挂载的 Writer
+type attachedWriter struct {
+	name     string
+	w        Writer
+	counters writerCounters
+	detached bool // 由 handlerState.mu 保护，卸载后正在进行的 Handle 跳过该 Writer
+}
+
+// attachedSet 运行时挂载的 Writer，写时复制，所有派生 Handler 共享。
+//
+// 修改在 handlerState.mu 下进行，Handle 路径上只有一次原子读取。
+type attachedSet struct {
+	list  atomic.Pointer[[]*attachedWriter]
+	owner *handlerState // 创建该集合的 Handler，关闭时负责关闭挂载的 Writer
+}
+
+// load 返回当前挂载的 Writer 快照
+func (s *attachedSet) load() []*attachedWriter {
+	if p := s.list.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// withAttached 返回 h.writers 之后追加 attached 的 Writer 列表
+func (h *Handler) withAttached(attached []*attachedWriter) []Writer {
+	if len(attached) == 0 {
+		return h.writers
+	}
+	writers := make([]Writer, 0, len(h.writers)+len(attached))
+	writers = append(writers, h.writers...)
+	for _, a := range attached {
+		writers = append(writers, a.w)
+	}
+	return writers
+}
+
+// writerEntry Writer 及其名称和写入状态，供统计和健康检查遍历
+type writerEntry struct {
+	name     string
+	w        Writer
+	counters *writerCounters
+}
+
+// writerEntries 返回配置的 Writer 和挂载的 Writer
+func (h *Handler) writerEntries() []writerEntry {
+	attached := h.attached.load()
+	entries := make([]writerEntry, 0, len(h.writers)+len(attached))
+	for i, w := range h.writers {
+		entries = append(entries, writerEntry{name: writerName(i, w), w: w, counters: &h.counters.writers[i]})
+	}
+	for _, a := range attached {
+		entries = append(entries, writerEntry{name: a.name, w: a.w, counters: &a.counters})
+	}
+	return entries
+}
+
+// AttachWriter 在运行中的 Handler 上挂载一个具名 Writer，之后的日志同时写入 w。
+//
+// 挂载对该 Handler 及其派生 Handler 立即生效，w 接收与其他 Writer 相同的格式化输出，
+// 可以是 TransformWriter 包装的 Writer。名称已存在或 Handler 已关闭时返回错误。
+// 挂载的 Writer 由 DetachWriter 或 Handler 的 Close 关闭。
+func (h *Handler) AttachWriter(name string, w Writer) error {
+	if name == "" || w == nil {
+		return errors.New("logm: attach writer: empty name or nil writer")
+	}
+
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+	if h.state.closed {
+		return errors.New("logm: attach writer: handler closed")
+	}
+	list := h.attached.load()
+	if slices.ContainsFunc(list, func(a *attachedWriter) bool { return a.name == name }) {
+		return fmt.Errorf("logm: attach writer: %q already attached", name)
+	}
+	next := append(slices.Clip(list), &attachedWriter{name: name, w: hoistTransform(w)})
+	h.attached.list.Store(&next)
+	return nil
+}
+
+// DetachWriter 卸载 AttachWriter 挂载的 Writer，刷新并关闭它。
+//
+// 返回时不再有日志写入该 Writer。名称不存在时返回错误。
+func (h *Handler) DetachWriter(name string) error {
+	h.state.mu.Lock()
+	list := h.attached.load()
+	i := slices.IndexFunc(list, func(a *attachedWriter) bool { return a.name == name })
+	if i < 0 {
+		h.state.mu.Unlock()
+		return fmt.Errorf("logm: detach writer: %q not attached", name)
+	}
+	a := list[i]
+	a.detached = true
+	next := slices.Delete(slices.Clone(list), i, i+1)
+	h.attached.list.Store(&next)
+	h.state.mu.Unlock()
+
+	return errors.Join(a.w.Sync(), a.w.Close())
+}
+
+// closeAttached 关闭所有挂载的 Writer，仅由创建集合的 Handler 执行，调用方已标记关闭
+func (h *Handler) closeAttached() error {
+	if h.attached.owner != h.state {
+		return nil
+	}
+	var errs []error
+	for _, a := range h.attached.load() {
+		errs = append(errs, a.w.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// AttachWriter 在全局日志系统上挂载一个具名 Writer，用于临时将线上日志
+// 复制到文件或调试 socket，排查结束后用 DetachWriter 移除，无需重启：
+//
+//	_ = logm.AttachWriter("debug", writer.File("/tmp/debug.log"))
+//	defer logm.DetachWriter("debug")
+//
+// 挂载的 Writer 跟随当前全局 Handler，重新 Init 后需要重新挂载。未初始化时返回错误。
+func AttachWriter(name string, w Writer) error {
+	globalMu.RLock()
+	h := globalHandler
+	globalMu.RUnlock()
+
+	if h == nil {
+		return errors.New("logm: attach writer: not initialized")
+	}
+	return h.AttachWriter(name, w)
+}
+
+// DetachWriter 卸载 AttachWriter 在全局日志系统上挂载的 Writer，刷新并关闭它。
+func DetachWriter(name string) error {
+	globalMu.RLock()
+	h := globalHandler
+	globalMu.RUnlock()
+
+	if h == nil {
+		return errors.New("logm: detach writer: not initialized")
+	}
+	return h.DetachWriter(name)
+}
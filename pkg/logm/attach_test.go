@@ -0,0 +1,81 @@
+package logm
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeTracker 记录是否已关闭的 Writer
+type closeTracker struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *closeTracker) Close() error { w.closed = true; return nil }
+func (w *closeTracker) Sync() error  { return nil }
+
+func TestHandler_AttachWriter(t *testing.T) {
+	var base bytes.Buffer
+	h := newHandler(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &base}))
+	logger := slog.New(h).With("svc", "api")
+
+	tee := &closeTracker{}
+	require.NoError(t, h.AttachWriter("debug", tee))
+	require.ErrorContains(t, h.AttachWriter("debug", &closeTracker{}), "already attached")
+
+	logger.Info("attached")
+	assert.Contains(t, base.String(), "attached")
+	assert.Contains(t, tee.String(), "attached")
+	assert.Contains(t, tee.String(), "svc=api")
+
+	status := h.Status()
+	require.Len(t, status, 2)
+	assert.Equal(t, "debug", status[1].Name)
+	assert.False(t, status[1].LastWrite.IsZero())
+	assert.Equal(t, "debug", h.Stats().Writers[1].Name)
+	assert.Equal(t, []string{"logm.testWriter#0", "debug"}, h.Config().Writers)
+
+	require.NoError(t, h.DetachWriter("debug"))
+	assert.True(t, tee.closed)
+	require.ErrorContains(t, h.DetachWriter("debug"), "not attached")
+
+	logger.Info("detached")
+	assert.Contains(t, base.String(), "detached")
+	assert.NotContains(t, tee.String(), "detached")
+	assert.Len(t, h.Status(), 1)
+}
+
+func TestHandler_AttachWriter_Close(t *testing.T) {
+	h := newHandler(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &bytes.Buffer{}}))
+	tee := &closeTracker{}
+	require.NoError(t, h.AttachWriter("debug", tee))
+
+	// 派生 Handler 共享挂载的 Writer，但关闭时不关闭它们
+	derived, err := Derive(slog.New(h))
+	require.NoError(t, err)
+	derived.Info("from derived")
+	assert.Contains(t, tee.String(), "from derived")
+	require.NoError(t, derived.Handler().(*Handler).Close())
+	assert.False(t, tee.closed)
+
+	require.NoError(t, h.Close())
+	assert.True(t, tee.closed)
+	require.ErrorContains(t, h.AttachWriter("late", &closeTracker{}), "closed")
+}
+
+func TestAttachWriter_Global(t *testing.T) {
+	require.NoError(t, Init(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &bytes.Buffer{}})))
+	defer func() { _ = Close() }()
+
+	tee := &closeTracker{}
+	require.NoError(t, AttachWriter("tee", tee))
+	Info("global tee")
+	require.NoError(t, DetachWriter("tee"))
+	assert.Contains(t, tee.String(), "global tee")
+	assert.True(t, tee.closed)
+}
@@ -0,0 +1,64 @@
+package logm
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+// RedactedValue Secret 输出的占位文本
+const RedactedValue = "[REDACTED]"
+
+// Dur 返回时长属性，各格式化器统一输出为 time.Duration.String() 形式（如 "1.5s"）。
+func Dur(key string, d time.Duration) slog.Attr {
+	return slog.Duration(key, d)
+}
+
+// ByteSize 返回字节数属性，值使用 FormatBytes 格式化为人类可读的字符串（如 "1.5 MB"）。
+func ByteSize(key string, n int64) slog.Attr {
+	return slog.String(key, FormatBytes(n))
+}
+
+// Stringer 返回调用 s.String() 的字符串属性。
+//
+// String 在日志确实输出时才调用，级别被过滤时无额外开销；
+// s 为 nil（包括持有 nil 指针的接口）时输出 "<nil>"。
+func Stringer(key string, s fmt.Stringer) slog.Attr {
+	return slog.Any(key, stringerValue{s})
+}
+
+// Secret 返回敏感信息属性，值输出为 "[REDACTED]"，键名保留以便排查。
+//
+//	slog.Info("login", "user", name, logm.Secret("password", password))
+//
+// 值包装为 Sensitive，规则见 Redacted。
+func Secret(key, value string) slog.Attr {
+	return slog.Any(key, Redacted(value))
+}
+
+// stringerValue 延迟调用 String 的 LogValuer
+type stringerValue struct {
+	s fmt.Stringer
+}
+
+// LogValue 实现 slog.LogValuer
+func (v stringerValue) LogValue() slog.Value {
+	if isNil(v.s) {
+		return slog.StringValue("<nil>")
+	}
+	return slog.StringValue(v.s.String())
+}
+
+// isNil 判断接口值是否为 nil 或持有 nil 指针
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
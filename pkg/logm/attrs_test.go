@@ -0,0 +1,59 @@
+package logm
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedAttrs_JSON(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.JSON())
+
+	logger.Info("done",
+		Dur("elapsed", 1500*time.Millisecond),
+		ByteSize("size", 1536),
+		Stringer("ip", net.IPv4(10, 0, 0, 1)),
+		Secret("password", "hunter2"),
+	)
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, "1.5s", m["elapsed"])
+	assert.Equal(t, "1.5 KB", m["size"])
+	assert.Equal(t, "10.0.0.1", m["ip"])
+	assert.Equal(t, RedactedValue, m["password"])
+	assert.NotContains(t, buf.String(), "hunter2")
+}
+
+func TestTypedAttrs_Text(t *testing.T) {
+	for name, f := range map[string]formatter.Formatter{
+		"text":       formatter.Text(),
+		"color_text": formatter.ColorText(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			logger, buf := newBufferLogger(f)
+
+			logger.Info("done", Dur("elapsed", time.Second), Secret("token", "abc123"))
+
+			out := buf.String()
+			assert.Contains(t, out, "1s")
+			assert.Contains(t, out, RedactedValue)
+			assert.NotContains(t, out, "abc123")
+		})
+	}
+}
+
+func TestStringer_Nil(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.Text())
+
+	var ip *net.IPNet
+	logger.Info("done", Stringer("net", ip), Stringer("none", nil))
+
+	assert.Contains(t, buf.String(), "net=<nil>")
+	assert.Contains(t, buf.String(), "none=<nil>")
+}
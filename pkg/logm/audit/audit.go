@@ -0,0 +1,293 @@
+// Package audit 提供防篡改的审计日志。
+//
+// 每条记录是一行 JSON，包含递增的 seq、上一条记录的哈希 prev_hash
+// 以及本条记录的哈希 hash，形成哈希链：修改、删除或插入任意一行都会使
+// 之后的校验失败。Logger 还会定期写入 checkpoint 行，记录当前链头，
+// 可将其同步到外部系统，用于发现末尾被截断的情况。
+//
+//	l, err := audit.OpenFile("/var/log/app/audit.log")
+//	if err != nil {
+//	    return err
+//	}
+//	defer l.Close()
+//
+//	auditLog := slog.New(l.Handler())
+//	auditLog.Info("user.role_changed", "actor", "admin", "user_id", 42, "role", "owner")
+//
+// 校验：
+//
+//	report, err := audit.VerifyFile("/var/log/app/audit.log")
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// GenesisHash 链中第一条记录的 prev_hash
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// 审计记录中的保留字段名，同名的业务属性会加上 "attr_" 前缀
+const (
+	KeySeq      = "seq"
+	KeyPrevHash = "prev_hash"
+	KeyHash     = "hash"
+	KeyType     = "type"
+)
+
+// CheckpointMessage checkpoint 行的 msg
+const CheckpointMessage = "audit.checkpoint"
+
+// DefaultCheckpointEvery 默认每隔多少条记录写入一次 checkpoint
+const DefaultCheckpointEvery = 100
+
+// hashMarker 哈希字段在行尾的前缀
+const hashMarker = `,"` + KeyHash + `":"`
+
+// Option 配置选项
+type Option func(*Logger)
+
+// WithCheckpointEvery 设置 checkpoint 间隔，n <= 0 表示只在 Close 时写入。
+func WithCheckpointEvery(n int) Option {
+	return func(l *Logger) {
+		l.every = n
+	}
+}
+
+// WithSync 设置每条记录写入后是否调用 Sync（默认开启）。
+//
+// 仅对实现 Sync() error 的 Writer 有效，如 *os.File。
+func WithSync(enable bool) Option {
+	return func(l *Logger) {
+		l.sync = enable
+	}
+}
+
+// WithClock 设置时间来源，用于测试。
+func WithClock(clock func() time.Time) Option {
+	return func(l *Logger) {
+		l.clock = clock
+	}
+}
+
+// Logger 哈希链审计日志。
+//
+// 所有方法可以并发调用，记录按调用顺序串行写入。
+type Logger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	format formatter.Formatter
+
+	seq     uint64 // 最后一条记录的序号
+	prev    string // 最后一条记录的哈希
+	pending int    // 上次 checkpoint 之后的记录数
+	closed  bool
+
+	every int
+	sync  bool
+	clock func() time.Time
+}
+
+// New 创建写入 w 的审计日志，从创世哈希开始新的链。
+//
+// w 应以追加方式打开；若要接续已有文件的链，请使用 OpenFile。
+func New(w io.Writer, opts ...Option) *Logger {
+	return newLogger(w, 0, GenesisHash, opts...)
+}
+
+// OpenFile 以追加方式打开审计日志文件（权限 0600）。
+//
+// 文件已有记录时先校验整条链，再从最后一条记录接续；
+// 链校验失败时返回错误，避免在被篡改的文件上继续追加。
+func OpenFile(path string, opts ...Option) (*Logger, error) {
+	report, err := VerifyFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600) //nolint:gosec // G304: 路径由调用方指定
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+
+	seq, prev := uint64(0), GenesisHash
+	if report != nil && report.Records > 0 {
+		seq, prev = report.LastSeq, report.LastHash
+	}
+	l := newLogger(f, seq, prev, opts...)
+	l.closer = f
+	return l, nil
+}
+
+func newLogger(w io.Writer, seq uint64, prev string, opts ...Option) *Logger {
+	l := &Logger{
+		w:      w,
+		format: formatter.JSON(formatter.WithTimeFormat(time.RFC3339Nano), formatter.WithTimezone("UTC")),
+		seq:    seq,
+		prev:   prev,
+		every:  DefaultCheckpointEvery,
+		sync:   true,
+		clock:  time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Handler 返回写入该审计日志的 slog.Handler，所有级别的记录都会写入。
+func (l *Logger) Handler() slog.Handler {
+	return &handler{l: l}
+}
+
+// Log 写入一条审计记录。
+func (l *Logger) Log(_ context.Context, msg string, args ...any) error {
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, msg, 0)
+	r.Add(args...)
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return l.write(slog.LevelInfo, msg, attrs, false)
+}
+
+// Checkpoint 立即写入 checkpoint 行。
+func (l *Logger) Checkpoint() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.checkpointLocked()
+}
+
+// Head 返回最后一条记录的序号和哈希，可同步到外部系统用于发现截断。
+func (l *Logger) Head() (seq uint64, hash string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq, l.prev
+}
+
+// Close 写入最终 checkpoint 并关闭由 OpenFile 打开的文件。
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+
+	var err error
+	if l.pending > 0 {
+		err = l.checkpointLocked()
+	}
+	l.closed = true
+	if l.closer != nil {
+		err = errors.Join(err, l.closer.Close())
+	}
+	return err
+}
+
+// write 写入一条记录，必要时随后写入 checkpoint
+func (l *Logger) write(level slog.Level, msg string, attrs []slog.Attr, checkpoint bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.writeLocked(level, msg, attrs, checkpoint); err != nil {
+		return err
+	}
+	if l.every > 0 && l.pending >= l.every {
+		return l.checkpointLocked()
+	}
+	return nil
+}
+
+// checkpointLocked 写入 checkpoint 行
+func (l *Logger) checkpointLocked() error {
+	return l.writeLocked(slog.LevelInfo, CheckpointMessage, []slog.Attr{
+		slog.Uint64("records", l.seq),
+	}, true)
+}
+
+// writeLocked 编码、计算哈希并写入一行
+func (l *Logger) writeLocked(level slog.Level, msg string, attrs []slog.Attr, checkpoint bool) error {
+	if l.closed {
+		return errors.New("audit: logger closed")
+	}
+
+	seq := l.seq + 1
+	head := make([]slog.Attr, 0, len(attrs)+3)
+	head = append(head, slog.Uint64(KeySeq, seq), slog.String(KeyPrevHash, l.prev))
+	if checkpoint {
+		head = append(head, slog.String(KeyType, "checkpoint"))
+	}
+	for _, a := range attrs {
+		// 避免业务属性覆盖保留字段
+		switch a.Key {
+		case KeySeq, KeyPrevHash, KeyHash, KeyType:
+			a.Key = "attr_" + a.Key
+		}
+		head = append(head, a)
+	}
+
+	data, err := l.format.Format(&formatter.Record{
+		Time:    l.clock(),
+		Level:   level,
+		Message: msg,
+		Attrs:   head,
+	})
+	if err != nil {
+		return fmt.Errorf("audit: format: %w", err)
+	}
+
+	// data 以 "}\n" 结尾，哈希覆盖不含 hash 字段的完整 JSON
+	body := bytes.TrimSuffix(data, []byte("\n"))
+	hash := hashLine(body)
+	line := make([]byte, 0, len(body)+len(hashMarker)+len(hash)+3)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, hashMarker...)
+	line = append(line, hash...)
+	line = append(line, "\"}\n"...)
+
+	if _, err := l.w.Write(line); err != nil {
+		return fmt.Errorf("audit: write: %w", err)
+	}
+	if s, ok := l.w.(interface{ Sync() error }); ok && l.sync {
+		if err := s.Sync(); err != nil {
+			return fmt.Errorf("audit: sync: %w", err)
+		}
+	}
+
+	l.seq = seq
+	l.prev = hash
+	if checkpoint {
+		l.pending = 0
+	} else {
+		l.pending++
+	}
+	return nil
+}
+
+// hashLine 计算记录哈希
+func hashLine(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// newLineScanner 创建支持长行的扫描器
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return sc
+}
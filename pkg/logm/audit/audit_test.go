@@ -0,0 +1,193 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTime = time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+
+func fixedClock() time.Time { return testTime }
+
+// lines 返回非空行
+func lines(buf *bytes.Buffer) []string {
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func TestLogger_HashChain(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithClock(fixedClock), WithCheckpointEvery(0))
+	logger := slog.New(l.Handler())
+
+	logger.Info("user.login", "user_id", 42)
+	logger.With("actor", "admin").WithGroup("change").Warn("user.role_changed", "role", "owner")
+	require.NoError(t, l.Log(context.Background(), "export", "rows", 10))
+
+	out := lines(&buf)
+	require.Len(t, out, 3)
+
+	var first, second map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(out[1]), &second))
+	assert.InDelta(t, 1, first["seq"], 0)
+	assert.Equal(t, GenesisHash, first["prev_hash"])
+	assert.Equal(t, first["hash"], second["prev_hash"])
+	assert.Equal(t, "admin", second["actor"])
+	assert.Equal(t, map[string]any{"role": "owner"}, second["change"])
+
+	report, err := Verify(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), report.Records)
+	seq, head := l.Head()
+	assert.Equal(t, seq, report.LastSeq)
+	assert.Equal(t, head, report.LastHash)
+}
+
+func TestLogger_Checkpoints(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithClock(fixedClock), WithCheckpointEvery(2))
+
+	for i := range 5 {
+		require.NoError(t, l.Log(context.Background(), "event", "i", i))
+	}
+	require.NoError(t, l.Close())
+	assert.Error(t, l.Log(context.Background(), "after close"))
+	assert.Contains(t, buf.String(), `"msg":"audit.checkpoint"`)
+
+	report, err := Verify(&buf)
+	require.NoError(t, err)
+	// 5 条记录 + 每 2 条一次 checkpoint + Close 时的最终 checkpoint
+	assert.Equal(t, uint64(3), report.Checkpoints)
+	assert.Equal(t, uint64(8), report.Records)
+}
+
+func TestLogger_ReservedKeys(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithClock(fixedClock), WithCheckpointEvery(0))
+
+	require.NoError(t, l.Log(context.Background(), "spoof", "seq", 999, "hash", "x"))
+
+	assert.Contains(t, buf.String(), `"attr_seq":999`)
+	assert.Contains(t, buf.String(), `"attr_hash":"x"`)
+	_, err := Verify(&buf)
+	require.NoError(t, err)
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithClock(fixedClock), WithCheckpointEvery(0))
+	for i := range 4 {
+		require.NoError(t, l.Log(context.Background(), "event", "amount", i*100))
+	}
+	orig := lines(&buf)
+
+	join := func(ls []string) *strings.Reader { return strings.NewReader(strings.Join(ls, "\n") + "\n") }
+
+	tests := map[string]struct {
+		lines []string
+		line  int
+		want  string
+	}{
+		"modified": {
+			lines: []string{orig[0], strings.Replace(orig[1], `"amount":100`, `"amount":1`, 1), orig[2], orig[3]},
+			line:  2, want: "hash mismatch",
+		},
+		"removed": {
+			lines: []string{orig[0], orig[2], orig[3]},
+			line:  2, want: "prev_hash mismatch",
+		},
+		"reordered": {
+			lines: []string{orig[0], orig[2], orig[1], orig[3]},
+			line:  2, want: "prev_hash mismatch",
+		},
+		"injected": {
+			lines: []string{orig[0], orig[1], `{"msg":"fake"}`, orig[2], orig[3]},
+			line:  3, want: "missing hash field",
+		},
+		"garbage": {
+			lines: []string{orig[0], "not json"},
+			line:  2, want: "invalid JSON",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Verify(join(tt.lines))
+			var ve *VerifyError
+			require.ErrorAs(t, err, &ve)
+			assert.Equal(t, tt.line, ve.Line)
+			assert.Contains(t, ve.Reason, tt.want)
+		})
+	}
+}
+
+func TestOpenFile_ContinuesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	l, err := OpenFile(path, WithCheckpointEvery(0))
+	require.NoError(t, err)
+	require.NoError(t, l.Log(context.Background(), "first"))
+	require.NoError(t, l.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	l, err = OpenFile(path, WithCheckpointEvery(0))
+	require.NoError(t, err)
+	require.NoError(t, l.Log(context.Background(), "second"))
+	require.NoError(t, l.Close())
+
+	report, err := VerifyFile(path)
+	require.NoError(t, err)
+	// first + checkpoint + second + checkpoint
+	assert.Equal(t, uint64(4), report.Records)
+}
+
+func TestOpenFile_RefusesTamperedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := OpenFile(path)
+	require.NoError(t, err)
+	require.NoError(t, l.Log(context.Background(), "event", "amount", 100))
+	require.NoError(t, l.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, bytes.Replace(data, []byte("100"), []byte("999"), 1), 0o600))
+
+	_, err = OpenFile(path)
+	var ve *VerifyError
+	assert.True(t, errors.As(err, &ve))
+}
+
+func TestLogger_Concurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithCheckpointEvery(10))
+	logger := slog.New(l.Handler())
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 50 {
+				logger.Info("event", "g", g, "i", i)
+			}
+		})
+	}
+	wg.Wait()
+	require.NoError(t, l.Close())
+
+	report, err := Verify(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(400+40), report.Records)
+}
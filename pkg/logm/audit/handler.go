@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// handler 写入审计日志的 slog.Handler
+type handler struct {
+	l *Logger
+
+	// 继承的属性和分组，按调用顺序记录
+	steps []step
+}
+
+// step WithAttrs 或 WithGroup 的一次调用
+type step struct {
+	group string
+	attrs []slog.Attr
+}
+
+// Enabled 审计记录不按级别过滤
+func (h *handler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle 写入一条审计记录
+func (h *handler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	// 由内向外嵌套分组，保证 seq、prev_hash 始终位于顶层
+	for i := len(h.steps) - 1; i >= 0; i-- {
+		s := h.steps[i]
+		if s.group != "" {
+			if len(attrs) == 0 {
+				continue
+			}
+			attrs = []slog.Attr{{Key: s.group, Value: slog.GroupValue(attrs...)}}
+			continue
+		}
+		attrs = append(slices.Clip(s.attrs), attrs...)
+	}
+
+	return h.l.write(r.Level, r.Message, attrs, false)
+}
+
+// WithAttrs 实现 slog.Handler
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &handler{l: h.l, steps: append(slices.Clip(h.steps), step{attrs: slices.Clone(attrs)})}
+}
+
+// WithGroup 实现 slog.Handler
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &handler{l: h.l, steps: append(slices.Clip(h.steps), step{group: name})}
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Report 校验结果。
+type Report struct {
+	Records     uint64 // 记录总数（含 checkpoint）
+	Checkpoints uint64 // checkpoint 行数
+	LastSeq     uint64 // 最后一条记录的序号
+	LastHash    string // 最后一条记录的哈希，可与外部保存的链头比对以发现截断
+}
+
+// VerifyError 哈希链校验失败。
+type VerifyError struct {
+	Line   int    // 出错的行号（从 1 开始）
+	Reason string // 失败原因
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("audit: line %d: %s", e.Line, e.Reason)
+}
+
+// entry 校验时解析的字段
+type entry struct {
+	Seq      uint64 `json:"seq"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+	Type     string `json:"type"`
+}
+
+// Verify 逐行校验哈希链。
+//
+// 检查每行哈希与内容一致、prev_hash 指向上一行、seq 连续递增。
+// 首行的 prev_hash 必须为 GenesisHash。校验失败时返回 *VerifyError，
+// 同时返回出错前已校验的部分结果。
+func Verify(r io.Reader) (*Report, error) {
+	report := &Report{LastHash: GenesisHash}
+	sc := newLineScanner(r)
+	line := 0
+
+	for sc.Scan() {
+		line++
+		raw := sc.Bytes()
+		if len(bytes.TrimSpace(raw)) == 0 {
+			return report, &VerifyError{Line: line, Reason: "empty line"}
+		}
+
+		var e entry
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return report, &VerifyError{Line: line, Reason: "invalid JSON: " + err.Error()}
+		}
+
+		idx := bytes.LastIndex(raw, []byte(hashMarker))
+		if idx < 0 || !bytes.HasSuffix(raw, []byte(`"}`)) {
+			return report, &VerifyError{Line: line, Reason: "missing hash field"}
+		}
+		body := append(raw[:idx:idx], '}')
+		if got := hashLine(body); got != e.Hash {
+			return report, &VerifyError{Line: line, Reason: "hash mismatch: record was modified"}
+		}
+		if e.PrevHash != report.LastHash {
+			return report, &VerifyError{Line: line, Reason: "prev_hash mismatch: records were removed, inserted or reordered"}
+		}
+		if e.Seq != report.LastSeq+1 {
+			return report, &VerifyError{Line: line, Reason: fmt.Sprintf("unexpected seq %d, want %d", e.Seq, report.LastSeq+1)}
+		}
+
+		report.Records++
+		if e.Type == "checkpoint" {
+			report.Checkpoints++
+		}
+		report.LastSeq = e.Seq
+		report.LastHash = e.Hash
+	}
+	if err := sc.Err(); err != nil {
+		return report, fmt.Errorf("audit: read: %w", err)
+	}
+	return report, nil
+}
+
+// VerifyFile 校验审计日志文件。
+func VerifyFile(path string) (*Report, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: 路径由调用方指定
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return Verify(f)
+}
@@ -0,0 +1,246 @@
+package logm
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// Builder 链式配置构建器。
+//
+// 与 Functional Options 等价，适合根据配置结构体动态组装；
+// 配置错误在 Build 或 Init 时统一返回，而不是被静默忽略：
+//
+//	log, err := logm.NewBuilder().
+//	    Level(cfg.Level).
+//	    JSON().
+//	    File(cfg.Path, writer.WithRotation(100, 7)).
+//	    Async(4096).
+//	    Build()
+//
+// Builder 不是并发安全的，Build 之后不应继续修改。
+type Builder struct {
+	level      string
+	format     string
+	formatter  Formatter
+	timeFormat string
+	precision  TimePrecision
+	timezone   string
+	writers    []Writer
+	asyncSize  int
+	opts       []Option
+	errs       []error
+}
+
+// NewBuilder 创建构建器，默认配置与 Init 相同。
+func NewBuilder() *Builder {
+	d := defaultOptions()
+	return &Builder{
+		level:      d.level,
+		timeFormat: d.timeFormat,
+		timezone:   d.timezone,
+	}
+}
+
+// Level 设置日志级别：DEBUG、INFO、WARN、ERROR（大小写不敏感）。
+func (b *Builder) Level(level string) *Builder {
+	switch strings.ToUpper(level) {
+	case "DEBUG", "INFO", "WARN", "WARNING", "ERROR":
+		b.level = level
+	default:
+		b.errs = append(b.errs, fmt.Errorf("logm: unknown level %q", level))
+	}
+	return b
+}
+
+// Format 按名称设置格式：json、text、color_text、color_json。
+func (b *Builder) Format(name string) *Builder {
+	switch strings.ToLower(name) {
+	case "json", "text", "color_text", "color_json":
+		b.format = strings.ToLower(name)
+		b.formatter = nil
+	default:
+		b.errs = append(b.errs, fmt.Errorf("logm: unknown format %q", name))
+	}
+	return b
+}
+
+// JSON 使用 JSON 格式。
+func (b *Builder) JSON() *Builder { return b.Format("json") }
+
+// Text 使用键值对文本格式。
+func (b *Builder) Text() *Builder { return b.Format("text") }
+
+// ColorText 使用彩色文本格式。
+func (b *Builder) ColorText() *Builder { return b.Format("color_text") }
+
+// ColorJSON 使用彩色 JSON 格式。
+func (b *Builder) ColorJSON() *Builder { return b.Format("color_json") }
+
+// Formatter 使用自定义格式化器，覆盖 Format 的设置。
+func (b *Builder) Formatter(f Formatter) *Builder {
+	if f == nil {
+		b.errs = append(b.errs, errors.New("logm: nil formatter"))
+		return b
+	}
+	b.formatter = f
+	b.format = ""
+	return b
+}
+
+// TimeFormat 设置时间格式，取值同 WithTimeFormat。
+func (b *Builder) TimeFormat(format string) *Builder {
+	b.timeFormat = format
+	return b
+}
+
+// TimePrecision 设置时间的秒以下精度，取值同 WithTimePrecision。
+func (b *Builder) TimePrecision(p TimePrecision) *Builder {
+	b.precision = p
+	return b
+}
+
+// Timezone 设置 IANA 时区名称，如 "Asia/Shanghai"、"UTC"。
+func (b *Builder) Timezone(tz string) *Builder {
+	if _, err := time.LoadLocation(tz); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("logm: timezone %q: %w", tz, err))
+		return b
+	}
+	b.timezone = tz
+	return b
+}
+
+// Stdout 添加标准输出。
+func (b *Builder) Stdout() *Builder { return b.Writer(writer.Stdout()) }
+
+// Stderr 添加标准错误输出。
+func (b *Builder) Stderr() *Builder { return b.Writer(writer.Stderr()) }
+
+// File 添加带轮转的文件输出。
+func (b *Builder) File(path string, opts ...writer.FileOption) *Builder {
+	if path == "" {
+		b.errs = append(b.errs, errors.New("logm: empty file path"))
+		return b
+	}
+	f := writer.File(path, opts...)
+	if err := f.Err(); err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	return b.Writer(f)
+}
+
+// Writer 添加自定义输出目标。
+func (b *Builder) Writer(w Writer) *Builder {
+	if w == nil {
+		b.errs = append(b.errs, errors.New("logm: nil writer"))
+		return b
+	}
+	b.writers = append(b.writers, w)
+	return b
+}
+
+// Async 以异步方式写入之前添加的所有输出，bufferSize 为缓冲的日志条数。
+func (b *Builder) Async(bufferSize int) *Builder {
+	if bufferSize <= 0 {
+		b.errs = append(b.errs, fmt.Errorf("logm: async buffer size must be positive, got %d", bufferSize))
+		return b
+	}
+	b.asyncSize = bufferSize
+	return b
+}
+
+// AddSource 设置是否记录源代码位置。
+func (b *Builder) AddSource(enable bool) *Builder {
+	return b.Option(WithAddSource(enable))
+}
+
+// DefaultAttrs 添加附加到每条日志的默认属性。
+func (b *Builder) DefaultAttrs(attrs ...slog.Attr) *Builder {
+	return b.Option(WithDefaultAttrs(attrs...))
+}
+
+// Interceptor 添加拦截器。
+func (b *Builder) Interceptor(i Interceptor) *Builder {
+	return b.Option(WithInterceptor(i))
+}
+
+// Option 追加 Functional Options，用于构建器未覆盖的配置。
+func (b *Builder) Option(opts ...Option) *Builder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Options 校验配置并返回等价的 Functional Options。
+func (b *Builder) Options() ([]Option, error) {
+	if err := errors.Join(b.errs...); err != nil {
+		return nil, err
+	}
+
+	f := b.formatter
+	if f == nil {
+		fopts := []formatter.Option{
+			formatter.WithTimeFormat(b.timeFormat),
+			formatter.WithTimePrecision(b.precision),
+			formatter.WithTimezone(b.timezone),
+		}
+		switch b.format {
+		case "json":
+			f = formatter.JSON(fopts...)
+		case "color_text":
+			f = formatter.ColorText(fopts...)
+		case "color_json":
+			f = formatter.ColorJSON(fopts...)
+		default:
+			f = formatter.Text(fopts...)
+		}
+	}
+
+	opts := []Option{
+		WithLevel(b.level),
+		WithFormatter(f),
+		WithTimeFormat(b.timeFormat),
+		WithTimePrecision(b.precision),
+		WithTimezone(b.timezone),
+	}
+	switch {
+	case len(b.writers) == 0 && b.asyncSize > 0:
+		opts = append(opts, WithWriter(writer.Async(writer.Stdout(), b.asyncSize)))
+	case len(b.writers) == 1 && b.asyncSize > 0:
+		opts = append(opts, WithWriter(writer.Async(b.writers[0], b.asyncSize)))
+	case b.asyncSize > 0:
+		ws := make([]writer.Writer, len(b.writers))
+		for i, w := range b.writers {
+			ws[i] = w
+		}
+		opts = append(opts, WithWriter(writer.Async(writer.Multi(ws...), b.asyncSize)))
+	default:
+		for _, w := range b.writers {
+			opts = append(opts, WithWriter(w))
+		}
+	}
+	return append(opts, b.opts...), nil
+}
+
+// Build 校验配置并创建独立的 logger，等价于 New。
+func (b *Builder) Build() (*slog.Logger, error) {
+	opts, err := b.Options()
+	if err != nil {
+		return nil, err
+	}
+	return New(opts...), nil
+}
+
+// Init 校验配置并初始化全局日志系统，等价于 Init。
+func (b *Builder) Init() error {
+	opts, err := b.Options()
+	if err != nil {
+		return err
+	}
+	return Init(opts...)
+}
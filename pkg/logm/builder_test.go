@@ -0,0 +1,77 @@
+package logm
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := NewBuilder().
+		Level("warn").
+		JSON().
+		Timezone("UTC").
+		Writer(&testWriter{buf: &buf}).
+		Build()
+	require.NoError(t, err)
+
+	log.Info("hidden")
+	log.Warn("shown", "k", "v")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), `"msg":"shown"`)
+	assert.Contains(t, buf.String(), `"k":"v"`)
+}
+
+func TestBuilder_TimePrecision(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := NewBuilder().
+		JSON().
+		TimeFormat("rfc3339").
+		TimePrecision(PrecisionMicros).
+		Timezone("UTC").
+		Writer(&testWriter{buf: &buf}).
+		Build()
+	require.NoError(t, err)
+
+	log.Info("m", "at", time.Date(2024, 1, 15, 10, 30, 45, 123456789, time.UTC))
+	assert.Regexp(t, `"time":"\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}Z"`, buf.String())
+	assert.Contains(t, buf.String(), `"at":"2024-01-15T10:30:45.123456Z"`)
+}
+
+func TestBuilder_Async(t *testing.T) {
+	var a, b bytes.Buffer
+	opts, err := NewBuilder().Text().Writer(&testWriter{buf: &a}).Writer(&testWriter{buf: &b}).Async(16).Options()
+	require.NoError(t, err)
+
+	h := newHandler(opts...)
+	require.Len(t, h.writers, 1)
+	assert.IsType(t, &writer.AsyncWriter{}, h.writers[0])
+
+	New(opts...).Info("async")
+	require.NoError(t, h.writers[0].Close())
+	assert.Contains(t, a.String(), "async")
+	assert.Contains(t, b.String(), "async")
+}
+
+func TestBuilder_ValidationErrors(t *testing.T) {
+	_, err := NewBuilder().
+		Level("verbose").
+		Format("xml").
+		Timezone("Mars/Olympus").
+		File("").
+		File(filepath.Join(t.TempDir(), "app.log"), writer.WithFilenamePattern("app-%Q.log")).
+		Async(0).
+		Build()
+	require.Error(t, err)
+	for _, want := range []string{`"verbose"`, `"xml"`, "Mars/Olympus", "empty file path", "%Q", "buffer size"} {
+		assert.Contains(t, err.Error(), want)
+	}
+
+	assert.Error(t, NewBuilder().Level("nope").Init())
+}
@@ -0,0 +1,63 @@
+package logm
+
+import (
+	"log/slog"
+	"runtime/debug"
+	"sync"
+)
+
+// BuildKey WithBuildInfo 附加的构建信息分组键名
+const BuildKey = "build"
+
+// buildInfoOnce 缓存构建信息属性，进程内不会变化
+var buildInfoOnce = sync.OnceValue(func() slog.Attr {
+	info, _ := debug.ReadBuildInfo()
+	return buildInfoAttr(info)
+})
+
+// WithBuildInfo 为每条日志附加构建信息分组，标识产生日志的具体构建：
+//
+//	build.version   主模块版本（go install 安装时为 tag，本地构建为 (devel)）
+//	build.revision  vcs.revision，提交哈希
+//	build.vcs_time  vcs.time，提交时间
+//	build.modified  vcs.modified，构建时工作区是否有未提交修改
+//	build.go        Go 版本
+//
+// 未嵌入的字段不输出（如 go run 或 -buildvcs=false 时没有 vcs 信息）。
+// 只需在启动时记录一次时，使用 BuildInfo：
+//
+//	slog.Info("starting", logm.BuildInfo())
+func WithBuildInfo() Option {
+	return WithDefaultAttrs(BuildInfo())
+}
+
+// BuildInfo 返回 WithBuildInfo 使用的构建信息分组属性。
+func BuildInfo() slog.Attr {
+	return buildInfoOnce()
+}
+
+// buildInfoAttr 从 debug.BuildInfo 提取构建信息，info 为 nil 时返回空分组
+func buildInfoAttr(info *debug.BuildInfo) slog.Attr {
+	if info == nil {
+		return slog.Group(BuildKey)
+	}
+
+	var attrs []slog.Attr
+	if v := info.Main.Version; v != "" {
+		attrs = append(attrs, slog.String("version", v))
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			attrs = append(attrs, slog.String("revision", s.Value))
+		case "vcs.time":
+			attrs = append(attrs, slog.String("vcs_time", s.Value))
+		case "vcs.modified":
+			attrs = append(attrs, slog.Bool("modified", s.Value == "true"))
+		}
+	}
+	if info.GoVersion != "" {
+		attrs = append(attrs, slog.String("go", info.GoVersion))
+	}
+	return slog.Attr{Key: BuildKey, Value: slog.GroupValue(attrs...)}
+}
@@ -0,0 +1,61 @@
+package logm
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInfoAttr(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.25.0",
+		Main:      debug.Module{Path: "example.com/app", Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2024-01-15T10:30:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	logger, buf := newBufferLogger(formatter.JSON())
+	logger.Info("starting", buildInfoAttr(info))
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, map[string]any{
+		"version":  "v1.2.3",
+		"revision": "abc123",
+		"vcs_time": "2024-01-15T10:30:00Z",
+		"modified": true,
+		"go":       "go1.25.0",
+	}, m[BuildKey])
+}
+
+func TestBuildInfoAttr_Nil(t *testing.T) {
+	a := buildInfoAttr(nil)
+	assert.Equal(t, BuildKey, a.Key)
+	assert.Empty(t, a.Value.Group())
+}
+
+func TestWithBuildInfo(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(WithBuildInfo(), WithFormatter(formatter.JSON()), WithWriter(&testWriter{buf: &buf}))
+
+	logger.Info("a")
+	logger.Info("b")
+
+	for line := range bytes.Lines(buf.Bytes()) {
+		var m map[string]any
+		require.NoError(t, json.Unmarshal(line, &m))
+		build, ok := m[BuildKey].(map[string]any)
+		require.True(t, ok, string(line))
+		assert.Equal(t, runtime.Version(), build["go"])
+	}
+}
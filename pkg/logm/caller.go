@@ -1,7 +1,9 @@
 package logm
 
 import (
+	"log/slog"
 	"runtime"
+	"slices"
 	"strings"
 )
 
@@ -23,14 +25,7 @@ func CallerPC(skipPkgs ...string) uintptr {
 
 	for {
 		frame, more := frames.Next()
-		skip := false
-		for _, pkg := range skipPkgs {
-			if strings.Contains(frame.Function, pkg) {
-				skip = true
-				break
-			}
-		}
-		if !skip {
+		if !inPackages(frame.Function, skipPkgs) {
 			return frame.PC
 		}
 		if !more {
@@ -39,3 +34,42 @@ func CallerPC(skipPkgs ...string) uintptr {
 	}
 	return 0
 }
+
+// inPackages 判断函数名是否包含 pkgs 中任意字符串
+func inPackages(function string, pkgs []string) bool {
+	for _, pkg := range pkgs {
+		if strings.Contains(function, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// callerSource 在当前调用栈中定位 pc 所在的栈帧，向上跳过 skip 层及 skipPkgs 中的栈帧，
+// 返回调用方的源代码位置。
+//
+// Handler 在记录日志的 goroutine 中同步执行，pc 位于当前调用栈中；
+// 找不到 pc（如 LogWithPC 传入的其他位置）或栈帧不足时返回 false。
+func callerSource(pc uintptr, skip int, skipPkgs []string) (*slog.Source, bool) {
+	var pcs [64]uintptr
+	n := runtime.Callers(2, pcs[:]) // 跳过 runtime.Callers 和 callerSource
+	i := slices.Index(pcs[:n], pc)
+	if i < 0 {
+		return nil, false
+	}
+
+	frames := runtime.CallersFrames(pcs[i:n])
+	for {
+		frame, more := frames.Next()
+		switch {
+		case skip > 0:
+			skip--
+		case inPackages(frame.Function, skipPkgs):
+		default:
+			return &slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}, true
+		}
+		if !more {
+			return nil, false
+		}
+	}
+}
@@ -1,9 +1,16 @@
 package logm
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
 )
 
 func TestCallerPC(t *testing.T) {
@@ -82,3 +89,88 @@ func TestCallerPC_NoMatch(t *testing.T) {
 		t.Errorf("expected function to contain 'TestCallerPC_NoMatch', got %s", frame.Function)
 	}
 }
+
+// auditWrapper 模拟业务方的日志封装函数
+//
+//go:noinline
+func auditWrapper(l *slog.Logger, msg string) {
+	l.Info(msg)
+}
+
+// auditWrapperOuter 两层封装
+//
+//go:noinline
+func auditWrapperOuter(l *slog.Logger, msg string) {
+	auditWrapper(l, msg)
+}
+
+func newCallerTestLogger(buf *bytes.Buffer, opts ...Option) *slog.Logger {
+	opts = append([]Option{
+		WithAddSource(true),
+		WithFormatter(formatter.JSON()),
+		WithWriter(&testWriter{buf: buf}),
+	}, opts...)
+	return New(opts...)
+}
+
+func sourceFunction(t *testing.T, buf *bytes.Buffer) string {
+	t.Helper()
+	var m struct {
+		Source string `json:"source"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("unmarshal %q: %v", buf.String(), err)
+	}
+	buf.Reset()
+	return m.Source
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	var buf bytes.Buffer
+
+	auditWrapper(newCallerTestLogger(&buf), "default")
+	_, _, line, _ := runtime.Caller(0)
+	if src := sourceFunction(t, &buf); !strings.Contains(src, "caller_test.go") || strings.HasSuffix(src, ":"+strconv.Itoa(line-1)) {
+		t.Errorf("without skip, source should point into auditWrapper, got %s", src)
+	}
+
+	auditWrapper(newCallerTestLogger(&buf, WithCallerSkip(1)), "skip")
+	_, _, line, _ = runtime.Caller(0)
+	if src := sourceFunction(t, &buf); !strings.HasSuffix(src, "caller_test.go:"+strconv.Itoa(line-1)) {
+		t.Errorf("expected source at line %d, got %s", line-1, src)
+	}
+}
+
+func TestWithCallerSkipPackages(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newCallerTestLogger(&buf, WithCallerSkipPackages("logm.auditWrapper"))
+
+	auditWrapperOuter(logger, "nested")
+	_, _, line, _ := runtime.Caller(0)
+	if src := sourceFunction(t, &buf); !strings.HasSuffix(src, "caller_test.go:"+strconv.Itoa(line-1)) {
+		t.Errorf("expected source at line %d, got %s", line-1, src)
+	}
+
+	// 派生 logger 继承配置
+	derived, err := Derive(logger, WithLevel("DEBUG"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditWrapper(derived, "derived")
+	_, _, line, _ = runtime.Caller(0)
+	if src := sourceFunction(t, &buf); !strings.HasSuffix(src, "caller_test.go:"+strconv.Itoa(line-1)) {
+		t.Errorf("expected source at line %d, got %s", line-1, src)
+	}
+}
+
+func TestWithCallerSkip_PCNotOnStack(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newCallerTestLogger(&buf, WithCallerSkip(1))
+
+	// LogWithPC 传入的 PC 不在当前调用栈中时回退到原位置
+	pc := CallerPC()
+	LogWithPC(WithLogger(context.Background(), logger), slog.LevelInfo, pc, "pc")
+	if src := sourceFunction(t, &buf); !strings.Contains(src, "caller_test.go") {
+		t.Errorf("expected fallback source in caller_test.go, got %s", src)
+	}
+}
@@ -0,0 +1,73 @@
+package logm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigInfo Handler 的生效配置快照，用于管理接口和诊断输出。
+type ConfigInfo struct {
+	// Level 当前级别
+	Level string
+	// Formatter 格式化器类型，如 "formatter.JSONFormatter"
+	Formatter string
+	// Writers Writer 名称，与 WriterStats.Name 一致
+	Writers []string
+	// Interceptors 拦截器数量
+	Interceptors int
+	// AddSource 是否记录源代码位置
+	AddSource bool
+	// TimeFormat WithTimeFormat 设置的时间格式，自定义格式化器可能使用自己的格式
+	TimeFormat string
+	// Timezone 时区名称
+	Timezone string
+	// MaxRecordSize 单条日志的最大字节数，0 表示不限制
+	MaxRecordSize int
+	// OversizePolicy 超长日志处理策略：truncate 或 drop
+	OversizePolicy string
+	// MaxAttrs 最大属性数，0 表示不限制
+	MaxAttrs int
+	// DuplicateKeys 重复键处理策略：keep_all、last_wins 或 first_wins
+	DuplicateKeys string
+	// Sanitize 是否清理无效 UTF-8 和控制字符
+	Sanitize bool
+	// MessageTemplate 是否替换消息中的 {key} 占位符
+	MessageTemplate bool
+}
+
+// Config 返回 Handler 的生效配置。
+func (h *Handler) Config() ConfigInfo {
+	c := ConfigInfo{
+		Level:           LevelString(h.Level()),
+		Formatter:       strings.TrimPrefix(fmt.Sprintf("%T", h.formatter.load()), "*"),
+		Writers:         make([]string, 0, len(h.writers)),
+		Interceptors:    len(h.interceptors),
+		AddSource:       h.addSource,
+		TimeFormat:      h.timeFormat,
+		MaxRecordSize:   max(h.maxRecordSize, 0),
+		OversizePolicy:  h.oversizePolicy.String(),
+		MaxAttrs:        max(h.maxAttrs, 0),
+		DuplicateKeys:   h.duplicateKeys.String(),
+		Sanitize:        h.sanitize,
+		MessageTemplate: h.msgTemplate,
+	}
+	for _, e := range h.writerEntries() {
+		c.Writers = append(c.Writers, e.name)
+	}
+	if h.location != nil {
+		c.Timezone = h.location.String()
+	}
+	return c
+}
+
+// Config 返回全局日志系统的生效配置，未初始化时返回零值。
+func Config() ConfigInfo {
+	globalMu.RLock()
+	h := globalHandler
+	globalMu.RUnlock()
+
+	if h == nil {
+		return ConfigInfo{}
+	}
+	return h.Config()
+}
@@ -0,0 +1,52 @@
+package logm
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Config(t *testing.T) {
+	var buf bytes.Buffer
+	h := newHandler(
+		WithLevel("warn"),
+		WithFormatter(formatter.JSON()),
+		WithWriter(&testWriter{buf: &buf}),
+		WithTimezone("UTC"),
+		WithMaxRecordSize(1024, OversizeDrop),
+		WithDuplicateKeys(DuplicateLastWins),
+		WithSanitize(),
+	)
+
+	c := h.Config()
+	assert.Equal(t, "WARN", c.Level)
+	assert.Equal(t, "formatter.JSONFormatter", c.Formatter)
+	assert.Equal(t, []string{"logm.testWriter#0"}, c.Writers)
+	assert.Equal(t, "UTC", c.Timezone)
+	assert.Equal(t, 1024, c.MaxRecordSize)
+	assert.Equal(t, "drop", c.OversizePolicy)
+	assert.Equal(t, "last_wins", c.DuplicateKeys)
+	assert.True(t, c.Sanitize)
+	assert.False(t, c.MessageTemplate)
+
+	h.SetLevel(slog.LevelDebug)
+	assert.Equal(t, "DEBUG", h.Config().Level)
+}
+
+func TestConfig_Global(t *testing.T) {
+	_ = Close()
+	assert.Equal(t, ConfigInfo{}, Config())
+
+	var buf bytes.Buffer
+	require.NoError(t, Init(WithLevel("error"), WithWriter(&testWriter{buf: &buf})))
+	defer func() { _ = Close() }()
+
+	c := Config()
+	assert.Equal(t, "ERROR", c.Level)
+	assert.Equal(t, "truncate", c.OversizePolicy)
+	assert.Equal(t, "keep_all", c.DuplicateKeys)
+}
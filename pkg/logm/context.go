@@ -10,6 +10,9 @@ type contextKey struct{}
 
 var loggerKey = contextKey{}
 
+// requestIDKey 是用于 context 中存储请求 ID 的键类型
+type requestIDKey struct{}
+
 // WithLogger 将 logger 存入 context
 //
 // 用于在请求处理链路中传递带有特定上下文信息的 logger
@@ -32,5 +35,23 @@ func FromContext(ctx context.Context) *slog.Logger {
 // 常用于 HTTP 请求处理，用于追踪单个请求的日志
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	logger := FromContext(ctx).With("request_id", requestID)
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
 	return WithLogger(ctx, logger)
 }
+
+// RequestIDFromContext 返回 WithRequestID 存入的请求 ID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// EnsureRequestID 确保 context 带有请求 ID
+//
+// 已有请求 ID 时原样返回，否则使用 NewRequestID 生成并通过 WithRequestID 存入
+func EnsureRequestID(ctx context.Context) (context.Context, string) {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return ctx, id
+	}
+	id := NewRequestID()
+	return WithRequestID(ctx, id), id
+}
@@ -0,0 +1,123 @@
+package logm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// CrashDumper 在崩溃时将最近的日志和调用栈写入崩溃文件。
+//
+// 配合 writer.Ring 使用：Ring 在内存中保留最近 N 条日志，
+// 发生未恢复的 panic 或收到指定信号时，CrashDumper 将这些日志连同调用栈一并落盘，
+// 把“进程悄无声息地退出”变成可排查的报告。
+//
+//	ring := writer.Ring(500)
+//	logm.MustInit(logm.WithWriter(writer.Stdout()), logm.WithWriter(ring))
+//	dumper := logm.NewCrashDumper("/var/log/app.crash", ring)
+//	defer dumper.RecoverAndDump()
+type CrashDumper struct {
+	path string
+	ring *writer.RingWriter
+	mu   sync.Mutex
+}
+
+// NewCrashDumper 创建崩溃转储器。
+//
+// path 为崩溃文件路径，每次转储会覆盖已有文件。
+func NewCrashDumper(path string, ring *writer.RingWriter) *CrashDumper {
+	return &CrashDumper{path: path, ring: ring}
+}
+
+// Dump 将 reason、调用栈和环形缓冲中的日志写入崩溃文件。
+func (d *CrashDumper) Dump(reason string, stack []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var buf bytes.Buffer
+	buf.WriteString("=== logm crash dump ===\n")
+	buf.WriteString("time: " + time.Now().Format(time.RFC3339Nano) + "\n")
+	buf.WriteString("reason: " + reason + "\n")
+
+	buf.WriteString("\n--- stack ---\n")
+	buf.Write(stack)
+	if len(stack) > 0 && stack[len(stack)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	if d.ring != nil {
+		records := d.ring.Records()
+		fmt.Fprintf(&buf, "\n--- last %d records ---\n", len(records))
+		for _, r := range records {
+			buf.Write(r)
+		}
+	}
+
+	return os.WriteFile(d.path, buf.Bytes(), 0o600)
+}
+
+// RecoverAndDump 捕获 panic，写入崩溃文件后重新抛出。
+//
+// 必须直接以 defer 调用，通常放在 main 或 goroutine 入口处。
+// 重新抛出前会尝试刷新全局日志系统。
+func (d *CrashDumper) RecoverAndDump() {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	if err := d.Dump(fmt.Sprintf("panic: %v", v), debug.Stack()); err != nil {
+		selflog.Printf("crash", "write crash dump %s failed: %v", d.path, err)
+	}
+	_ = Sync()
+
+	panic(v)
+}
+
+// DumpOnSignal 收到指定信号时写入崩溃文件（包含所有 goroutine 的调用栈）。
+//
+// 进程不会因此退出，可用于在卡死时获取现场。返回的 stop 函数用于取消监听。
+func (d *CrashDumper) DumpOnSignal(sigs ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				if err := d.Dump("signal: "+sig.String(), allStacks()); err != nil {
+					selflog.Printf("crash", "write crash dump %s failed: %v", d.path, err)
+				}
+			case <-done:
+				signal.Stop(ch)
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// allStacks 返回所有 goroutine 的调用栈
+func allStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 16<<20 {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
@@ -0,0 +1,52 @@
+package logm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrashDumper_RecoverAndDump(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.crash")
+	ring := writer.Ring(2)
+	logger := New(WithWriter(ring))
+	dumper := NewCrashDumper(path, ring)
+
+	logger.Info("first")
+	logger.Info("second")
+	logger.Info("third")
+
+	assert.PanicsWithValue(t, "boom", func() {
+		defer dumper.RecoverAndDump()
+		crash()
+	})
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	dump := string(data)
+	assert.Contains(t, dump, "reason: panic: boom")
+	assert.Contains(t, dump, "--- stack ---")
+	assert.Contains(t, dump, "logm.crash")
+	assert.Contains(t, dump, "--- last 2 records ---")
+	assert.NotContains(t, dump, "msg=first")
+	assert.Contains(t, dump, "msg=second")
+	assert.Contains(t, dump, "msg=third")
+}
+
+func TestCrashDumper_Dump(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.crash")
+	dumper := NewCrashDumper(path, nil)
+
+	require.NoError(t, dumper.Dump("manual", allStacks()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "reason: manual")
+	assert.Contains(t, string(data), "goroutine")
+	assert.NotContains(t, string(data), "records ---")
+}
@@ -0,0 +1,128 @@
+package logm
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// DebugState 日志管道的完整内部状态，用于调试页面和诊断转储。
+type DebugState struct {
+	// Config 生效配置
+	Config ConfigInfo
+	// Interceptors 拦截器函数名，按执行顺序
+	Interceptors []string
+	// NamedLevels SetNamedLevel 配置的命名级别
+	NamedLevels map[string]string
+	// Stats 运行统计，含丢弃计数和队列深度
+	Stats HandlerStats
+	// Writers 每个 Writer 的健康状态
+	Writers []WriterStatus
+	// SelfLog 最近的自诊断消息，见 SetSelfLog
+	SelfLog []string
+}
+
+// DebugState 返回 Handler 的内部状态。
+func (h *Handler) DebugState() DebugState {
+	s := DebugState{
+		Config:       h.Config(),
+		Interceptors: make([]string, len(h.interceptors)),
+		NamedLevels:  NamedLevels(),
+		Stats:        h.Stats(),
+		Writers:      h.Status(),
+		SelfLog:      selflog.Recent(),
+	}
+	for i, ic := range h.interceptors {
+		s.Interceptors[i] = funcName(ic)
+	}
+	return s
+}
+
+// GetDebugState 返回全局日志系统的内部状态，未初始化时只包含命名级别。
+func GetDebugState() DebugState {
+	globalMu.RLock()
+	h := globalHandler
+	globalMu.RUnlock()
+
+	if h == nil {
+		return DebugState{NamedLevels: NamedLevels(), SelfLog: selflog.Recent()}
+	}
+	return h.DebugState()
+}
+
+// WriteText 以 pprof 风格的纯文本输出状态，便于终端和浏览器直接查看。
+func (s DebugState) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	c := s.Config
+
+	fmt.Fprintf(tw, "logm: level=%s formatter=%s timezone=%s\n\n", c.Level, c.Formatter, c.Timezone)
+
+	fmt.Fprintln(tw, "config:")
+	fmt.Fprintf(tw, "  add_source\t%t\n", c.AddSource)
+	fmt.Fprintf(tw, "  time_format\t%s\n", c.TimeFormat)
+	fmt.Fprintf(tw, "  max_record_size\t%d (%s)\n", c.MaxRecordSize, c.OversizePolicy)
+	fmt.Fprintf(tw, "  max_attrs\t%d\n", c.MaxAttrs)
+	fmt.Fprintf(tw, "  duplicate_keys\t%s\n", c.DuplicateKeys)
+	fmt.Fprintf(tw, "  sanitize\t%t\n", c.Sanitize)
+	fmt.Fprintf(tw, "  message_template\t%t\n\n", c.MessageTemplate)
+
+	fmt.Fprintf(tw, "interceptors: %d\n", len(s.Interceptors))
+	for i, name := range s.Interceptors {
+		fmt.Fprintf(tw, "  #%d\t%s\n", i, name)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "level rules: %d\n", len(s.NamedLevels))
+	for _, name := range slices.Sorted(maps.Keys(s.NamedLevels)) {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, s.NamedLevels[name])
+	}
+	fmt.Fprintln(tw)
+
+	st := s.Stats
+	fmt.Fprintln(tw, "records:")
+	for _, name := range levelNames {
+		fmt.Fprintf(tw, "  %s\t%d\n", name, st.Records[name])
+	}
+	fmt.Fprintf(tw, "  bytes_written\t%d\n\n", st.BytesWritten)
+
+	fmt.Fprintln(tw, "drops:")
+	fmt.Fprintf(tw, "  dropped\t%d\n", st.Dropped)
+	fmt.Fprintf(tw, "  oversized\t%d\n", st.Oversized)
+	fmt.Fprintf(tw, "  format_errors\t%d\n", st.FormatErrors)
+	if st.LastWriteError != "" {
+		fmt.Fprintf(tw, "  last_write_error\t%s (%s)\n", st.LastWriteError, st.LastWriteErrorTime.Format(time.RFC3339))
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "writers: %d (queue %d)\n", len(s.Writers), st.QueueDepth)
+	fmt.Fprintln(tw, "  NAME\tCONNECTED\tQUEUE\tDROPPED\tERRORS\tLAST WRITE\tLAST ERROR")
+	for i, ws := range s.Writers {
+		var counters WriterStats
+		if i < len(st.Writers) {
+			counters = st.Writers[i]
+		}
+		fmt.Fprintf(tw, "  %s\t%t\t%d\t%d\t%d\t%s\t%s\n",
+			ws.Name, ws.Connected, ws.QueueDepth, counters.Dropped, counters.WriteErrors,
+			formatDebugTime(ws.LastWrite), ws.LastError)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "self-log: %d\n", len(s.SelfLog))
+	for _, line := range s.SelfLog {
+		fmt.Fprintf(tw, "  %s\n", line)
+	}
+	return tw.Flush()
+}
+
+// formatDebugTime 格式化调试输出中的时间，零值输出 "-"
+func formatDebugTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,64 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func debugInterceptor(_ context.Context, r *Record) *Record { return r }
+
+func TestHandler_DebugState(t *testing.T) {
+	SetNamedLevel("db", "WARN")
+	defer ResetNamedLevel("db")
+
+	var buf bytes.Buffer
+	fw := &flakyWriter{buf: &buf}
+	h := newHandler(
+		WithFormatter(formatter.JSON()),
+		WithWriter(fw),
+		WithInterceptor(debugInterceptor),
+	)
+	logger := slog.New(h)
+	logger.Info("ok")
+	fw.fail = true
+	logger.Error("lost")
+
+	s := h.DebugState()
+	assert.Equal(t, []string{"logm.debugInterceptor"}, s.Interceptors)
+	assert.Equal(t, "WARN", s.NamedLevels["db"])
+	assert.Equal(t, uint64(1), s.Stats.Records["INFO"])
+	require.Len(t, s.Writers, 1)
+	assert.NotEmpty(t, s.Writers[0].LastError)
+
+	var out strings.Builder
+	require.NoError(t, s.WriteText(&out))
+	text := out.String()
+	assert.Contains(t, text, "logm: level=INFO formatter=formatter.JSONFormatter")
+	assert.Contains(t, text, "interceptors: 1")
+	assert.Contains(t, text, "logm.debugInterceptor")
+	assert.Contains(t, text, "level rules: 1")
+	assert.Regexp(t, `db\s+WARN`, text)
+	assert.Regexp(t, `logm.flakyWriter#0\s+true\s+0\s+0\s+1\s+\S+\s+`, text)
+	assert.Contains(t, text, "last_write_error")
+}
+
+func TestGetDebugState(t *testing.T) {
+	_ = Close()
+	s := GetDebugState()
+	assert.Empty(t, s.Writers)
+	assert.NotNil(t, s.NamedLevels)
+
+	var out strings.Builder
+	require.NoError(t, s.WriteText(&out))
+
+	require.NoError(t, Init(WithWriter(&testWriter{buf: &bytes.Buffer{}})))
+	defer func() { _ = Close() }()
+	assert.Equal(t, []string{"logm.testWriter#0"}, GetDebugState().Config.Writers)
+}
@@ -0,0 +1,80 @@
+package logm
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// DuplicateKeyPolicy 同一层级出现重复键时的处理策略
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeepAll 保留所有重复键（默认，与 slog 内置 Handler 行为一致）
+	DuplicateKeepAll DuplicateKeyPolicy = iota
+	// DuplicateLastWins 保留最后出现的值，通常为调用处或拦截器添加的值
+	DuplicateLastWins
+	// DuplicateFirstWins 保留最先出现的值，通常为 WithAttrs 或默认属性添加的值
+	DuplicateFirstWins
+)
+
+// String 返回策略名称：keep_all、last_wins 或 first_wins。
+func (p DuplicateKeyPolicy) String() string {
+	switch p {
+	case DuplicateKeepAll:
+		return "keep_all"
+	case DuplicateLastWins:
+		return "last_wins"
+	case DuplicateFirstWins:
+		return "first_wins"
+	default:
+		return fmt.Sprintf("DuplicateKeyPolicy(%d)", int(p))
+	}
+}
+
+// dedupAttrs 按 policy 去除同一层级的重复键，递归处理分组，空键分组内联到当前层级。
+//
+// 属性按 WithAttrs、调用处、拦截器的顺序排列，保留的属性维持原有相对顺序。
+func dedupAttrs(attrs []slog.Attr, policy DuplicateKeyPolicy) []slog.Attr {
+	if policy == DuplicateKeepAll {
+		return attrs
+	}
+
+	flat := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		flat = appendInline(flat, a)
+	}
+
+	// 记录每个键保留的位置
+	keep := make(map[string]int, len(flat))
+	for i, a := range flat {
+		if _, seen := keep[a.Key]; seen && policy == DuplicateFirstWins {
+			continue
+		}
+		keep[a.Key] = i
+	}
+
+	out := flat[:0]
+	for i, a := range flat {
+		if keep[a.Key] != i {
+			continue
+		}
+		if v := a.Value.Resolve(); v.Kind() == slog.KindGroup {
+			a = slog.Attr{Key: a.Key, Value: slog.GroupValue(dedupAttrs(v.Group(), policy)...)}
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// appendInline 追加属性，空键分组展开到当前层级，空属性丢弃
+func appendInline(dst []slog.Attr, a slog.Attr) []slog.Attr {
+	if a.Key != "" {
+		return append(dst, a)
+	}
+	if v := a.Value.Resolve(); v.Kind() == slog.KindGroup {
+		for _, ga := range v.Group() {
+			dst = appendInline(dst, ga)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,66 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupAttrs(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.String("user", "alice"),
+		slog.Int("n", 1),
+		slog.Group("", slog.String("user", "carol")),
+		slog.Group("http", slog.String("method", "GET"), slog.String("method", "POST")),
+		slog.String("user", "bob"),
+	}
+
+	tests := []struct {
+		policy DuplicateKeyPolicy
+		want   []slog.Attr
+	}{
+		{DuplicateKeepAll, attrs},
+		{DuplicateLastWins, []slog.Attr{
+			slog.Int("n", 1),
+			slog.Group("http", slog.String("method", "POST")),
+			slog.String("user", "bob"),
+		}},
+		{DuplicateFirstWins, []slog.Attr{
+			slog.String("user", "alice"),
+			slog.Int("n", 1),
+			slog.Group("http", slog.String("method", "GET")),
+		}},
+	}
+	for _, tt := range tests {
+		got := dedupAttrs(attrs, tt.policy)
+		assert.Len(t, got, len(tt.want))
+		for i := range tt.want {
+			assert.True(t, tt.want[i].Equal(got[i]), "policy %d: attr %d = %v, want %v", tt.policy, i, got[i], tt.want[i])
+		}
+	}
+}
+
+func TestWithDuplicateKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(
+		WithDuplicateKeys(DuplicateLastWins),
+		WithFormatter(formatter.JSON()),
+		WithWriter(&testWriter{buf: &buf}),
+		WithInterceptor(func(_ context.Context, r *Record) *Record {
+			r.Attrs = append(r.Attrs, slog.String("source_app", "interceptor"))
+			return r
+		}),
+	).With("user", "alice", "source_app", "with")
+
+	logger.Info("login", "user", "bob")
+
+	out := buf.String()
+	assert.Contains(t, out, `"user":"bob"`)
+	assert.NotContains(t, out, "alice")
+	assert.Contains(t, out, `"source_app":"interceptor"`)
+	assert.NotContains(t, out, `"with"`)
+}
@@ -0,0 +1,108 @@
+package logm
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+)
+
+// Derive 基于 logm 创建的 logger 派生新 logger，只覆盖指定的配置。
+//
+// 未覆盖的配置（Formatter、Writer、拦截器、With 添加的属性等）沿用 base：
+//   - WithLevel / WithLevelVar: 使用独立级别；未指定时与 base 共享级别，随 base 动态调整
+//   - WithFormatter: 使用独立格式化器；未指定时与 base 共享，随 base 的 SetFormatter 切换
+//   - WithWriter / WithOutput: 在 base 的 Writer 之外追加输出
+//   - WithInterceptor: 在 base 的拦截器之后追加
+//   - WithDefaultAttrs: 在 base 的属性之后追加
+//   - 其他选项替换 base 的对应配置
+//
+// 示例：为支付模块单独打开调试日志，并额外写入独立文件：
+//
+//	payLog, err := logm.Derive(slog.Default(),
+//	    logm.WithLevel("DEBUG"),
+//	    logm.WithWriter(writer.File("/var/log/payment.log")),
+//	)
+//
+// 派生 logger 与 base 共享 Writer 和写入锁；其 Close 只关闭追加的 Writer，
+// base 关闭后不应继续使用派生 logger。base 不是由 logm 创建时返回错误。
+func Derive(base *slog.Logger, opts ...Option) (*slog.Logger, error) {
+	h, ok := logmHandler(base.Handler())
+	if !ok {
+		return nil, errors.New("logm: derive: base logger is not backed by logm.Handler")
+	}
+
+	o := &options{
+		addSource:      h.addSource,
+		timeFormat:     h.timeFormat,
+		location:       h.location,
+		maxRecordSize:  h.maxRecordSize,
+		oversizePolicy: h.oversizePolicy,
+		onWriteError:   h.onWriteError,
+		clock:          h.clock,
+		callerSkip:     h.callerSkip,
+		callerSkipPkgs: slices.Clip(h.callerSkipPkgs),
+		duplicateKeys:  h.duplicateKeys,
+		maxAttrs:       h.maxAttrs,
+		sanitize:       h.sanitize,
+		msgTemplate:    h.msgTemplate,
+	}
+	o.apply(opts...)
+
+	d := h.clone()
+	if o.formatter != nil {
+		d.formatter = newFormatterRef(o.formatter)
+	}
+	d.addSource = o.addSource
+	d.timeFormat = o.timeFormat
+	d.location = o.location
+	if o.timezone != "" {
+		if loc := mustLoadTimezone(o.timezone); loc != nil {
+			d.location = loc
+		}
+	}
+	d.maxRecordSize = o.maxRecordSize
+	d.oversizePolicy = o.oversizePolicy
+	d.onWriteError = o.onWriteError
+	d.clock = o.clock
+	d.callerSkip = o.callerSkip
+	d.callerSkipPkgs = o.callerSkipPkgs
+	d.duplicateKeys = o.duplicateKeys
+	d.maxAttrs = o.maxAttrs
+	d.sanitize = o.sanitize
+	d.msgTemplate = o.msgTemplate
+
+	switch {
+	case o.levelVar != nil:
+		d.levelVar = o.levelVar
+		if o.level != "" {
+			d.levelVar.Set(ParseLevel(o.level))
+		}
+	case o.level != "":
+		d.levelVar = &slog.LevelVar{}
+		d.levelVar.Set(ParseLevel(o.level))
+	}
+
+	if len(o.interceptors) > 0 {
+		d.interceptors = append(slices.Clip(h.interceptors), o.interceptors...)
+	}
+	if len(o.defaultAttrs) > 0 {
+		d.attrs = d.attrs.push(o.defaultAttrs)
+	}
+
+	d.writers = append(slices.Clip(h.writers), hoistTransforms(o.writers)...)
+	d.flags, d.writers = newFlagState(o.flags, h.flags, d.writers)
+	d.ownFrom = len(h.writers)
+	d.counters = &handlerCounters{writers: make([]writerCounters, len(d.writers))}
+	d.state = &handlerState{mu: h.state.mu}
+
+	return slog.New(d), nil
+}
+
+// logmHandler 取出 h 对应的 *Handler，Named 和 Get 返回的 logger 解析为其当前目标
+func logmHandler(h slog.Handler) (*Handler, bool) {
+	if lh, ok := h.(*lazyHandler); ok {
+		h = lh.handler()
+	}
+	lh, ok := h.(*Handler)
+	return lh, ok
+}
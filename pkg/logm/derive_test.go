@@ -0,0 +1,72 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerive_Overrides(t *testing.T) {
+	var base, extra bytes.Buffer
+	baseW := &closeTrackingWriter{testWriter: testWriter{buf: &base}}
+	extraW := &closeTrackingWriter{testWriter: testWriter{buf: &extra}}
+	parent := New(WithLevel("INFO"), WithFormatter(formatter.Text()), WithWriter(baseW)).With("service", "api")
+
+	child, err := Derive(parent,
+		WithLevel("DEBUG"),
+		WithWriter(extraW),
+		WithInterceptor(func(_ context.Context, r *Record) *Record {
+			r.Attrs = append(r.Attrs, slog.String("module", "payment"))
+			return r
+		}),
+	)
+	require.NoError(t, err)
+
+	child.Debug("charge")
+	parent.Debug("parent debug")
+
+	// 子 logger 写入 base 和追加的 Writer，继承 With 属性
+	assert.Contains(t, base.String(), "msg=charge service=api module=payment")
+	assert.Contains(t, extra.String(), "msg=charge")
+	assert.NotContains(t, base.String(), "parent debug")
+
+	// 只关闭追加的 Writer
+	require.NoError(t, child.Handler().(*Handler).Close())
+	assert.True(t, extraW.closed)
+	assert.False(t, baseW.closed)
+	parent.Info("still works")
+	assert.Contains(t, base.String(), "still works")
+}
+
+func TestDerive_SharesLevel(t *testing.T) {
+	var buf bytes.Buffer
+	lv := &slog.LevelVar{}
+	parent := slog.New(NewHandler(&HandlerConfig{LevelVar: lv, Formatter: formatter.Text(), Writers: []Writer{&testWriter{buf: &buf}}}))
+	child, err := Derive(parent, WithFormatter(formatter.JSON()))
+	require.NoError(t, err)
+
+	lv.Set(slog.LevelError)
+	child.Warn("hidden")
+	assert.Empty(t, buf.String())
+	child.Error("shown")
+	assert.Contains(t, buf.String(), `"msg":"shown"`)
+
+	_, err = Derive(slog.New(slog.NewTextHandler(&buf, nil)))
+	require.Error(t, err)
+}
+
+// closeTrackingWriter 记录是否被关闭
+type closeTrackingWriter struct {
+	testWriter
+	closed bool
+}
+
+func (w *closeTrackingWriter) Close() error {
+	w.closed = true
+	return nil
+}
@@ -63,6 +63,23 @@
 //	writer.File(path, writer.WithRotation(100, 7))  // 带轮转的文件
 //	writer.Async(w, 1000)                    // 异步写入
 //	writer.Multi(w1, w2)                     // 多目标输出
+//	writer.Ring(500)                         // 内存环形缓冲，保留最近日志
+//
+// logmtest 子包提供测试辅助工具，以结构化方式断言日志：
+//
+//	h := logmtest.SetDefault(t)
+//	h.AssertLogged(t, slog.LevelInfo, "user signed up", "user_id", 42)
+//
+// redact 子包提供集中配置的脱敏策略，以拦截器形式接入：
+//
+//	policy, _ := redact.LoadFile("redact.json")
+//	logm.Init(logm.WithInterceptor(policy.Interceptor()))
+//
+// audit 子包提供哈希链防篡改审计日志及校验：
+//
+//	l, _ := audit.OpenFile("audit.log")
+//	slog.New(l.Handler()).Info("user.role_changed", "actor", "admin")
+//	report, err := audit.VerifyFile("audit.log")
 //
 // # Dynamic Level
 //
@@ -71,6 +88,20 @@
 //	logm.SetLevel("DEBUG")  // 开启调试日志
 //	logm.SetLevel("ERROR")  // 只显示错误
 //
+// # Named Loggers and Pipelines
+//
+// Named 返回附带 logger 属性的模块 logger，级别可按名称层级单独调整：
+//
+//	var log = logm.Named("server.http")
+//	logm.SetNamedLevel("server", "DEBUG") // server.http 同样生效
+//
+// 审计、访问等需要独立格式和输出的日志流使用管道：
+//
+//	logm.InitPipelines(map[string][]logm.Option{
+//	    "audit": {logm.WithFormatter(formatter.JSON()), logm.WithWriter(writer.File("audit.log"))},
+//	})
+//	logm.Get("audit").Info("login", "user", "alice")
+//
 // # Interceptors
 //
 // 使用拦截器添加通用字段或过滤日志：
@@ -82,6 +113,13 @@
 //	    }),
 //	)
 //
+// # Stats
+//
+// 通过 Stats 查看日志管道是否在丢失日志：
+//
+//	s := logm.Stats()
+//	fmt.Println(s.Records["ERROR"], s.Dropped, s.Writers[0].WriteErrors)
+//
 // # Context Integration
 //
 // 在 HTTP 请求等场景中，可将 logger 存入 context 实现请求追踪：
@@ -97,4 +135,11 @@
 // 本包所有导出函数都是并发安全的。全局 logger 可在多个 goroutine 中安全使用。
 // [slog.Logger] 实例也是并发安全的，可以在 context 中自由传递。
 // 动态级别调整（SetLevel）也是线程安全的。
+//
+// Handler 及其通过 With/WithGroup 派生的 Handler 共享同一组 Writer，
+// 对 Writer 的 Write、Sync、Close 调用由共享的锁串行化，
+// 因此自定义 Writer 无需自行加锁。Close 和 Shutdown 可与写入并发调用：
+// 返回前所有进行中的写入已完成，Writer 只会被关闭一次，
+// 之后到达的日志会被丢弃并计入 Stats().Dropped。
+// 重复调用 Init 时先切换全局 logger 再关闭旧的 Writer。
 package logm
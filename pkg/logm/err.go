@@ -0,0 +1,93 @@
+package logm
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strconv"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// ErrorKey 错误属性的键名，见 Err。
+const ErrorKey = formatter.ErrorKey
+
+// maxErrChain Err 展开的错误链最大长度
+const maxErrChain = 16
+
+// maxErrStack ErrWithStack 记录的最大栈帧数
+const maxErrStack = 32
+
+// Err 返回标准化的错误属性，键为 "error"，值为包含以下字段的分组：
+//   - msg: err.Error()
+//   - type: 错误的具体类型，如 *fs.PathError
+//   - chain: 被包装的错误，每项为 "<类型>: <消息>"（仅在存在包装时输出）
+//
+// JSON 输出为 {"error":{"msg":...,"type":...}}，文本输出为 error.msg=... error.type=...，
+// 彩色格式以错误颜色显示。err 为 nil 时返回空属性，不会输出：
+//
+//	slog.Error("save failed", logm.Err(err), "path", path)
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	return slog.Attr{Key: ErrorKey, Value: slog.GroupValue(errAttrs(err)...)}
+}
+
+// ErrWithStack 同 Err，额外在 stack 字段记录调用处的调用栈，每项为 "<函数> <文件>:<行号>"。
+func ErrWithStack(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	var pcs [maxErrStack]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make([]string, 0, n)
+	for {
+		f, more := frames.Next()
+		stack = append(stack, f.Function+" "+clipWorkspacePath(f.File)+":"+strconv.Itoa(f.Line))
+		if !more {
+			break
+		}
+	}
+	attrs := append(errAttrs(err), slog.Any("stack", stack))
+	return slog.Attr{Key: ErrorKey, Value: slog.GroupValue(attrs...)}
+}
+
+// errAttrs 返回错误分组的字段
+func errAttrs(err error) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("msg", err.Error()),
+		slog.String("type", fmt.Sprintf("%T", err)),
+	}
+	if chain := errChain(err); len(chain) > 0 {
+		attrs = append(attrs, slog.Any("chain", chain))
+	}
+	return attrs
+}
+
+// errChain 按深度优先顺序展开被包装的错误，支持 Unwrap() error 和 Unwrap() []error
+func errChain(err error) []string {
+	var chain []string
+	var walk func(error)
+	walk = func(e error) {
+		var next []error
+		switch u := e.(type) {
+		case interface{ Unwrap() error }:
+			if w := u.Unwrap(); w != nil {
+				next = []error{w}
+			}
+		case interface{ Unwrap() []error }:
+			next = u.Unwrap()
+		}
+		for _, w := range next {
+			if w == nil || len(chain) >= maxErrChain {
+				continue
+			}
+			chain = append(chain, fmt.Sprintf("%T: %s", w, w.Error()))
+			walk(w)
+		}
+	}
+	walk(err)
+	return chain
+}
@@ -0,0 +1,97 @@
+package logm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBufferLogger(f formatter.Formatter) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	h := NewHandler(&HandlerConfig{
+		LevelVar:  &slog.LevelVar{},
+		Formatter: f,
+		Writers:   []Writer{&testWriter{buf: &buf}},
+	})
+	return slog.New(h), &buf
+}
+
+func TestErr_JSON(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.JSON())
+
+	base := &fs.PathError{Op: "open", Path: "/etc/app.yaml", Err: fs.ErrNotExist}
+	err := fmt.Errorf("load config: %w", base)
+	logger.Error("startup failed", Err(err))
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	e, ok := m["error"].(map[string]any)
+	require.True(t, ok, "error should be a nested object: %s", buf.String())
+	assert.Equal(t, err.Error(), e["msg"])
+	assert.Equal(t, "*fmt.wrapError", e["type"])
+	assert.Equal(t, []any{
+		"*fs.PathError: " + base.Error(),
+		"*errors.errorString: file does not exist",
+	}, e["chain"])
+}
+
+func TestErr_Text(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.Text())
+
+	logger.Error("failed", Err(errors.New("boom")))
+
+	out := buf.String()
+	assert.Contains(t, out, "error.msg=boom")
+	assert.Contains(t, out, "error.type=*errors.errorString")
+	assert.NotContains(t, out, "error.chain")
+}
+
+func TestErr_Nil(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.Text())
+
+	logger.Info("ok", Err(nil), ErrWithStack(nil))
+
+	assert.NotContains(t, buf.String(), "error")
+}
+
+func TestErr_Join(t *testing.T) {
+	err := errors.Join(errors.New("a"), fmt.Errorf("b: %w", errors.New("c")))
+	chain := errChain(err)
+	assert.Equal(t, []string{
+		"*errors.errorString: a",
+		"*fmt.wrapError: b: c",
+		"*errors.errorString: c",
+	}, chain)
+}
+
+func TestErrWithStack(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.JSON())
+
+	logger.Error("failed", ErrWithStack(errors.New("boom")))
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	e := m["error"].(map[string]any)
+	stack, ok := e["stack"].([]any)
+	require.True(t, ok)
+	require.NotEmpty(t, stack)
+	assert.True(t, strings.HasPrefix(stack[0].(string), "github.com/lwmacct/251219-go-pkg-logm/pkg/logm.TestErrWithStack "), stack[0])
+	assert.Contains(t, stack[0], "err_test.go:")
+}
+
+func TestErr_ColorText(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.ColorText())
+
+	logger.Error("failed", Err(errors.New("boom")))
+
+	assert.Contains(t, buf.String(), formatter.ColorRed+`"boom"`)
+}
@@ -0,0 +1,189 @@
+package logm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// EventKey 事件日志附加的事件名属性键名
+const EventKey = "event"
+
+// ErrUnknownEvent 记录未通过 DefineEvent 注册的事件
+var ErrUnknownEvent = errors.New("logm: unknown event")
+
+var (
+	// eventsMu 保护 events
+	eventsMu sync.RWMutex
+	// events 已注册的事件类型
+	events = make(map[string]*EventType)
+	// strictEvents 是否校验事件字段
+	strictEvents atomic.Bool
+)
+
+// EventField 事件字段定义
+type EventField struct {
+	// Key 字段名
+	Key string
+	// Kind 值类型，slog.KindAny 表示不限
+	Kind slog.Kind
+	// Optional 为 true 时字段可以缺省
+	Optional bool
+}
+
+// Field 定义必填的事件字段。
+func Field(key string, kind slog.Kind) EventField {
+	return EventField{Key: key, Kind: kind}
+}
+
+// OptionalField 定义可缺省的事件字段。
+func OptionalField(key string, kind slog.Kind) EventField {
+	return EventField{Key: key, Kind: kind, Optional: true}
+}
+
+// EventType 通过 DefineEvent 注册的事件类型
+type EventType struct {
+	name   string
+	fields []EventField
+}
+
+// DefineEvent 注册事件类型，通常在包级变量中定义，同名事件重复注册时 panic：
+//
+//	var UserSignup = logm.DefineEvent("user.signup",
+//	    logm.Field("user_id", slog.KindInt64),
+//	    logm.Field("plan", slog.KindString),
+//	    logm.OptionalField("referrer", slog.KindString),
+//	)
+//
+//	UserSignup.Log(ctx, "user_id", 42, "plan", "pro")
+//	logm.Event(ctx, "user.signup", "user_id", 42, "plan", "pro") // 等价
+//
+// 面向分析系统的日志借此保持字段稳定。校验仅在 SetStrictEvents(true) 时进行，
+// 见 EventType.Log。
+func DefineEvent(name string, fields ...EventField) *EventType {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	if _, ok := events[name]; ok {
+		panic("logm: event " + name + " already defined")
+	}
+	e := &EventType{name: name, fields: slices.Clone(fields)}
+	events[name] = e
+	return e
+}
+
+// LookupEvent 返回已注册的事件类型。
+func LookupEvent(name string) (*EventType, bool) {
+	eventsMu.RLock()
+	defer eventsMu.RUnlock()
+	e, ok := events[name]
+	return e, ok
+}
+
+// Events 返回已注册的事件类型，按事件名排序。
+func Events() []*EventType {
+	eventsMu.RLock()
+	defer eventsMu.RUnlock()
+	out := make([]*EventType, 0, len(events))
+	for _, e := range events {
+		out = append(out, e)
+	}
+	slices.SortFunc(out, func(a, b *EventType) int { return strings.Compare(a.name, b.name) })
+	return out
+}
+
+// SetStrictEvents 设置是否校验事件字段，建议仅在开发和测试环境开启。
+func SetStrictEvents(enable bool) {
+	strictEvents.Store(enable)
+}
+
+// Name 返回事件名。
+func (e *EventType) Name() string {
+	return e.name
+}
+
+// Fields 返回事件字段定义。
+func (e *EventType) Fields() []EventField {
+	return slices.Clone(e.fields)
+}
+
+// Log 使用 FromContext(ctx) 以 INFO 级别记录事件，消息为事件名，附加 event 属性和 args。
+//
+// 开启 SetStrictEvents 时校验缺少的必填字段、未定义的字段和类型不符的字段，
+// 事件仍会记录，校验错误通过返回值和 logm 自诊断输出报告。
+func (e *EventType) Log(ctx context.Context, args ...any) error {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // 跳过 Callers 和 Log
+	return logEvent(ctx, e.name, e, pcs[0], args)
+}
+
+// Event 按名称记录事件，规则见 EventType.Log。
+//
+// 开启 SetStrictEvents 时未注册的事件返回 ErrUnknownEvent。
+func Event(ctx context.Context, name string, args ...any) error {
+	e, _ := LookupEvent(name)
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // 跳过 Callers 和 Event
+	return logEvent(ctx, name, e, pcs[0], args)
+}
+
+// logEvent 校验并记录事件，e 为 nil 表示未注册
+func logEvent(ctx context.Context, name string, e *EventType, pc uintptr, args []any) error {
+	logger := FromContext(ctx)
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, name, pc)
+	r.AddAttrs(slog.String(EventKey, name))
+	r.Add(args...)
+
+	var err error
+	if strictEvents.Load() {
+		if e == nil {
+			err = fmt.Errorf("%w: %s", ErrUnknownEvent, name)
+		} else {
+			err = e.validate(r)
+		}
+		if err != nil {
+			selflog.Printf("event", "%v", err)
+		}
+	}
+
+	if logger.Enabled(ctx, slog.LevelInfo) {
+		_ = logger.Handler().Handle(ctx, r)
+	}
+	return err
+}
+
+// validate 校验记录中的字段
+func (e *EventType) validate(r slog.Record) error {
+	seen := make(map[string]bool, r.NumAttrs())
+	var errs []error
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == EventKey {
+			return true
+		}
+		seen[a.Key] = true
+		i := slices.IndexFunc(e.fields, func(f EventField) bool { return f.Key == a.Key })
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("logm: event %s: undefined field %q", e.name, a.Key))
+			return true
+		}
+		if want, got := e.fields[i].Kind, a.Value.Resolve().Kind(); want != slog.KindAny && want != got {
+			errs = append(errs, fmt.Errorf("logm: event %s: field %q is %s, want %s", e.name, a.Key, got, want))
+		}
+		return true
+	})
+	for _, f := range e.fields {
+		if !f.Optional && !seen[f.Key] {
+			errs = append(errs, fmt.Errorf("logm: event %s: missing field %q", e.name, f.Key))
+		}
+	}
+	return errors.Join(errs...)
+}
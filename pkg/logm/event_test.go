@@ -0,0 +1,75 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSignupEvent = DefineEvent("test.user.signup",
+	Field("user_id", slog.KindInt64),
+	Field("plan", slog.KindString),
+	OptionalField("referrer", slog.KindString),
+	OptionalField("meta", slog.KindAny),
+)
+
+func TestEvent_Log(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.JSON())
+	ctx := WithLogger(context.Background(), logger)
+
+	require.NoError(t, testSignupEvent.Log(ctx, "user_id", 42, "plan", "pro"))
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, "test.user.signup", m["msg"])
+	assert.Equal(t, "test.user.signup", m[EventKey])
+	assert.InDelta(t, 42, m["user_id"], 0)
+	assert.Equal(t, "pro", m["plan"])
+}
+
+func TestEvent_Strict(t *testing.T) {
+	SetStrictEvents(true)
+	t.Cleanup(func() { SetStrictEvents(false) })
+
+	logger, buf := newBufferLogger(formatter.JSON())
+	ctx := WithLogger(context.Background(), logger)
+
+	assert.NoError(t, Event(ctx, "test.user.signup", "user_id", 1, "plan", "free", "meta", map[string]int{"a": 1}))
+
+	err := Event(ctx, "test.user.signup", "user_id", "not-a-number", "extra", true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `field "user_id" is String, want Int64`)
+	assert.Contains(t, err.Error(), `undefined field "extra"`)
+	assert.Contains(t, err.Error(), `missing field "plan"`)
+	assert.NotContains(t, err.Error(), "referrer")
+
+	err = Event(ctx, "test.no.such.event")
+	assert.ErrorIs(t, err, ErrUnknownEvent)
+
+	// 校验失败的事件仍然记录
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("\n")))
+}
+
+func TestEvent_NotStrict(t *testing.T) {
+	logger, _ := newBufferLogger(formatter.JSON())
+	ctx := WithLogger(context.Background(), logger)
+
+	assert.NoError(t, Event(ctx, "test.no.such.event", "x", 1))
+	assert.NoError(t, testSignupEvent.Log(ctx))
+}
+
+func TestDefineEvent_Duplicate(t *testing.T) {
+	assert.Panics(t, func() { DefineEvent("test.user.signup") })
+
+	e, ok := LookupEvent("test.user.signup")
+	require.True(t, ok)
+	assert.Same(t, testSignupEvent, e)
+	assert.Equal(t, "test.user.signup", e.Name())
+	assert.Len(t, e.Fields(), 4)
+}
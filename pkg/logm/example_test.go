@@ -7,25 +7,29 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
 	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
 )
 
+// exampleClock 示例使用的固定时钟，保证输出稳定
+func exampleClock() time.Time {
+	return time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+}
+
 // Example 展示 logm 包的基本使用方式。
 //
-//nolint:testableexamples // 日志输出不确定，无法验证
+// 示例使用 PresetStable 固定时间并排序属性，使输出可验证。
 func Example() {
-	// 使用 Functional Options 初始化
-	_ = logm.Init(
-		logm.WithLevel("DEBUG"),
-		logm.WithFormatter(formatter.Text()),
-	)
+	_ = logm.Init(logm.PresetStable()...)
 	defer func() { _ = logm.Close() }()
 
-	// 实际使用时，日志会输出到配置的目标
-	// logm.Info("应用启动", "version", "1.0.0")
-	// logm.Debug("调试信息", "key", "value")
+	logm.Info("应用启动", "version", "1.0.0", "env", "dev")
+	logm.Debug("调试信息", "key", "value")
+	// Output:
+	// time=2024-01-15 10:30:45 level=INFO msg=应用启动 env=dev version=1.0.0
+	// time=2024-01-15 10:30:45 level=DEBUG msg=调试信息 key=value
 }
 
 // Example_slogCompatible 展示 logm 与标准库 slog 的完全兼容性。
@@ -102,9 +106,10 @@ func Example_fromEnv() {
 }
 
 // Example_withRequestID 展示如何在请求处理中追踪日志。
-//
-//nolint:testableexamples // 无实际输出
 func Example_withRequestID() {
+	_ = logm.Init(logm.PresetStable()...)
+	defer func() { _ = logm.Close() }()
+
 	ctx := context.Background()
 
 	// 为请求添加追踪 ID
@@ -112,7 +117,8 @@ func Example_withRequestID() {
 
 	// 从 context 获取带有 request_id 的 logger
 	log := logm.FromContext(ctx)
-	_ = log // log.Info("处理请求") 会自动包含 request_id 字段
+	log.Info("处理请求", "path", "/api/users")
+	// Output: time=2024-01-15 10:30:45 level=INFO msg=处理请求 path=/api/users request_id=req-12345
 }
 
 // Example_new 展示如何创建独立的 logger 实例。
@@ -232,36 +238,39 @@ func Example_coloredOutput() {
 // Example_jsonOutput 展示 JSON 输出配置。
 //
 // JSON 输出适合生产环境，便于日志采集和分析。
-//
-//nolint:testableexamples // 日志输出不确定，无法验证
+// 示例使用 WithClock 固定时间，使输出可验证。
 func Example_jsonOutput() {
 	_ = logm.Init(
 		logm.WithLevel("INFO"),
 		logm.WithFormatter(formatter.JSON(
 			formatter.WithTimeFormat("rfc3339ms"),
+			formatter.WithTimezone("UTC"),
 		)),
+		logm.WithClock(exampleClock),
 	)
 	defer func() { _ = logm.Close() }()
 
 	// JSON 输出格式化后易于机器解析
-	// logm.Info("API 请求", "method", "POST", "path", "/api/users")
+	logm.Info("API 请求", "method", "POST", "path", "/api/users")
+	// Output:
+	// {"time":"2024-01-15T10:30:45.000Z","level":"INFO","msg":"API 请求","method":"POST","path":"/api/users"}
 }
 
 // Example_dynamicLevel 展示动态调整日志级别。
-//
-//nolint:testableexamples // 日志输出不确定，无法验证
 func Example_dynamicLevel() {
-	_ = logm.Init(logm.WithLevel("INFO"))
+	_ = logm.Init(append(logm.PresetStable(), logm.WithLevel("INFO"))...)
 	defer func() { _ = logm.Close() }()
 
-	// 运行时动态调整日志级别
-	logm.SetLevel("DEBUG")
+	logm.Debug("不会输出")
 
-	// 现在 DEBUG 级别的日志可以输出了
-	// logm.Debug("动态启用调试日志")
+	// 运行时动态调整日志级别，现在 DEBUG 级别的日志可以输出了
+	logm.SetLevel("DEBUG")
+	logm.Debug("动态启用调试日志")
 
 	// 切换回 INFO 级别
 	logm.SetLevel("INFO")
+	logm.Debug("再次被过滤")
+	// Output: time=2024-01-15 10:30:45 level=DEBUG msg=动态启用调试日志
 }
 
 // Example_slogGroup 展示使用 slog.Group 组织结构化日志。
@@ -0,0 +1,49 @@
+package logm
+
+import (
+	"expvar"
+	"time"
+)
+
+// PublishExpvar 将全局日志系统的状态发布到 expvar。
+//
+// 发布后可通过 /debug/vars 查看日志级别、缓冲队列深度、丢弃计数和最近的写入错误，
+// 无需额外依赖。name 为空时使用 "logm"；重复发布同名变量时不做任何操作。
+//
+// 示例：
+//
+//	logm.PublishExpvar("")
+//	http.ListenAndServe(":6060", nil) // expvar 自动注册 /debug/vars
+func PublishExpvar(name string) {
+	if name == "" {
+		name = "logm"
+	}
+
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	if expvar.Get(name) != nil {
+		return
+	}
+	expvar.Publish(name, expvar.Func(expvarState))
+}
+
+// expvarState 生成 expvar 输出的状态快照
+func expvarState() any {
+	s := Stats()
+
+	state := map[string]any{
+		"level":         GetLevel(),
+		"records":       s.Records,
+		"bytes_written": s.BytesWritten,
+		"dropped":       s.Dropped,
+		"oversized":     s.Oversized,
+		"format_errors": s.FormatErrors,
+		"queue_depth":   s.QueueDepth,
+	}
+	if s.LastWriteError != "" {
+		state["last_write_error"] = s.LastWriteError
+		state["last_write_error_time"] = s.LastWriteErrorTime.Format(time.RFC3339)
+	}
+	return state
+}
@@ -0,0 +1,37 @@
+package logm
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Init(
+		WithLevel("WARN"),
+		WithWriter(&testWriter{buf: &buf}),
+		WithWriter(&errWriter{}),
+	))
+	defer func() { _ = Close() }()
+
+	PublishExpvar("logm_test")
+	// 重复发布不应 panic
+	assert.NotPanics(t, func() { PublishExpvar("logm_test") })
+
+	Error("boom")
+
+	v := expvar.Get("logm_test")
+	require.NotNil(t, v)
+
+	var state map[string]any
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &state))
+	assert.Equal(t, "WARN", state["level"])
+	assert.InDelta(t, 0, state["queue_depth"], 0)
+	assert.Contains(t, state["last_write_error"], "logm.errWriter#1: write failed")
+	assert.NotEmpty(t, state["last_write_error_time"])
+}
@@ -0,0 +1,170 @@
+package logm
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// FlagEvaluator 特性开关的求值接口，方法与 OpenFeature 客户端的 BooleanValue、StringValue 对应。
+//
+// 求值失败时应返回 def。本模块不依赖 OpenFeature SDK，适配 *openfeature.Client 只需几行：
+//
+//	type ofFlags struct{ c *openfeature.Client }
+//
+//	func (f ofFlags) BoolFlag(ctx context.Context, flag string, def bool, fc logm.FlagContext) bool {
+//	    v, _ := f.c.BooleanValue(ctx, flag, def, openfeature.NewEvaluationContext(fc.TargetingKey, fc.Attrs))
+//	    return v
+//	}
+//
+//	func (f ofFlags) StringFlag(ctx context.Context, flag, def string, fc logm.FlagContext) string {
+//	    v, _ := f.c.StringValue(ctx, flag, def, openfeature.NewEvaluationContext(fc.TargetingKey, fc.Attrs))
+//	    return v
+//	}
+type FlagEvaluator interface {
+	BoolFlag(ctx context.Context, flag string, def bool, fc FlagContext) bool
+	StringFlag(ctx context.Context, flag, def string, fc FlagContext) string
+}
+
+// FlagContext 开关的评估上下文，取自日志记录的属性（包括 With 添加的属性）。
+type FlagContext struct {
+	// TargetingKey FlagConfig.Keys 中第一个存在的属性的值，通常为用户或租户 ID
+	TargetingKey string
+	// Attrs FlagConfig.Keys 中存在的属性，键为配置的路径
+	Attrs map[string]any
+}
+
+// FlagConfig 由特性开关控制的日志行为，见 WithFeatureFlags。
+type FlagConfig struct {
+	// Evaluator 开关求值，为 nil 时不启用
+	Evaluator FlagEvaluator
+	// Keys 组成评估上下文的属性路径，如 "user_id"、"tenant"、"http.client_ip"
+	Keys []string
+	// LevelFlag 字符串开关，值为级别名称；低于全局级别的日志在不低于该级别时仍然输出
+	LevelFlag string
+	// SinkFlag 布尔开关，为 true 的日志额外写入 Sinks
+	SinkFlag string
+	// Sinks SinkFlag 打开时额外写入的 Writer，与 WithWriter 添加的 Writer 一样由 Handler 关闭
+	Sinks []Writer
+}
+
+// WithFeatureFlags 按特性开关为特定用户、租户单独调整日志：
+//
+//	logm.Init(
+//	    logm.WithLevel("INFO"),
+//	    logm.WithFeatureFlags(logm.FlagConfig{
+//	        Evaluator: ofFlags{client},
+//	        Keys:      []string{"user_id", "tenant"},
+//	        LevelFlag: "log-level",  // 对灰度用户返回 "DEBUG"
+//	        SinkFlag:  "log-trace-sink",
+//	        Sinks:     []logm.Writer{writer.File("/var/log/app/trace.log")},
+//	    }),
+//	)
+//
+//	log := slog.With("user_id", uid)
+//	log.Debug("cart loaded", "items", n) // 仅在 log-level 对该用户为 DEBUG 时输出
+//
+// 设置 LevelFlag 后 DEBUG 及以上的日志都会进入 Handler，对低于全局级别的日志逐条求值，
+// 有一定开销；不低于全局级别的日志只在设置了 SinkFlag 时求值。求值在拦截器之前进行，
+// 只能看到记录自身和 With 添加的属性。
+//
+// 用于 Derive 时与 base 的开关配置合并：cfg 中非零的字段覆盖 base 的对应字段，
+// base 的 Sinks 仍只接收 SinkFlag 打开的日志。
+func WithFeatureFlags(cfg FlagConfig) Option {
+	return func(o *options) {
+		if cfg.Evaluator == nil {
+			o.flags = nil
+			return
+		}
+		o.flags = &cfg
+	}
+}
+
+// flagState Handler 中的特性开关配置
+type flagState struct {
+	FlagConfig
+
+	sinkIdx []int // Sinks 在 Handler.writers 中的下标
+}
+
+// newFlagState 将 cfg.Sinks 追加到 writers 末尾，返回新的 writers。
+//
+// base 为 Derive 的基础 Handler 的开关配置：cfg 中非零的字段覆盖 base 的对应字段，
+// base 的 Sinks 与 cfg.Sinks 一起只接收 SinkFlag 打开的日志。cfg 为 nil 时返回 base。
+func newFlagState(cfg *FlagConfig, base *flagState, writers []Writer) (*flagState, []Writer) {
+	if cfg == nil || cfg.Evaluator == nil {
+		return base, writers
+	}
+	f := &flagState{FlagConfig: *cfg}
+	if base != nil {
+		f.FlagConfig = base.FlagConfig
+		f.Evaluator = cfg.Evaluator
+		if len(cfg.Keys) > 0 {
+			f.Keys = cfg.Keys
+		}
+		if cfg.LevelFlag != "" {
+			f.LevelFlag = cfg.LevelFlag
+		}
+		if cfg.SinkFlag != "" {
+			f.SinkFlag = cfg.SinkFlag
+		}
+		f.Sinks = append(slices.Clip(base.Sinks), cfg.Sinks...)
+		f.sinkIdx = slices.Clip(base.sinkIdx)
+	}
+	for i := range cfg.Sinks {
+		f.sinkIdx = append(f.sinkIdx, len(writers)+i)
+	}
+	return f, append(writers[:len(writers):len(writers)], cfg.Sinks...)
+}
+
+// enabled 判断低于全局级别的 level 是否可能被开关打开
+func (f *flagState) enabled(level slog.Level) bool {
+	return f.LevelFlag != "" && level >= slog.LevelDebug
+}
+
+// gated 判断第 i 个 Writer 是否只接收 SinkFlag 打开的日志
+func (f *flagState) gated(i int) bool {
+	return slices.Contains(f.sinkIdx, i)
+}
+
+// evaluate 对记录求值，返回是否输出以及是否写入 Sinks
+func (f *flagState) evaluate(ctx context.Context, rec *Record, base slog.Level) (keep, sinks bool) {
+	below := rec.Level < base
+	useSinks := f.SinkFlag != "" && len(f.Sinks) > 0
+	if !below && !useSinks {
+		return true, false
+	}
+	if below && f.LevelFlag == "" {
+		return false, false
+	}
+
+	fc := f.context(rec)
+	if below {
+		level, ok := formatter.ParseLevelName(f.Evaluator.StringFlag(ctx, f.LevelFlag, "", fc))
+		if !ok || rec.Level < level {
+			return false, false
+		}
+	}
+	if useSinks {
+		sinks = f.Evaluator.BoolFlag(ctx, f.SinkFlag, false, fc)
+	}
+	return true, sinks
+}
+
+// context 从记录属性构建评估上下文
+func (f *flagState) context(rec *Record) FlagContext {
+	fc := FlagContext{Attrs: make(map[string]any, len(f.Keys))}
+	for _, key := range f.Keys {
+		v, ok := lookupAttr(rec.Attrs, key)
+		if !ok {
+			continue
+		}
+		fc.Attrs[key] = v.Any()
+		if fc.TargetingKey == "" {
+			fc.TargetingKey = v.String()
+		}
+	}
+	return fc
+}
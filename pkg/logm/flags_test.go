@@ -0,0 +1,159 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFlags 按 TargetingKey 返回开关值的 FlagEvaluator
+type fakeFlags struct {
+	mu     sync.Mutex
+	levels map[string]string
+	sinks  map[string]bool
+	seen   []FlagContext
+}
+
+func (f *fakeFlags) BoolFlag(_ context.Context, flag string, def bool, fc FlagContext) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seen = append(f.seen, fc)
+	if v, ok := f.sinks[fc.TargetingKey]; ok && flag == "trace-sink" {
+		return v
+	}
+	return def
+}
+
+func (f *fakeFlags) StringFlag(_ context.Context, flag, def string, fc FlagContext) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seen = append(f.seen, fc)
+	if v, ok := f.levels[fc.TargetingKey]; ok && flag == "log-level" {
+		return v
+	}
+	return def
+}
+
+func TestFeatureFlags_Level(t *testing.T) {
+	var buf bytes.Buffer
+	flags := &fakeFlags{levels: map[string]string{"alice": "DEBUG", "bob": "WARN"}}
+	logger := New(
+		WithLevel("INFO"),
+		WithFormatter(formatter.Text()),
+		WithWriter(&testWriter{buf: &buf}),
+		WithFeatureFlags(FlagConfig{Evaluator: flags, Keys: []string{"user", "tenant"}, LevelFlag: "log-level"}),
+	)
+
+	assert.True(t, logger.Enabled(context.Background(), slog.LevelDebug))
+	assert.False(t, logger.Enabled(context.Background(), slog.LevelDebug-4))
+
+	logger.With("user", "alice").Debug("alice debug")
+	logger.Debug("bob debug", "user", "bob")
+	logger.Debug("anonymous debug")
+	logger.Info("bob info", "user", "bob") // 不低于全局级别，不求值
+
+	out := buf.String()
+	assert.Contains(t, out, "alice debug")
+	assert.NotContains(t, out, "bob debug")
+	assert.NotContains(t, out, "anonymous debug")
+	assert.Contains(t, out, "bob info")
+	assert.Len(t, flags.seen, 3)
+	assert.Equal(t, FlagContext{TargetingKey: "alice", Attrs: map[string]any{"user": "alice"}}, flags.seen[0])
+}
+
+func TestFeatureFlags_Sinks(t *testing.T) {
+	var main, trace bytes.Buffer
+	flags := &fakeFlags{sinks: map[string]bool{"acme": true}}
+	h := newHandler(
+		WithFormatter(formatter.Text()),
+		WithWriter(&testWriter{buf: &main}),
+		WithFeatureFlags(FlagConfig{
+			Evaluator: flags,
+			Keys:      []string{"req.tenant"},
+			SinkFlag:  "trace-sink",
+			Sinks:     []Writer{&testWriter{buf: &trace}},
+		}),
+	)
+	logger := slog.New(h)
+
+	assert.False(t, logger.Enabled(context.Background(), slog.LevelDebug), "no LevelFlag, debug stays off")
+	logger.Info("acme", slog.Group("req", slog.String("tenant", "acme")))
+	logger.Info("other", slog.Group("req", slog.String("tenant", "other")))
+
+	assert.Equal(t, 2, strings.Count(main.String(), "\n"))
+	assert.Contains(t, trace.String(), "msg=acme")
+	assert.NotContains(t, trace.String(), "other")
+	assert.Equal(t, []string{"logm.testWriter#0", "logm.testWriter#1"}, h.Config().Writers)
+	assert.False(t, h.Status()[1].LastWrite.IsZero(), "sink writes are tracked like other writers")
+}
+
+func TestFeatureFlags_Derive(t *testing.T) {
+	var main, trace bytes.Buffer
+	flags := &fakeFlags{sinks: map[string]bool{"alice": true}}
+	base := New(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &main}))
+
+	derived, err := Derive(base, WithFeatureFlags(FlagConfig{
+		Evaluator: flags,
+		Keys:      []string{"user"},
+		SinkFlag:  "trace-sink",
+		Sinks:     []Writer{&testWriter{buf: &trace}},
+	}))
+	require.NoError(t, err)
+	derived.Info("hi", "user", "alice")
+	base.Info("base", "user", "alice")
+
+	assert.Equal(t, 2, strings.Count(main.String(), "\n"))
+	assert.Equal(t, 1, strings.Count(trace.String(), "\n"))
+}
+
+func TestFeatureFlags_DeriveMergesBase(t *testing.T) {
+	var main, baseTrace, ownTrace bytes.Buffer
+	flags := &fakeFlags{
+		levels: map[string]string{"alice": "DEBUG"},
+		sinks:  map[string]bool{"alice": true},
+	}
+	base := New(
+		WithFormatter(formatter.Text()),
+		WithWriter(&testWriter{buf: &main}),
+		WithFeatureFlags(FlagConfig{
+			Evaluator: flags,
+			Keys:      []string{"user"},
+			SinkFlag:  "trace-sink",
+			Sinks:     []Writer{&testWriter{buf: &baseTrace}},
+		}),
+	)
+
+	// 只覆盖 LevelFlag 并追加 Sink，Keys 和 SinkFlag 沿用 base
+	derived, err := Derive(base, WithFeatureFlags(FlagConfig{
+		Evaluator: flags,
+		LevelFlag: "log-level",
+		Sinks:     []Writer{&testWriter{buf: &ownTrace}},
+	}))
+	require.NoError(t, err)
+
+	derived.Info("bob info", "user", "bob")
+	derived.Debug("alice debug", "user", "alice")
+	derived.Debug("bob debug", "user", "bob")
+
+	assert.Contains(t, main.String(), "bob info")
+	assert.Contains(t, main.String(), "alice debug")
+	assert.NotContains(t, main.String(), "bob debug")
+	for name, out := range map[string]string{"base sink": baseTrace.String(), "derived sink": ownTrace.String()} {
+		assert.Contains(t, out, "alice debug", name)
+		assert.NotContains(t, out, "bob", name+" stays gated")
+	}
+}
+
+func TestWithFeatureFlags_NilEvaluator(t *testing.T) {
+	var buf bytes.Buffer
+	h := newHandler(WithWriter(&testWriter{buf: &buf}), WithFeatureFlags(FlagConfig{LevelFlag: "x"}))
+	assert.Nil(t, h.flags)
+	assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"log/slog"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -39,11 +41,8 @@ func (f *ColorTextFormatter) Format(r *Record) ([]byte, error) {
 	defer putBuffer(buf)
 
 	// 时间
-	t := r.Time
-	if f.opts.Location != nil {
-		t = t.In(f.opts.Location)
-	}
-	f.writeColored(buf, f.opts.ColorScheme.Time, formatTime(t, f.opts.TimeFormat))
+	t := f.opts.recordTime(r)
+	f.writeColored(buf, f.opts.ColorScheme.Time, f.opts.timeText(t))
 	buf.WriteByte(' ')
 
 	// 级别（带颜色）
@@ -53,8 +52,13 @@ func (f *ColorTextFormatter) Format(r *Record) ([]byte, error) {
 	// 消息（无色）
 	buf.WriteString(r.Message)
 
-	// 属性
-	f.writeAttrs(buf, r.Attrs, r.Groups)
+	// 属性，多行值写入 tail，在日志行之后输出
+	var tail *bytes.Buffer
+	if f.opts.MultiLine {
+		tail = getBuffer()
+		defer putBuffer(tail)
+	}
+	f.writeAttrs(buf, tail, f.opts.recordAttrs(r), r.Groups)
 
 	// 源代码位置
 	if r.Source != nil {
@@ -63,6 +67,9 @@ func (f *ColorTextFormatter) Format(r *Record) ([]byte, error) {
 	}
 
 	buf.WriteByte('\n')
+	if tail != nil {
+		buf.Write(tail.Bytes())
+	}
 
 	return copyBytes(buf.Bytes()), nil
 }
@@ -93,54 +100,171 @@ func (f *ColorTextFormatter) writeColored(buf *bytes.Buffer, color, text string)
 	}
 }
 
-// writeAttrs 写入属性
-func (f *ColorTextFormatter) writeAttrs(buf *bytes.Buffer, attrs []slog.Attr, groups []string) {
+// writeAttrs 写入属性，tail 非 nil 时含换行的值写入 tail
+func (f *ColorTextFormatter) writeAttrs(buf, tail *bytes.Buffer, attrs []slog.Attr, groups []string) {
+	if f.opts.NestedGroup {
+		for _, attr := range nestAttrs(attrs, groups) {
+			f.writeAttr(buf, tail, attr, "", false)
+		}
+		return
+	}
+
 	prefix := ""
-	var prefixSb140 strings.Builder
-	for _, g := range groups {
-		prefixSb140.WriteString(g + ".")
+	if len(groups) > 0 {
+		prefix = strings.Join(groups, ".") + "."
 	}
-	prefix += prefixSb140.String()
 
 	for _, attr := range attrs {
-		if attr.Key == "" {
-			continue
-		}
-		buf.WriteByte(' ')
-		f.writeAttr(buf, attr, prefix)
+		f.writeAttr(buf, tail, attr, prefix, false)
 	}
 }
 
-// writeAttr 写入单个属性
-func (f *ColorTextFormatter) writeAttr(buf *bytes.Buffer, attr slog.Attr, prefix string) {
+// writeAttr 写入单个属性（含前导空格）。
+//
+// 分组和 JSON 内容展开为平铺的 key.sub=value 形式，空键分组内联到当前层级；
+// 启用 NestedGroup 时分组输出为 group={key=value}。
+// ErrorKey 属性及其分组内的值以错误颜色显示。
+// tail 非 nil 时含换行的字符串和错误值以续行形式写入 tail。
+func (f *ColorTextFormatter) writeAttr(buf, tail *bytes.Buffer, attr slog.Attr, prefix string, inErr bool) {
+	v := attr.Value.Resolve()
 	key := prefix + attr.Key
-	f.writeColored(buf, f.opts.ColorScheme.Key, key)
-	buf.WriteByte('=')
+	inErr = inErr || attr.Key == ErrorKey
+
+	// 启用 NestedGroup 时输出为 group={key=value}
+	if v.Kind() == slog.KindGroup && f.opts.NestedGroup && attr.Key != "" {
+		if len(v.Group()) == 0 {
+			return
+		}
+		buf.WriteByte(' ')
+		f.writeColored(buf, f.opts.ColorScheme.Key, quoteTextKey(key))
+		buf.WriteString("={")
+		start := buf.Len()
+		for _, ga := range v.Group() {
+			f.writeAttr(buf, tail, ga, "", inErr)
+		}
+		trimGroupSpace(buf, start)
+		buf.WriteByte('}')
+		return
+	}
+
+	// 展开分组为平铺格式
+	if v.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix = key + "."
+		}
+		for _, ga := range v.Group() {
+			f.writeAttr(buf, tail, ga, prefix, inErr)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+
+	if tail != nil && !f.opts.RawFields[attr.Key] {
+		if s, isErr := multiLineText(v); strings.Contains(s, "\n") {
+			color := f.opts.ColorScheme.String
+			if inErr || isErr {
+				color = f.opts.ColorScheme.Error
+			}
+			f.writeBlock(tail, key, s, color)
+			return
+		}
+	}
+
+	buf.WriteByte(' ')
+
+	if inErr {
+		if s, ok := errorText(v); ok {
+			f.writeColored(buf, f.opts.ColorScheme.Key, quoteTextKey(key))
+			buf.WriteByte('=')
+			f.writeColored(buf, f.opts.ColorScheme.Error, strconv.Quote(s))
+			return
+		}
+	}
 
 	// 检查是否为 raw 字段（不加引号直接输出，但保留颜色）
 	if f.opts.RawFields[attr.Key] {
-		f.writeColored(buf, f.opts.ColorScheme.String, attr.Value.Resolve().String())
+		f.writeColored(buf, f.opts.ColorScheme.Key, quoteTextKey(key))
+		buf.WriteByte('=')
+		f.writeColored(buf, f.opts.ColorScheme.String, v.String())
+		return
+	}
+
+	// 尝试展开 JSON 内容
+	if expanded := f.tryFlattenValue(v, key); expanded != "" {
+		buf.WriteString(expanded)
 		return
 	}
 
-	f.writeValue(buf, attr.Value, key)
+	f.writeColored(buf, f.opts.ColorScheme.Key, quoteTextKey(key))
+	buf.WriteByte('=')
+	f.writeValue(buf, v)
 }
 
-// writeValue 写入值
-func (f *ColorTextFormatter) writeValue(buf *bytes.Buffer, v slog.Value, keyPath string) {
-	v = v.Resolve()
+// multiLineIndent 多行值续行的缩进
+const multiLineIndent = "    "
+
+// multiLineText 返回可按多行输出的文本：字符串或 error，isErr 表示值为 error
+func multiLineText(v slog.Value) (s string, isErr bool) {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String(), false
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error(), true
+		}
+	default:
+	}
+	return "", false
+}
+
+// writeBlock 以缩进续行写入多行值，key 单独一行
+func (f *ColorTextFormatter) writeBlock(tail *bytes.Buffer, key, s, color string) {
+	tail.WriteString(multiLineIndent)
+	f.writeColored(tail, f.opts.ColorScheme.Key, quoteTextKey(key))
+	tail.WriteString(":\n")
+	for line := range strings.SplitSeq(strings.TrimRight(s, "\n"), "\n") {
+		tail.WriteString(multiLineIndent + multiLineIndent)
+		f.writeColored(tail, color, strings.TrimSuffix(line, "\r"))
+		tail.WriteByte('\n')
+	}
+}
+
+// tryFlattenValue 尝试将 JSON 字符串或复杂类型展开为平铺格式，无法展开时返回空字符串
+func (f *ColorTextFormatter) tryFlattenValue(v slog.Value, keyPath string) string {
+	if !f.flattenJSON {
+		return ""
+	}
 
 	switch v.Kind() {
 	case slog.KindString:
 		s := v.String()
-		// 尝试展开 JSON 字符串
-		if f.flattenJSON && len(s) > 0 && (s[0] == '{' || s[0] == '[') {
-			if expanded := f.tryFlattenJSON(s, keyPath); expanded != "" {
-				buf.WriteString(expanded)
-				return
-			}
+		if len(s) > 0 && (s[0] == '{' || s[0] == '[') {
+			return f.tryFlattenJSON(s, keyPath)
+		}
+	case slog.KindAny:
+		val := v.Any()
+		if val == nil {
+			return ""
 		}
-		f.writeColored(buf, f.opts.ColorScheme.String, strconv.Quote(s))
+		if _, ok := val.(error); ok {
+			return ""
+		}
+		data, err := marshalJSON(val, f.opts.TagHasher)
+		if err == nil && len(data) > 0 && (data[0] == '{' || data[0] == '[') {
+			return f.tryFlattenJSON(string(data), keyPath)
+		}
+	default:
+	}
+	return ""
+}
+
+// writeValue 写入值
+func (f *ColorTextFormatter) writeValue(buf *bytes.Buffer, v slog.Value) {
+	switch v.Kind() {
+	case slog.KindString:
+		f.writeColored(buf, f.opts.ColorScheme.String, strconv.Quote(v.String()))
 
 	case slog.KindInt64:
 		f.writeColored(buf, f.opts.ColorScheme.Number, strconv.FormatInt(v.Int64(), 10))
@@ -166,20 +290,10 @@ func (f *ColorTextFormatter) writeValue(buf *bytes.Buffer, v slog.Value, keyPath
 		if f.opts.Location != nil {
 			t = t.In(f.opts.Location)
 		}
-		f.writeColored(buf, f.opts.ColorScheme.String, strconv.Quote(formatTime(t, f.opts.TimeFormat)))
-
-	case slog.KindGroup:
-		// 展开分组为平铺格式
-		attrs := v.Group()
-		for i, attr := range attrs {
-			if i > 0 {
-				buf.WriteByte(' ')
-			}
-			f.writeAttr(buf, attr, keyPath+".")
-		}
+		f.writeColored(buf, f.opts.ColorScheme.String, strconv.Quote(f.opts.timeText(t)))
 
 	case slog.KindAny:
-		f.writeAny(buf, v.Any(), keyPath)
+		f.writeAny(buf, v.Any())
 
 	default:
 		f.writeColored(buf, f.opts.ColorScheme.String, strconv.Quote(v.String()))
@@ -187,25 +301,20 @@ func (f *ColorTextFormatter) writeValue(buf *bytes.Buffer, v slog.Value, keyPath
 }
 
 // writeAny 写入任意类型
-func (f *ColorTextFormatter) writeAny(buf *bytes.Buffer, v any, keyPath string) {
+func (f *ColorTextFormatter) writeAny(buf *bytes.Buffer, v any) {
 	if v == nil {
 		f.writeColored(buf, f.opts.ColorScheme.Null, "null")
 		return
 	}
 
-	// 尝试 JSON 序列化后平铺
-	if f.flattenJSON {
-		data, err := json.Marshal(v)
-		if err == nil && len(data) > 0 {
-			if expanded := f.tryFlattenJSON(string(data), keyPath); expanded != "" {
-				buf.WriteString(expanded)
-				return
-			}
-		}
+	// error 接口序列化为 {}，改为输出错误信息
+	if err, ok := v.(error); ok {
+		f.writeColored(buf, f.opts.ColorScheme.Error, strconv.Quote(err.Error()))
+		return
 	}
 
 	// 回退到简单字符串
-	data, err := json.Marshal(v)
+	data, err := marshalJSON(v, f.opts.TagHasher)
 	if err != nil {
 		f.writeColored(buf, f.opts.ColorScheme.String, "<error>")
 		return
@@ -221,20 +330,32 @@ func (f *ColorTextFormatter) tryFlattenJSON(s string, keyPath string) string {
 	}
 
 	var parts []string
-	f.flattenValue(data, keyPath, &parts)
+	f.flattenValue(data, keyPath, 0, &parts)
 	return strings.Join(parts, " ")
 }
 
+// maxFlattenDepth JSON 展开的最大嵌套深度，更深的部分以紧凑 JSON 输出
+const maxFlattenDepth = 32
+
 // flattenValue 递归展开值
-func (f *ColorTextFormatter) flattenValue(v any, path string, parts *[]string) {
+func (f *ColorTextFormatter) flattenValue(v any, path string, depth int, parts *[]string) {
 	switch val := v.(type) {
 	case map[string]any:
-		for k, v := range val {
-			f.flattenValue(v, path+"."+k, parts)
+		if depth >= maxFlattenDepth {
+			f.flattenCompact(val, path, parts)
+			return
+		}
+		// 按键排序，保证输出稳定
+		for _, k := range slices.Sorted(maps.Keys(val)) {
+			f.flattenValue(val[k], path+"."+k, depth+1, parts)
 		}
 	case []any:
+		if depth >= maxFlattenDepth {
+			f.flattenCompact(val, path, parts)
+			return
+		}
 		for i, v := range val {
-			f.flattenValue(v, path+"["+strconv.Itoa(i)+"]", parts)
+			f.flattenValue(v, path+"["+strconv.Itoa(i)+"]", depth+1, parts)
 		}
 	case string:
 		*parts = append(*parts, f.coloredKV(path, strconv.Quote(val)))
@@ -245,17 +366,23 @@ func (f *ColorTextFormatter) flattenValue(v any, path string, parts *[]string) {
 	case nil:
 		*parts = append(*parts, f.coloredKV(path, "null"))
 	default:
-		data, err := json.Marshal(val)
-		if err != nil {
-			*parts = append(*parts, f.coloredKV(path, "<error>"))
-			return
-		}
-		*parts = append(*parts, f.coloredKV(path, string(data)))
+		f.flattenCompact(val, path, parts)
+	}
+}
+
+// flattenCompact 将值序列化为紧凑 JSON 后作为单个字段输出
+func (f *ColorTextFormatter) flattenCompact(v any, path string, parts *[]string) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		*parts = append(*parts, f.coloredKV(path, "<error>"))
+		return
 	}
+	*parts = append(*parts, f.coloredKV(path, strconv.Quote(string(data))))
 }
 
 // coloredKV 生成带颜色的 key=value
 func (f *ColorTextFormatter) coloredKV(key, value string) string {
+	key = quoteTextKey(key)
 	if f.opts.EnableColor {
 		return f.opts.ColorScheme.Key + key + ColorReset + "=" + f.opts.ColorScheme.String + value + ColorReset
 	}
@@ -2,9 +2,9 @@ package formatter
 
 import (
 	"bytes"
-	"encoding/json"
 	"log/slog"
 	"strconv"
+	"time"
 )
 
 // ColorJSONFormatter 彩色 JSON 格式化器。
@@ -32,12 +32,9 @@ func (f *ColorJSONFormatter) Format(r *Record) ([]byte, error) {
 	buf.WriteByte('{')
 
 	// time
-	t := r.Time
-	if f.opts.Location != nil {
-		t = t.In(f.opts.Location)
-	}
+	t := f.opts.recordTime(r)
 	f.writeKey(buf, "time", false)
-	f.writeColoredString(buf, f.opts.ColorScheme.Time, formatTime(t, f.opts.TimeFormat))
+	f.writeTime(buf, f.opts.ColorScheme.Time, t)
 
 	// level
 	f.writeKey(buf, "level", true)
@@ -54,7 +51,7 @@ func (f *ColorJSONFormatter) Format(r *Record) ([]byte, error) {
 	}
 
 	// 其他属性
-	f.writeAttrs(buf, r.Attrs, r.Groups)
+	f.writeAttrs(buf, f.opts.recordAttrs(r), r.Groups)
 
 	buf.WriteByte('}')
 	buf.WriteByte('\n')
@@ -92,6 +89,16 @@ func (f *ColorJSONFormatter) writeColoredString(buf *bytes.Buffer, color, s stri
 	buf.WriteByte('"')
 }
 
+// writeTime 写入带颜色的时间值，Unix 时间戳格式输出为数字
+func (f *ColorJSONFormatter) writeTime(buf *bytes.Buffer, color string, t time.Time) {
+	s := f.opts.timeText(t)
+	if isEpochFormat(f.opts.TimeFormat) {
+		f.writeColoredValue(buf, color, s)
+		return
+	}
+	f.writeColoredString(buf, color, s)
+}
+
 // writeColoredValue 写入带颜色的值（非字符串类型）
 func (f *ColorJSONFormatter) writeColoredValue(buf *bytes.Buffer, color, value string) {
 	if f.opts.EnableColor {
@@ -108,17 +115,25 @@ func (f *ColorJSONFormatter) writeAttrs(buf *bytes.Buffer, attrs []slog.Attr, gr
 	// 处理分组
 	openGroups := 0
 	for _, g := range groups {
-		buf.WriteString(`,"`)
-		buf.WriteString(g)
-		buf.WriteString(`":{`)
+		// 嵌套分组紧跟在上一层的 { 之后，不需要逗号
+		if openGroups == 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(buf, g)
+		buf.WriteString(`:{`)
 		openGroups++
 	}
 
+	// 分组内的第一个属性不需要逗号
+	needComma := openGroups == 0
 	for _, attr := range attrs {
 		if attr.Key == "" {
 			continue
 		}
-		buf.WriteByte(',')
+		if needComma {
+			buf.WriteByte(',')
+		}
+		needComma = true
 		f.writeAttr(buf, attr)
 	}
 
@@ -130,12 +145,38 @@ func (f *ColorJSONFormatter) writeAttrs(buf *bytes.Buffer, attrs []slog.Attr, gr
 
 // writeAttr 写入单个属性
 func (f *ColorJSONFormatter) writeAttr(buf *bytes.Buffer, attr slog.Attr) {
-	buf.WriteByte('"')
-	buf.WriteString(attr.Key)
-	buf.WriteString(`":`)
+	writeJSONString(buf, attr.Key)
+	buf.WriteByte(':')
+	if attr.Key == ErrorKey {
+		f.writeErrorValue(buf, attr.Value)
+		return
+	}
 	f.writeValue(buf, attr.Value)
 }
 
+// writeErrorValue 以错误颜色写入 ErrorKey 属性的值，分组内的字符串同样着色
+func (f *ColorJSONFormatter) writeErrorValue(buf *bytes.Buffer, v slog.Value) {
+	v = v.Resolve()
+	if s, ok := errorText(v); ok {
+		f.writeColoredString(buf, f.opts.ColorScheme.Error, s)
+		return
+	}
+	if v.Kind() != slog.KindGroup {
+		f.writeValue(buf, v)
+		return
+	}
+	buf.WriteByte('{')
+	for i, attr := range v.Group() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(buf, attr.Key)
+		buf.WriteByte(':')
+		f.writeErrorValue(buf, attr.Value)
+	}
+	buf.WriteByte('}')
+}
+
 // writeValue 写入值
 func (f *ColorJSONFormatter) writeValue(buf *bytes.Buffer, v slog.Value) {
 	v = v.Resolve()
@@ -168,7 +209,7 @@ func (f *ColorJSONFormatter) writeValue(buf *bytes.Buffer, v slog.Value) {
 		if f.opts.Location != nil {
 			t = t.In(f.opts.Location)
 		}
-		f.writeColoredString(buf, f.opts.ColorScheme.String, formatTime(t, f.opts.TimeFormat))
+		f.writeTime(buf, f.opts.ColorScheme.String, t)
 
 	case slog.KindGroup:
 		buf.WriteByte('{')
@@ -196,7 +237,13 @@ func (f *ColorJSONFormatter) writeAny(buf *bytes.Buffer, v any) {
 		return
 	}
 
-	data, err := json.Marshal(v)
+	// error 接口序列化为 {}，改为输出错误信息
+	if err, ok := v.(error); ok {
+		f.writeColoredString(buf, f.opts.ColorScheme.Error, err.Error())
+		return
+	}
+
+	data, err := marshalJSON(v, f.opts.TagHasher)
 	if err != nil {
 		f.writeColoredString(buf, ColorRed, "<error>")
 		return
@@ -1,29 +1,142 @@
 package formatter
 
-import "bytes"
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
 
-// EscapeJSON 转义 JSON 字符串内容（不含引号）
+const hexDigits = "0123456789abcdef"
+
+// EscapeJSON 转义 JSON 字符串内容（不含引号）。
+//
+// 非法 UTF-8 字节替换为 \ufffd，控制字符与 U+2028/U+2029 使用 \u 转义，
+// 保证任意输入都能生成合法且单行的 JSON 字符串。
 func EscapeJSON(buf *bytes.Buffer, s string) {
-	for _, r := range s {
-		switch r {
-		case '"':
-			buf.WriteString(`\"`)
-		case '\\':
-			buf.WriteString(`\\`)
-		case '\n':
-			buf.WriteString(`\n`)
-		case '\r':
-			buf.WriteString(`\r`)
-		case '\t':
-			buf.WriteString(`\t`)
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch c {
+			case '"':
+				buf.WriteString(`\"`)
+			case '\\':
+				buf.WriteString(`\\`)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				if c < 0x20 {
+					writeUnicodeEscape(buf, rune(c))
+				} else {
+					buf.WriteByte(c)
+				}
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf.WriteString(`\ufffd`)
+		case r == '\u2028' || r == '\u2029':
+			// 合法 JSON，但部分 JavaScript 解析器视为换行
+			writeUnicodeEscape(buf, r)
 		default:
-			if r < 0x20 {
-				buf.WriteString(`\u00`)
-				buf.WriteByte("0123456789abcdef"[r>>4])
-				buf.WriteByte("0123456789abcdef"[r&0xf])
-			} else {
-				buf.WriteRune(r)
+			buf.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+}
+
+// writeUnicodeEscape 写入 \uXXXX 形式的转义（仅用于 BMP 字符）
+func writeUnicodeEscape(buf *bytes.Buffer, r rune) {
+	buf.WriteString(`\u`)
+	buf.WriteByte(hexDigits[r>>12&0xf])
+	buf.WriteByte(hexDigits[r>>8&0xf])
+	buf.WriteByte(hexDigits[r>>4&0xf])
+	buf.WriteByte(hexDigits[r&0xf])
+}
+
+// needsTextQuote 判断文本格式的值或键是否需要加引号。
+//
+// 空串、空白、引号、等号、非法 UTF-8 以及不可打印字符都需要加引号，
+// 避免破坏 key=value 结构或向终端输出控制序列。
+func needsTextQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c <= ' ' || c == '"' || c == '=' || c == '\\' || c == 0x7f {
+				return true
 			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 || !unicode.IsPrint(r) {
+			return true
 		}
+		i += size
+	}
+	return false
+}
+
+// writeTextQuoted 写入带引号的文本值。
+//
+// 转义规则与 JSON 兼容：非法 UTF-8 替换为 \ufffd，不可打印字符使用 \u 转义。
+func writeTextQuoted(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch c {
+			case '"':
+				buf.WriteString(`\"`)
+			case '\\':
+				buf.WriteString(`\\`)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				if c < 0x20 || c == 0x7f {
+					writeUnicodeEscape(buf, rune(c))
+				} else {
+					buf.WriteByte(c)
+				}
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf.WriteString(`\ufffd`)
+		case !unicode.IsPrint(r) && r <= 0xffff:
+			writeUnicodeEscape(buf, r)
+		default:
+			buf.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	buf.WriteByte('"')
+}
+
+// quoteTextKey 返回可安全用于 key=value 格式的键名
+func quoteTextKey(key string) string {
+	if !needsTextQuote(key) {
+		return key
 	}
+	var buf bytes.Buffer
+	writeTextQuoted(&buf, key)
+	return buf.String()
 }
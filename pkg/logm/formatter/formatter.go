@@ -4,13 +4,28 @@
 //   - JSON: 结构化 JSON 输出，适合生产环境日志采集
 //   - Text: 键值对文本输出，兼容传统日志分析工具
 //   - Color: 彩色终端输出，适合开发环境
+//
+// 序列化结构体时遵循字段标签 logm:"omit"、logm:"mask"、logm:"hash"，
+// 敏感字段在类型定义处声明即可，见 tags.go。
 package formatter
 
 import (
+	"bytes"
 	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
 )
 
+// ErrorKey 错误属性的键名。
+//
+// 彩色格式以 ColorScheme.Error 显示该键下的值，包括 logm.Err 生成的分组；
+// JSON 和文本格式输出为 error.msg、error.type 等嵌套或平铺的键。
+const ErrorKey = "error"
+
 // Record 日志记录，Formatter 的输入。
 type Record struct {
 	Time    time.Time
@@ -28,13 +43,59 @@ type Formatter interface {
 
 // Options 格式化器通用选项
 type Options struct {
-	TimeFormat  string
-	Location    *time.Location
-	SourceClip  string          // Source 路径裁剪前缀 (如 "/workspace/")
-	SourceDepth int             // Source 路径保留层数 (默认 3)
-	ColorScheme *ColorScheme    // 颜色配置方案
-	EnableColor bool            // 启用颜色输出
-	RawFields   map[string]bool // 不加引号直接输出的字段名集合
+	TimeFormat    string
+	Location      *time.Location
+	SourceClip    string                    // Source 路径裁剪前缀 (如 "/workspace/")
+	SourceDepth   int                       // Source 路径保留层数 (默认 3)
+	ColorScheme   *ColorScheme              // 颜色配置方案
+	EnableColor   bool                      // 启用颜色输出
+	RawFields     map[string]bool           // 不加引号直接输出的字段名集合
+	Clock         func() time.Time          // 时间来源，非 nil 时替代 Record.Time
+	SortKeys      bool                      // 按键名排序属性（含分组内属性）
+	MultiLine     bool                      // ColorText 中含换行的值在续行中原样输出
+	NestedGroup   bool                      // Text/ColorText 中分组输出为 group={k=v} 而不是 group.k=v
+	TimePrecision TimePrecision             // 时间的秒以下精度，PrecisionDefault 时由 TimeFormat 决定
+	TagHasher     func(value string) string // logm:"hash" 字段的假名函数，nil 时输出 TagMask
+}
+
+// TimePrecision 时间的秒以下精度
+type TimePrecision int
+
+const (
+	// PrecisionDefault 由时间格式决定精度（如 timems 为毫秒，JSON 时间属性为纳秒）
+	PrecisionDefault TimePrecision = iota
+	// PrecisionSeconds 精确到秒
+	PrecisionSeconds
+	// PrecisionMillis 精确到毫秒
+	PrecisionMillis
+	// PrecisionMicros 精确到微秒
+	PrecisionMicros
+	// PrecisionNanos 精确到纳秒
+	PrecisionNanos
+)
+
+// digits 返回小数位数，PrecisionDefault 返回 -1
+func (p TimePrecision) digits() int {
+	switch p {
+	case PrecisionSeconds:
+		return 0
+	case PrecisionMillis:
+		return 3
+	case PrecisionMicros:
+		return 6
+	case PrecisionNanos:
+		return 9
+	default:
+		return -1
+	}
+}
+
+// fraction 返回 time.Format 的小数部分布局，位数固定
+func (p TimePrecision) fraction() string {
+	if n := p.digits(); n > 0 {
+		return "." + strings.Repeat("0", n)
+	}
+	return ""
 }
 
 // Option 选项函数
@@ -50,13 +111,63 @@ func defaultOptions() *Options {
 	}
 }
 
-// WithTimeFormat 设置时间格式
+// errorText 返回错误属性中以文本显示的值：字符串或 error
+func errorText(v slog.Value) (string, bool) {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String(), true
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error(), true
+		}
+	default:
+	}
+	return "", false
+}
+
+// WithTimeFormat 设置时间格式。
+//
+// 预置格式：time、timems、datetime、rfc3339、rfc3339ms，
+// 以及 Unix 时间戳 unix（秒）、unixms（毫秒）、unixnano（纳秒）、unixfloat（秒，6 位小数），
+// 时间戳在 JSON 和 ColorJSON 中输出为数字。其他值按 time.Format 布局处理。
 func WithTimeFormat(format string) Option {
 	return func(o *Options) {
 		o.TimeFormat = format
 	}
 }
 
+// WithTimePrecision 设置时间的秒以下精度。
+//
+// 统一作用于记录时间和时间类型的属性，覆盖预置格式自带的精度：
+// time/timems、datetime、rfc3339/rfc3339ms 按精度输出固定位数的小数，
+// unixfloat 按精度输出小数位数，JSON 中的时间属性按 RFC3339 加对应小数输出。
+// unix、unixms、unixnano 和自定义布局不受影响。
+func WithTimePrecision(p TimePrecision) Option {
+	return func(o *Options) {
+		o.TimePrecision = p
+	}
+}
+
+// WithClock 设置时间来源。
+//
+// 设置后忽略 Record.Time，使用 clock 返回的时间，
+// 用于测试和示例生成稳定的输出。
+func WithClock(clock func() time.Time) Option {
+	return func(o *Options) {
+		o.Clock = clock
+	}
+}
+
+// WithSortedKeys 按键名排序输出属性。
+//
+// 分组内的属性同样排序，同名属性保持原有顺序。
+// 用于生成与属性添加顺序无关的稳定输出，有额外的排序开销。
+func WithSortedKeys() Option {
+	return func(o *Options) {
+		o.SortKeys = true
+	}
+}
+
 // WithTimezone 设置时区
 func WithTimezone(tz string) Option {
 	return func(o *Options) {
@@ -112,6 +223,113 @@ func WithRawFields(fields ...string) Option {
 	}
 }
 
+// WithMultiLine 在 ColorText 中将含换行的字符串和错误值（调用栈、SQL、diff 等）
+// 原样输出在日志行之后的缩进续行中，而不是转义为 \n 挤在一行：
+//
+//	10:30:45 ERROR query failed rows=0
+//	    sql:
+//	        SELECT *
+//	        FROM users
+//
+// 仅对 ColorText 生效，其他格式保持单行输出以便机器解析。
+func WithMultiLine() Option {
+	return func(o *Options) {
+		o.MultiLine = true
+	}
+}
+
+// WithNestedGroups 在 Text 和 ColorText 中以嵌套结构输出分组，使层级可见：
+//
+//	req={method=GET headers={host=example.com}}   // 而不是 req.method=GET req.headers.host=example.com
+//
+// WithGroup 产生的分组同样嵌套输出，与 slog.JSONHandler 的语义一致；空分组不输出。
+// JSON 格式本身即为嵌套结构，不受影响。
+func WithNestedGroups() Option {
+	return func(o *Options) {
+		o.NestedGroup = true
+	}
+}
+
+// WithTagHasher 设置 logm:"hash" 字段的假名函数，通常使用 HMAC 密钥环：
+//
+//	kr, _ := redact.KeyringFromEnv("LOGM_HMAC_KEYS")
+//	formatter.JSON(formatter.WithTagHasher(kr.Pseudonym))
+//
+// 未设置时 logm:"hash" 字段与 logm:"mask" 一样输出 TagMask。
+// 不使用无密钥的摘要：邮箱、手机号等取值空间有限，可以通过字典反推原值。
+func WithTagHasher(fn func(value string) string) Option {
+	return func(o *Options) {
+		o.TagHasher = fn
+	}
+}
+
+// nestAttrs 将 attrs 依次包裹在 groups 中，返回最外层分组
+func nestAttrs(attrs []slog.Attr, groups []string) []slog.Attr {
+	for i := len(groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
+
+// trimGroupSpace 删除 start 处子属性写入的前导空格，使分组输出为 {k=v} 而不是 { k=v}
+func trimGroupSpace(buf *bytes.Buffer, start int) {
+	b := buf.Bytes()
+	if len(b) > start && b[start] == ' ' {
+		copy(b[start:], b[start+1:])
+		buf.Truncate(len(b) - 1)
+	}
+}
+
+// recordTime 返回日志时间（应用 Clock 和时区）
+func (o *Options) recordTime(r *Record) time.Time {
+	t := r.Time
+	if o.Clock != nil {
+		t = o.Clock()
+	}
+	if o.Location != nil {
+		t = t.In(o.Location)
+	}
+	return t
+}
+
+// timeText 按 TimeFormat 和 TimePrecision 格式化时间
+func (o *Options) timeText(t time.Time) string {
+	return formatTimePrecision(t, o.TimeFormat, o.TimePrecision)
+}
+
+// recordAttrs 返回待输出的属性（按需排序，不修改 r）
+func (o *Options) recordAttrs(r *Record) []slog.Attr {
+	if !o.SortKeys {
+		return r.Attrs
+	}
+	return sortAttrs(r.Attrs)
+}
+
+// sortAttrs 返回按键名稳定排序的副本，递归处理分组
+func sortAttrs(attrs []slog.Attr) []slog.Attr {
+	sorted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		if v := a.Value.Resolve(); v.Kind() == slog.KindGroup {
+			a = slog.Attr{Key: a.Key, Value: slog.GroupValue(sortAttrs(v.Group())...)}
+		}
+		sorted[i] = a
+	}
+	slices.SortStableFunc(sorted, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return sorted
+}
+
+// isEpochFormat 判断时间格式是否为 Unix 时间戳，JSON 中以数字输出
+func isEpochFormat(format string) bool {
+	switch format {
+	case "unix", "unixms", "unixnano", "unixfloat":
+		return true
+	default:
+		return false
+	}
+}
+
 // formatTime 根据格式字符串格式化时间
 func formatTime(t time.Time, format string) string {
 	switch format {
@@ -125,6 +343,14 @@ func formatTime(t time.Time, format string) string {
 		return t.Format(time.RFC3339)
 	case "rfc3339ms":
 		return t.Format("2006-01-02T15:04:05.000Z07:00")
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unixms":
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case "unixnano":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	case "unixfloat":
+		return strconv.FormatFloat(float64(t.UnixMicro())/1e6, 'f', 6, 64)
 	default:
 		if format == "" {
 			return t.Format("2006-01-02 15:04:05")
@@ -133,13 +359,48 @@ func formatTime(t time.Time, format string) string {
 	}
 }
 
-// loadTimezone 加载时区
+// formatTimePrecision 按格式和精度格式化时间，PrecisionDefault 等价于 formatTime
+func formatTimePrecision(t time.Time, format string, p TimePrecision) string {
+	if p == PrecisionDefault {
+		return formatTime(t, format)
+	}
+	switch format {
+	case "time", "timems":
+		return t.Format("15:04:05" + p.fraction())
+	case "", "datetime":
+		return t.Format("2006-01-02 15:04:05" + p.fraction())
+	case "rfc3339", "rfc3339ms":
+		return t.Format("2006-01-02T15:04:05" + p.fraction() + "Z07:00")
+	case "unixfloat":
+		sec := strconv.FormatInt(t.Unix(), 10)
+		if n := p.digits(); n > 0 {
+			ns := strconv.Itoa(t.Nanosecond() + 1e9) // 补齐前导零
+			return sec + "." + ns[1:1+n]
+		}
+		return sec
+	default:
+		return formatTime(t, format)
+	}
+}
+
+// writeJSONTime 写入 JSON 时间值，Unix 时间戳格式输出为数字
+func writeJSONTime(buf *bytes.Buffer, t time.Time, o *Options) {
+	s := o.timeText(t)
+	if isEpochFormat(o.TimeFormat) {
+		buf.WriteString(s)
+		return
+	}
+	writeJSONString(buf, s)
+}
+
+// loadTimezone 加载时区，失败时回退到本地时区并通过自诊断输出报告
 func loadTimezone(tz string) *time.Location {
 	if tz == "" {
 		return time.Local
 	}
 	loc, err := time.LoadLocation(tz)
 	if err != nil {
+		selflog.Printf("timezone", "load timezone %q failed, falling back to local: %v", tz, err)
 		return time.Local
 	}
 	return loc
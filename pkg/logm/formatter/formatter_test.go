@@ -1,8 +1,10 @@
 package formatter
 
 import (
+	"encoding/json"
 	"errors"
 	"log/slog"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -101,6 +103,35 @@ func TestJSONFormatter_TimeFormat(t *testing.T) {
 	assert.Contains(t, output, `"time":"2024-01-15T10:30:45Z"`)
 }
 
+func TestJSONFormatter_EpochTimeFormat(t *testing.T) {
+	at := slog.Time("at", testTime.Add(time.Second))
+	tests := []struct {
+		format string
+		time   string
+		at     string
+	}{
+		{"unix", `"time":1705314645,`, `"at":1705314646}`},
+		{"unixms", `"time":1705314645000,`, `"at":1705314646000}`},
+		{"unixnano", `"time":1705314645000000000,`, `"at":1705314646000000000}`},
+		{"unixfloat", `"time":1705314645.000000,`, `"at":1705314646.000000}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			data, err := JSON(WithTimeFormat(tt.format)).Format(newTestRecord("test", at))
+			require.NoError(t, err)
+
+			output := string(data)
+			assert.Contains(t, output, tt.time)
+			assert.Contains(t, output, tt.at)
+
+			var m map[string]any
+			require.NoError(t, json.Unmarshal(data, &m))
+			assert.IsType(t, float64(0), m["time"])
+		})
+	}
+}
+
 func TestJSONFormatter_EscapesSpecialChars(t *testing.T) {
 	f := JSON()
 	r := newTestRecord(`message with "quotes" and \backslash`)
@@ -156,6 +187,32 @@ func TestJSONFormatter_ErrorAttr(t *testing.T) {
 	assert.NotContains(t, output, `"error":{}`, "error should not be serialized as empty object")
 }
 
+// codeError 带导出字段的错误类型，json.Marshal 会输出字段而不是错误信息
+type codeError struct {
+	Code int
+}
+
+func (e codeError) Error() string { return "code " + strconv.Itoa(e.Code) }
+
+// TestColorFormatters_ErrorAttr 验证彩色格式化器输出错误信息，而不是 {} 或错误类型的字段
+func TestColorFormatters_ErrorAttr(t *testing.T) {
+	r := newTestRecord("operation failed",
+		slog.Any("error", errors.New("database connection failed")),
+		slog.Any("cause", codeError{Code: 7}),
+	)
+
+	data, err := ColorJSON(WithColor(false)).Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"error":"database connection failed"`)
+	assert.Contains(t, string(data), `"cause":"code 7"`)
+
+	data, err = ColorText(WithColor(false)).Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `error="database connection failed"`)
+	assert.Contains(t, string(data), `cause="code 7"`)
+	assert.NotContains(t, string(data), "cause.Code")
+}
+
 // ============ Text Formatter Tests ============
 
 func TestTextFormatter_BasicOutput(t *testing.T) {
@@ -266,6 +323,82 @@ func TestColorTextFormatter_JSONFlatten(t *testing.T) {
 	assert.Contains(t, output, "alice")
 }
 
+func TestColorTextFormatter_MultiLine(t *testing.T) {
+	f := ColorText(WithColor(false), WithMultiLine())
+	r := newTestRecord("query failed",
+		slog.String("sql", "SELECT *\nFROM users\n"),
+		slog.Int("rows", 0),
+		slog.Any("err", errors.New("line1\nline2")),
+		slog.String("single", "a b"),
+	)
+
+	data, err := f.Format(r)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, lines, 7)
+	assert.Contains(t, lines[0], "query failed rows=0 single=\"a b\"")
+	assert.NotContains(t, lines[0], "sql")
+	assert.Equal(t, []string{
+		"    sql:",
+		"        SELECT *",
+		"        FROM users",
+		"    err:",
+		"        line1",
+		"        line2",
+	}, lines[1:])
+}
+
+func TestColorTextFormatter_MultiLineDisabled(t *testing.T) {
+	f := ColorText(WithColor(false))
+	data, err := f.Format(newTestRecord("msg", slog.String("sql", "a\nb")))
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"))
+	assert.Contains(t, string(data), `sql="a\nb"`)
+}
+
+// TestTextFormatters_FlattenGroups 验证分组属性平铺为 group.key=value，空键分组内联，
+// JSON 内容按键排序展开
+func TestTextFormatters_FlattenGroups(t *testing.T) {
+	r := newTestRecord("req",
+		slog.Group("http", slog.String("method", "GET"), slog.Group("headers", slog.String("host", "example.com"))),
+		slog.Group("", slog.Int("inline", 1)),
+		slog.String("body", `{"b":2,"a":1}`),
+	)
+	r.Groups = []string{"svc"}
+
+	data, err := Text().Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ` svc.http.method=GET svc.http.headers.host=example.com svc.inline=1 `)
+
+	data, err = ColorText(WithColor(false)).Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ` svc.http.method="GET" svc.http.headers.host="example.com" svc.inline=1 svc.body.a=1 svc.body.b=2`)
+}
+
+func TestNestedGroups(t *testing.T) {
+	r := newTestRecord("req",
+		slog.Group("http", slog.String("method", "GET"), slog.Group("headers", slog.String("host", "example.com"))),
+		slog.Group("empty"),
+		slog.Int("n", 1),
+	)
+	r.Groups = []string{"svc"}
+
+	data, err := Text(WithNestedGroups()).Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ` svc={http={method=GET headers={host=example.com}} n=1}`)
+	assert.NotContains(t, string(data), "empty")
+
+	data, err = ColorText(WithColor(false), WithNestedGroups()).Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ` svc={http={method="GET" headers={host="example.com"}} n=1}`)
+
+	// 默认仍为平铺格式
+	data, err = Text().Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ` svc.http.method=GET svc.http.headers.host=example.com svc.n=1`)
+}
+
 func TestColorTextFormatter_LevelColors(t *testing.T) {
 	tests := []struct {
 		level slog.Level
@@ -301,6 +434,10 @@ func TestFormatTime(t *testing.T) {
 		{"timems", "10:30:45.123"},
 		{"datetime", "2024-01-15 10:30:45"},
 		{"rfc3339", "2024-01-15T10:30:45Z"},
+		{"unix", "1705314645"},
+		{"unixms", "1705314645123"},
+		{"unixnano", "1705314645123000000"},
+		{"unixfloat", "1705314645.123000"},
 		{"", "2024-01-15 10:30:45"},  // default
 		{"2006/01/02", "2024/01/15"}, // custom format
 	}
@@ -313,6 +450,63 @@ func TestFormatTime(t *testing.T) {
 	}
 }
 
+func TestFormatTimePrecision(t *testing.T) {
+	tm := time.Date(2024, 1, 15, 10, 30, 45, 123456789, time.UTC)
+
+	tests := []struct {
+		format    string
+		precision TimePrecision
+		expected  string
+	}{
+		{"timems", PrecisionDefault, "10:30:45.123"},
+		{"timems", PrecisionSeconds, "10:30:45"},
+		{"time", PrecisionMicros, "10:30:45.123456"},
+		{"datetime", PrecisionMillis, "2024-01-15 10:30:45.123"},
+		{"", PrecisionNanos, "2024-01-15 10:30:45.123456789"},
+		{"rfc3339", PrecisionMillis, "2024-01-15T10:30:45.123Z"},
+		{"rfc3339ms", PrecisionSeconds, "2024-01-15T10:30:45Z"},
+		{"unixfloat", PrecisionSeconds, "1705314645"},
+		{"unixfloat", PrecisionMillis, "1705314645.123"},
+		{"unixfloat", PrecisionNanos, "1705314645.123456789"},
+		{"unixms", PrecisionNanos, "1705314645123"},
+		{"2006/01/02", PrecisionNanos, "2024/01/15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatTimePrecision(tm, tt.format, tt.precision))
+		})
+	}
+}
+
+func TestWithTimePrecision_AllFormatters(t *testing.T) {
+	tm := time.Date(2024, 1, 15, 10, 30, 45, 123456789, time.UTC)
+	r := newTestRecord("test", slog.Time("at", tm))
+	r.Time = tm
+	opts := []Option{WithTimeFormat("rfc3339"), WithTimezone("UTC"), WithTimePrecision(PrecisionMillis), WithColor(false)}
+
+	tests := []struct {
+		name      string
+		formatter Formatter
+		time      string
+		at        string
+	}{
+		{"json", JSON(opts...), `"time":"2024-01-15T10:30:45.123Z"`, `"at":"2024-01-15T10:30:45.123Z"`},
+		{"text", Text(opts...), `time=2024-01-15T10:30:45.123Z`, `at=2024-01-15T10:30:45.123Z`},
+		{"color_json", ColorJSON(opts...), `"time":"2024-01-15T10:30:45.123Z"`, `"at":"2024-01-15T10:30:45.123Z"`},
+		{"color_text", ColorText(opts...), `2024-01-15T10:30:45.123Z INFO`, `at="2024-01-15T10:30:45.123Z"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.formatter.Format(r)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), tt.time)
+			assert.Contains(t, string(data), tt.at)
+		})
+	}
+}
+
 // ============ loadTimezone Tests ============
 
 func TestLoadTimezone(t *testing.T) {
@@ -352,6 +546,46 @@ func TestWithTimezone(t *testing.T) {
 	assert.Equal(t, "UTC", opts.Location.String())
 }
 
+func TestWithClock(t *testing.T) {
+	fixed := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	formatters := map[string]Formatter{
+		"json":       JSON(WithClock(func() time.Time { return fixed }), WithTimezone("UTC")),
+		"text":       Text(WithClock(func() time.Time { return fixed }), WithTimezone("UTC")),
+		"color_text": ColorText(WithClock(func() time.Time { return fixed }), WithTimezone("UTC"), WithColor(false)),
+		"color_json": ColorJSON(WithClock(func() time.Time { return fixed }), WithTimezone("UTC"), WithColor(false)),
+	}
+
+	for name, f := range formatters {
+		t.Run(name, func(t *testing.T) {
+			data, err := f.Format(newTestRecord("test"))
+			require.NoError(t, err)
+			assert.Contains(t, string(data), "2024-06-01 08:00:00")
+			assert.NotContains(t, string(data), "2024-01-15")
+		})
+	}
+}
+
+func TestWithSortedKeys(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.String("b", "2"),
+		slog.Group("a", slog.Int("y", 1), slog.Int("x", 2)),
+		slog.String("c", "3"),
+		slog.String("b", "dup"),
+	}
+	r := newTestRecord("test", attrs...)
+
+	data, err := JSON(WithSortedKeys()).Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"a":{"x":2,"y":1},"b":"2","b":"dup","c":"3"`)
+
+	// 原记录不被修改
+	assert.Equal(t, "b", r.Attrs[0].Key)
+
+	data, err = Text(WithSortedKeys()).Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "a.x=2 a.y=1 b=2 b=dup c=3")
+}
+
 func TestDefaultOptions(t *testing.T) {
 	opts := defaultOptions()
 	assert.Equal(t, "datetime", opts.TimeFormat)
@@ -476,6 +710,20 @@ func TestColorJSONFormatter_BasicOutput(t *testing.T) {
 	assert.True(t, strings.HasSuffix(output, "}\n"))
 }
 
+func TestColorJSONFormatter_EpochTimeFormat(t *testing.T) {
+	f := ColorJSON(WithTimeFormat("unixms"), WithColor(false))
+	data, err := f.Format(newTestRecord("test", slog.Time("at", testTime)))
+	require.NoError(t, err)
+
+	output := string(data)
+	assert.Contains(t, output, `"time":1705314645000,`)
+	assert.Contains(t, output, `"at":1705314645000}`)
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(data, &m))
+	assert.IsType(t, float64(0), m["time"])
+}
+
 func TestColorJSONFormatter_WithAttrs(t *testing.T) {
 	f := ColorJSON()
 	r := newTestRecord("test",
@@ -564,6 +812,33 @@ func TestColorJSONFormatter_WithGroups(t *testing.T) {
 	assert.Contains(t, output, `"method":"`)
 }
 
+// TestJSONFormatters_GroupComma 验证分组内第一个属性前不写逗号，输出为合法 JSON
+func TestJSONFormatters_GroupComma(t *testing.T) {
+	r := &Record{
+		Time:    testTime,
+		Level:   slog.LevelInfo,
+		Message: "test",
+		Groups:  []string{"request", "http"},
+		Attrs: []slog.Attr{
+			{}, // 空键属性被跳过，不影响逗号
+			slog.String("method", "GET"),
+			slog.Int("status", 200),
+		},
+	}
+
+	for name, f := range map[string]Formatter{
+		"json":       JSON(),
+		"color_json": ColorJSON(WithColor(false)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := f.Format(r)
+			require.NoError(t, err)
+			assert.True(t, json.Valid(data), string(data))
+			assert.Contains(t, string(data), `"request":{"http":{"method":"GET","status":200}}`)
+		})
+	}
+}
+
 // ============ WithSourceClip/WithSourceDepth Tests ============
 
 func TestWithSourceClip(t *testing.T) {
@@ -0,0 +1,166 @@
+// Package formattertest 提供 Formatter 的黄金文件（golden file）测试工具。
+//
+// 使用固定时间的标准 Record 集合渲染任意 Formatter，并与 testdata 下的
+// 黄金文件比较，使自定义 Formatter 或配色方案的改动以 diff 形式呈现：
+//
+//	func TestMyFormatter(t *testing.T) {
+//	    formattertest.AssertGolden(t, myFormatter(formatter.WithTimezone("UTC")), "my_formatter")
+//	}
+//
+// 运行 go test -update 重新生成黄金文件。
+package formattertest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+func init() {
+	// 其他测试辅助包可能已注册同名标志，此时沿用它
+	if flag.Lookup("update") == nil {
+		flag.Bool("update", false, "update golden files")
+	}
+}
+
+// updating 报告是否指定了 -update。
+//
+// 每次调用时按名称查找标志，而不是在初始化时缓存取值：标志由其他包注册时，
+// 命令行解析发生在初始化之后，缓存的副本永远是 false。
+func updating() bool {
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	if g, ok := f.Value.(flag.Getter); ok {
+		b, _ := g.Get().(bool)
+		return b
+	}
+	return f.Value.String() == "true"
+}
+
+// Time 标准 Record 集合使用的固定时间（UTC）
+var Time = time.Date(2024, 1, 15, 10, 30, 45, 123456789, time.UTC)
+
+// Records 返回覆盖常见场景的标准 Record 集合。
+//
+// 包含所有级别、各种值类型、分组、源代码位置、特殊字符和 JSON 字符串，
+// 所有时间均为固定值，每次调用返回新的副本。
+func Records() []*formatter.Record {
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	return []*formatter.Record{
+		{
+			Time:    Time,
+			Level:   slog.LevelDebug,
+			Message: "cache miss",
+			Attrs:   []slog.Attr{slog.String("key", "user:42")},
+		},
+		{
+			Time:    Time.Add(time.Millisecond),
+			Level:   slog.LevelInfo,
+			Message: "request completed",
+			Attrs: []slog.Attr{
+				slog.String("method", "GET"),
+				slog.Int("status", 200),
+				slog.Uint64("bytes", 1024),
+				slog.Float64("ratio", 0.75),
+				slog.Bool("cached", false),
+				slog.Duration("elapsed", 1500*time.Microsecond),
+				slog.Time("started", Time.Add(-time.Second)),
+			},
+		},
+		{
+			Time:    Time.Add(2 * time.Millisecond),
+			Level:   slog.LevelWarn,
+			Message: "slow query",
+			Groups:  []string{"db"},
+			Attrs: []slog.Attr{
+				slog.String("table", "users"),
+				slog.Group("stats", slog.Int("rows", 3), slog.Duration("took", 2*time.Second)),
+			},
+		},
+		{
+			Time:    Time.Add(3 * time.Millisecond),
+			Level:   slog.LevelError,
+			Message: "operation failed",
+			Attrs: []slog.Attr{
+				slog.Any("error", errors.New("connection refused")),
+				slog.Any("user", user{ID: 42, Name: "alice"}),
+				slog.Any("nothing", nil),
+			},
+			Source: &slog.Source{Function: "main.run", File: "/workspace/app/cmd/server/main.go", Line: 42},
+		},
+		{
+			Time:    Time.Add(4 * time.Millisecond),
+			Level:   slog.LevelInfo,
+			Message: "special \"chars\"\n\ttab 中文",
+			Attrs: []slog.Attr{
+				slog.String("quote", `say "hi"`),
+				slog.String("empty", ""),
+				slog.String("payload", `{"b":1,"a":[true,null,"x"]}`),
+			},
+		},
+	}
+}
+
+// Render 使用 f 依次格式化 records 并拼接结果。
+func Render(f formatter.Formatter, records []*formatter.Record) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, r := range records {
+		data, err := f.Format(r)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// AssertGolden 使用标准 Record 集合渲染 f，并与 testdata/<name>.golden 比较。
+//
+// 指定 -update 时改为写入黄金文件。时间输出依赖 Formatter 的时区设置，
+// 建议使用 formatter.WithTimezone("UTC") 以保证结果与运行环境无关。
+func AssertGolden(t testing.TB, f formatter.Formatter, name string) {
+	t.Helper()
+	AssertGoldenRecords(t, f, Records(), name)
+}
+
+// AssertGoldenRecords 与 AssertGolden 相同，但使用自定义的 Record 集合。
+func AssertGoldenRecords(t testing.TB, f formatter.Formatter, records []*formatter.Record, name string) {
+	t.Helper()
+
+	got, err := Render(f, records)
+	if err != nil {
+		t.Fatalf("formattertest: render %s: %v", name, err)
+	}
+
+	path := filepath.Join("testdata", name+".golden")
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("formattertest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			t.Fatalf("formattertest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path) //nolint:gosec // G304: path is built from the test's golden name
+	if err != nil {
+		t.Fatalf("formattertest: read golden file (run with -update to create): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("formattertest: %s mismatch (run with -update to accept)\n--- want\n%s\n--- got\n%s", path, want, got)
+	}
+}
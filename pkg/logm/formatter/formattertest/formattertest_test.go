@@ -0,0 +1,22 @@
+package formattertest
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdating(t *testing.T) {
+	f := flag.Lookup("update")
+	require.NotNil(t, f)
+	old := f.Value.String()
+	t.Cleanup(func() { _ = f.Value.Set(old) })
+
+	// 初始化之后设置的值同样生效
+	require.NoError(t, f.Value.Set("true"))
+	assert.True(t, updating())
+	require.NoError(t, f.Value.Set("false"))
+	assert.False(t, updating())
+}
@@ -0,0 +1,169 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fuzzSeeds 常见的恶意或异常输入
+var fuzzSeeds = []string{
+	"",
+	"plain",
+	"with space",
+	`quote " and \ backslash`,
+	"line1\nline2\r\n\ttab",
+	"\x00\x01\x1b[31mred\x1b[0m\x7f",
+	"\xff\xfe invalid utf8 \xc3",
+	"ls\u2028ps\u2029",
+	"bidi \u202e override",
+	"中文 emoji 😀",
+	"key=value",
+	`{"a":{"b":[1,"x",null,true]}}`,
+	strings.Repeat("[", 200) + strings.Repeat("]", 200),
+}
+
+// FuzzEscapeJSON 验证转义结果始终是合法的单行 JSON 字符串
+func FuzzEscapeJSON(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		var buf bytes.Buffer
+		writeJSONString(&buf, s)
+		out := buf.Bytes()
+
+		require.True(t, json.Valid(out), "invalid JSON: %q", out)
+		assert.NotContains(t, string(out), "\n")
+
+		var got string
+		require.NoError(t, json.Unmarshal(out, &got))
+		assert.Equal(t, string([]rune(s)), got)
+	})
+}
+
+// FuzzWriteTextValue 验证文本值不会破坏 key=value 结构
+func FuzzWriteTextValue(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		var buf bytes.Buffer
+		writeTextValue(&buf, s)
+		out := buf.String()
+
+		require.True(t, utf8.ValidString(out), "invalid UTF-8: %q", out)
+		for _, r := range out {
+			assert.True(t, r == ' ' || unicode.IsPrint(r) || r > 0xffff, "unescaped rune %U in %q", r, out)
+		}
+
+		if !strings.HasPrefix(out, `"`) {
+			// 未加引号时必须原样输出且不含分隔符
+			assert.Equal(t, s, out)
+			assert.NotContains(t, out, " ")
+			assert.NotContains(t, out, "=")
+			return
+		}
+
+		// 带引号的输出与 JSON 字符串兼容
+		var got string
+		require.NoError(t, json.Unmarshal([]byte(out), &got), "out=%q", out)
+		assert.Equal(t, string([]rune(s)), got)
+	})
+}
+
+// FuzzFormatters 验证任意键值经各格式化器输出后仍为单行且结构完整
+func FuzzFormatters(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s, s)
+	}
+	jsonF := JSON()
+	textF := Text()
+	colorF := ColorText(WithColor(false))
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		r := &Record{
+			Time:    testTime,
+			Level:   slog.LevelInfo,
+			Message: value,
+			Attrs: []slog.Attr{
+				slog.String(key, value),
+				slog.Group(key, slog.String(key, value)),
+			},
+			Groups: []string{key},
+		}
+
+		data, err := jsonF.Format(r)
+		require.NoError(t, err)
+		require.True(t, json.Valid(data), "invalid JSON: %q", data)
+
+		data, err = textF.Format(r)
+		require.NoError(t, err)
+		assert.Equal(t, 1, bytes.Count(data, []byte("\n")), "text output must be one line: %q", data)
+
+		// ColorText 的消息原样输出，此处只检查属性部分
+		r.Message = "msg"
+		data, err = colorF.Format(r)
+		require.NoError(t, err)
+		assert.Equal(t, 1, bytes.Count(data, []byte("\n")), "color output must be one line: %q", data)
+	})
+}
+
+// FuzzFlattenJSON 验证 JSON 展开对任意输入不会崩溃且保持单行
+func FuzzFlattenJSON(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	colorF := ColorText(WithColor(false))
+
+	f.Fuzz(func(t *testing.T, s string) {
+		out := colorF.tryFlattenJSON(s, "data")
+		assert.NotContains(t, out, "\n")
+	})
+}
+
+func TestColorTextFormatter_FlattenDepthLimit(t *testing.T) {
+	f := ColorText(WithColor(false))
+	deep := strings.Repeat(`{"a":`, 5000) + "1" + strings.Repeat("}", 5000)
+
+	out := f.tryFlattenJSON(deep, "data")
+
+	// 超过最大深度的部分以紧凑 JSON 输出，键路径长度受限
+	assert.Less(t, len(out), 100000)
+	assert.Contains(t, out, "data"+strings.Repeat(".a", maxFlattenDepth)+"=")
+}
+
+func TestEscapeJSON_InvalidUTF8(t *testing.T) {
+	var buf bytes.Buffer
+	EscapeJSON(&buf, "a\xffb\u2028")
+	assert.Equal(t, `a\ufffdb\u2028`, buf.String())
+}
+
+func TestTextFormatter_EscapesControlChars(t *testing.T) {
+	f := Text()
+	r := newTestRecord("test", slog.String("bad key", "\x1b[31mred"))
+
+	data, err := f.Format(r)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), `"bad key"="\u001b[31mred"`)
+}
+
+func TestJSONFormatter_EscapesKeys(t *testing.T) {
+	f := JSON()
+	r := newTestRecord("test", slog.String(`k"ey`, "v"))
+	r.Groups = []string{"g\n"}
+
+	data, err := f.Format(r)
+	require.NoError(t, err)
+
+	assert.True(t, json.Valid(data))
+	assert.Contains(t, string(data), `"g\n":{"k\"ey":"v"}`)
+}
@@ -0,0 +1,27 @@
+package formatter_test
+
+import (
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter/formattertest"
+)
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		f    formatter.Formatter
+	}{
+		{"json", formatter.JSON(formatter.WithTimezone("UTC"), formatter.WithTimeFormat("rfc3339ms"))},
+		{"text", formatter.Text(formatter.WithTimezone("UTC"))},
+		{"color_text", formatter.ColorText(formatter.WithTimezone("UTC"), formatter.WithSourceClip("/workspace/"))},
+		{"color_text_nocolor", formatter.ColorText(formatter.WithTimezone("UTC"), formatter.WithColor(false))},
+		{"color_json", formatter.ColorJSON(formatter.WithTimezone("UTC"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formattertest.AssertGolden(t, tt.f, tt.name)
+		})
+	}
+}
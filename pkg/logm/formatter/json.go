@@ -2,7 +2,6 @@ package formatter
 
 import (
 	"bytes"
-	"encoding/json"
 	"log/slog"
 	"strconv"
 	"time"
@@ -32,13 +31,9 @@ func (f *JSONFormatter) Format(r *Record) ([]byte, error) {
 	buf.WriteByte('{')
 
 	// 时间
-	t := r.Time
-	if f.opts.Location != nil {
-		t = t.In(f.opts.Location)
-	}
-	buf.WriteString(`"time":"`)
-	buf.WriteString(formatTime(t, f.opts.TimeFormat))
-	buf.WriteByte('"')
+	t := f.opts.recordTime(r)
+	buf.WriteString(`"time":`)
+	writeJSONTime(buf, t, f.opts)
 
 	// 级别
 	buf.WriteString(`,"level":"`)
@@ -57,7 +52,7 @@ func (f *JSONFormatter) Format(r *Record) ([]byte, error) {
 	}
 
 	// 属性
-	f.writeAttrs(buf, r.Attrs, r.Groups)
+	f.writeAttrs(buf, f.opts.recordAttrs(r), r.Groups)
 
 	buf.WriteByte('}')
 	buf.WriteByte('\n')
@@ -70,27 +65,25 @@ func (f *JSONFormatter) writeAttrs(buf *bytes.Buffer, attrs []slog.Attr, groups
 	// 处理分组
 	openGroups := 0
 	for _, g := range groups {
-		buf.WriteString(`,"`)
-		buf.WriteString(g)
-		buf.WriteString(`":{`)
+		// 嵌套分组紧跟在上一层的 { 之后，不需要逗号
+		if openGroups == 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(buf, g)
+		buf.WriteString(`:{`)
 		openGroups++
 	}
 
-	// 写入属性
-	first := len(groups) == 0
+	// 写入属性（分组内的第一个属性不需要逗号）
+	needComma := openGroups == 0
 	for _, attr := range attrs {
 		if attr.Key == "" {
 			continue
 		}
-		switch {
-		case !first:
-			buf.WriteByte(',')
-		case openGroups > 0:
-			// 分组内的第一个属性不需要逗号
-		default:
+		if needComma {
 			buf.WriteByte(',')
 		}
-		first = false
+		needComma = true
 		f.writeAttr(buf, attr)
 	}
 
@@ -102,9 +95,8 @@ func (f *JSONFormatter) writeAttrs(buf *bytes.Buffer, attrs []slog.Attr, groups
 
 // writeAttr 写入单个属性
 func (f *JSONFormatter) writeAttr(buf *bytes.Buffer, attr slog.Attr) {
-	buf.WriteByte('"')
-	buf.WriteString(attr.Key)
-	buf.WriteString(`":`)
+	writeJSONString(buf, attr.Key)
+	buf.WriteByte(':')
 	f.writeValue(buf, attr.Value)
 }
 
@@ -134,7 +126,14 @@ func (f *JSONFormatter) writeValue(buf *bytes.Buffer, v slog.Value) {
 		if f.opts.Location != nil {
 			t = t.In(f.opts.Location)
 		}
-		writeJSONString(buf, t.Format(time.RFC3339Nano))
+		switch {
+		case isEpochFormat(f.opts.TimeFormat):
+			buf.WriteString(f.opts.timeText(t))
+		case f.opts.TimePrecision != PrecisionDefault:
+			writeJSONString(buf, formatTimePrecision(t, "rfc3339", f.opts.TimePrecision))
+		default:
+			writeJSONString(buf, t.Format(time.RFC3339Nano))
+		}
 	case slog.KindGroup:
 		buf.WriteByte('{')
 		attrs := v.Group()
@@ -167,7 +166,7 @@ func (f *JSONFormatter) writeAny(buf *bytes.Buffer, v any) {
 	}
 
 	// 尝试 JSON 序列化
-	data, err := json.Marshal(v)
+	data, err := marshalJSON(v, f.opts.TagHasher)
 	if err != nil {
 		writeJSONString(buf, "<error>")
 		return
@@ -0,0 +1,367 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnrecognized 行不是 JSON 或 Text 格式化器的输出
+var ErrUnrecognized = errors.New("formatter: unrecognized log line")
+
+// Entry 从格式化输出中解析出的日志记录
+type Entry struct {
+	// Time 记录时间，无法解析时为零值
+	Time time.Time
+	// Level 记录级别，无法识别时为 INFO
+	Level slog.Level
+	// Message 日志消息
+	Message string
+	// Source 源代码位置，没有时为空
+	Source string
+	// Attrs 其余字段，按出现顺序；JSON 对象解析为分组，Text 的值都是字符串
+	Attrs []slog.Attr
+}
+
+// ParseLine 解析一行 JSON 或 Text 格式化器的输出，是 Format 的逆过程。
+//
+// 用于重放归档日志、在命令行中过滤和转换日志等场景。JSON 行的 time 可以是
+// 字符串或 Unix 时间戳；没有时区的时间按本地时区解析，只有时分秒的时间无法还原日期，
+// Time 为零值。带颜色的输出和自定义格式化器的输出返回 ErrUnrecognized。
+func ParseLine(line []byte) (*Entry, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	switch {
+	case len(line) > 0 && line[0] == '{':
+		return parseJSONLine(line)
+	case bytes.HasPrefix(line, []byte("time=")) || bytes.HasPrefix(line, []byte("level=")):
+		return parseTextLine(string(line))
+	default:
+		return nil, ErrUnrecognized
+	}
+}
+
+// ParseLevelName 解析 LevelName 输出的级别名称，同时接受 WARNING、小写形式
+// 和 slog.Level.String 输出的偏移形式（如 INFO+2、DEBUG-4）。
+func ParseLevelName(name string) (slog.Level, bool) {
+	offset := 0
+	if i := strings.IndexAny(name, "+-"); i > 0 {
+		n, err := strconv.Atoi(name[i:])
+		if err != nil {
+			return slog.LevelInfo, false
+		}
+		name, offset = name[:i], n
+	}
+	var level slog.Level
+	switch strings.ToUpper(name) {
+	case "DEBUG":
+		level = slog.LevelDebug
+	case "INFO":
+		level = slog.LevelInfo
+	case "WARN", "WARNING":
+		level = slog.LevelWarn
+	case "ERROR":
+		level = slog.LevelError
+	default:
+		return slog.LevelInfo, false
+	}
+	return level + slog.Level(offset), true
+}
+
+// ParseTime 解析 WithTimeFormat 内置格式输出的时间文本，无法识别时返回 false。
+//
+// 纯数字按数量级识别为秒、毫秒或纳秒时间戳，带小数时为秒。
+func ParseTime(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	if sec, frac, ok := strings.Cut(s, "."); ok {
+		// 按文本解析小数部分，避免浮点误差
+		n, err1 := strconv.ParseInt(sec, 10, 64)
+		ns, err2 := strconv.ParseUint((frac + "000000000")[:9], 10, 64)
+		if err1 != nil || err2 != nil || len(frac) > 9 {
+			return time.Time{}, false
+		}
+		return time.Unix(n, int64(ns)), true //nolint:gosec // 不超过 9 位
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	switch {
+	case err != nil:
+		return time.Time{}, false
+	case n < 1e11:
+		return time.Unix(n, 0), true
+	case n < 1e14:
+		return time.UnixMilli(n), true
+	default:
+		return time.Unix(0, n), true
+	}
+}
+
+// Record 将解析的日志转换为格式化器的输入，Source 不是 file:line 时作为 source 属性保留。
+func (e *Entry) Record() *Record {
+	r := &Record{
+		Time:    e.Time,
+		Level:   e.Level,
+		Message: e.Message,
+		Attrs:   e.Attrs,
+	}
+	if e.Source != "" {
+		i := strings.LastIndexByte(e.Source, ':')
+		if n, err := strconv.Atoi(e.Source[i+1:]); i > 0 && err == nil {
+			r.Source = &slog.Source{File: e.Source[:i], Line: n}
+		} else {
+			r.Attrs = append(slices.Clip(r.Attrs), slog.String("source", e.Source))
+		}
+	}
+	return r
+}
+
+// setField 将内置字段写入 e，其他字段返回 false
+func (e *Entry) setField(key string, v slog.Value) bool {
+	switch key {
+	case "time":
+		switch v.Kind() {
+		case slog.KindFloat64:
+			e.Time, _ = ParseTime(strconv.FormatFloat(v.Float64(), 'f', -1, 64))
+		case slog.KindGroup:
+		default:
+			e.Time, _ = ParseTime(v.String())
+		}
+	case "level":
+		e.Level, _ = ParseLevelName(v.String())
+	case "msg":
+		e.Message = v.String()
+	case "source":
+		e.Source = sourceText(v)
+	default:
+		return false
+	}
+	return true
+}
+
+// sourceText 返回 source 字段的 file:line 文本，slog.JSONHandler 输出为
+// {"function":...,"file":...,"line":...} 对象
+func sourceText(v slog.Value) string {
+	if v.Kind() != slog.KindGroup {
+		return v.String()
+	}
+	var file, line string
+	for _, a := range v.Group() {
+		switch a.Key {
+		case "file":
+			file = a.Value.String()
+		case "line":
+			line = a.Value.String()
+		default:
+		}
+	}
+	if line == "" {
+		return file
+	}
+	return file + ":" + line
+}
+
+// parseJSONLine 解析 JSON 行，保持字段顺序
+func parseJSONLine(line []byte) (*Entry, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, ErrUnrecognized
+	}
+	attrs, err := decodeJSONObject(dec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnrecognized, err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: trailing data", ErrUnrecognized)
+	}
+
+	e := &Entry{Level: slog.LevelInfo}
+	for _, a := range attrs {
+		if !e.setField(a.Key, a.Value) {
+			e.Attrs = append(e.Attrs, a)
+		}
+	}
+	return e, nil
+}
+
+// decodeJSONObject 解析 '{' 之后的对象成员，消费结尾的 '}'
+func decodeJSONObject(dec *json.Decoder) ([]slog.Attr, error) {
+	var attrs []slog.Attr
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, errors.New("object key is not a string")
+		}
+		v, err := decodeJSONValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		if v.Kind() == slog.KindGroup {
+			attrs = append(attrs, slog.Attr{Key: key, Value: v})
+			continue
+		}
+		attrs = append(attrs, slog.Any(key, v.Any()))
+	}
+	_, err := dec.Token()
+	return attrs, err
+}
+
+// decodeJSONValue 解析一个 JSON 值，对象转换为分组，数组转换为 []any
+func decodeJSONValue(dec *json.Decoder) (slog.Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return slog.Value{}, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			attrs, err := decodeJSONObject(dec)
+			return slog.GroupValue(attrs...), err
+		}
+		var list []any
+		for dec.More() {
+			v, err := decodeJSONValue(dec)
+			if err != nil {
+				return slog.Value{}, err
+			}
+			if v.Kind() == slog.KindGroup {
+				list = append(list, groupMap(v.Group()))
+			} else {
+				list = append(list, v.Any())
+			}
+		}
+		_, err := dec.Token()
+		return slog.AnyValue(list), err
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return slog.Int64Value(n), nil
+		}
+		f, err := t.Float64()
+		return slog.Float64Value(f), err
+	default:
+		// string、bool、nil
+		return slog.AnyValue(t), nil
+	}
+}
+
+// groupMap 将数组中的对象转换为 map
+func groupMap(attrs []slog.Attr) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		if a.Value.Kind() == slog.KindGroup {
+			m[a.Key] = groupMap(a.Value.Group())
+			continue
+		}
+		m[a.Key] = a.Value.Any()
+	}
+	return m
+}
+
+// parseTextLine 解析 key=value 形式的 Text 行
+func parseTextLine(s string) (*Entry, error) {
+	attrs, rest, err := parseTextAttrs(s, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnrecognized, err)
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrUnrecognized, rest)
+	}
+
+	e := &Entry{Level: slog.LevelInfo}
+	for _, a := range attrs {
+		if !e.setField(a.Key, a.Value) {
+			e.Attrs = append(e.Attrs, a)
+		}
+	}
+	return e, nil
+}
+
+// parseTextAttrs 解析以空格分隔的 key=value 序列，inGroup 时在 '}' 处停止并返回剩余部分。
+//
+// NestedGroup 输出的 key={...} 解析为分组；默认格式 time=2006-01-02 15:04:05
+// 中日期后的时分秒合并到 time 的值中。
+func parseTextAttrs(s string, inGroup bool) (attrs []slog.Attr, rest string, err error) {
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" || (inGroup && s[0] == '}') {
+			return attrs, s, nil
+		}
+
+		var key string
+		if key, s, err = parseTextToken(s, " =}"); err != nil {
+			return nil, "", err
+		}
+		if s == "" || s[0] != '=' {
+			// 无键的片段只出现在默认时间格式的时分秒部分
+			if n := len(attrs); n > 0 && attrs[n-1].Key == "time" {
+				attrs[n-1].Value = slog.StringValue(attrs[n-1].Value.String() + " " + key)
+				continue
+			}
+			return nil, "", fmt.Errorf("missing '=' after %q", key)
+		}
+		s = s[1:]
+
+		if strings.HasPrefix(s, "{") {
+			var group []slog.Attr
+			if group, s, err = parseTextAttrs(s[1:], true); err != nil {
+				return nil, "", err
+			}
+			if !strings.HasPrefix(s, "}") {
+				return nil, "", errors.New("unterminated group")
+			}
+			s = s[1:]
+			attrs = append(attrs, slog.Attr{Key: key, Value: slog.GroupValue(group...)})
+			continue
+		}
+
+		stop := " "
+		if inGroup {
+			stop = " }"
+		}
+		var value string
+		if value, s, err = parseTextToken(s, stop); err != nil {
+			return nil, "", err
+		}
+		attrs = append(attrs, slog.String(key, value))
+	}
+}
+
+// parseTextToken 解析一个可能带引号的键或值，不带引号时在 stop 中的字符处结束
+func parseTextToken(s, stop string) (token, rest string, err error) {
+	if strings.HasPrefix(s, `"`) {
+		end := 1
+		for end < len(s) && s[end] != '"' {
+			if s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return "", "", errors.New("unterminated quoted string")
+		}
+		// 引号内的转义规则与 JSON 兼容
+		if err := json.Unmarshal([]byte(s[:end+1]), &token); err != nil {
+			return "", "", err
+		}
+		return token, s[end+1:], nil
+	}
+
+	end := strings.IndexAny(s, stop)
+	if end < 0 {
+		end = len(s)
+	}
+	return s[:end], s[end:], nil
+}
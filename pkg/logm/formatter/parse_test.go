@@ -0,0 +1,171 @@
+package formatter
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine_JSON(t *testing.T) {
+	r := newTestRecord("request \"done\"\n",
+		slog.Int("status", 200),
+		slog.Float64("ratio", 0.5),
+		slog.Bool("cached", false),
+		slog.Group("user", slog.Int("id", 42), slog.String("name", "alice")),
+		slog.Any("tags", []string{"a", "b"}),
+	)
+	r.Level = slog.LevelWarn
+	r.Source = &slog.Source{File: "/app/main.go", Line: 10}
+
+	data, err := JSON(WithTimeFormat("rfc3339ms")).Format(r)
+	require.NoError(t, err)
+	e, err := ParseLine(data)
+	require.NoError(t, err)
+
+	assert.True(t, testTime.Equal(e.Time))
+	assert.Equal(t, slog.LevelWarn, e.Level)
+	assert.Equal(t, "request \"done\"\n", e.Message)
+	assert.Equal(t, "/app/main.go:10", e.Source)
+	assert.Equal(t, []slog.Attr{
+		slog.Int64("status", 200),
+		slog.Float64("ratio", 0.5),
+		slog.Bool("cached", false),
+		slog.Group("user", slog.Int64("id", 42), slog.String("name", "alice")),
+		slog.Any("tags", []any{"a", "b"}),
+	}, e.Attrs)
+}
+
+func TestParseLine_Text(t *testing.T) {
+	r := newTestRecord("hello world",
+		slog.String("path", "/a b"),
+		slog.Int("n", 3),
+		slog.Group("db", slog.String("table", "users")),
+	)
+	r.Level = slog.LevelError
+
+	for name, f := range map[string]Formatter{
+		"default": Text(),
+		"rfc3339": Text(WithTimeFormat("rfc3339")),
+		"unix":    Text(WithTimeFormat("unix")),
+		"nested":  Text(WithNestedGroups()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := f.Format(r)
+			require.NoError(t, err)
+			e, err := ParseLine(data)
+			require.NoError(t, err, string(data))
+
+			assert.True(t, testTime.Equal(e.Time), e.Time)
+			assert.Equal(t, slog.LevelError, e.Level)
+			assert.Equal(t, "hello world", e.Message)
+			if name == "nested" {
+				assert.Equal(t, slog.Group("db", slog.String("table", "users")), e.Attrs[2])
+			} else {
+				assert.Equal(t, slog.String("db.table", "users"), e.Attrs[2])
+			}
+			assert.Equal(t, []slog.Attr{slog.String("path", "/a b"), slog.String("n", "3")}, e.Attrs[:2])
+		})
+	}
+}
+
+func TestParseLine_JSONEpoch(t *testing.T) {
+	for _, format := range []string{"unix", "unixms", "unixnano", "unixfloat"} {
+		data, err := JSON(WithTimeFormat(format)).Format(newTestRecord("x"))
+		require.NoError(t, err)
+		e, err := ParseLine(data)
+		require.NoError(t, err)
+		assert.True(t, testTime.Equal(e.Time), "%s: %s", format, e.Time)
+	}
+}
+
+func TestParseLine_Unrecognized(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"plain text",
+		"2024-01-15 10:30:45 INFO colored",
+		`{"msg":"broken"`,
+		`{"msg":"x"} trailing`,
+		`time=2024-01-15 msg="unterminated`,
+	} {
+		_, err := ParseLine([]byte(line))
+		require.ErrorIs(t, err, ErrUnrecognized, line)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.UTC)
+	for _, s := range []string{
+		"2024-01-15T10:30:45.123Z",
+		"1705314645123",
+		"1705314645.123",
+		"1705314645123000000",
+	} {
+		got, ok := ParseTime(s)
+		require.True(t, ok, s)
+		assert.True(t, want.Equal(got), "%s: %s", s, got)
+	}
+
+	got, ok := ParseTime("1705314645")
+	require.True(t, ok)
+	assert.Equal(t, int64(1705314645), got.Unix())
+
+	got, ok = ParseTime("2024-01-15 10:30:45")
+	require.True(t, ok)
+	assert.Equal(t, time.Local, got.Location())
+
+	_, ok = ParseTime("10:30:45")
+	assert.False(t, ok)
+}
+
+func TestParseLevelName(t *testing.T) {
+	for name, want := range map[string]slog.Level{"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "WARNING": slog.LevelWarn, "ERROR": slog.LevelError} {
+		got, ok := ParseLevelName(name)
+		assert.True(t, ok)
+		assert.Equal(t, want, got)
+	}
+	got, ok := ParseLevelName("VERBOSE")
+	assert.False(t, ok)
+	assert.Equal(t, slog.LevelInfo, got)
+
+	got, ok = ParseLevelName("INFO+2")
+	assert.True(t, ok)
+	assert.Equal(t, slog.LevelInfo+2, got)
+
+	got, ok = ParseLevelName("DEBUG-4")
+	assert.True(t, ok)
+	assert.Equal(t, slog.LevelDebug-4, got)
+
+	_, ok = ParseLevelName("INFO+x")
+	assert.False(t, ok)
+}
+
+func TestParseLine_SlogJSONHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, Level: slog.LevelDebug - 4}))
+	logger.Log(t.Context(), slog.LevelWarn+1, "disk", "free", 0.1)
+
+	e, err := ParseLine(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn+1, e.Level)
+	assert.Equal(t, "disk", e.Message)
+	assert.Contains(t, e.Source, "parse_test.go:")
+	assert.False(t, e.Time.IsZero())
+	assert.Equal(t, []slog.Attr{slog.Float64("free", 0.1)}, e.Attrs)
+}
+
+func TestEntry_Record(t *testing.T) {
+	e := &Entry{Level: slog.LevelWarn, Message: "m", Source: "app/main.go:42", Attrs: []slog.Attr{slog.Int("n", 1)}}
+	r := e.Record()
+	assert.Equal(t, &slog.Source{File: "app/main.go", Line: 42}, r.Source)
+	assert.Equal(t, []slog.Attr{slog.Int("n", 1)}, r.Attrs)
+
+	e.Source = "legacy.c"
+	r = e.Record()
+	assert.Nil(t, r.Source)
+	assert.Equal(t, []slog.Attr{slog.Int("n", 1), slog.String("source", "legacy.c")}, r.Attrs)
+	assert.Len(t, e.Attrs, 1)
+}
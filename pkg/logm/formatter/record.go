@@ -0,0 +1,77 @@
+package formatter
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// NewRecord 创建日志记录，时间默认为当前时间。
+//
+// 配合链式方法构造测试数据，适合编写自定义 Formatter 或 Interceptor 的测试：
+//
+//	r := formatter.NewRecord(slog.LevelInfo, "user login").
+//	    WithTime(fixed).
+//	    With("user_id", 42).
+//	    WithSource("/app/main.go", 10)
+//	data, err := f.Format(r)
+func NewRecord(level slog.Level, msg string) *Record {
+	return &Record{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+	}
+}
+
+// WithTime 设置日志时间，返回 r 本身。
+func (r *Record) WithTime(t time.Time) *Record {
+	r.Time = t
+	return r
+}
+
+// WithAttr 追加属性，返回 r 本身。
+func (r *Record) WithAttr(attrs ...slog.Attr) *Record {
+	r.Attrs = append(r.Attrs, attrs...)
+	return r
+}
+
+// With 以 slog 风格的键值对追加属性，返回 r 本身。
+//
+// 参数规则与 slog.Logger.Info 一致，可以混用 slog.Attr 和 key, value 对。
+func (r *Record) With(args ...any) *Record {
+	sr := slog.NewRecord(time.Time{}, 0, "", 0)
+	sr.Add(args...)
+	sr.Attrs(func(a slog.Attr) bool {
+		r.Attrs = append(r.Attrs, a)
+		return true
+	})
+	return r
+}
+
+// WithGroup 追加分组路径，返回 r 本身。
+//
+// 与 Handler.WithGroup 一致，分组作用于记录的全部属性。
+func (r *Record) WithGroup(names ...string) *Record {
+	r.Groups = append(r.Groups, names...)
+	return r
+}
+
+// WithSource 设置源代码位置，返回 r 本身。
+func (r *Record) WithSource(file string, line int) *Record {
+	r.Source = &slog.Source{File: file, Line: line}
+	return r
+}
+
+// Clone 返回 r 的副本，修改副本的属性、分组和源代码位置不影响 r。
+//
+// 属性值本身不会深拷贝，slog.Value 为不可变值，KindAny 中的指针仍指向同一对象。
+func (r *Record) Clone() *Record {
+	c := *r
+	c.Attrs = slices.Clone(r.Attrs)
+	c.Groups = slices.Clone(r.Groups)
+	if r.Source != nil {
+		src := *r.Source
+		c.Source = &src
+	}
+	return &c
+}
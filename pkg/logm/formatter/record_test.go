@@ -0,0 +1,59 @@
+package formatter
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecord(t *testing.T) {
+	r := NewRecord(slog.LevelWarn, "disk low").
+		WithTime(testTime).
+		WithAttr(slog.String("mount", "/data")).
+		With("free", 42, slog.Bool("critical", true)).
+		WithGroup("host").
+		WithSource("/app/main.go", 10)
+
+	assert.Equal(t, testTime, r.Time)
+	assert.Equal(t, slog.LevelWarn, r.Level)
+	assert.Equal(t, "disk low", r.Message)
+	require.Len(t, r.Attrs, 3)
+	assert.Equal(t, "mount", r.Attrs[0].Key)
+	assert.Equal(t, int64(42), r.Attrs[1].Value.Int64())
+	assert.True(t, r.Attrs[2].Value.Bool())
+	assert.Equal(t, []string{"host"}, r.Groups)
+	assert.Equal(t, &slog.Source{File: "/app/main.go", Line: 10}, r.Source)
+
+	data, err := JSON(WithTimezone("UTC")).Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"host":{"mount":"/data","free":42,"critical":true}`)
+}
+
+func TestNewRecord_DefaultTime(t *testing.T) {
+	r := NewRecord(slog.LevelInfo, "now")
+	assert.WithinDuration(t, time.Now(), r.Time, time.Second)
+	assert.Empty(t, r.Attrs)
+}
+
+func TestRecord_Clone(t *testing.T) {
+	r := NewRecord(slog.LevelInfo, "orig").
+		With("k", "v").
+		WithGroup("g").
+		WithSource("/app/main.go", 10)
+
+	c := r.Clone()
+	c.Message = "changed"
+	c.Attrs[0] = slog.String("k", "changed")
+	c.Attrs = append(c.Attrs, slog.Int("n", 1))
+	c.Groups[0] = "h"
+	c.Source.Line = 20
+
+	assert.Equal(t, "orig", r.Message)
+	assert.Equal(t, []slog.Attr{slog.String("k", "v")}, r.Attrs)
+	assert.Equal(t, []string{"g"}, r.Groups)
+	assert.Equal(t, 10, r.Source.Line)
+	assert.Nil(t, NewRecord(slog.LevelInfo, "m").Clone().Source)
+}
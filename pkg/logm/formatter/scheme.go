@@ -45,6 +45,22 @@ func DefaultScheme() *ColorScheme {
 	}
 }
 
+// LightScheme 浅色背景终端的配色方案，避免黄色和灰色在白底上难以辨认
+func LightScheme() *ColorScheme {
+	return &ColorScheme{
+		Time:   ColorBlue,
+		Debug:  ColorCyan,
+		Info:   ColorGreen,
+		Warn:   ColorPurple,
+		Error:  ColorRed,
+		Key:    ColorBlue,
+		String: ColorGreen,
+		Number: ColorPurple,
+		Source: ColorBlue,
+		Null:   ColorPurple,
+	}
+}
+
 // LevelColor 返回级别对应颜色
 func (s *ColorScheme) LevelColor(level slog.Level) string {
 	switch {
@@ -0,0 +1,501 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TagMask logm:"mask" 字段输出的掩码
+const TagMask = "***"
+
+// 结构体字段标签 logm 的取值：
+//
+//	type User struct {
+//	    Name     string `json:"name"`
+//	    Password string `json:"password" logm:"omit"` // 不输出
+//	    Phone    string `json:"phone" logm:"mask"`    // 输出 "***"
+//	    Email    string `json:"email" logm:"hash"`    // 输出 WithTagHasher 计算的假名
+//	}
+//
+// logm:"hash" 需要通过 WithTagHasher 配置带密钥的假名函数（如 redact.Keyring.Pseudonym），
+// 日志仍可按假名关联同一个值；未配置时输出 TagMask，不会输出可被字典反推的无密钥摘要。
+//
+// 格式化器序列化结构体时遵循这些标签，敏感字段在类型定义处统一控制，
+// 无需在每次记录日志时处理。嵌套结构体、指针、切片和 map 中的结构体同样生效；
+// 实现了 slog.LogValuer 的类型按 LogValue 的结果输出（如 logm.Sensitive 输出占位文本），
+// 实现了 json.Marshaler 或 encoding.TextMarshaler 的类型按其自身实现输出。
+const (
+	tagOmit = "omit"
+	tagMask = "mask"
+	tagHash = "hash"
+)
+
+var (
+	logValuerType     = reflect.TypeFor[slog.LogValuer]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// tagCache 缓存类型是否（间接）包含 logm 标签，key 为 reflect.Type
+var tagCache sync.Map
+
+// fieldCache 缓存结构体的字段信息，key 为 reflect.Type
+var fieldCache sync.Map
+
+// tagField 结构体字段信息
+type tagField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+	quoted    bool // json:",string"
+	action    string
+}
+
+// MarshalJSON 按格式化器的规则序列化 v：遵循结构体字段的 logm 标签，
+// logm:"omit" 字段不输出，logm:"mask" 和 logm:"hash" 字段输出 TagMask。
+//
+// 供需要检查日志实际输出内容的拦截器使用，如 redact.Detector。
+func MarshalJSON(v any) ([]byte, error) {
+	return marshalJSON(v, nil)
+}
+
+// marshalJSON 序列化任意值，遵循结构体字段的 logm 标签，hash 为 logm:"hash" 的假名函数。
+//
+// 类型不含 logm 标签时等价于 json.Marshal。
+func marshalJSON(v any, hash func(string) string) ([]byte, error) {
+	if v == nil || !hasTags(reflect.TypeOf(v)) {
+		return json.Marshal(v)
+	}
+	var buf bytes.Buffer
+	if err := encodeTagged(&buf, &encodeState{hash: hash}, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// hasTags 判断类型是否包含需要处理的 logm 标签
+func hasTags(t reflect.Type) bool {
+	if cached, ok := tagCache.Load(t); ok {
+		return cached.(bool) //nolint:forcetypeassert // 缓存值类型固定
+	}
+	// 顶层计算时没有其他类型在计算中，结果总是确定的
+	result, _ := computeHasTags(t, make(map[reflect.Type]bool))
+	tagCache.Store(t, result)
+	return result
+}
+
+// computeHasTags 计算类型是否包含 logm 标签，visiting 为正在计算的类型，用于处理递归类型。
+//
+// 遇到正在计算的类型时暂按 false 处理并返回 pending = true：此时的 false 依赖外层的结果，
+// 不能写入缓存，否则相互递归的类型会被永久当作不含标签。true 不受影响，总是可以缓存。
+func computeHasTags(t reflect.Type, visiting map[reflect.Type]bool) (result, pending bool) {
+	if cached, ok := tagCache.Load(t); ok {
+		return cached.(bool), false //nolint:forcetypeassert // 缓存值类型固定
+	}
+	if visiting[t] {
+		return false, true
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	result, pending = typeHasTags(t, visiting)
+	if result || !pending {
+		tagCache.Store(t, result)
+	}
+	return result, pending && !result
+}
+
+// typeHasTags 检查类型本身及其元素、字段是否包含 logm 标签
+func typeHasTags(t reflect.Type, visiting map[reflect.Type]bool) (result, pending bool) {
+	if t.Implements(logValuerType) {
+		return true, false
+	}
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return false, false
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return computeHasTags(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			if !sf.IsExported() && !sf.Anonymous {
+				continue
+			}
+			if _, ok := sf.Tag.Lookup("logm"); ok {
+				return true, false
+			}
+			has, p := computeHasTags(sf.Type, visiting)
+			if has {
+				return true, false
+			}
+			pending = pending || p
+		}
+	default:
+	}
+	return false, pending
+}
+
+// structFields 返回结构体的可导出字段，嵌入结构体的字段按 encoding/json 规则提升
+func structFields(t reflect.Type) []tagField {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]tagField) //nolint:forcetypeassert // 缓存值类型固定
+	}
+
+	var fields []tagField
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		jsonTag := sf.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(jsonTag, ",")
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			// 未命名的嵌入结构体：提升其字段
+			for _, f := range structFields(ft) {
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, tagField{
+			index:     []int{i},
+			name:      name,
+			omitEmpty: slices.Contains(strings.Split(opts, ","), "omitempty"),
+			quoted:    slices.Contains(strings.Split(opts, ","), "string"),
+			action:    sf.Tag.Get("logm"),
+		})
+	}
+
+	// 同名字段保留层级最浅的一个
+	seen := make(map[string]int, len(fields))
+	out := fields[:0:0]
+	for _, f := range fields {
+		if j, ok := seen[f.name]; ok {
+			if len(f.index) < len(out[j].index) {
+				out[j] = f
+			}
+			continue
+		}
+		seen[f.name] = len(out)
+		out = append(out, f)
+	}
+
+	fieldCache.Store(t, out)
+	return out
+}
+
+// maxTagDepth encodeTagged 的最大嵌套深度，与 encoding/json 开始检测循环的深度一致
+const maxTagDepth = 1000
+
+// encodeState encodeTagged 的递归状态，用于检测循环引用
+type encodeState struct {
+	hash     func(string) string // logm:"hash" 的假名函数，nil 时输出 TagMask
+	depth    int
+	visiting map[visitKey]struct{} // 当前路径上的指针、map 和切片
+}
+
+// visitKey 标识指针、map 或切片，切片同时比较长度
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// enter 进入一层嵌套，超出深度上限或遇到循环引用时返回错误。
+//
+// 返回 true 时调用方在处理完 v 后调用 leave。
+func (st *encodeState) enter(v reflect.Value) (tracked bool, err error) {
+	if st.depth++; st.depth > maxTagDepth {
+		return false, fmt.Errorf("exceeded max depth %d", maxTagDepth)
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+	key := visitKeyOf(v)
+	if _, ok := st.visiting[key]; ok {
+		return false, fmt.Errorf("encountered a cycle via %s", v.Type())
+	}
+	if st.visiting == nil {
+		st.visiting = make(map[visitKey]struct{})
+	}
+	st.visiting[key] = struct{}{}
+	return true, nil
+}
+
+// leave 退出 enter 进入的一层嵌套
+func (st *encodeState) leave(v reflect.Value, tracked bool) {
+	st.depth--
+	if tracked {
+		delete(st.visiting, visitKeyOf(v))
+	}
+}
+
+// visitKeyOf 返回非 nil 指针、map 或切片的 visitKey
+func visitKeyOf(v reflect.Value) visitKey {
+	key := visitKey{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	return key
+}
+
+// encodeTagged 按 logm 标签写入 v 的 JSON，遇到循环引用时返回错误
+func encodeTagged(buf *bytes.Buffer, st *encodeState, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+	if !hasTags(v.Type()) {
+		return encodeDefault(buf, v)
+	}
+	tracked, err := st.enter(v)
+	if err != nil {
+		return err
+	}
+	defer st.leave(v, tracked)
+	if v.Type().Implements(logValuerType) && v.CanInterface() {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		//nolint:forcetypeassert // 已检查实现了 LogValuer
+		return encodeLogValue(buf, st, v.Interface().(slog.LogValuer).LogValue().Resolve())
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return encodeTagged(buf, st, v.Elem())
+
+	case reflect.Struct:
+		return encodeStruct(buf, st, v)
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i := range v.Len() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeTagged(buf, st, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+
+	case reflect.Map:
+		return encodeMap(buf, st, v)
+
+	default:
+		return encodeDefault(buf, v)
+	}
+}
+
+// encodeLogValue 写入 LogValuer 解析后的值，分组输出为对象
+func encodeLogValue(buf *bytes.Buffer, st *encodeState, v slog.Value) error {
+	switch v.Kind() {
+	case slog.KindString:
+		writeJSONString(buf, v.String())
+	case slog.KindInt64:
+		buf.WriteString(strconv.FormatInt(v.Int64(), 10))
+	case slog.KindUint64:
+		buf.WriteString(strconv.FormatUint(v.Uint64(), 10))
+	case slog.KindBool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case slog.KindDuration:
+		writeJSONString(buf, v.Duration().String())
+	case slog.KindGroup:
+		buf.WriteByte('{')
+		for i, a := range v.Group() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, a.Key)
+			buf.WriteByte(':')
+			if err := encodeLogValue(buf, st, a.Value.Resolve()); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case slog.KindAny:
+		return encodeTagged(buf, st, reflect.ValueOf(v.Any()))
+	default:
+		// Float64、Time 等交给标准库，保证 NaN 等非法值返回错误
+		return encodeDefault(buf, reflect.ValueOf(v.Any()))
+	}
+	return nil
+}
+
+// encodeStruct 写入结构体，处理字段标签
+func encodeStruct(buf *bytes.Buffer, st *encodeState, v reflect.Value) error {
+	buf.WriteByte('{')
+	first := true
+	for _, f := range structFields(v.Type()) {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || f.action == tagOmit {
+			continue
+		}
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeJSONString(buf, f.name)
+		buf.WriteByte(':')
+
+		switch f.action {
+		case tagMask:
+			writeJSONString(buf, TagMask)
+		case tagHash:
+			writeJSONString(buf, hashValue(fv, st.hash))
+		default:
+			if f.quoted && !hasTags(fv.Type()) {
+				// json:",string" 交给标准库处理单个字段
+				data, err := json.Marshal(fv.Interface())
+				if err != nil {
+					return err
+				}
+				writeJSONString(buf, string(data))
+				continue
+			}
+			if err := encodeTagged(buf, st, fv); err != nil {
+				return err
+			}
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// encodeMap 写入 map，键按 encoding/json 规则转换为字符串并排序
+func encodeMap(buf *bytes.Buffer, st *encodeState, v reflect.Value) error {
+	if v.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+
+	type entry struct {
+		key string
+		val reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKeyString(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key, iter.Value()})
+	}
+	slices.SortFunc(entries, func(a, b entry) int { return strings.Compare(a.key, b.key) })
+
+	buf.WriteByte('{')
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(buf, e.key)
+		buf.WriteByte(':')
+		if err := encodeTagged(buf, st, e.val); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// mapKeyString 将 map 键转换为字符串
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		data, err := tm.MarshalText()
+		return string(data), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("unsupported map key type %s", k.Type())
+	}
+}
+
+// encodeDefault 使用标准库序列化
+func encodeDefault(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.CanInterface() {
+		buf.WriteString("null")
+		return nil
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// fieldByIndex 按索引取字段，经过 nil 嵌入指针时返回 false
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// hashValue 返回 hash 计算的假名，hash 为 nil 时返回 TagMask
+func hashValue(v reflect.Value, hash func(string) string) string {
+	if hash == nil {
+		return TagMask
+	}
+	var s string
+	if v.Kind() == reflect.String {
+		s = v.String()
+	} else if v.CanInterface() {
+		s = fmt.Sprint(v.Interface())
+	}
+	return hash(s)
+}
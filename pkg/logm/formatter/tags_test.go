@@ -0,0 +1,252 @@
+package formatter
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tagAddress struct {
+	City   string `json:"city"`
+	Street string `json:"street" logm:"mask"`
+}
+
+type tagBase struct {
+	ID int `json:"id"`
+}
+
+type tagUser struct {
+	tagBase
+
+	Name     string            `json:"name"`
+	Password string            `json:"password" logm:"omit"`
+	Phone    string            `json:"phone,omitempty" logm:"mask"`
+	Email    string            `json:"email" logm:"hash"`
+	Address  *tagAddress       `json:"address,omitempty"`
+	History  []tagAddress      `json:"history,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Ignored  string            `json:"-"`
+}
+
+type plainUser struct {
+	Name string `json:"name"`
+}
+
+// tagPseudonym 测试用假名函数，只保留长度
+func tagPseudonym(s string) string { return "pseudo:" + strconv.Itoa(len(s)) }
+
+func TestMarshalJSON_Tags(t *testing.T) {
+	u := tagUser{
+		tagBase:  tagBase{ID: 7},
+		Name:     "alice",
+		Password: "hunter2",
+		Email:    "alice@example.com",
+		Address:  &tagAddress{City: "Shanghai", Street: "Nanjing Rd"},
+		History:  []tagAddress{{City: "Beijing", Street: "Chang'an Ave"}},
+		Ignored:  "x",
+	}
+
+	data, err := marshalJSON(u, nil)
+	require.NoError(t, err)
+	s := string(data)
+
+	assert.NotContains(t, s, "hunter2")
+	assert.NotContains(t, s, "password")
+	assert.NotContains(t, s, "alice@example.com")
+	assert.NotContains(t, s, "Nanjing")
+	assert.NotContains(t, s, "Chang'an")
+	assert.NotContains(t, s, "phone", "omitempty 对标签字段同样生效")
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.InDelta(t, 7, got["id"], 0)
+	assert.Equal(t, "alice", got["name"])
+	assert.Equal(t, TagMask, got["email"], "未配置假名函数时不输出摘要")
+	assert.Equal(t, map[string]any{"city": "Shanghai", "street": TagMask}, got["address"])
+
+	data, err = marshalJSON(u, tagPseudonym)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"email":"pseudo:17"`)
+
+	// 指针与 map 中的结构体同样生效
+	data, err = marshalJSON(map[string]*tagUser{"u": {Phone: "13800000000"}}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"phone":"***"`)
+}
+
+// tagCycleA 和 tagCycleC 相互递归，标签只在 tagCycleA 上
+type tagCycleA struct {
+	C      *tagCycleC
+	Secret string `logm:"mask"`
+}
+
+type tagCycleC struct {
+	A *tagCycleA
+}
+
+// tagCycleSelf 自身递归且不含标签
+type tagCycleSelf struct {
+	Next *tagCycleSelf
+	Name string
+}
+
+func TestMarshalJSON_RecursiveTypes(t *testing.T) {
+	v := tagCycleA{C: &tagCycleC{A: &tagCycleA{Secret: "nested-plaintext"}}, Secret: "top"}
+	data, err := marshalJSON(v, nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"C":{"A":{"C":null,"Secret":"***"}},"Secret":"***"}`, string(data))
+
+	// 从另一端开始同样生效
+	data, err = marshalJSON(tagCycleC{A: &tagCycleA{Secret: "x"}}, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"x"`)
+
+	assert.True(t, hasTags(reflect.TypeFor[tagCycleC]()))
+	assert.False(t, hasTags(reflect.TypeFor[tagCycleSelf]()))
+}
+
+// tagNode 带标签的自引用类型
+type tagNode struct {
+	Next   *tagNode
+	Kids   []*tagNode
+	Secret string `logm:"mask"`
+}
+
+func TestMarshalJSON_CyclicValues(t *testing.T) {
+	n := &tagNode{Secret: "x"}
+	n.Next = n
+	_, err := marshalJSON(n, nil)
+	require.ErrorContains(t, err, "cycle")
+
+	m := &tagNode{}
+	m.Kids = []*tagNode{{Next: m}}
+	_, err = marshalJSON(m, nil)
+	require.ErrorContains(t, err, "cycle")
+
+	// 共享但不成环的指针可以正常序列化
+	shared := &tagNode{Secret: "s"}
+	data, err := marshalJSON(tagNode{Next: shared, Kids: []*tagNode{shared, shared}}, nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Next":{"Next":null,"Kids":null,"Secret":"***"},"Kids":[`+
+		`{"Next":null,"Kids":null,"Secret":"***"},{"Next":null,"Kids":null,"Secret":"***"}],"Secret":"***"}`, string(data))
+
+	// 过深的嵌套同样返回错误
+	deep := &tagNode{}
+	for range maxTagDepth {
+		deep = &tagNode{Next: deep}
+	}
+	_, err = marshalJSON(deep, nil)
+	require.ErrorContains(t, err, "max depth")
+
+	// 格式化器回退到错误占位
+	r := &Record{Level: slog.LevelInfo, Message: "m", Attrs: []slog.Attr{slog.Any("n", n)}}
+	out, err := JSON().Format(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"n":"<error>"`)
+}
+
+func TestMarshalJSON_NoTags(t *testing.T) {
+	for _, v := range []any{plainUser{Name: "bob"}, map[string]int{"b": 2, "a": 1}, []string{"x"}, nil} {
+		want, err := json.Marshal(v)
+		require.NoError(t, err)
+		got, err := marshalJSON(v, nil)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got))
+	}
+}
+
+func TestFormatters_HonorTags(t *testing.T) {
+	u := tagUser{Name: "alice", Password: "hunter2", Phone: "13800000000", Email: "alice@example.com"}
+	r := &Record{
+		Time:    time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC),
+		Level:   slog.LevelInfo,
+		Message: "login",
+		Attrs:   []slog.Attr{slog.Any("user", u), slog.Any("ptr", &u)},
+	}
+
+	formatters := map[string]Formatter{
+		"JSON":      JSON(WithTagHasher(tagPseudonym)),
+		"Text":      Text(WithTagHasher(tagPseudonym)),
+		"ColorText": ColorText(WithColor(false), WithTagHasher(tagPseudonym)),
+		"ColorJSON": ColorJSON(WithColor(false), WithTagHasher(tagPseudonym)),
+	}
+	for name, f := range formatters {
+		t.Run(name, func(t *testing.T) {
+			data, err := f.Format(r)
+			require.NoError(t, err)
+			out := string(data)
+			assert.Contains(t, out, "alice")
+			for _, secret := range []string{"hunter2", "13800000000", "alice@example.com"} {
+				assert.NotContains(t, out, secret)
+			}
+			assert.Contains(t, out, "pseudo:17")
+		})
+	}
+}
+
+// tagToken 通过 LogValue 隐藏原始值，未实现 json.Marshaler
+type tagToken string
+
+func (tagToken) LogValue() slog.Value { return slog.StringValue("[hidden]") }
+
+// tagCreds 通过 LogValue 输出分组
+type tagCreds struct {
+	User string
+	Key  string
+}
+
+func (c tagCreds) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("user", c.User), slog.Int("key_len", len(c.Key)))
+}
+
+type tagSession struct {
+	ID     string     `json:"id"`
+	Token  tagToken   `json:"token"`
+	Creds  *tagCreds  `json:"creds"`
+	Tokens []tagToken `json:"tokens"`
+}
+
+func TestMarshalJSON_LogValuer(t *testing.T) {
+	s := tagSession{
+		ID:     "s1",
+		Token:  "raw-token",
+		Creds:  &tagCreds{User: "alice", Key: "secret-key"},
+		Tokens: []tagToken{"t1"},
+	}
+	data, err := marshalJSON(s, nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"s1","token":"[hidden]","creds":{"user":"alice","key_len":10},"tokens":["[hidden]"]}`, string(data))
+
+	data, err = marshalJSON(tagSession{}, nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"","token":"[hidden]","creds":null,"tokens":null}`, string(data))
+}
+
+func TestFormatters_HonorNestedLogValuer(t *testing.T) {
+	r := &Record{
+		Time:    time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC),
+		Level:   slog.LevelInfo,
+		Message: "login",
+		Attrs:   []slog.Attr{slog.Any("session", tagSession{ID: "s1", Token: "raw-token"})},
+	}
+
+	for name, f := range map[string]Formatter{
+		"JSON":      JSON(),
+		"Text":      Text(),
+		"ColorText": ColorText(WithColor(false)),
+		"ColorJSON": ColorJSON(WithColor(false)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := f.Format(r)
+			require.NoError(t, err)
+			assert.NotContains(t, string(data), "raw-token")
+			assert.Contains(t, string(data), "[hidden]")
+		})
+	}
+}
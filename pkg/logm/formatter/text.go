@@ -3,6 +3,7 @@ package formatter
 import (
 	"bytes"
 	"log/slog"
+	"reflect"
 	"strconv"
 	"strings"
 )
@@ -29,12 +30,9 @@ func (f *TextFormatter) Format(r *Record) ([]byte, error) {
 	defer putBuffer(buf)
 
 	// 时间
-	t := r.Time
-	if f.opts.Location != nil {
-		t = t.In(f.opts.Location)
-	}
+	t := f.opts.recordTime(r)
 	buf.WriteString("time=")
-	buf.WriteString(formatTime(t, f.opts.TimeFormat))
+	buf.WriteString(f.opts.timeText(t))
 
 	// 级别
 	buf.WriteString(" level=")
@@ -51,7 +49,7 @@ func (f *TextFormatter) Format(r *Record) ([]byte, error) {
 	}
 
 	// 属性
-	f.writeAttrs(buf, r.Attrs, r.Groups)
+	f.writeAttrs(buf, f.opts.recordAttrs(r), r.Groups)
 
 	buf.WriteByte('\n')
 
@@ -60,41 +58,72 @@ func (f *TextFormatter) Format(r *Record) ([]byte, error) {
 
 // writeAttrs 写入属性
 func (f *TextFormatter) writeAttrs(buf *bytes.Buffer, attrs []slog.Attr, groups []string) {
+	if f.opts.NestedGroup {
+		for _, attr := range nestAttrs(attrs, groups) {
+			f.writeAttr(buf, attr, "")
+		}
+		return
+	}
+
 	prefix := ""
-	var prefixSb63 strings.Builder
-	for _, g := range groups {
-		prefixSb63.WriteString(g + ".")
+	if len(groups) > 0 {
+		prefix = strings.Join(groups, ".") + "."
 	}
-	prefix += prefixSb63.String()
 
 	for _, attr := range attrs {
-		if attr.Key == "" {
-			continue
-		}
-		buf.WriteByte(' ')
-		buf.WriteString(prefix)
 		f.writeAttr(buf, attr, prefix)
 	}
 }
 
-// writeAttr 写入单个属性
+// writeAttr 写入单个属性（含前导空格）。
+//
+// 分组属性展开为 prefix.group.key=value 形式，空键分组内联到当前层级；
+// 启用 NestedGroup 时输出为 group={key=value}。
 func (f *TextFormatter) writeAttr(buf *bytes.Buffer, attr slog.Attr, prefix string) {
-	buf.WriteString(attr.Key)
+	v := attr.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if f.opts.NestedGroup && attr.Key != "" {
+			if len(v.Group()) == 0 {
+				return
+			}
+			buf.WriteByte(' ')
+			writeTextValue(buf, attr.Key)
+			buf.WriteString("={")
+			start := buf.Len()
+			for _, ga := range v.Group() {
+				f.writeAttr(buf, ga, "")
+			}
+			trimGroupSpace(buf, start)
+			buf.WriteByte('}')
+			return
+		}
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, ga := range v.Group() {
+			f.writeAttr(buf, ga, prefix)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+
+	buf.WriteByte(' ')
+	writeTextValue(buf, prefix+attr.Key)
 	buf.WriteByte('=')
 
 	// 检查是否为 raw 字段（不加引号直接输出）
 	if f.opts.RawFields[attr.Key] {
-		buf.WriteString(attr.Value.Resolve().String())
+		buf.WriteString(v.String())
 		return
 	}
 
-	f.writeValue(buf, attr.Value, prefix+attr.Key+".")
+	f.writeValue(buf, v)
 }
 
 // writeValue 写入值
-func (f *TextFormatter) writeValue(buf *bytes.Buffer, v slog.Value, prefix string) {
-	v = v.Resolve()
-
+func (f *TextFormatter) writeValue(buf *bytes.Buffer, v slog.Value) {
 	switch v.Kind() {
 	case slog.KindString:
 		writeTextValue(buf, v.String())
@@ -117,17 +146,18 @@ func (f *TextFormatter) writeValue(buf *bytes.Buffer, v slog.Value, prefix strin
 		if f.opts.Location != nil {
 			t = t.In(f.opts.Location)
 		}
-		writeTextValue(buf, formatTime(t, f.opts.TimeFormat))
-	case slog.KindGroup:
-		// 展开分组
-		attrs := v.Group()
-		for i, attr := range attrs {
-			if i > 0 {
-				buf.WriteByte(' ')
-				buf.WriteString(prefix[:len(prefix)-1]) // 去掉末尾的点
+		writeTextValue(buf, f.opts.timeText(t))
+	case slog.KindAny:
+		// 带 logm 标签的结构体序列化为 JSON，避免 %v 输出敏感字段
+		if a := v.Any(); a != nil && hasTags(reflect.TypeOf(a)) {
+			if data, err := marshalJSON(a, f.opts.TagHasher); err == nil {
+				writeTextValue(buf, string(data))
+				return
 			}
-			f.writeAttr(buf, attr, prefix)
+			writeTextValue(buf, "<error>")
+			return
 		}
+		writeTextValue(buf, v.String())
 	default:
 		writeTextValue(buf, v.String())
 	}
@@ -135,35 +165,9 @@ func (f *TextFormatter) writeValue(buf *bytes.Buffer, v slog.Value, prefix strin
 
 // writeTextValue 写入文本值（需要时添加引号）
 func writeTextValue(buf *bytes.Buffer, s string) {
-	needQuote := false
-	for _, r := range s {
-		if r == ' ' || r == '"' || r == '=' || r == '\n' || r == '\r' || r == '\t' {
-			needQuote = true
-			break
-		}
-	}
-
-	if !needQuote && len(s) > 0 {
-		buf.WriteString(s)
+	if needsTextQuote(s) {
+		writeTextQuoted(buf, s)
 		return
 	}
-
-	buf.WriteByte('"')
-	for _, r := range s {
-		switch r {
-		case '"':
-			buf.WriteString(`\"`)
-		case '\\':
-			buf.WriteString(`\\`)
-		case '\n':
-			buf.WriteString(`\n`)
-		case '\r':
-			buf.WriteString(`\r`)
-		case '\t':
-			buf.WriteString(`\t`)
-		default:
-			buf.WriteRune(r)
-		}
-	}
-	buf.WriteByte('"')
+	buf.WriteString(s)
 }
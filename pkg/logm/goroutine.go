@@ -0,0 +1,79 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+)
+
+// GoroutineKey WithGoroutineID 附加的 goroutine id 属性键名
+const GoroutineKey = "goroutine"
+
+// WorkerKey WithWorkerID 设置的 worker id 属性键名
+const WorkerKey = "worker"
+
+// workerIDKey context 中存储 worker id 的键
+type workerIDKey struct{}
+
+// WithGoroutineID 为每条日志附加记录所在 goroutine 的 id，便于调试时区分交错的并发日志。
+//
+// context 中通过 WithWorkerID 设置了 worker id 时改为附加 worker 属性，不再附加 goroutine id：
+//
+//	logm.Init(logm.PresetDev()..., logm.WithGoroutineID())
+//
+//	go func(id int) {
+//	    ctx := logm.WithWorkerID(ctx, fmt.Sprintf("fetcher-%d", id))
+//	    slog.InfoContext(ctx, "start") // worker=fetcher-1
+//	}(1)
+//
+// 获取 goroutine id 需要解析 runtime.Stack 的输出，有一定开销，建议仅在调试时开启。
+func WithGoroutineID() Option {
+	return WithInterceptor(goroutineInterceptor)
+}
+
+// WithWorkerID 将 worker id 存入 context，配合 WithGoroutineID 使用。
+func WithWorkerID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, workerIDKey{}, id)
+}
+
+// WorkerIDFromContext 返回 WithWorkerID 设置的 worker id。
+func WorkerIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(workerIDKey{}).(string)
+	return id, ok
+}
+
+// goroutineInterceptor 附加 worker id 或 goroutine id
+func goroutineInterceptor(ctx context.Context, r *Record) *Record {
+	if id, ok := WorkerIDFromContext(ctx); ok {
+		r.Attrs = append(r.Attrs, slog.String(WorkerKey, id))
+		return r
+	}
+	// Handle 在记录日志的 goroutine 中同步执行，此处即为调用方的 goroutine
+	if id := goroutineID(); id != 0 {
+		r.Attrs = append(r.Attrs, slog.Uint64(GoroutineKey, id))
+	}
+	return r
+}
+
+// goroutineID 从 "goroutine 123 [running]:" 中解析当前 goroutine id，失败返回 0
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b, ok := bytes.CutPrefix(b, []byte("goroutine "))
+	if !ok {
+		return 0
+	}
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,67 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoroutineID(t *testing.T) {
+	ids := make(chan uint64, 2)
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids <- goroutineID()
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	a, b := <-ids, <-ids
+	assert.NotZero(t, a)
+	assert.NotZero(t, b)
+	assert.NotEqual(t, a, b)
+}
+
+func TestWithGoroutineID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(
+		WithGoroutineID(),
+		WithFormatter(formatter.JSON()),
+		WithWriter(&testWriter{buf: &buf}),
+	)
+
+	logger.Info("plain")
+	logger.InfoContext(WithWorkerID(context.Background(), "fetcher-1"), "worker")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &m))
+	assert.InDelta(t, float64(goroutineID()), m[GoroutineKey], 0)
+	assert.NotContains(t, m, WorkerKey)
+
+	m = nil
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &m))
+	assert.Equal(t, "fetcher-1", m[WorkerKey])
+	assert.NotContains(t, m, GoroutineKey)
+}
+
+func TestWorkerIDFromContext(t *testing.T) {
+	_, ok := WorkerIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	id, ok := WorkerIDFromContext(WithWorkerID(context.Background(), "w"))
+	assert.True(t, ok)
+	assert.Equal(t, "w", id)
+}
@@ -0,0 +1,347 @@
+// Package grok 使用 grok 风格的模式解析旧应用输出的非结构化日志行。
+//
+// 模式是带 %{NAME:field:type} 占位符的正则表达式（RE2 语法）：
+//
+//	p, err := grok.New([]string{
+//	    `^%{TIMESTAMP_ISO8601:time} \[%{LOGLEVEL:level}\] %{GREEDYDATA:msg}$`,
+//	    `%{COMMONAPACHELOG}`,
+//	})
+//	e, err := p.Parse([]byte("2024-05-01 12:00:00,123 [WARN] disk almost full"))
+//	rec := e.Record() // *formatter.Record，可直接交给格式化器
+//
+// 占位符形式：
+//   - %{NAME}: 引用模式，不捕获
+//   - %{NAME:field}: 捕获为 field 字段，值为字符串
+//   - %{NAME:field:int}: 按类型转换，支持 int、float、bool、string，转换失败时保留字符串
+//
+// 也可以直接使用命名分组 (?P<field>...)。字段名 time、level、msg（或 message）、
+// source 写入 formatter.Entry 的对应字段，其余按出现顺序作为属性；
+// time 按 formatter.ParseTime、常见日志时间格式和 WithTimeLayout 解析，
+// level 额外识别 trace、notice、err、fatal 等旧应用常用的级别名。
+//
+// 内置模式见 Patterns，包括 COMMONAPACHELOG、COMBINEDAPACHELOG、SYSLOGLINE
+// 和 GOLOG（标准库 log 的默认输出）。
+package grok
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// maxDepth 模式展开的最大嵌套深度，超过时视为循环引用
+const maxDepth = 32
+
+// placeholder 匹配 %{NAME}、%{NAME:field} 和 %{NAME:field:type}
+var placeholder = regexp.MustCompile(`%\{(\w+)(?::([\w.@-]+))?(?::(\w+))?\}`)
+
+// Option 配置选项函数
+type Option func(*options)
+
+// options 内部配置
+type options struct {
+	patterns map[string]string
+	layouts  []string
+	err      error
+}
+
+// WithPattern 定义或覆盖命名模式，可在其他模式中以 %{NAME} 引用。
+func WithPattern(name, expr string) Option {
+	return func(o *options) {
+		o.patterns[name] = expr
+	}
+}
+
+// WithPatternFile 从文件加载 Logstash 格式的模式定义：每行 "NAME 表达式"，
+// # 开头的行和空行忽略。
+func WithPatternFile(path string) Option {
+	return func(o *options) {
+		f, err := os.Open(path) //nolint:gosec // G304: 路径来自调用方配置
+		if err != nil {
+			o.err = err
+			return
+		}
+		defer func() { _ = f.Close() }()
+		if err := readPatterns(f, o.patterns); err != nil {
+			o.err = fmt.Errorf("grok: %s: %w", path, err)
+		}
+	}
+}
+
+// WithTimeLayout 添加 time 字段的时间布局（time.Parse 格式），优先于内置格式尝试。
+func WithTimeLayout(layout string) Option {
+	return func(o *options) {
+		o.layouts = append(o.layouts, layout)
+	}
+}
+
+// field 一个捕获分组对应的字段
+type field struct {
+	name string
+	typ  string
+}
+
+// expr 编译后的一个模式
+type expr struct {
+	re     *regexp.Regexp
+	fields []field // 按分组下标，未捕获的分组为空
+}
+
+// Parser 按模式解析日志行，并发安全
+type Parser struct {
+	exprs   []expr
+	layouts []string
+}
+
+// New 编译模式，多个模式按顺序尝试，使用第一个匹配的模式。
+func New(patterns []string, opts ...Option) (*Parser, error) {
+	o := &options{patterns: maps.Clone(builtins)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.err != nil {
+		return nil, o.err
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("grok: no patterns")
+	}
+
+	p := &Parser{layouts: o.layouts}
+	for _, pattern := range patterns {
+		e, err := compile(pattern, o.patterns)
+		if err != nil {
+			return nil, err
+		}
+		p.exprs = append(p.exprs, e)
+	}
+	return p, nil
+}
+
+// Patterns 返回内置模式的名称。
+func Patterns() []string {
+	return slices.Sorted(maps.Keys(builtins))
+}
+
+// compile 展开占位符并编译模式
+func compile(pattern string, defs map[string]string) (expr, error) {
+	var fields []field
+	var expand func(s string, depth int) (string, error)
+	expand = func(s string, depth int) (string, error) {
+		if depth > maxDepth {
+			return "", fmt.Errorf("grok: pattern nesting exceeds %d levels, check for recursive definitions", maxDepth)
+		}
+		var err error
+		out := placeholder.ReplaceAllStringFunc(s, func(m string) string {
+			if err != nil {
+				return ""
+			}
+			sub := placeholder.FindStringSubmatch(m)
+			def, ok := defs[sub[1]]
+			if !ok {
+				err = fmt.Errorf("grok: unknown pattern %%{%s}", sub[1])
+				return ""
+			}
+			body, e := expand(def, depth+1)
+			if e != nil {
+				err = e
+				return ""
+			}
+			if sub[2] == "" {
+				return "(?:" + body + ")"
+			}
+			switch sub[3] {
+			case "", "string", "int", "float", "bool":
+			default:
+				err = fmt.Errorf("grok: %s: unknown type %q (int, float, bool, string)", m, sub[3])
+				return ""
+			}
+			fields = append(fields, field{name: sub[2], typ: sub[3]})
+			return fmt.Sprintf("(?P<grok%d>%s)", len(fields)-1, body)
+		})
+		return out, err
+	}
+
+	body, err := expand(pattern, 0)
+	if err != nil {
+		return expr{}, err
+	}
+	re, err := regexp.Compile(body)
+	if err != nil {
+		return expr{}, fmt.Errorf("grok: %q: %w", pattern, err)
+	}
+
+	e := expr{re: re, fields: make([]field, re.NumSubexp()+1)}
+	for i, name := range re.SubexpNames() {
+		if n, ok := strings.CutPrefix(name, "grok"); ok {
+			if j, err := strconv.Atoi(n); err == nil && j < len(fields) {
+				e.fields[i] = fields[j]
+				continue
+			}
+		}
+		if name != "" {
+			e.fields[i] = field{name: name}
+		}
+	}
+	return e, nil
+}
+
+// readPatterns 读取 "NAME 表达式" 格式的模式定义
+func readPatterns(r io.Reader, defs map[string]string) error {
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		name, body, ok := strings.Cut(line, " ")
+		if !ok {
+			return fmt.Errorf("line %d: want \"NAME expression\"", n)
+		}
+		defs[name] = strings.TrimSpace(body)
+	}
+	return sc.Err()
+}
+
+// Parse 解析一行日志，没有模式匹配时返回 formatter.ErrUnrecognized。
+func (p *Parser) Parse(line []byte) (*formatter.Entry, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	for _, e := range p.exprs {
+		m := e.re.FindSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		entry := &formatter.Entry{Level: slog.LevelInfo}
+		seen := make(map[string]bool)
+		for i, f := range e.fields {
+			if f.name == "" || m[2*i] < 0 || seen[f.name] {
+				continue
+			}
+			seen[f.name] = true
+			p.setField(entry, f, string(line[m[2*i]:m[2*i+1]]))
+		}
+		return entry, nil
+	}
+	return nil, formatter.ErrUnrecognized
+}
+
+// setField 写入一个捕获的字段
+func (p *Parser) setField(e *formatter.Entry, f field, s string) {
+	switch f.name {
+	case "time":
+		if t, ok := p.parseTime(s); ok {
+			e.Time = t
+			return
+		}
+	case "level":
+		if level, ok := ParseLevel(s); ok {
+			e.Level = level
+			return
+		}
+	case "msg", "message":
+		e.Message = s
+		return
+	case "source":
+		e.Source = s
+		return
+	default:
+	}
+	e.Attrs = append(e.Attrs, slog.Attr{Key: f.name, Value: convert(s, f.typ)})
+}
+
+// convert 按类型转换捕获的文本，失败时保留字符串
+func convert(s, typ string) slog.Value {
+	switch typ {
+	case "int":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return slog.Int64Value(n)
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return slog.Float64Value(f)
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return slog.BoolValue(b)
+		}
+	default:
+	}
+	return slog.StringValue(s)
+}
+
+// timeLayouts formatter.ParseTime 之外尝试的常见时间格式
+var timeLayouts = []string{
+	"02/Jan/2006:15:04:05 -0700",    // HTTPDATE
+	"2006/01/02 15:04:05.999999999", // 标准库 log
+	"2006-01-02T15:04:05.999999999", // 无时区的 ISO 8601
+	"2006-01-02 15:04:05.999999999 -0700",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.UnixDate,
+	time.ANSIC,
+}
+
+// parseTime 解析 time 字段，无时区的时间按本地时区
+func (p *Parser) parseTime(s string) (time.Time, bool) {
+	for _, layout := range p.layouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	// Python logging 等使用逗号分隔毫秒
+	if t, ok := formatter.ParseTime(strings.Replace(s, ",", ".", 1)); ok {
+		return t, true
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	// syslog 时间没有年份，取不晚于当前时间一天的最近年份
+	if t, err := time.ParseInLocation(time.Stamp, s, time.Local); err == nil {
+		now := time.Now()
+		t = t.AddDate(now.Year(), 0, 0)
+		if t.After(now.Add(24 * time.Hour)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// ParseLevel 解析级别名称，在 formatter.ParseLevelName 的基础上识别旧应用常用的级别：
+//
+//	trace             DEBUG-4
+//	notice            INFO+2
+//	information       INFO
+//	err, severe       ERROR
+//	crit, critical, alert, fatal, emerg, emergency  ERROR+4
+func ParseLevel(s string) (slog.Level, bool) {
+	if level, ok := formatter.ParseLevelName(s); ok {
+		return level, true
+	}
+	switch strings.ToLower(s) {
+	case "trace":
+		return slog.LevelDebug - 4, true
+	case "notice":
+		return slog.LevelInfo + 2, true
+	case "information":
+		return slog.LevelInfo, true
+	case "err", "severe":
+		return slog.LevelError, true
+	case "crit", "critical", "alert", "fatal", "emerg", "emergency":
+		return slog.LevelError + 4, true
+	default:
+		return slog.LevelInfo, false
+	}
+}
@@ -0,0 +1,142 @@
+package grok
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Fields(t *testing.T) {
+	p, err := New([]string{`^%{TIMESTAMP_ISO8601:time} \[%{LOGLEVEL:level}\] %{NOTSPACE:logger} %{GREEDYDATA:msg} took=%{NUMBER:took:float}ms retries=%{INT:retries:int}$`})
+	require.NoError(t, err)
+
+	e, err := p.Parse([]byte("2024-05-01 12:00:00,123 [WARNING] db.pool slow query took=12.5ms retries=2\n"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 123e6, time.Local), e.Time)
+	assert.Equal(t, slog.LevelWarn, e.Level)
+	assert.Equal(t, "slow query", e.Message)
+	assert.Equal(t, []slog.Attr{
+		slog.String("logger", "db.pool"),
+		slog.Float64("took", 12.5),
+		slog.Int64("retries", 2),
+	}, e.Attrs)
+
+	_, err = p.Parse([]byte("plain text"))
+	assert.ErrorIs(t, err, formatter.ErrUnrecognized)
+}
+
+func TestParser_Builtins(t *testing.T) {
+	p, err := New([]string{`%{COMBINEDAPACHELOG}`, `%{SYSLOGLINE}`, `^%{GOLOG}`})
+	require.NoError(t, err)
+
+	e, err := p.Parse([]byte(`10.0.0.7 - frank [10/Oct/2023:13:55:36 -0700] "GET /index.html HTTP/1.1" 404 2326 "-" "curl/8.5.0"`))
+	require.NoError(t, err)
+	assert.True(t, e.Time.Equal(time.Date(2023, 10, 10, 20, 55, 36, 0, time.UTC)))
+	attrs := map[string]slog.Value{}
+	for _, a := range e.Attrs {
+		attrs[a.Key] = a.Value
+	}
+	assert.Equal(t, "10.0.0.7", attrs["client_ip"].String())
+	assert.Equal(t, "GET", attrs["method"].String())
+	assert.Equal(t, "/index.html", attrs["path"].String())
+	assert.Equal(t, int64(404), attrs["status"].Int64())
+	assert.Equal(t, int64(2326), attrs["bytes"].Int64())
+	assert.Equal(t, `"curl/8.5.0"`, attrs["user_agent"].String())
+
+	e, err = p.Parse([]byte("Mar  3 04:05:06 web-1 sshd[812]: Accepted publickey for deploy"))
+	require.NoError(t, err)
+	assert.Equal(t, "Accepted publickey for deploy", e.Message)
+	assert.Equal(t, time.March, e.Time.Month())
+	assert.Contains(t, e.Attrs, slog.Int64("pid", 812))
+
+	e, err = p.Parse([]byte("2024/05/01 08:09:10 listening on :8080"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 8, 9, 10, 0, time.Local), e.Time)
+	assert.Equal(t, "listening on :8080", e.Message)
+}
+
+func TestParser_NamedGroupsAndOptions(t *testing.T) {
+	dir := t.TempDir()
+	defs := filepath.Join(dir, "patterns")
+	require.NoError(t, os.WriteFile(defs, []byte("# legacy app\nJOBID job-[0-9a-f]{6}\n"), 0o600))
+
+	p, err := New([]string{`^(?P<time>\d{8}T\d{6}) (?P<level>\w+) %{JOBID:job} %{TENANT:tenant} (?P<msg>.*)$`},
+		WithPatternFile(defs),
+		WithPattern("TENANT", `t-\d+`),
+		WithTimeLayout("20060102T150405"),
+	)
+	require.NoError(t, err)
+	e, err := p.Parse([]byte("20240501T120000 fatal job-00ff1a t-42 out of memory"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local), e.Time)
+	assert.Equal(t, slog.LevelError+4, e.Level)
+	assert.Equal(t, "out of memory", e.Message)
+	assert.Equal(t, []slog.Attr{slog.String("job", "job-00ff1a"), slog.String("tenant", "t-42")}, e.Attrs)
+
+	r := e.Record()
+	assert.Equal(t, "out of memory", r.Message)
+}
+
+func TestParser_UnparsedBuiltinFieldsKept(t *testing.T) {
+	p, err := New([]string{`^%{NOTSPACE:time} %{WORD:level} %{GREEDYDATA:msg}`})
+	require.NoError(t, err)
+	e, err := p.Parse([]byte("yesterday LOUD hello"))
+	require.NoError(t, err)
+	assert.True(t, e.Time.IsZero())
+	assert.Equal(t, slog.LevelInfo, e.Level)
+	assert.Equal(t, []slog.Attr{slog.String("time", "yesterday"), slog.String("level", "LOUD")}, e.Attrs)
+}
+
+func TestNew_Errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		patterns []string
+		opts     []Option
+		want     string
+	}{
+		"none":      {nil, nil, "no patterns"},
+		"unknown":   {[]string{`%{NOPE:x}`}, nil, "unknown pattern %{NOPE}"},
+		"type":      {[]string{`%{INT:x:long}`}, nil, `unknown type "long"`},
+		"recursive": {[]string{`%{A}`}, []Option{WithPattern("A", "%{B}"), WithPattern("B", "%{A}")}, "nesting exceeds"},
+		"regexp":    {[]string{`(`}, nil, "missing closing )"},
+		"file":      {[]string{`%{INT}`}, []Option{WithPatternFile("/nonexistent/patterns")}, "no such file"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(tc.patterns, tc.opts...)
+			assert.ErrorContains(t, err, tc.want)
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]slog.Level{
+		"TRACE":     slog.LevelDebug - 4,
+		"debug":     slog.LevelDebug,
+		"Notice":    slog.LevelInfo + 2,
+		"INFO+2":    slog.LevelInfo + 2,
+		"warning":   slog.LevelWarn,
+		"ERR":       slog.LevelError,
+		"CRITICAL":  slog.LevelError + 4,
+		"emergency": slog.LevelError + 4,
+	} {
+		got, ok := ParseLevel(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, want, got, s)
+	}
+	_, ok := ParseLevel("verbose")
+	assert.False(t, ok)
+}
+
+func TestPatterns(t *testing.T) {
+	names := Patterns()
+	assert.Contains(t, names, "COMMONAPACHELOG")
+	for _, name := range names {
+		_, err := New([]string{"%{" + name + "}"})
+		assert.NoError(t, err, name)
+	}
+}
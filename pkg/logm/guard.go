@@ -0,0 +1,152 @@
+package logm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// OversizePolicy 超长日志处理策略
+type OversizePolicy int
+
+const (
+	// OversizeTruncate 截断过长的字符串值，并添加 truncated_bytes 标记属性
+	OversizeTruncate OversizePolicy = iota
+	// OversizeDrop 直接丢弃超长日志并计数
+	OversizeDrop
+)
+
+// String 返回策略名称：truncate 或 drop。
+func (p OversizePolicy) String() string {
+	switch p {
+	case OversizeTruncate:
+		return "truncate"
+	case OversizeDrop:
+		return "drop"
+	default:
+		return fmt.Sprintf("OversizePolicy(%d)", int(p))
+	}
+}
+
+// TruncatedKey 截断标记属性的键名，值为截断前的编码字节数。
+const TruncatedKey = "truncated_bytes"
+
+// TruncatedAttrsKey 属性数超出 WithMaxAttrs 限制时标记属性的键名，值为丢弃的属性数。
+const TruncatedAttrsKey = "truncated_attrs"
+
+// minTruncateLen 单个值截断后保留的最小长度，低于此值仍超限则丢弃
+const minTruncateLen = 64
+
+// truncateSuffix 截断值的结尾标记
+const truncateSuffix = "...(truncated)"
+
+// fitRecord 将超长日志调整到 maxRecordSize 以内。
+//
+// 截断策略下逐步减半单个字符串值的长度上限并重新格式化，
+// 直到输出满足限制；仍无法满足时返回 nil 表示丢弃。
+func (h *Handler) fitRecord(rec *Record, size int, f Formatter) []byte {
+	if h.oversizePolicy == OversizeDrop {
+		return nil
+	}
+
+	for limit := h.maxRecordSize / 2; limit >= minTruncateLen; limit /= 2 {
+		shrunk := *rec
+		shrunk.Message = truncateString(rec.Message, limit)
+		shrunk.Attrs = make([]slog.Attr, 0, len(rec.Attrs)+1)
+		for _, a := range rec.Attrs {
+			shrunk.Attrs = append(shrunk.Attrs, truncateAttr(a, limit))
+		}
+		shrunk.Attrs = append(shrunk.Attrs, slog.Int(TruncatedKey, size))
+
+		data, err := f.Format(&shrunk)
+		if err != nil {
+			return nil
+		}
+		if len(data) <= h.maxRecordSize {
+			return data
+		}
+	}
+	return nil
+}
+
+// truncateAttr 截断属性中过长的值（递归处理分组）
+func truncateAttr(a slog.Attr, limit int) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, truncateString(v.String(), limit))
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = truncateAttr(ga, limit)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	case slog.KindAny:
+		// 复杂类型无法按字段截断，超长时序列化为字符串后截断
+		var s string
+		if err, ok := v.Any().(error); ok {
+			s = err.Error()
+		} else if data, err := json.Marshal(v.Any()); err == nil {
+			s = string(data)
+		} else {
+			return a
+		}
+		if len(s) > limit {
+			return slog.String(a.Key, truncateString(s, limit))
+		}
+		return a
+	default:
+		return a
+	}
+}
+
+// truncateString 截断字符串到 limit 字节以内，保证不破坏 UTF-8 字符
+func truncateString(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	n := limit - len(truncateSuffix)
+	if n < 0 {
+		n = 0
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + truncateSuffix
+}
+
+// limitAttrs 按平铺后的属性数（分组展开计算叶子属性）限制日志属性。
+//
+// 保留前 limit 个叶子属性，其余丢弃并追加 truncated_attrs 标记；未超限时原样返回。
+func limitAttrs(attrs []slog.Attr, limit int) []slog.Attr {
+	budget := limit
+	kept, dropped := keepAttrs(attrs, &budget)
+	if dropped == 0 {
+		return attrs
+	}
+	return append(kept, slog.Int(TruncatedAttrsKey, dropped))
+}
+
+// keepAttrs 在 budget 内保留叶子属性，返回保留的属性和丢弃的叶子数，丢空的分组不输出
+func keepAttrs(attrs []slog.Attr, budget *int) (kept []slog.Attr, dropped int) {
+	kept = make([]slog.Attr, 0, min(len(attrs), max(*budget, 0)))
+	for _, a := range attrs {
+		if v := a.Value.Resolve(); v.Kind() == slog.KindGroup {
+			sub, n := keepAttrs(v.Group(), budget)
+			dropped += n
+			if len(sub) > 0 {
+				kept = append(kept, slog.Attr{Key: a.Key, Value: slog.GroupValue(sub...)})
+			}
+			continue
+		}
+		if *budget <= 0 {
+			dropped++
+			continue
+		}
+		*budget--
+		kept = append(kept, a)
+	}
+	return kept, dropped
+}
@@ -0,0 +1,111 @@
+package logm
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_MaxRecordSize_Truncate(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&HandlerConfig{
+		Formatter:      formatter.JSON(),
+		Writers:        []Writer{&testWriter{buf: &buf}},
+		MaxRecordSize:  1024,
+		OversizePolicy: OversizeTruncate,
+	})
+
+	slog.New(h).Info("big", "payload", strings.Repeat("x", 10*1024), "small", "ok")
+
+	output := buf.String()
+	assert.LessOrEqual(t, len(output), 1024)
+	assert.Contains(t, output, `"truncated_bytes":`)
+	assert.Contains(t, output, `"small":"ok"`)
+	assert.Contains(t, output, "...(truncated)")
+	assert.Equal(t, uint64(0), h.OversizeDropped())
+}
+
+func TestHandler_MaxRecordSize_Drop(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&HandlerConfig{
+		Formatter:      formatter.Text(),
+		Writers:        []Writer{&testWriter{buf: &buf}},
+		MaxRecordSize:  256,
+		OversizePolicy: OversizeDrop,
+	})
+
+	logger := slog.New(h).With("service", "api")
+	logger.Info("big", "payload", strings.Repeat("x", 1024))
+	logger.Info("small")
+
+	output := buf.String()
+	assert.NotContains(t, output, "msg=big")
+	assert.Contains(t, output, "msg=small")
+	assert.Equal(t, uint64(1), h.OversizeDropped())
+}
+
+func TestHandler_MaxRecordSize_TooManyAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&HandlerConfig{
+		Formatter:     formatter.Text(),
+		Writers:       []Writer{&testWriter{buf: &buf}},
+		MaxRecordSize: 128,
+	})
+
+	// 大量短属性无法通过截断值满足限制，应被丢弃
+	args := make([]any, 0, 200)
+	for i := range 100 {
+		args = append(args, "k", i)
+	}
+	slog.New(h).Info("many", args...)
+
+	assert.Empty(t, buf.String())
+	assert.Equal(t, uint64(1), h.OversizeDropped())
+}
+
+func TestHandler_MaxAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&HandlerConfig{
+		Formatter: formatter.Text(),
+		Writers:   []Writer{&testWriter{buf: &buf}},
+		MaxAttrs:  3,
+	})
+
+	slog.New(h).With("service", "api").Info("many",
+		slog.Group("req", "a", 1, "b", 2, "c", 3),
+		"d", 4,
+	)
+	assert.Contains(t, buf.String(), "service=api req.a=1 req.b=2 truncated_attrs=2")
+	assert.NotContains(t, buf.String(), "req.c")
+	assert.NotContains(t, buf.String(), "d=4")
+
+	// 未超限时原样输出
+	buf.Reset()
+	slog.New(h).Info("few", "a", 1, "b", 2, "c", 3)
+	assert.Contains(t, buf.String(), "a=1 b=2 c=3\n")
+}
+
+func TestLimitAttrs_DropsEmptyGroups(t *testing.T) {
+	attrs := []slog.Attr{slog.Int("a", 1), slog.Group("g", "x", 1, "y", 2)}
+
+	got := limitAttrs(attrs, 1)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "a", got[0].Key)
+	assert.Equal(t, TruncatedAttrsKey, got[1].Key)
+	assert.Equal(t, int64(2), got[1].Value.Int64())
+
+	assert.Equal(t, attrs, limitAttrs(attrs, 3))
+}
+
+func TestTruncateString(t *testing.T) {
+	assert.Equal(t, "short", truncateString("short", 64))
+
+	s := truncateString(strings.Repeat("中", 100), 64)
+	assert.LessOrEqual(t, len(s), 64)
+	assert.True(t, strings.HasSuffix(s, truncateSuffix))
+	assert.True(t, strings.HasPrefix(s, "中"))
+}
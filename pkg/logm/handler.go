@@ -2,10 +2,16 @@ package logm
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
 )
 
 // Handler 统一的 slog.Handler 实现。
@@ -14,18 +20,57 @@ import (
 // 支持多目标输出和拦截器链。
 type Handler struct {
 	levelVar     *slog.LevelVar
-	formatter    Formatter
+	formatter    *formatterRef // With/WithGroup 派生的 Handler 共享，见 SetFormatter
 	writers      []Writer
 	interceptors []Interceptor
 	addSource    bool
 	timeFormat   string
 	location     *time.Location
 
-	// 继承的分组和属性
+	maxRecordSize  int
+	oversizePolicy OversizePolicy
+	maxAttrs       int
+
+	onWriteError WriteErrorFunc
+	clock        func() time.Time
+
+	// 源代码位置向上跳过的层数和包
+	callerSkip     int
+	callerSkipPkgs []string
+
+	duplicateKeys DuplicateKeyPolicy
+	sanitize      bool
+	msgTemplate   bool
+	flags         *flagState
+
+	// 计数器，所有派生 Handler 共享
+	counters *handlerCounters
+
+	// 写入状态，所有派生 Handler 共享
+	state *handlerState
+
+	// ownFrom 之后的 Writer 由本 Handler 关闭，之前的属于 Derive 的基础 Handler
+	ownFrom int
+
+	// 观察者，所有派生 Handler 共享
+	observers *observerSet
+
+	// 运行时挂载的 Writer，所有派生 Handler 共享，见 AttachWriter
+	attached *attachedSet
+
+	// 继承的分组和属性，派生 Handler 之间共享且不可修改
 	groups []string
-	attrs  []slog.Attr
+	attrs  *attrChain
+}
 
-	mu sync.Mutex
+// handlerState 派生 Handler 共享的写入状态。
+//
+// mu 串行化对 writers 的 Write、Sync 和 Close 调用，
+// 关闭后到达的日志直接丢弃，不会写入已关闭的 Writer。
+// Derive 创建的 Handler 与基础 Handler 共享 mu，但各自维护 closed。
+type handlerState struct {
+	mu     *sync.Mutex
+	closed bool
 }
 
 // HandlerConfig Handler 配置
@@ -37,6 +82,65 @@ type HandlerConfig struct {
 	AddSource    bool
 	TimeFormat   string
 	Location     *time.Location
+
+	// MaxRecordSize 单条日志编码后的最大字节数，<= 0 表示不限制
+	MaxRecordSize int
+	// OversizePolicy 超长日志处理策略
+	OversizePolicy OversizePolicy
+	// OnWriteError Writer 写入失败时的回调
+	OnWriteError WriteErrorFunc
+	// Clock 时间来源，非 nil 时替代 slog.Record 的时间
+	Clock func() time.Time
+	// DefaultAttrs 附加到每条日志的属性，位于 WithAttrs 添加的属性之前
+	DefaultAttrs []slog.Attr
+	// CallerSkip 记录源代码位置时在日志调用处之上额外跳过的栈帧数
+	CallerSkip int
+	// CallerSkipPackages 记录源代码位置时跳过函数名包含这些字符串的栈帧，规则同 CallerPC
+	CallerSkipPackages []string
+	// DuplicateKeys 重复键处理策略，在拦截器之后、格式化之前应用
+	DuplicateKeys DuplicateKeyPolicy
+	// Sanitize 清理消息和字符串值中的无效 UTF-8 和控制字符
+	Sanitize bool
+	// MessageTemplate 将消息中的 {key} 占位符替换为同名属性的值
+	MessageTemplate bool
+	// MaxAttrs 平铺后的最大属性数，超出部分折叠为 truncated_attrs 标记，<= 0 表示不限制
+	MaxAttrs int
+	// FeatureFlags 由特性开关控制的级别和额外输出，Sinks 追加到 Writers 之后，见 WithFeatureFlags
+	FeatureFlags *FlagConfig
+}
+
+// handlerCounters Handler 内部计数器
+type handlerCounters struct {
+	levels       [4]atomic.Uint64 // 按级别统计已输出的日志数（DEBUG/INFO/WARN/ERROR）
+	bytes        atomic.Uint64    // 已成功写入的字节数
+	formatErrors atomic.Uint64    // 格式化失败的日志数
+	oversized    atomic.Uint64    // 因超长被丢弃的日志数
+	closedDrops  atomic.Uint64    // Handler 关闭后到达而被丢弃的日志数
+	writers      []writerCounters // 与 writers 一一对应的写入状态
+
+	lastErr atomic.Pointer[writeError] // 最近一次写入错误
+}
+
+// writerCounters 单个 Writer 的写入状态
+type writerCounters struct {
+	errors    atomic.Uint64              // 写入错误数
+	lastWrite atomic.Int64               // 最近一次成功写入时间（UnixNano）
+	failSince atomic.Int64               // 连续失败开始时间（UnixNano），0 表示正常
+	lastErr   atomic.Pointer[writeError] // 最近一次写入错误
+}
+
+// writeFailure 待回调的写入失败
+type writeFailure struct {
+	w    Writer
+	err  error
+	data []byte
+}
+
+// writeError 记录一次写入错误
+type writeError struct {
+	writer string
+	err    error
+	at     time.Time
 }
 
 // NewHandler 创建新的 Handler。
@@ -45,15 +149,32 @@ func NewHandler(cfg *HandlerConfig) *Handler {
 		cfg = &HandlerConfig{}
 	}
 
+	flags, writers := newFlagState(cfg.FeatureFlags, nil, cfg.Writers)
 	h := &Handler{
 		levelVar:     cfg.LevelVar,
-		formatter:    cfg.Formatter,
-		writers:      cfg.Writers,
+		formatter:    newFormatterRef(cfg.Formatter),
+		writers:      hoistTransforms(writers),
 		interceptors: cfg.Interceptors,
 		addSource:    cfg.AddSource,
 		timeFormat:   cfg.TimeFormat,
 		location:     cfg.Location,
+
+		maxRecordSize:  cfg.MaxRecordSize,
+		oversizePolicy: cfg.OversizePolicy,
+		onWriteError:   cfg.OnWriteError,
+		clock:          cfg.Clock,
+		callerSkip:     cfg.CallerSkip,
+		callerSkipPkgs: cfg.CallerSkipPackages,
+		duplicateKeys:  cfg.DuplicateKeys,
+		maxAttrs:       cfg.MaxAttrs,
+		sanitize:       cfg.Sanitize,
+		msgTemplate:    cfg.MessageTemplate,
+		flags:          flags,
+		counters:       &handlerCounters{writers: make([]writerCounters, len(writers))},
+		state:          &handlerState{mu: &sync.Mutex{}},
+		observers:      &observerSet{},
 	}
+	h.attached = &attachedSet{owner: h.state}
 
 	if h.levelVar == nil {
 		h.levelVar = &slog.LevelVar{}
@@ -64,12 +185,16 @@ func NewHandler(cfg *HandlerConfig) *Handler {
 		h.location = time.Local
 	}
 
+	if len(cfg.DefaultAttrs) > 0 {
+		h.attrs = h.attrs.push(cfg.DefaultAttrs)
+	}
+
 	return h
 }
 
 // Enabled 实现 slog.Handler 接口。
 func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
-	return level >= h.levelVar.Level()
+	return level >= h.levelVar.Level() || (h.flags != nil && h.flags.enabled(level))
 }
 
 // Handle 实现 slog.Handler 接口。
@@ -77,6 +202,15 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 	// 转换为 Record
 	rec := h.toRecord(r)
 
+	// 特性开关
+	var flagged bool
+	if h.flags != nil {
+		var keep bool
+		if keep, flagged = h.flags.evaluate(ctx, rec, h.levelVar.Level()); !keep {
+			return nil
+		}
+	}
+
 	// 应用拦截器
 	for _, interceptor := range h.interceptors {
 		rec = interceptor(ctx, rec)
@@ -85,28 +219,131 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 		}
 	}
 
-	// 格式化
-	if h.formatter == nil {
+	// 去除重复键
+	rec.Attrs = dedupAttrs(rec.Attrs, h.duplicateKeys)
+	if h.msgTemplate {
+		rec.Message = expandMessage(rec.Message, rec.Attrs)
+	}
+	if h.maxAttrs > 0 {
+		rec.Attrs = limitAttrs(rec.Attrs, h.maxAttrs)
+	}
+	if h.sanitize {
+		sanitizeRecord(rec)
+	}
+
+	h.observers.notify(rec)
+
+	// 格式化，同一条日志只使用一个格式化器，不受并发的 SetFormatter 影响
+	f := h.formatter.load()
+	if f == nil {
 		return nil
 	}
 
-	data, err := h.formatter.Format(rec)
-	if err != nil {
-		return err
+	writers := h.writers
+	attached := h.attached.load()
+	if len(attached) > 0 {
+		writers = h.withAttached(attached)
 	}
 
-	// 写入所有目标
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	// 带转换的 Writer 各自复制记录并格式化，见 TransformWriter
+	var (
+		data     []byte
+		payloads [][]byte
+		err      error
+	)
+	if hasTransform(writers) {
+		payloads, err = h.payloads(rec, writers, f)
+		if !slices.ContainsFunc(payloads, func(p []byte) bool { return p != nil }) {
+			return err
+		}
+	} else {
+		data, err = h.encode(rec, f)
+		if data == nil {
+			return err
+		}
+	}
 
-	for _, w := range h.writers {
-		if _, err := w.Write(data); err != nil {
-			// 写入失败继续尝试其他 writer
+	// 写入所有目标
+	var failed []writeFailure
+	now := time.Now()
+	h.state.mu.Lock()
+	if h.state.closed {
+		h.state.mu.Unlock()
+		h.counters.closedDrops.Add(1)
+		return nil
+	}
+	h.counters.levels[levelIndex(rec.Level)].Add(1)
+	for i, w := range writers {
+		if !flagged && h.flags != nil && h.flags.gated(i) {
 			continue
 		}
+		p := data
+		if payloads != nil {
+			if p = payloads[i]; p == nil {
+				continue
+			}
+		}
+		var wc *writerCounters
+		name := ""
+		if i < len(h.writers) {
+			wc = &h.counters.writers[i]
+		} else {
+			a := attached[i-len(h.writers)]
+			if a.detached {
+				continue
+			}
+			wc, name = &a.counters, a.name
+		}
+		n, err := writeRecord(w, rec, p, f)
+		h.counters.bytes.Add(uint64(max(n, 0))) //nolint:gosec // G115: n 已保证非负
+		if err == nil {
+			wc.lastWrite.Store(now.UnixNano())
+			wc.failSince.Store(0)
+		} else {
+			// 写入失败继续尝试其他 writer
+			if name == "" {
+				name = writerName(i, w)
+			}
+			we := &writeError{writer: name, err: err, at: now}
+			wc.errors.Add(1)
+			wc.failSince.CompareAndSwap(0, now.UnixNano())
+			wc.lastErr.Store(we)
+			h.counters.lastErr.Store(we)
+			selflog.Printf("write", "write to %s failed: %v", we.writer, err)
+			if h.onWriteError != nil {
+				failed = append(failed, writeFailure{w: w, err: err, data: p})
+			}
+		}
+	}
+	h.state.mu.Unlock()
+
+	// 回调在释放锁后执行，允许回调中再次记录日志
+	for _, f := range failed {
+		h.onWriteError(f.w, f.err, f.data)
+	}
+
+	return err
+}
+
+// encode 格式化记录并应用超长保护，记录因超长被丢弃时返回 nil, nil
+func (h *Handler) encode(rec *Record, f Formatter) ([]byte, error) {
+	data, err := f.Format(rec)
+	if err != nil {
+		h.counters.formatErrors.Add(1)
+		selflog.Printf("format", "format record %q failed: %v", rec.Message, err)
+		return nil, err
 	}
 
-	return nil
+	// 超长保护
+	if size := len(data); h.maxRecordSize > 0 && size > h.maxRecordSize {
+		data = h.fitRecord(rec, size, f)
+		if data == nil {
+			h.counters.oversized.Add(1)
+			selflog.Printf("oversize", "dropped oversized record %q (%d bytes, limit %d)", rec.Message, size, h.maxRecordSize)
+			return nil, nil
+		}
+	}
+	return data, nil
 }
 
 // WithAttrs 实现 slog.Handler 接口。
@@ -116,7 +353,7 @@ func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	}
 
 	clone := h.clone()
-	clone.attrs = append(clone.attrs, attrs...)
+	clone.attrs = clone.attrs.push(attrs)
 	return clone
 }
 
@@ -127,11 +364,14 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 	}
 
 	clone := h.clone()
-	clone.groups = append(clone.groups, name)
+	clone.groups = append(slices.Clip(clone.groups), name)
 	return clone
 }
 
-// clone 创建 Handler 的浅拷贝
+// clone 创建 Handler 的浅拷贝。
+//
+// groups 和 attrs 为不可变前缀，直接共享而不复制，
+// 使链式派生 logger 的开销与新增属性数量成正比。
 func (h *Handler) clone() *Handler {
 	return &Handler{
 		levelVar:     h.levelVar,
@@ -141,22 +381,82 @@ func (h *Handler) clone() *Handler {
 		addSource:    h.addSource,
 		timeFormat:   h.timeFormat,
 		location:     h.location,
-		groups:       append([]string{}, h.groups...),
-		attrs:        append([]slog.Attr{}, h.attrs...),
+
+		maxRecordSize:  h.maxRecordSize,
+		oversizePolicy: h.oversizePolicy,
+		onWriteError:   h.onWriteError,
+		clock:          h.clock,
+		callerSkip:     h.callerSkip,
+		callerSkipPkgs: h.callerSkipPkgs,
+		duplicateKeys:  h.duplicateKeys,
+		maxAttrs:       h.maxAttrs,
+		sanitize:       h.sanitize,
+		msgTemplate:    h.msgTemplate,
+		flags:          h.flags,
+		counters:       h.counters,
+		state:          h.state,
+		ownFrom:        h.ownFrom,
+		observers:      h.observers,
+		attached:       h.attached,
+
+		groups: h.groups,
+		attrs:  h.attrs,
+	}
+}
+
+// attrChain 不可变的属性链。
+//
+// 每次 WithAttrs 只新增一个节点并指向父节点，派生 Handler 共享已有前缀，
+// 避免逐层复制属性切片。
+type attrChain struct {
+	parent *attrChain
+	attrs  []slog.Attr
+	n      int // 链上属性总数
+}
+
+// push 返回追加 attrs 后的新链，不修改原链
+func (c *attrChain) push(attrs []slog.Attr) *attrChain {
+	return &attrChain{
+		parent: c,
+		attrs:  slices.Clone(attrs),
+		n:      c.len() + len(attrs),
 	}
 }
 
+// len 返回链上属性总数
+func (c *attrChain) len() int {
+	if c == nil {
+		return 0
+	}
+	return c.n
+}
+
+// appendTo 按添加顺序将链上所有属性追加到 dst
+func (c *attrChain) appendTo(dst []slog.Attr) []slog.Attr {
+	if c == nil {
+		return dst
+	}
+	dst = c.parent.appendTo(dst)
+	return append(dst, c.attrs...)
+}
+
 // toRecord 将 slog.Record 转换为 Record
 func (h *Handler) toRecord(r slog.Record) *Record {
+	t := r.Time
+	if h.clock != nil {
+		t = h.clock()
+	}
+
 	rec := &Record{
-		Time:    r.Time.In(h.location),
+		Time:    t.In(h.location),
 		Level:   r.Level,
 		Message: r.Message,
 		Groups:  h.groups,
+		Attrs:   make([]slog.Attr, 0, h.attrs.len()+r.NumAttrs()),
 	}
 
 	// 添加继承的属性
-	rec.Attrs = append(rec.Attrs, h.attrs...)
+	rec.Attrs = h.attrs.appendTo(rec.Attrs)
 
 	// 添加当前记录的属性
 	r.Attrs(func(a slog.Attr) bool {
@@ -174,6 +474,11 @@ func (h *Handler) toRecord(r slog.Record) *Record {
 
 // source 从 PC 获取源代码位置
 func (h *Handler) source(pc uintptr) *slog.Source {
+	if h.callerSkip > 0 || len(h.callerSkipPkgs) > 0 {
+		if src, ok := callerSource(pc, h.callerSkip, h.callerSkipPkgs); ok {
+			return src
+		}
+	}
 	fs := runtime.CallersFrames([]uintptr{pc})
 	f, _ := fs.Next()
 	return &slog.Source{
@@ -183,21 +488,85 @@ func (h *Handler) source(pc uintptr) *slog.Source {
 	}
 }
 
-// Close 关闭所有 Writer
+// markClosed 标记 Handler 已关闭，返回 false 表示之前已关闭。
+//
+// 获取写锁保证返回时没有正在进行的写入，之后到达的日志都会被丢弃。
+func (h *Handler) markClosed() bool {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+	if h.state.closed {
+		return false
+	}
+	h.state.closed = true
+	return true
+}
+
+// Close 关闭所有 Writer。
+//
+// 重复调用或与派生 Handler 的 Close 同时调用时，Writer 只会被关闭一次。
+// Derive 创建的 Handler 只关闭自己添加的 Writer。
 func (h *Handler) Close() error {
+	if !h.markClosed() {
+		return nil
+	}
+
 	var firstErr error
-	for _, w := range h.writers {
+	for _, w := range h.writers[h.ownFrom:] {
 		if err := w.Close(); err != nil && firstErr == nil {
 			firstErr = err
 		}
 	}
+	if err := h.closeAttached(); err != nil && firstErr == nil {
+		firstErr = err
+	}
 	return firstErr
 }
 
+// Shutdown 在 ctx 结束前刷新并关闭所有 Writer。
+//
+// 各 Writer 并发关闭；AsyncWriter 会尽量写出缓冲数据，ctx 结束时放弃剩余部分。
+// 返回关闭后所有 Writer 累计丢弃的日志数，以及关闭过程中的错误（超时返回 ctx.Err()）。
+func (h *Handler) Shutdown(ctx context.Context) (dropped uint64, err error) {
+	if !h.markClosed() {
+		return h.Stats().Dropped, nil
+	}
+
+	owned := h.writers[h.ownFrom:]
+	if h.attached.owner == h.state {
+		for _, a := range h.attached.load() {
+			owned = append(slices.Clip(owned), a.w)
+		}
+	}
+	errs := make([]error, len(owned))
+	var wg sync.WaitGroup
+	for i, w := range owned {
+		wg.Go(func() {
+			if err := writer.SyncContext(ctx, w); err != nil {
+				errs[i] = err
+				if ctx.Err() != nil {
+					return
+				}
+			}
+			if err := writer.CloseContext(ctx, w); err != nil && errs[i] == nil {
+				errs[i] = err
+			}
+		})
+	}
+	wg.Wait()
+
+	return h.Stats().Dropped, errors.Join(errs...)
+}
+
 // Sync 刷新所有 Writer 缓冲区
 func (h *Handler) Sync() error {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+	if h.state.closed {
+		return nil
+	}
+
 	var firstErr error
-	for _, w := range h.writers {
+	for _, w := range h.withAttached(h.attached.load()) {
 		if err := w.Sync(); err != nil && firstErr == nil {
 			firstErr = err
 		}
@@ -205,6 +574,21 @@ func (h *Handler) Sync() error {
 	return firstErr
 }
 
+// Rotate 轮转所有支持轮转的 Writer，包括 Multi、Async 等包装内层的 FileWriter。
+func (h *Handler) Rotate() error {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+	if h.state.closed {
+		return nil
+	}
+
+	var errs []error
+	for _, w := range h.withAttached(h.attached.load()) {
+		errs = append(errs, writer.Rotate(w))
+	}
+	return errors.Join(errs...)
+}
+
 // SetLevel 动态设置日志级别
 func (h *Handler) SetLevel(level slog.Level) {
 	h.levelVar.Set(level)
@@ -214,3 +598,8 @@ func (h *Handler) SetLevel(level slog.Level) {
 func (h *Handler) Level() slog.Level {
 	return h.levelVar.Level()
 }
+
+// OversizeDropped 返回因超出 MaxRecordSize 被丢弃的日志数
+func (h *Handler) OversizeDropped() uint64 {
+	return h.counters.oversized.Load()
+}
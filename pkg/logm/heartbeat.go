@@ -0,0 +1,123 @@
+package logm
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// HeartbeatMessage 心跳日志的消息
+const HeartbeatMessage = "heartbeat"
+
+// DefaultHeartbeatInterval StartHeartbeat 的默认间隔
+const DefaultHeartbeatInterval = time.Minute
+
+// HeartbeatOption 心跳配置选项
+type HeartbeatOption func(*heartbeatConfig)
+
+// heartbeatConfig 心跳配置
+type heartbeatConfig struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// WithHeartbeatLogger 设置输出心跳的 logger，默认使用输出时的 slog.Default()。
+func WithHeartbeatLogger(l *slog.Logger) HeartbeatOption {
+	return func(c *heartbeatConfig) {
+		c.logger = l
+	}
+}
+
+// WithHeartbeatLevel 设置心跳日志级别，默认 INFO。
+func WithHeartbeatLevel(level slog.Level) HeartbeatOption {
+	return func(c *heartbeatConfig) {
+		c.level = level
+	}
+}
+
+// StartHeartbeat 在后台每隔 interval 记录一条运行时状态日志，没有接入指标系统的服务可借此获得基本的可观测性。
+//
+// 日志消息为 "heartbeat"，runtime 分组包含：
+//
+//	goroutines     goroutine 数量
+//	heap_alloc     堆上已分配对象的字节数
+//	heap_sys       从系统申请的堆内存字节数
+//	gc_count       距上次心跳的 GC 次数
+//	gc_pause       距上次心跳的 GC 暂停总时长
+//	gc_pause_max   距上次心跳的最长单次 GC 暂停
+//	rss            进程常驻内存字节数（仅 Linux）
+//
+// interval <= 0 时使用 DefaultHeartbeatInterval。返回的 stop 函数用于停止心跳：
+//
+//	defer logm.StartHeartbeat(30 * time.Second)()
+func StartHeartbeat(interval time.Duration, opts ...HeartbeatOption) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	cfg := heartbeatConfig{level: slog.LevelInfo}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var hb heartbeat
+		hb.sample() // 建立 GC 统计基线
+		for {
+			select {
+			case <-ticker.C:
+				logger := cfg.logger
+				if logger == nil {
+					logger = slog.Default()
+				}
+				logger.LogAttrs(context.Background(), cfg.level, HeartbeatMessage, hb.sample())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// heartbeat 记录上次采样的 GC 统计，用于计算增量
+type heartbeat struct {
+	numGC      uint32
+	pauseTotal uint64
+}
+
+// sample 采集运行时状态并更新基线
+func (hb *heartbeat) sample() slog.Attr {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	// PauseNs 是最近 256 次 GC 的环形缓冲，只统计本次间隔内新增的部分
+	var pauseMax uint64
+	for n := ms.NumGC; n > hb.numGC && ms.NumGC-n < uint32(len(ms.PauseNs)); n-- {
+		pauseMax = max(pauseMax, ms.PauseNs[(n+255)%256])
+	}
+
+	attrs := []slog.Attr{
+		slog.Int("goroutines", runtime.NumGoroutine()),
+		slog.Uint64("heap_alloc", ms.HeapAlloc),
+		slog.Uint64("heap_sys", ms.HeapSys),
+		slog.Uint64("gc_count", uint64(ms.NumGC-hb.numGC)),
+		slog.Duration("gc_pause", time.Duration(ms.PauseTotalNs-hb.pauseTotal)), //nolint:gosec // 暂停时长不会溢出 int64
+		slog.Duration("gc_pause_max", time.Duration(pauseMax)),                  //nolint:gosec // 同上
+	}
+	if rss, ok := processRSS(); ok {
+		attrs = append(attrs, slog.Uint64("rss", rss))
+	}
+
+	hb.numGC = ms.NumGC
+	hb.pauseTotal = ms.PauseTotalNs
+	return slog.Attr{Key: "runtime", Value: slog.GroupValue(attrs...)}
+}
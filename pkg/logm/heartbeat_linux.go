@@ -0,0 +1,27 @@
+//go:build linux
+
+package logm
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// processRSS 从 /proc/self/statm 读取进程常驻内存字节数
+func processRSS() (uint64, bool) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+	// 格式: size resident shared text lib data dt，单位为页
+	fields := bytes.Fields(data)
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseUint(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * uint64(os.Getpagesize()), true
+}
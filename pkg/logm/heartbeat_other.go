@@ -0,0 +1,8 @@
+//go:build !linux
+
+package logm
+
+// processRSS 非 Linux 平台不采集常驻内存
+func processRSS() (uint64, bool) {
+	return 0, false
+}
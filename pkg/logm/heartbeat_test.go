@@ -0,0 +1,67 @@
+package logm
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatSample(t *testing.T) {
+	var hb heartbeat
+	hb.sample()
+	runtime.GC()
+	runtime.GC()
+
+	logger, buf := newBufferLogger(formatter.JSON())
+	logger.Info(HeartbeatMessage, hb.sample())
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	rt, ok := m["runtime"].(map[string]any)
+	require.True(t, ok)
+	assert.Greater(t, rt["goroutines"], float64(0))
+	assert.Greater(t, rt["heap_alloc"], float64(0))
+	assert.GreaterOrEqual(t, rt["gc_count"], float64(2))
+	assert.Contains(t, rt, "gc_pause")
+	assert.Contains(t, rt, "gc_pause_max")
+	if runtime.GOOS == "linux" {
+		assert.Greater(t, rt["rss"], float64(0))
+	}
+
+	// 基线更新后增量归零
+	buf.Reset()
+	logger.Info(HeartbeatMessage, hb.sample())
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Less(t, m["runtime"].(map[string]any)["gc_count"], float64(2))
+}
+
+func TestStartHeartbeat(t *testing.T) {
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	logger := New(WithFormatter(formatter.JSON()), WithWriter(&lockedWriter{mu: &mu, buf: &buf}))
+	output := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return buf.String()
+	}
+
+	stop := StartHeartbeat(10*time.Millisecond, WithHeartbeatLogger(logger))
+	require.Eventually(t, func() bool {
+		return strings.Count(output(), `"msg":"heartbeat"`) >= 2
+	}, 2*time.Second, 5*time.Millisecond)
+	stop()
+	stop() // 重复调用安全
+
+	time.Sleep(30 * time.Millisecond)
+	n := strings.Count(output(), "\n")
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, n, strings.Count(output(), "\n"), "stop 后不再输出")
+}
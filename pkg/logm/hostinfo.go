@@ -0,0 +1,100 @@
+package logm
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// HostKey WithHostInfo 附加的主机信息分组键名
+const HostKey = "host"
+
+var (
+	// hostInfo 缓存的主机信息分组
+	hostInfo atomic.Pointer[slog.Attr]
+	// hostInfoOnce 首次使用时解析主机信息
+	hostInfoOnce sync.Once
+)
+
+// WithHostInfo 为每条日志附加主机信息分组，多台主机直接向同一收集端发送日志时用于区分来源：
+//
+//	host.name  主机名
+//	host.ip    主出口 IP，无法确定时不输出
+//	host.os    操作系统和架构，如 linux/amd64
+//
+// 主机信息在调用 WithHostInfo 时解析一次并缓存，主机名或 IP 变化后调用 RefreshHostInfo 更新。
+func WithHostInfo() Option {
+	HostInfo()
+	return WithInterceptor(hostInterceptor)
+}
+
+// HostInfo 返回缓存的主机信息分组属性，首次调用时解析。
+func HostInfo() slog.Attr {
+	hostInfoOnce.Do(func() {
+		if hostInfo.Load() == nil {
+			RefreshHostInfo()
+		}
+	})
+	return *hostInfo.Load()
+}
+
+// RefreshHostInfo 重新解析主机信息，之后的日志使用新值。
+func RefreshHostInfo() {
+	a := resolveHostInfo()
+	hostInfo.Store(&a)
+}
+
+// hostInterceptor 附加主机信息
+func hostInterceptor(_ context.Context, r *Record) *Record {
+	r.Attrs = append(r.Attrs, HostInfo())
+	return r
+}
+
+// resolveHostInfo 解析主机名、主 IP 和操作系统
+func resolveHostInfo() slog.Attr {
+	var attrs []slog.Attr
+	if name, err := os.Hostname(); err == nil {
+		attrs = append(attrs, slog.String("name", name))
+	}
+	if ip := primaryIP(); ip != "" {
+		attrs = append(attrs, slog.String("ip", ip))
+	}
+	attrs = append(attrs, slog.String("os", runtime.GOOS+"/"+runtime.GOARCH))
+	return slog.Attr{Key: HostKey, Value: slog.GroupValue(attrs...)}
+}
+
+// primaryIP 返回主出口 IP。
+//
+// 优先取默认路由的源地址（UDP Dial 不发送数据包），失败时取第一个非回环的网卡地址。
+func primaryIP() string {
+	if conn, err := net.Dial("udp", "192.0.2.1:9"); err == nil {
+		addr, ok := conn.LocalAddr().(*net.UDPAddr)
+		_ = conn.Close()
+		if ok && !addr.IP.IsUnspecified() && !addr.IP.IsLoopback() {
+			return addr.IP.String()
+		}
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	var v6 string
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+		if v6 == "" {
+			v6 = ipNet.IP.String()
+		}
+	}
+	return v6
+}
@@ -0,0 +1,48 @@
+package logm
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHostInfo(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(WithHostInfo(), WithFormatter(formatter.JSON()), WithWriter(&testWriter{buf: &buf}))
+
+	logger.Info("hello")
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	host, ok := m[HostKey].(map[string]any)
+	require.True(t, ok, buf.String())
+	name, _ := os.Hostname()
+	assert.Equal(t, name, host["name"])
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, host["os"])
+}
+
+func TestRefreshHostInfo(t *testing.T) {
+	old := HostInfo()
+	t.Cleanup(func() { hostInfo.Store(&old) })
+
+	fake := slog.Group(HostKey, slog.String("name", "fake"))
+	hostInfo.Store(&fake)
+
+	var buf bytes.Buffer
+	h := New(WithHostInfo(), WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &buf}))
+	h.Info("a")
+	assert.Contains(t, buf.String(), "host.name=fake")
+
+	RefreshHostInfo()
+	buf.Reset()
+	h.Info("b")
+	assert.NotContains(t, buf.String(), "host.name=fake")
+	assert.Contains(t, buf.String(), "host.os=")
+}
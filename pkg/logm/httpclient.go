@@ -0,0 +1,70 @@
+package logm
+
+import (
+	"net/http"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// HTTPOption 配置 AlertWebhook、HTTPSource 和 ConsulKV 发起请求使用的 HTTP 客户端
+type HTTPOption func(*httpConfig)
+
+// httpConfig HTTP 客户端配置
+type httpConfig struct {
+	client *http.Client
+	tls    *writer.TLSConfig
+}
+
+// WithHTTPClient 使用指定的 HTTP 客户端，默认 http.DefaultClient。
+func WithHTTPClient(c *http.Client) HTTPOption {
+	return func(cfg *httpConfig) {
+		if c != nil {
+			cfg.client = c
+		}
+	}
+}
+
+// WithTLS 使用 TLS 配置连接服务端，如私有 CA 签发的证书或双向 TLS：
+//
+//	logm.ConsulKV("https://consul:8501", "logm/app", token,
+//	    logm.WithTLS(&writer.TLSConfig{CAFile: "/etc/consul/ca.pem", CertFile: "client.pem", KeyFile: "client-key.pem"}))
+//
+// 证书在创建时加载，加载失败时每次请求都返回该错误。
+// 与 WithHTTPClient 同时使用时替换该客户端的 Transport。
+func WithTLS(c *writer.TLSConfig) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.tls = c
+	}
+}
+
+// httpClient 按选项返回 HTTP 客户端，TLS 配置无效时返回每次请求都失败的客户端
+func httpClient(opts []HTTPOption) *http.Client {
+	cfg := httpConfig{client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.tls == nil {
+		return cfg.client
+	}
+
+	client := *cfg.client
+	tlsCfg, err := cfg.tls.Build()
+	if err != nil {
+		client.Transport = errTransport{err: err}
+		return &client
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // 标准库的默认 Transport
+	tr.TLSClientConfig = tlsCfg
+	client.Transport = tr
+	return &client
+}
+
+// errTransport 总是返回 err 的 RoundTripper
+type errTransport struct {
+	err error
+}
+
+// RoundTrip 实现 http.RoundTripper。
+func (t errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
@@ -0,0 +1,43 @@
+package logm
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"level":"DEBUG"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+
+	// 默认客户端不信任测试服务端的证书
+	_, err := HTTPSource(srv.URL).Fetch(t.Context())
+	require.ErrorContains(t, err, "certificate")
+
+	cfg, err := HTTPSource(srv.URL, WithTLS(&writer.TLSConfig{CAFile: ca})).Fetch(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "DEBUG", cfg.Level)
+
+	// 自定义客户端同样使用 TLS 配置
+	cfg, err = HTTPSource(srv.URL, WithHTTPClient(&http.Client{}), WithTLS(&writer.TLSConfig{CAFile: ca})).Fetch(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "DEBUG", cfg.Level)
+}
+
+func TestWithTLS_InvalidConfig(t *testing.T) {
+	src := HTTPSource("https://127.0.0.1:1", WithTLS(&writer.TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}))
+	_, err := src.Fetch(t.Context())
+	require.ErrorContains(t, err, "writer: tls: read CA")
+}
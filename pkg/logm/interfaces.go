@@ -18,6 +18,18 @@ type Formatter = formatter.Formatter
 // Record 封装单条日志记录的所有信息，是 Formatter 的输入。
 type Record = formatter.Record
 
+// TimePrecision 是 formatter.TimePrecision 的别名，表示时间的秒以下精度。
+type TimePrecision = formatter.TimePrecision
+
+// 时间精度取值，见 formatter.WithTimePrecision。
+const (
+	PrecisionDefault = formatter.PrecisionDefault
+	PrecisionSeconds = formatter.PrecisionSeconds
+	PrecisionMillis  = formatter.PrecisionMillis
+	PrecisionMicros  = formatter.PrecisionMicros
+	PrecisionNanos   = formatter.PrecisionNanos
+)
+
 // Writer 定义日志输出目标。
 //
 // 扩展 io.Writer 和 io.Closer，增加 Sync 方法用于刷新缓冲区。
@@ -39,3 +51,9 @@ type Writer interface {
 //
 // 返回 nil 表示丢弃该条日志。
 type Interceptor func(ctx context.Context, r *Record) *Record
+
+// WriteErrorFunc Writer 写入失败时的回调。
+//
+// w 为失败的 Writer，record 为格式化后的日志内容（回调返回后不可再引用）。
+// 可用于统计失败、切换备用输出，或在日志链路损坏时直接退出程序。
+type WriteErrorFunc func(w Writer, err error, record []byte)
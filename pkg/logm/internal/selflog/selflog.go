@@ -0,0 +1,109 @@
+// Package selflog 提供 logm 内部故障的自诊断输出。
+//
+// logm 自身的问题（格式化失败、日志丢弃、写入错误等）无法通过日志管道本身上报，
+// 因此统一输出到一个极简的独立 sink（默认 stderr），并按类别限流，
+// 避免故障期间刷屏。
+package selflog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultInterval 同一类别消息的默认最小输出间隔
+const DefaultInterval = 10 * time.Second
+
+// recentSize Recent 保留的消息条数
+const recentSize = 32
+
+var (
+	mu       sync.Mutex
+	out      io.Writer = os.Stderr
+	interval           = DefaultInterval
+	states             = make(map[string]*state)
+	now                = time.Now
+
+	// recent 最近输出的消息，环形缓冲
+	recent      [recentSize]string
+	recentNext  int
+	recentCount int
+)
+
+// state 单个类别的限流状态
+type state struct {
+	last       time.Time
+	suppressed int
+}
+
+// SetOutput 设置自诊断输出目标，nil 表示关闭。
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+}
+
+// SetInterval 设置同一类别消息的最小输出间隔，<= 0 表示不限流。
+func SetInterval(d time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	interval = d
+	states = make(map[string]*state)
+}
+
+// Enabled 返回自诊断输出是否开启。
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return out != nil
+}
+
+// Recent 按时间顺序返回最近的自诊断消息（不含换行），输出关闭时同样记录。
+func Recent() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	lines := make([]string, 0, recentCount)
+	start := (recentNext - recentCount + recentSize) % recentSize
+	for i := range recentCount {
+		lines = append(lines, recent[(start+i)%recentSize])
+	}
+	return lines
+}
+
+// Printf 输出一条自诊断消息。
+//
+// key 为消息类别（如 "async.drop"），同一类别在限流间隔内只输出一次，
+// 期间被抑制的条数会附加在下一次输出中。
+func Printf(key, format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	t := now()
+	st := states[key]
+	if st == nil {
+		st = &state{}
+		states[key] = st
+	}
+	if interval > 0 && !st.last.IsZero() && t.Sub(st.last) < interval {
+		st.suppressed++
+		return
+	}
+
+	msg := fmt.Sprintf(format, args...)
+	if st.suppressed > 0 {
+		msg += fmt.Sprintf(" (suppressed %d similar messages)", st.suppressed)
+	}
+	st.last = t
+	st.suppressed = 0
+
+	line := fmt.Sprintf("logm: %s [%s] %s", t.Format(time.RFC3339), key, msg)
+	recent[recentNext] = line
+	recentNext = (recentNext + 1) % recentSize
+	recentCount = min(recentCount+1, recentSize)
+
+	if out != nil {
+		_, _ = io.WriteString(out, line+"\n")
+	}
+}
@@ -0,0 +1,66 @@
+package selflog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintf_RateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetInterval(time.Minute)
+	defer func() {
+		SetOutput(os.Stderr)
+		SetInterval(DefaultInterval)
+		now = time.Now
+	}()
+
+	base := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+	now = func() time.Time { return base }
+
+	Printf("async.drop", "dropped %d records", 1)
+	Printf("async.drop", "dropped %d records", 2)
+	Printf("async.drop", "dropped %d records", 3)
+	Printf("format", "format failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "logm: 2024-01-15T10:30:45Z [async.drop] dropped 1 records", lines[0])
+	assert.Contains(t, lines[1], "[format] format failed")
+
+	buf.Reset()
+	now = func() time.Time { return base.Add(2 * time.Minute) }
+	Printf("async.drop", "dropped %d records", 4)
+	assert.Contains(t, buf.String(), "dropped 4 records (suppressed 2 similar messages)")
+}
+
+func TestPrintf_Disabled(t *testing.T) {
+	SetOutput(nil)
+	defer SetOutput(os.Stderr)
+
+	assert.False(t, Enabled())
+	assert.NotPanics(t, func() { Printf("x", "y") })
+}
+
+func TestRecent(t *testing.T) {
+	SetOutput(nil)
+	SetInterval(0)
+	defer func() {
+		SetOutput(os.Stderr)
+		SetInterval(DefaultInterval)
+	}()
+
+	for i := range recentSize + 3 {
+		Printf("recent", "message %d", i)
+	}
+	lines := Recent()
+	assert.Len(t, lines, recentSize)
+	assert.Contains(t, lines[0], "[recent] message 3")
+	assert.Contains(t, lines[recentSize-1], fmt.Sprintf("message %d", recentSize+2))
+}
@@ -0,0 +1,144 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// maxLineSize LineWriter 缓冲的最大行长度，超过时不等换行直接输出
+const maxLineSize = 64 * 1024
+
+// LineParser 将一行文本解析为日志记录，无法解析时返回错误。
+//
+// grok.Parser 实现了该接口；formatter.ParseLine 可通过 LineParserFunc 使用。
+type LineParser interface {
+	Parse(line []byte) (*formatter.Entry, error)
+}
+
+// LineParserFunc 函数形式的 LineParser
+type LineParserFunc func(line []byte) (*formatter.Entry, error)
+
+// Parse 实现 LineParser 接口。
+func (f LineParserFunc) Parse(line []byte) (*formatter.Entry, error) {
+	return f(line)
+}
+
+// LineWriterOption LineWriter 配置选项
+type LineWriterOption func(*LineWriter)
+
+// WithLineParser 设置解析每行的 LineParser，解析成功的行使用解析出的时间、级别、消息和字段。
+func WithLineParser(p LineParser) LineWriterOption {
+	return func(w *LineWriter) {
+		w.parser = p
+	}
+}
+
+// WithLineLevel 设置无法解析的行使用的级别，默认 INFO。
+func WithLineLevel(level slog.Level) LineWriterOption {
+	return func(w *LineWriter) {
+		w.level = level
+	}
+}
+
+// LineWriter 将写入的文本逐行转换为日志记录的 io.Writer，用于接入只能输出文本的旧代码：
+//
+//	w := logm.NewLineWriter(logger, logm.WithLineParser(p)) // p 如 grok.New 的结果
+//	log.SetOutput(w)         // 标准库 log
+//	cmd.Stderr = w           // 子进程输出
+//	defer w.Close()
+//
+// 没有设置 LineParser 或解析失败时，整行作为消息、以 WithLineLevel 的级别记录。
+// 空行忽略，超过 64KiB 仍没有换行的内容直接作为一行输出。
+type LineWriter struct {
+	handler slog.Handler
+	parser  LineParser
+	level   slog.Level
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewLineWriter 创建写入 logger 的 LineWriter。
+func NewLineWriter(logger *slog.Logger, opts ...LineWriterOption) *LineWriter {
+	w := &LineWriter{handler: logger.Handler(), level: slog.LevelInfo}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Write 实现 io.Writer 接口，末尾不完整的行缓冲到下一次写入或 Flush。
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	start := 0
+	for {
+		i := bytes.IndexByte(w.buf[start:], '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[start : start+i])
+		start += i + 1
+	}
+	if len(w.buf)-start >= maxLineSize {
+		w.emit(w.buf[start:])
+		start = len(w.buf)
+	}
+	// 剩余的不完整行移到缓冲区开头
+	w.buf = w.buf[:copy(w.buf, w.buf[start:])]
+	return len(p), nil
+}
+
+// Flush 输出缓冲中没有换行结尾的内容。
+func (w *LineWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = w.buf[:0]
+	}
+	return nil
+}
+
+// Close 等同于 Flush，不关闭 logger。
+func (w *LineWriter) Close() error {
+	return w.Flush()
+}
+
+// emit 将一行转换为日志记录
+func (w *LineWriter) emit(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	ctx := context.Background()
+
+	if w.parser != nil {
+		if e, err := w.parser.Parse(line); err == nil {
+			if !w.handler.Enabled(ctx, e.Level) {
+				return
+			}
+			t := e.Time
+			if t.IsZero() {
+				t = time.Now()
+			}
+			r := slog.NewRecord(t, e.Level, e.Message, 0)
+			r.AddAttrs(e.Attrs...)
+			if e.Source != "" {
+				r.AddAttrs(slog.String("source", e.Source))
+			}
+			_ = w.handler.Handle(ctx, r)
+			return
+		}
+	}
+	if w.handler.Enabled(ctx, w.level) {
+		_ = w.handler.Handle(ctx, slog.NewRecord(time.Now(), w.level, string(line), 0))
+	}
+}
@@ -0,0 +1,86 @@
+package logm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseEntries 解析缓冲中的 JSON 日志
+func parseEntries(t *testing.T, buf *bytes.Buffer) []*formatter.Entry {
+	t.Helper()
+	var out []*formatter.Entry
+	for line := range strings.Lines(buf.String()) {
+		e, err := formatter.ParseLine([]byte(line))
+		require.NoError(t, err, line)
+		out = append(out, e)
+	}
+	return out
+}
+
+func TestLineWriter_Plain(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.JSON())
+	w := NewLineWriter(logger, WithLineLevel(slog.LevelWarn))
+
+	std := log.New(w, "legacy: ", 0)
+	std.Print("first")
+	std.Print("second")
+	_, _ = w.Write([]byte("\n\r\npartial"))
+	_, _ = w.Write([]byte(" line"))
+	require.NoError(t, w.Close())
+
+	entries := parseEntries(t, buf)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "legacy: first", entries[0].Message)
+	assert.Equal(t, slog.LevelWarn, entries[0].Level)
+	assert.Equal(t, "legacy: second", entries[1].Message)
+	assert.Equal(t, "partial line", entries[2].Message)
+}
+
+func TestLineWriter_Parser(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.JSON())
+	parser := LineParserFunc(func(line []byte) (*formatter.Entry, error) {
+		level, msg, ok := strings.Cut(string(line), ": ")
+		if !ok {
+			return nil, errors.New("no level")
+		}
+		lv, _ := formatter.ParseLevelName(level)
+		return &formatter.Entry{
+			Time:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			Level:   lv,
+			Message: msg,
+			Attrs:   []slog.Attr{slog.Int("n", len(msg))},
+			Source:  "legacy.c:10",
+		}, nil
+	})
+	w := NewLineWriter(logger, WithLineParser(parser))
+	_, _ = fmt.Fprint(w, "ERROR: disk full\nno structure here\nDEBUG: filtered by level\n")
+
+	entries := parseEntries(t, buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, slog.LevelError, entries[0].Level)
+	assert.Equal(t, "disk full", entries[0].Message)
+	assert.True(t, entries[0].Time.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Contains(t, buf.String(), `"n":9`)
+	assert.Equal(t, "legacy.c:10", entries[0].Source)
+	assert.Equal(t, "no structure here", entries[1].Message)
+	assert.Equal(t, slog.LevelInfo, entries[1].Level)
+}
+
+func TestLineWriter_LongLine(t *testing.T) {
+	logger, buf := newBufferLogger(formatter.JSON())
+	w := NewLineWriter(logger)
+	_, _ = w.Write(bytes.Repeat([]byte("x"), maxLineSize+10))
+	assert.Len(t, parseEntries(t, buf), 1)
+	require.NoError(t, w.Flush())
+	assert.Len(t, parseEntries(t, buf), 1)
+}
@@ -1,11 +1,13 @@
 package logm
 
 import (
+	"context"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
-	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
 	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
 )
 
@@ -14,6 +16,8 @@ var (
 	globalHandler *Handler
 	// globalMu 保护全局状态
 	globalMu sync.RWMutex
+	// globalDefaultAttrs Init 配置的默认属性
+	globalDefaultAttrs []slog.Attr
 )
 
 // Init 初始化全局日志系统。
@@ -44,10 +48,7 @@ func Init(opts ...Option) error {
 
 	// 默认 formatter
 	if o.formatter == nil {
-		o.formatter = formatter.Text(
-			formatter.WithTimeFormat(o.timeFormat),
-			formatter.WithTimezone(o.timezone),
-		)
+		o.formatter = o.defaultFormatter()
 	}
 
 	// 默认 writer
@@ -71,17 +72,34 @@ func Init(opts ...Option) error {
 		AddSource:    o.addSource,
 		TimeFormat:   o.timeFormat,
 		Location:     o.location,
+
+		MaxRecordSize:  o.maxRecordSize,
+		OversizePolicy: o.oversizePolicy,
+		OnWriteError:   o.onWriteError,
+		Clock:          o.clock,
+		DefaultAttrs:   o.defaultAttrs,
+
+		CallerSkip:         o.callerSkip,
+		CallerSkipPackages: o.callerSkipPkgs,
+		DuplicateKeys:      o.duplicateKeys,
+		MaxAttrs:           o.maxAttrs,
+		Sanitize:           o.sanitize,
+		MessageTemplate:    o.msgTemplate,
+		FeatureFlags:       o.flags,
 	})
+	h.observers = globalObservers
 
-	// 设置全局
+	// 先切换全局 Handler 再关闭旧的，缩短切换期间日志被丢弃的窗口
 	globalMu.Lock()
-	if globalHandler != nil {
-		_ = globalHandler.Close()
-	}
+	old := globalHandler
 	globalHandler = h
+	globalDefaultAttrs = slices.Clone(o.defaultAttrs)
+	slog.SetDefault(slog.New(h))
 	globalMu.Unlock()
 
-	slog.SetDefault(slog.New(h))
+	if old != nil {
+		_ = old.Close()
+	}
 	return nil
 }
 
@@ -104,6 +122,11 @@ func MustInit(opts ...Option) {
 //
 // 返回的 logger 独立于全局配置，适用于模块专用日志。
 func New(opts ...Option) *slog.Logger {
+	return slog.New(newHandler(opts...))
+}
+
+// newHandler 按选项创建使用独立 LevelVar 的 Handler
+func newHandler(opts ...Option) *Handler {
 	o := defaultOptions()
 	o.apply(opts...)
 
@@ -117,10 +140,7 @@ func New(opts ...Option) *slog.Logger {
 
 	// 默认 formatter
 	if o.formatter == nil {
-		o.formatter = formatter.Text(
-			formatter.WithTimeFormat(o.timeFormat),
-			formatter.WithTimezone(o.timezone),
-		)
+		o.formatter = o.defaultFormatter()
 	}
 
 	// 默认 writer
@@ -132,7 +152,7 @@ func New(opts ...Option) *slog.Logger {
 	levelVar := &slog.LevelVar{}
 	levelVar.Set(ParseLevel(o.level))
 
-	h := NewHandler(&HandlerConfig{
+	return NewHandler(&HandlerConfig{
 		LevelVar:     levelVar,
 		Formatter:    o.formatter,
 		Writers:      o.writers,
@@ -140,24 +160,60 @@ func New(opts ...Option) *slog.Logger {
 		AddSource:    o.addSource,
 		TimeFormat:   o.timeFormat,
 		Location:     o.location,
-	})
 
-	return slog.New(h)
+		MaxRecordSize:  o.maxRecordSize,
+		OversizePolicy: o.oversizePolicy,
+		OnWriteError:   o.onWriteError,
+		Clock:          o.clock,
+		DefaultAttrs:   o.defaultAttrs,
+
+		CallerSkip:         o.callerSkip,
+		CallerSkipPackages: o.callerSkipPkgs,
+		DuplicateKeys:      o.duplicateKeys,
+		MaxAttrs:           o.maxAttrs,
+		Sanitize:           o.sanitize,
+		MessageTemplate:    o.msgTemplate,
+		FeatureFlags:       o.flags,
+	})
 }
 
 // Close 关闭全局日志系统，释放资源。
+//
+// InitPipelines 创建的管道不受影响，使用 Pipelines().Close() 单独关闭。
 func Close() error {
 	globalMu.Lock()
-	defer globalMu.Unlock()
+	h := globalHandler
+	globalHandler = nil
+	globalMu.Unlock()
 
-	if globalHandler != nil {
-		err := globalHandler.Close()
-		globalHandler = nil
-		return err
+	if h != nil {
+		return h.Close()
 	}
 	return nil
 }
 
+// Shutdown 在 ctx 结束前刷新并关闭全局日志系统。
+//
+// 与 Close 不同，Shutdown 受 ctx 截止时间约束，不会因 Writer 阻塞而无限挂起，
+// 适合在进程退出流程中使用。返回累计丢弃的日志数：
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	if dropped, err := logm.Shutdown(ctx); err != nil || dropped > 0 {
+//	    fmt.Fprintf(os.Stderr, "logm shutdown: dropped=%d err=%v\n", dropped, err)
+//	}
+func Shutdown(ctx context.Context) (dropped uint64, err error) {
+	globalMu.Lock()
+	h := globalHandler
+	globalHandler = nil
+	globalMu.Unlock()
+
+	if h == nil {
+		return 0, nil
+	}
+	return h.Shutdown(ctx)
+}
+
 // Sync 刷新全局日志缓冲区。
 func Sync() error {
 	globalMu.RLock()
@@ -170,6 +226,27 @@ func Sync() error {
 	return nil
 }
 
+// Rotate 立即轮转全局日志系统中的所有文件 Writer。
+//
+// 外部工具（如 logrotate 的 postrotate 脚本）需要触发轮转时使用，也可以通过 RotateOnSignal 绑定到信号。
+func Rotate() error {
+	globalMu.RLock()
+	h := globalHandler
+	globalMu.RUnlock()
+
+	if h != nil {
+		return h.Rotate()
+	}
+	return nil
+}
+
+// DefaultAttrs 返回 Init 通过 WithDefaultAttrs 配置的默认属性。
+func DefaultAttrs() []slog.Attr {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return slices.Clone(globalDefaultAttrs)
+}
+
 // Default 返回全局默认 logger。
 func Default() *slog.Logger {
 	return slog.Default()
@@ -214,6 +291,7 @@ func mustLoadTimezone(tz string) *time.Location {
 	}
 	loc, err := time.LoadLocation(tz)
 	if err != nil {
+		selflog.Printf("timezone", "load timezone %q failed, falling back to local: %v", tz, err)
 		return nil
 	}
 	return loc
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
 	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
@@ -40,6 +41,15 @@ func TestInit_Production(t *testing.T) {
 	defer func() { _ = Close() }()
 }
 
+func TestPresetStable(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(append(PresetStable(), WithWriter(&testWriter{buf: &buf}))...)
+
+	logger.With("b", 2).Info("hello", "c", 3, slog.Group("a", "z", 1, "y", 2))
+
+	assert.Equal(t, "time=2024-01-15 10:30:45 level=INFO msg=hello a.y=2 a.z=1 b=2 c=3\n", buf.String())
+}
+
 func TestMustInit_Success(t *testing.T) {
 	// MustInit 成功时不应 panic
 	assert.NotPanics(t, func() {
@@ -257,6 +267,94 @@ func TestHandler_WithAttrs(t *testing.T) {
 	assert.Contains(t, output, "service=api")
 }
 
+func TestHandler_WithAttrs_SharedPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	stdoutWriter := &testWriter{buf: &buf}
+
+	h := NewHandler(&HandlerConfig{
+		Formatter: formatter.Text(),
+		Writers:   []Writer{stdoutWriter},
+	})
+
+	base := slog.New(h).With("service", "api")
+	a := base.With("route", "a")
+	b := base.With("route", "b")
+
+	a.Info("from a")
+	assert.Contains(t, buf.String(), "service=api route=a")
+	assert.NotContains(t, buf.String(), "route=b")
+
+	buf.Reset()
+	b.Info("from b")
+	assert.Contains(t, buf.String(), "service=api route=b")
+	assert.NotContains(t, buf.String(), "route=a")
+
+	buf.Reset()
+	base.Info("from base")
+	assert.NotContains(t, buf.String(), "route=")
+}
+
+func TestInit_WithDefaultAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Init(
+		WithFormatter(formatter.Text()),
+		WithWriter(&testWriter{buf: &buf}),
+		WithDefaultAttrs(slog.String("service", "billing"), slog.String("env", "prod")),
+	))
+	defer func() { _ = Close() }()
+
+	// 替换 slog 默认 logger 后属性仍由 Handler 添加
+	slog.SetDefault(slog.New(slog.Default().Handler()).With("component", "api"))
+	slog.Info("charged")
+	assert.Contains(t, buf.String(), "service=billing env=prod component=api")
+
+	// 独立 logger 显式复用默认属性
+	var own bytes.Buffer
+	log := New(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &own}),
+		WithDefaultAttrs(DefaultAttrs()...))
+	log.Info("standalone")
+	assert.Contains(t, own.String(), "service=billing env=prod")
+
+	own.Reset()
+	New(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &own})).Info("plain")
+	assert.NotContains(t, own.String(), "service=")
+}
+
+func TestHandler_PerLevelWriter(t *testing.T) {
+	var app, errs bytes.Buffer
+	h := NewHandler(&HandlerConfig{
+		Formatter: formatter.Text(),
+		Writers: []Writer{writer.PerLevel(map[slog.Level]writer.Writer{
+			slog.LevelDebug: &testWriter{buf: &app},
+			slog.LevelError: &testWriter{buf: &errs},
+		})},
+	})
+
+	logger := slog.New(h)
+	logger.Info("started")
+	logger.Error("failed")
+
+	assert.Contains(t, app.String(), "started")
+	assert.Contains(t, app.String(), "failed")
+	assert.NotContains(t, errs.String(), "started")
+	assert.Contains(t, errs.String(), "failed")
+}
+
+func BenchmarkHandler_WithAttrsChain(b *testing.B) {
+	h := NewHandler(&HandlerConfig{
+		Formatter: formatter.Text(),
+		Writers:   []Writer{&testWriter{buf: &bytes.Buffer{}}},
+	})
+
+	b.ReportAllocs()
+	for b.Loop() {
+		logger := slog.New(h)
+		for range 100 {
+			logger = logger.With("k", "v")
+		}
+	}
+}
+
 func TestHandler_WithGroup(t *testing.T) {
 	var buf bytes.Buffer
 	stdoutWriter := &testWriter{buf: &buf}
@@ -368,6 +466,52 @@ func TestHandler_AddSource(t *testing.T) {
 	assert.Contains(t, output, ".go:")
 }
 
+func TestHandler_Clock(t *testing.T) {
+	var buf bytes.Buffer
+	fixed := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+
+	h := NewHandler(&HandlerConfig{
+		Formatter: formatter.JSON(formatter.WithTimezone("UTC"), formatter.WithTimeFormat("rfc3339")),
+		Writers:   []Writer{&testWriter{buf: &buf}},
+		Clock:     func() time.Time { return fixed },
+	})
+
+	slog.New(h).Info("first")
+	slog.New(h).With("derived", true).Info("second")
+
+	assert.Equal(t, 2, strings.Count(buf.String(), `"time":"2024-01-15T10:30:45Z"`))
+}
+
+func TestNew_WithClock(t *testing.T) {
+	var buf bytes.Buffer
+	fixed := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+
+	logger := New(
+		WithFormatter(formatter.Text(formatter.WithTimezone("UTC"))),
+		WithWriter(&testWriter{buf: &buf}),
+		WithClock(func() time.Time { return fixed }),
+	)
+	logger.Info("test")
+
+	assert.Equal(t, "time=2024-01-15 10:30:45 level=INFO msg=test\n", buf.String())
+}
+
+func TestNew_WithClock_Derived(t *testing.T) {
+	var buf bytes.Buffer
+	fixed := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+
+	logger := New(
+		WithFormatter(formatter.Text(formatter.WithTimezone("UTC"))),
+		WithWriter(&testWriter{buf: &buf}),
+		WithClock(func() time.Time { return fixed }),
+	)
+	logger.With("k", "v").Info("with")
+	logger.WithGroup("g").Info("group", "k", "v")
+
+	assert.Equal(t, "time=2024-01-15 10:30:45 level=INFO msg=with k=v\n"+
+		"time=2024-01-15 10:30:45 level=INFO msg=group g.k=v\n", buf.String())
+}
+
 func TestDebugInfoWarnError(t *testing.T) {
 	err := Init(WithLevel("DEBUG"))
 	require.NoError(t, err)
@@ -427,3 +571,52 @@ func (w *testWriter) Close() error {
 func (w *testWriter) Sync() error {
 	return nil
 }
+
+func TestShutdown(t *testing.T) {
+	var buf bytes.Buffer
+	aw := writer.Async(&testWriter{buf: &buf}, 100)
+	require.NoError(t, Init(WithWriter(aw)))
+
+	Info("before shutdown")
+
+	dropped, err := Shutdown(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), dropped)
+	assert.Contains(t, buf.String(), "before shutdown")
+
+	// 重复调用不报错
+	_, err = Shutdown(context.Background())
+	require.NoError(t, err)
+}
+
+func TestShutdown_Deadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	aw := writer.Async(&blockingTestWriter{release: release}, 100)
+	require.NoError(t, Init(WithWriter(aw)))
+
+	for range 5 {
+		Info("stuck")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := Shutdown(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// blockingTestWriter 在 release 关闭前阻塞所有写入
+type blockingTestWriter struct {
+	release chan struct{}
+}
+
+func (w *blockingTestWriter) Write(p []byte) (n int, err error) {
+	<-w.release
+	return len(p), nil
+}
+
+func (w *blockingTestWriter) Close() error { return nil }
+func (w *blockingTestWriter) Sync() error  { return nil }
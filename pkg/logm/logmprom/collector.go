@@ -0,0 +1,100 @@
+// Package logmprom 将 logm 的运行统计暴露为 Prometheus 指标。
+//
+// 独立于 logm 主包，只有导入本包的程序才依赖 prometheus/client_golang。
+package logmprom
+
+import (
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// levelNames HandlerStats.Records 的键
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// Collector 将日志管道统计暴露为 Prometheus 指标。
+//
+// 每次采集时读取 Stats，不额外维护状态。暴露的指标：
+//   - log_records_total{level}: 按级别统计的已输出日志数
+//   - log_dropped_total: Writer 丢弃的日志数（如 Async 缓冲区满）
+//   - log_oversized_total: 因超出 MaxRecordSize 被丢弃的日志数
+//   - log_write_errors_total{writer}: 按 Writer 统计的写入错误数
+//   - log_bytes_written_total: 累计写入字节数
+type Collector struct {
+	stats func() logm.HandlerStats
+
+	records     *prometheus.Desc
+	dropped     *prometheus.Desc
+	oversized   *prometheus.Desc
+	writeErrors *prometheus.Desc
+	bytes       *prometheus.Desc
+}
+
+// NewCollector 创建全局日志系统的 Prometheus 采集器。
+//
+// 示例：
+//
+//	prometheus.MustRegister(logmprom.NewCollector())
+func NewCollector() *Collector {
+	return NewStatsCollector(logm.Stats)
+}
+
+// NewHandlerCollector 创建指定 Handler 的 Prometheus 采集器。
+//
+// 适用于通过 logm.New 或 logm.NewHandler 创建的独立 logger。
+func NewHandlerCollector(h *logm.Handler) *Collector {
+	return NewStatsCollector(h.Stats)
+}
+
+// NewStatsCollector 创建从 stats 读取统计的采集器，用于其他来源的 logm.HandlerStats。
+func NewStatsCollector(stats func() logm.HandlerStats) *Collector {
+	return &Collector{
+		stats: stats,
+		records: prometheus.NewDesc(
+			"log_records_total", "Total number of log records emitted, by level.",
+			[]string{"level"}, nil,
+		),
+		dropped: prometheus.NewDesc(
+			"log_dropped_total", "Total number of log records dropped by writers.",
+			nil, nil,
+		),
+		oversized: prometheus.NewDesc(
+			"log_oversized_total", "Total number of log records dropped for exceeding the max record size.",
+			nil, nil,
+		),
+		writeErrors: prometheus.NewDesc(
+			"log_write_errors_total", "Total number of failed writes, by writer.",
+			[]string{"writer"}, nil,
+		),
+		bytes: prometheus.NewDesc(
+			"log_bytes_written_total", "Total number of bytes written by all writers.",
+			nil, nil,
+		),
+	}
+}
+
+// Describe 实现 prometheus.Collector 接口。
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.records
+	ch <- c.dropped
+	ch <- c.oversized
+	ch <- c.writeErrors
+	ch <- c.bytes
+}
+
+// Collect 实现 prometheus.Collector 接口。
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+
+	for _, level := range levelNames {
+		ch <- prometheus.MustNewConstMetric(c.records, prometheus.CounterValue, float64(s.Records[level]), level)
+	}
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(s.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.oversized, prometheus.CounterValue, float64(s.Oversized))
+	for _, w := range s.Writers {
+		ch <- prometheus.MustNewConstMetric(c.writeErrors, prometheus.CounterValue, float64(w.WriteErrors), w.Name)
+	}
+	ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.CounterValue, float64(s.BytesWritten))
+}
+
+// 确保 Collector 实现 prometheus.Collector 接口
+var _ prometheus.Collector = (*Collector)(nil)
@@ -0,0 +1,70 @@
+package logmprom
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bufWriter 写入内存的 Writer
+type bufWriter struct{ bytes.Buffer }
+
+func (w *bufWriter) Close() error { return nil }
+func (w *bufWriter) Sync() error  { return nil }
+
+// errWriter 总是写入失败的 Writer
+type errWriter struct{}
+
+func (w *errWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+func (w *errWriter) Close() error              { return nil }
+func (w *errWriter) Sync() error               { return nil }
+
+func TestCollector(t *testing.T) {
+	h := logm.NewHandler(&logm.HandlerConfig{
+		Formatter: formatter.Text(),
+		Writers:   []logm.Writer{&bufWriter{}, &errWriter{}},
+	})
+
+	logger := slog.New(h)
+	logger.Info("one")
+	logger.Error("two")
+
+	c := NewHandlerCollector(h)
+
+	expected := `
+# HELP log_records_total Total number of log records emitted, by level.
+# TYPE log_records_total counter
+log_records_total{level="DEBUG"} 0
+log_records_total{level="ERROR"} 1
+log_records_total{level="INFO"} 1
+log_records_total{level="WARN"} 0
+# HELP log_write_errors_total Total number of failed writes, by writer.
+# TYPE log_write_errors_total counter
+log_write_errors_total{writer="logmprom.errWriter#1"} 2
+log_write_errors_total{writer="logmprom.bufWriter#0"} 0
+# HELP log_dropped_total Total number of log records dropped by writers.
+# TYPE log_dropped_total counter
+log_dropped_total 0
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"log_records_total", "log_write_errors_total", "log_dropped_total")
+	require.NoError(t, err)
+}
+
+func TestNewCollector_Global(t *testing.T) {
+	require.NoError(t, logm.Init(logm.WithWriter(&bufWriter{})))
+	defer func() { _ = logm.Close() }()
+
+	logm.Warn("hello")
+
+	c := NewCollector()
+	assert.Equal(t, 8, testutil.CollectAndCount(c))
+}
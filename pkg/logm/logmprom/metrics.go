@@ -0,0 +1,203 @@
+package logmprom
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// MetricRule 从日志派生指标的规则。
+//
+// 记录同时满足 MinLevel、Message、Match 和 Filter 时计入指标：
+// Value 为空时为计数器，每条记录加一；否则为直方图，观察该属性的数值。
+// 属性键相对于记录的属性，可以是以 . 连接的分组路径，如 http.status。
+type MetricRule struct {
+	// Name 指标名，如 http_requests_total
+	Name string
+	// Help 指标说明，空时自动生成
+	Help string
+	// MinLevel 最低级别，零值为 INFO
+	MinLevel slog.Level
+	// Message 精确匹配的日志消息，空表示不限
+	Message string
+	// Match 属性值需要等于的文本，如 {"http.method": "GET"}
+	Match map[string]string
+	// Filter 自定义匹配，nil 表示不限
+	Filter func(r *logm.Record) bool
+	// Labels 作为指标标签的属性键，标签名中的 . 替换为 _，属性缺失时标签值为空
+	Labels []string
+	// Value 直方图观察的属性键，整数和浮点数原样观察，时长按秒观察，数字字符串按解析结果观察
+	Value string
+	// Buckets 直方图的桶，nil 时使用 prometheus.DefBuckets
+	Buckets []float64
+}
+
+// LogMetrics 按规则从日志派生 Prometheus 指标，小型服务无需为简单的计数单独埋点：
+//
+//	m := logmprom.MustLogMetrics(
+//	    logmprom.MetricRule{Name: "login_failures_total", Message: "login failed", MinLevel: slog.LevelWarn},
+//	    logmprom.MetricRule{Name: "http_request_seconds", Message: "request", Labels: []string{"route"}, Value: "elapsed"},
+//	)
+//	defer logm.Observe(m.Observe)()
+//	prometheus.MustRegister(m)
+//
+// Observe 作为观察者接收最终写出的日志记录，可以注册到全局或单个 Handler。
+type LogMetrics struct {
+	rules []metricRule
+}
+
+// metricRule 编译后的规则
+type metricRule struct {
+	MetricRule
+	counter   *prometheus.CounterVec
+	histogram *prometheus.HistogramVec
+}
+
+// NewLogMetrics 创建日志指标。
+//
+// 指标名和标签名按 Prometheus 传统命名规则（[a-zA-Z_:][a-zA-Z0-9_:]*，标签不含 :）校验，
+// 名称为空、重复或不合法时返回错误，而不是在注册时 panic。
+func NewLogMetrics(rules ...MetricRule) (*LogMetrics, error) {
+	m := &LogMetrics{rules: make([]metricRule, 0, len(rules))}
+	seen := make(map[string]bool, len(rules))
+	var errs []error
+	for i, rule := range rules {
+		switch {
+		case rule.Name == "":
+			errs = append(errs, fmt.Errorf("logmprom: metric rule %d: empty name", i))
+			continue
+		case seen[rule.Name]:
+			errs = append(errs, fmt.Errorf("logmprom: metric rule %d: duplicate name %q", i, rule.Name))
+			continue
+		case !model.LegacyValidation.IsValidMetricName(rule.Name):
+			errs = append(errs, fmt.Errorf("logmprom: metric rule %d: invalid metric name %q", i, rule.Name))
+			continue
+		}
+		seen[rule.Name] = true
+
+		help := rule.Help
+		if help == "" {
+			help = "Derived from log records by logm."
+		}
+		labels := make([]string, len(rule.Labels))
+		valid := true
+		for j, key := range rule.Labels {
+			labels[j] = strings.ReplaceAll(key, ".", "_")
+			if !model.LegacyValidation.IsValidLabelName(labels[j]) || strings.HasPrefix(labels[j], model.ReservedLabelPrefix) {
+				errs = append(errs, fmt.Errorf("logmprom: metric rule %d (%s): invalid label name %q from attr %q", i, rule.Name, labels[j], key))
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+		mr := metricRule{MetricRule: rule}
+		if rule.Value == "" {
+			mr.counter = prometheus.NewCounterVec(prometheus.CounterOpts{Name: rule.Name, Help: help}, labels)
+		} else {
+			mr.histogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: rule.Name, Help: help, Buckets: rule.Buckets}, labels)
+		}
+		m.rules = append(m.rules, mr)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MustLogMetrics 同 NewLogMetrics，出错时 panic。
+func MustLogMetrics(rules ...MetricRule) *LogMetrics {
+	m, err := NewLogMetrics(rules...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Observe 按规则处理一条日志记录，签名与 ObserverFunc 一致。
+func (m *LogMetrics) Observe(r logm.Record) {
+	for i := range m.rules {
+		rule := &m.rules[i]
+		if !rule.matches(&r) {
+			continue
+		}
+		labels := make([]string, len(rule.Labels))
+		for j, key := range rule.Labels {
+			if v, ok := logm.LookupAttr(r.Attrs, key); ok {
+				labels[j] = v.String()
+			}
+		}
+		if rule.counter != nil {
+			rule.counter.WithLabelValues(labels...).Inc()
+			continue
+		}
+		if v, ok := logm.LookupAttr(r.Attrs, rule.Value); ok {
+			if f, ok := metricValue(v); ok {
+				rule.histogram.WithLabelValues(labels...).Observe(f)
+			}
+		}
+	}
+}
+
+// Describe 实现 prometheus.Collector 接口。
+func (m *LogMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, rule := range m.rules {
+		rule.collector().Describe(ch)
+	}
+}
+
+// Collect 实现 prometheus.Collector 接口。
+func (m *LogMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, rule := range m.rules {
+		rule.collector().Collect(ch)
+	}
+}
+
+// collector 返回规则对应的指标
+func (r *metricRule) collector() prometheus.Collector {
+	if r.counter != nil {
+		return r.counter
+	}
+	return r.histogram
+}
+
+// matches 判断记录是否满足规则
+func (r *metricRule) matches(rec *logm.Record) bool {
+	if rec.Level < r.MinLevel || (r.Message != "" && rec.Message != r.Message) {
+		return false
+	}
+	for key, want := range r.Match {
+		if v, ok := logm.LookupAttr(rec.Attrs, key); !ok || v.String() != want {
+			return false
+		}
+	}
+	return r.Filter == nil || r.Filter(rec)
+}
+
+// metricValue 将属性值转换为直方图观察值
+func metricValue(v slog.Value) (float64, bool) {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return float64(v.Int64()), true
+	case slog.KindUint64:
+		return float64(v.Uint64()), true
+	case slog.KindFloat64:
+		return v.Float64(), true
+	case slog.KindDuration:
+		return v.Duration().Seconds(), true
+	case slog.KindString:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// 确保 LogMetrics 实现 prometheus.Collector 接口
+var _ prometheus.Collector = (*LogMetrics)(nil)
@@ -0,0 +1,105 @@
+package logmprom
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMetrics(t *testing.T) {
+	m := MustLogMetrics(
+		MetricRule{Name: "login_failures_total", Help: "Failed logins.", Message: "login failed", MinLevel: slog.LevelWarn},
+		MetricRule{Name: "get_requests_total", Help: "GET requests.", Match: map[string]string{"http.method": "GET"}, Labels: []string{"http.status"}},
+		MetricRule{Name: "request_seconds", Help: "Request latency.", Message: "request", Value: "elapsed", Buckets: []float64{0.1, 1}},
+	)
+	h := logm.NewHandler(&logm.HandlerConfig{Formatter: formatter.Text(), Writers: []logm.Writer{&bufWriter{}}})
+	defer h.Observe(m.Observe)()
+	logger := slog.New(h)
+
+	logger.Warn("login failed", "user", "a")
+	logger.Info("login failed", "user", "b") // 低于 MinLevel
+	logger.Error("login failed", "user", "c")
+	logger.Info("request", slog.Group("http", slog.String("method", "GET"), slog.Int("status", 200)), "elapsed", 50*time.Millisecond)
+	logger.Info("request", slog.Group("http", slog.String("method", "GET"), slog.Int("status", 500)), "elapsed", 2*time.Second)
+	logger.Info("request", slog.Group("http", slog.String("method", "POST"), slog.Int("status", 200)), "elapsed", "0.5")
+	logger.Info("request", "elapsed", "fast") // 无法解析，不观察
+
+	expected := `
+# HELP login_failures_total Failed logins.
+# TYPE login_failures_total counter
+login_failures_total 2
+# HELP get_requests_total GET requests.
+# TYPE get_requests_total counter
+get_requests_total{http_status="200"} 1
+get_requests_total{http_status="500"} 1
+# HELP request_seconds Request latency.
+# TYPE request_seconds histogram
+request_seconds_bucket{le="0.1"} 1
+request_seconds_bucket{le="1"} 2
+request_seconds_bucket{le="+Inf"} 3
+request_seconds_sum 2.55
+request_seconds_count 3
+`
+	require.NoError(t, testutil.CollectAndCompare(m, strings.NewReader(expected)))
+}
+
+func TestLogMetrics_Filter(t *testing.T) {
+	m := MustLogMetrics(MetricRule{
+		Name:     "slow_total",
+		MinLevel: slog.LevelDebug,
+		Filter: func(r *logm.Record) bool {
+			v, ok := logm.LookupAttr(r.Attrs, "ms")
+			return ok && v.Int64() > 100
+		},
+	})
+
+	m.Observe(logm.Record{Level: slog.LevelDebug, Attrs: []slog.Attr{slog.Int("ms", 50)}})
+	m.Observe(logm.Record{Level: slog.LevelDebug, Attrs: []slog.Attr{slog.Int("ms", 500)}})
+
+	assert.InDelta(t, 1, testutil.ToFloat64(m), 0)
+}
+
+func TestNewLogMetrics_Errors(t *testing.T) {
+	_, err := NewLogMetrics(MetricRule{}, MetricRule{Name: "a"}, MetricRule{Name: "a"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "empty name")
+	assert.Contains(t, err.Error(), `duplicate name "a"`)
+
+	assert.Panics(t, func() { MustLogMetrics(MetricRule{}) })
+}
+
+func TestNewLogMetrics_InvalidNames(t *testing.T) {
+	_, err := NewLogMetrics(
+		MetricRule{Name: "login-failures"},
+		MetricRule{Name: "ok_total", Labels: []string{"http.route", "user-id", "__internal"}},
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid metric name "login-failures"`)
+	assert.Contains(t, err.Error(), `invalid label name "user-id"`)
+	assert.Contains(t, err.Error(), `invalid label name "__internal"`)
+	assert.NotContains(t, err.Error(), "http_route")
+
+	// 校验通过的规则注册时不会 panic
+	m, err := NewLogMetrics(MetricRule{Name: "ok_total", Labels: []string{"http.route"}})
+	require.NoError(t, err)
+	assert.NotPanics(t, func() { prometheus.NewPedanticRegistry().MustRegister(m) })
+}
+
+func TestLogMetrics_Register(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := MustLogMetrics(MetricRule{Name: "a_total"}, MetricRule{Name: "b_seconds", Value: "v"})
+	require.NoError(t, reg.Register(m))
+
+	m.Observe(logm.Record{Level: slog.LevelInfo, Attrs: []slog.Attr{slog.Float64("v", 0.2)}})
+	n, err := testutil.GatherAndCount(reg)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
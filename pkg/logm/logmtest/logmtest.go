@@ -0,0 +1,317 @@
+// Package logmtest 提供日志断言的测试辅助工具。
+//
+// Handler 是一个内存 slog.Handler，按结构化形式记录每条日志，
+// 避免对格式化后的字节做脆弱的子串匹配：
+//
+//	func TestSignup(t *testing.T) {
+//	    h := logmtest.SetDefault(t)
+//
+//	    signup(ctx, 42)
+//
+//	    h.AssertLogged(t, slog.LevelInfo, "user signed up", "user_id", 42)
+//	    assert.Empty(t, h.FilterLevel(slog.LevelError))
+//	}
+package logmtest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Entry 一条被记录的日志。
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Attrs 平铺后的属性，分组使用 "." 连接，如 "request.method"
+	Attrs map[string]slog.Value
+	// Keys 属性键的出现顺序
+	Keys   []string
+	Source *slog.Source
+}
+
+// Attr 返回指定键的属性值。
+func (e Entry) Attr(key string) (slog.Value, bool) {
+	v, ok := e.Attrs[key]
+	return v, ok
+}
+
+// HasAttr 判断日志是否包含指定键值的属性。
+//
+// value 按 slog.AnyValue 规范化后比较，因此 int 与 int64 等价。
+func (e Entry) HasAttr(key string, value any) bool {
+	v, ok := e.Attrs[key]
+	if !ok {
+		return false
+	}
+	return v.Equal(slog.AnyValue(value).Resolve())
+}
+
+// String 返回便于阅读的日志描述，用于断言失败信息。
+func (e Entry) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Level.String())
+	sb.WriteByte(' ')
+	sb.WriteString(e.Message)
+	for _, k := range e.Keys {
+		fmt.Fprintf(&sb, " %s=%v", k, e.Attrs[k])
+	}
+	return sb.String()
+}
+
+// Entries 日志列表，提供链式过滤方法。
+type Entries []Entry
+
+// FilterLevel 返回指定级别的日志。
+func (es Entries) FilterLevel(level slog.Level) Entries {
+	return es.Filter(func(e Entry) bool { return e.Level == level })
+}
+
+// FilterMessage 返回消息包含 substr 的日志。
+func (es Entries) FilterMessage(substr string) Entries {
+	return es.Filter(func(e Entry) bool { return strings.Contains(e.Message, substr) })
+}
+
+// FilterAttr 返回包含指定键值属性的日志。
+func (es Entries) FilterAttr(key string, value any) Entries {
+	return es.Filter(func(e Entry) bool { return e.HasAttr(key, value) })
+}
+
+// Filter 返回满足 fn 的日志。
+func (es Entries) Filter(fn func(Entry) bool) Entries {
+	var out Entries
+	for _, e := range es {
+		if fn(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// HasAttr 判断是否有任意日志包含指定键值的属性。
+func (es Entries) HasAttr(key string, value any) bool {
+	return len(es.FilterAttr(key, value)) > 0
+}
+
+// Messages 返回所有日志的消息。
+func (es Entries) Messages() []string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Message
+	}
+	return msgs
+}
+
+// store 记录存储，派生 Handler 共享
+type store struct {
+	mu      sync.Mutex
+	entries Entries
+}
+
+// Handler 记录结构化日志的内存 slog.Handler。
+type Handler struct {
+	store  *store
+	level  slog.Leveler
+	prefix string      // 当前分组前缀，如 "request."
+	attrs  []attrEntry // WithAttrs 添加的属性（已带前缀）
+}
+
+// attrEntry 已平铺的属性
+type attrEntry struct {
+	key   string
+	value slog.Value
+}
+
+// NewHandler 创建记录所有级别日志的 Handler。
+func NewHandler() *Handler {
+	return NewHandlerWithLevel(slog.Level(-100))
+}
+
+// NewHandlerWithLevel 创建只记录 level 及以上级别日志的 Handler。
+func NewHandlerWithLevel(level slog.Leveler) *Handler {
+	return &Handler{store: &store{}, level: level}
+}
+
+// SetDefault 创建 Handler 并设置为 slog 默认 logger，测试结束时恢复原默认 logger。
+func SetDefault(t testing.TB) *Handler {
+	t.Helper()
+	h := NewHandler()
+	prev := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return h
+}
+
+// Logger 返回使用该 Handler 的 logger。
+func (h *Handler) Logger() *slog.Logger {
+	return slog.New(h)
+}
+
+// Enabled 实现 slog.Handler 接口。
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle 实现 slog.Handler 接口。
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	e := Entry{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   make(map[string]slog.Value, len(h.attrs)+r.NumAttrs()),
+	}
+	for _, a := range h.attrs {
+		e.add(a.key, a.value)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		flatten(h.prefix, a, e.add)
+		return true
+	})
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		e.Source = &slog.Source{Function: f.Function, File: f.File, Line: f.Line}
+	}
+
+	h.store.mu.Lock()
+	h.store.entries = append(h.store.entries, e)
+	h.store.mu.Unlock()
+	return nil
+}
+
+// WithAttrs 实现 slog.Handler 接口。
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = slices.Clip(h.attrs)
+	for _, a := range attrs {
+		flatten(h.prefix, a, func(k string, v slog.Value) {
+			clone.attrs = append(clone.attrs, attrEntry{key: k, value: v})
+		})
+	}
+	return &clone
+}
+
+// WithGroup 实现 slog.Handler 接口。
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// Entries 返回已记录的所有日志。
+func (h *Handler) Entries() Entries {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	return slices.Clone(h.store.entries)
+}
+
+// Len 返回已记录的日志条数。
+func (h *Handler) Len() int {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	return len(h.store.entries)
+}
+
+// Reset 清空已记录的日志。
+func (h *Handler) Reset() {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	h.store.entries = nil
+}
+
+// FilterLevel 返回指定级别的日志。
+func (h *Handler) FilterLevel(level slog.Level) Entries {
+	return h.Entries().FilterLevel(level)
+}
+
+// HasAttr 判断是否有任意日志包含指定键值的属性。
+func (h *Handler) HasAttr(key string, value any) bool {
+	return h.Entries().HasAttr(key, value)
+}
+
+// AssertLogged 断言存在一条指定级别和消息、且包含所有给定属性的日志。
+//
+// args 为交替的键值对，与 slog.Logger.Info 的参数形式一致。
+func (h *Handler) AssertLogged(t testing.TB, level slog.Level, msg string, args ...any) bool {
+	t.Helper()
+
+	want := slog.NewRecord(time.Time{}, level, msg, 0)
+	want.Add(args...)
+
+	for _, e := range h.Entries() {
+		if e.Level != level || e.Message != msg {
+			continue
+		}
+		matched := true
+		want.Attrs(func(a slog.Attr) bool {
+			flatten("", a, func(k string, v slog.Value) {
+				if got, ok := e.Attrs[k]; !ok || !got.Equal(v) {
+					matched = false
+				}
+			})
+			return matched
+		})
+		if matched {
+			return true
+		}
+	}
+
+	var sb strings.Builder
+	for _, e := range h.Entries() {
+		sb.WriteString("\n\t")
+		sb.WriteString(e.String())
+	}
+	t.Errorf("logmtest: no entry matched %s %q %v; recorded entries:%s", level, msg, args, sb.String())
+	return false
+}
+
+// AssertNotLogged 断言不存在指定级别和消息的日志。
+func (h *Handler) AssertNotLogged(t testing.TB, level slog.Level, msg string) bool {
+	t.Helper()
+	for _, e := range h.Entries() {
+		if e.Level == level && e.Message == msg {
+			t.Errorf("logmtest: unexpected entry %s", e.String())
+			return false
+		}
+	}
+	return true
+}
+
+// add 添加属性
+func (e *Entry) add(key string, value slog.Value) {
+	if _, ok := e.Attrs[key]; !ok {
+		e.Keys = append(e.Keys, key)
+	}
+	e.Attrs[key] = value
+}
+
+// flatten 平铺属性，分组展开为 "." 连接的键
+func flatten(prefix string, a slog.Attr, fn func(key string, value slog.Value)) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			flatten(groupPrefix, ga, fn)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	fn(prefix+a.Key, v)
+}
+
+// 确保 Handler 实现 slog.Handler 接口
+var _ slog.Handler = (*Handler)(nil)
@@ -0,0 +1,84 @@
+package logmtest
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Records(t *testing.T) {
+	h := NewHandler()
+	logger := h.Logger().With("service", "api")
+
+	logger.Info("user signed up", "user_id", 42, slog.Group("req", "method", "POST"))
+	logger.WithGroup("db").Error("query failed", "table", "users")
+
+	entries := h.Entries()
+	require.Len(t, entries, 2)
+
+	e := entries[0]
+	assert.Equal(t, slog.LevelInfo, e.Level)
+	assert.Equal(t, "user signed up", e.Message)
+	assert.True(t, e.HasAttr("user_id", 42))
+	assert.True(t, e.HasAttr("user_id", int64(42)))
+	assert.True(t, e.HasAttr("service", "api"))
+	assert.True(t, e.HasAttr("req.method", "POST"))
+	assert.False(t, e.HasAttr("user_id", 43))
+	assert.Equal(t, []string{"service", "user_id", "req.method"}, e.Keys)
+
+	assert.True(t, entries[1].HasAttr("db.table", "users"))
+	// WithGroup 之前添加的属性不带分组前缀
+	assert.True(t, entries[1].HasAttr("service", "api"))
+}
+
+func TestEntries_Filters(t *testing.T) {
+	h := NewHandler()
+	logger := h.Logger()
+
+	logger.Debug("cache miss", "key", "a")
+	logger.Info("request", "status", 200)
+	logger.Error("request failed", "status", 500)
+
+	assert.Len(t, h.FilterLevel(slog.LevelError), 1)
+	assert.Equal(t, []string{"request", "request failed"}, h.Entries().FilterMessage("request").Messages())
+	assert.True(t, h.HasAttr("status", 500))
+	assert.Len(t, h.Entries().FilterLevel(slog.LevelInfo).FilterAttr("status", 200), 1)
+
+	h.Reset()
+	assert.Equal(t, 0, h.Len())
+}
+
+func TestHandler_AssertLogged(t *testing.T) {
+	h := NewHandler()
+	h.Logger().Info("user signed up", "user_id", 42, "plan", "pro")
+
+	assert.True(t, h.AssertLogged(t, slog.LevelInfo, "user signed up", "user_id", 42))
+	assert.True(t, h.AssertNotLogged(t, slog.LevelError, "user signed up"))
+
+	mock := &testing.T{}
+	assert.False(t, h.AssertLogged(mock, slog.LevelInfo, "user signed up", "user_id", 7))
+	assert.False(t, h.AssertLogged(mock, slog.LevelWarn, "user signed up"))
+}
+
+func TestHandler_Level(t *testing.T) {
+	h := NewHandlerWithLevel(slog.LevelWarn)
+	logger := h.Logger()
+
+	logger.Info("ignored")
+	logger.Warn("kept")
+
+	assert.Equal(t, []string{"kept"}, h.Entries().Messages())
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+}
+
+func TestSetDefault(t *testing.T) {
+	h := SetDefault(t)
+
+	slog.Info("via default", "k", "v")
+
+	h.AssertLogged(t, slog.LevelInfo, "via default", "k", "v")
+	require.NotNil(t, h.Entries()[0].Source)
+}
@@ -0,0 +1,222 @@
+package logm
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LoggerKey 命名 logger 附加的名称属性键
+const LoggerKey = "logger"
+
+// namedLevels 按名称配置的日志级别
+var namedLevels = &levelRegistry{levels: make(map[string]slog.Level)}
+
+// levelRegistry 名称到级别的映射，gen 在每次修改时递增，用于使缓存失效
+type levelRegistry struct {
+	mu     sync.RWMutex
+	levels map[string]slog.Level
+	gen    atomic.Uint64
+}
+
+// lookup 返回 name 或其最近的上级名称配置的级别
+func (r *levelRegistry) lookup(name string) (slog.Level, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for {
+		if lvl, ok := r.levels[name]; ok {
+			return lvl, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
+
+// SetNamedLevel 设置命名 logger 的日志级别，对该名称及其下级名称生效。
+//
+// 名称以 "." 分隔层级，下级未单独配置时沿用最近上级的级别，都未配置时使用全局级别：
+//
+//	logm.SetNamedLevel("server", "WARN")       // server、server.http、server.grpc
+//	logm.SetNamedLevel("server.http", "DEBUG") // 仅 server.http 及其下级
+//
+// 命名级别可以低于全局级别，用于单独打开某个模块的调试日志。
+func SetNamedLevel(name, level string) {
+	namedLevels.mu.Lock()
+	namedLevels.levels[name] = ParseLevel(level)
+	namedLevels.mu.Unlock()
+	namedLevels.gen.Add(1)
+}
+
+// ResetNamedLevel 移除名称的级别配置，恢复沿用上级或全局级别。
+func ResetNamedLevel(name string) {
+	namedLevels.mu.Lock()
+	delete(namedLevels.levels, name)
+	namedLevels.mu.Unlock()
+	namedLevels.gen.Add(1)
+}
+
+// NamedLevels 返回所有已配置的命名级别。
+func NamedLevels() map[string]string {
+	namedLevels.mu.RLock()
+	defer namedLevels.mu.RUnlock()
+	out := make(map[string]string, len(namedLevels.levels))
+	for name, lvl := range namedLevels.levels {
+		out[name] = LevelString(lvl)
+	}
+	return out
+}
+
+// Named 返回带名称的 logger。
+//
+// 日志写入全局 logger（slog.Default），自动附加 logger=<name> 属性，
+// 级别由 SetNamedLevel 按名称控制。可以在 Init 之前创建，如作为包级变量，
+// 之后 Init 重新配置全局日志系统时自动跟随：
+//
+//	var log = logm.Named("server.http")
+//
+//	func handle() {
+//	    log.Debug("request", "path", path) // logger=server.http
+//	}
+func Named(name string) *slog.Logger {
+	return slog.New(&lazyHandler{
+		name:    name,
+		resolve: defaultHandler,
+		level:   &atomic.Pointer[namedLevel]{},
+		base:    &atomic.Pointer[namedBase]{},
+	})
+}
+
+// stderrHandler slog.Default 指向 lazyHandler 且全局日志系统未初始化时的输出
+var stderrHandler slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: globalLevelVar})
+
+// defaultHandler 返回 slog.Default 的 Handler。
+//
+// slog.SetDefault(logm.Named("app")) 后 slog.Default 的 Handler 就是 lazyHandler，
+// 直接委托会无限递归，此时改用全局 Handler，未初始化时输出到 stderr。
+func defaultHandler() slog.Handler {
+	h := slog.Default().Handler()
+	if _, ok := h.(*lazyHandler); !ok {
+		return h
+	}
+	globalMu.RLock()
+	g := globalHandler
+	globalMu.RUnlock()
+	if g != nil {
+		return g
+	}
+	return stderrHandler
+}
+
+// namedLevel 缓存的命名级别
+type namedLevel struct {
+	gen   uint64
+	level slog.Level
+	ok    bool
+}
+
+// namedBase 缓存的派生 Handler，base 变化时重建
+type namedBase struct {
+	base    slog.Handler
+	handler slog.Handler
+}
+
+// namedOp 在 base 上重放的 WithAttrs 或 WithGroup 调用
+type namedOp struct {
+	attrs []slog.Attr
+	group string
+}
+
+// lazyHandler 每次写入时委托给 resolve 返回的当前 Handler，目标被替换后自动跟随。
+//
+// name 非空时附加 logger 属性并按名称控制级别。
+type lazyHandler struct {
+	name    string
+	resolve func() slog.Handler
+	ops     []namedOp
+	level   *atomic.Pointer[namedLevel] // 同名 Handler 共享
+	base    *atomic.Pointer[namedBase]
+}
+
+// Enabled 实现 slog.Handler，优先使用命名级别。
+func (h *lazyHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if lvl, ok := h.namedLevel(); ok {
+		return level >= lvl
+	}
+	return h.handler().Enabled(ctx, level)
+}
+
+// Handle 实现 slog.Handler。
+func (h *lazyHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler().Handle(ctx, r)
+}
+
+// WithAttrs 实现 slog.Handler。
+func (h *lazyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.derive(namedOp{attrs: attrs})
+}
+
+// WithGroup 实现 slog.Handler。
+func (h *lazyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.derive(namedOp{group: name})
+}
+
+// derive 返回追加 op 后的 Handler
+func (h *lazyHandler) derive(op namedOp) *lazyHandler {
+	ops := make([]namedOp, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &lazyHandler{
+		name:    h.name,
+		resolve: h.resolve,
+		ops:     append(ops, op),
+		level:   h.level,
+		base:    &atomic.Pointer[namedBase]{},
+	}
+}
+
+// namedLevel 返回缓存的命名级别，配置变化后重新查找
+func (h *lazyHandler) namedLevel() (slog.Level, bool) {
+	if h.name == "" {
+		return 0, false
+	}
+	gen := namedLevels.gen.Load()
+	if c := h.level.Load(); c != nil && c.gen == gen {
+		return c.level, c.ok
+	}
+	lvl, ok := namedLevels.lookup(h.name)
+	h.level.Store(&namedLevel{gen: gen, level: lvl, ok: ok})
+	return lvl, ok
+}
+
+// handler 返回基于当前目标 Handler 派生的 Handler
+func (h *lazyHandler) handler() slog.Handler {
+	base := h.resolve()
+	if c := h.base.Load(); c != nil && c.base == base {
+		return c.handler
+	}
+
+	d := base
+	if h.name != "" {
+		d = d.WithAttrs([]slog.Attr{slog.String(LoggerKey, h.name)})
+	}
+	for _, op := range h.ops {
+		if op.group != "" {
+			d = d.WithGroup(op.group)
+		} else {
+			d = d.WithAttrs(op.attrs)
+		}
+	}
+	h.base.Store(&namedBase{base: base, handler: d})
+	return d
+}
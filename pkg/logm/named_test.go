@@ -0,0 +1,91 @@
+package logm
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamed_FollowsInit(t *testing.T) {
+	// 在 Init 之前创建
+	log := Named("server.http").With("route", "/users")
+
+	var buf bytes.Buffer
+	require.NoError(t, Init(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &buf})))
+	defer func() { _ = Close() }()
+
+	log.Info("request")
+	assert.Contains(t, buf.String(), "logger=server.http route=/users")
+
+	// 重新初始化后写入新的 Handler
+	var buf2 bytes.Buffer
+	require.NoError(t, Init(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &buf2})))
+	log.Info("again")
+	assert.Contains(t, buf2.String(), "logger=server.http")
+}
+
+func TestNamed_LevelHierarchy(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Init(WithLevel("INFO"), WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &buf})))
+	defer func() { _ = Close() }()
+	defer ResetNamedLevel("server")
+	defer ResetNamedLevel("server.http")
+
+	http := Named("server.http")
+	grpc := Named("server.grpc")
+	db := Named("db")
+
+	SetNamedLevel("server", "WARN")
+	SetNamedLevel("server.http", "DEBUG")
+	assert.Equal(t, map[string]string{"server": "WARN", "server.http": "DEBUG"}, NamedLevels())
+
+	http.Debug("http debug")
+	grpc.Info("grpc info")
+	grpc.Warn("grpc warn")
+	db.Debug("db debug")
+	db.Info("db info")
+
+	out := buf.String()
+	assert.Contains(t, out, "http debug")
+	assert.NotContains(t, out, "grpc info")
+	assert.Contains(t, out, "grpc warn")
+	assert.NotContains(t, out, "db debug")
+	assert.Contains(t, out, "db info")
+
+	// 移除后沿用上级级别
+	ResetNamedLevel("server.http")
+	buf.Reset()
+	http.Info("http info")
+	assert.Empty(t, buf.String())
+	assert.False(t, http.Enabled(t.Context(), slog.LevelInfo))
+}
+
+func TestNamed_SetAsDefault(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	var buf bytes.Buffer
+	require.NoError(t, Init(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &buf})))
+	defer func() { _ = Close() }()
+
+	// slog.Default 指向 Named 自身时写入全局 Handler，而不是无限递归
+	slog.SetDefault(Named("app"))
+	slog.Info("hi")
+	Get("missing").Info("via pipeline fallback")
+	assert.Contains(t, buf.String(), "msg=hi logger=app")
+	assert.Contains(t, buf.String(), `msg="via pipeline fallback"`)
+}
+
+func TestNamed_SetAsDefault_Uninitialized(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+	require.NoError(t, Close())
+
+	slog.SetDefault(Named("app"))
+	assert.Equal(t, stderrHandler, defaultHandler())
+	assert.NotPanics(t, func() { slog.Debug("dropped below the global level") })
+}
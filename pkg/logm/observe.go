@@ -0,0 +1,89 @@
+package logm
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ObserverFunc 接收日志记录的观察者。
+//
+// r 中的 Attrs 和 Groups 与后续格式化共享，观察者不得修改，
+// 需要在回调返回后继续使用时应自行复制。
+type ObserverFunc func(r Record)
+
+// observerSet 观察者集合，写时复制，Handle 路径上只有一次原子读取
+type observerSet struct {
+	mu   sync.Mutex
+	list atomic.Pointer[[]*observer]
+}
+
+// observer 包装观察者，以指针身份区分重复注册的同一函数
+type observer struct {
+	fn ObserverFunc
+}
+
+// globalObservers 全局观察者，Init 创建的 Handler 共享，重新初始化后仍然有效
+var globalObservers = &observerSet{}
+
+// add 注册观察者，返回取消函数
+func (s *observerSet) add(fn ObserverFunc) (cancel func()) {
+	o := &observer{fn: fn}
+
+	s.mu.Lock()
+	list := s.load()
+	next := append(slices.Clip(list), o)
+	s.list.Store(&next)
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { s.remove(o) })
+	}
+}
+
+// remove 移除观察者
+func (s *observerSet) remove(o *observer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := slices.DeleteFunc(slices.Clone(s.load()), func(x *observer) bool { return x == o })
+	s.list.Store(&next)
+}
+
+// load 返回当前观察者快照
+func (s *observerSet) load() []*observer {
+	if p := s.list.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// notify 依次通知所有观察者
+func (s *observerSet) notify(r *Record) {
+	for _, o := range s.load() {
+		o.fn(*r)
+	}
+}
+
+// Observe 注册观察者，接收该 Handler 及其派生 Handler 的每条日志。
+//
+// 观察者在拦截器之后、格式化之前同步调用，看到的是最终写出的记录内容，
+// 无需添加 Writer 再解析格式化后的字节。回调在日志调用方的协程中执行，
+// 应尽快返回；耗时处理请转发到 channel 异步完成。返回的 cancel 用于取消注册。
+func (h *Handler) Observe(fn ObserverFunc) (cancel func()) {
+	return h.observers.add(fn)
+}
+
+// Observe 注册全局观察者，接收全局 logger 输出的每条日志。
+//
+// 适合构建应用内日志查看器或在测试中断言日志，重新调用 Init 后仍然有效：
+//
+//	cancel := logm.Observe(func(r logm.Record) {
+//	    if r.Level >= slog.LevelError {
+//	        alerts <- r.Message
+//	    }
+//	})
+//	defer cancel()
+func Observe(fn ObserverFunc) (cancel func()) {
+	return globalObservers.add(fn)
+}
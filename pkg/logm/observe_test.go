@@ -0,0 +1,99 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Observe(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&HandlerConfig{
+		Formatter: formatter.JSON(),
+		Writers:   []Writer{&testWriter{buf: &buf}},
+		Interceptors: []Interceptor{
+			func(_ context.Context, r *Record) *Record {
+				if r.Message == "skip" {
+					return nil
+				}
+				r.Attrs = append(r.Attrs, slog.String("added", "yes"))
+				return r
+			},
+		},
+	})
+
+	var got []Record
+	cancel := h.Observe(func(r Record) { got = append(got, r) })
+
+	logger := slog.New(h).With("svc", "api").WithGroup("req")
+	logger.Info("hello", "id", 1)
+	logger.Info("skip")
+	logger.Debug("below level")
+
+	require.Len(t, got, 1)
+	assert.Equal(t, "hello", got[0].Message)
+	assert.Equal(t, []string{"req"}, got[0].Groups)
+
+	keys := make([]string, 0, len(got[0].Attrs))
+	for _, a := range got[0].Attrs {
+		keys = append(keys, a.Key)
+	}
+	// 观察者看到拦截器修改后的记录
+	assert.Equal(t, []string{"svc", "id", "added"}, keys)
+
+	cancel()
+	cancel() // 重复取消无副作用
+	logger.Info("after cancel")
+	assert.Len(t, got, 1)
+}
+
+func TestHandler_Observe_Multiple(t *testing.T) {
+	h := NewHandler(&HandlerConfig{Formatter: formatter.Text()})
+
+	var a, b int
+	cancelA := h.Observe(func(Record) { a++ })
+	h.Observe(func(Record) { b++ })
+
+	slog.New(h).Info("one")
+	cancelA()
+	slog.New(h).Info("two")
+
+	assert.Equal(t, 1, a)
+	assert.Equal(t, 2, b)
+}
+
+func TestObserve_Global(t *testing.T) {
+	t.Cleanup(func() { _ = Close() })
+
+	var (
+		mu   sync.Mutex
+		msgs []string
+	)
+	cancel := Observe(func(r Record) {
+		mu.Lock()
+		msgs = append(msgs, r.Message)
+		mu.Unlock()
+	})
+	defer cancel()
+
+	var buf bytes.Buffer
+	require.NoError(t, Init(WithWriter(&testWriter{buf: &buf})))
+	Info("first")
+
+	// 重新初始化后观察者仍然有效
+	require.NoError(t, Init(WithWriter(&testWriter{buf: &buf})))
+	slog.Warn("second")
+
+	// 独立 logger 不会通知全局观察者
+	New(WithWriter(&testWriter{buf: &buf})).Info("independent")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"first", "second"}, msgs)
+}
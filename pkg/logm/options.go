@@ -7,6 +7,9 @@ import (
 	"time"
 )
 
+// DefaultTimezone 未设置 WithTimezone 时使用的时区
+const DefaultTimezone = "Asia/Shanghai"
+
 // Option 配置选项函数
 type Option func(*options)
 
@@ -20,8 +23,22 @@ type options struct {
 	timeFormat string
 	timezone   string
 	location   *time.Location
+	precision  TimePrecision
 
 	interceptors []Interceptor
+	defaultAttrs []slog.Attr
+
+	maxRecordSize  int
+	oversizePolicy OversizePolicy
+	onWriteError   WriteErrorFunc
+	clock          func() time.Time
+	callerSkip     int
+	callerSkipPkgs []string
+	duplicateKeys  DuplicateKeyPolicy
+	maxAttrs       int
+	sanitize       bool
+	msgTemplate    bool
+	flags          *FlagConfig
 }
 
 // defaultOptions 返回默认配置
@@ -30,7 +47,7 @@ func defaultOptions() *options {
 		level:      "INFO",
 		addSource:  false,
 		timeFormat: "datetime",
-		timezone:   "Asia/Shanghai",
+		timezone:   DefaultTimezone,
 	}
 }
 
@@ -122,6 +139,8 @@ func WithAddSource(enable bool) Option {
 //   - "datetime": 2006-01-02 15:04:05
 //   - "rfc3339": RFC3339 格式
 //   - "rfc3339ms": RFC3339 带毫秒
+//   - "unix", "unixms", "unixnano": Unix 时间戳（秒、毫秒、纳秒）
+//   - "unixfloat": Unix 时间戳（秒，6 位小数）
 //   - 自定义: Go time 格式字符串
 func WithTimeFormat(format string) Option {
 	return func(o *options) {
@@ -129,6 +148,16 @@ func WithTimeFormat(format string) Option {
 	}
 }
 
+// WithTimePrecision 设置时间的秒以下精度，统一作用于记录时间和时间属性。
+//
+// 与 WithTimeFormat 相同，仅作用于未通过 WithFormatter 指定时的默认格式化器，
+// 自定义格式化器使用 formatter.WithTimePrecision。
+func WithTimePrecision(p TimePrecision) Option {
+	return func(o *options) {
+		o.precision = p
+	}
+}
+
 // WithTimezone 设置时区。
 //
 // 支持 IANA 时区名称（如 "Asia/Shanghai"）或固定偏移（如 "+08:00"）
@@ -147,6 +176,154 @@ func WithInterceptor(i Interceptor) Option {
 	}
 }
 
+// WithDefaultAttrs 设置附加到每条日志的默认属性，如服务名和环境。
+//
+// 属性由 Handler 本身添加，即使之后 slog.SetDefault 被替换为基于同一 Handler 的 logger 也不会丢失，
+// 效果相当于在根 logger 上调用 With：
+//
+//	logm.Init(logm.WithDefaultAttrs(
+//	    slog.String("service", "billing"),
+//	    slog.String("env", "prod"),
+//	))
+//
+// New 创建的独立 logger 需要显式传入，可通过 DefaultAttrs 复用 Init 的配置：
+//
+//	log := logm.New(logm.WithDefaultAttrs(logm.DefaultAttrs()...), logm.WithOutput("stderr"))
+func WithDefaultAttrs(attrs ...slog.Attr) Option {
+	return func(o *options) {
+		o.defaultAttrs = append(o.defaultAttrs, attrs...)
+	}
+}
+
+// WithMaxRecordSize 设置单条日志编码后的最大字节数。
+//
+// 超长日志会阻塞 Writer 并破坏下游按行解析的工具，超出限制时按 policy 处理：
+//   - OversizeTruncate: 截断过长的字符串值，并添加 truncated_bytes 标记属性
+//   - OversizeDrop: 直接丢弃，可通过 Handler.OversizeDropped 查看丢弃数
+//
+// size <= 0 表示不限制（默认）。
+func WithMaxRecordSize(size int, policy OversizePolicy) Option {
+	return func(o *options) {
+		o.maxRecordSize = size
+		o.oversizePolicy = policy
+	}
+}
+
+// WithMaxAttrs 设置单条日志平铺后的最大属性数（分组展开后按叶子属性计数）。
+//
+// 超出部分被丢弃并折叠为一个 truncated_attrs=N 标记属性，
+// 防止异常的大负载展开成成千上万个字段。n <= 0 表示不限制（默认），建议值 128。
+func WithMaxAttrs(n int) Option {
+	return func(o *options) {
+		o.maxAttrs = n
+	}
+}
+
+// WithSanitize 清理日志消息、属性键和字符串值（包括 error 值）中的不可信内容：
+//   - 无效 UTF-8 替换为 U+FFFD
+//   - 去除 ANSI 转义序列（颜色、光标移动、OSC 标题等）
+//   - 去除制表符和换行以外的控制字符（包括回车）
+//
+// 防止用户输入中的转义序列篡改终端显示、伪造日志行或破坏下游解析器。
+// 换行保留，由各格式化器按自身规则转义。清理在拦截器之后、格式化之前进行。
+func WithSanitize() Option {
+	return func(o *options) {
+		o.sanitize = true
+	}
+}
+
+// WithMessageTemplate 将日志消息中的 {key} 占位符替换为同名属性的值，属性本身照常输出：
+//
+//	logm.Info("user {user_id} purchased {sku}", "user_id", 42, "sku", "X1")
+//	// msg="user 42 purchased X1" user_id=42 sku=X1
+//
+// 开发时阅读消息即可，日志系统仍按字段检索。支持分组路径 {req.id}，
+// 没有对应属性的占位符原样保留，{{ 和 }} 输出为字面花括号。
+// 替换在拦截器和去重之后、清理之前进行，拦截器看到的是原始模板。
+func WithMessageTemplate() Option {
+	return func(o *options) {
+		o.msgTemplate = true
+	}
+}
+
+// WithOnWriteError 设置 Writer 写入失败时的回调。
+//
+// 默认情况下写入失败会被静默跳过（仅计入 Stats）。
+// 回调在释放 Handler 内部锁后同步执行，可以在其中记录日志，
+// 但应避免长时间阻塞。
+//
+// 示例：
+//
+//	logm.WithOnWriteError(func(w logm.Writer, err error, record []byte) {
+//	    _, _ = os.Stderr.Write(record) // 回退到 stderr
+//	})
+func WithOnWriteError(fn WriteErrorFunc) Option {
+	return func(o *options) {
+		o.onWriteError = fn
+	}
+}
+
+// WithClock 设置日志时间来源。
+//
+// 设置后所有日志使用 clock 返回的时间，而非记录产生时的系统时间，
+// 便于测试和示例生成稳定的输出：
+//
+//	fixed := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+//	logm.Init(logm.WithClock(func() time.Time { return fixed }))
+func WithClock(clock func() time.Time) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithCallerSkip 设置记录源代码位置时额外向上跳过的栈帧数。
+//
+// 在自己的封装函数中调用 slog 时，源代码位置默认指向封装函数内部，
+// 跳过 n 层后指向封装函数的调用方：
+//
+//	func Audit(msg string, args ...any) { slog.Info(msg, args...) }
+//
+//	logm.Init(logm.WithAddSource(true), logm.WithCallerSkip(1))
+//	Audit("login") // source 指向调用 Audit 处
+//
+// 仅在启用 WithAddSource 时生效。
+func WithCallerSkip(n int) Option {
+	return func(o *options) {
+		o.callerSkip = n
+	}
+}
+
+// WithCallerSkipPackages 设置记录源代码位置时跳过的封装包。
+//
+// 跳过函数名包含 pkgs 中任意字符串的栈帧（规则同 CallerPC），
+// 封装函数嵌套层数不固定时比 WithCallerSkip 更可靠：
+//
+//	logm.Init(logm.WithAddSource(true), logm.WithCallerSkipPackages("github.com/acme/app/pkg/log."))
+//
+// 与 WithCallerSkip 同时使用时先跳过固定层数，再跳过封装包。仅在启用 WithAddSource 时生效。
+func WithCallerSkipPackages(pkgs ...string) Option {
+	return func(o *options) {
+		o.callerSkipPkgs = append(o.callerSkipPkgs, pkgs...)
+	}
+}
+
+// WithDuplicateKeys 设置同一层级出现重复键时的处理策略。
+//
+// 同一个键可能分别来自 WithAttrs、调用处和拦截器，默认全部保留（DuplicateKeepAll），
+// JSON 输出中会出现重复键，部分 JSON 解析器会报错或只取其一：
+//
+//	logger := slog.New(h).With("user", "alice")
+//	logger.Info("login", "user", "bob") // {"user":"alice","user":"bob"}
+//
+//	logm.Init(logm.WithDuplicateKeys(logm.DuplicateLastWins)) // {"user":"bob"}
+//
+// 策略在拦截器之后、格式化之前应用，分组内的键同样去重。
+func WithDuplicateKeys(policy DuplicateKeyPolicy) Option {
+	return func(o *options) {
+		o.duplicateKeys = policy
+	}
+}
+
 // stdWriter 包装标准输出
 type stdWriter struct {
 	w io.Writer
@@ -3,6 +3,7 @@ package logm
 import (
 	"os"
 	"strings"
+	"time"
 
 	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
 	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
@@ -26,7 +27,7 @@ func PresetDev() []Option {
 		WithWriter(writer.Stdout()),
 		WithAddSource(true),
 		WithTimeFormat("time"),
-		WithTimezone("Asia/Shanghai"),
+		WithTimezone(DefaultTimezone),
 	}
 }
 
@@ -50,6 +51,40 @@ func PresetProd() []Option {
 	}
 }
 
+// StableTime PresetStable 使用的固定时间（UTC）
+var StableTime = time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+
+// PresetStable 返回输出稳定的预设配置，用于 Example 测试和文档。
+//
+// 特点：
+//   - 文本格式输出到 stdout，无颜色
+//   - DEBUG 级别
+//   - 不显示源代码位置
+//   - 时间固定为 StableTime（UTC）
+//   - 属性按键名排序
+//
+// 输出只取决于日志内容，可以直接用 // Output: 验证：
+//
+//	func Example() {
+//	    _ = logm.Init(logm.PresetStable()...)
+//	    defer logm.Close()
+//	    logm.Info("hello", "user", "alice")
+//	    // Output: time=2024-01-15 10:30:45 level=INFO msg=hello user=alice
+//	}
+func PresetStable() []Option {
+	return []Option{
+		WithLevel("DEBUG"),
+		WithFormatter(formatter.Text(
+			formatter.WithTimezone("UTC"),
+			formatter.WithSortedKeys(),
+		)),
+		WithWriter(writer.Stdout()),
+		WithAddSource(false),
+		WithTimezone("UTC"),
+		WithClock(func() time.Time { return StableTime }),
+	}
+}
+
 // PresetAuto 自动检测环境并返回相应配置。
 //
 // 检测逻辑：
@@ -70,7 +105,7 @@ func PresetAuto() []Option {
 //   - LOGM_FORMAT: json, text, color_text, color_json
 //   - LOGM_OUTPUT: stdout, stderr, 或文件路径
 //   - LOGM_SOURCE: true, false
-//   - LOGM_TIME_FORMAT: time, datetime, rfc3339, rfc3339ms
+//   - LOGM_TIME_FORMAT: time, datetime, rfc3339, rfc3339ms, unix, unixms, unixnano, unixfloat
 func PresetFromEnv() []Option {
 	// 基础预设
 	var opts []Option
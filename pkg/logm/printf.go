@@ -0,0 +1,107 @@
+package logm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// Debugf 按 fmt.Sprintf 格式化消息并记录调试级别日志。
+//
+// 格式串消耗的参数之后的剩余参数作为结构化属性，便于逐步迁移 log.Printf 调用：
+//
+//	logm.Infof("user %s logged in", name)
+//	logm.Infof("user %s logged in", name, "ip", ip, logm.Dur("elapsed", d))
+func Debugf(format string, args ...any) {
+	logf(slog.LevelDebug, format, args)
+}
+
+// Infof 按 fmt.Sprintf 格式化消息并记录信息级别日志，规则见 Debugf。
+func Infof(format string, args ...any) {
+	logf(slog.LevelInfo, format, args)
+}
+
+// Warnf 按 fmt.Sprintf 格式化消息并记录警告级别日志，规则见 Debugf。
+func Warnf(format string, args ...any) {
+	logf(slog.LevelWarn, format, args)
+}
+
+// Errorf 按 fmt.Sprintf 格式化消息并记录错误级别日志，规则见 Debugf。
+//
+// 与 fmt.Errorf 不同，Errorf 不返回 error。
+func Errorf(format string, args ...any) {
+	logf(slog.LevelError, format, args)
+}
+
+// logf 格式化消息并以调用方位置记录日志
+func logf(level slog.Level, format string, args []any) {
+	ctx := context.Background()
+	logger := slog.Default()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	n := min(countFormatArgs(format), len(args))
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // 跳过 Callers、logf 和 Xxxf
+
+	r := slog.NewRecord(time.Now(), level, fmt.Sprintf(format, args[:n]...), pcs[0])
+	r.Add(args[n:]...)
+	_ = logger.Handler().Handle(ctx, r)
+}
+
+// countFormatArgs 返回格式串消耗的参数个数，支持 %% 转义、* 宽度/精度和 [n] 显式索引
+func countFormatArgs(format string) int {
+	argNum, maxArg := 0, 0
+	consume := func() {
+		argNum++
+		maxArg = max(maxArg, argNum)
+	}
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		// 标志
+		for i < len(format) && isFormatFlag(format[i]) {
+			i++
+		}
+		// 宽度、精度及参数索引
+	spec:
+		for ; i < len(format); i++ {
+			switch c := format[i]; {
+			case c == '[':
+				j, idx := i+1, 0
+				for j < len(format) && format[j] >= '0' && format[j] <= '9' {
+					idx = idx*10 + int(format[j]-'0')
+					j++
+				}
+				if j < len(format) && format[j] == ']' && idx > 0 {
+					argNum = idx - 1
+				}
+				i = j
+			case c == '*':
+				consume()
+			case c == '.' || (c >= '0' && c <= '9'):
+			default:
+				break spec
+			}
+		}
+		if i >= len(format) || format[i] == '%' {
+			continue
+		}
+		consume()
+	}
+	return maxArg
+}
+
+// isFormatFlag 判断是否为 fmt 标志字符
+func isFormatFlag(c byte) bool {
+	switch c {
+	case '+', '-', '#', ' ', '0':
+		return true
+	}
+	return false
+}
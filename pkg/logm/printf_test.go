@@ -0,0 +1,73 @@
+package logm
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountFormatArgs(t *testing.T) {
+	tests := []struct {
+		format string
+		want   int
+	}{
+		{"plain", 0},
+		{"100%%", 0},
+		{"%s %d", 2},
+		{"%-10s|%+.2f", 2},
+		{"%*d", 2},
+		{"%.*f", 2},
+		{"%[2]s %[1]s", 2},
+		{"%[1]s %[1]q", 1},
+		{"%[3]*.[2]*[1]f", 3},
+		{"trailing %", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			assert.Equal(t, tt.want, countFormatArgs(tt.format))
+		})
+	}
+}
+
+func TestPrintf(t *testing.T) {
+	var buf bytes.Buffer
+	levelVar := &slog.LevelVar{}
+	levelVar.Set(slog.LevelDebug)
+	h := NewHandler(&HandlerConfig{
+		LevelVar:  levelVar,
+		Formatter: formatter.JSON(),
+		Writers:   []Writer{&testWriter{buf: &buf}},
+		AddSource: true,
+	})
+	old := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	Infof("user %s logged in after %d tries", "alice", 3, "ip", "10.0.0.1")
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, "INFO", m["level"])
+	assert.Equal(t, "user alice logged in after 3 tries", m["msg"])
+	assert.Equal(t, "10.0.0.1", m["ip"])
+	assert.Contains(t, m["source"], "printf_test.go")
+
+	for _, fn := range []func(string, ...any){Debugf, Warnf, Errorf} {
+		buf.Reset()
+		fn("n=%d", 1, Dur("d", 0))
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+		assert.Equal(t, "n=1", m["msg"])
+		assert.Equal(t, "0s", m["d"])
+	}
+
+	// 级别过滤时不输出
+	levelVar.Set(slog.LevelError)
+	buf.Reset()
+	Infof("hidden %s", "x")
+	assert.Empty(t, buf.String())
+}
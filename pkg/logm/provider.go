@@ -0,0 +1,198 @@
+package logm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Provider 管理多个按名称区分、独立配置的日志管道。
+//
+// 每个管道拥有自己的 Formatter、Writer 和级别，适合将业务日志、审计日志、
+// 访问日志写入不同的目标：
+//
+//	p := logm.NewProvider(map[string][]logm.Option{
+//	    "audit":  {logm.WithFormatter(formatter.JSON()), logm.WithWriter(writer.File("audit.log"))},
+//	    "access": {logm.WithLevel("INFO"), logm.WithWriter(writer.File("access.log"))},
+//	})
+//	defer p.Close()
+//	p.Get("audit").Info("login", "user", "alice")
+//
+// 全局日志系统内置一个 Provider，通过 InitPipelines 和 Get 使用。
+type Provider struct {
+	mu        sync.RWMutex
+	pipelines map[string]*Handler
+}
+
+// NewProvider 按配置创建 Provider，key 为管道名称。
+func NewProvider(config map[string][]Option) *Provider {
+	p := &Provider{pipelines: make(map[string]*Handler, len(config))}
+	for name, opts := range config {
+		p.pipelines[name] = newHandler(opts...)
+	}
+	return p
+}
+
+// Set 创建或替换管道，替换时关闭旧管道的 Writer。
+func (p *Provider) Set(name string, opts ...Option) {
+	h := newHandler(opts...)
+	p.mu.Lock()
+	old := p.pipelines[name]
+	p.pipelines[name] = h
+	p.mu.Unlock()
+
+	if old != nil {
+		_ = old.Close()
+	}
+}
+
+// Remove 移除并关闭管道。
+func (p *Provider) Remove(name string) error {
+	p.mu.Lock()
+	h := p.pipelines[name]
+	delete(p.pipelines, name)
+	p.mu.Unlock()
+
+	if h == nil {
+		return nil
+	}
+	return h.Close()
+}
+
+// Get 返回管道的 logger。
+//
+// 返回的 logger 在每次写入时查找管道，管道之后被 Set 替换或注册时自动跟随；
+// 管道不存在时写入全局 logger（slog.Default），未配置独立管道的环境中也能正常运行。
+func (p *Provider) Get(name string) *slog.Logger {
+	return slog.New(&lazyHandler{
+		resolve: func() slog.Handler {
+			if h, ok := p.Handler(name); ok {
+				return h
+			}
+			return defaultHandler()
+		},
+		level: &atomic.Pointer[namedLevel]{},
+		base:  &atomic.Pointer[namedBase]{},
+	})
+}
+
+// Handler 返回管道的 Handler，可用于调整级别或查看统计。
+func (p *Provider) Handler(name string) (*Handler, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	h, ok := p.pipelines[name]
+	return h, ok
+}
+
+// Names 返回所有管道名称（已排序）。
+func (p *Provider) Names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := make([]string, 0, len(p.pipelines))
+	for name := range p.pipelines {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Sync 刷新所有管道。
+func (p *Provider) Sync() error {
+	return p.each(func(h *Handler) error { return h.Sync() })
+}
+
+// Rotate 轮转所有管道中的文件 Writer。
+func (p *Provider) Rotate() error {
+	return p.each(func(h *Handler) error { return h.Rotate() })
+}
+
+// Close 关闭并移除所有管道。
+func (p *Provider) Close() error {
+	p.mu.Lock()
+	pipelines := p.pipelines
+	p.pipelines = make(map[string]*Handler)
+	p.mu.Unlock()
+
+	var errs []error
+	for _, h := range pipelines {
+		errs = append(errs, h.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown 在 ctx 结束前关闭并移除所有管道，返回累计丢弃的日志数。
+func (p *Provider) Shutdown(ctx context.Context) (dropped uint64, err error) {
+	p.mu.Lock()
+	pipelines := p.pipelines
+	p.pipelines = make(map[string]*Handler)
+	p.mu.Unlock()
+
+	var errs []error
+	for _, h := range pipelines {
+		d, err := h.Shutdown(ctx)
+		dropped += d
+		errs = append(errs, err)
+	}
+	return dropped, errors.Join(errs...)
+}
+
+// each 对所有管道执行 fn
+func (p *Provider) each(fn func(h *Handler) error) error {
+	p.mu.RLock()
+	handlers := make([]*Handler, 0, len(p.pipelines))
+	for _, h := range p.pipelines {
+		handlers = append(handlers, h)
+	}
+	p.mu.RUnlock()
+
+	var errs []error
+	for _, h := range handlers {
+		errs = append(errs, fn(h))
+	}
+	return errors.Join(errs...)
+}
+
+// globalProvider 全局日志系统的管道
+var globalProvider = NewProvider(nil)
+
+// InitPipelines 按配置初始化全局管道，替换并关闭之前的所有管道。
+//
+// 管道与 Init 配置的全局 logger 相互独立，全局的 Close、Shutdown、Sync 和 Rotate 不作用于管道，
+// 通过 Pipelines() 返回的 Provider 单独刷新和关闭：
+//
+//	logm.MustInit(logm.PresetProd()...)
+//	logm.InitPipelines(map[string][]logm.Option{
+//	    "audit": {logm.WithFormatter(formatter.JSON()), logm.WithWriter(writer.File("audit.log"))},
+//	})
+//	logm.Get("audit").Info("login", "user", "alice")
+//	defer logm.Pipelines().Close()
+func InitPipelines(config map[string][]Option) error {
+	next := make(map[string]*Handler, len(config))
+	for name, opts := range config {
+		next[name] = newHandler(opts...)
+	}
+
+	globalProvider.mu.Lock()
+	old := globalProvider.pipelines
+	globalProvider.pipelines = next
+	globalProvider.mu.Unlock()
+
+	var errs []error
+	for _, h := range old {
+		errs = append(errs, h.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Get 返回全局管道的 logger，管道不存在时写入全局 logger。
+func Get(name string) *slog.Logger {
+	return globalProvider.Get(name)
+}
+
+// Pipelines 返回全局 Provider。
+func Pipelines() *Provider {
+	return globalProvider
+}
@@ -0,0 +1,68 @@
+package logm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvider_Pipelines(t *testing.T) {
+	var audit, access bytes.Buffer
+	p := NewProvider(map[string][]Option{
+		"audit":  {WithFormatter(formatter.JSON()), WithWriter(&testWriter{buf: &audit})},
+		"access": {WithLevel("WARN"), WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &access})},
+	})
+	defer func() { _ = p.Close() }()
+
+	assert.Equal(t, []string{"access", "audit"}, p.Names())
+
+	p.Get("audit").Info("login", "user", "alice")
+	p.Get("access").Info("GET /")
+	p.Get("access").Warn("slow request")
+
+	assert.Contains(t, audit.String(), `"msg":"login"`)
+	assert.NotContains(t, audit.String(), "slow request")
+	assert.NotContains(t, access.String(), "GET /")
+	assert.Contains(t, access.String(), "slow request")
+
+	h, ok := p.Handler("access")
+	require.True(t, ok)
+	h.SetLevel(ParseLevel("INFO"))
+	p.Get("access").Info("GET /health")
+	assert.Contains(t, access.String(), "GET /health")
+}
+
+func TestProvider_GetFollowsSet(t *testing.T) {
+	var global, first, second bytes.Buffer
+	require.NoError(t, Init(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &global})))
+	defer func() { _ = Close() }()
+
+	log := Get("audit").With("req", 1)
+
+	// 未配置时写入全局 logger
+	log.Info("before")
+	assert.Contains(t, global.String(), "before")
+
+	require.NoError(t, InitPipelines(map[string][]Option{
+		"audit": {WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &first})},
+	}))
+	log.Info("configured")
+	assert.Contains(t, first.String(), "configured req=1")
+
+	Pipelines().Set("audit", WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &second}))
+	log.Info("replaced")
+	assert.Contains(t, second.String(), "replaced")
+	assert.NotContains(t, first.String(), "replaced")
+
+	// 全局 Close 不关闭管道
+	require.NoError(t, Close())
+	assert.Equal(t, []string{"audit"}, Pipelines().Names())
+	Get("audit").Info("after close")
+	assert.Contains(t, second.String(), "after close")
+
+	require.NoError(t, Pipelines().Close())
+	assert.Empty(t, Pipelines().Names())
+}
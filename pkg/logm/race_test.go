@@ -0,0 +1,191 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unsafeWriter 故意不加锁的 Writer，依赖 Handler 串行化调用。
+//
+// 配合 -race 运行时，任何并发调用都会被检测出来。
+type unsafeWriter struct {
+	buf     bytes.Buffer
+	lines   int
+	closed  bool
+	closes  int
+	lateErr int // 关闭后仍收到的写入次数
+}
+
+func (w *unsafeWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		w.lateErr++
+	}
+	w.lines++
+	return w.buf.Write(p)
+}
+
+func (w *unsafeWriter) Close() error {
+	w.closed = true
+	w.closes++
+	return nil
+}
+
+func (w *unsafeWriter) Sync() error {
+	_ = w.buf.Len()
+	return nil
+}
+
+// stress 启动 n 个协程反复调用 fn，直到 stop 关闭
+func stress(wg *sync.WaitGroup, stop <-chan struct{}, n int, fn func(i int)) {
+	for g := range n {
+		wg.Go(func() {
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+					fn(g*1_000_000 + i)
+				}
+			}
+		})
+	}
+}
+
+func TestRace_HandlerConcurrentUse(t *testing.T) {
+	w := &unsafeWriter{}
+	h := NewHandler(&HandlerConfig{
+		Formatter: formatter.JSON(),
+		Writers:   []Writer{w},
+	})
+	root := slog.New(h)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	// 并发写入，每次都派生新的 logger
+	stress(&wg, stop, 8, func(i int) {
+		root.With("i", i).WithGroup("g").Info("msg", "k", strconv.Itoa(i))
+	})
+	// 共享同一个派生 logger
+	shared := root.With("shared", true)
+	stress(&wg, stop, 4, func(i int) {
+		shared.Warn("shared", "i", i)
+	})
+	// 动态调整级别
+	stress(&wg, stop, 2, func(i int) {
+		if i%2 == 0 {
+			h.SetLevel(slog.LevelDebug)
+		} else {
+			h.SetLevel(slog.LevelInfo)
+		}
+	})
+	// 并发读取统计和刷新
+	stress(&wg, stop, 2, func(int) {
+		_ = h.Stats()
+		_ = h.Status()
+		_ = h.Sync()
+	})
+
+	time.Sleep(100 * time.Millisecond)
+
+	// 写入进行中时从多个派生 Handler 同时关闭
+	var closeWG sync.WaitGroup
+	for _, hh := range []slog.Handler{h, root.With("a", 1).Handler(), shared.Handler()} {
+		closeWG.Go(func() { _ = hh.(*Handler).Close() })
+	}
+	closeWG.Wait()
+
+	time.Sleep(10 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	assert.Equal(t, 1, w.closes, "writer must be closed exactly once")
+	assert.Zero(t, w.lateErr, "no writes after close")
+	assert.Positive(t, w.lines)
+
+	s := h.Stats()
+	assert.Equal(t, uint64(w.lines), s.Records["INFO"]+s.Records["WARN"]) //nolint:gosec // G115: 测试数据非负
+	assert.Positive(t, s.Dropped, "records after close are counted as dropped")
+}
+
+func TestRace_HandlerShutdownWhileWriting(t *testing.T) {
+	w := &unsafeWriter{}
+	h := NewHandler(&HandlerConfig{
+		Formatter: formatter.Text(),
+		Writers:   []Writer{w},
+	})
+	logger := slog.New(h)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	stress(&wg, stop, 8, func(i int) {
+		logger.Info("msg", "i", i)
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := h.Shutdown(ctx)
+	require.NoError(t, err)
+
+	close(stop)
+	wg.Wait()
+
+	assert.Equal(t, 1, w.closes)
+	assert.Zero(t, w.lateErr)
+}
+
+func TestRace_GlobalReinit(t *testing.T) {
+	t.Cleanup(func() { _ = Close() })
+
+	var (
+		mu      sync.Mutex
+		writers []*unsafeWriter
+	)
+	newWriter := func() Writer {
+		w := &unsafeWriter{}
+		mu.Lock()
+		writers = append(writers, w)
+		mu.Unlock()
+		return w
+	}
+	require.NoError(t, Init(WithWriter(newWriter()), WithFormatter(formatter.JSON())))
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	// 全局便捷函数和 slog.Default 并发写入
+	stress(&wg, stop, 8, func(i int) {
+		Info("global", "i", i)
+		slog.Default().With("i", i).Warn("default")
+	})
+	// 运行时重新初始化全局配置
+	stress(&wg, stop, 2, func(i int) {
+		_ = Init(WithWriter(newWriter()), WithLevel([]string{"DEBUG", "INFO"}[i%2]))
+	})
+	// 动态调整级别并读取统计
+	stress(&wg, stop, 2, func(i int) {
+		SetLevel([]string{"DEBUG", "INFO", "WARN"}[i%3])
+		_ = Stats()
+		_ = Sync()
+	})
+
+	time.Sleep(200 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	require.NoError(t, Close())
+	for _, w := range writers {
+		assert.Equal(t, 1, w.closes, "every replaced writer must be closed exactly once")
+		assert.Zero(t, w.lateErr, "no writes after close")
+	}
+}
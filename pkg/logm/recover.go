@@ -0,0 +1,127 @@
+package logm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecoverOption RecoverAndLog 选项函数
+type RecoverOption func(*recoverOptions)
+
+// recoverOptions RecoverAndLog 内部配置
+type recoverOptions struct {
+	repanic    bool
+	stackDepth int
+}
+
+// WithRepanic 记录日志后重新抛出 panic。
+//
+// 适用于只想留下日志、仍希望进程按原有方式崩溃的场景。
+func WithRepanic() RecoverOption {
+	return func(o *recoverOptions) {
+		o.repanic = true
+	}
+}
+
+// WithStackDepth 设置记录的最大栈帧数（默认 32）。
+func WithStackDepth(depth int) RecoverOption {
+	return func(o *recoverOptions) {
+		o.stackDepth = depth
+	}
+}
+
+// RecoverAndLog 捕获 panic 并通过 context 中的 logger 记录 ERROR 日志。
+//
+// 必须直接以 defer 调用。日志包含 panic 值（"panic"）和裁剪后的调用栈（"stack"），
+// 调用栈从 panic 发生处开始，不含 runtime 和 logm 自身的栈帧；
+// 启用 AddSource 时 source 指向 panic 发生的位置。
+//
+// 示例：
+//
+//	go func() {
+//	    defer logm.RecoverAndLog(ctx, "worker crashed")
+//	    work()
+//	}()
+func RecoverAndLog(ctx context.Context, msg string, opts ...RecoverOption) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	o := &recoverOptions{stackDepth: 32}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	pc, stack := panicStack(o.stackDepth)
+
+	logger := FromContext(ctx)
+	if logger.Enabled(ctx, slog.LevelError) {
+		r := slog.NewRecord(time.Now(), slog.LevelError, msg, pc)
+		r.AddAttrs(slog.String("panic", fmt.Sprint(v)))
+		if err, ok := v.(error); ok {
+			r.AddAttrs(slog.Any("error", err))
+		}
+		r.AddAttrs(slog.String("stack", stack))
+		_ = logger.Handler().Handle(ctx, r)
+	}
+
+	if o.repanic {
+		panic(v)
+	}
+}
+
+// panicStack 返回 panic 发生处的 PC 和裁剪后的调用栈
+func panicStack(depth int) (uintptr, string) {
+	var pcs [64]uintptr
+	n := runtime.Callers(3, pcs[:]) // 跳过 runtime.Callers、panicStack、RecoverAndLog
+	frames := runtime.CallersFrames(pcs[:n])
+
+	// 跳过 panic 之前的 runtime 栈帧（gopanic 及 defer 调度）
+	var all []runtime.Frame
+	panicAt := -1
+	for {
+		f, more := frames.Next()
+		all = append(all, f)
+		if f.Function == "runtime.gopanic" {
+			panicAt = len(all)
+		}
+		if !more {
+			break
+		}
+	}
+	if panicAt >= 0 {
+		all = all[panicAt:]
+	}
+
+	var sb strings.Builder
+	var pc uintptr
+	count := 0
+	for _, f := range all {
+		if count >= depth {
+			break
+		}
+		// runtime 内部帧（如 panicIndex、goexit）没有诊断价值
+		if strings.HasPrefix(f.Function, "runtime.") {
+			continue
+		}
+		if pc == 0 {
+			pc = f.PC
+		}
+		if count > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(f.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(f.File)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(f.Line))
+		count++
+	}
+	return pc, sb.String()
+}
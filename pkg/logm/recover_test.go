@@ -0,0 +1,95 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverAndLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(
+		WithFormatter(formatter.JSON()),
+		WithWriter(&testWriter{buf: &buf}),
+		WithAddSource(true),
+	)
+	ctx := WithLogger(context.Background(), logger.With("worker", "w1"))
+
+	assert.NotPanics(t, func() {
+		defer RecoverAndLog(ctx, "worker crashed")
+		crash()
+	})
+
+	output := buf.String()
+	assert.Contains(t, output, `"msg":"worker crashed"`)
+	assert.Contains(t, output, `"level":"ERROR"`)
+	assert.Contains(t, output, `"panic":"boom"`)
+	assert.Contains(t, output, `"worker":"w1"`)
+	assert.Contains(t, output, `"source":"`)
+	assert.Contains(t, output, "recover_test.go")
+	assert.Contains(t, output, "logm.crash")
+	assert.NotContains(t, output, "runtime.gopanic")
+}
+
+func TestRecoverAndLog_Error(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), New(WithWriter(&testWriter{buf: &buf})))
+
+	func() {
+		defer RecoverAndLog(ctx, "crashed")
+		panic(errors.New("bad state"))
+	}()
+
+	assert.Contains(t, buf.String(), "error=\"bad state\"")
+}
+
+func TestRecoverAndLog_Repanic(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), slog.New(NewHandler(&HandlerConfig{
+		Formatter: formatter.Text(),
+		Writers:   []Writer{&testWriter{buf: &buf}},
+	})))
+
+	assert.PanicsWithValue(t, "boom", func() {
+		defer RecoverAndLog(ctx, "crashed", WithRepanic())
+		crash()
+	})
+	assert.Contains(t, buf.String(), "panic=boom")
+}
+
+func TestRecoverAndLog_NoPanic(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), New(WithWriter(&testWriter{buf: &buf})))
+
+	func() {
+		defer RecoverAndLog(ctx, "crashed")
+	}()
+
+	assert.Empty(t, buf.String())
+}
+
+func TestRecoverAndLog_StackDepth(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), New(
+		WithFormatter(formatter.JSON()),
+		WithWriter(&testWriter{buf: &buf}),
+	))
+
+	func() {
+		defer RecoverAndLog(ctx, "crashed", WithStackDepth(1))
+		crash()
+	}()
+
+	assert.Contains(t, buf.String(), `"stack":"github.com/lwmacct/251219-go-pkg-logm/pkg/logm.crash\n\t`)
+	assert.NotContains(t, buf.String(), "TestRecoverAndLog_StackDepth")
+}
+
+//go:noinline
+func crash() {
+	panic("boom")
+}
@@ -0,0 +1,69 @@
+package redact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+)
+
+// Dynamic 可在运行时原子替换的脱敏策略，用于接收远程下发的策略：
+//
+//	d := redact.NewDynamic(redact.MustNew(rules...))
+//	logm.Init(logm.WithInterceptor(d.Interceptor()))
+//	defer logm.StartRemoteWatch(w, logm.WithRemoteRedaction(d))()
+//
+// 替换对正在处理的日志没有影响，之后的日志使用新策略。
+type Dynamic struct {
+	p atomic.Pointer[Policy]
+}
+
+// NewDynamic 创建以 p 为初始策略的 Dynamic，p 为 nil 时不做脱敏。
+func NewDynamic(p *Policy) *Dynamic {
+	d := &Dynamic{}
+	d.p.Store(p)
+	return d
+}
+
+// Policy 返回当前策略，可能为 nil。
+func (d *Dynamic) Policy() *Policy {
+	return d.p.Load()
+}
+
+// Store 替换当前策略。
+func (d *Dynamic) Store(p *Policy) {
+	d.p.Store(p)
+}
+
+// Interceptor 返回应用当前策略的拦截器。
+func (d *Dynamic) Interceptor() logm.Interceptor {
+	return func(_ context.Context, r *logm.Record) *logm.Record {
+		if p := d.p.Load(); p != nil {
+			r.Attrs = p.Apply(r.Groups, r.Attrs)
+			r.Message = p.ApplyMessage(r.Message)
+		}
+		return r
+	}
+}
+
+// Prepare 实现 logm.RemotePolicy，按 Load 的格式编译策略，沿用当前策略的 Keyring。
+func (d *Dynamic) Prepare(raw json.RawMessage) (apply func(), err error) {
+	p, err := Load(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	if cur := d.p.Load(); cur != nil && cur.keyring != nil {
+		p = p.WithKeyring(cur.keyring)
+	}
+	return func() { d.p.Store(p) }, nil
+}
+
+// Snapshot 实现 logm.RemotePolicy。
+func (d *Dynamic) Snapshot() (restore func()) {
+	p := d.p.Load()
+	return func() { d.p.Store(p) }
+}
+
+var _ logm.RemotePolicy = (*Dynamic)(nil)
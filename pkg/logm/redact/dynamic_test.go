@@ -0,0 +1,58 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamic_Interceptor(t *testing.T) {
+	var buf bytes.Buffer
+	d := NewDynamic(nil)
+	logger := logm.New(
+		logm.WithFormatter(formatter.JSON()),
+		logm.WithWriter(&bufWriter{&buf}),
+		logm.WithInterceptor(d.Interceptor()),
+	)
+
+	logger.Info("a", "token", "t1")
+	d.Store(MustNew(Rule{Keys: []string{"token"}, Action: ActionMask}))
+	logger.Info("b", "token", "t2")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"token":"t1"`)
+	assert.Contains(t, lines[1], `"token":"***"`)
+}
+
+func TestDynamic_RemotePolicy(t *testing.T) {
+	kr, err := NewKeyring("k1", testKey1)
+	require.NoError(t, err)
+	local := MustNew(Rule{Keys: []string{"password"}, Action: ActionMask}).WithKeyring(kr)
+	d := NewDynamic(local)
+
+	_, err = d.Prepare(json.RawMessage(`{"rules":[{"keys":["x"],"action":"explode"}]}`))
+	require.Error(t, err)
+	_, err = d.Prepare(json.RawMessage(`{"rulez":[]}`))
+	require.Error(t, err)
+	assert.Same(t, local, d.Policy())
+
+	restore := d.Snapshot()
+	apply, err := d.Prepare(json.RawMessage(`{"rules":[{"keys":["user_id"],"action":"hmac"}]}`))
+	require.NoError(t, err)
+	assert.Same(t, local, d.Policy(), "Prepare must not switch the policy")
+
+	apply()
+	remote := d.Policy()
+	require.NotSame(t, local, remote)
+	assert.Same(t, kr, remote.keyring, "keyring is carried over")
+
+	restore()
+	assert.Same(t, local, d.Policy())
+}
@@ -0,0 +1,75 @@
+package redact
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// IP 匿名化保留的前缀长度，参考 GDPR 对访问日志的常见建议
+const (
+	ipv4PrefixLen = 24
+	ipv6PrefixLen = 48
+)
+
+// AnonymizeIP 匿名化 IP 地址，IPv4 保留 /24，IPv6 保留 /48。
+//
+// 支持以下形式，端口和 IPv6 zone 之外的格式保持不变：
+//
+//	192.168.1.77          -> 192.168.1.0
+//	192.168.1.77:8080     -> 192.168.1.0:8080
+//	[2001:db8:1:2::5]:443 -> [2001:db8:1::]:443
+//	10.0.0.1, 10.0.0.2    -> 10.0.0.0, 10.0.0.0（X-Forwarded-For 列表）
+//
+// 任意一项无法解析为 IP 时返回 false。
+func AnonymizeIP(s string) (string, bool) {
+	if strings.Contains(s, ",") {
+		parts := strings.Split(s, ",")
+		for i, part := range parts {
+			trimmed := strings.TrimSpace(part)
+			anon, ok := anonymizeAddr(trimmed)
+			if !ok {
+				return "", false
+			}
+			parts[i] = strings.Replace(part, trimmed, anon, 1)
+		}
+		return strings.Join(parts, ","), true
+	}
+	return anonymizeAddr(strings.TrimSpace(s))
+}
+
+// anonymizeAddr 匿名化单个地址，支持 host:port
+func anonymizeAddr(s string) (string, bool) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return truncateAddr(addr).String(), true
+	}
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return "", false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return "", false
+	}
+	return net.JoinHostPort(truncateAddr(addr).String(), port), true
+}
+
+// truncateAddr 截断地址，去掉 zone
+func truncateAddr(addr netip.Addr) netip.Addr {
+	addr = addr.WithZone("")
+	bits := ipv6PrefixLen
+	if addr.Is4() || addr.Is4In6() {
+		addr = addr.Unmap()
+		bits = ipv4PrefixLen
+	}
+	prefix, _ := addr.Prefix(bits)
+	return prefix.Addr()
+}
+
+// anonymizeOrMask 匿名化 IP，无法解析时返回掩码
+func anonymizeOrMask(r *Rule, s string) string {
+	if anon, ok := AnonymizeIP(s); ok {
+		return anon
+	}
+	return r.Mask
+}
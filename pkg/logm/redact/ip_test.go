@@ -0,0 +1,64 @@
+package redact
+
+import (
+	"log/slog"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymizeIP(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		"ipv4":        {"192.168.1.77", "192.168.1.0", true},
+		"ipv4 port":   {"192.168.1.77:8080", "192.168.1.0:8080", true},
+		"ipv6":        {"2001:db8:1:2::5", "2001:db8:1::", true},
+		"ipv6 port":   {"[2001:db8:1:2::5]:443", "[2001:db8:1::]:443", true},
+		"ipv6 zone":   {"fe80::1%eth0", "fe80::", true},
+		"mapped ipv4": {"::ffff:10.1.2.3", "10.1.2.0", true},
+		"list":        {"10.0.0.1, 203.0.113.9", "10.0.0.0, 203.0.113.0", true},
+		"hostname":    {"example.com:80", "", false},
+		"list bad":    {"10.0.0.1, unknown", "", false},
+		"empty":       {"", "", false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := AnonymizeIP(tt.in)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPolicy_IP(t *testing.T) {
+	p := MustNew(
+		Rule{Keys: []string{"client_ip", "remote_addr", "peer"}, Action: ActionIP},
+		Rule{Values: []string{`\b\d{1,3}(?:\.\d{1,3}){3}\b`}, Action: ActionIP, Message: true},
+	)
+
+	got := attrMap("", p.Apply(nil, []slog.Attr{
+		slog.String("client_ip", "198.51.100.23"),
+		slog.String("remote_addr", "[2001:db8:abcd:12::1]:52100"),
+		slog.Any("peer", net.ParseIP("203.0.113.200")),
+		slog.Any("addr", netip.MustParseAddr("10.9.8.7")),
+		slog.String("note", "from 172.16.5.4 via proxy"),
+		slog.String("client_ip_bad", "x"),
+	}), nil)
+
+	assert.Equal(t, "198.51.100.0", got["client_ip"])
+	assert.Equal(t, "[2001:db8:abcd::]:52100", got["remote_addr"])
+	assert.Equal(t, "203.0.113.0", got["peer"])
+	assert.Equal(t, "10.9.8.7", got["addr"], "未配置的键保持原值")
+	assert.Equal(t, "from 172.16.5.0 via proxy", got["note"])
+	assert.Equal(t, "x", got["client_ip_bad"])
+
+	got = attrMap("", p.Apply(nil, []slog.Attr{slog.String("client_ip", "unknown")}), nil)
+	assert.Equal(t, DefaultMask, got["client_ip"], "无法解析时输出掩码")
+
+	assert.Equal(t, "client 192.0.2.0 connected", p.ApplyMessage("client 192.0.2.44 connected"))
+}
@@ -0,0 +1,164 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// minKeyLen HMAC 密钥最小长度（字节）
+const minKeyLen = 16
+
+// Keyring HMAC 假名化使用的密钥环。
+//
+// 假名格式为 "hmac:<key id>:<摘要前 16 位十六进制>"，同一密钥下相同值得到相同假名，
+// 日志仍可按假名关联，但无法反推原值。轮换密钥后新日志使用新密钥，
+// 旧密钥保留在密钥环中，用 Pseudonyms 计算某个值在所有密钥下的假名即可跨轮换检索。
+//
+// Keyring 可以在多个 goroutine 中并发使用。
+type Keyring struct {
+	mu    sync.Mutex
+	state atomic.Pointer[keyringState]
+}
+
+// keyringState 密钥环快照，写时复制
+type keyringState struct {
+	current string
+	keys    map[string][]byte
+	order   []string // 按添加顺序排列的密钥 ID
+}
+
+// NewKeyring 创建密钥环，id 为当前密钥的标识。
+func NewKeyring(id string, key []byte) (*Keyring, error) {
+	kr := &Keyring{}
+	kr.state.Store(&keyringState{keys: map[string][]byte{}})
+	if err := kr.Rotate(id, key); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// KeyringFromEnv 从环境变量加载密钥环。
+//
+// 变量值格式为逗号分隔的 "id:base64密钥"，最后一个为当前密钥：
+//
+//	LOGM_HMAC_KEYS="2024q1:c2VjcmV0LWtleS0x...,2024q2:c2VjcmV0LWtleS0y..."
+func KeyringFromEnv(name string) (*Keyring, error) {
+	val := os.Getenv(name)
+	if val == "" {
+		return nil, fmt.Errorf("redact: environment variable %s is empty", name)
+	}
+
+	var kr *Keyring
+	for item := range strings.SplitSeq(val, ",") {
+		id, enc, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok {
+			return nil, fmt.Errorf("redact: %s: invalid key entry, want id:base64", name)
+		}
+		key, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("redact: %s: key %q: %w", name, id, err)
+		}
+		if kr == nil {
+			if kr, err = NewKeyring(id, key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := kr.Rotate(id, key); err != nil {
+			return nil, err
+		}
+	}
+	return kr, nil
+}
+
+// Rotate 添加密钥并设为当前密钥，之前的密钥仍保留用于 Pseudonyms。
+func (k *Keyring) Rotate(id string, key []byte) error {
+	if id == "" || strings.Contains(id, ":") {
+		return fmt.Errorf("redact: invalid key id %q", id)
+	}
+	if len(key) < minKeyLen {
+		return fmt.Errorf("redact: key %q too short: need at least %d bytes", id, minKeyLen)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	old := k.state.Load()
+	if _, ok := old.keys[id]; ok {
+		return fmt.Errorf("redact: key id %q already used", id)
+	}
+	next := &keyringState{
+		current: id,
+		keys:    make(map[string][]byte, len(old.keys)+1),
+		order:   make([]string, 0, len(old.order)+1),
+	}
+	for _, oid := range old.order {
+		next.keys[oid] = old.keys[oid]
+		next.order = append(next.order, oid)
+	}
+	next.keys[id] = append([]byte(nil), key...)
+	next.order = append(next.order, id)
+	k.state.Store(next)
+	return nil
+}
+
+// Retire 移除不再需要的旧密钥，不能移除当前密钥。
+func (k *Keyring) Retire(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	old := k.state.Load()
+	if id == old.current {
+		return errors.New("redact: cannot retire the current key")
+	}
+	if _, ok := old.keys[id]; !ok {
+		return fmt.Errorf("redact: unknown key id %q", id)
+	}
+	next := &keyringState{current: old.current, keys: make(map[string][]byte, len(old.keys)-1)}
+	for _, oid := range old.order {
+		if oid != id {
+			next.keys[oid] = old.keys[oid]
+			next.order = append(next.order, oid)
+		}
+	}
+	k.state.Store(next)
+	return nil
+}
+
+// Current 返回当前密钥的标识。
+func (k *Keyring) Current() string {
+	return k.state.Load().current
+}
+
+// Pseudonym 使用当前密钥计算 value 的假名。
+func (k *Keyring) Pseudonym(value string) string {
+	s := k.state.Load()
+	return pseudonym(s.current, s.keys[s.current], value)
+}
+
+// Pseudonyms 返回 value 在密钥环中所有密钥下的假名，按密钥添加顺序排列。
+//
+// 用于在跨越密钥轮换的日志中检索同一个标识。
+func (k *Keyring) Pseudonyms(value string) []string {
+	s := k.state.Load()
+	out := make([]string, 0, len(s.order))
+	for _, id := range s.order {
+		out = append(out, pseudonym(id, s.keys[id], value))
+	}
+	return out
+}
+
+// pseudonym 计算 "hmac:<id>:<hex>" 格式的假名
+func pseudonym(id string, key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return "hmac:" + id + ":" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
@@ -0,0 +1,121 @@
+package redact
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testKey1 = []byte("0123456789abcdef-key-one")
+	testKey2 = []byte("0123456789abcdef-key-two")
+)
+
+func TestKeyring_Pseudonym(t *testing.T) {
+	kr, err := NewKeyring("k1", testKey1)
+	require.NoError(t, err)
+
+	a := kr.Pseudonym("user-42")
+	assert.True(t, strings.HasPrefix(a, "hmac:k1:"))
+	assert.Len(t, a, len("hmac:k1:")+16)
+	assert.Equal(t, a, kr.Pseudonym("user-42"), "pseudonyms must be stable")
+	assert.NotEqual(t, a, kr.Pseudonym("user-43"))
+
+	other, err := NewKeyring("k1", testKey2)
+	require.NoError(t, err)
+	assert.NotEqual(t, a, other.Pseudonym("user-42"), "different keys give different pseudonyms")
+}
+
+func TestKeyring_TagHasher(t *testing.T) {
+	kr, err := NewKeyring("k1", testKey1)
+	require.NoError(t, err)
+	type account struct {
+		Email string `json:"email" logm:"hash"`
+	}
+
+	out, err := formatter.JSON(formatter.WithTagHasher(kr.Pseudonym)).Format(&formatter.Record{
+		Message: "login",
+		Attrs:   []slog.Attr{slog.Any("account", account{Email: "alice@example.com"})},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"account":{"email":"`+kr.Pseudonym("alice@example.com")+`"}`)
+	assert.NotContains(t, string(out), "alice@example.com")
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	kr, err := NewKeyring("k1", testKey1)
+	require.NoError(t, err)
+	before := kr.Pseudonym("alice@example.com")
+
+	require.NoError(t, kr.Rotate("k2", testKey2))
+	assert.Equal(t, "k2", kr.Current())
+
+	after := kr.Pseudonym("alice@example.com")
+	assert.True(t, strings.HasPrefix(after, "hmac:k2:"))
+	// 旧密钥仍可用于检索轮换前的日志
+	assert.Equal(t, []string{before, after}, kr.Pseudonyms("alice@example.com"))
+
+	assert.Error(t, kr.Rotate("k1", testKey2), "key ids cannot be reused")
+	assert.Error(t, kr.Retire("k2"), "current key cannot be retired")
+	require.NoError(t, kr.Retire("k1"))
+	assert.Equal(t, []string{after}, kr.Pseudonyms("alice@example.com"))
+	assert.Error(t, kr.Retire("k1"))
+}
+
+func TestKeyring_InvalidKeys(t *testing.T) {
+	_, err := NewKeyring("", testKey1)
+	assert.Error(t, err)
+	_, err = NewKeyring("a:b", testKey1)
+	assert.Error(t, err)
+	_, err = NewKeyring("k1", []byte("short"))
+	assert.Error(t, err)
+}
+
+func TestKeyringFromEnv(t *testing.T) {
+	enc := base64.StdEncoding.EncodeToString
+	t.Setenv("TEST_HMAC_KEYS", "k1:"+enc(testKey1)+", k2:"+enc(testKey2))
+
+	kr, err := KeyringFromEnv("TEST_HMAC_KEYS")
+	require.NoError(t, err)
+	assert.Equal(t, "k2", kr.Current())
+	assert.Len(t, kr.Pseudonyms("x"), 2)
+
+	t.Setenv("TEST_HMAC_KEYS", "")
+	_, err = KeyringFromEnv("TEST_HMAC_KEYS")
+	assert.Error(t, err)
+
+	t.Setenv("TEST_HMAC_KEYS", "no-separator")
+	_, err = KeyringFromEnv("TEST_HMAC_KEYS")
+	assert.Error(t, err)
+}
+
+func TestPolicy_HMAC(t *testing.T) {
+	kr, err := NewKeyring("k1", testKey1)
+	require.NoError(t, err)
+	p := MustNew(
+		Rule{Keys: []string{"user_id", "email"}, Action: ActionHMAC},
+		Rule{Values: []string{`[\w.]+@[\w.]+`}, Action: ActionHMAC},
+	)
+
+	// 未配置密钥时不泄露原值
+	got := attrMap("", p.Apply(nil, []slog.Attr{slog.Int("user_id", 42)}), nil)
+	assert.Equal(t, DefaultMask, got["user_id"])
+
+	p = p.WithKeyring(kr)
+	got = attrMap("", p.Apply(nil, []slog.Attr{
+		slog.Int("user_id", 42),
+		slog.String("note", "contact bob@example.com today"),
+	}), nil)
+	assert.Equal(t, kr.Pseudonym("42"), got["user_id"])
+	assert.Equal(t, "contact "+kr.Pseudonym("bob@example.com")+" today", got["note"])
+
+	// 轮换后的新日志使用新密钥
+	require.NoError(t, kr.Rotate("k2", testKey2))
+	got = attrMap("", p.Apply(nil, []slog.Attr{slog.Int("user_id", 42)}), nil)
+	assert.True(t, strings.HasPrefix(got["user_id"], "hmac:k2:"))
+}
@@ -0,0 +1,387 @@
+// Package redact 提供集中管理的日志脱敏策略。
+//
+// Policy 由一组有序规则组成，每条规则通过键名通配、属性路径选择器
+// 或值正则匹配属性，并执行 mask、hash 或 drop 动作。
+// 策略可以从 JSON 配置加载，作为 Interceptor 接入 logm：
+//
+//	policy, err := redact.LoadFile("/etc/app/redact.json")
+//	if err != nil {
+//	    return err
+//	}
+//	logm.Init(logm.WithInterceptor(policy.Interceptor()))
+//
+// 配置示例：
+//
+//	{
+//	  "rules": [
+//	    {"name": "credentials", "keys": ["*password*", "*secret*", "token"], "action": "mask"},
+//	    {"name": "auth-header", "paths": ["**.headers.authorization"], "action": "drop"},
+//	    {"name": "email", "paths": ["user.email"], "action": "hash"},
+//	    {"name": "user-id", "keys": ["user_id"], "action": "hmac"},
+//	    {"name": "client-ip", "keys": ["client_ip", "remote_addr"], "action": "ip"},
+//	    {"name": "card", "values": ["\\b\\d{4}(?:[ -]?\\d{4}){3}\\b"], "action": "mask", "message": true}
+//	  ]
+//	}
+//
+// Detector 不依赖配置，识别常见的密钥形态并计数，作为脱敏策略之外的兜底。
+package redact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+)
+
+// Action 脱敏动作
+type Action string
+
+const (
+	// ActionMask 替换为掩码；值正则规则只替换匹配的部分
+	ActionMask Action = "mask"
+	// ActionHash 替换为 SHA-256 摘要（前 16 位十六进制），相同值得到相同结果；值正则规则只替换匹配的部分
+	ActionHash Action = "hash"
+	// ActionDrop 删除整个属性；值正则规则删除匹配的部分
+	ActionDrop Action = "drop"
+	// ActionHMAC 替换为带密钥的 HMAC 假名，需通过 Policy.WithKeyring 配置密钥；
+	// 未配置密钥时输出掩码
+	ActionHMAC Action = "hmac"
+	// ActionIP 匿名化 IP 地址：IPv4 保留 /24，IPv6 保留 /48，端口保留；
+	// 无法解析为 IP 的值输出掩码
+	ActionIP Action = "ip"
+)
+
+// DefaultMask 默认掩码
+const DefaultMask = "***"
+
+// Rule 脱敏规则。
+//
+// Keys、Paths、Values 任一匹配即视为命中：
+//   - Keys: 属性键名通配（path.Match 语法，不区分大小写），匹配任意层级的键
+//   - Paths: 属性路径选择器，以 "." 分隔分组和键，"*" 匹配一段，"**" 匹配任意多段
+//   - Values: 字符串值正则，只作用于字符串和 error 值
+type Rule struct {
+	Name    string   `json:"name,omitempty"`
+	Keys    []string `json:"keys,omitempty"`
+	Paths   []string `json:"paths,omitempty"`
+	Values  []string `json:"values,omitempty"`
+	Action  Action   `json:"action"`
+	Mask    string   `json:"mask,omitempty"`    // 掩码文本，默认 DefaultMask
+	Message bool     `json:"message,omitempty"` // 值正则是否同时作用于日志消息
+
+	values []*regexp.Regexp
+	paths  [][]string
+}
+
+// Policy 脱敏策略，规则按顺序匹配，属性命中第一条规则后不再继续匹配。
+//
+// Policy 创建后只读，可以在多个 goroutine 中并发使用。
+type Policy struct {
+	rules   []*Rule
+	keyring *Keyring
+}
+
+// config 配置文件结构
+type config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// New 编译规则并创建策略。
+func New(rules ...Rule) (*Policy, error) {
+	p := &Policy{rules: make([]*Rule, 0, len(rules))}
+	for i := range rules {
+		r := rules[i]
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("redact: rule %d (%s): %w", i, r.Name, err)
+		}
+		p.rules = append(p.rules, &r)
+	}
+	return p, nil
+}
+
+// MustNew 与 New 相同，规则无效时 panic。
+func MustNew(rules ...Rule) *Policy {
+	p, err := New(rules...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Load 从 JSON 配置读取策略。
+func Load(r io.Reader) (*Policy, error) {
+	var cfg config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("redact: decode config: %w", err)
+	}
+	return New(cfg.Rules...)
+}
+
+// LoadFile 从 JSON 配置文件读取策略。
+func LoadFile(name string) (*Policy, error) {
+	f, err := os.Open(name) //nolint:gosec // G304: 配置文件路径由调用方指定
+	if err != nil {
+		return nil, fmt.Errorf("redact: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return Load(f)
+}
+
+// compile 校验并预编译规则
+func (r *Rule) compile() error {
+	switch r.Action {
+	case ActionMask, ActionHash, ActionDrop, ActionHMAC, ActionIP:
+	case "":
+		return errors.New("missing action")
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	if len(r.Keys) == 0 && len(r.Paths) == 0 && len(r.Values) == 0 {
+		return errors.New("rule matches nothing: set keys, paths or values")
+	}
+	if r.Mask == "" {
+		r.Mask = DefaultMask
+	}
+
+	r.Keys = slices.Clone(r.Keys)
+	for i, k := range r.Keys {
+		k = strings.ToLower(k)
+		if _, err := path.Match(k, ""); err != nil {
+			return fmt.Errorf("key %q: %w", k, err)
+		}
+		r.Keys[i] = k
+	}
+	for _, p := range r.Paths {
+		if p == "" {
+			return errors.New("empty path")
+		}
+		r.paths = append(r.paths, strings.Split(strings.ToLower(p), "."))
+	}
+	for _, v := range r.Values {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return fmt.Errorf("value %q: %w", v, err)
+		}
+		r.values = append(r.values, re)
+	}
+	return nil
+}
+
+// matchKey 判断属性键名或路径是否命中规则
+func (r *Rule) matchKey(key string, segs []string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.Keys {
+		if ok, _ := path.Match(k, key); ok {
+			return true
+		}
+	}
+	for _, sel := range r.paths {
+		if matchPath(sel, segs) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath 匹配路径选择器，"*" 匹配一段，"**" 匹配零或多段
+func matchPath(sel, segs []string) bool {
+	for len(sel) > 0 {
+		if sel[0] == "**" {
+			for i := len(segs); i >= 0; i-- {
+				if matchPath(sel[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(sel[0], strings.ToLower(segs[0])); !ok {
+			return false
+		}
+		sel, segs = sel[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// WithKeyring 返回使用 kr 计算 HMAC 假名的策略副本。
+//
+// 密钥不应写入策略配置文件，通常从密钥管理服务或环境变量加载。
+func (p *Policy) WithKeyring(kr *Keyring) *Policy {
+	cp := *p
+	cp.keyring = kr
+	return &cp
+}
+
+// Interceptor 返回应用该策略的拦截器。
+func (p *Policy) Interceptor() logm.Interceptor {
+	return func(_ context.Context, r *logm.Record) *logm.Record {
+		r.Attrs = p.Apply(r.Groups, r.Attrs)
+		r.Message = p.ApplyMessage(r.Message)
+		return r
+	}
+}
+
+// Apply 返回脱敏后的属性，不修改传入的切片。
+//
+// groups 为属性所在的分组路径，参与 Paths 匹配。
+func (p *Policy) Apply(groups []string, attrs []slog.Attr) []slog.Attr {
+	if len(p.rules) == 0 {
+		return attrs
+	}
+	segs := make([]string, len(groups), len(groups)+4)
+	copy(segs, groups)
+	return p.applyAttrs(segs, attrs)
+}
+
+// ApplyMessage 对日志消息应用设置了 Message 的值正则规则。
+func (p *Policy) ApplyMessage(msg string) string {
+	for _, r := range p.rules {
+		if r.Message {
+			msg, _ = p.replaceValue(r, msg)
+		}
+	}
+	return msg
+}
+
+// applyAttrs 递归处理属性
+func (p *Policy) applyAttrs(segs []string, attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if a, ok := p.applyAttr(segs, a); ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// applyAttr 处理单个属性，返回 false 表示删除
+func (p *Policy) applyAttr(segs []string, a slog.Attr) (slog.Attr, bool) {
+	v := a.Value.Resolve()
+	if a.Key == "" {
+		// 空键分组内联到当前层级
+		if v.Kind() == slog.KindGroup {
+			return slog.Attr{Value: slog.GroupValue(p.applyAttrs(segs, v.Group())...)}, true
+		}
+		return a, true
+	}
+
+	attrPath := append(segs, a.Key)
+	for _, r := range p.rules {
+		if r.matchKey(a.Key, attrPath) {
+			return p.applyWhole(r, a.Key, v)
+		}
+	}
+
+	switch v.Kind() {
+	case slog.KindGroup:
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(p.applyAttrs(attrPath, v.Group())...)}, true
+	case slog.KindString:
+		if s, changed := p.applyValue(v.String()); changed {
+			return slog.String(a.Key, s), true
+		}
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			if s, changed := p.applyValue(err.Error()); changed {
+				return slog.String(a.Key, s), true
+			}
+		}
+	default:
+	}
+	return slog.Attr{Key: a.Key, Value: v}, true
+}
+
+// applyValue 对字符串值应用第一条命中的值正则规则
+func (p *Policy) applyValue(s string) (string, bool) {
+	for _, r := range p.rules {
+		if red, matched := p.replaceValue(r, s); matched {
+			return red, true
+		}
+	}
+	return s, false
+}
+
+// replaceValue 按值正则替换字符串中匹配的部分
+func (p *Policy) replaceValue(r *Rule, s string) (string, bool) {
+	matched := false
+	for _, re := range r.values {
+		if !re.MatchString(s) {
+			continue
+		}
+		matched = true
+		switch r.Action {
+		case ActionMask:
+			s = re.ReplaceAllLiteralString(s, r.Mask)
+		case ActionDrop:
+			s = re.ReplaceAllLiteralString(s, "")
+		case ActionHash, ActionHMAC:
+			s = re.ReplaceAllStringFunc(s, func(m string) string { return p.digest(r, m) })
+		case ActionIP:
+			s = re.ReplaceAllStringFunc(s, func(m string) string { return anonymizeOrMask(r, m) })
+		}
+	}
+	return s, matched
+}
+
+// applyWhole 对整个属性值执行动作
+func (p *Policy) applyWhole(r *Rule, key string, v slog.Value) (slog.Attr, bool) {
+	switch r.Action {
+	case ActionDrop:
+		return slog.Attr{}, false
+	case ActionHash, ActionHMAC:
+		return slog.String(key, p.digest(r, valueString(v))), true
+	case ActionIP:
+		s := valueString(v)
+		if sv, ok := v.Any().(fmt.Stringer); ok && v.Kind() == slog.KindAny {
+			s = sv.String() // net.IP、netip.Addr 等
+		}
+		return slog.String(key, anonymizeOrMask(r, s)), true
+	default:
+		return slog.String(key, r.Mask), true
+	}
+}
+
+// digest 按规则动作计算值的摘要
+func (p *Policy) digest(r *Rule, s string) string {
+	if r.Action == ActionHMAC {
+		if p.keyring == nil {
+			// 未配置密钥时不输出原值
+			return r.Mask
+		}
+		return p.keyring.Pseudonym(s)
+	}
+	return hashString(s)
+}
+
+// valueString 返回用于摘要的值文本
+func valueString(v slog.Value) string {
+	if v.Kind() == slog.KindAny {
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		if data, err := json.Marshal(v.Any()); err == nil {
+			return string(data)
+		}
+	}
+	return v.String()
+}
+
+// hashString 返回 "sha256:" 加摘要前 16 位十六进制
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
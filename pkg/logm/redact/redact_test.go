@@ -0,0 +1,193 @@
+package redact
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// attrMap 将属性展开为 path -> 值文本
+func attrMap(prefix string, attrs []slog.Attr, m map[string]string) map[string]string {
+	if m == nil {
+		m = map[string]string{}
+	}
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		if v.Kind() == slog.KindGroup {
+			attrMap(prefix+a.Key+".", v.Group(), m)
+			continue
+		}
+		m[prefix+a.Key] = v.String()
+	}
+	return m
+}
+
+func TestPolicy_Keys(t *testing.T) {
+	p := MustNew(Rule{Keys: []string{"*password*", "Token"}, Action: ActionMask})
+
+	got := attrMap("", p.Apply(nil, []slog.Attr{
+		slog.String("user", "alice"),
+		slog.String("db_Password", "hunter2"),
+		slog.Group("auth", slog.String("token", "abc"), slog.Int("ttl", 60)),
+	}), nil)
+
+	assert.Equal(t, map[string]string{
+		"user":        "alice",
+		"db_Password": DefaultMask,
+		"auth.token":  DefaultMask,
+		"auth.ttl":    "60",
+	}, got)
+}
+
+func TestPolicy_Paths(t *testing.T) {
+	p := MustNew(
+		Rule{Paths: []string{"**.headers.authorization"}, Action: ActionDrop},
+		Rule{Paths: []string{"req.user.*"}, Action: ActionMask, Mask: "[redacted]"},
+	)
+
+	attrs := []slog.Attr{
+		slog.Group("headers", slog.String("Authorization", "Bearer x"), slog.String("accept", "*/*")),
+		slog.Group("user", slog.String("email", "a@b.c"), slog.Int("id", 7)),
+		slog.String("authorization", "top-level is not matched"),
+	}
+	// groups 参与路径匹配
+	got := attrMap("", p.Apply([]string{"req"}, attrs), nil)
+
+	assert.Equal(t, map[string]string{
+		"headers.accept": "*/*",
+		"user.email":     "[redacted]",
+		"user.id":        "[redacted]",
+		"authorization":  "top-level is not matched",
+	}, got)
+}
+
+func TestPolicy_Values(t *testing.T) {
+	p := MustNew(
+		Rule{Values: []string{`\b\d{4}(?:[ -]?\d{4}){3}\b`}, Action: ActionMask, Message: true},
+		Rule{Values: []string{`secret-\w+`}, Action: ActionDrop},
+	)
+
+	attrs := p.Apply(nil, []slog.Attr{
+		slog.String("note", "card 4111 1111 1111 1111 charged"),
+		slog.Any("error", errors.New("bad key secret-abc")),
+		slog.Int("amount", 42),
+	})
+	got := attrMap("", attrs, nil)
+
+	assert.Equal(t, "card *** charged", got["note"])
+	assert.Equal(t, "bad key ", got["error"])
+	assert.Equal(t, "42", got["amount"])
+	assert.Equal(t, "paid with ***", p.ApplyMessage("paid with 4111-1111-1111-1111"))
+}
+
+func TestPolicy_Hash(t *testing.T) {
+	p := MustNew(Rule{Keys: []string{"email"}, Action: ActionHash})
+
+	a := attrMap("", p.Apply(nil, []slog.Attr{slog.String("email", "alice@example.com")}), nil)
+	b := attrMap("", p.Apply(nil, []slog.Attr{slog.String("email", "alice@example.com")}), nil)
+	c := attrMap("", p.Apply(nil, []slog.Attr{slog.String("email", "bob@example.com")}), nil)
+
+	assert.True(t, strings.HasPrefix(a["email"], "sha256:"))
+	assert.Len(t, a["email"], len("sha256:")+16)
+	assert.Equal(t, a["email"], b["email"], "same value must hash to the same result")
+	assert.NotEqual(t, a["email"], c["email"])
+}
+
+func TestPolicy_FirstRuleWins(t *testing.T) {
+	p := MustNew(
+		Rule{Keys: []string{"password"}, Action: ActionDrop},
+		Rule{Keys: []string{"*"}, Action: ActionMask},
+	)
+	got := p.Apply(nil, []slog.Attr{slog.String("password", "x"), slog.String("user", "alice")})
+	assert.Equal(t, map[string]string{"user": DefaultMask}, attrMap("", got, nil))
+}
+
+func TestPolicy_DoesNotModifyInput(t *testing.T) {
+	p := MustNew(Rule{Keys: []string{"token"}, Action: ActionMask})
+	attrs := []slog.Attr{slog.Group("auth", slog.String("token", "abc"))}
+
+	_ = p.Apply(nil, attrs)
+
+	assert.Equal(t, "abc", attrs[0].Value.Group()[0].Value.String())
+}
+
+func TestNew_InvalidRules(t *testing.T) {
+	tests := map[string]Rule{
+		"missing action": {Keys: []string{"a"}},
+		"unknown action": {Keys: []string{"a"}, Action: "encrypt"},
+		"empty rule":     {Action: ActionMask},
+		"bad glob":       {Keys: []string{"[a"}, Action: ActionMask},
+		"bad regexp":     {Values: []string{"("}, Action: ActionMask},
+		"empty path":     {Paths: []string{""}, Action: ActionMask},
+	}
+	for name, r := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(r)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redact.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"rules": [
+			{"name": "credentials", "keys": ["*password*"], "action": "mask"},
+			{"name": "email", "paths": ["user.email"], "action": "hash"}
+		]
+	}`), 0o600))
+
+	p, err := LoadFile(path)
+	require.NoError(t, err)
+
+	got := attrMap("", p.Apply(nil, []slog.Attr{
+		slog.String("password", "x"),
+		slog.Group("user", slog.String("email", "a@b.c")),
+	}), nil)
+	assert.Equal(t, DefaultMask, got["password"])
+	assert.True(t, strings.HasPrefix(got["user.email"], "sha256:"))
+
+	_, err = Load(strings.NewReader(`{"rules":[{"keys":["a"],"action":"mask","unknown":1}]}`))
+	assert.Error(t, err, "unknown fields are rejected")
+
+	_, err = LoadFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestPolicy_Interceptor(t *testing.T) {
+	var buf bytes.Buffer
+	p := MustNew(
+		Rule{Keys: []string{"password"}, Action: ActionMask},
+		Rule{Values: []string{`\d{3}-\d{4}`}, Action: ActionMask, Message: true},
+	)
+
+	logger := logm.New(
+		logm.WithFormatter(formatter.JSON()),
+		logm.WithWriter(&bufWriter{&buf}),
+		logm.WithInterceptor(p.Interceptor()),
+	)
+	logger.With("password", "inherited").InfoContext(context.Background(), "call 555-1234", "phone", "555-9876")
+
+	out := buf.String()
+	assert.Contains(t, out, `"msg":"call ***"`)
+	assert.Contains(t, out, `"password":"***"`)
+	assert.Contains(t, out, `"phone":"***"`)
+	assert.NotContains(t, out, "inherited")
+}
+
+// bufWriter 输出到 bytes.Buffer 的 logm.Writer
+type bufWriter struct{ buf *bytes.Buffer }
+
+func (w *bufWriter) Write(p []byte) (int, error) { return w.buf.Write(p) }
+func (w *bufWriter) Close() error                { return nil }
+func (w *bufWriter) Sync() error                 { return nil }
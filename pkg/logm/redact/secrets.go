@@ -0,0 +1,212 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync/atomic"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// 内置的密钥类型
+const (
+	SecretAWSAccessKey = "aws_access_key"
+	SecretGitHubToken  = "github_token"
+	SecretJWT          = "jwt"
+	SecretPrivateKey   = "private_key"
+)
+
+// builtinSecrets 内置的密钥特征
+var builtinSecrets = []struct {
+	kind    string
+	pattern string
+}{
+	{SecretAWSAccessKey, `\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`},
+	{SecretGitHubToken, `\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`},
+	{SecretJWT, `\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`},
+	{SecretPrivateKey, `-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----(?:[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----)?`},
+}
+
+// secretPattern 已编译的密钥特征
+type secretPattern struct {
+	kind   string
+	re     *regexp.Regexp
+	leaked atomic.Uint64
+}
+
+// DetectorOption 密钥检测器选项
+type DetectorOption func(*Detector) error
+
+// WithSecretPattern 添加自定义密钥特征，kind 用于掩码和计数。
+func WithSecretPattern(kind, pattern string) DetectorOption {
+	return func(d *Detector) error {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("redact: secret pattern %q: %w", kind, err)
+		}
+		d.patterns = append(d.patterns, &secretPattern{kind: kind, re: re})
+		return nil
+	}
+}
+
+// WithOnLeak 设置发现密钥时的回调，可用于告警。
+//
+// 回调在记录日志的 goroutine 中同步执行，应尽快返回，且不能再记录会触发检测的日志。
+func WithOnLeak(fn func(kind string)) DetectorOption {
+	return func(d *Detector) error {
+		d.onLeak = fn
+		return nil
+	}
+}
+
+// Detector 密钥检测器。
+//
+// 识别日志消息和属性值中常见的密钥形态（AWS Access Key、GitHub Token、JWT、
+// PEM 私钥），将其替换为 "[REDACTED:<kind>]" 并计数。
+// 脱敏策略处理已知的敏感字段，Detector 作为兜底，发现意外泄漏的密钥；
+// 计数大于 0 意味着代码中存在需要修复的泄漏，应当告警：
+//
+//	d, err := redact.NewDetector(redact.WithOnLeak(func(kind string) {
+//	    leakCounter.WithLabelValues(kind).Inc()
+//	}))
+//	logm.Init(logm.WithInterceptor(d.Interceptor()))
+//
+// Detector 可以在多个 goroutine 中并发使用。
+type Detector struct {
+	patterns []*secretPattern
+	onLeak   func(kind string)
+	leaked   atomic.Uint64
+}
+
+// NewDetector 创建包含内置特征的密钥检测器。
+func NewDetector(opts ...DetectorOption) (*Detector, error) {
+	d := &Detector{}
+	for _, s := range builtinSecrets {
+		d.patterns = append(d.patterns, &secretPattern{kind: s.kind, re: regexp.MustCompile(s.pattern)})
+	}
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Interceptor 返回检测并替换密钥的拦截器。
+func (d *Detector) Interceptor() logm.Interceptor {
+	return func(_ context.Context, r *logm.Record) *logm.Record {
+		r.Message = d.Scrub(r.Message)
+		r.Attrs = d.ScrubAttrs(r.Attrs)
+		return r
+	}
+}
+
+// Scrub 替换 s 中的密钥。
+func (d *Detector) Scrub(s string) string {
+	for _, p := range d.patterns {
+		n := 0
+		s = p.re.ReplaceAllStringFunc(s, func(string) string {
+			n++
+			return "[REDACTED:" + p.kind + "]"
+		})
+		if n > 0 {
+			p.leaked.Add(uint64(n)) //nolint:gosec // G115: n > 0
+			d.leaked.Add(uint64(n)) //nolint:gosec // G115: n > 0
+			if d.onLeak != nil {
+				for range n {
+					d.onLeak(p.kind)
+				}
+			}
+		}
+	}
+	return s
+}
+
+// ScrubAttrs 返回替换密钥后的属性，不修改传入的切片。
+//
+// 处理字符串、error、[]byte 和 fmt.Stringer 值，递归处理分组。结构体、map 等其他值
+// 检测 formatter.MarshalJSON 的输出，logm:"omit"/"mask" 等标签隐藏的字段不会出现在其中；
+// 发现密钥时整个值替换为脱敏后的文本。未导出字段中的密钥不会被检测。
+func (d *Detector) ScrubAttrs(attrs []slog.Attr) []slog.Attr {
+	out, _ := d.scrubAttrs(attrs)
+	return out
+}
+
+// scrubAttrs 处理属性列表，没有修改时返回原切片
+func (d *Detector) scrubAttrs(attrs []slog.Attr) ([]slog.Attr, bool) {
+	var out []slog.Attr
+	for i, a := range attrs {
+		na, changed := d.scrubAttr(a)
+		if changed && out == nil {
+			out = make([]slog.Attr, i, len(attrs))
+			copy(out, attrs[:i])
+		}
+		if out != nil {
+			out = append(out, na)
+		}
+	}
+	if out == nil {
+		return attrs, false
+	}
+	return out, true
+}
+
+// scrubAttr 处理单个属性，返回是否有修改
+func (d *Detector) scrubAttr(a slog.Attr) (slog.Attr, bool) {
+	v := a.Value.Resolve()
+	var s string
+	switch v.Kind() {
+	case slog.KindString:
+		s = v.String()
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case nil:
+			return a, false
+		case error:
+			s = x.Error()
+		case []byte:
+			s = string(x)
+		case fmt.Stringer:
+			s = x.String()
+		default:
+			// 不使用 %v：它会输出 logm 标签隐藏的字段
+			data, err := formatter.MarshalJSON(x)
+			if err != nil {
+				return a, false
+			}
+			s = string(data)
+		}
+	case slog.KindGroup:
+		scrubbed, changed := d.scrubAttrs(v.Group())
+		if !changed {
+			return a, false
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(scrubbed...)}, true
+	default:
+		return a, false
+	}
+
+	if red := d.Scrub(s); red != s {
+		return slog.String(a.Key, red), true
+	}
+	return a, false
+}
+
+// Leaked 返回累计发现的密钥数量。
+func (d *Detector) Leaked() uint64 {
+	return d.leaked.Load()
+}
+
+// LeakedByKind 按类型返回累计发现的密钥数量，不含数量为 0 的类型。
+func (d *Detector) LeakedByKind() map[string]uint64 {
+	m := make(map[string]uint64)
+	for _, p := range d.patterns {
+		if n := p.leaked.Load(); n > 0 {
+			m[p.kind] += n
+		}
+	}
+	return m
+}
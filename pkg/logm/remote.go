@@ -0,0 +1,367 @@
+package logm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// DefaultRemoteInterval StartRemoteControl 的默认轮询间隔
+const DefaultRemoteInterval = 30 * time.Second
+
+// DefaultRemoteTTL 远程覆盖的默认最长生效时间
+const DefaultRemoteTTL = time.Hour
+
+// RemoteConfig 远程下发的日志级别、采样和脱敏策略覆盖，零值表示没有覆盖：
+//
+//	{
+//	  "level": "DEBUG",
+//	  "sampling": {"DEBUG": {"first": 100, "thereafter": 10}},
+//	  "redaction": {"rules": [{"keys": ["*token*"], "action": "mask"}]},
+//	  "expires_at": "2026-01-02T15:04:05Z"
+//	}
+type RemoteConfig struct {
+	// Level 日志级别：DEBUG、INFO、WARN、ERROR，空表示不覆盖
+	Level string `json:"level,omitempty"`
+	// Sampling 按级别名称覆盖采样规则，需要通过 WithRemoteSampler 指定采样器
+	Sampling map[string]SampleRule `json:"sampling,omitempty"`
+	// Redaction 脱敏策略配置（redact.Load 的格式），需要通过 WithRemoteRedaction 指定接收方
+	Redaction json.RawMessage `json:"redaction,omitempty"`
+	// ExpiresAt 覆盖的失效时间，零值表示只受 TTL 限制
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// empty 判断配置是否没有任何覆盖
+func (c *RemoteConfig) empty() bool {
+	return c == nil || (c.Level == "" && len(c.Sampling) == 0 && len(c.Redaction) == 0)
+}
+
+// Validate 校验级别名称和采样规则的级别，与下发时的校验一致。
+// 脱敏策略由接收方在应用前校验，见 RemotePolicy。
+//
+// 用于发布前检查配置，logm check 命令通过它校验远程配置文件。
+func (c *RemoteConfig) Validate() error {
+	if c.Level != "" {
+		if _, err := parseRemoteLevel(c.Level); err != nil {
+			return err
+		}
+	}
+	for name := range c.Sampling {
+		if _, err := parseRemoteLevel(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoteSource 远程配置来源。
+//
+// Fetch 返回 nil 表示当前没有覆盖。内置 HTTPSource；etcd、consul 等来源
+// 可用 RemoteSourceFunc 包装各自客户端的读取调用。
+type RemoteSource interface {
+	Fetch(ctx context.Context) (*RemoteConfig, error)
+}
+
+// RemoteSourceFunc 将函数适配为 RemoteSource。
+type RemoteSourceFunc func(ctx context.Context) (*RemoteConfig, error)
+
+// Fetch 实现 RemoteSource。
+func (f RemoteSourceFunc) Fetch(ctx context.Context) (*RemoteConfig, error) {
+	return f(ctx)
+}
+
+// HTTPSource 通过 GET url 获取 JSON 格式的 RemoteConfig。
+//
+// 响应 404 或 204 表示没有覆盖，其他非 2xx 状态码视为错误。opts 配置 HTTP 客户端，如 WithTLS。
+func HTTPSource(url string, opts ...HTTPOption) RemoteSource {
+	client := httpClient(opts)
+	return RemoteSourceFunc(func(ctx context.Context) (*RemoteConfig, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent:
+			return nil, nil //nolint:nilnil // 没有覆盖
+		case resp.StatusCode < 200 || resp.StatusCode > 299:
+			return nil, fmt.Errorf("logm: remote config %s: %s", url, resp.Status)
+		}
+		var cfg RemoteConfig
+		if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("logm: remote config %s: %w", url, err)
+		}
+		return &cfg, nil
+	})
+}
+
+// RemotePolicy 远程下发的脱敏策略的接收方，redact.Dynamic 实现了该接口。
+type RemotePolicy interface {
+	// Prepare 校验并编译策略配置，返回切换到新策略的函数；配置无效时返回错误，不做任何修改
+	Prepare(raw json.RawMessage) (apply func(), err error)
+	// Snapshot 返回恢复当前策略的函数
+	Snapshot() (restore func())
+}
+
+// RemoteOption 远程控制配置选项
+type RemoteOption func(*remoteControl)
+
+// WithRemoteLevelVar 设置被控制的级别变量，默认为全局级别。
+func WithRemoteLevelVar(lv *slog.LevelVar) RemoteOption {
+	return func(rc *remoteControl) {
+		rc.levelVar = lv
+	}
+}
+
+// WithRemoteSampler 设置被控制的采样器，未设置时忽略远程下发的采样规则。
+func WithRemoteSampler(s *Sampler) RemoteOption {
+	return func(rc *remoteControl) {
+		rc.sampler = s
+	}
+}
+
+// WithRemoteRedaction 设置脱敏策略的接收方，未设置时忽略远程下发的脱敏策略。
+func WithRemoteRedaction(p RemotePolicy) RemoteOption {
+	return func(rc *remoteControl) {
+		rc.redaction = p
+	}
+}
+
+// WithRemoteTTL 设置同一份覆盖的最长生效时间，默认 DefaultRemoteTTL，<= 0 表示不限制。
+//
+// 超时后恢复本地配置，直到远程配置发生变化（包括修改 expires_at）才再次生效，
+// 避免事故结束后遗忘的覆盖长期保留。
+func WithRemoteTTL(ttl time.Duration) RemoteOption {
+	return func(rc *remoteControl) {
+		rc.ttl = ttl
+	}
+}
+
+// WithRemoteLogger 设置记录覆盖生效和恢复的 logger，默认使用记录时的 slog.Default()。
+func WithRemoteLogger(l *slog.Logger) RemoteOption {
+	return func(rc *remoteControl) {
+		rc.logger = l
+	}
+}
+
+// StartRemoteControl 在后台每隔 interval 从 src 获取日志级别和采样覆盖，
+// 事故期间可以在一处调高整个集群的日志详细程度，并在过期后自动恢复：
+//
+//	s := logm.NewSampler(time.Second, map[slog.Level]logm.SampleRule{slog.LevelDebug: {Thereafter: 100}})
+//	logm.MustInit(logm.WithLevel("INFO"), logm.WithSampler(s))
+//	defer logm.StartRemoteControl(logm.HTTPSource("http://config/logm/api"), 0,
+//	    logm.WithRemoteSampler(s))()
+//
+// 首次应用覆盖时保存本地的级别和采样规则，覆盖被移除、到达 expires_at 或超过 TTL 时恢复。
+// 获取失败时保留当前状态，错误通过 logm 自诊断输出报告。覆盖的生效和恢复以 WARN 级别记录。
+// 启动时立即获取一次；interval <= 0 时使用 DefaultRemoteInterval。返回的 stop 函数用于停止轮询，
+// 停止时恢复本地配置。
+func StartRemoteControl(src RemoteSource, interval time.Duration, opts ...RemoteOption) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultRemoteInterval
+	}
+	rc := &remoteControl{src: src, levelVar: globalLevelVar, ttl: DefaultRemoteTTL}
+	for _, opt := range opts {
+		opt(rc)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			pollCtx, pollCancel := context.WithTimeout(ctx, interval)
+			rc.poll(pollCtx, time.Now())
+			pollCancel()
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				rc.restore("stopped")
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+}
+
+// remoteControl 远程覆盖的状态，只在轮询 goroutine 中访问
+type remoteControl struct {
+	src       RemoteSource
+	levelVar  *slog.LevelVar
+	sampler   *Sampler
+	redaction RemotePolicy
+	ttl       time.Duration
+	logger    *slog.Logger
+
+	active    bool
+	key       string    // 当前覆盖的内容标识
+	appliedAt time.Time // 当前覆盖的生效时间
+	expired   string    // 超过 TTL 的覆盖标识，内容不变时不再应用
+
+	baseLevel     slog.Level
+	baseRules     map[slog.Level]SampleRule
+	baseRedaction func()
+}
+
+// poll 获取一次远程配置并应用
+func (rc *remoteControl) poll(ctx context.Context, now time.Time) {
+	cfg, err := rc.src.Fetch(ctx)
+	rc.update(cfg, err, now)
+}
+
+// update 处理一次获取结果：应用新的覆盖，或按 TTL、失效时间恢复本地配置
+func (rc *remoteControl) update(cfg *RemoteConfig, err error, now time.Time) {
+	if err != nil {
+		selflog.Printf("remote", "fetch remote config: %v", err)
+		if rc.active && rc.ttl > 0 && now.Sub(rc.appliedAt) >= rc.ttl {
+			rc.expired = rc.key
+			rc.restore("ttl")
+		}
+		return
+	}
+
+	if cfg.empty() {
+		rc.expired = ""
+		rc.restore("removed")
+		return
+	}
+	if !cfg.ExpiresAt.IsZero() && !now.Before(cfg.ExpiresAt) {
+		rc.restore("expired")
+		return
+	}
+
+	data, _ := json.Marshal(cfg)
+	key := string(data)
+	switch {
+	case key == rc.expired:
+		return
+	case rc.active && key == rc.key:
+		if rc.ttl > 0 && now.Sub(rc.appliedAt) >= rc.ttl {
+			rc.expired = key
+			rc.restore("ttl")
+		}
+		return
+	}
+
+	if err := rc.apply(cfg); err != nil {
+		selflog.Printf("remote", "apply remote config: %v", err)
+		return
+	}
+	rc.key = key
+	rc.appliedAt = now
+	rc.expired = ""
+}
+
+// apply 校验并应用覆盖，任何一项校验失败时不做任何修改
+func (rc *remoteControl) apply(cfg *RemoteConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	var applyRedaction func()
+	if rc.redaction != nil && len(cfg.Redaction) > 0 {
+		var err error
+		if applyRedaction, err = rc.redaction.Prepare(cfg.Redaction); err != nil {
+			return fmt.Errorf("logm: remote config: redaction: %w", err)
+		}
+	}
+	level := ParseLevel(cfg.Level)
+	rules := make(map[slog.Level]SampleRule, len(cfg.Sampling))
+	for name, rule := range cfg.Sampling {
+		rules[ParseLevel(name)] = rule
+	}
+
+	if !rc.active {
+		rc.baseLevel = rc.levelVar.Level()
+		if rc.sampler != nil {
+			rc.baseRules = rc.sampler.Rules()
+		}
+		if rc.redaction != nil {
+			rc.baseRedaction = rc.redaction.Snapshot()
+		}
+		rc.active = true
+	}
+
+	if cfg.Level != "" {
+		rc.levelVar.Set(level)
+	} else {
+		rc.levelVar.Set(rc.baseLevel)
+	}
+	if rc.sampler != nil {
+		merged := maps.Clone(rc.baseRules)
+		maps.Copy(merged, rules)
+		rc.sampler.SetRules(merged)
+	}
+	switch {
+	case applyRedaction != nil:
+		applyRedaction()
+	case rc.baseRedaction != nil:
+		rc.baseRedaction()
+	default:
+	}
+
+	rc.log("logm remote override applied",
+		slog.String("level", LevelString(rc.levelVar.Level())),
+		slog.Any("sampling", cfg.Sampling),
+		slog.Bool("redaction", applyRedaction != nil),
+		slog.Time("expires_at", cfg.ExpiresAt),
+	)
+	return nil
+}
+
+// restore 恢复本地配置
+func (rc *remoteControl) restore(reason string) {
+	if !rc.active {
+		return
+	}
+	rc.levelVar.Set(rc.baseLevel)
+	if rc.sampler != nil {
+		rc.sampler.SetRules(rc.baseRules)
+	}
+	if rc.baseRedaction != nil {
+		rc.baseRedaction()
+	}
+	rc.active = false
+	rc.key = ""
+	rc.log("logm remote override cleared", slog.String("reason", reason))
+}
+
+// log 以 WARN 级别记录覆盖状态变化
+func (rc *remoteControl) log(msg string, attrs ...slog.Attr) {
+	logger := rc.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.LogAttrs(context.Background(), slog.LevelWarn, msg, attrs...)
+}
+
+// parseRemoteLevel 解析远程下发的级别名称，无法识别时返回错误
+func parseRemoteLevel(s string) (slog.Level, error) {
+	switch strings.ToUpper(s) {
+	case "DEBUG", "INFO", "WARN", "WARNING", "ERROR":
+		return ParseLevel(s), nil
+	default:
+		return 0, fmt.Errorf("logm: remote config: unknown level %q", s)
+	}
+}
@@ -0,0 +1,170 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource 返回可修改的远程配置
+type fakeSource struct {
+	mu  sync.Mutex
+	cfg *RemoteConfig
+	err error
+}
+
+func (s *fakeSource) set(cfg *RemoteConfig, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg, s.err = cfg, err
+}
+
+func (s *fakeSource) Fetch(context.Context) (*RemoteConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg, s.err
+}
+
+func newTestRemote(src RemoteSource, opts ...RemoteOption) (*remoteControl, *slog.LevelVar, *Sampler, *bytes.Buffer) {
+	lv := &slog.LevelVar{}
+	lv.Set(slog.LevelInfo)
+	s := NewSampler(time.Second, map[slog.Level]SampleRule{slog.LevelDebug: {Thereafter: 100}})
+	var buf bytes.Buffer
+	logger := New(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &buf}))
+
+	rc := &remoteControl{src: src, levelVar: lv, ttl: DefaultRemoteTTL}
+	for _, opt := range append([]RemoteOption{WithRemoteSampler(s), WithRemoteLogger(logger)}, opts...) {
+		opt(rc)
+	}
+	return rc, lv, s, &buf
+}
+
+func TestRemoteControl_ApplyAndRemove(t *testing.T) {
+	src := &fakeSource{}
+	rc, lv, s, buf := newTestRemote(src)
+	now := time.Now()
+
+	rc.poll(t.Context(), now)
+	assert.Equal(t, slog.LevelInfo, lv.Level())
+
+	src.set(&RemoteConfig{Level: "debug", Sampling: map[string]SampleRule{"DEBUG": {First: 10}, "INFO": {Thereafter: 2}}}, nil)
+	rc.poll(t.Context(), now)
+	assert.Equal(t, slog.LevelDebug, lv.Level())
+	assert.Equal(t, map[slog.Level]SampleRule{slog.LevelDebug: {First: 10}, slog.LevelInfo: {Thereafter: 2}}, s.Rules())
+	assert.Contains(t, buf.String(), "logm remote override applied")
+
+	// 获取失败时保留覆盖
+	src.set(nil, errors.New("unreachable"))
+	rc.poll(t.Context(), now.Add(time.Minute))
+	assert.Equal(t, slog.LevelDebug, lv.Level())
+
+	src.set(nil, nil)
+	rc.poll(t.Context(), now.Add(2*time.Minute))
+	assert.Equal(t, slog.LevelInfo, lv.Level())
+	assert.Equal(t, map[slog.Level]SampleRule{slog.LevelDebug: {Thereafter: 100}}, s.Rules())
+	assert.Contains(t, buf.String(), "reason=removed")
+}
+
+func TestRemoteControl_ExpiresAt(t *testing.T) {
+	now := time.Now()
+	src := &fakeSource{cfg: &RemoteConfig{Level: "DEBUG", ExpiresAt: now.Add(time.Minute)}}
+	rc, lv, _, buf := newTestRemote(src)
+
+	rc.poll(t.Context(), now)
+	assert.Equal(t, slog.LevelDebug, lv.Level())
+
+	rc.poll(t.Context(), now.Add(time.Minute))
+	assert.Equal(t, slog.LevelInfo, lv.Level())
+	assert.Contains(t, buf.String(), "reason=expired")
+}
+
+func TestRemoteControl_TTL(t *testing.T) {
+	now := time.Now()
+	src := &fakeSource{cfg: &RemoteConfig{Level: "DEBUG"}}
+	rc, lv, _, buf := newTestRemote(src, WithRemoteTTL(10*time.Minute))
+
+	rc.poll(t.Context(), now)
+	rc.poll(t.Context(), now.Add(5*time.Minute))
+	assert.Equal(t, slog.LevelDebug, lv.Level())
+
+	rc.poll(t.Context(), now.Add(10*time.Minute))
+	assert.Equal(t, slog.LevelInfo, lv.Level())
+	assert.Contains(t, buf.String(), "reason=ttl")
+
+	// 内容不变时不再应用
+	rc.poll(t.Context(), now.Add(11*time.Minute))
+	assert.Equal(t, slog.LevelInfo, lv.Level())
+
+	// 内容变化后重新生效
+	src.set(&RemoteConfig{Level: "WARN"}, nil)
+	rc.poll(t.Context(), now.Add(12*time.Minute))
+	assert.Equal(t, slog.LevelWarn, lv.Level())
+}
+
+func TestRemoteControl_InvalidConfig(t *testing.T) {
+	src := &fakeSource{cfg: &RemoteConfig{Level: "DEBUG", Sampling: map[string]SampleRule{"VERBOSE": {}}}}
+	rc, lv, s, _ := newTestRemote(src)
+
+	rc.poll(t.Context(), time.Now())
+	assert.Equal(t, slog.LevelInfo, lv.Level())
+	assert.Equal(t, map[slog.Level]SampleRule{slog.LevelDebug: {Thereafter: 100}}, s.Rules())
+	assert.False(t, rc.active)
+}
+
+func TestRemoteConfig_Validate(t *testing.T) {
+	require.NoError(t, (&RemoteConfig{}).Validate())
+	require.NoError(t, (&RemoteConfig{Level: "warning", Sampling: map[string]SampleRule{"debug": {}}}).Validate())
+	require.ErrorContains(t, (&RemoteConfig{Level: "TRACE"}).Validate(), `"TRACE"`)
+	require.ErrorContains(t, (&RemoteConfig{Sampling: map[string]SampleRule{"VERBOSE": {}}}).Validate(), `"VERBOSE"`)
+}
+
+func TestHTTPSource(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"level":"DEBUG","sampling":{"INFO":{"first":5,"thereafter":10}},"expires_at":"2030-01-02T03:04:05Z"}`))
+	}))
+	defer srv.Close()
+	src := HTTPSource(srv.URL)
+
+	cfg, err := src.Fetch(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, &RemoteConfig{
+		Level:     "DEBUG",
+		Sampling:  map[string]SampleRule{"INFO": {First: 5, Thereafter: 10}},
+		ExpiresAt: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	}, cfg)
+
+	status = http.StatusNotFound
+	cfg, err = src.Fetch(t.Context())
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	status = http.StatusBadGateway
+	_, err = src.Fetch(t.Context())
+	assert.ErrorContains(t, err, "502")
+}
+
+func TestStartRemoteControl(t *testing.T) {
+	lv := &slog.LevelVar{}
+	lv.Set(slog.LevelWarn)
+	src := &fakeSource{cfg: &RemoteConfig{Level: "DEBUG"}}
+	logger := New(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &bytes.Buffer{}}))
+
+	stop := StartRemoteControl(src, 10*time.Millisecond, WithRemoteLevelVar(lv), WithRemoteLogger(logger))
+	assert.Eventually(t, func() bool { return lv.Level() == slog.LevelDebug }, time.Second, 5*time.Millisecond)
+
+	stop()
+	stop()
+	assert.Equal(t, slog.LevelWarn, lv.Level())
+}
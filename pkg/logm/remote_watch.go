@@ -0,0 +1,246 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+const (
+	// remoteRetry 监听断开或查询失败后的重试间隔
+	remoteRetry = 5 * time.Second
+	// remoteCheck 监听模式下重新检查 TTL 和 expires_at 的间隔
+	remoteCheck = time.Minute
+	// consulWait consul 阻塞查询的最长等待时间
+	consulWait = 5 * time.Minute
+	// maxRemoteConfig 远程配置的最大字节数
+	maxRemoteConfig = 1 << 20
+)
+
+// RemoteWatcher 推送式的远程配置来源，如 etcd watch 和 consul 阻塞查询。
+//
+// Watch 阻塞直到 ctx 结束，键的值每次变化时调用 update：data 为 RemoteConfig 的 JSON，
+// 键不存在或被删除时为 nil；连接错误可以通过 update 的 err 报告后自行重试，
+// 也可以直接返回，由 StartRemoteWatch 稍后重新调用 Watch。
+//
+// 内置 ConsulKV；etcd 可用 RemoteWatcherFunc 包装 clientv3：
+//
+//	w := logm.RemoteWatcherFunc(func(ctx context.Context, update func([]byte, error)) error {
+//	    resp, err := cli.Get(ctx, key)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    if len(resp.Kvs) > 0 {
+//	        update(resp.Kvs[0].Value, nil)
+//	    } else {
+//	        update(nil, nil)
+//	    }
+//	    for wr := range cli.Watch(ctx, key, clientv3.WithRev(resp.Header.Revision+1)) {
+//	        if err := wr.Err(); err != nil {
+//	            return err
+//	        }
+//	        for _, ev := range wr.Events {
+//	            if ev.Type == clientv3.EventTypeDelete {
+//	                update(nil, nil)
+//	            } else {
+//	                update(ev.Kv.Value, nil)
+//	            }
+//	        }
+//	    }
+//	    return ctx.Err()
+//	})
+type RemoteWatcher interface {
+	Watch(ctx context.Context, update func(data []byte, err error)) error
+}
+
+// RemoteWatcherFunc 将函数适配为 RemoteWatcher。
+type RemoteWatcherFunc func(ctx context.Context, update func(data []byte, err error)) error
+
+// Watch 实现 RemoteWatcher。
+func (f RemoteWatcherFunc) Watch(ctx context.Context, update func(data []byte, err error)) error {
+	return f(ctx, update)
+}
+
+// StartRemoteWatch 监听 w 推送的远程配置，变化后立即应用，无需等待轮询：
+//
+//	defer logm.StartRemoteWatch(logm.ConsulKV("http://127.0.0.1:8500", "logm/api", ""),
+//	    logm.WithRemoteSampler(s), logm.WithRemoteRedaction(policy))()
+//
+// 覆盖的应用、恢复和 TTL 与 StartRemoteControl 相同。每次变化作为一个整体应用：
+// JSON 无法解析、包含未知字段、级别或脱敏策略无效时保留当前生效的配置，
+// 不会只应用其中一部分；错误通过 logm 自诊断输出报告。返回的 stop 函数用于停止监听，
+// 停止时恢复本地配置。
+func StartRemoteWatch(w RemoteWatcher, opts ...RemoteOption) (stop func()) {
+	rc := &remoteControl{levelVar: globalLevelVar, ttl: DefaultRemoteTTL}
+	for _, opt := range opts {
+		opt(rc)
+	}
+
+	type result struct {
+		cfg *RemoteConfig
+		err error
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan result)
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		update := func(data []byte, err error) {
+			r := result{err: err}
+			if err == nil {
+				r.cfg, r.err = decodeRemoteConfig(data)
+			}
+			select {
+			case results <- r:
+			case <-ctx.Done():
+			}
+		}
+		for {
+			err := w.Watch(ctx, update)
+			if ctx.Err() != nil {
+				return
+			}
+			selflog.Printf("remote", "watch remote config: %v, retrying in %s", err, remoteRetry)
+			select {
+			case <-time.After(remoteRetry):
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	wg.Go(func() {
+		ticker := time.NewTicker(remoteCheck)
+		defer ticker.Stop()
+		var last *RemoteConfig
+		var received bool
+		for {
+			select {
+			case r := <-results:
+				if r.err == nil {
+					last, received = r.cfg, true
+				}
+				rc.update(r.cfg, r.err, time.Now())
+			case <-ticker.C:
+				if received {
+					rc.update(last, nil, time.Now())
+				}
+			case <-ctx.Done():
+				rc.restore("stopped")
+				return
+			}
+		}
+	})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			wg.Wait()
+		})
+	}
+}
+
+// decodeRemoteConfig 严格解析推送的配置，空值表示没有覆盖
+func decodeRemoteConfig(data []byte) (*RemoteConfig, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil //nolint:nilnil // 没有覆盖
+	}
+	var cfg RemoteConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("logm: remote config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// ConsulKV 返回监听 consul KV 键的 RemoteWatcher，使用 HTTP 阻塞查询，不依赖 consul 客户端。
+//
+// addr 为 consul HTTP 地址，如 "http://127.0.0.1:8500"；token 为 ACL 令牌，不需要时传空。
+// 键不存在时视为没有覆盖。opts 配置 HTTP 客户端，如 WithTLS。
+func ConsulKV(addr, key, token string, opts ...HTTPOption) RemoteWatcher {
+	client := httpClient(opts)
+	endpoint := strings.TrimRight(addr, "/") + "/v1/kv/" + strings.TrimLeft(key, "/")
+	return RemoteWatcherFunc(func(ctx context.Context, update func([]byte, error)) error {
+		var index uint64
+		for ctx.Err() == nil {
+			data, next, err := consulGet(ctx, client, endpoint, token, index)
+			if ctx.Err() != nil {
+				break
+			}
+			if err != nil {
+				update(nil, err)
+				select {
+				case <-time.After(remoteRetry):
+				case <-ctx.Done():
+				}
+				continue
+			}
+
+			prev := index
+			switch {
+			case next < prev:
+				index = 0 // consul 重建索引后从头开始
+			case next == 0:
+				index = 1
+			default:
+				index = next
+			}
+			if prev != 0 && next == prev {
+				continue // 等待超时，没有变化
+			}
+			update(data, nil)
+		}
+		return ctx.Err()
+	})
+}
+
+// consulGet 执行一次阻塞查询，返回键的原始值（不存在时为 nil）和 X-Consul-Index
+func consulGet(ctx context.Context, client *http.Client, endpoint, token string, index uint64) ([]byte, uint64, error) {
+	q := url.Values{"raw": {""}}
+	if index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", consulWait.String())
+	}
+	ctx, cancel := context.WithTimeout(ctx, consulWait+time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if token != "" {
+		req.Header.Set("X-Consul-Token", token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	next, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, next, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, 0, fmt.Errorf("logm: consul %s: %s", endpoint, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfig+1))
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(data) > maxRemoteConfig {
+		return nil, 0, fmt.Errorf("logm: consul %s: value exceeds %d bytes", endpoint, maxRemoteConfig)
+	}
+	return data, next, nil
+}
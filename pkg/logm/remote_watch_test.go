@@ -0,0 +1,191 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePolicy 记录当前策略配置的 RemotePolicy
+type fakePolicy struct {
+	mu  sync.Mutex
+	cur string
+}
+
+func (p *fakePolicy) Prepare(raw json.RawMessage) (func(), error) {
+	var v struct{ Rules []string }
+	if err := json.Unmarshal(raw, &v); err != nil || len(v.Rules) == 0 {
+		return nil, errors.New("invalid policy")
+	}
+	return func() { p.set(string(raw)) }, nil
+}
+
+func (p *fakePolicy) Snapshot() func() {
+	cur := p.get()
+	return func() { p.set(cur) }
+}
+
+func (p *fakePolicy) set(s string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cur = s
+}
+
+func (p *fakePolicy) get() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cur
+}
+
+func TestRemoteControl_Redaction(t *testing.T) {
+	src := &fakeSource{}
+	policy := &fakePolicy{cur: "local"}
+	rc, lv, _, _ := newTestRemote(src, WithRemoteRedaction(policy))
+	now := time.Now()
+
+	src.set(&RemoteConfig{Level: "DEBUG", Redaction: json.RawMessage(`{"rules":["a"]}`)}, nil)
+	rc.poll(t.Context(), now)
+	assert.Equal(t, slog.LevelDebug, lv.Level())
+	assert.JSONEq(t, `{"rules":["a"]}`, policy.get())
+
+	// 脱敏策略无效时整体不生效，级别也保持不变
+	src.set(&RemoteConfig{Level: "ERROR", Redaction: json.RawMessage(`{"rules":[]}`)}, nil)
+	rc.poll(t.Context(), now.Add(time.Minute))
+	assert.Equal(t, slog.LevelDebug, lv.Level())
+	assert.JSONEq(t, `{"rules":["a"]}`, policy.get())
+
+	// 新的覆盖不含脱敏策略时恢复本地策略
+	src.set(&RemoteConfig{Level: "WARN"}, nil)
+	rc.poll(t.Context(), now.Add(2*time.Minute))
+	assert.Equal(t, slog.LevelWarn, lv.Level())
+	assert.Equal(t, "local", policy.get())
+
+	src.set(&RemoteConfig{Redaction: json.RawMessage(`{"rules":["b"]}`)}, nil)
+	rc.poll(t.Context(), now.Add(3*time.Minute))
+	assert.JSONEq(t, `{"rules":["b"]}`, policy.get())
+	assert.Equal(t, slog.LevelInfo, lv.Level())
+
+	src.set(nil, nil)
+	rc.poll(t.Context(), now.Add(4*time.Minute))
+	assert.Equal(t, "local", policy.get())
+}
+
+// chanWatcher 从 channel 推送配置的 RemoteWatcher
+type chanWatcher chan []byte
+
+func (w chanWatcher) Watch(ctx context.Context, update func([]byte, error)) error {
+	for {
+		select {
+		case data := <-w:
+			update(data, nil)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestStartRemoteWatch(t *testing.T) {
+	lv := &slog.LevelVar{}
+	lv.Set(slog.LevelWarn)
+	policy := &fakePolicy{cur: "local"}
+	logger := New(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &bytes.Buffer{}}))
+	w := make(chanWatcher)
+
+	stop := StartRemoteWatch(w, WithRemoteLevelVar(lv), WithRemoteRedaction(policy), WithRemoteLogger(logger))
+	defer stop()
+
+	w <- []byte(`{"level":"DEBUG","redaction":{"rules":["a"]}}`)
+	assert.Eventually(t, func() bool { return lv.Level() == slog.LevelDebug }, time.Second, 5*time.Millisecond)
+
+	// 无效的推送保留当前配置
+	for _, data := range []string{`{"level":`, `{"levle":"ERROR"}`, `{"level":"LOUD"}`, `{"level":"ERROR","redaction":{}}`} {
+		w <- []byte(data)
+	}
+	w <- []byte(`{"level":"DEBUG","redaction":{"rules":["a"]}}`)
+	assert.Equal(t, slog.LevelDebug, lv.Level())
+	assert.JSONEq(t, `{"rules":["a"]}`, policy.get())
+
+	// 键被删除时恢复本地配置
+	w <- nil
+	assert.Eventually(t, func() bool { return lv.Level() == slog.LevelWarn }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "local", policy.get())
+
+	w <- []byte(`{"level":"ERROR"}`)
+	assert.Eventually(t, func() bool { return lv.Level() == slog.LevelError }, time.Second, 5*time.Millisecond)
+	stop()
+	stop()
+	assert.Equal(t, slog.LevelWarn, lv.Level())
+}
+
+func TestConsulKV(t *testing.T) {
+	queries := make(chan string, 10)
+	changed := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/kv/logm/api", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-Consul-Token"))
+		queries <- r.URL.RawQuery
+
+		switch r.URL.Query().Get("index") {
+		case "":
+			w.Header().Set("X-Consul-Index", "7")
+			_, _ = w.Write([]byte(`{"level":"DEBUG"}`))
+		case "7":
+			<-changed // 阻塞查询等待键变化
+			w.Header().Set("X-Consul-Index", "9")
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	updates := make(chan []byte)
+	done := make(chan error)
+	go func() {
+		done <- ConsulKV(srv.URL+"/", "/logm/api", "secret").Watch(ctx, func(data []byte, err error) {
+			assert.NoError(t, err)
+			updates <- data
+		})
+	}()
+
+	assert.Equal(t, "raw=", <-queries)
+	assert.JSONEq(t, `{"level":"DEBUG"}`, string(<-updates))
+	assert.Equal(t, "index=7&raw=&wait=5m0s", <-queries)
+	close(changed)
+	assert.Nil(t, <-updates)
+	assert.Equal(t, "index=9&raw=&wait=5m0s", <-queries)
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestConsulKV_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		_ = ConsulKV(srv.URL, "logm", "").Watch(ctx, func(_ []byte, err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+	}()
+	assert.ErrorContains(t, <-errs, "403")
+}
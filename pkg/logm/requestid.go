@@ -0,0 +1,41 @@
+package logm
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// NewRequestID 生成 UUIDv7 格式的请求 ID（RFC 9562）。
+//
+// 前 48 位为毫秒时间戳，其余为随机数，按字典序排序即按生成时间排序，
+// 便于在日志中按时间定位请求：
+//
+//	ctx, id := logm.EnsureRequestID(r.Context())
+//	w.Header().Set("X-Request-ID", id)
+func NewRequestID() string {
+	var u [16]byte
+	_, _ = rand.Read(u[:]) // crypto/rand.Read 不会返回错误
+
+	ms := uint64(time.Now().UnixMilli()) //nolint:gosec // 时间戳为正数
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	u[6] = u[6]&0x0f | 0x70 // 版本 7
+	u[8] = u[8]&0x3f | 0x80 // RFC 9562 变体
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
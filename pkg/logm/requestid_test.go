@@ -0,0 +1,55 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+)
+
+var uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewRequestID(t *testing.T) {
+	seen := make(map[string]bool)
+	prev := ""
+	for range 100 {
+		id := NewRequestID()
+		assert.Regexp(t, uuidV7Pattern, id)
+		assert.False(t, seen[id], "duplicate id %s", id)
+		seen[id] = true
+		// 时间戳前缀单调不减
+		assert.GreaterOrEqual(t, id[:13], prev[:min(len(prev), 13)])
+		prev = id
+	}
+
+	before := NewRequestID()
+	time.Sleep(2 * time.Millisecond)
+	assert.Less(t, before, NewRequestID())
+}
+
+func TestEnsureRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), New(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &buf})))
+
+	_, ok := RequestIDFromContext(ctx)
+	assert.False(t, ok)
+
+	ctx, id := EnsureRequestID(ctx)
+	assert.Regexp(t, uuidV7Pattern, id)
+	got, ok := RequestIDFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, id, got)
+
+	FromContext(ctx).Info("handled")
+	assert.Contains(t, buf.String(), "request_id="+id)
+
+	// 已有请求 ID 时保持不变
+	ctx2, id2 := EnsureRequestID(WithRequestID(context.Background(), "req-1"))
+	assert.Equal(t, "req-1", id2)
+	got, _ = RequestIDFromContext(ctx2)
+	assert.Equal(t, "req-1", got)
+}
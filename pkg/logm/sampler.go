@@ -0,0 +1,197 @@
+package logm
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sampleBuckets 每个级别的计数槽数量，消息按哈希分配到槽中
+const sampleBuckets = 4096
+
+// SampleRule 单个级别的采样规则。
+//
+// 每个周期内同一消息的前 First 条全部保留（突发额度），
+// 之后每 Thereafter 条保留 1 条；Thereafter <= 0 时丢弃额度之外的全部日志。
+//
+//	{First: 0, Thereafter: 100}   // 保留 1%
+//	{First: 100, Thereafter: 100} // 每周期前 100 条，之后保留 1%
+type SampleRule struct {
+	First      int `json:"first"`
+	Thereafter int `json:"thereafter"`
+}
+
+// Sampler 按级别采样日志，作为拦截器接入 Handler：
+//
+//	s := logm.NewSampler(time.Second, map[slog.Level]logm.SampleRule{
+//	    slog.LevelDebug: {Thereafter: 100},             // DEBUG 保留 1%
+//	    slog.LevelInfo:  {First: 100, Thereafter: 100}, // INFO 每秒前 100 条，之后 1%
+//	}) // 未配置的级别（WARN 及以上）不采样
+//	logm.Init(logm.WithSampler(s))
+//
+// 与 zap 的采样器类似，计数按级别和消息分开，每个周期重置，
+// 重复刷屏的消息被限流，偶发的消息不受影响。不同消息可能因哈希冲突共享计数。
+// 规则按级别精确匹配，可以在运行时通过 SetRule、RemoveRule 调整，并发安全。
+// 周期按单调时钟计算，不受记录时间（可能为零值）和系统时间回拨的影响。
+type Sampler struct {
+	tick  time.Duration
+	start time.Time        // 创建时间，计数周期以此为起点
+	now   func() time.Time // 时间来源，测试时替换
+
+	mu    sync.Mutex // 串行化规则修改
+	rules atomic.Pointer[map[slog.Level]*levelSampler]
+
+	dropped sync.Map // slog.Level -> *atomic.Uint64
+}
+
+// levelSampler 单个级别的规则和计数
+type levelSampler struct {
+	rule   SampleRule
+	counts [sampleBuckets]sampleCounter
+}
+
+// sampleCounter 周期计数
+type sampleCounter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+// NewSampler 创建采样器，tick 为计数周期，<= 0 时为 1 秒。
+func NewSampler(tick time.Duration, rules map[slog.Level]SampleRule) *Sampler {
+	if tick <= 0 {
+		tick = time.Second
+	}
+	s := &Sampler{tick: tick, start: time.Now(), now: time.Now}
+	m := make(map[slog.Level]*levelSampler, len(rules))
+	for level, rule := range rules {
+		m[level] = &levelSampler{rule: rule}
+	}
+	s.rules.Store(&m)
+	return s
+}
+
+// WithSampler 添加采样拦截器。
+//
+// 采样在拦截器链中按添加顺序执行，建议放在其他拦截器之前，避免为丢弃的日志做无用功。
+func WithSampler(s *Sampler) Option {
+	return WithInterceptor(s.Interceptor())
+}
+
+// SetRule 设置级别的采样规则，该级别的计数重新开始。
+func (s *Sampler) SetRule(level slog.Level, rule SampleRule) {
+	s.update(func(m map[slog.Level]*levelSampler) {
+		m[level] = &levelSampler{rule: rule}
+	})
+}
+
+// RemoveRule 移除级别的采样规则，该级别的日志不再采样。
+func (s *Sampler) RemoveRule(level slog.Level) {
+	s.update(func(m map[slog.Level]*levelSampler) {
+		delete(m, level)
+	})
+}
+
+// SetRules 替换全部采样规则，所有级别的计数重新开始。
+func (s *Sampler) SetRules(rules map[slog.Level]SampleRule) {
+	s.update(func(m map[slog.Level]*levelSampler) {
+		clear(m)
+		for level, rule := range rules {
+			m[level] = &levelSampler{rule: rule}
+		}
+	})
+}
+
+// Rules 返回当前的采样规则。
+func (s *Sampler) Rules() map[slog.Level]SampleRule {
+	m := *s.rules.Load()
+	rules := make(map[slog.Level]SampleRule, len(m))
+	for level, ls := range m {
+		rules[level] = ls.rule
+	}
+	return rules
+}
+
+// Dropped 返回各级别被采样丢弃的日志数，键为 LevelString 返回的级别名称，
+// 自定义级别计入所属的标准级别。
+func (s *Sampler) Dropped() map[string]uint64 {
+	out := make(map[string]uint64)
+	s.dropped.Range(func(k, v any) bool {
+		out[LevelString(k.(slog.Level))] += v.(*atomic.Uint64).Load() //nolint:forcetypeassert // 存储类型固定
+		return true
+	})
+	return out
+}
+
+// Interceptor 返回执行采样的拦截器，被采样丢弃的日志返回 nil。
+func (s *Sampler) Interceptor() Interceptor {
+	return func(_ context.Context, r *Record) *Record {
+		if s.keep(r) {
+			return r
+		}
+		s.counter(r.Level).Add(1)
+		return nil
+	}
+}
+
+// update 以写时复制方式修改规则
+func (s *Sampler) update(fn func(map[slog.Level]*levelSampler)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := maps.Clone(*s.rules.Load())
+	fn(m)
+	s.rules.Store(&m)
+}
+
+// keep 判断记录是否保留
+func (s *Sampler) keep(r *Record) bool {
+	ls, ok := (*s.rules.Load())[r.Level]
+	if !ok {
+		return true
+	}
+	// time.Now 带有单调时钟读数，Sub 按单调时钟计算经过的时间
+	n := ls.counts[bucket(r.Message)].inc(s.now().Sub(s.start).Nanoseconds(), s.tick)
+	first := uint64(max(ls.rule.First, 0))
+	if n <= first {
+		return true
+	}
+	if ls.rule.Thereafter <= 0 {
+		return false
+	}
+	return (n-first)%uint64(ls.rule.Thereafter) == 0
+}
+
+// counter 返回级别的丢弃计数器
+func (s *Sampler) counter(level slog.Level) *atomic.Uint64 {
+	if c, ok := s.dropped.Load(level); ok {
+		return c.(*atomic.Uint64) //nolint:forcetypeassert // 存储类型固定
+	}
+	c, _ := s.dropped.LoadOrStore(level, &atomic.Uint64{})
+	return c.(*atomic.Uint64) //nolint:forcetypeassert // 存储类型固定
+}
+
+// inc 计数加一并返回周期内的计数，now 为 Sampler 创建后经过的纳秒数，超过重置时间时从 1 重新开始
+func (c *sampleCounter) inc(now int64, tick time.Duration) uint64 {
+	resetAt := c.resetAt.Load()
+	if now < resetAt {
+		return c.n.Add(1)
+	}
+	c.n.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, now+tick.Nanoseconds()) {
+		// 其他 goroutine 已完成重置，计入新周期
+		return c.n.Add(1)
+	}
+	return 1
+}
+
+// bucket 返回消息对应的计数槽（FNV-1a 哈希）
+func bucket(msg string) uint32 {
+	h := uint32(2166136261)
+	for i := range len(msg) {
+		h ^= uint32(msg[i])
+		h *= 16777619
+	}
+	return h % sampleBuckets
+}
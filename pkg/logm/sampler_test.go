@@ -0,0 +1,156 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleRun 将采样器的时钟固定在 at，向拦截器发送 n 条记录，返回保留的条数
+func sampleRun(s *Sampler, level slog.Level, msg string, at time.Time, n int) int {
+	s.now = func() time.Time { return at }
+	intercept := s.Interceptor()
+	kept := 0
+	for range n {
+		if intercept(context.Background(), &Record{Time: at, Level: level, Message: msg}) != nil {
+			kept++
+		}
+	}
+	return kept
+}
+
+func TestSampler_Rules(t *testing.T) {
+	s := NewSampler(time.Second, map[slog.Level]SampleRule{
+		slog.LevelDebug: {Thereafter: 100},
+		slog.LevelInfo:  {First: 100, Thereafter: 100},
+		slog.Level(-8):  {First: 5},
+	})
+	now := s.start
+
+	assert.Equal(t, 10, sampleRun(s, slog.LevelDebug, "d", now, 1000))
+	assert.Equal(t, 109, sampleRun(s, slog.LevelInfo, "i", now, 1000))
+	assert.Equal(t, 5, sampleRun(s, slog.Level(-8), "t", now, 1000))
+	assert.Equal(t, 1000, sampleRun(s, slog.LevelWarn, "w", now, 1000))
+
+	assert.Equal(t, map[string]uint64{"DEBUG": 990 + 995, "INFO": 891}, s.Dropped())
+}
+
+func TestSampler_PerMessage(t *testing.T) {
+	s := NewSampler(time.Second, map[slog.Level]SampleRule{slog.LevelInfo: {First: 2}})
+	now := s.start
+
+	assert.Equal(t, 2, sampleRun(s, slog.LevelInfo, "noisy", now, 10))
+	assert.Equal(t, 1, sampleRun(s, slog.LevelInfo, "rare", now, 1))
+}
+
+func TestSampler_TickResets(t *testing.T) {
+	s := NewSampler(time.Second, map[slog.Level]SampleRule{slog.LevelInfo: {First: 3}})
+	now := s.start
+
+	assert.Equal(t, 3, sampleRun(s, slog.LevelInfo, "m", now, 10))
+	assert.Equal(t, 0, sampleRun(s, slog.LevelInfo, "m", now.Add(500*time.Millisecond), 10))
+	assert.Equal(t, 3, sampleRun(s, slog.LevelInfo, "m", now.Add(time.Second), 10))
+}
+
+func TestSampler_IgnoresRecordTime(t *testing.T) {
+	s := NewSampler(10*time.Millisecond, map[slog.Level]SampleRule{slog.LevelInfo: {First: 2}})
+	intercept := s.Interceptor()
+
+	kept := 0
+	for i := range 5 {
+		if i > 0 {
+			time.Sleep(20 * time.Millisecond)
+		}
+		// 零值时间和回拨的时间都不影响周期
+		at := time.Time{}
+		if i%2 == 1 {
+			at = time.Now().Add(-time.Hour)
+		}
+		if intercept(context.Background(), &Record{Time: at, Level: slog.LevelInfo, Message: "m"}) != nil {
+			kept++
+		}
+	}
+	assert.Equal(t, 5, kept)
+}
+
+func TestSampler_RuntimeAdjust(t *testing.T) {
+	s := NewSampler(0, nil)
+	now := s.start
+	assert.Equal(t, 10, sampleRun(s, slog.LevelDebug, "m", now, 10))
+
+	s.SetRule(slog.LevelDebug, SampleRule{First: 1})
+	assert.Equal(t, map[slog.Level]SampleRule{slog.LevelDebug: {First: 1}}, s.Rules())
+	assert.Equal(t, 1, sampleRun(s, slog.LevelDebug, "m", now, 10))
+
+	s.SetRule(slog.LevelDebug, SampleRule{First: 4})
+	assert.Equal(t, 4, sampleRun(s, slog.LevelDebug, "m", now, 10), "计数重新开始")
+
+	s.RemoveRule(slog.LevelDebug)
+	assert.Empty(t, s.Rules())
+	assert.Equal(t, 10, sampleRun(s, slog.LevelDebug, "m", now, 10))
+	assert.Equal(t, map[string]uint64{"DEBUG": 15}, s.Dropped())
+}
+
+func TestWithSampler(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewSampler(time.Minute, map[slog.Level]SampleRule{slog.LevelInfo: {First: 2, Thereafter: 3}})
+	logger := New(
+		WithSampler(s),
+		WithFormatter(formatter.Text()),
+		WithWriter(&testWriter{buf: &buf}),
+	)
+
+	for range 8 {
+		logger.Info("tick")
+	}
+	logger.Warn("warn")
+
+	// 保留第 1、2、5、8 条
+	assert.Equal(t, 4, strings.Count(buf.String(), "msg=tick"))
+	assert.Contains(t, buf.String(), "msg=warn")
+	assert.Equal(t, map[string]uint64{"INFO": 4}, s.Dropped())
+}
+
+func TestSampler_Concurrent(t *testing.T) {
+	s := NewSampler(time.Hour, map[slog.Level]SampleRule{slog.LevelInfo: {First: 100, Thereafter: 10}})
+	intercept := s.Interceptor()
+	now := time.Now()
+
+	var kept sync.Map
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			n := 0
+			for range 1000 {
+				if intercept(context.Background(), &Record{Time: now, Level: slog.LevelInfo, Message: "m"}) != nil {
+					n++
+				}
+			}
+			kept.Store(g, n)
+		})
+	}
+	wg.Go(func() {
+		for range 100 {
+			s.Rules()
+			s.Dropped()
+		}
+	})
+	wg.Wait()
+
+	total := 0
+	kept.Range(func(_, v any) bool {
+		total += v.(int) //nolint:forcetypeassert // 测试数据
+		return true
+	})
+	// 周期开始时并发重置可能少计几次，与 zap 相同
+	require.InDelta(t, 100+790, total, 16)
+	assert.Equal(t, uint64(8000-total), s.Dropped()["INFO"])
+}
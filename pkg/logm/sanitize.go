@@ -0,0 +1,159 @@
+package logm
+
+import (
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// sanitizeRecord 清理日志消息、属性键和字符串值中的无效 UTF-8 和控制字符，见 WithSanitize
+func sanitizeRecord(rec *Record) {
+	rec.Message = sanitizeString(rec.Message)
+	if !attrsNeedSanitize(rec.Attrs) {
+		return
+	}
+	rec.Attrs = sanitizeAttrs(rec.Attrs)
+}
+
+// attrsNeedSanitize 判断属性中是否存在需要清理的内容，避免无谓的复制
+func attrsNeedSanitize(attrs []slog.Attr) bool {
+	for _, a := range attrs {
+		if needsSanitize(a.Key) {
+			return true
+		}
+		v := a.Value.Resolve()
+		switch v.Kind() {
+		case slog.KindString:
+			if needsSanitize(v.String()) {
+				return true
+			}
+		case slog.KindGroup:
+			if attrsNeedSanitize(v.Group()) {
+				return true
+			}
+		case slog.KindAny:
+			if err, ok := v.Any().(error); ok && needsSanitize(err.Error()) {
+				return true
+			}
+		default:
+		}
+	}
+	return false
+}
+
+// sanitizeAttrs 返回清理后的属性副本，递归处理分组
+func sanitizeAttrs(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		key := sanitizeString(a.Key)
+		v := a.Value.Resolve()
+		switch v.Kind() {
+		case slog.KindString:
+			out[i] = slog.String(key, sanitizeString(v.String()))
+		case slog.KindGroup:
+			out[i] = slog.Attr{Key: key, Value: slog.GroupValue(sanitizeAttrs(v.Group())...)}
+		case slog.KindAny:
+			if err, ok := v.Any().(error); ok && needsSanitize(err.Error()) {
+				out[i] = slog.String(key, sanitizeString(err.Error()))
+				continue
+			}
+			out[i] = slog.Attr{Key: key, Value: v}
+		default:
+			out[i] = slog.Attr{Key: key, Value: v}
+		}
+	}
+	return out
+}
+
+// needsSanitize 判断 s 是否包含无效 UTF-8 或需要去除的控制字符
+func needsSanitize(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < 0x20 && c != '\t' && c != '\n') || c == 0x7f || c >= utf8.RuneSelf {
+			return !isCleanTail(s[i:])
+		}
+	}
+	return false
+}
+
+// isCleanTail 逐个 rune 检查 s，用于 needsSanitize 遇到非 ASCII 字节后的慢路径
+func isCleanTail(s string) bool {
+	for _, r := range s {
+		if r == utf8.RuneError || isStripped(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isStripped 判断 rune 是否为需要去除的控制字符：
+// 除制表符和换行外的 C0 控制字符（包括回车）、DEL 和 C1 控制字符
+func isStripped(r rune) bool {
+	switch {
+	case r == '\t' || r == '\n':
+		return false
+	case r < 0x20 || r == 0x7f:
+		return true
+	case r >= 0x80 && r <= 0x9f:
+		return true
+	default:
+		return false
+	}
+}
+
+// sanitizeString 将无效 UTF-8 替换为 U+FFFD，去除 ANSI 转义序列和其他控制字符，
+// 保留制表符和换行（由格式化器按各自规则转义）
+func sanitizeString(s string) string {
+	if !needsSanitize(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			b.WriteRune(utf8.RuneError)
+		case r == 0x1b:
+			size = escapeLen(s[i:])
+		case isStripped(r):
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// escapeLen 返回 s 开头 ANSI 转义序列的长度（s[0] 为 ESC）。
+//
+// 支持 CSI（ESC [ ... 终止字节）和 OSC（ESC ] ... BEL 或 ESC \），
+// 其他序列视为 ESC 加一个字符。
+func escapeLen(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	default:
+		_, size := utf8.DecodeRuneInString(s[1:])
+		return 1 + size
+	}
+}
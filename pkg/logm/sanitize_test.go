@@ -0,0 +1,63 @@
+package logm
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeString(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"clean", "hello 世界", "hello 世界"},
+		{"tab and newline kept", "a\tb\nc", "a\tb\nc"},
+		{"carriage return", "ok\rFAKE", "okFAKE"},
+		{"invalid utf8", "a\xffb\xc3", "a�b�"},
+		{"csi color", "\x1b[31mred\x1b[0m", "red"},
+		{"csi cursor", "x\x1b[2Ky", "xy"},
+		{"osc title bel", "\x1b]0;pwned\x07after", "after"},
+		{"osc title st", "\x1b]0;pwned\x1b\\after", "after"},
+		{"bare esc", "a\x1bcb", "ab"},
+		{"trailing esc", "a\x1b", "a"},
+		{"c0 and del", "a\x00b\x07c\x7fd", "abcd"},
+		{"c1", "a\u009bb", "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sanitizeString(tt.in))
+			assert.Equal(t, tt.in != tt.want, needsSanitize(tt.in))
+		})
+	}
+}
+
+func TestWithSanitize(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(WithSanitize(), WithFormatter(formatter.ColorText(formatter.WithColor(false))), WithWriter(&testWriter{buf: &buf}))
+
+	logger.Info("user \x1b[2J\x1b[Hinput",
+		"name", "\x1b[31mmallory\x1b[0m",
+		slog.Group("req", "path", "/a\xffb"),
+		"err", errors.New("boom\x1b]0;x\x07"),
+		"n", 1,
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "\x1b")
+	assert.Contains(t, out, "user input")
+	assert.Contains(t, out, `name="mallory"`)
+	assert.Contains(t, out, `req.path="/a`+"�"+`b"`)
+	assert.Contains(t, out, `err="boom"`)
+	assert.Contains(t, out, "n=1")
+}
+
+func TestSanitizeRecord_NoCopyWhenClean(t *testing.T) {
+	attrs := []slog.Attr{slog.String("a", "ok"), slog.Int("n", 1)}
+	rec := &Record{Message: "clean", Attrs: attrs}
+	sanitizeRecord(rec)
+	assert.Equal(t, &attrs[0], &rec.Attrs[0])
+}
@@ -0,0 +1,286 @@
+package logm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// SchemaDialect Schema 输出的字段命名约定
+type SchemaDialect string
+
+const (
+	// SchemaJSON 按 logm JSON 输出的字段描述
+	SchemaJSON SchemaDialect = "json"
+	// SchemaECS 在 SchemaJSON 的基础上为每个字段标注对应的 Elastic Common Schema 字段（x-ecs-field），
+	// 供采集端配置字段重命名
+	SchemaECS SchemaDialect = "ecs"
+)
+
+// schemaDraft Schema 使用的 JSON Schema 版本
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// ecsFields logm 字段到 ECS 字段的映射，分组内的字段使用点号路径
+var ecsFields = map[string]string{
+	"time":       "@timestamp",
+	"level":      "log.level",
+	"msg":        "message",
+	"source":     "log.origin.file.name",
+	ErrorKey:     "error.message",
+	LoggerKey:    "log.logger",
+	EventKey:     "event.action",
+	SeqKey:       "event.sequence",
+	"host.name":  "host.name",
+	"host.ip":    "host.ip",
+	"host.os":    "host.os.platform",
+	"trace_id":   "trace.id",
+	"span_id":    "span.id",
+	"request_id": "http.request.id",
+	GoroutineKey: "process.thread.id",
+}
+
+// schemaProbe 探测日志的消息
+const schemaProbe = "logm schema probe"
+
+// Schema 返回按 opts 配置的 logger 输出日志的 JSON Schema，用于和采集端核对字段约定。
+//
+// Schema 以 ERROR 级别向 opts 组成的管道发送一条探测日志（不写出），
+// 根据格式化结果推断字段和类型，因此包含：
+//   - 内置字段 time、level、msg，启用 WithAddSource 时的 source
+//   - WithDefaultAttrs 的默认属性和拦截器附加的属性（如 WithSequence、WithHostInfo）
+//   - DefineEvent 注册的事件：event 为对应事件名时要求的字段
+//
+// 格式化器输出不是 JSON 时（Text 等）按 formatter.JSON 推断类型，字段名相同。
+// 调用处附加的属性无法静态得知，Schema 允许额外字段。
+func Schema(dialect SchemaDialect, opts ...Option) (map[string]any, error) {
+	if dialect != SchemaJSON && dialect != SchemaECS {
+		return nil, fmt.Errorf("logm: unknown schema dialect %q", dialect)
+	}
+
+	capture := &schemaCapture{}
+	opts = append(slices.Clone(opts), func(o *options) {
+		// 替换写入目标，已创建的 writer 不再使用
+		for _, w := range o.writers {
+			_ = w.Close()
+		}
+		o.writers = []Writer{schemaDiscard{}}
+		if o.flags != nil {
+			for _, w := range o.flags.Sinks {
+				_ = w.Close()
+			}
+			o.flags = nil
+		}
+		if o.formatter == nil {
+			o.formatter = o.defaultFormatter()
+		}
+		capture.inner = o.formatter
+		o.formatter = capture
+	})
+	h := newHandler(opts...)
+	slog.New(h).Error(schemaProbe)
+	_ = h.Close()
+
+	if capture.record == nil {
+		return nil, errors.New("logm: schema probe record was dropped by the level or an interceptor")
+	}
+	fields, err := capture.fields()
+	if err != nil {
+		return nil, err
+	}
+
+	props := make(map[string]any, len(fields))
+	required := make([]string, 0, len(fields))
+	for key, v := range fields {
+		p := jsonValueSchema(v)
+		switch key {
+		case "time":
+			p["description"] = "record time"
+		case "level":
+			p = map[string]any{
+				"type":        "string",
+				"pattern":     `^(DEBUG|INFO|WARN|ERROR)([+-][0-9]+)?$`,
+				"description": "level; custom levels carry an offset such as INFO+2",
+			}
+		case "msg":
+			p["description"] = "message"
+		case "source":
+			p["pattern"] = `:[0-9]+$`
+			p["description"] = "caller location as file:line"
+		}
+		props[key] = p
+		required = append(required, key)
+	}
+	slices.Sort(required)
+
+	var rules []any
+	for _, e := range Events() {
+		rules = append(rules, eventSchema(e))
+	}
+	if _, ok := props[EventKey]; !ok && len(rules) > 0 {
+		props[EventKey] = map[string]any{"type": "string", "description": "event name registered with DefineEvent"}
+	}
+
+	if dialect == SchemaECS {
+		annotateECS(props, "")
+	}
+	schema := map[string]any{
+		"$schema":              schemaDraft,
+		"title":                "logm record",
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": true,
+	}
+	if len(rules) > 0 {
+		schema["allOf"] = rules
+	}
+	return schema, nil
+}
+
+// defaultFormatter 返回未设置 WithFormatter 时使用的 Text 格式化器
+func (o *options) defaultFormatter() Formatter {
+	return formatter.Text(
+		formatter.WithTimeFormat(o.timeFormat),
+		formatter.WithTimePrecision(o.precision),
+		formatter.WithTimezone(o.timezone),
+	)
+}
+
+// schemaCapture 记录探测日志的格式化器
+type schemaCapture struct {
+	inner  Formatter
+	record *Record
+	output []byte
+}
+
+// Format 实现 Formatter 接口。
+func (c *schemaCapture) Format(r *Record) ([]byte, error) {
+	data, err := c.inner.Format(r)
+	if err != nil {
+		return nil, err
+	}
+	c.record = r.Clone()
+	c.output = data
+	return data, nil
+}
+
+// fields 解析探测日志的 JSON 输出，不是 JSON 时按 formatter.JSON 重新格式化
+func (c *schemaCapture) fields() (map[string]any, error) {
+	data := bytes.TrimSpace(c.output)
+	if len(data) == 0 || data[0] != '{' || !json.Valid(data) {
+		var err error
+		if data, err = formatter.JSON().Format(c.record); err != nil {
+			return nil, err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("logm: schema probe output: %w", err)
+	}
+	return fields, nil
+}
+
+// schemaDiscard 丢弃探测日志的输出
+type schemaDiscard struct{}
+
+func (schemaDiscard) Write(p []byte) (int, error) { return len(p), nil }
+func (schemaDiscard) Close() error                { return nil }
+func (schemaDiscard) Sync() error                 { return nil }
+
+// jsonValueSchema 返回 JSON 值对应的 Schema，RFC 3339 时间字符串标注 date-time
+func jsonValueSchema(v any) map[string]any {
+	switch v := v.(type) {
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return map[string]any{"type": "number"}
+		}
+		return map[string]any{"type": "integer"}
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		return map[string]any{"type": "string"}
+	case bool:
+		return map[string]any{"type": "boolean"}
+	case []any:
+		return map[string]any{"type": "array"}
+	case map[string]any:
+		props := make(map[string]any, len(v))
+		required := make([]string, 0, len(v))
+		for key, sub := range v {
+			props[key] = jsonValueSchema(sub)
+			required = append(required, key)
+		}
+		slices.Sort(required)
+		return map[string]any{"type": "object", "properties": props, "required": required}
+	default:
+		return map[string]any{"type": "null"}
+	}
+}
+
+// kindSchema 返回 slog.Kind 在 JSON 输出中对应的 Schema，KindAny 不限类型
+func kindSchema(kind slog.Kind) map[string]any {
+	switch kind {
+	case slog.KindString, slog.KindDuration:
+		return map[string]any{"type": "string"}
+	case slog.KindInt64, slog.KindUint64:
+		return map[string]any{"type": "integer"}
+	case slog.KindFloat64:
+		return map[string]any{"type": "number"}
+	case slog.KindBool:
+		return map[string]any{"type": "boolean"}
+	case slog.KindTime:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case slog.KindGroup:
+		return map[string]any{"type": "object"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// eventSchema 返回事件的条件规则：event 为事件名时要求必填字段并约束字段类型
+func eventSchema(e *EventType) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+	for _, f := range e.Fields() {
+		props[f.Key] = kindSchema(f.Kind)
+		if !f.Optional {
+			required = append(required, f.Key)
+		}
+	}
+	return map[string]any{
+		"if": map[string]any{
+			"properties": map[string]any{EventKey: map[string]any{"const": e.Name()}},
+			"required":   []string{EventKey},
+		},
+		"then": map[string]any{
+			"properties": props,
+			"required":   required,
+		},
+	}
+}
+
+// annotateECS 为有 ECS 对应字段的属性添加 x-ecs-field，prefix 为分组路径
+func annotateECS(props map[string]any, prefix string) {
+	for key, p := range props {
+		p, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		if f, ok := ecsFields[prefix+key]; ok {
+			p["x-ecs-field"] = f
+		}
+		if sub, ok := p["properties"].(map[string]any); ok {
+			annotateECS(sub, prefix+key+".")
+		}
+	}
+}
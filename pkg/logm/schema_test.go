@@ -0,0 +1,87 @@
+package logm
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_Fields(t *testing.T) {
+	s, err := Schema(SchemaJSON,
+		WithFormatter(formatter.JSON(formatter.WithTimeFormat("rfc3339ms"))),
+		WithAddSource(true),
+		WithDefaultAttrs(slog.String("service", "billing"), slog.Group("build", slog.Int("rev", 42))),
+		WithSequence(),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, schemaDraft, s["$schema"])
+	assert.Equal(t, []string{"build", "level", "msg", SeqKey, "service", "source", "time"}, s["required"])
+	props := s["properties"].(map[string]any)
+	assert.Equal(t, "date-time", props["time"].(map[string]any)["format"])
+	assert.Equal(t, "integer", props[SeqKey].(map[string]any)["type"])
+	assert.Equal(t, "string", props["service"].(map[string]any)["type"])
+	assert.Contains(t, props["source"], "pattern")
+
+	build := props["build"].(map[string]any)
+	assert.Equal(t, "object", build["type"])
+	assert.Equal(t, "integer", build["properties"].(map[string]any)["rev"].(map[string]any)["type"])
+
+	_, err = json.Marshal(s)
+	assert.NoError(t, err)
+}
+
+func TestSchema_TextFormatterAndTimeFormat(t *testing.T) {
+	s, err := Schema(SchemaJSON, WithFormatter(formatter.Text()), WithDefaultAttrs(slog.Bool("canary", true)))
+	require.NoError(t, err)
+	props := s["properties"].(map[string]any)
+	assert.Equal(t, "boolean", props["canary"].(map[string]any)["type"])
+	assert.NotContains(t, props, "source")
+
+	s, err = Schema(SchemaJSON, WithFormatter(formatter.JSON(formatter.WithTimeFormat("unixms"))))
+	require.NoError(t, err)
+	assert.Equal(t, "integer", s["properties"].(map[string]any)["time"].(map[string]any)["type"])
+}
+
+func TestSchema_Events(t *testing.T) {
+	s, err := Schema(SchemaJSON, WithFormatter(formatter.JSON()))
+	require.NoError(t, err)
+
+	var rule map[string]any
+	for _, r := range s["allOf"].([]any) {
+		r := r.(map[string]any)
+		cond := r["if"].(map[string]any)["properties"].(map[string]any)[EventKey].(map[string]any)
+		if cond["const"] == testSignupEvent.Name() {
+			rule = r["then"].(map[string]any)
+		}
+	}
+	require.NotNil(t, rule)
+	assert.Equal(t, []string{"user_id", "plan"}, rule["required"])
+	fields := rule["properties"].(map[string]any)
+	assert.Equal(t, "integer", fields["user_id"].(map[string]any)["type"])
+	assert.Empty(t, fields["meta"])
+	assert.Contains(t, s["properties"], EventKey)
+}
+
+func TestSchema_ECS(t *testing.T) {
+	s, err := Schema(SchemaECS, WithFormatter(formatter.JSON()), WithHostInfo())
+	require.NoError(t, err)
+	props := s["properties"].(map[string]any)
+	assert.Equal(t, "@timestamp", props["time"].(map[string]any)["x-ecs-field"])
+	assert.Equal(t, "log.level", props["level"].(map[string]any)["x-ecs-field"])
+	host := props[HostKey].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, "host.name", host["name"].(map[string]any)["x-ecs-field"])
+
+	_, err = Schema("avro")
+	assert.Error(t, err)
+}
+
+func TestSchema_ProbeDropped(t *testing.T) {
+	_, err := Schema(SchemaJSON, WithInterceptor(func(_ context.Context, _ *Record) *Record { return nil }))
+	assert.ErrorContains(t, err, "dropped")
+}
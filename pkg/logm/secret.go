@@ -0,0 +1,74 @@
+package logm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// revealSecrets 为 true 时 Sensitive 输出原始值，见 UnsafeRevealSecrets
+var revealSecrets atomic.Bool
+
+// Sensitive 包装敏感值，记录日志时输出 "[REDACTED]"。
+//
+// 可直接作为结构体字段类型，嵌套在任意结构中同样生效：
+//
+//	type LoginRequest struct {
+//	    User     string                   `json:"user"`
+//	    Password logm.Sensitive[string]   `json:"password"`
+//	}
+//
+// 除 LogValue 外，String、GoString 和 MarshalJSON 同样输出占位文本，
+// 避免经 fmt 或 encoding/json 间接输出时泄漏原始值。
+type Sensitive[T any] struct {
+	v T
+}
+
+// Redacted 将 v 包装为 Sensitive：
+//
+//	slog.Info("connect", "dsn", logm.Redacted(dsn))
+func Redacted[T any](v T) Sensitive[T] {
+	return Sensitive[T]{v: v}
+}
+
+// Value 返回原始值。
+func (s Sensitive[T]) Value() T {
+	return s.v
+}
+
+// LogValue 实现 slog.LogValuer。
+func (s Sensitive[T]) LogValue() slog.Value {
+	if revealSecrets.Load() {
+		return slog.AnyValue(s.v)
+	}
+	return slog.StringValue(RedactedValue)
+}
+
+// String 实现 fmt.Stringer。
+func (s Sensitive[T]) String() string {
+	if revealSecrets.Load() {
+		return fmt.Sprint(s.v)
+	}
+	return RedactedValue
+}
+
+// GoString 实现 fmt.GoStringer，%#v 同样不输出原始值。
+func (s Sensitive[T]) GoString() string {
+	return s.String()
+}
+
+// MarshalJSON 实现 json.Marshaler。
+func (s Sensitive[T]) MarshalJSON() ([]byte, error) {
+	if revealSecrets.Load() {
+		return json.Marshal(s.v)
+	}
+	return json.Marshal(RedactedValue)
+}
+
+// UnsafeRevealSecrets 设置 Sensitive（包括 Secret 属性）是否输出原始值。
+//
+// 仅用于本地开发调试，不要在生产环境开启。该设置是全局的，对所有 logger 生效。
+func UnsafeRevealSecrets(enable bool) {
+	revealSecrets.Store(enable)
+}
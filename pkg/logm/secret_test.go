@@ -0,0 +1,67 @@
+package logm
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type secretLogin struct {
+	User     string            `json:"user"`
+	Password Sensitive[string] `json:"password"`
+}
+
+func TestRedacted(t *testing.T) {
+	s := Redacted("hunter2")
+
+	assert.Equal(t, "hunter2", s.Value())
+	assert.Equal(t, RedactedValue, s.String())
+	assert.NotContains(t, fmt.Sprintf("%v %+v %#v %s", s, s, s, s), "hunter2")
+
+	data, err := json.Marshal(secretLogin{User: "alice", Password: s})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"user":"alice","password":"[REDACTED]"}`, string(data))
+}
+
+func TestRedacted_Formatters(t *testing.T) {
+	for name, f := range map[string]formatter.Formatter{
+		"json":       formatter.JSON(),
+		"text":       formatter.Text(),
+		"color_text": formatter.ColorText(),
+		"color_json": formatter.ColorJSON(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			logger, buf := newBufferLogger(f)
+
+			logger.Info("login",
+				"dsn", Redacted("postgres://u:p@db"),
+				"req", secretLogin{User: "alice", Password: Redacted("hunter2")},
+				Secret("token", "abc123"),
+			)
+
+			out := buf.String()
+			assert.Contains(t, out, "alice")
+			for _, raw := range []string{"postgres://", "hunter2", "abc123"} {
+				assert.NotContains(t, out, raw)
+			}
+		})
+	}
+}
+
+func TestUnsafeRevealSecrets(t *testing.T) {
+	UnsafeRevealSecrets(true)
+	t.Cleanup(func() { UnsafeRevealSecrets(false) })
+
+	logger, buf := newBufferLogger(formatter.JSON())
+	logger.Info("login", "req", secretLogin{User: "alice", Password: Redacted("hunter2")}, Secret("token", "abc123"))
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, "abc123", m["token"])
+	assert.Equal(t, map[string]any{"user": "alice", "password": "hunter2"}, m["req"])
+	assert.Equal(t, "hunter2", Redacted("hunter2").String())
+}
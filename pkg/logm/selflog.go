@@ -0,0 +1,28 @@
+package logm
+
+import (
+	"io"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// SetSelfLog 设置 logm 自诊断输出目标。
+//
+// logm 自身的故障（格式化失败、超长丢弃、写入错误、Async 缓冲区满、时区加载失败等）
+// 无法通过日志管道本身上报，默认以极简格式输出到 stderr：
+//
+//	logm: 2024-01-15T10:30:45Z [async.drop] async buffer full (size 1000), dropped 1 records so far
+//
+// 传入 nil 关闭自诊断输出。
+func SetSelfLog(w io.Writer) {
+	selflog.SetOutput(w)
+}
+
+// SetSelfLogInterval 设置自诊断输出的限流间隔。
+//
+// 同一类别的消息在间隔内只输出一次，期间被抑制的条数会附加在下一次输出中。
+// 默认 10 秒，<= 0 表示不限流。
+func SetSelfLogInterval(d time.Duration) {
+	selflog.SetInterval(d)
+}
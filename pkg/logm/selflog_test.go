@@ -0,0 +1,30 @@
+package logm
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetSelfLog(t *testing.T) {
+	var out bytes.Buffer
+	SetSelfLog(&out)
+	SetSelfLogInterval(0)
+	defer func() {
+		SetSelfLog(os.Stderr)
+		SetSelfLogInterval(selflog.DefaultInterval)
+	}()
+
+	var buf bytes.Buffer
+	logger := New(WithWriter(&testWriter{buf: &buf}), WithWriter(&errWriter{}))
+	logger.Info("hello")
+
+	assert.Contains(t, out.String(), "[write] write to logm.errWriter#1 failed: write failed")
+
+	out.Reset()
+	_ = New(WithTimezone("Invalid/Zone"))
+	assert.Contains(t, out.String(), `[timezone] load timezone "Invalid/Zone" failed`)
+}
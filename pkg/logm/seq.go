@@ -0,0 +1,25 @@
+package logm
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// SeqKey WithSequence 附加的序号属性键名
+const SeqKey = "seq"
+
+// WithSequence 为每条日志附加从 1 开始单调递增的 seq 属性。
+//
+// 下游按 seq 检查缺口和乱序，可以发现 Async、批量发送等环节丢弃或打乱的日志。
+// 序号在进入 Handler 时以一次原子加法分配，每次调用 WithSequence 使用独立的计数器；
+// 多个 goroutine 并发记录时，相邻序号的写出顺序可能互换。
+//
+// 被级别过滤的日志不占用序号；被拦截器丢弃或因超长被丢弃的日志会留下缺口。
+func WithSequence() Option {
+	var seq atomic.Uint64
+	return WithInterceptor(func(_ context.Context, r *Record) *Record {
+		r.Attrs = append(r.Attrs, slog.Uint64(SeqKey, seq.Add(1)))
+		return r
+	})
+}
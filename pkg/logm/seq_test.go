@@ -0,0 +1,59 @@
+package logm
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSequence(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(WithSequence(), WithLevel("INFO"), WithFormatter(formatter.JSON()), WithWriter(&testWriter{buf: &buf}))
+
+	logger.Info("a")
+	logger.Debug("filtered")
+	logger.Warn("b")
+
+	var seqs []float64
+	for line := range bytes.Lines(buf.Bytes()) {
+		var m map[string]any
+		require.NoError(t, json.Unmarshal(line, &m))
+		seqs = append(seqs, m[SeqKey].(float64))
+	}
+	assert.Equal(t, []float64{1, 2}, seqs)
+}
+
+func TestWithSequence_Concurrent(t *testing.T) {
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	logger := New(WithSequence(), WithFormatter(formatter.JSON()), WithWriter(&lockedWriter{mu: &mu, buf: &buf}))
+
+	const goroutines, perG = 8, 100
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Go(func() {
+			for range perG {
+				logger.Info("x")
+			}
+		})
+	}
+	wg.Wait()
+
+	seen := make(map[uint64]bool)
+	for line := range bytes.Lines(buf.Bytes()) {
+		var m struct {
+			Seq uint64 `json:"seq"`
+		}
+		require.NoError(t, json.Unmarshal(line, &m))
+		seen[m.Seq] = true
+	}
+	require.Len(t, seen, goroutines*perG)
+	for i := uint64(1); i <= goroutines*perG; i++ {
+		assert.True(t, seen[i], "missing seq %d", i)
+	}
+}
@@ -0,0 +1,153 @@
+package logm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// FlushTimeout FlushOnSignal 刷新日志的最长等待时间
+const FlushTimeout = 5 * time.Second
+
+// dumpOutput DumpOnSignal 的输出目标，测试时替换
+var dumpOutput io.Writer = os.Stderr
+
+// RotateOnSignal 在每次收到指定信号时调用 Rotate。
+//
+// 未指定信号时 Unix 上默认监听 SIGUSR2，Windows 上没有默认信号，不做任何监听。
+// 返回的 stop 函数用于取消监听。
+//
+//	logm.MustInit(logm.WithWriter(writer.Async(writer.File("/var/log/app.log"), 1000)))
+//	defer logm.RotateOnSignal()()
+//	// kill -USR2 <pid>
+func RotateOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = defaultRotateSignals
+	}
+	if len(sigs) == 0 {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case sig := <-ch:
+				if err := Rotate(); err != nil {
+					selflog.Printf("signal.rotate", "rotate on %v: %v", sig, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// FlushOnSignal 在收到指定信号时刷新并关闭全局日志系统。
+//
+// 未指定信号时默认监听 SIGINT 和 SIGTERM。收到信号后在 FlushTimeout 内执行 Shutdown，
+// 随后停止监听并将同一信号重新发送给当前进程：未注册其他处理器时进程按默认行为退出，
+// 应用自己的 signal.Notify 处理器仍会收到信号。
+// 容器停止时可借此避免丢失 Async 缓冲区尾部的日志。
+//
+// 返回的 stop 函数用于取消监听。
+//
+//	func main() {
+//	    logm.MustInit(logm.PresetProd()...)
+//	    defer logm.FlushOnSignal(syscall.SIGTERM, syscall.SIGINT)()
+//	    // ...
+//	}
+func FlushOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			ctx, cancel := context.WithTimeout(context.Background(), FlushTimeout)
+			if dropped, err := Shutdown(ctx); err != nil || dropped > 0 {
+				selflog.Printf("signal", "shutdown on %v: dropped %d records, err=%v", sig, dropped, err)
+			}
+			cancel()
+
+			signal.Stop(ch)
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				_ = p.Signal(sig)
+			}
+		case <-done:
+			signal.Stop(ch)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// DumpOnSignal 在收到指定信号时将日志系统的内部状态输出到 stderr，用于排查"日志为什么停了"。
+//
+// 输出内容见 DebugState：生效配置、Writer 健康状态、队列深度、丢弃计数和最近的自诊断消息。
+// 未指定信号时 Unix 上默认监听 SIGQUIT，Windows 上没有默认信号，不做任何监听。
+// 输出后停止监听并将同一信号重新发送给当前进程：SIGQUIT 按 Go 运行时的默认行为
+// 继续输出所有 goroutine 的堆栈并退出，logm 的状态紧接在堆栈之前。
+//
+// 返回的 stop 函数用于取消监听。
+//
+//	logm.MustInit(logm.PresetProd()...)
+//	defer logm.DumpOnSignal()()
+//	// kill -QUIT <pid>
+func DumpOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = defaultDumpSignals
+	}
+	if len(sigs) == 0 {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			fmt.Fprintf(dumpOutput, "logm: state dump on %v\n", sig)
+			_ = GetDebugState().WriteText(dumpOutput)
+			fmt.Fprintln(dumpOutput)
+
+			signal.Stop(ch)
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				_ = p.Signal(sig)
+			}
+		case <-done:
+			signal.Stop(ch)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
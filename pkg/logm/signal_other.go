@@ -0,0 +1,11 @@
+//go:build !unix
+
+package logm
+
+import "os"
+
+// defaultRotateSignals RotateOnSignal 默认监听的信号，非 Unix 平台没有 SIGUSR2
+var defaultRotateSignals []os.Signal
+
+// defaultDumpSignals DumpOnSignal 默认监听的信号，非 Unix 平台没有 SIGQUIT
+var defaultDumpSignals []os.Signal
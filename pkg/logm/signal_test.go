@@ -0,0 +1,131 @@
+//go:build unix
+
+package logm
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlushOnSignal(t *testing.T) {
+	// 测试自身也监听信号，避免重新发送的信号终止测试进程
+	appCh := make(chan os.Signal, 2)
+	signal.Notify(appCh, syscall.SIGUSR1)
+	defer signal.Stop(appCh)
+
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	aw := writer.Async(&lockedWriter{mu: &mu, buf: &buf}, 100)
+	require.NoError(t, Init(WithWriter(aw)))
+	stop := FlushOnSignal(syscall.SIGUSR1)
+	defer stop()
+
+	Info("tail record")
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	// 应用处理器收到原始信号和刷新后重新发送的信号
+	for range 2 {
+		select {
+		case <-appCh:
+		case <-time.After(time.Second):
+			t.Fatal("signal not received")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, buf.String(), "tail record")
+}
+
+func TestFlushOnSignal_Stop(t *testing.T) {
+	stop := FlushOnSignal(syscall.SIGUSR2)
+	assert.NotPanics(t, func() {
+		stop()
+		stop()
+	})
+}
+
+func TestRotateOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, Init(WithWriter(writer.Async(writer.File(path, writer.WithCompress(false)), 100))))
+	defer func() { _ = Close() }()
+	stop := RotateOnSignal()
+	defer stop()
+
+	Info("before rotate")
+	require.NoError(t, Sync())
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+
+	require.Eventually(t, func() bool {
+		entries, err := os.ReadDir(dir)
+		return err == nil && len(entries) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDumpOnSignal(t *testing.T) {
+	appCh := make(chan os.Signal, 2)
+	signal.Notify(appCh, syscall.SIGUSR1)
+	defer signal.Stop(appCh)
+
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	dumpOutput = &lockedWriter{mu: &mu, buf: &buf}
+	defer func() { dumpOutput = os.Stderr }()
+
+	require.NoError(t, Init(WithWriter(&lockedWriter{mu: &mu, buf: &bytes.Buffer{}})))
+	defer func() { _ = Close() }()
+	selflog.Printf("test.dump", "writer stalled")
+	stop := DumpOnSignal(syscall.SIGUSR1)
+	defer stop()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	for range 2 {
+		select {
+		case <-appCh:
+		case <-time.After(time.Second):
+			t.Fatal("signal not received")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	out := buf.String()
+	assert.Contains(t, out, "logm: state dump on user defined signal 1")
+	assert.Contains(t, out, "writers: 1")
+	assert.Contains(t, out, "[test.dump] writer stalled")
+}
+
+func TestDumpOnSignal_Stop(t *testing.T) {
+	stop := DumpOnSignal(syscall.SIGUSR2)
+	assert.NotPanics(t, func() {
+		stop()
+		stop()
+	})
+}
+
+// lockedWriter 加锁写入 buffer 的 Writer
+type lockedWriter struct {
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+}
+
+func (w *lockedWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *lockedWriter) Close() error { return nil }
+func (w *lockedWriter) Sync() error  { return nil }
@@ -0,0 +1,14 @@
+//go:build unix
+
+package logm
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultRotateSignals RotateOnSignal 默认监听的信号
+var defaultRotateSignals = []os.Signal{syscall.SIGUSR2}
+
+// defaultDumpSignals DumpOnSignal 默认监听的信号
+var defaultDumpSignals = []os.Signal{syscall.SIGQUIT}
@@ -0,0 +1,119 @@
+package logm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// SpanEvent 写入追踪 span 的日志事件
+type SpanEvent struct {
+	// Name 日志消息
+	Name string
+	// Time 日志时间
+	Time time.Time
+	// Level 日志级别
+	Level slog.Level
+	// Attrs 平铺并解析后的属性，分组内的键为 group.key 形式
+	Attrs []slog.Attr
+	// Err 第一个 error 类型的属性值，没有时为 nil；Err(err) 生成的分组还原为同消息的 error
+	Err error
+}
+
+// SpanSink 将日志事件写入 ctx 中的活跃 span，ctx 没有活跃 span 时不做任何操作。
+//
+// 返回的属性追加到日志记录上，通常为 trace_id、span_id，使日志和追踪可以互相跳转。
+type SpanSink func(ctx context.Context, e SpanEvent) []slog.Attr
+
+// WithSpanEvents 将不低于 level 的日志同时记录为 ctx 中活跃 span 的事件。
+//
+// logm 不依赖具体的追踪库，OTel 的适配如下：
+//
+//	logm.WithSpanEvents(slog.LevelError, func(ctx context.Context, e logm.SpanEvent) []slog.Attr {
+//	    span := trace.SpanFromContext(ctx)
+//	    if !span.IsRecording() {
+//	        return nil
+//	    }
+//	    kvs := make([]attribute.KeyValue, 0, len(e.Attrs))
+//	    for _, a := range e.Attrs {
+//	        kvs = append(kvs, attribute.String(a.Key, a.Value.String()))
+//	    }
+//	    span.AddEvent(e.Name, trace.WithTimestamp(e.Time), trace.WithAttributes(kvs...))
+//	    if e.Err != nil {
+//	        span.RecordError(e.Err)
+//	    }
+//	    span.SetStatus(codes.Error, e.Name)
+//	    sc := span.SpanContext()
+//	    return []slog.Attr{slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String())}
+//	})
+//
+// 以拦截器实现，事件在拦截器链中的这一位置生成，过滤日志的拦截器应在它之前添加。
+// 需要通过 slog.ErrorContext 等带 context 的方法记录日志才能关联到 span。
+func WithSpanEvents(level slog.Level, sink SpanSink) Option {
+	return WithInterceptor(func(ctx context.Context, r *Record) *Record {
+		if r.Level < level || ctx == nil {
+			return r
+		}
+		e := SpanEvent{Name: r.Message, Time: r.Time, Level: r.Level}
+		e.Attrs, e.Err = flattenSpanAttrs(e.Attrs, r.Attrs, strings.Join(r.Groups, "."), nil)
+		if attrs := sink(ctx, e); len(attrs) > 0 {
+			// 关联属性不受 WithGroup 影响，放在记录的顶层
+			ungroupRecord(r)
+			r.Attrs = append(r.Attrs, attrs...)
+		}
+		return r
+	})
+}
+
+// ungroupRecord 将 r.Groups 折叠为嵌套的分组属性，之后追加的属性位于顶层
+func ungroupRecord(r *Record) {
+	if len(r.Groups) == 0 {
+		return
+	}
+	attrs := r.Attrs
+	for i := len(r.Groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: r.Groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+	r.Attrs = attrs
+	r.Groups = nil
+}
+
+// flattenSpanAttrs 平铺属性并解析 LogValuer，返回属性和第一个 error 值
+func flattenSpanAttrs(dst, attrs []slog.Attr, prefix string, err error) ([]slog.Attr, error) {
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		key := a.Key
+		if prefix != "" && key != "" {
+			key = prefix + "." + key
+		} else if key == "" {
+			key = prefix
+		}
+		if v.Kind() == slog.KindGroup {
+			if err == nil && a.Key == ErrorKey {
+				err = groupError(v.Group())
+			}
+			dst, err = flattenSpanAttrs(dst, v.Group(), key, err)
+			continue
+		}
+		if a.Key == "" {
+			continue
+		}
+		if err == nil && v.Kind() == slog.KindAny {
+			err, _ = v.Any().(error)
+		}
+		dst = append(dst, slog.Attr{Key: key, Value: v})
+	}
+	return dst, err
+}
+
+// groupError 将 Err 生成的分组还原为 error，没有 msg 字段时返回 nil
+func groupError(attrs []slog.Attr) error {
+	for _, a := range attrs {
+		if a.Key == "msg" {
+			return errors.New(a.Value.String())
+		}
+	}
+	return nil
+}
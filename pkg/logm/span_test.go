@@ -0,0 +1,82 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// spanKey context 中存储测试 span 的键
+type spanKey struct{}
+
+// testSpan 记录收到的事件
+type testSpan struct {
+	id     string
+	events []SpanEvent
+}
+
+func testSpanSink(ctx context.Context, e SpanEvent) []slog.Attr {
+	span, ok := ctx.Value(spanKey{}).(*testSpan)
+	if !ok {
+		return nil
+	}
+	span.events = append(span.events, e)
+	return []slog.Attr{slog.String("span_id", span.id)}
+}
+
+func TestWithSpanEvents(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(
+		WithFormatter(formatter.JSON()),
+		WithWriter(&testWriter{buf: &buf}),
+		WithSpanEvents(slog.LevelError, testSpanSink),
+	)
+	span := &testSpan{id: "s-1"}
+	ctx := context.WithValue(context.Background(), spanKey{}, span)
+	boom := errors.New("boom")
+
+	logger.InfoContext(ctx, "below level")
+	logger.ErrorContext(ctx, "query failed", Err(boom), slog.Group("db", slog.String("table", "users")), Secret("dsn", "pw"))
+	logger.ErrorContext(ctx, "raw error", "cause", boom)
+	logger.Error("no span")
+
+	require.Len(t, span.events, 2)
+	e := span.events[0]
+	assert.Equal(t, "query failed", e.Name)
+	assert.Equal(t, slog.LevelError, e.Level)
+	assert.EqualError(t, e.Err, "boom")
+	assert.Equal(t, []slog.Attr{
+		slog.String("error.msg", "boom"),
+		slog.String("error.type", "*errors.errorString"),
+		slog.String("db.table", "users"),
+		slog.String("dsn", RedactedValue),
+	}, e.Attrs)
+	assert.Same(t, boom, span.events[1].Err)
+
+	assert.Contains(t, buf.String(), `"msg":"query failed",`)
+	assert.Contains(t, buf.String(), `"span_id":"s-1"`)
+	assert.NotContains(t, buf.String(), `"msg":"below level","span_id"`)
+}
+
+func TestWithSpanEvents_Groups(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(
+		WithFormatter(formatter.JSON()),
+		WithWriter(&testWriter{buf: &buf}),
+		WithSpanEvents(slog.LevelWarn, testSpanSink),
+	).WithGroup("http").WithGroup("req")
+	span := &testSpan{id: "s-2"}
+	ctx := context.WithValue(context.Background(), spanKey{}, span)
+
+	logger.WarnContext(ctx, "slow", "ms", 900)
+
+	require.Len(t, span.events, 1)
+	assert.Equal(t, []slog.Attr{slog.Int("http.req.ms", 900)}, span.events[0].Attrs)
+	assert.Contains(t, buf.String(), `"http":{"req":{"ms":900}},"span_id":"s-2"}`)
+}
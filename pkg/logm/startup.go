@@ -0,0 +1,111 @@
+package logm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// StartupMessage LogStartup 输出日志的消息
+const StartupMessage = "logm started"
+
+// LogStartup 记录一条汇总当前生效配置的启动日志，用于在生产日志中确认实际生效的配置。
+//
+// 日志包含以下字段，args 追加在其后：
+//
+//	service       WithDefaultAttrs 中的 service 属性，未设置时为可执行文件名
+//	version       WithDefaultAttrs 中的 version 属性，未设置时为主模块版本
+//	pid           进程 id
+//	log_level     当前全局级别
+//	formatter     格式化器类型，如 formatter.JSONFormatter
+//	writers       Writer 列表，名称与 Stats 一致
+//	interceptors  拦截器函数名
+//	add_source    是否记录源代码位置
+//	timezone      时区
+//	pipelines     InitPipelines 创建的管道名称
+//
+// 启动日志以 INFO 级别直接交给全局 Handler，不受级别过滤，即使级别为 ERROR 也会输出：
+//
+//	logm.MustInit(logm.PresetProd()...)
+//	logm.LogStartup("listen", ":8080")
+func LogStartup(args ...any) {
+	globalMu.RLock()
+	h := globalHandler
+	defaults := globalDefaultAttrs
+	globalMu.RUnlock()
+
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // 跳过 Callers 和 LogStartup
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, StartupMessage, pcs[0])
+	r.AddAttrs(startupAttrs(h, defaults)...)
+	r.Add(args...)
+
+	handler := slog.Default().Handler()
+	if h != nil {
+		handler = h
+	}
+	_ = handler.Handle(context.Background(), r)
+}
+
+// startupAttrs 汇总 h 的配置，h 为 nil（未调用 Init）时只输出进程信息
+func startupAttrs(h *Handler, defaults []slog.Attr) []slog.Attr {
+	service := filepath.Base(os.Args[0])
+	version := ""
+	if a := BuildInfo().Value.Group(); len(a) > 0 && a[0].Key == "version" {
+		version = a[0].Value.String()
+	}
+	for _, a := range defaults {
+		switch a.Key {
+		case "service":
+			service = a.Value.String()
+		case "version":
+			version = a.Value.String()
+		}
+	}
+
+	attrs := []slog.Attr{
+		slog.String("service", service),
+		slog.String("version", version),
+		slog.Int("pid", os.Getpid()),
+	}
+	if h == nil {
+		return attrs
+	}
+
+	writers := make([]string, len(h.writers))
+	for i, w := range h.writers {
+		writers[i] = writerName(i, w)
+	}
+	interceptors := make([]string, len(h.interceptors))
+	for i, fn := range h.interceptors {
+		interceptors[i] = funcName(fn)
+	}
+	return append(attrs,
+		slog.String("log_level", LevelString(h.levelVar.Level())),
+		slog.String("formatter", strings.TrimPrefix(fmt.Sprintf("%T", h.formatter.load()), "*")),
+		slog.Any("writers", writers),
+		slog.Any("interceptors", interceptors),
+		slog.Bool("add_source", h.addSource),
+		slog.String("timezone", h.location.String()),
+		slog.Any("pipelines", globalProvider.Names()),
+	)
+}
+
+// funcName 返回函数的短名称，如 logm.goroutineInterceptor
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
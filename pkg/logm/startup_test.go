@@ -0,0 +1,58 @@
+package logm
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogStartup(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Init(
+		WithLevel("ERROR"),
+		WithFormatter(formatter.JSON()),
+		WithWriter(&testWriter{buf: &buf}),
+		WithDefaultAttrs(slog.String("service", "billing"), slog.String("version", "v1.2.3")),
+		WithGoroutineID(),
+		WithTimezone("UTC"),
+	))
+	t.Cleanup(func() { _ = Close() })
+
+	LogStartup("listen", ":8080")
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m), buf.String())
+	assert.Equal(t, StartupMessage, m["msg"])
+	assert.Equal(t, "billing", m["service"])
+	assert.Equal(t, "v1.2.3", m["version"])
+	assert.Equal(t, "ERROR", m["log_level"])
+	assert.Equal(t, "formatter.JSONFormatter", m["formatter"])
+	assert.Equal(t, []any{"logm.testWriter#0"}, m["writers"])
+	assert.Equal(t, []any{"logm.goroutineInterceptor"}, m["interceptors"])
+	assert.Equal(t, false, m["add_source"])
+	assert.Equal(t, "UTC", m["timezone"])
+	assert.Equal(t, ":8080", m["listen"])
+}
+
+func TestLogStartup_NotInitialized(t *testing.T) {
+	require.NoError(t, Close())
+
+	logger, buf := newBufferLogger(formatter.JSON())
+	old := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	LogStartup()
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m), buf.String())
+	assert.Equal(t, StartupMessage, m["msg"])
+	assert.Contains(t, m, "service")
+	assert.Contains(t, m, "pid")
+	assert.NotContains(t, m, "writers")
+}
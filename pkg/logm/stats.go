@@ -0,0 +1,133 @@
+package logm
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// HandlerStats 日志管道的运行统计。
+//
+// 用于判断日志是否丢失：Dropped、Oversized、FormatErrors 和
+// Writers[i].WriteErrors 任一持续增长都意味着有日志未能送达。
+type HandlerStats struct {
+	// Records 按级别（DEBUG/INFO/WARN/ERROR）统计已输出的日志数
+	Records map[string]uint64
+	// BytesWritten 所有 Writer 累计成功写入的字节数
+	BytesWritten uint64
+	// Dropped 累计丢弃的日志数，包括 Writer 内部丢弃（如 AsyncWriter 缓冲区满）
+	// 和 Handler 关闭后到达的日志
+	Dropped uint64
+	// Oversized 因超出 MaxRecordSize 被丢弃的日志数
+	Oversized uint64
+	// FormatErrors 格式化失败的日志数
+	FormatErrors uint64
+	// QueueDepth 所有 Writer 缓冲区中等待写入的日志数
+	QueueDepth int
+	// LastWriteError 最近一次写入错误，格式为 "writer: error"，无错误时为空
+	LastWriteError string
+	// LastWriteErrorTime 最近一次写入错误的发生时间
+	LastWriteErrorTime time.Time
+	// Writers 每个 Writer 的统计
+	Writers []WriterStats
+}
+
+// WriterStats 单个 Writer 的统计。
+type WriterStats struct {
+	// Name Writer 名称，格式为 "类型#序号"，如 "writer.AsyncWriter#0"
+	Name string
+	// WriteErrors 写入返回错误的次数
+	WriteErrors uint64
+	// Dropped Writer 内部丢弃的日志数，仅对实现 Dropped() uint64 的 Writer 有效
+	Dropped uint64
+	// QueueDepth Writer 缓冲区中等待写入的日志数，仅对实现 Len() int 的 Writer 有效
+	QueueDepth int
+}
+
+// levelNames 与 handlerCounters.levels 下标对应的级别名称
+var levelNames = [4]string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// levelIndex 返回级别在 handlerCounters.levels 中的下标
+func levelIndex(level slog.Level) int {
+	switch {
+	case level < slog.LevelInfo:
+		return 0
+	case level < slog.LevelWarn:
+		return 1
+	case level < slog.LevelError:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Stats 返回 Handler 的运行统计。
+//
+// 派生的 Handler（With/WithGroup）与原 Handler 共享统计。
+func (h *Handler) Stats() HandlerStats {
+	c := h.counters
+	s := HandlerStats{
+		Records:      make(map[string]uint64, len(levelNames)),
+		BytesWritten: c.bytes.Load(),
+		Oversized:    c.oversized.Load(),
+		FormatErrors: c.formatErrors.Load(),
+		Dropped:      c.closedDrops.Load(),
+	}
+	entries := h.writerEntries()
+	s.Writers = make([]WriterStats, len(entries))
+	for i, name := range levelNames {
+		s.Records[name] = c.levels[i].Load()
+	}
+	for i, e := range entries {
+		ws := WriterStats{
+			Name:        e.name,
+			WriteErrors: e.counters.errors.Load(),
+		}
+		w := unwrapTransform(e.w)
+		if d, ok := w.(interface{ Dropped() uint64 }); ok {
+			ws.Dropped = d.Dropped()
+		}
+		if l, ok := w.(interface{ Len() int }); ok {
+			ws.QueueDepth = l.Len()
+		}
+		s.Dropped += ws.Dropped
+		s.QueueDepth += ws.QueueDepth
+		s.Writers[i] = ws
+	}
+	if e := c.lastErr.Load(); e != nil {
+		s.LastWriteError = e.writer + ": " + e.err.Error()
+		s.LastWriteErrorTime = e.at
+	}
+	return s
+}
+
+// Stats 返回全局日志系统的运行统计。
+//
+// 未初始化时返回零值统计。
+//
+// 示例：
+//
+//	s := logm.Stats()
+//	if s.Dropped > 0 {
+//	    // 日志正在丢失，考虑增大 Async 缓冲区
+//	}
+func Stats() HandlerStats {
+	globalMu.RLock()
+	h := globalHandler
+	globalMu.RUnlock()
+
+	if h == nil {
+		s := HandlerStats{Records: make(map[string]uint64, len(levelNames))}
+		for _, name := range levelNames {
+			s.Records[name] = 0
+		}
+		return s
+	}
+	return h.Stats()
+}
+
+// writerName 生成 Writer 的统计名称
+func writerName(i int, w Writer) string {
+	return fmt.Sprintf("%s#%d", strings.TrimPrefix(fmt.Sprintf("%T", unwrapTransform(w)), "*"), i)
+}
@@ -0,0 +1,95 @@
+package logm
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Stats(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&HandlerConfig{
+		LevelVar:  new(slog.LevelVar),
+		Formatter: formatter.Text(),
+		Writers:   []Writer{&testWriter{buf: &buf}, &errWriter{}},
+	})
+
+	logger := slog.New(h).With("service", "api")
+	logger.Info("one")
+	logger.Info("two")
+	logger.Warn("three")
+	logger.Error("four")
+
+	s := h.Stats()
+	assert.Equal(t, uint64(0), s.Records["DEBUG"])
+	assert.Equal(t, uint64(2), s.Records["INFO"])
+	assert.Equal(t, uint64(1), s.Records["WARN"])
+	assert.Equal(t, uint64(1), s.Records["ERROR"])
+	assert.Equal(t, uint64(buf.Len()), s.BytesWritten)
+
+	require.Len(t, s.Writers, 2)
+	assert.Equal(t, "logm.testWriter#0", s.Writers[0].Name)
+	assert.Equal(t, uint64(0), s.Writers[0].WriteErrors)
+	assert.Equal(t, "logm.errWriter#1", s.Writers[1].Name)
+	assert.Equal(t, uint64(4), s.Writers[1].WriteErrors)
+}
+
+func TestStats_Global(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Init(WithWriter(&testWriter{buf: &buf})))
+	defer func() { _ = Close() }()
+
+	Info("hello")
+
+	s := Stats()
+	assert.Equal(t, uint64(1), s.Records["INFO"])
+	assert.Positive(t, s.BytesWritten)
+}
+
+func TestStats_NotInitialized(t *testing.T) {
+	_ = Close()
+
+	s := Stats()
+	assert.Equal(t, uint64(0), s.Records["INFO"])
+	assert.Empty(t, s.Writers)
+}
+
+func TestHandler_OnWriteError(t *testing.T) {
+	var buf bytes.Buffer
+	var failedWriter Writer
+	var failedRecord string
+	var failedErr error
+
+	logger := New(
+		WithFormatter(formatter.Text()),
+		WithWriter(&testWriter{buf: &buf}),
+		WithWriter(&errWriter{}),
+		WithOnWriteError(func(w Writer, err error, record []byte) {
+			failedWriter = w
+			failedErr = err
+			failedRecord = string(record)
+		}),
+	)
+
+	logger.Info("hello")
+
+	assert.IsType(t, &errWriter{}, failedWriter)
+	require.Error(t, failedErr)
+	assert.Contains(t, failedRecord, "msg=hello")
+	assert.Contains(t, buf.String(), "msg=hello")
+}
+
+// errWriteFailed 测试用写入错误
+var errWriteFailed = errors.New("write failed")
+
+// errWriter 总是返回写入错误的 Writer
+type errWriter struct{}
+
+func (w *errWriter) Write(p []byte) (n int, err error) { return 0, errWriteFailed }
+func (w *errWriter) Close() error                      { return nil }
+func (w *errWriter) Sync() error                       { return nil }
@@ -0,0 +1,113 @@
+package logm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WriterStatus 单个 Writer 的健康状态。
+type WriterStatus struct {
+	// Name Writer 名称，与 WriterStats.Name 一致
+	Name string
+	// Connected 是否已连接，仅对实现 Connected() bool 的 Writer 有意义，其余恒为 true
+	Connected bool
+	// LastError 最近一次写入错误，无错误时为空
+	LastError string
+	// LastErrorTime 最近一次写入错误的发生时间
+	LastErrorTime time.Time
+	// LastWrite 最近一次成功写入的时间
+	LastWrite time.Time
+	// FailingSince 连续写入失败的开始时间，最近一次写入成功时为零值
+	FailingSince time.Time
+	// QueueDepth 缓冲区中等待写入的日志数，仅对实现 Len() int 的 Writer 有效
+	QueueDepth int
+}
+
+// Healthy 判断 Writer 是否健康。
+//
+// 连续写入失败超过 maxFailure 或报告未连接时视为不健康。
+func (s WriterStatus) Healthy(maxFailure time.Duration) bool {
+	if !s.Connected {
+		return false
+	}
+	if s.FailingSince.IsZero() {
+		return true
+	}
+	return time.Since(s.FailingSince) < maxFailure
+}
+
+// Status 返回 Handler 中每个 Writer 的健康状态。
+func (h *Handler) Status() []WriterStatus {
+	entries := h.writerEntries()
+	statuses := make([]WriterStatus, len(entries))
+	for i, e := range entries {
+		wc := e.counters
+		s := WriterStatus{
+			Name:      e.name,
+			Connected: true,
+		}
+		w := unwrapTransform(e.w)
+		if c, ok := w.(interface{ Connected() bool }); ok {
+			s.Connected = c.Connected()
+		}
+		if l, ok := w.(interface{ Len() int }); ok {
+			s.QueueDepth = l.Len()
+		}
+		if ts := wc.lastWrite.Load(); ts != 0 {
+			s.LastWrite = time.Unix(0, ts)
+		}
+		if ts := wc.failSince.Load(); ts != 0 {
+			s.FailingSince = time.Unix(0, ts)
+		}
+		if e := wc.lastErr.Load(); e != nil {
+			s.LastError = e.err.Error()
+			s.LastErrorTime = e.at
+		}
+		statuses[i] = s
+	}
+	return statuses
+}
+
+// Status 返回全局日志系统中每个 Writer 的健康状态。
+//
+// 未初始化时返回 nil。
+func Status() []WriterStatus {
+	globalMu.RLock()
+	h := globalHandler
+	globalMu.RUnlock()
+
+	if h == nil {
+		return nil
+	}
+	return h.Status()
+}
+
+// CheckHealth 检查全局日志系统是否能正常投递日志。
+//
+// 任一 Writer 连续写入失败超过 maxFailure 或报告未连接时返回错误，
+// 适合作为 /healthz 或就绪探针的一部分：
+//
+//	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+//	    if err := logm.CheckHealth(5 * time.Minute); err != nil {
+//	        http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//	        return
+//	    }
+//	    w.WriteHeader(http.StatusOK)
+//	})
+func CheckHealth(maxFailure time.Duration) error {
+	var errs []error
+	for _, s := range Status() {
+		if s.Healthy(maxFailure) {
+			continue
+		}
+		switch {
+		case !s.Connected:
+			errs = append(errs, fmt.Errorf("logm: writer %s not connected", s.Name))
+		default:
+			errs = append(errs, fmt.Errorf("logm: writer %s failing since %s: %s",
+				s.Name, s.FailingSince.Format(time.RFC3339), s.LastError))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package logm
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	var buf bytes.Buffer
+	fw := &flakyWriter{buf: &buf}
+	require.NoError(t, Init(WithWriter(fw)))
+	defer func() { _ = Close() }()
+
+	Info("ok")
+
+	st := Status()
+	require.Len(t, st, 1)
+	assert.Equal(t, "logm.flakyWriter#0", st[0].Name)
+	assert.True(t, st[0].Connected)
+	assert.False(t, st[0].LastWrite.IsZero())
+	assert.True(t, st[0].FailingSince.IsZero())
+	require.NoError(t, CheckHealth(time.Minute))
+
+	fw.fail = true
+	Info("fail 1")
+	Info("fail 2")
+
+	st = Status()
+	assert.Equal(t, "write failed", st[0].LastError)
+	assert.False(t, st[0].FailingSince.IsZero())
+	// 失败时间未超过窗口，仍视为健康
+	require.NoError(t, CheckHealth(time.Minute))
+	require.ErrorContains(t, CheckHealth(0), "logm.flakyWriter#0 failing since")
+
+	// 恢复后重置失败窗口
+	fw.fail = false
+	Info("recovered")
+	st = Status()
+	assert.True(t, st[0].FailingSince.IsZero())
+	assert.Equal(t, "write failed", st[0].LastError)
+	require.NoError(t, CheckHealth(0))
+}
+
+func TestStatus_NotInitialized(t *testing.T) {
+	_ = Close()
+	assert.Nil(t, Status())
+	assert.NoError(t, CheckHealth(0))
+}
+
+// flakyWriter 可切换写入失败的 Writer
+type flakyWriter struct {
+	buf  *bytes.Buffer
+	fail bool
+}
+
+func (w *flakyWriter) Write(p []byte) (n int, err error) {
+	if w.fail {
+		return 0, errWriteFailed
+	}
+	return w.buf.Write(p)
+}
+
+func (w *flakyWriter) Close() error { return nil }
+func (w *flakyWriter) Sync() error  { return nil }
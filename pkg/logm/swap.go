@@ -0,0 +1,52 @@
+package logm
+
+import "sync/atomic"
+
+// formatterRef 可原子替换的格式化器
+type formatterRef struct {
+	p atomic.Pointer[Formatter]
+}
+
+// newFormatterRef 创建持有 f 的 formatterRef，f 可以为 nil
+func newFormatterRef(f Formatter) *formatterRef {
+	r := &formatterRef{}
+	if f != nil {
+		r.p.Store(&f)
+	}
+	return r
+}
+
+// load 返回当前格式化器，未设置时返回 nil
+func (r *formatterRef) load() Formatter {
+	if p := r.p.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// SetFormatter 原子替换 Handler 的格式化器，之后的日志按 f 格式化，nil 被忽略。
+//
+// 替换对该 Handler 及其 With/WithGroup 派生的 Handler 立即生效，
+// Derive 创建且未指定 WithFormatter 的 logger 同样跟随切换。
+// 正在格式化的日志仍使用替换前的格式化器，单条日志不会混用两种格式。
+func (h *Handler) SetFormatter(f Formatter) {
+	if f == nil {
+		return
+	}
+	h.formatter.p.Store(&f)
+}
+
+// SetFormatter 原子替换全局日志系统的格式化器，无需重启即可切换输出格式：
+//
+//	logm.SetFormatter(formatter.JSON())
+//
+// 重新 Init 后使用 Init 配置的格式化器。未初始化时不做任何操作。
+func SetFormatter(f Formatter) {
+	globalMu.RLock()
+	h := globalHandler
+	globalMu.RUnlock()
+
+	if h != nil {
+		h.SetFormatter(f)
+	}
+}
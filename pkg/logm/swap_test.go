@@ -0,0 +1,74 @@
+package logm
+
+import (
+	"bytes"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_SetFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	h := newHandler(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &buf}))
+	logger := slog.New(h).With("svc", "api")
+	derived, err := Derive(slog.New(h))
+	require.NoError(t, err)
+	var own bytes.Buffer
+	pinned, err := Derive(slog.New(h), WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &own}))
+	require.NoError(t, err)
+
+	logger.Info("before")
+	assert.Contains(t, buf.String(), `msg=before svc=api`)
+
+	buf.Reset()
+	h.SetFormatter(formatter.JSON())
+	h.SetFormatter(nil)
+	logger.Info("after")
+	derived.Info("derived")
+	assert.Contains(t, buf.String(), `"msg":"after","svc":"api"`)
+	assert.Contains(t, buf.String(), `"msg":"derived"`)
+	assert.Equal(t, "formatter.JSONFormatter", h.Config().Formatter)
+
+	pinned.Info("pinned")
+	assert.Contains(t, own.String(), "msg=pinned")
+}
+
+func TestHandler_SetFormatter_Concurrent(t *testing.T) {
+	h := newHandler(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &bytes.Buffer{}}))
+	logger := slog.New(h)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for i := range 100 {
+			if i%2 == 0 {
+				h.SetFormatter(formatter.JSON())
+			} else {
+				h.SetFormatter(formatter.Text())
+			}
+		}
+	})
+	wg.Go(func() {
+		for range 100 {
+			_ = h.Sync()
+			_ = h.Config()
+		}
+	})
+	for range 100 {
+		logger.Info("race")
+	}
+	wg.Wait()
+}
+
+func TestSetFormatter_Global(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Init(WithFormatter(formatter.Text()), WithWriter(&testWriter{buf: &buf})))
+	defer func() { _ = Close() }()
+
+	SetFormatter(formatter.JSON())
+	Info("switched")
+	assert.Contains(t, buf.String(), `"msg":"switched"`)
+}
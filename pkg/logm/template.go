@@ -0,0 +1,80 @@
+package logm
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// expandMessage 将消息中的 {key} 占位符替换为同名属性的值，见 WithMessageTemplate。
+//
+// 键可以是以 . 连接的分组路径，如 {req.id}；同名属性取最后一个，
+// 即调用处的属性优先于 With 继承的属性。没有对应属性、值为分组或
+// 花括号未闭合时占位符原样保留，{{ 和 }} 分别输出为 { 和 }。
+func expandMessage(msg string, attrs []slog.Attr) string {
+	if !strings.ContainsAny(msg, "{}") {
+		return msg
+	}
+
+	var b strings.Builder
+	b.Grow(len(msg))
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if (c == '{' || c == '}') && i+1 < len(msg) && msg[i+1] == c {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		if c != '{' {
+			b.WriteByte(c)
+			continue
+		}
+		end := strings.IndexAny(msg[i+1:], "{}")
+		if end < 0 || msg[i+1+end] != '}' {
+			b.WriteByte(c)
+			continue
+		}
+		key := msg[i+1 : i+1+end]
+		if v, ok := lookupAttr(attrs, key); ok {
+			b.WriteString(v.String())
+		} else {
+			b.WriteString(msg[i : i+end+2])
+		}
+		i += end + 1
+	}
+	return b.String()
+}
+
+// LookupAttr 按以 . 连接的分组路径查找属性值，返回解析后的值，
+// 供观察者和自定义拦截器使用，规则同 lookupAttr。
+func LookupAttr(attrs []slog.Attr, path string) (slog.Value, bool) {
+	return lookupAttr(attrs, path)
+}
+
+// lookupAttr 按分组路径查找属性值，返回解析后的值。
+//
+// 键可以是以 . 连接的分组路径，空键分组内联到当前层级；同名属性取最后一个。
+// 路径指向分组时返回 false。
+func lookupAttr(attrs []slog.Attr, path string) (slog.Value, bool) {
+	if path == "" {
+		return slog.Value{}, false
+	}
+	for i := len(attrs) - 1; i >= 0; i-- {
+		a := attrs[i]
+		v := a.Value.Resolve()
+		if a.Key == "" && v.Kind() == slog.KindGroup {
+			if found, ok := lookupAttr(v.Group(), path); ok {
+				return found, true
+			}
+			continue
+		}
+		if a.Key == path {
+			return v, v.Kind() != slog.KindGroup
+		}
+		if rest, ok := strings.CutPrefix(path, a.Key+"."); ok && v.Kind() == slog.KindGroup {
+			if found, ok := lookupAttr(v.Group(), rest); ok {
+				return found, true
+			}
+		}
+	}
+	return slog.Value{}, false
+}
@@ -0,0 +1,73 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandMessage(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.Int("user_id", 42),
+		slog.String("sku", "X1"),
+		slog.Group("req", slog.String("id", "r-1")),
+		slog.Group("", slog.Bool("inline", true)),
+		slog.Any("err", errors.New("boom")),
+		slog.String("sku", "X2"),
+	}
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "no placeholders", "no placeholders"},
+		{"basic", "user {user_id} purchased {sku}", "user 42 purchased X2"},
+		{"group path", "request {req.id}", "request r-1"},
+		{"inline group", "inline={inline}", "inline=true"},
+		{"error", "failed: {err}", "failed: boom"},
+		{"missing", "hello {name}", "hello {name}"},
+		{"group value", "{req}", "{req}"},
+		{"empty", "{}", "{}"},
+		{"unclosed", "open {user_id", "open {user_id"},
+		{"nested brace", "{a{user_id}", "{a42"},
+		{"escaped", "{{user_id}} is {user_id}", "{user_id} is 42"},
+		{"stray close", "a } b", "a } b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, expandMessage(tt.in, attrs))
+		})
+	}
+}
+
+func TestWithMessageTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(
+		WithMessageTemplate(),
+		WithFormatter(formatter.JSON()),
+		WithWriter(&testWriter{buf: &buf}),
+		WithInterceptor(func(_ context.Context, r *Record) *Record {
+			r.Attrs = append(r.Attrs, slog.String("template", r.Message))
+			return r
+		}),
+	)
+
+	logger.With("tenant", "acme").Info("user {user_id} of {tenant} purchased {sku}", "user_id", 42, "sku", "X1")
+
+	out := buf.String()
+	assert.Contains(t, out, `"msg":"user 42 of acme purchased X1"`)
+	assert.Contains(t, out, `"user_id":42`)
+	assert.Contains(t, out, `"sku":"X1"`)
+	assert.Contains(t, out, `"template":"user {user_id} of {tenant} purchased {sku}"`)
+}
+
+func TestWithMessageTemplate_Disabled(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(WithFormatter(formatter.JSON()), WithWriter(&testWriter{buf: &buf}))
+
+	logger.Info("user {user_id}", "user_id", 42)
+	assert.Contains(t, buf.String(), `"msg":"user {user_id}"`)
+}
@@ -0,0 +1,458 @@
+package logm
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// DefaultTenantKey TenantRouter 默认读取的租户属性
+const DefaultTenantKey = "tenant_id"
+
+// DefaultMaxTenants TenantRouter 默认同时保持打开的租户 Writer 数
+const DefaultMaxTenants = 100
+
+// TenantQuotaMessage 租户超出配额时输出的汇总日志消息
+const TenantQuotaMessage = "tenant quota exceeded"
+
+// TenantQuota 单个租户在每个时间窗口内允许写入的日志量，超出部分丢弃，
+// 窗口结束时向该租户的 Writer 写入一条 TenantQuotaMessage 汇总日志，
+// 该租户之后不再写入时由定时器写出。
+type TenantQuota struct {
+	// Records 每个窗口的最大日志数，<= 0 表示不限制
+	Records int
+	// Bytes 每个窗口的最大字节数（格式化后），<= 0 表示不限制
+	Bytes int64
+	// Window 窗口长度，<= 0 时为 time.Minute
+	Window time.Duration
+}
+
+// limited 判断是否配置了限制
+func (q TenantQuota) limited() bool {
+	return q.Records > 0 || q.Bytes > 0
+}
+
+// window 返回窗口长度，未设置时为 time.Minute
+func (q TenantQuota) window() time.Duration {
+	if q.Window <= 0 {
+		return time.Minute
+	}
+	return q.Window
+}
+
+// TenantFactory 为租户创建 Writer，首次出现该租户的日志时调用。
+type TenantFactory func(tenant string) (Writer, error)
+
+// TenantOption TenantRouter 配置选项
+type TenantOption func(*TenantRouter)
+
+// WithTenantKey 设置租户属性，可以是以 . 连接的分组路径，默认 DefaultTenantKey。
+func WithTenantKey(key string) TenantOption {
+	return func(r *TenantRouter) {
+		r.key = key
+	}
+}
+
+// WithMaxTenants 设置同时保持打开的租户 Writer 数，超出时关闭最久未写入的租户，
+// 该租户再次出现时重新创建。默认 DefaultMaxTenants，<= 0 表示不限制。
+func WithMaxTenants(n int) TenantOption {
+	return func(r *TenantRouter) {
+		r.maxTenants = n
+	}
+}
+
+// WithTenantQuota 为所有租户设置默认配额，避免单个租户占满输出和采集预算。默认不限制。
+func WithTenantQuota(q TenantQuota) TenantOption {
+	return func(r *TenantRouter) {
+		r.quota = q
+	}
+}
+
+// WithTenantQuotaFor 为指定租户设置配额，覆盖 WithTenantQuota 的默认值。
+func WithTenantQuotaFor(tenant string, q TenantQuota) TenantOption {
+	return func(r *TenantRouter) {
+		r.quotas[tenant] = q
+	}
+}
+
+// WithTenantFallback 设置没有租户属性或租户 Writer 创建失败时的输出目标，默认丢弃。
+func WithTenantFallback(w Writer) TenantOption {
+	return func(r *TenantRouter) {
+		r.fallback = w
+	}
+}
+
+// TenantRouter 按租户属性将日志分发到各租户独立 Writer 的 Writer。
+//
+// 租户 Writer 在首次出现该租户时由 TenantFactory 惰性创建，按 LRU 限制打开数量。
+// WithTenantQuota 可限制每个租户的日志量，配额状态随租户 Writer 一起淘汰。
+// 路由依赖记录的属性，只有经由 Handler 写入（WithWriter 或 AttachWriter）时生效；
+// 直接调用 Write 时无法获知租户，写入回退目标。
+type TenantRouter struct {
+	factory    TenantFactory
+	key        string
+	maxTenants int
+	fallback   Writer
+	quota      TenantQuota
+	quotas     map[string]TenantQuota
+	now        func() time.Time
+
+	mu      sync.Mutex
+	tenants map[string]*list.Element // 值为 *tenantEntry
+	lru     *list.List               // 最近写入的租户在前
+	closed  bool
+
+	dropped atomic.Uint64 // 没有目标或超出配额而丢弃的日志数
+}
+
+// tenantEntry 单个租户的 Writer 和配额状态
+type tenantEntry struct {
+	tenant string
+	w      Writer
+	quota  TenantQuota
+
+	windowStart    time.Time
+	records        int
+	bytes          int64
+	droppedRecords int
+	droppedBytes   int64
+	format         Formatter   // 最近一次写入使用的格式化器，用于输出汇总日志
+	timer          *time.Timer // 有丢弃时在窗口结束后写出汇总
+}
+
+// admit 按配额判断能否写入 size 字节的日志，窗口结束且有丢弃时返回待写入的汇总日志
+func (e *tenantEntry) admit(now time.Time, size int, f Formatter) (ok bool, summary []byte) {
+	if !e.quota.limited() {
+		return true, nil
+	}
+	e.format = f
+	if now.Sub(e.windowStart) >= e.quota.window() {
+		summary = e.summary(now)
+		e.windowStart, e.records, e.bytes = now, 0, 0
+	}
+
+	if (e.quota.Records > 0 && e.records >= e.quota.Records) ||
+		(e.quota.Bytes > 0 && e.bytes+int64(size) > e.quota.Bytes) {
+		if e.droppedRecords == 0 {
+			selflog.Printf("tenant.quota", "tenant %q exceeded its quota, dropping records until the window ends", e.tenant)
+		}
+		e.droppedRecords++
+		e.droppedBytes += int64(size)
+		return false, summary
+	}
+	e.records++
+	e.bytes += int64(size)
+	return true, summary
+}
+
+// summary 格式化当前窗口的丢弃汇总并清零计数，没有丢弃时返回 nil
+func (e *tenantEntry) summary(now time.Time) []byte {
+	if e.droppedRecords == 0 {
+		return nil
+	}
+	rec := &Record{
+		Time:    now,
+		Level:   slog.LevelWarn,
+		Message: TenantQuotaMessage,
+		Attrs: []slog.Attr{
+			slog.String("tenant", e.tenant),
+			slog.Int("dropped_records", e.droppedRecords),
+			slog.Int64("dropped_bytes", e.droppedBytes),
+			slog.Time("window_start", e.windowStart),
+		},
+	}
+	e.droppedRecords, e.droppedBytes = 0, 0
+	if e.format == nil {
+		return nil
+	}
+	data, err := e.format.Format(rec)
+	if err != nil {
+		selflog.Printf("tenant.quota", "format quota summary for tenant %q: %v", e.tenant, err)
+		return nil
+	}
+	return data
+}
+
+// flushSummary 写出 e 当前窗口的丢弃汇总，用于淘汰和关闭前
+func (e *tenantEntry) flushSummary(now time.Time) {
+	if data := e.summary(now); data != nil {
+		if _, err := writer.WriteLevel(e.w, slog.LevelWarn, data); err != nil {
+			selflog.Printf("tenant.quota", "write quota summary for tenant %q: %v", e.tenant, err)
+		}
+	}
+}
+
+// stopTimer 停止汇总定时器，用于淘汰和关闭时
+func (e *tenantEntry) stopTimer() {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+}
+
+// NewTenantRouter 创建按租户分发的 Writer，通过 WithWriter 添加：
+//
+//	logm.Init(
+//	    logm.WithFormatter(formatter.JSON()),
+//	    logm.WithWriter(logm.NewTenantRouter(logm.TenantFile("/var/log/tenants"),
+//	        logm.WithMaxTenants(500),
+//	        logm.WithTenantQuota(logm.TenantQuota{Records: 10000, Bytes: 8 << 20, Window: time.Minute}),
+//	        logm.WithTenantFallback(writer.File("/var/log/app.log")),
+//	    )),
+//	)
+//	logm.Info("order created", "tenant_id", "acme") // 写入 /var/log/tenants/acme.log
+func NewTenantRouter(factory TenantFactory, opts ...TenantOption) *TenantRouter {
+	r := &TenantRouter{
+		factory:    factory,
+		key:        DefaultTenantKey,
+		maxTenants: DefaultMaxTenants,
+		quotas:     make(map[string]TenantQuota),
+		now:        time.Now,
+		tenants:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// TenantFile 返回在 dir 下为每个租户创建 <tenant>.log 的 TenantFactory。
+//
+// 租户名只允许字母、数字、'-'、'_' 和 '.'，且不能以 '.' 开头，避免写到 dir 之外。
+func TenantFile(dir string, opts ...writer.FileOption) TenantFactory {
+	return func(tenant string) (Writer, error) {
+		if !validTenant(tenant) {
+			return nil, fmt.Errorf("logm: invalid tenant name %q", tenant)
+		}
+		return writer.File(filepath.Join(dir, tenant+".log"), opts...), nil
+	}
+}
+
+// validTenant 判断租户名能否安全地用作文件名
+func validTenant(tenant string) bool {
+	if tenant == "" || len(tenant) > 128 || tenant[0] == '.' {
+		return false
+	}
+	for _, c := range tenant {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Write 实现 io.Writer，无法获知租户，写入回退目标。
+func (r *TenantRouter) Write(p []byte) (n int, err error) {
+	return r.WriteLevel(slog.LevelInfo, p)
+}
+
+// WriteLevel 实现 writer.LevelWriter，无法获知租户，写入回退目标。
+func (r *TenantRouter) WriteLevel(level slog.Level, p []byte) (n int, err error) {
+	return r.writeFallback(level, p)
+}
+
+// route 按 rec 的租户属性写入对应的 Writer，f 为格式化 p 的格式化器
+func (r *TenantRouter) route(rec *Record, p []byte, f Formatter) (int, error) {
+	v, ok := lookupAttr(rec.Attrs, r.key)
+	if !ok || v.String() == "" {
+		return r.writeFallback(rec.Level, p)
+	}
+	w, summary, admitted, err := r.acquire(v.String(), len(p), f)
+	if err != nil {
+		selflog.Printf("tenant", "create writer for tenant %q: %v", v.String(), err)
+		_, ferr := r.writeFallback(rec.Level, p)
+		return len(p), errors.Join(err, ferr)
+	}
+	if summary != nil {
+		if _, err := writer.WriteLevel(w, slog.LevelWarn, summary); err != nil {
+			selflog.Printf("tenant.quota", "write quota summary for tenant %q: %v", v.String(), err)
+		}
+	}
+	if !admitted {
+		return len(p), nil
+	}
+	return writer.WriteLevel(w, rec.Level, p)
+}
+
+// acquire 返回租户的 Writer 和待写入的配额汇总，不存在时创建，必要时淘汰最久未写入的租户。
+//
+// 日志超出配额或 Router 已关闭时 admitted 为 false，此时仍可能有汇总需要写入。
+func (r *TenantRouter) acquire(tenant string, size int, f Formatter) (w Writer, summary []byte, admitted bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		r.dropped.Add(1)
+		return nil, nil, false, nil
+	}
+
+	var e *tenantEntry
+	if el, ok := r.tenants[tenant]; ok {
+		r.lru.MoveToFront(el)
+		e = el.Value.(*tenantEntry) //nolint:forcetypeassert // lru 只存放 *tenantEntry
+	} else {
+		w, err := r.factory(tenant)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		e = &tenantEntry{tenant: tenant, w: w, quota: r.quota}
+		if q, ok := r.quotas[tenant]; ok {
+			e.quota = q
+		}
+		r.tenants[tenant] = r.lru.PushFront(e)
+		r.evict()
+	}
+
+	now := r.now()
+	admitted, summary = e.admit(now, size, f)
+	if !admitted {
+		r.dropped.Add(1)
+		r.scheduleSummary(e, now)
+	}
+	return e.w, summary, admitted, nil
+}
+
+// scheduleSummary 在 e 的当前窗口结束时写出汇总，调用方持有 r.mu
+func (r *TenantRouter) scheduleSummary(e *tenantEntry, now time.Time) {
+	if e.timer != nil {
+		return
+	}
+	e.timer = time.AfterFunc(e.windowStart.Add(e.quota.window()).Sub(now), func() {
+		r.flushExpired(e)
+	})
+}
+
+// flushExpired 由定时器调用，窗口已结束时写出 e 的汇总，否则等到新窗口结束
+func (r *TenantRouter) flushExpired(e *tenantEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.timer = nil
+	if el, ok := r.tenants[e.tenant]; r.closed || !ok || el.Value != e {
+		return
+	}
+	now := r.now()
+	if now.Sub(e.windowStart) < e.quota.window() {
+		// 等待期间已进入新窗口，新窗口的丢弃在其结束时汇总
+		if e.droppedRecords > 0 {
+			r.scheduleSummary(e, now)
+		}
+		return
+	}
+	e.flushSummary(now)
+}
+
+// evict 关闭超出 maxTenants 的最久未写入的租户，调用方持有 r.mu
+func (r *TenantRouter) evict() {
+	for r.maxTenants > 0 && r.lru.Len() > r.maxTenants {
+		e := r.lru.Remove(r.lru.Back()).(*tenantEntry) //nolint:forcetypeassert // lru 只存放 *tenantEntry
+		delete(r.tenants, e.tenant)
+		e.stopTimer()
+		e.flushSummary(r.now())
+		if err := e.w.Close(); err != nil {
+			selflog.Printf("tenant", "close evicted tenant %q: %v", e.tenant, err)
+		}
+	}
+}
+
+// writeFallback 写入回退目标，没有回退目标时丢弃
+func (r *TenantRouter) writeFallback(level slog.Level, p []byte) (int, error) {
+	if r.fallback == nil {
+		r.dropped.Add(1)
+		return len(p), nil
+	}
+	return writer.WriteLevel(r.fallback, level, p)
+}
+
+// Tenants 返回当前打开的租户，最近写入的在前。
+func (r *TenantRouter) Tenants() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenants := make([]string, 0, r.lru.Len())
+	for el := r.lru.Front(); el != nil; el = el.Next() {
+		tenants = append(tenants, el.Value.(*tenantEntry).tenant) //nolint:forcetypeassert // lru 只存放 *tenantEntry
+	}
+	return tenants
+}
+
+// Dropped 返回没有输出目标而丢弃的日志数，加上各目标自身丢弃的日志数。
+func (r *TenantRouter) Dropped() uint64 {
+	total := r.dropped.Load()
+	_ = r.each(func(w Writer) error {
+		if d, ok := w.(interface{ Dropped() uint64 }); ok {
+			total += d.Dropped()
+		}
+		return nil
+	})
+	return total
+}
+
+// Sync 实现 Writer.Sync，刷新所有租户 Writer 和回退目标。
+func (r *TenantRouter) Sync() error {
+	return r.each(Writer.Sync)
+}
+
+// Rotate 实现 writer.Rotator，轮转所有支持轮转的租户 Writer 和回退目标。
+func (r *TenantRouter) Rotate() error {
+	return r.each(func(w Writer) error { return writer.Rotate(w) })
+}
+
+// SyncContext 在 ctx 结束前刷新所有租户 Writer 和回退目标。
+func (r *TenantRouter) SyncContext(ctx context.Context) error {
+	return r.each(func(w Writer) error { return writer.SyncContext(ctx, w) })
+}
+
+// CloseContext 在 ctx 结束前关闭所有租户 Writer 和回退目标，之后到达的租户日志被丢弃。
+func (r *TenantRouter) CloseContext(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	for el := r.lru.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*tenantEntry) //nolint:forcetypeassert // lru 只存放 *tenantEntry
+		e.stopTimer()
+		e.flushSummary(r.now())
+	}
+	r.mu.Unlock()
+	return r.each(func(w Writer) error { return writer.CloseContext(ctx, w) })
+}
+
+// Close 实现 io.Closer，关闭所有租户 Writer 和回退目标。
+func (r *TenantRouter) Close() error {
+	return r.CloseContext(context.Background())
+}
+
+// each 对每个租户 Writer 和回退目标执行一次 fn
+func (r *TenantRouter) each(fn func(Writer) error) error {
+	r.mu.Lock()
+	writers := make([]Writer, 0, r.lru.Len()+1)
+	for el := r.lru.Front(); el != nil; el = el.Next() {
+		writers = append(writers, el.Value.(*tenantEntry).w) //nolint:forcetypeassert // lru 只存放 *tenantEntry
+	}
+	r.mu.Unlock()
+	if r.fallback != nil {
+		writers = append(writers, r.fallback)
+	}
+
+	var errs []error
+	for _, w := range writers {
+		errs = append(errs, fn(w))
+	}
+	return errors.Join(errs...)
+}
+
+// writeRecord 将 rec 由 f 格式化后的数据 p 写入 w，TenantRouter 按记录的租户属性路由
+func writeRecord(w Writer, rec *Record, p []byte, f Formatter) (int, error) {
+	if r, ok := unwrapTransform(w).(*TenantRouter); ok {
+		return r.route(rec, p, f)
+	}
+	return writer.WriteLevel(w, rec.Level, p)
+}
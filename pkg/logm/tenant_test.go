@@ -0,0 +1,167 @@
+package logm
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantWriters 记录 TenantFactory 创建的 Writer
+type tenantWriters map[string]*closeTracker
+
+func (m tenantWriters) factory(tenant string) (Writer, error) {
+	if tenant == "bad" {
+		return nil, errors.New("no such tenant")
+	}
+	w := &closeTracker{}
+	m[tenant] = w
+	return w, nil
+}
+
+func TestTenantRouter(t *testing.T) {
+	created := tenantWriters{}
+	var fallback bytes.Buffer
+	router := NewTenantRouter(created.factory, WithMaxTenants(2), WithTenantFallback(&testWriter{buf: &fallback}))
+	logger := New(WithFormatter(formatter.Text()), WithWriter(router))
+
+	logger.Info("a1", "tenant_id", "acme")
+	logger.With("tenant_id", "globex").Info("g1")
+	logger.Info("no tenant")
+	assert.Contains(t, created["acme"].String(), "msg=a1")
+	assert.Contains(t, created["globex"].String(), "msg=g1")
+	assert.NotContains(t, created["acme"].String(), "g1")
+	assert.Contains(t, fallback.String(), `msg="no tenant"`)
+
+	// 超出上限时关闭最久未写入的租户
+	logger.Info("a2", "tenant_id", "acme")
+	logger.Info("i1", "tenant_id", "initech")
+	assert.Equal(t, []string{"initech", "acme"}, router.Tenants())
+	assert.True(t, created["globex"].closed)
+	assert.False(t, created["acme"].closed)
+
+	// 创建失败时写入回退目标
+	logger.Info("b1", "tenant_id", "bad")
+	assert.Contains(t, fallback.String(), "msg=b1")
+
+	require.NoError(t, router.Close())
+	assert.True(t, created["acme"].closed)
+	assert.True(t, created["initech"].closed)
+}
+
+func TestTenantRouter_KeyAndDrop(t *testing.T) {
+	created := tenantWriters{}
+	router := NewTenantRouter(created.factory, WithTenantKey("req.tenant"))
+	logger := New(WithFormatter(formatter.Text()), WithWriter(router))
+
+	logger.Info("grouped", slog.Group("req", slog.Int("tenant", 42)))
+	logger.Info("dropped")
+	assert.Contains(t, created["42"].String(), "msg=grouped")
+	assert.Equal(t, uint64(1), router.Dropped())
+
+	n, err := router.Write([]byte("raw\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, uint64(2), router.Dropped())
+}
+
+func TestTenantFile(t *testing.T) {
+	dir := t.TempDir()
+	router := NewTenantRouter(TenantFile(dir, writer.WithCompress(false)))
+	logger := New(WithFormatter(formatter.Text()), WithWriter(router))
+
+	logger.Info("hello", "tenant_id", "acme")
+	logger.Info("escape", "tenant_id", "../etc")
+	require.NoError(t, router.Close())
+
+	data, err := os.ReadFile(filepath.Join(dir, "acme.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "msg=hello")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestValidTenant(t *testing.T) {
+	for _, name := range []string{"acme", "tenant-1", "a_b.c"} {
+		assert.True(t, validTenant(name), name)
+	}
+	for _, name := range []string{"", ".hidden", "../x", "a/b", "a b"} {
+		assert.False(t, validTenant(name), name)
+	}
+}
+
+func TestTenantRouter_Quota(t *testing.T) {
+	created := tenantWriters{}
+	router := NewTenantRouter(created.factory,
+		WithTenantQuota(TenantQuota{Records: 2, Window: time.Minute}),
+		WithTenantQuotaFor("vip", TenantQuota{}),
+	)
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	router.now = func() time.Time { return now }
+	logger := New(WithFormatter(formatter.Text()), WithWriter(router))
+
+	for i := range 5 {
+		logger.Info("noisy", "tenant_id", "acme", "i", i)
+		logger.Info("quiet", "tenant_id", "vip", "i", i)
+	}
+	assert.Equal(t, 2, strings.Count(created["acme"].String(), "msg=noisy"))
+	assert.Equal(t, 5, strings.Count(created["vip"].String(), "msg=quiet"))
+	assert.Equal(t, uint64(3), router.Dropped())
+	assert.NotContains(t, created["acme"].String(), TenantQuotaMessage)
+
+	// 下一个窗口先写出汇总，再恢复写入
+	now = now.Add(time.Minute)
+	logger.Info("recovered", "tenant_id", "acme")
+	out := created["acme"].String()
+	assert.Contains(t, out, `level=WARN msg="tenant quota exceeded" tenant=acme dropped_records=3`)
+	assert.Less(t, strings.Index(out, TenantQuotaMessage), strings.Index(out, "msg=recovered"))
+}
+
+func TestTenantRouter_QuotaBytesSummaryOnClose(t *testing.T) {
+	created := tenantWriters{}
+	router := NewTenantRouter(created.factory, WithTenantQuota(TenantQuota{Bytes: 300}))
+	logger := New(WithFormatter(formatter.JSON()), WithWriter(router))
+
+	for range 10 {
+		logger.Info("payload", "tenant_id", "acme", "data", strings.Repeat("x", 50))
+	}
+	require.NoError(t, router.Close())
+
+	lines := strings.Split(strings.TrimSpace(created["acme"].String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[2], `"msg":"tenant quota exceeded"`)
+	assert.Contains(t, lines[2], `"dropped_records":8`)
+}
+
+func TestTenantRouter_QuotaSummaryOnTimer(t *testing.T) {
+	created := tenantWriters{}
+	router := NewTenantRouter(created.factory, WithTenantQuota(TenantQuota{Records: 1, Window: 50 * time.Millisecond}))
+	logger := New(WithFormatter(formatter.Text()), WithWriter(router))
+
+	for range 3 {
+		logger.Info("burst", "tenant_id", "acme")
+	}
+	// 租户之后不再写入，汇总由定时器在窗口结束时写出；定时器在 router.mu 下写入
+	output := func() string {
+		router.mu.Lock()
+		defer router.mu.Unlock()
+		return created["acme"].String()
+	}
+	assert.NotContains(t, output(), TenantQuotaMessage)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(output(), "dropped_records=2")
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, router.Close())
+	assert.Equal(t, 1, strings.Count(created["acme"].String(), TenantQuotaMessage))
+}
@@ -0,0 +1,60 @@
+package logm
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// ElapsedKey Track 记录耗时的属性键名
+const ElapsedKey = "elapsed"
+
+// DefaultSlowThreshold Track 的默认慢操作阈值
+const DefaultSlowThreshold = time.Second
+
+// slowThreshold Track 的慢操作阈值，<= 0 表示不升级级别
+var slowThreshold atomic.Int64
+
+func init() {
+	slowThreshold.Store(int64(DefaultSlowThreshold))
+}
+
+// SetSlowThreshold 设置 Track 的慢操作阈值，d <= 0 表示不升级级别。
+func SetSlowThreshold(d time.Duration) {
+	slowThreshold.Store(int64(d))
+}
+
+// Track 记录操作耗时，返回的函数在操作结束时调用：
+//
+//	func loadUsers(ctx context.Context) error {
+//	    defer logm.Track(ctx, "load users", "tenant", tenant)()
+//	    // ...
+//	}
+//
+// 结束时使用 FromContext(ctx) 以 INFO 级别记录 msg，附加 args 和 elapsed 耗时属性；
+// 耗时超过慢操作阈值（默认 1s，见 SetSlowThreshold）时升级为 WARN。
+// 源代码位置为调用 Track 处。
+func Track(ctx context.Context, msg string, args ...any) (done func()) {
+	start := time.Now()
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // 跳过 Callers 和 Track
+
+	return func() {
+		elapsed := time.Since(start)
+		level := slog.LevelInfo
+		if threshold := time.Duration(slowThreshold.Load()); threshold > 0 && elapsed > threshold {
+			level = slog.LevelWarn
+		}
+
+		logger := FromContext(ctx)
+		if !logger.Enabled(ctx, level) {
+			return
+		}
+		r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+		r.Add(args...)
+		r.AddAttrs(slog.Duration(ElapsedKey, elapsed))
+		_ = logger.Handler().Handle(ctx, r)
+	}
+}
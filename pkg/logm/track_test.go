@@ -0,0 +1,68 @@
+package logm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTrackContext(t *testing.T) (context.Context, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&HandlerConfig{
+		Formatter: formatter.JSON(),
+		Writers:   []Writer{&testWriter{buf: &buf}},
+		AddSource: true,
+	}))
+	return WithLogger(context.Background(), logger), &buf
+}
+
+func TestTrack(t *testing.T) {
+	ctx, buf := newTrackContext(t)
+
+	func() {
+		defer Track(ctx, "load users", "tenant", "acme")()
+		time.Sleep(time.Millisecond)
+	}()
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, "INFO", m["level"])
+	assert.Equal(t, "load users", m["msg"])
+	assert.Equal(t, "acme", m["tenant"])
+	assert.Contains(t, m["source"], "track_test.go")
+
+	d, err := time.ParseDuration(m[ElapsedKey].(string))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, d, time.Millisecond)
+}
+
+func TestTrack_Slow(t *testing.T) {
+	SetSlowThreshold(time.Millisecond)
+	t.Cleanup(func() { SetSlowThreshold(DefaultSlowThreshold) })
+
+	ctx, buf := newTrackContext(t)
+	done := Track(ctx, "slow op")
+	time.Sleep(5 * time.Millisecond)
+	done()
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, "WARN", m["level"])
+
+	// 阈值 <= 0 时不升级
+	SetSlowThreshold(0)
+	buf.Reset()
+	done = Track(ctx, "slow op")
+	time.Sleep(5 * time.Millisecond)
+	done()
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, "INFO", m["level"])
+}
@@ -0,0 +1,182 @@
+package logm
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+)
+
+// TransformFunc 在格式化前调整写入单个 Writer 的日志记录。
+//
+// 参数是 Handler 为该 Writer 复制的记录，可以直接修改；返回 nil 表示该 Writer 不写入这条日志。
+type TransformFunc func(r *Record) *Record
+
+// transformWriter 带记录转换的 Writer，见 TransformWriter
+type transformWriter struct {
+	Writer
+	fn TransformFunc
+}
+
+// TransformWriter 为 w 附加记录转换，返回的 Writer 通过 WithWriter 添加：
+//
+//	logm.Init(
+//	    logm.WithWriter(logm.TransformWriter(writer.Stdout(), logm.DropAttrs("sql", "payload"))),
+//	    logm.WithWriter(writer.File("/var/log/app.log")),
+//	)
+//
+// Handler 为每个带转换的 Writer 单独复制记录、调用 fn 并格式化，
+// 其他 Writer 共享同一次格式化的结果。转换在拦截器、去重和清理之后进行，
+// 超长保护对转换后的结果单独生效。fn 在多个 goroutine 中并发调用，必须是并发安全的。
+//
+// 转换发生在格式化之前，只能作用于 Handler 直接持有的 Writer。
+// 被 Async、DLQ、WAL 等单一目标的包装 Writer 包住时，转换对整个包装链生效，
+// 等同于包在最外层；被 Multi、PerLevel 等多目标 Writer 包住时无法只转换其中一个目标，
+// 该转换被忽略并通过自诊断日志报告，此时应将 TransformWriter 直接传给 WithWriter。
+func TransformWriter(w Writer, fn TransformFunc) Writer {
+	return &transformWriter{Writer: w, fn: fn}
+}
+
+// WriteLevel 实现 writer.LevelWriter，将级别传递给被包装的 Writer。
+func (t *transformWriter) WriteLevel(level slog.Level, p []byte) (int, error) {
+	return writer.WriteLevel(t.Writer, level, p)
+}
+
+// Rotate 实现 writer.Rotator，轮转被包装的 Writer。
+func (t *transformWriter) Rotate() error {
+	return writer.Rotate(t.Writer)
+}
+
+// SyncContext 在 ctx 结束前刷新被包装的 Writer。
+func (t *transformWriter) SyncContext(ctx context.Context) error {
+	return writer.SyncContext(ctx, t.Writer)
+}
+
+// CloseContext 在 ctx 结束前关闭被包装的 Writer。
+func (t *transformWriter) CloseContext(ctx context.Context) error {
+	return writer.CloseContext(ctx, t.Writer)
+}
+
+// Unwrap 实现 writer.Wrapper，返回被包装的 Writer。
+func (t *transformWriter) Unwrap() []writer.Writer {
+	return []writer.Writer{t.Writer}
+}
+
+// DropAttrs 返回删除指定顶层属性的 TransformFunc，用于在某个输出目标上省略冗长字段。
+func DropAttrs(keys ...string) TransformFunc {
+	return func(r *Record) *Record {
+		r.Attrs = slices.DeleteFunc(r.Attrs, func(a slog.Attr) bool {
+			return slices.Contains(keys, a.Key)
+		})
+		return r
+	}
+}
+
+// hasTransform 判断 writers 中是否有带转换的 Writer
+func hasTransform(writers []Writer) bool {
+	for _, w := range writers {
+		if _, ok := w.(*transformWriter); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hoistTransform 将 w 单一目标包装链内部的 TransformWriter 提升到最外层，
+// 并报告无法生效的 TransformWriter，见 TransformWriter。
+//
+// 内层的 transformWriter 按字节原样转发写入，提升后保留在原位不影响输出。
+func hoistTransform(w Writer) Writer {
+	var hoisted *transformWriter
+	if t, ok := w.(*transformWriter); ok {
+		hoisted = t
+	} else {
+		for inner := w; hoisted == nil; {
+			u, ok := inner.(writer.Wrapper)
+			if !ok {
+				break
+			}
+			next := u.Unwrap()
+			if len(next) != 1 {
+				break
+			}
+			if t, ok := next[0].(*transformWriter); ok {
+				hoisted = t
+				w = &transformWriter{Writer: w, fn: t.fn}
+			}
+			inner = next[0]
+		}
+	}
+
+	writer.Walk(w, func(inner writer.Writer) bool {
+		if t, ok := inner.(*transformWriter); ok && t != hoisted && t != w {
+			selflog.Printf("transform", "TransformWriter inside %T is ignored; pass it to WithWriter directly", unwrapTransform(w))
+			return false
+		}
+		return true
+	})
+	return w
+}
+
+// hoistTransforms 对每个 Writer 调用 hoistTransform，有变化时返回新的切片，不修改 writers
+func hoistTransforms(writers []Writer) []Writer {
+	var out []Writer
+	for i, w := range writers {
+		hw := hoistTransform(w)
+		if hw != w && out == nil {
+			out = slices.Clone(writers)
+		}
+		if out != nil {
+			out[i] = hw
+		}
+	}
+	if out == nil {
+		return writers
+	}
+	return out
+}
+
+// unwrapTransform 返回带转换的 Writer 包装的目标，其他 Writer 原样返回
+func unwrapTransform(w Writer) Writer {
+	if t, ok := w.(*transformWriter); ok {
+		return t.Writer
+	}
+	return w
+}
+
+// payloads 为每个 Writer 生成待写入的数据，nil 表示该 Writer 跳过这条日志。
+//
+// 未带转换的 Writer 共享 rec 的格式化结果，且仅在需要时格式化一次；
+// 单个 Writer 格式化失败时跳过该 Writer，返回第一个错误。
+func (h *Handler) payloads(rec *Record, writers []Writer, f Formatter) ([][]byte, error) {
+	out := make([][]byte, len(writers))
+	var (
+		shared   []byte
+		encoded  bool
+		firstErr error
+	)
+	for i, w := range writers {
+		var data []byte
+		var err error
+		if t, ok := w.(*transformWriter); ok {
+			r := t.fn(rec.Clone())
+			if r == nil {
+				continue
+			}
+			data, err = h.encode(r, f)
+		} else {
+			if !encoded {
+				shared, err = h.encode(rec, f)
+				encoded = true
+			}
+			data = shared
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		out[i] = data
+	}
+	return out, firstErr
+}
@@ -0,0 +1,211 @@
+package logm
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformWriter(t *testing.T) {
+	var console, file bytes.Buffer
+	logger := New(
+		WithFormatter(formatter.JSON()),
+		WithWriter(TransformWriter(&testWriter{buf: &console}, DropAttrs("sql", "payload"))),
+		WithWriter(&testWriter{buf: &file}),
+	)
+
+	logger.Info("query", "sql", "SELECT 1", "payload", "big", "rows", 1)
+
+	assert.NotContains(t, console.String(), "sql")
+	assert.NotContains(t, console.String(), "payload")
+	assert.Contains(t, console.String(), `"rows":1`)
+	assert.Contains(t, file.String(), `"sql":"SELECT 1"`)
+	assert.Contains(t, file.String(), `"payload":"big"`)
+}
+
+func TestTransformWriter_IsolatesRecords(t *testing.T) {
+	var a, b, c bytes.Buffer
+	rename := func(r *Record) *Record {
+		r.Message = "renamed"
+		r.Attrs[0] = slog.String("k", "changed")
+		r.Groups = append(r.Groups, "extra")
+		return r
+	}
+	logger := New(
+		WithFormatter(formatter.Text()),
+		WithWriter(&testWriter{buf: &a}),
+		WithWriter(TransformWriter(&testWriter{buf: &b}, rename)),
+		WithWriter(&testWriter{buf: &c}),
+	)
+
+	logger.WithGroup("g").Info("orig", "k", "v")
+
+	assert.Contains(t, a.String(), "msg=orig g.k=v")
+	assert.Contains(t, b.String(), "msg=renamed g.extra.k=changed")
+	assert.Equal(t, a.String(), c.String())
+}
+
+func TestTransformWriter_Skip(t *testing.T) {
+	var all, errs bytes.Buffer
+	onlyErrors := func(r *Record) *Record {
+		if r.Level < slog.LevelError {
+			return nil
+		}
+		return r
+	}
+	h := newHandler(
+		WithFormatter(formatter.Text()),
+		WithWriter(&testWriter{buf: &all}),
+		WithWriter(TransformWriter(&testWriter{buf: &errs}, onlyErrors)),
+	)
+	logger := slog.New(h)
+
+	logger.Info("info")
+	logger.Error("error")
+
+	assert.Equal(t, 2, strings.Count(all.String(), "\n"))
+	assert.Equal(t, 1, strings.Count(errs.String(), "\n"))
+	assert.Contains(t, errs.String(), "msg=error")
+
+	stats := h.Stats()
+	require.Len(t, stats.Writers, 2)
+	assert.Equal(t, "logm.testWriter#1", stats.Writers[1].Name)
+}
+
+func TestTransformWriter_AllSkippedDoesNotFormat(t *testing.T) {
+	var buf bytes.Buffer
+	f := &countingFormatter{Formatter: formatter.Text()}
+	logger := New(
+		WithFormatter(f),
+		WithWriter(TransformWriter(&testWriter{buf: &buf}, func(*Record) *Record { return nil })),
+	)
+
+	logger.Info("dropped")
+
+	assert.Empty(t, buf.String())
+	assert.Zero(t, f.calls)
+}
+
+func TestTransformWriter_FormatError(t *testing.T) {
+	var good, bad bytes.Buffer
+	poison := func(r *Record) *Record {
+		r.Message = "poison"
+		return r
+	}
+	logger := New(
+		WithFormatter(&poisonFormatter{Formatter: formatter.JSON()}),
+		WithWriter(&testWriter{buf: &good}),
+		WithWriter(TransformWriter(&testWriter{buf: &bad}, poison)),
+	)
+
+	err := logger.Handler().Handle(t.Context(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0))
+	require.Error(t, err)
+	assert.Contains(t, good.String(), `"msg":"msg"`)
+	assert.Empty(t, bad.String())
+}
+
+func TestTransformWriter_Concurrent(t *testing.T) {
+	var mu sync.Mutex
+	var a, b bytes.Buffer
+	logger := New(
+		WithFormatter(formatter.JSON()),
+		WithWriter(&lockedWriter{mu: &mu, buf: &a}),
+		WithWriter(TransformWriter(&lockedWriter{mu: &mu, buf: &b}, DropAttrs("i"))),
+	).With("shared", "x")
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for range 50 {
+				logger.Info("m", "i", i)
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, 400, strings.Count(a.String(), `"i":`))
+	assert.Zero(t, strings.Count(b.String(), `"i":`))
+	assert.Equal(t, 400, strings.Count(b.String(), `"shared":"x"`))
+}
+
+func TestTransformWriter_Delegates(t *testing.T) {
+	w := &closeTrackingWriter{testWriter: testWriter{buf: &bytes.Buffer{}}}
+	require.NoError(t, TransformWriter(w, DropAttrs()).Close())
+	assert.True(t, w.closed)
+
+	_, err := TransformWriter(&errWriter{}, DropAttrs()).Write([]byte("x"))
+	assert.ErrorIs(t, err, errWriteFailed)
+}
+
+func TestTransformWriter_InsideWrappers(t *testing.T) {
+	var async, attached, plain bytes.Buffer
+	h := newHandler(
+		WithFormatter(formatter.Text()),
+		WithWriter(writer.Async(TransformWriter(&testWriter{buf: &async}, DropAttrs("sql")), 16)),
+		WithWriter(&testWriter{buf: &plain}),
+	)
+	require.NoError(t, h.AttachWriter("tee", writer.Async(TransformWriter(&testWriter{buf: &attached}, DropAttrs("sql")), 16)))
+	slog.New(h).Info("query", "sql", "SELECT 1", "rows", 1)
+	require.NoError(t, h.Close())
+
+	assert.Contains(t, async.String(), "msg=query rows=1")
+	assert.NotContains(t, async.String(), "sql")
+	assert.NotContains(t, attached.String(), "sql")
+	assert.Contains(t, plain.String(), `sql="SELECT 1"`)
+	assert.Equal(t, "writer.AsyncWriter#0", h.Stats().Writers[0].Name)
+}
+
+func TestTransformWriter_InsideMultiIgnored(t *testing.T) {
+	var out bytes.Buffer
+	SetSelfLog(&out)
+	SetSelfLogInterval(0)
+	defer func() {
+		SetSelfLog(os.Stderr)
+		SetSelfLogInterval(selflog.DefaultInterval)
+	}()
+
+	var a, b bytes.Buffer
+	logger := New(
+		WithFormatter(formatter.Text()),
+		WithWriter(writer.Multi(TransformWriter(&testWriter{buf: &a}, DropAttrs("sql")), &testWriter{buf: &b})),
+	)
+	logger.Info("query", "sql", "SELECT 1")
+
+	assert.Contains(t, out.String(), "[transform] TransformWriter inside *writer.MultiWriter is ignored")
+	assert.Contains(t, a.String(), "sql=")
+	assert.Equal(t, a.String(), b.String())
+}
+
+// countingFormatter 统计 Format 调用次数
+type countingFormatter struct {
+	formatter.Formatter
+	calls int
+}
+
+func (f *countingFormatter) Format(r *Record) ([]byte, error) {
+	f.calls++
+	return f.Formatter.Format(r)
+}
+
+// poisonFormatter 消息为 poison 时返回错误
+type poisonFormatter struct {
+	formatter.Formatter
+}
+
+func (f *poisonFormatter) Format(r *Record) ([]byte, error) {
+	if r.Message == "poison" {
+		return nil, errors.New("poisoned")
+	}
+	return f.Formatter.Format(r)
+}
@@ -1,32 +1,54 @@
 package writer
 
 import (
+	"context"
+	"log/slog"
 	"sync"
+	"sync/atomic"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
 )
 
 // AsyncWriter 异步 Writer。
 //
 // 使用缓冲通道异步写入，提升高并发场景下的性能。
-// 调用 Close 时会等待所有缓冲数据写入完成。
+// 调用 Close 时会等待所有缓冲数据写入完成。缓冲区满时默认丢弃日志，
+// 配置 WithSpill 后溢出到磁盘。
 type AsyncWriter struct {
 	writer Writer
-	ch     chan []byte
+	ch     chan asyncItem
 	wg     sync.WaitGroup
 	closed bool
-	mu     sync.Mutex
+	mu     sync.RWMutex
+
+	abort   atomic.Bool   // 关闭超时后放弃剩余数据
+	dropped atomic.Uint64 // 缓冲区满、已关闭或关闭超时时丢弃的条数
+
+	spill   *spillQueue   // 缓冲区满时的溢出文件，见 WithSpill
+	spilled atomic.Uint64 // 溢出到文件的条数
+}
+
+// asyncItem 缓冲通道中的元素，done 非 nil 时为同步标记
+type asyncItem struct {
+	data  []byte
+	level slog.Level
+	done  chan struct{}
 }
 
 // Async 创建异步 Writer。
 //
 // bufferSize 指定缓冲通道大小，建议值 1000-10000。
-func Async(w Writer, bufferSize int) *AsyncWriter {
+func Async(w Writer, bufferSize int, opts ...AsyncOption) *AsyncWriter {
 	if bufferSize <= 0 {
 		bufferSize = 1000
 	}
 
 	aw := &AsyncWriter{
 		writer: w,
-		ch:     make(chan []byte, bufferSize),
+		ch:     make(chan asyncItem, bufferSize),
+	}
+	for _, opt := range opts {
+		opt(aw)
 	}
 
 	aw.wg.Add(1)
@@ -38,70 +60,212 @@ func Async(w Writer, bufferSize int) *AsyncWriter {
 // run 后台写入协程
 func (a *AsyncWriter) run() {
 	defer a.wg.Done()
-	for data := range a.ch {
-		_, _ = a.writer.Write(data)
+	for {
+		var item asyncItem
+		var ok bool
+		select {
+		case item, ok = <-a.ch:
+		default:
+			// 通道已空，写回溢出的日志
+			if a.writeSpilled() {
+				continue
+			}
+			item, ok = <-a.ch
+		}
+
+		if !ok {
+			if a.spill != nil {
+				for a.writeSpilled() {
+				}
+				a.dropped.Add(a.spill.close())
+			}
+			return
+		}
+		if item.done != nil {
+			// 同步标记之前溢出的日志也需要写完
+			if a.spill != nil {
+				pushed, popped := a.spill.counts()
+				for ; popped < pushed && a.writeSpilled(); popped++ {
+				}
+			}
+			close(item.done)
+			continue
+		}
+		a.write(item.level, item.data)
+	}
+}
+
+// write 写入底层 Writer，关闭超时后丢弃
+func (a *AsyncWriter) write(level slog.Level, p []byte) {
+	if a.abort.Load() {
+		a.dropped.Add(1)
+		return
+	}
+	_, _ = WriteLevel(a.writer, level, p)
+}
+
+// writeSpilled 写回一条溢出的日志，没有时返回 false
+func (a *AsyncWriter) writeSpilled() bool {
+	if a.spill == nil {
+		return false
+	}
+	level, p, ok := a.spill.pop()
+	if ok {
+		a.write(level, p)
 	}
+	return ok
 }
 
 // Write 实现 io.Writer。
 //
 // 将数据复制后放入缓冲通道，非阻塞（除非缓冲区满）。
 func (a *AsyncWriter) Write(p []byte) (n int, err error) {
-	a.mu.Lock()
+	return a.WriteLevel(slog.LevelInfo, p)
+}
+
+// WriteLevel 实现 LevelWriter，级别随数据一起缓冲并传递给底层 Writer。
+func (a *AsyncWriter) WriteLevel(level slog.Level, p []byte) (n int, err error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
 	if a.closed {
-		a.mu.Unlock()
+		a.dropped.Add(1)
 		return 0, nil
 	}
-	a.mu.Unlock()
+
+	// 已开始溢出时排在文件末尾，保证顺序
+	if a.spill != nil {
+		if queued, err := a.spill.pushIfActive(level, p); queued {
+			a.spillResult(err)
+			return len(p), nil
+		}
+	}
 
 	// 复制数据避免竞态
 	data := make([]byte, len(p))
 	copy(data, p)
 
 	select {
-	case a.ch <- data:
+	case a.ch <- asyncItem{data: data, level: level}:
 		return len(p), nil
 	default:
+		if a.spill != nil {
+			a.spillResult(a.spill.push(level, p))
+			return len(p), nil
+		}
 		// 缓冲区满，丢弃日志（或可选择阻塞）
+		selflog.Printf("async.drop", "async buffer full (size %d), dropped %d records so far", cap(a.ch), a.dropped.Add(1))
 		return len(p), nil
 	}
 }
 
+// spillResult 统计溢出结果，失败时计入 Dropped
+func (a *AsyncWriter) spillResult(err error) {
+	if err != nil {
+		selflog.Printf("async.drop", "async spill failed: %v, dropped %d records so far", err, a.dropped.Add(1))
+		return
+	}
+	a.spilled.Add(1)
+}
+
+// Rotate 实现 Rotator，等待缓冲区数据写入完成后轮转底层 Writer。
+//
+// 调用前记录的日志都写入轮转前的文件。
+func (a *AsyncWriter) Rotate() error {
+	if err := a.Sync(); err != nil {
+		return err
+	}
+	return Rotate(a.writer)
+}
+
+// Unwrap 实现 Wrapper，返回底层 Writer。
+func (a *AsyncWriter) Unwrap() []Writer {
+	return []Writer{a.writer}
+}
+
+// Dropped 返回因缓冲区满、已关闭、关闭超时或溢出失败而丢弃的日志条数。
+func (a *AsyncWriter) Dropped() uint64 {
+	if a.spill != nil {
+		return a.dropped.Load() + a.spill.lost.Load()
+	}
+	return a.dropped.Load()
+}
+
+// Spilled 返回溢出到文件的日志条数，见 WithSpill。
+func (a *AsyncWriter) Spilled() uint64 {
+	return a.spilled.Load()
+}
+
+// Len 返回当前缓冲区中等待写入的日志条数。
+func (a *AsyncWriter) Len() int {
+	return len(a.ch)
+}
+
 // Close 实现 io.Closer。
 //
 // 关闭通道并等待所有缓冲数据写入完成。
 func (a *AsyncWriter) Close() error {
+	return a.CloseContext(context.Background())
+}
+
+// CloseContext 关闭通道并在 ctx 结束前等待缓冲数据写入完成。
+//
+// ctx 结束时放弃尚未写入的数据（计入 Dropped）并返回 ctx.Err()，
+// 此时底层 Writer 不会被关闭，避免在已阻塞的 Writer 上再次阻塞。
+func (a *AsyncWriter) CloseContext(ctx context.Context) error {
 	a.mu.Lock()
 	if a.closed {
 		a.mu.Unlock()
 		return nil
 	}
 	a.closed = true
+	close(a.ch)
 	a.mu.Unlock()
 
-	close(a.ch)
-	a.wg.Wait()
-	return a.writer.Close()
+	finished := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return a.writer.Close()
+	case <-ctx.Done():
+		a.abort.Store(true)
+		return ctx.Err()
+	}
 }
 
 // Sync 实现 Writer.Sync。
 //
 // 等待当前缓冲区数据写入完成。
 func (a *AsyncWriter) Sync() error {
-	// 创建一个 done 通道来同步
+	return a.SyncContext(context.Background())
+}
+
+// SyncContext 在 ctx 结束前等待当前缓冲区数据写入完成。
+func (a *AsyncWriter) SyncContext(ctx context.Context) error {
 	done := make(chan struct{})
-	a.ch <- nil // 发送一个 nil 作为同步标记
 
-	go func() {
-		for data := range a.ch {
-			if data == nil {
-				close(done)
-				return
-			}
-			_, _ = a.writer.Write(data)
-		}
-	}()
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return a.writer.Sync()
+	}
+	// 同步标记排在已缓冲数据之后，后台协程处理到它时说明之前的数据已写完
+	select {
+	case a.ch <- asyncItem{done: done}:
+	case <-ctx.Done():
+		a.mu.RUnlock()
+		return ctx.Err()
+	}
+	a.mu.RUnlock()
 
-	<-done
-	return a.writer.Sync()
+	select {
+	case <-done:
+		return a.writer.Sync()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
@@ -0,0 +1,160 @@
+package writer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// spillHeaderSize 溢出记录头：4 字节级别 + 4 字节长度
+const spillHeaderSize = 8
+
+// errSpillFull 溢出文件达到上限
+var errSpillFull = errors.New("spill file full")
+
+// AsyncOption AsyncWriter 配置选项
+type AsyncOption func(*AsyncWriter)
+
+// WithSpill 缓冲区满时将新日志溢出到 dir 下的临时文件，后台追上后按顺序写回。
+//
+// 内存占用保持在缓冲区大小以内，下游停顿几分钟也不会丢弃日志：
+//
+//	writer.Async(collector, 4096, writer.WithSpill("/var/lib/app/spill", 512*1024*1024))
+//
+// 开始溢出后新日志都写入文件，直到文件中的日志全部写回，保证写入顺序。
+// maxBytes 限制溢出文件的大小，文件全部写回前不会复用空间，超过后丢弃新日志并计入 Dropped。
+// dir 为空时使用 os.TempDir()。文件在首次溢出时创建，Close 时删除，不用于进程重启后的恢复。
+func WithSpill(dir string, maxBytes int64) AsyncOption {
+	return func(a *AsyncWriter) {
+		a.spill = &spillQueue{dir: dir, max: maxBytes}
+	}
+}
+
+// spillQueue 基于单个临时文件的 FIFO 队列
+type spillQueue struct {
+	dir string
+	max int64
+
+	mu     sync.Mutex
+	f      *os.File
+	failed bool  // 创建文件失败或已关闭，不再创建
+	rd, wr int64 // 读写偏移，全部读出后文件截断并归零
+	pushed uint64
+	popped uint64
+	lost   atomic.Uint64 // 读取失败而丢弃的条数
+}
+
+// pushIfActive 队列非空时追加记录，保证开始溢出后的日志都排在文件中
+func (q *spillQueue) pushIfActive(level slog.Level, p []byte) (queued bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.rd == q.wr {
+		return false, nil
+	}
+	return true, q.pushLocked(level, p)
+}
+
+// push 追加一条记录
+func (q *spillQueue) push(level slog.Level, p []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pushLocked(level, p)
+}
+
+// pushLocked 追加一条记录，调用方持有锁
+func (q *spillQueue) pushLocked(level slog.Level, p []byte) error {
+	if q.f == nil {
+		if q.failed {
+			return errSpillFull
+		}
+		f, err := os.CreateTemp(q.dir, "logm-spill-*")
+		if err != nil {
+			q.failed = true
+			return fmt.Errorf("create spill file: %w", err)
+		}
+		q.f = f
+	}
+	size := int64(spillHeaderSize + len(p))
+	if q.max > 0 && q.wr+size > q.max {
+		return errSpillFull
+	}
+
+	buf := make([]byte, size)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(int32(level))) //nolint:gosec // 级别按位保存
+	binary.BigEndian.PutUint32(buf[4:8], uint32(len(p)))       //nolint:gosec // 单条日志不会超过 4GB
+	copy(buf[spillHeaderSize:], p)
+	if _, err := q.f.WriteAt(buf, q.wr); err != nil {
+		return err
+	}
+	q.wr += size
+	q.pushed++
+	return nil
+}
+
+// pop 读出最早的一条记录，队列为空时返回 ok 为 false
+func (q *spillQueue) pop() (level slog.Level, p []byte, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.rd == q.wr {
+		return 0, nil, false
+	}
+
+	var hdr [spillHeaderSize]byte
+	_, err := q.f.ReadAt(hdr[:], q.rd)
+	if err == nil {
+		level = slog.Level(int32(binary.BigEndian.Uint32(hdr[0:4]))) //nolint:gosec // 与 pushLocked 对应
+		p = make([]byte, binary.BigEndian.Uint32(hdr[4:8]))
+		_, err = q.f.ReadAt(p, q.rd+spillHeaderSize)
+	}
+	if err != nil {
+		// 文件损坏时丢弃剩余记录，避免反复失败
+		selflog.Printf("async.spill", "read spill file: %v, discarded %d records", err, q.pushed-q.popped)
+		q.lost.Add(q.pushed - q.popped)
+		q.popped = q.pushed
+		q.resetLocked()
+		return 0, nil, false
+	}
+
+	q.rd += int64(spillHeaderSize + len(p))
+	q.popped++
+	if q.rd == q.wr {
+		q.resetLocked()
+	}
+	return level, p, true
+}
+
+// resetLocked 清空文件以复用空间
+func (q *spillQueue) resetLocked() {
+	q.rd, q.wr = 0, 0
+	if err := q.f.Truncate(0); err != nil {
+		selflog.Printf("async.spill", "truncate spill file: %v", err)
+	}
+}
+
+// counts 返回累计写入和读出的记录数
+func (q *spillQueue) counts() (pushed, popped uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pushed, q.popped
+}
+
+// close 删除溢出文件，返回未读出的记录数
+func (q *spillQueue) close() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.f == nil {
+		return 0
+	}
+	pending := q.pushed - q.popped
+	_ = q.f.Close()
+	_ = os.Remove(q.f.Name())
+	q.f = nil
+	q.failed = true
+	return pending
+}
@@ -0,0 +1,266 @@
+package writer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// DLQ 默认的重试配置
+const (
+	DefaultDLQAttempts = 3
+	DefaultDLQBackoff  = 100 * time.Millisecond
+)
+
+// dlqReplaySuffix 重放期间死信文件的临时后缀
+const dlqReplaySuffix = ".replaying"
+
+// dlqEntry 死信文件中的一条记录，每行一个 JSON 对象
+type dlqEntry struct {
+	Time  time.Time  `json:"time"`
+	Level slog.Level `json:"level"`
+	Error string     `json:"error,omitempty"`
+	Data  []byte     `json:"data"`
+}
+
+// DLQWriter 写入失败时重试、重试耗尽后将记录保存到本地死信文件的 Writer。
+//
+// 包装网络类 Writer，采集端故障期间的日志不会丢失，恢复后用 ReplayDLQ 重新投递：
+//
+//	w := writer.DLQ(collector, "/var/lib/app/logm.dlq")
+//	// 采集端恢复后
+//	n, err := writer.ReplayDLQ(ctx, "/var/lib/app/logm.dlq", collector)
+//
+// 每次 Write 为一条完整记录（Handler 即如此调用）。重试在调用方协程中进行，
+// 通常放在 Async 的内层。记录保存到死信文件后 Write 返回成功；
+// 死信文件写入失败或超过上限时返回原始错误，并计入 Dropped。
+type DLQWriter struct {
+	w        Writer
+	path     string
+	attempts int
+	backoff  time.Duration
+	maxSize  int64
+	mode     os.FileMode
+
+	mu      sync.Mutex // 串行追加死信文件
+	dead    atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// DLQOption DLQWriter 配置选项
+type DLQOption func(*DLQWriter)
+
+// WithDLQRetry 设置写入尝试次数和首次重试前的等待时间，等待时间每次翻倍。
+//
+// 默认 DefaultDLQAttempts 次，DefaultDLQBackoff。
+func WithDLQRetry(attempts int, backoff time.Duration) DLQOption {
+	return func(d *DLQWriter) {
+		if attempts > 0 {
+			d.attempts = attempts
+		}
+		if backoff >= 0 {
+			d.backoff = backoff
+		}
+	}
+}
+
+// WithDLQMaxSize 设置死信文件的大小上限（字节），超过后丢弃新的记录，默认 0 不限制。
+func WithDLQMaxSize(bytes int64) DLQOption {
+	return func(d *DLQWriter) {
+		d.maxSize = bytes
+	}
+}
+
+// WithDLQFileMode 设置死信文件权限，默认 0600。
+func WithDLQFileMode(mode os.FileMode) DLQOption {
+	return func(d *DLQWriter) {
+		d.mode = mode
+	}
+}
+
+// DLQ 创建带死信文件的 Writer，死信文件在首次需要时创建。
+func DLQ(w Writer, path string, opts ...DLQOption) *DLQWriter {
+	d := &DLQWriter{
+		w:        w,
+		path:     path,
+		attempts: DefaultDLQAttempts,
+		backoff:  DefaultDLQBackoff,
+		mode:     0o600,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Write 实现 io.Writer。
+func (d *DLQWriter) Write(p []byte) (n int, err error) {
+	return d.WriteLevel(slog.LevelInfo, p)
+}
+
+// WriteLevel 实现 LevelWriter，级别随记录一起保存，重放时原样传递。
+func (d *DLQWriter) WriteLevel(level slog.Level, p []byte) (n int, err error) {
+	backoff := d.backoff
+	for i := range d.attempts {
+		if i > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if _, err = WriteLevel(d.w, level, p); err == nil {
+			return len(p), nil
+		}
+	}
+
+	if dlqErr := d.append(dlqEntry{Time: time.Now(), Level: level, Error: err.Error(), Data: p}); dlqErr != nil {
+		d.dropped.Add(1)
+		selflog.Printf("dlq", "dead letter %s: %v", d.path, dlqErr)
+		return 0, err
+	}
+	d.dead.Add(1)
+	return len(p), nil
+}
+
+// append 追加一条记录到死信文件
+func (d *DLQWriter) append(e dlqEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f, err := os.OpenFile(d.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, d.mode)
+	if err != nil {
+		return err
+	}
+	if d.maxSize > 0 {
+		if fi, err := f.Stat(); err == nil && fi.Size()+int64(len(line)) > d.maxSize {
+			_ = f.Close()
+			return fmt.Errorf("dead letter file exceeds %d bytes", d.maxSize)
+		}
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// DeadLettered 返回保存到死信文件的记录数。
+func (d *DLQWriter) DeadLettered() uint64 {
+	return d.dead.Load()
+}
+
+// Dropped 返回重试耗尽且无法保存到死信文件的记录数。
+func (d *DLQWriter) Dropped() uint64 {
+	return d.dropped.Load()
+}
+
+// Path 返回死信文件路径。
+func (d *DLQWriter) Path() string {
+	return d.path
+}
+
+// Close 实现 io.Closer。
+func (d *DLQWriter) Close() error {
+	return d.w.Close()
+}
+
+// Sync 实现 Writer.Sync。
+func (d *DLQWriter) Sync() error {
+	return d.w.Sync()
+}
+
+// Rotate 实现 Rotator，轮转底层 Writer。
+func (d *DLQWriter) Rotate() error {
+	return Rotate(d.w)
+}
+
+// Unwrap 实现 Wrapper，返回底层 Writer。
+func (d *DLQWriter) Unwrap() []Writer {
+	return []Writer{d.w}
+}
+
+// ReplayDLQ 将死信文件中的记录按顺序重新写入 target，返回成功写入的条数。
+//
+// 重放前将死信文件改名为 path+".replaying"，期间新的死信写入新文件，不会与重放冲突。
+// ctx 结束或 target 写入失败时停止，未重放的记录追加回 path，之后可以再次调用；
+// 上次重放中断遗留的临时文件会先被处理，中断前已写入的记录可能重复投递。
+// 无法解析的行跳过并通过 logm 自诊断输出报告。
+// 死信文件不存在时返回 0, nil。
+func ReplayDLQ(ctx context.Context, path string, target Writer) (n int, err error) {
+	pending := path + dlqReplaySuffix
+	if _, err := os.Stat(pending); errors.Is(err, fs.ErrNotExist) {
+		if err := os.Rename(path, pending); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("writer: replay dlq: %w", err)
+		}
+	}
+
+	f, err := os.Open(pending)
+	if err != nil {
+		return 0, fmt.Errorf("writer: replay dlq: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("writer: replay dlq: %w", err)
+	}
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	var rest *os.File // 停止后未重放的记录追加回 path
+	var stopErr error
+	for line := 1; sc.Scan(); line++ {
+		if rest == nil {
+			var e dlqEntry
+			if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+				selflog.Printf("dlq", "skip malformed line %d in %s: %v", line, pending, err)
+				continue
+			}
+			if stopErr = ctx.Err(); stopErr == nil {
+				_, stopErr = WriteLevel(target, e.Level, e.Data)
+			}
+			if stopErr == nil {
+				n++
+				continue
+			}
+			// 沿用死信文件的权限（见 WithDLQFileMode）
+			if rest, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, info.Mode().Perm()); err != nil {
+				return n, fmt.Errorf("writer: replay dlq: %w", errors.Join(stopErr, err))
+			}
+			defer func() { _ = rest.Close() }()
+		}
+		if _, err := rest.Write(append(sc.Bytes(), '\n')); err != nil {
+			return n, fmt.Errorf("writer: replay dlq: %w", errors.Join(stopErr, err))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return n, fmt.Errorf("writer: replay dlq: %w", errors.Join(stopErr, err))
+	}
+	if rest != nil {
+		if err := rest.Sync(); err != nil {
+			return n, fmt.Errorf("writer: replay dlq: %w", errors.Join(stopErr, err))
+		}
+	}
+	if err := os.Remove(pending); err != nil {
+		return n, fmt.Errorf("writer: replay dlq: %w", errors.Join(stopErr, err))
+	}
+	if stopErr != nil {
+		return n, fmt.Errorf("writer: replay dlq: %w", stopErr)
+	}
+	return n, nil
+}
@@ -0,0 +1,335 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// 加密块格式（所有整数为大端序）：
+//
+//	magic       4 字节 "LME1"
+//	keyIDLen    1 字节
+//	keyID       keyIDLen 字节，主密钥标识
+//	wrappedKey  60 字节，主密钥 AES-GCM 加密的数据密钥（nonce 12 + 密文 32 + tag 16）
+//	nonce       12 字节
+//	length      4 字节，密文长度
+//	ciphertext  length 字节，数据密钥 AES-GCM 加密的日志，以块头为附加数据
+//
+// 每个块都携带封装后的数据密钥，可以独立解密，轮转切分文件不影响解密。
+const (
+	encMagic      = "LME1"
+	dataKeySize   = 32
+	gcmNonceSize  = 12
+	wrappedKeyLen = gcmNonceSize + dataKeySize + gcmTagSize
+	gcmTagSize    = 16
+	maxChunkLen   = 64 << 20 // Decrypt 接受的最大密文长度
+	maxChunkPlain = maxChunkLen - gcmTagSize
+)
+
+// KeyLookup 按标识返回主密钥，用于解密。
+type KeyLookup func(keyID string) ([]byte, error)
+
+// EncryptOption 加密 Writer 选项
+type EncryptOption func(*EncryptedWriter)
+
+// WithChunkSize 设置加密块大小（字节）。
+//
+// 默认 0，每次 Write 单独加密为一个块，不会在内存中缓冲日志；
+// 大于 0 时缓冲到该大小或调用 Sync、Close 时才加密写出，
+// 块头开销更小，但进程崩溃时会丢失缓冲中的日志。
+// 块大小不超过 64MB，更大的值按 64MB 处理，超过块大小的单次写入拆分为多个块。
+func WithChunkSize(n int) EncryptOption {
+	return func(e *EncryptedWriter) {
+		e.chunkSize = min(max(n, 0), maxChunkPlain)
+	}
+}
+
+// EncryptedWriter 静态加密 Writer。
+//
+// 使用信封加密：每个 Writer 生成随机数据密钥加密日志，
+// 数据密钥再由主密钥加密后写入每个块头。导出的日志归档离开原磁盘后，
+// 没有主密钥无法读取，适合磁盘级加密不能覆盖的场景。
+//
+// 使用 Decrypt 还原明文。
+type EncryptedWriter struct {
+	mu     sync.Mutex
+	w      Writer
+	keyID  string
+	header []byte // magic + keyID + 封装后的数据密钥
+	aead   cipher.AEAD
+	prefix [4]byte // nonce 随机前缀
+	count  uint64  // nonce 计数器
+
+	chunkSize int
+	maxChunk  int // 单个块的最大明文长度，测试时替换
+	buf       []byte
+	bufLevel  slog.Level // 缓冲中最高的级别
+}
+
+// Encrypt 创建加密写入 w 的 Writer。
+//
+// keyID 标识主密钥，随块头写出，解密时据此查找密钥；key 为 32 字节 AES-256 主密钥。
+func Encrypt(w Writer, keyID string, key []byte, opts ...EncryptOption) (*EncryptedWriter, error) {
+	if len(keyID) == 0 || len(keyID) > 255 {
+		return nil, fmt.Errorf("writer: invalid key id %q", keyID)
+	}
+	kek, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("writer: generate data key: %w", err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(encMagic)+1+len(keyID)+wrappedKeyLen)
+	header = append(header, encMagic...)
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+	// 主密钥加密数据密钥时以 magic 和 keyID 为附加数据（Seal 要求附加数据与输出不重叠）
+	aad := bytes.Clone(header)
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("writer: generate nonce: %w", err)
+	}
+	header = append(header, nonce...)
+	header = kek.Seal(header, nonce, dataKey, aad)
+
+	e := &EncryptedWriter{w: w, keyID: keyID, header: header, aead: aead, maxChunk: maxChunkPlain}
+	if _, err := rand.Read(e.prefix[:]); err != nil {
+		return nil, fmt.Errorf("writer: generate nonce: %w", err)
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e, nil
+}
+
+// EncryptedFile 创建加密的文件 Writer，保留 File 的轮转能力，fileOpts 传给 File：
+//
+//	w, err := writer.EncryptedFile("/var/log/app.log.enc", "2024q1", key,
+//	    []writer.FileOption{writer.WithRotation(100, 3)},
+//	    writer.WithChunkSize(64<<10),
+//	)
+//
+// 密文无法压缩，轮转后的文件不再启用 gzip 压缩。
+func EncryptedFile(path, keyID string, key []byte, fileOpts []FileOption, opts ...EncryptOption) (*EncryptedWriter, error) {
+	f := File(path, append(slices.Clip(fileOpts), WithCompress(false))...)
+	return Encrypt(f, keyID, key, opts...)
+}
+
+// Write 实现 io.Writer，按 INFO 级别写入。
+func (e *EncryptedWriter) Write(p []byte) (n int, err error) {
+	return e.WriteLevel(slog.LevelInfo, p)
+}
+
+// WriteLevel 实现 LevelWriter，将级别传递给底层 Writer（如 WithFsyncLevel）。
+//
+// 设置 WithChunkSize 时，块以其中最高的级别写出。
+func (e *EncryptedWriter) WriteLevel(level slog.Level, p []byte) (n int, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.chunkSize == 0 {
+		if err := e.writeChunk(level, p); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if len(e.buf) == 0 || level > e.bufLevel {
+		e.bufLevel = level
+	}
+	e.buf = append(e.buf, p...)
+	if len(e.buf) >= e.chunkSize {
+		if err := e.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Sync 加密写出缓冲的日志并刷新底层 Writer。
+func (e *EncryptedWriter) Sync() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.flush(); err != nil {
+		return err
+	}
+	return e.w.Sync()
+}
+
+// Close 加密写出缓冲的日志并关闭底层 Writer。
+func (e *EncryptedWriter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return errors.Join(e.flush(), e.w.Close())
+}
+
+// Unwrap 实现 Wrapper，返回底层 Writer。
+func (e *EncryptedWriter) Unwrap() []Writer {
+	return []Writer{e.w}
+}
+
+// Rotate 写出缓冲的日志后轮转底层 Writer。
+//
+// 底层 Writer 不支持轮转时返回错误。
+func (e *EncryptedWriter) Rotate() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	r, ok := e.w.(Rotator)
+	if !ok {
+		return errors.New("writer: underlying writer does not support rotation")
+	}
+	if err := e.flush(); err != nil {
+		return err
+	}
+	return r.Rotate()
+}
+
+// KeyID 返回主密钥标识。
+func (e *EncryptedWriter) KeyID() string {
+	return e.keyID
+}
+
+// flush 加密写出缓冲区
+func (e *EncryptedWriter) flush() error {
+	if len(e.buf) == 0 {
+		return nil
+	}
+	err := e.writeChunk(e.bufLevel, e.buf)
+	e.buf = e.buf[:0]
+	return err
+}
+
+// writeChunk 将 p 加密写入底层 Writer，超过 maxChunk 时拆分为多个块，每个块一次 WriteLevel
+func (e *EncryptedWriter) writeChunk(level slog.Level, p []byte) error {
+	for len(p) > e.maxChunk {
+		if err := e.sealChunk(level, p[:e.maxChunk]); err != nil {
+			return err
+		}
+		p = p[e.maxChunk:]
+	}
+	return e.sealChunk(level, p)
+}
+
+// sealChunk 将 p 加密为一个块写入底层 Writer
+func (e *EncryptedWriter) sealChunk(level slog.Level, p []byte) error {
+	e.count++
+	nonce := make([]byte, gcmNonceSize)
+	copy(nonce, e.prefix[:])
+	binary.BigEndian.PutUint64(nonce[4:], e.count)
+
+	ctLen := len(p) + e.aead.Overhead()
+	chunk := make([]byte, 0, len(e.header)+gcmNonceSize+4+ctLen)
+	chunk = append(chunk, e.header...)
+	chunk = append(chunk, nonce...)
+	chunk = binary.BigEndian.AppendUint32(chunk, uint32(ctLen)) //nolint:gosec // G115: 块大小远小于 4GB
+	chunk = e.aead.Seal(chunk, nonce, p, bytes.Clone(chunk))
+
+	_, err := WriteLevel(e.w, level, chunk)
+	return err
+}
+
+// Decrypt 解密 src 中的加密块并将明文写入 dst。
+//
+// 可以处理 EncryptedWriter 输出的任意完整文件，包括多次打开追加写入的文件。
+// 块被篡改或密钥错误时返回错误，之前的块已写入 dst。
+func Decrypt(dst io.Writer, src io.Reader, lookup KeyLookup) error {
+	r := bufio.NewReader(src)
+	keys := map[string]cipher.AEAD{} // 按封装后的数据密钥缓存
+	for {
+		magic := make([]byte, len(encMagic)+1)
+		if _, err := io.ReadFull(r, magic); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("writer: decrypt: truncated chunk: %w", err)
+		}
+		if string(magic[:len(encMagic)]) != encMagic {
+			return errors.New("writer: decrypt: invalid chunk magic")
+		}
+
+		rest := make([]byte, int(magic[len(encMagic)])+wrappedKeyLen+gcmNonceSize+4)
+		if _, err := io.ReadFull(r, rest); err != nil {
+			return fmt.Errorf("writer: decrypt: truncated chunk: %w", err)
+		}
+		header := append(magic, rest...)
+		idEnd := len(magic) + int(magic[len(encMagic)])
+		wrapped := header[idEnd : idEnd+wrappedKeyLen]
+		nonce := header[idEnd+wrappedKeyLen : idEnd+wrappedKeyLen+gcmNonceSize]
+		ctLen := binary.BigEndian.Uint32(header[len(header)-4:])
+		if ctLen > maxChunkLen {
+			return fmt.Errorf("writer: decrypt: chunk too large (%d bytes)", ctLen)
+		}
+
+		aead, ok := keys[string(wrapped)]
+		if !ok {
+			var err error
+			if aead, err = unwrapKey(header[:idEnd], wrapped, lookup); err != nil {
+				return err
+			}
+			keys[string(wrapped)] = aead
+		}
+
+		ct := make([]byte, ctLen)
+		if _, err := io.ReadFull(r, ct); err != nil {
+			return fmt.Errorf("writer: decrypt: truncated chunk: %w", err)
+		}
+		plain, err := aead.Open(nil, nonce, ct, header)
+		if err != nil {
+			return fmt.Errorf("writer: decrypt: %w", err)
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+	}
+}
+
+// unwrapKey 使用主密钥解出数据密钥
+func unwrapKey(prefix, wrapped []byte, lookup KeyLookup) (cipher.AEAD, error) {
+	keyID := string(prefix[len(encMagic)+1:])
+	key, err := lookup(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("writer: decrypt: key %q: %w", keyID, err)
+	}
+	kek, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := kek.Open(nil, wrapped[:gcmNonceSize], wrapped[gcmNonceSize:], prefix)
+	if err != nil {
+		return nil, fmt.Errorf("writer: decrypt: unwrap data key %q: %w", keyID, err)
+	}
+	return newGCM(dataKey)
+}
+
+// newGCM 创建 AES-256-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("writer: encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("writer: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
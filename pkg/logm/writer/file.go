@@ -1,37 +1,126 @@
 package writer
 
 import (
-	"gopkg.in/natefinch/lumberjack.v2"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
 )
 
+// megabyte MaxSize 的单位
+const megabyte = 1024 * 1024
+
 // FileWriter 文件 Writer，支持日志轮转。
 //
-// 基于 lumberjack 实现，支持按大小轮转、备份数量限制和压缩。
+// 文件大小超过上限或到达轮转周期时（以先到者为准），当前文件被重命名为带时间戳的备份，
+// 并在原路径创建新文件；备份按数量、天数限制清理，可选 gzip 压缩。
+// 清理和压缩在后台协程中执行，Close 会等待其完成。
 type FileWriter struct {
-	lj *lumberjack.Logger
+	mu      sync.Mutex
+	base    string // 调用方指定的路径
+	path    string // 当前写入的路径，按日期分目录时随日期变化
+	dateDir string // 日期目录的时间格式，空表示不分目录
+	link    string // 指向当前文件的符号链接路径，空表示不维护
+
+	maxSize    int64         // 单个文件最大字节数
+	maxBackups int           // 保留的备份数量，0 表示不限
+	maxAge     time.Duration // 备份保留时长，0 表示不限
+	maxTotal   int64         // 当前文件与备份的总字节数上限，0 表示不限
+	compress   bool
+	localTime  bool
+	interval   time.Duration // 按时间轮转的周期，0 表示不按时间轮转
+	pattern    string        // 备份文件名模式，空表示默认格式
+	backup     *backupPattern
+
+	mode     os.FileMode // 日志文件权限，0 表示新文件 0600、轮转时沿用旧文件权限
+	dirMode  os.FileMode // 新建目录权限，0 表示 0755
+	uid, gid int
+	chown    bool
+
+	file     *os.File
+	size     int64
+	openedAt time.Time // 当前文件开始写入的时间，用于备份文件名
+	deadline time.Time // 下一次按时间轮转的时刻
+	err      error     // 配置错误，写入时返回
+
+	millOnce sync.Once
+	millCh   chan struct{}
+	millWG   sync.WaitGroup
+
+	onRotate func(oldPath string)
+	rotated  []string // 等待通知 onRotate 的备份路径
+
+	diskFull    DiskFullPolicy
+	onDiskFull  func(err error)
+	full        bool     // 处于磁盘空间不足状态
+	pending     [][]byte // DiskFullBuffer 暂存的数据
+	pendingSize int
+	pendingMax  int
+	retry       time.Duration // DiskFullBlock 的重试间隔
+	closing     atomic.Bool   // Close 进行中，用于结束 DiskFullBlock 的等待
+	wake        chan struct{} // Close 唤醒 DiskFullBlock 的等待
+	dropped     atomic.Uint64
+
+	fsyncInterval time.Duration
+	fsyncLevel    slog.Level
+	fsyncOnLevel  bool
+	dirty         bool // 有写入尚未 fsync
+	fsyncTimer    *time.Timer
+
+	write func(file *os.File, p []byte) (int, error) // 写入函数，测试时替换
+	fsync func(file *os.File) error                  // fsync 函数，测试时替换
+
+	now func() time.Time // 时间来源，测试时替换
 }
 
 // FileOption 文件 Writer 选项
-type FileOption func(*lumberjack.Logger)
+type FileOption func(*FileWriter)
 
 // File 创建文件 Writer。
 //
 // 默认配置：100MB 轮转、保留 7 个备份、30 天过期、启用压缩。
+// 文件在首次写入时打开，已存在时追加写入。
 func File(path string, opts ...FileOption) *FileWriter {
-	lj := &lumberjack.Logger{
-		Filename:   path,
-		MaxSize:    100, // MB
-		MaxBackups: 7,
-		MaxAge:     30, // days
-		Compress:   true,
-		LocalTime:  true,
+	f := &FileWriter{
+		base:       path,
+		path:       path,
+		maxSize:    100 * megabyte,
+		maxBackups: 7,
+		maxAge:     30 * 24 * time.Hour,
+		compress:   true,
+		localTime:  true,
+		pendingMax: defaultDiskFullBuffer,
+		retry:      diskFullRetry,
+		wake:       make(chan struct{}, 1),
+		now:        time.Now,
+		write:      (*os.File).Write,
+		fsync:      defaultFsync,
 	}
-
 	for _, opt := range opts {
-		opt(lj)
+		opt(f)
 	}
-
-	return &FileWriter{lj: lj}
+	pattern := f.pattern
+	if pattern == "" {
+		pattern = defaultPattern(path)
+	}
+	f.backup, f.err = compilePattern(pattern)
+	if f.dateDir != "" {
+		if strings.ContainsAny(f.dateDir, `/\`) {
+			f.err = fmt.Errorf("writer: date directory layout %q must not contain path separators", f.dateDir)
+		}
+		f.path = f.datedPath(f.now())
+	}
+	if f.link != "" && f.dateDir == "" && filepath.Clean(f.link) == filepath.Clean(f.base) {
+		f.err = fmt.Errorf("writer: symlink %q must differ from the log file path", f.link)
+	}
+	return f
 }
 
 // WithRotation 设置轮转配置。
@@ -39,50 +128,518 @@ func File(path string, opts ...FileOption) *FileWriter {
 // maxSize: 单个文件最大大小（MB）
 // maxBackups: 保留的备份文件数量
 func WithRotation(maxSize, maxBackups int) FileOption {
-	return func(lj *lumberjack.Logger) {
-		lj.MaxSize = maxSize
-		lj.MaxBackups = maxBackups
+	return func(f *FileWriter) {
+		if maxSize > 0 {
+			f.maxSize = int64(maxSize) * megabyte
+		}
+		f.maxBackups = maxBackups
 	}
 }
 
 // WithMaxAge 设置文件保留天数。
 func WithMaxAge(days int) FileOption {
-	return func(lj *lumberjack.Logger) {
-		lj.MaxAge = days
+	return func(f *FileWriter) {
+		f.maxAge = time.Duration(days) * 24 * time.Hour
+	}
+}
+
+// WithMaxTotalSize 设置当前文件与所有备份的总大小上限（字节），如 2 << 30。
+//
+// 在数量和天数限制之外生效：每次轮转后从最旧的备份开始删除，直到总大小不超过上限，
+// 避免单个文件异常增大时备份占满磁盘。当前文件不会被删除。
+func WithMaxTotalSize(bytes int64) FileOption {
+	return func(f *FileWriter) {
+		f.maxTotal = max(bytes, 0)
 	}
 }
 
 // WithCompress 设置是否压缩旧日志。
 func WithCompress(enable bool) FileOption {
-	return func(lj *lumberjack.Logger) {
-		lj.Compress = enable
+	return func(f *FileWriter) {
+		f.compress = enable
 	}
 }
 
 // WithLocalTime 设置文件名时间戳是否使用本地时间。
 func WithLocalTime(enable bool) FileOption {
-	return func(lj *lumberjack.Logger) {
-		lj.LocalTime = enable
+	return func(f *FileWriter) {
+		f.localTime = enable
+	}
+}
+
+// WithInterval 设置按时间轮转的周期，与大小轮转以先到者为准。
+//
+// 轮转时刻按周期对齐：1 小时在每个整点，24 小时在每天零点（按 WithLocalTime 的时区）。
+// 启动时已有文件的最后修改时间属于上一个周期时，首次写入会先轮转。
+func WithInterval(d time.Duration) FileOption {
+	return func(f *FileWriter) {
+		f.interval = max(d, 0)
+	}
+}
+
+// WithFilenamePattern 设置备份文件名模式，备份与日志文件位于同一目录。
+//
+// 支持的占位符：%Y 年、%y 两位年、%m 月、%d 日、%H 时、%M 分、%S 秒、%L 毫秒、%% 百分号。
+// 时间为该文件开始写入的时间，按周期轮转时即文件内容所属的周期：
+//
+//	writer.File("/var/log/app.log",
+//	    writer.WithInterval(time.Hour),
+//	    writer.WithFilenamePattern("app-%Y%m%d-%H.log.gz"),
+//	)
+//
+// 启用压缩时备份名总是以 .gz 结尾，模式中的 .gz 后缀可写可不写。
+// 同名备份已存在时在扩展名前追加 "-1"、"-2" 等序号。
+// 默认模式为 "<名称>-%Y-%m-%dT%H-%M-%S.%L<扩展名>"。
+func WithFilenamePattern(pattern string) FileOption {
+	return func(f *FileWriter) {
+		f.pattern = pattern
+	}
+}
+
+// WithDateDir 按日期分目录存放日志，layout 为 Go 时间格式，如 "2006-01-02"。
+//
+// 日志写入 File 路径所在目录下以日期命名的子目录，日期变化时自动创建新目录并切换：
+//
+//	writer.File("logs/app.log", writer.WithDateDir("2006-01-02"))
+//	// logs/2024-01-15/app.log
+//	// logs/2024-01-16/app.log
+//
+// 日期按 WithLocalTime 的时区计算。大小和周期轮转的备份与当前文件位于同一日期目录，
+// 数量、总大小限制只作用于当前目录；设置 WithMaxAge 时，日期早于保留期限的目录整个删除。
+// 切换目录时旧文件不改名，设置了 WithOnRotate 时以旧文件路径回调。
+func WithDateDir(layout string) FileOption {
+	return func(f *FileWriter) {
+		f.dateDir = layout
+	}
+}
+
+// WithSymlink 维护指向当前日志文件的符号链接，打开新文件时更新。
+//
+// 与 WithDateDir 配合使用，运维可以始终通过固定路径查看当前日志：
+//
+//	writer.File("logs/app.log",
+//	    writer.WithDateDir("2006-01-02"),
+//	    writer.WithSymlink("logs/app.log"), // -> 2024-01-15/app.log
+//	)
+//
+// 链接使用相对路径并原子替换，`tail -F logs/app.log` 在切换后继续跟随新文件。
+// link 已存在且不是符号链接时不会覆盖；更新失败只记录到 selflog，不影响写入。
+func WithSymlink(link string) FileOption {
+	return func(f *FileWriter) {
+		f.link = link
+	}
+}
+
+// WithOnRotate 设置轮转后的回调，oldPath 为轮转出的备份文件路径。
+//
+// 回调在后台协程中执行，此时备份已完成压缩和清理，文件不会再被写入，
+// 可以直接上传到对象存储或触发重建索引。启用压缩时 oldPath 以 .gz 结尾；
+// 备份已被保留策略删除时不会回调。Close 会等待回调返回。
+func WithOnRotate(fn func(oldPath string)) FileOption {
+	return func(f *FileWriter) {
+		f.onRotate = fn
+	}
+}
+
+// WithFileMode 设置日志文件权限，如 0o640。
+//
+// 创建文件时精确设置权限（不受 umask 影响），已存在的文件也会被修改，
+// 轮转生成的新文件和压缩备份使用相同的权限与属主。
+func WithFileMode(mode os.FileMode) FileOption {
+	return func(f *FileWriter) {
+		f.mode = mode.Perm()
+	}
+}
+
+// WithDirMode 设置自动创建的日志目录权限，如 0o750，已存在的目录不做修改。
+func WithDirMode(mode os.FileMode) FileOption {
+	return func(f *FileWriter) {
+		f.dirMode = mode.Perm()
+	}
+}
+
+// WithOwner 设置日志文件及自动创建目录的属主。
+//
+// 通常需要 root 权限或 CAP_CHOWN；Windows 不支持，首次写入时返回错误。
+func WithOwner(uid, gid int) FileOption {
+	return func(f *FileWriter) {
+		f.uid, f.gid = uid, gid
+		f.chown = true
 	}
 }
 
 // Write 实现 io.Writer。
+//
+// 单次写入超过文件大小上限时返回错误；磁盘空间不足时按 WithDiskFullPolicy 处理。
 func (f *FileWriter) Write(p []byte) (n int, err error) {
-	return f.lj.Write(p)
+	return f.writeRecord(p, false)
 }
 
-// Close 实现 io.Closer。
+// writeRecord 写入一条记录，fsync 为 true 时写入后立即 fsync
+func (f *FileWriter) writeRecord(p []byte, fsync bool) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return 0, f.err
+	}
+	if int64(len(p)) > f.maxSize {
+		return 0, fmt.Errorf("writer: write length %d exceeds maximum file size %d", len(p), f.maxSize)
+	}
+
+	written := 0
+	for {
+		n, err := f.writeLocked(p[written:])
+		written += n
+		if err == nil {
+			f.recovered()
+			return len(p), f.afterWrite(fsync)
+		}
+		if !isDiskFull(err) {
+			return written, err
+		}
+		retry, err := f.handleDiskFull(p[written:], err)
+		if !retry {
+			if err != nil {
+				return written, err
+			}
+			return len(p), nil
+		}
+	}
+}
+
+// writeLocked 打开或轮转文件，写回暂存数据后写入 p
+func (f *FileWriter) writeLocked(p []byte) (n int, err error) {
+	if f.dateDir != "" {
+		if path := f.datedPath(f.now()); path != f.path {
+			f.switchPath(path)
+		}
+	}
+	if f.file == nil {
+		if err := f.openExistingOrNew(); err != nil {
+			return 0, err
+		}
+	}
+	if f.size+int64(len(p)) > f.maxSize || f.due() {
+		if err := f.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(f.pending) > 0 {
+		if err := f.flushPending(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err = f.write(f.file, p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close 实现 io.Closer，关闭当前文件并等待后台清理完成。
 func (f *FileWriter) Close() error {
-	return f.lj.Close()
+	f.closing.Store(true)
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+	f.mu.Lock()
+	f.closing.Store(false)
+	// 没有等待中的写入时清除唤醒信号，避免影响之后的 DiskFullBlock
+	select {
+	case <-f.wake:
+	default:
+	}
+	if len(f.pending) > 0 && f.file != nil {
+		_ = f.flushPending()
+	}
+	f.dropPending()
+	if f.fsyncTimer != nil {
+		f.fsyncTimer.Stop()
+	}
+	err := f.closeFile()
+	if f.millCh != nil {
+		close(f.millCh)
+		f.millCh = nil
+		// 允许关闭后再次写入时重新启动清理协程
+		f.millOnce = sync.Once{}
+	}
+	f.mu.Unlock()
+
+	f.millWG.Wait()
+	return err
 }
 
-// Sync 实现 Writer.Sync。
+// Sync 实现 Writer.Sync，尝试写回磁盘空间不足时暂存的数据；配置了 fsync 时同时刷到磁盘。
 func (f *FileWriter) Sync() error {
-	// lumberjack 没有显式的 sync 方法，每次写入都会 flush
+	// 每次写入直接调用 write 系统调用，没有用户态缓冲
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	if len(f.pending) > 0 {
+		if err := f.flushPending(); err != nil {
+			return err
+		}
+		f.recovered()
+	}
+	if f.fsyncEnabled() {
+		return f.syncFile()
+	}
 	return nil
 }
 
+// Err 返回配置错误（如无效的文件名模式），配置有效时返回 nil。
+//
+// 配置错误也会在每次写入时返回，Err 便于在启动阶段提前发现。
+func (f *FileWriter) Err() error {
+	return f.err
+}
+
 // Rotate 手动触发日志轮转。
 func (f *FileWriter) Rotate() error {
-	return f.lj.Rotate()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return f.err
+	}
+	return f.rotateLocked()
+}
+
+// closeFile 关闭当前文件
+func (f *FileWriter) closeFile() error {
+	if f.file == nil {
+		return nil
+	}
+	var syncErr error
+	if f.dirty {
+		syncErr = f.syncFile()
+	}
+	err := f.file.Close()
+	f.file = nil
+	return errors.Join(syncErr, err)
+}
+
+// datedPath 返回 t 所在日期目录中的日志路径
+func (f *FileWriter) datedPath(t time.Time) string {
+	dir, name := filepath.Split(f.base)
+	return filepath.Join(dir, f.inZone(t).Format(f.dateDir), name)
+}
+
+// switchPath 关闭当前文件并切换到新的日期目录，新文件在随后的写入中打开
+func (f *FileWriter) switchPath(path string) {
+	if f.file != nil {
+		if err := f.closeFile(); err != nil {
+			selflog.Printf("file.close", "close log file %s: %v", f.path, err)
+		}
+		if f.onRotate != nil {
+			f.rotated = append(f.rotated, f.path)
+		}
+	}
+	f.path = path
+	f.openedAt = time.Time{}
+}
+
+// updateLink 将符号链接指向当前文件，已指向时不做修改
+func (f *FileWriter) updateLink() {
+	if f.link == "" {
+		return
+	}
+	target := f.path
+	if rel, err := filepath.Rel(filepath.Dir(f.link), f.path); err == nil {
+		target = rel
+	}
+
+	info, err := os.Lstat(f.link)
+	switch {
+	case err == nil && info.Mode()&os.ModeSymlink == 0:
+		selflog.Printf("file.symlink", "symlink %s: refusing to replace non-symlink file", f.link)
+		return
+	case err == nil:
+		if cur, err := os.Readlink(f.link); err == nil && cur == target {
+			return
+		}
+	}
+
+	// 先创建临时链接再重命名，读取方不会看到链接缺失的中间状态
+	tmp := f.link + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		selflog.Printf("file.symlink", "symlink %s: %v", f.link, err)
+		return
+	}
+	if err := os.Rename(tmp, f.link); err != nil {
+		_ = os.Remove(tmp)
+		selflog.Printf("file.symlink", "symlink %s: %v", f.link, err)
+	}
+}
+
+// due 判断是否到达按时间轮转的时刻
+func (f *FileWriter) due() bool {
+	return f.interval > 0 && !f.now().Before(f.deadline)
+}
+
+// openExistingOrNew 打开已有文件追加写入，不存在时创建
+func (f *FileWriter) openExistingOrNew() error {
+	// 启动时清理上次运行遗留的过期备份
+	f.mill()
+
+	info, err := os.Stat(f.path)
+	if os.IsNotExist(err) {
+		return f.openNew()
+	}
+	if err != nil {
+		return fmt.Errorf("writer: stat log file: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY, 0o600) //nolint:gosec // G304: 路径由调用方指定
+	if err != nil {
+		// 无法追加时忽略旧文件，轮转为备份后重新创建
+		return f.openNew()
+	}
+	if err := f.applyPerm(f.path, f.mode); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("writer: set log file permissions: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = info.ModTime()
+	f.deadline = f.nextBoundary(info.ModTime())
+	f.updateLink()
+	return nil
+}
+
+// openNew 将已有文件移为备份，在原路径创建新文件。
+//
+// 未设置 WithFileMode 时新文件沿用旧文件的权限。
+func (f *FileWriter) openNew() error {
+	if err := f.mkdirAll(filepath.Dir(f.path)); err != nil {
+		return fmt.Errorf("writer: create log directory: %w", err)
+	}
+
+	now := f.now()
+	var mode os.FileMode
+	if info, err := os.Stat(f.path); err == nil {
+		mode = info.Mode().Perm()
+		backup, err := f.backupName(f.openedAtOr(info.ModTime()))
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(f.path, backup); err != nil {
+			return fmt.Errorf("writer: rename log file: %w", err)
+		}
+		if f.onRotate != nil {
+			f.rotated = append(f.rotated, backup)
+		}
+	}
+	if f.mode != 0 {
+		mode = f.mode
+	}
+	if mode == 0 {
+		mode = 0o600
+	}
+
+	// 使用 O_TRUNC：文件刚被移走，期间被其他进程创建的内容直接丢弃
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode) //nolint:gosec // G304: 路径由调用方指定
+	if err != nil {
+		return fmt.Errorf("writer: open new log file: %w", err)
+	}
+	if err := f.applyPerm(f.path, mode); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("writer: set log file permissions: %w", err)
+	}
+
+	f.file = file
+	f.size = 0
+	f.openedAt = now
+	f.deadline = f.nextBoundary(now)
+	f.updateLink()
+	return nil
+}
+
+// openedAtOr 返回当前文件的开始时间，未记录时返回 fallback
+func (f *FileWriter) openedAtOr(fallback time.Time) time.Time {
+	if f.openedAt.IsZero() {
+		return fallback
+	}
+	return f.openedAt
+}
+
+// rotateLocked 关闭当前文件、移为备份并创建新文件，随后触发清理
+func (f *FileWriter) rotateLocked() error {
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+	if err := f.openNew(); err != nil {
+		return err
+	}
+	f.mill()
+	return nil
+}
+
+// nextBoundary 返回 t 之后的下一个轮转时刻
+func (f *FileWriter) nextBoundary(t time.Time) time.Time {
+	if f.interval <= 0 {
+		return time.Time{}
+	}
+	t = f.inZone(t)
+	const day = 24 * time.Hour
+	if f.interval%day == 0 {
+		// 按天对齐到所在时区的零点
+		y, m, d := t.Date()
+		midnight := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		return midnight.AddDate(0, 0, int(f.interval/day))
+	}
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(f.interval).Add(f.interval - shift)
+}
+
+// inZone 按配置转换时区
+func (f *FileWriter) inZone(t time.Time) time.Time {
+	if f.localTime {
+		return t.Local()
+	}
+	return t.UTC()
+}
+
+// mkdirAll 逐级创建目录，新建的目录应用 dirMode 和属主
+func (f *FileWriter) mkdirAll(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if parent := filepath.Dir(dir); parent != dir {
+		if err := f.mkdirAll(parent); err != nil {
+			return err
+		}
+	}
+
+	mode := f.dirMode
+	if mode == 0 {
+		mode = 0o755
+	}
+	if err := os.Mkdir(dir, mode); err != nil {
+		if os.IsExist(err) {
+			return nil
+		}
+		return err
+	}
+	return f.applyPerm(dir, f.dirMode)
+}
+
+// applyPerm 设置权限（mode 非 0 时）和属主
+func (f *FileWriter) applyPerm(name string, mode os.FileMode) error {
+	if mode != 0 {
+		if err := os.Chmod(name, mode); err != nil {
+			return err
+		}
+	}
+	if f.chown {
+		return os.Chown(name, f.uid, f.gid)
+	}
+	return nil
 }
@@ -0,0 +1,159 @@
+package writer
+
+import (
+	"errors"
+	"syscall"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// DiskFullPolicy 磁盘空间不足（ENOSPC）时的处理策略
+type DiskFullPolicy int
+
+const (
+	// DiskFullError 返回写入错误（默认），由 Handler 的 OnWriteError 处理
+	DiskFullError DiskFullPolicy = iota
+	// DiskFullDrop 丢弃日志并计入 Dropped，写入返回成功
+	DiskFullDrop
+	// DiskFullBlock 阻塞写入并定期重试，直到空间可用或 Writer 被关闭。
+	//
+	// 等待期间持有 Writer 的锁，经 Handler 写入时同一 Handler 的其他日志调用也会被阻塞，
+	// 应放在 Async 之后使用，由后台协程承担等待。Close 会立即结束等待并丢弃该条日志。
+	DiskFullBlock
+	// DiskFullBuffer 暂存到内存缓冲区，空间恢复后按顺序写回；缓冲区满时丢弃最旧的日志
+	DiskFullBuffer
+)
+
+// 磁盘空间不足处理的默认值
+const (
+	defaultDiskFullBuffer = 4 * megabyte
+	diskFullRetry         = time.Second
+)
+
+// WithDiskFullPolicy 设置磁盘空间不足时的处理策略。
+//
+//	writer.File("/var/log/app.log",
+//	    writer.WithDiskFullPolicy(writer.DiskFullBuffer),
+//	    writer.WithOnDiskFull(func(err error) { alert("log disk full: " + err.Error()) }),
+//	)
+//
+// 被丢弃的日志通过 Dropped 统计，Handler.Stats 会汇总该计数。
+func WithDiskFullPolicy(policy DiskFullPolicy) FileOption {
+	return func(f *FileWriter) {
+		f.diskFull = policy
+	}
+}
+
+// WithDiskFullBuffer 设置 DiskFullBuffer 策略的内存缓冲区大小（字节），默认 4MB。
+func WithDiskFullBuffer(bytes int) FileOption {
+	return func(f *FileWriter) {
+		if bytes > 0 {
+			f.pendingMax = bytes
+		}
+	}
+}
+
+// WithOnDiskFull 设置磁盘空间不足时的回调。
+//
+// 每次从正常进入空间不足状态时调用一次，空间恢复后再次不足时会再次调用。
+// 回调在独立协程中执行，可以记录日志或发送告警。
+func WithOnDiskFull(fn func(err error)) FileOption {
+	return func(f *FileWriter) {
+		f.onDiskFull = fn
+	}
+}
+
+// isDiskFull 判断是否为磁盘空间不足错误
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
+
+// Dropped 返回因磁盘空间不足而丢弃的日志条数。
+func (f *FileWriter) Dropped() uint64 {
+	return f.dropped.Load()
+}
+
+// handleDiskFull 按策略处理空间不足，rest 为未写入的数据。
+//
+// 返回 retry 为 true 时调用方应重试写入 rest。
+func (f *FileWriter) handleDiskFull(rest []byte, err error) (retry bool, _ error) {
+	if !f.full {
+		f.full = true
+		selflog.Printf("file.diskfull", "log file %s: disk full: %v", f.path, err)
+		if f.onDiskFull != nil {
+			go f.onDiskFull(err)
+		}
+	}
+
+	switch f.diskFull {
+	case DiskFullDrop:
+		f.dropped.Add(1)
+		return false, nil
+	case DiskFullBuffer:
+		f.pushPending(rest)
+		return false, nil
+	case DiskFullBlock:
+		if f.closing.Load() {
+			f.dropped.Add(1)
+			return false, err
+		}
+		timer := time.NewTimer(f.retry)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-f.wake:
+		}
+		return true, nil
+	default:
+		return false, err
+	}
+}
+
+// recovered 写入成功后清除空间不足状态
+func (f *FileWriter) recovered() {
+	if f.full {
+		f.full = false
+		selflog.Printf("file.diskfull", "log file %s: disk space available again", f.path)
+	}
+}
+
+// pushPending 暂存数据，超出缓冲区大小时丢弃最旧的日志
+func (f *FileWriter) pushPending(p []byte) {
+	data := make([]byte, len(p))
+	copy(data, p)
+	f.pending = append(f.pending, data)
+	f.pendingSize += len(data)
+	for f.pendingSize > f.pendingMax && len(f.pending) > 0 {
+		f.pendingSize -= len(f.pending[0])
+		f.pending[0] = nil
+		f.pending = f.pending[1:]
+		f.dropped.Add(1)
+	}
+}
+
+// flushPending 按顺序写回暂存的数据，失败时保留未写入的部分
+func (f *FileWriter) flushPending() error {
+	for len(f.pending) > 0 {
+		data := f.pending[0]
+		n, err := f.write(f.file, data)
+		f.size += int64(n)
+		if err != nil {
+			f.pending[0] = data[n:]
+			f.pendingSize -= n
+			return err
+		}
+		f.pendingSize -= len(data)
+		f.pending[0] = nil
+		f.pending = f.pending[1:]
+	}
+	f.pending = nil
+	return nil
+}
+
+// dropPending 丢弃所有暂存数据并计数
+func (f *FileWriter) dropPending() {
+	f.dropped.Add(uint64(len(f.pending)))
+	f.pending = nil
+	f.pendingSize = 0
+}
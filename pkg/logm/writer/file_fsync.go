@@ -0,0 +1,88 @@
+package writer
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// WithFsyncInterval 设置 fsync 周期，写入后最迟 d 时间内将数据刷到磁盘。
+//
+// 默认不调用 fsync，数据由操作系统择机落盘，断电时可能丢失最后几秒的日志。
+// 周期内有写入时才会调用 fsync，空闲时没有开销。轮转和 Close 时也会刷新未落盘的数据。
+func WithFsyncInterval(d time.Duration) FileOption {
+	return func(f *FileWriter) {
+		f.fsyncInterval = max(d, 0)
+	}
+}
+
+// WithFsyncLevel 设置写入不低于 level 的日志后立即 fsync，如 slog.LevelError。
+//
+// 需要经由 Handler 写入（或直接调用 WriteLevel）才能获知级别，
+// 经过 Async、Multi、PerLevel 包装时级别会继续传递。
+// 其余日志按 WithFsyncInterval 的周期刷新。
+func WithFsyncLevel(level slog.Level) FileOption {
+	return func(f *FileWriter) {
+		f.fsyncLevel = level
+		f.fsyncOnLevel = true
+	}
+}
+
+// WriteLevel 实现 LevelWriter，级别达到 WithFsyncLevel 时写入后立即 fsync。
+func (f *FileWriter) WriteLevel(level slog.Level, p []byte) (n int, err error) {
+	return f.writeRecord(p, f.fsyncOnLevel && level >= f.fsyncLevel)
+}
+
+// afterWrite 写入成功后立即 fsync 或安排周期 fsync
+func (f *FileWriter) afterWrite(fsync bool) error {
+	if fsync {
+		if err := f.syncFile(); err != nil {
+			return fmt.Errorf("writer: fsync: %w", err)
+		}
+		return nil
+	}
+	if f.fsyncInterval <= 0 || f.dirty {
+		return nil
+	}
+	f.dirty = true
+	if f.fsyncTimer == nil {
+		f.fsyncTimer = time.AfterFunc(f.fsyncInterval, f.fsyncTick)
+	} else {
+		f.fsyncTimer.Reset(f.fsyncInterval)
+	}
+	return nil
+}
+
+// fsyncTick 周期 fsync 的定时器回调
+func (f *FileWriter) fsyncTick() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.dirty {
+		return
+	}
+	if err := f.syncFile(); err != nil {
+		selflog.Printf("file.fsync", "fsync log file %s: %v", f.path, err)
+	}
+}
+
+// syncFile 将当前文件刷到磁盘
+func (f *FileWriter) syncFile() error {
+	f.dirty = false
+	if f.file == nil {
+		return nil
+	}
+	return f.fsync(f.file)
+}
+
+// fsyncEnabled 是否配置了 fsync
+func (f *FileWriter) fsyncEnabled() bool {
+	return f.fsyncInterval > 0 || f.fsyncOnLevel
+}
+
+// defaultFsync 调用 fsync
+func defaultFsync(file *os.File) error {
+	return file.Sync()
+}
@@ -0,0 +1,387 @@
+package writer
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// gzipExt 压缩备份的扩展名
+const gzipExt = ".gz"
+
+// patternTokens 文件名模式占位符对应的时间格式和匹配正则
+var patternTokens = map[byte]struct {
+	layout string
+	re     string
+}{
+	'Y': {"2006", `\d{4}`},
+	'y': {"06", `\d{2}`},
+	'm': {"01", `\d{2}`},
+	'd': {"02", `\d{2}`},
+	'H': {"15", `\d{2}`},
+	'M': {"04", `\d{2}`},
+	'S': {"05", `\d{2}`},
+	'L': {"000", `\d{3}`},
+}
+
+// backupPattern 编译后的备份文件名模式
+type backupPattern struct {
+	stem string         // 扩展名之前的部分，序号插入在其后
+	ext  string         // 扩展名（不含 .gz），不含占位符
+	re   *regexp.Regexp // 匹配该模式生成的所有备份名
+}
+
+// compilePattern 校验并编译备份文件名模式
+func compilePattern(pattern string) (*backupPattern, error) {
+	if strings.ContainsAny(pattern, `/\`) {
+		return nil, fmt.Errorf("writer: filename pattern %q must not contain path separators", pattern)
+	}
+	pattern = strings.TrimSuffix(pattern, gzipExt)
+	if pattern == "" {
+		return nil, errors.New("writer: empty filename pattern")
+	}
+
+	p := &backupPattern{stem: pattern}
+	if ext := filepath.Ext(pattern); !strings.Contains(ext, "%") {
+		p.stem, p.ext = pattern[:len(pattern)-len(ext)], ext
+	}
+
+	var re strings.Builder
+	re.WriteByte('^')
+	for i := 0; i < len(p.stem); i++ {
+		c := p.stem[i]
+		if c != '%' {
+			re.WriteString(regexp.QuoteMeta(string(c)))
+			continue
+		}
+		if i+1 >= len(p.stem) {
+			return nil, fmt.Errorf("writer: filename pattern %q ends with %%", pattern)
+		}
+		i++
+		if p.stem[i] == '%' {
+			re.WriteByte('%')
+			continue
+		}
+		tok, ok := patternTokens[p.stem[i]]
+		if !ok {
+			return nil, fmt.Errorf("writer: filename pattern %q: unknown verb %%%c", pattern, p.stem[i])
+		}
+		re.WriteString(tok.re)
+	}
+	re.WriteString(`(?:-\d+)?`)
+	re.WriteString(regexp.QuoteMeta(p.ext))
+	re.WriteString(`(?:` + regexp.QuoteMeta(gzipExt) + `)?$`)
+
+	var err error
+	if p.re, err = regexp.Compile(re.String()); err != nil {
+		return nil, fmt.Errorf("writer: filename pattern %q: %w", pattern, err)
+	}
+	return p, nil
+}
+
+// format 按时间生成备份名（不含 .gz），n > 0 时追加序号
+func (p *backupPattern) format(t time.Time, n int) string {
+	var b strings.Builder
+	for i := 0; i < len(p.stem); i++ {
+		c := p.stem[i]
+		if c != '%' || i+1 >= len(p.stem) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if tok, ok := patternTokens[p.stem[i]]; ok {
+			b.WriteString(t.Format(tok.layout))
+		} else {
+			b.WriteByte(p.stem[i])
+		}
+	}
+	if n > 0 {
+		b.WriteString("-" + strconv.Itoa(n))
+	}
+	b.WriteString(p.ext)
+	return b.String()
+}
+
+// defaultPattern 与 lumberjack 兼容的默认备份名模式
+func defaultPattern(path string) string {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	escape := func(s string) string { return strings.ReplaceAll(s, "%", "%%") }
+	return escape(base[:len(base)-len(ext)]) + "-%Y-%m-%dT%H-%M-%S.%L" + escape(ext)
+}
+
+// backupName 返回未被占用的备份路径
+func (f *FileWriter) backupName(t time.Time) (string, error) {
+	p := f.backup
+	dir := filepath.Dir(f.path)
+	t = f.inZone(t)
+	for n := 0; n < 10000; n++ {
+		name := filepath.Join(dir, p.format(t, n))
+		if name == f.path {
+			continue
+		}
+		if !exists(name) && !exists(name+gzipExt) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("writer: no free backup name for %s", f.path)
+}
+
+// exists 判断文件是否存在
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// startMill 启动后台清理协程
+func (f *FileWriter) startMill() {
+	f.millOnce.Do(func() {
+		f.millCh = make(chan struct{}, 1)
+		f.millWG.Add(1)
+		go f.runMill(f.millCh)
+	})
+}
+
+// mill 通知后台协程清理备份，不阻塞
+func (f *FileWriter) mill() {
+	f.startMill()
+	select {
+	case f.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// runMill 后台清理协程
+func (f *FileWriter) runMill(ch <-chan struct{}) {
+	defer f.millWG.Done()
+	for range ch {
+		if err := f.millRunOnce(); err != nil {
+			selflog.Printf("file.mill", "log file cleanup failed: %v", err)
+		}
+		f.notifyRotated()
+	}
+}
+
+// notifyRotated 为已完成处理的备份调用 onRotate
+func (f *FileWriter) notifyRotated() {
+	f.mu.Lock()
+	rotated := f.rotated
+	f.rotated = nil
+	f.mu.Unlock()
+
+	for _, path := range rotated {
+		switch {
+		case exists(path):
+		case exists(path + gzipExt):
+			path += gzipExt
+		default:
+			continue
+		}
+		f.onRotate(path)
+	}
+}
+
+// backupFile 备份文件信息
+type backupFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+	gzipped bool
+}
+
+// backups 返回 path 所在目录中属于该 Writer 的备份，从新到旧排序
+func (f *FileWriter) backups(current string) ([]backupFile, error) {
+	dir := filepath.Dir(current)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	p := f.backup
+	var files []backupFile
+	for _, e := range entries {
+		if e.IsDir() || !p.re.MatchString(e.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if path == current {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, backupFile{
+			path:    path,
+			size:    info.Size(),
+			modTime: info.ModTime(),
+			gzipped: strings.HasSuffix(e.Name(), gzipExt),
+		})
+	}
+	slices.SortFunc(files, func(a, b backupFile) int { return b.modTime.Compare(a.modTime) })
+	return files, nil
+}
+
+// millRunOnce 按数量和时间清理备份，并压缩未压缩的备份
+func (f *FileWriter) millRunOnce() error {
+	if f.maxBackups == 0 && f.maxAge == 0 && f.maxTotal == 0 && !f.compress {
+		return nil
+	}
+	f.mu.Lock()
+	current := f.path
+	f.mu.Unlock()
+
+	files, err := f.backups(current)
+	if err != nil {
+		return err
+	}
+
+	// 同一备份的压缩中间状态（x 与 x.gz 并存）按一个计数，保留未压缩的原件
+	plain := make(map[string]bool)
+	for _, b := range files {
+		if !b.gzipped {
+			plain[b.path] = true
+		}
+	}
+
+	var errs []error
+	var keep []backupFile
+	cutoff := f.now().Add(-f.maxAge)
+	count := 0
+	for _, b := range files {
+		if b.gzipped && plain[strings.TrimSuffix(b.path, gzipExt)] {
+			// 上次压缩未完成，删除不完整的 .gz，稍后重新压缩
+			errs = append(errs, removeFile(b.path))
+			continue
+		}
+		count++
+		if (f.maxBackups > 0 && count > f.maxBackups) || (f.maxAge > 0 && b.modTime.Before(cutoff)) {
+			errs = append(errs, removeFile(b.path))
+			continue
+		}
+		keep = append(keep, b)
+	}
+
+	if f.compress {
+		for _, b := range keep {
+			if !b.gzipped {
+				errs = append(errs, f.compressFile(b.path, b.modTime))
+			}
+		}
+	}
+	if f.maxTotal > 0 {
+		errs = append(errs, f.enforceTotal(current))
+	}
+	if f.dateDir != "" && f.maxAge > 0 {
+		errs = append(errs, f.removeDateDirs(current, cutoff))
+	}
+	return errors.Join(errs...)
+}
+
+// enforceTotal 从最旧的备份开始删除，直到当前文件与备份的总大小不超过 maxTotal
+func (f *FileWriter) enforceTotal(current string) error {
+	// 压缩后大小发生变化，重新统计
+	files, err := f.backups(current)
+	if err != nil {
+		return err
+	}
+	var total int64
+	if info, err := os.Stat(current); err == nil {
+		total = info.Size()
+	}
+
+	var errs []error
+	for _, b := range files {
+		total += b.size
+		if total > f.maxTotal {
+			errs = append(errs, removeFile(b.path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeDateDirs 删除日期早于 cutoff 的日期目录，当前目录和名称不符合格式的目录不受影响
+func (f *FileWriter) removeDateDirs(current string, cutoff time.Time) error {
+	root := filepath.Dir(f.base)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	currentDir := filepath.Base(filepath.Dir(current))
+	loc := f.inZone(cutoff).Location()
+
+	var errs []error
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || name == currentDir {
+			continue
+		}
+		t, err := time.ParseInLocation(f.dateDir, name, loc)
+		if err != nil || t.Format(f.dateDir) != name || !t.Before(cutoff) {
+			continue
+		}
+		errs = append(errs, os.RemoveAll(filepath.Join(root, name)))
+	}
+	return errors.Join(errs...)
+}
+
+// removeFile 删除文件，忽略不存在的错误
+func removeFile(name string) error {
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// compressFile 将备份压缩为 .gz 并删除原文件，保留权限、属主和修改时间
+func (f *FileWriter) compressFile(src string, modTime time.Time) (err error) {
+	in, err := os.Open(src) //nolint:gosec // G304: 备份路径由 Writer 生成
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	dst := src + gzipExt
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm()) //nolint:gosec // G304: 备份路径由 Writer 生成
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(dst)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = f.applyPerm(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err = os.Chtimes(dst, modTime, modTime); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
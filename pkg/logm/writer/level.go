@@ -0,0 +1,120 @@
+package writer
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+)
+
+// LevelWriter 按日志级别写入的 Writer。
+//
+// Handler 写入实现该接口的 Writer 时调用 WriteLevel，传入记录的级别；
+// Multi、Async 会将级别继续传递给子 Writer。
+type LevelWriter interface {
+	Writer
+	WriteLevel(level slog.Level, p []byte) (n int, err error)
+}
+
+// WriteLevel 按级别写入 w，w 未实现 LevelWriter 时调用 Write。
+func WriteLevel(w Writer, level slog.Level, p []byte) (n int, err error) {
+	if lw, ok := w.(LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return w.Write(p)
+}
+
+// levelRoute 级别路由
+type levelRoute struct {
+	min slog.Level
+	w   Writer
+}
+
+// PerLevelWriter 按级别分发日志的 Writer。
+//
+// 每个目标接收不低于其最低级别的日志，常见布局为全部日志写入 app.log，
+// ERROR 及以上再额外写入 error.log：
+//
+//	writer.PerLevel(map[slog.Level]writer.Writer{
+//	    slog.LevelDebug: writer.File("/var/log/app.log"),
+//	    slog.LevelError: writer.File("/var/log/error.log"),
+//	})
+type PerLevelWriter struct {
+	routes []levelRoute
+}
+
+// PerLevel 创建按级别分发的 Writer，key 为目标接收的最低级别。
+func PerLevel(routes map[slog.Level]Writer) *PerLevelWriter {
+	p := &PerLevelWriter{routes: make([]levelRoute, 0, len(routes))}
+	for lvl, w := range routes {
+		p.routes = append(p.routes, levelRoute{min: lvl, w: w})
+	}
+	slices.SortFunc(p.routes, func(a, b levelRoute) int { return int(a.min - b.min) })
+	return p
+}
+
+// Write 实现 io.Writer，无法获知级别，按 INFO 处理。
+func (p *PerLevelWriter) Write(b []byte) (n int, err error) {
+	return p.WriteLevel(slog.LevelInfo, b)
+}
+
+// WriteLevel 实现 LevelWriter，写入所有最低级别不高于 level 的目标。
+//
+// 单个目标失败时继续写入其他目标，返回合并后的错误。
+func (p *PerLevelWriter) WriteLevel(level slog.Level, b []byte) (n int, err error) {
+	var errs []error
+	for _, r := range p.routes {
+		if level < r.min {
+			break
+		}
+		if _, err := WriteLevel(r.w, level, b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(b), errors.Join(errs...)
+}
+
+// Close 实现 io.Closer，关闭所有目标。
+func (p *PerLevelWriter) Close() error {
+	return p.each(Writer.Close)
+}
+
+// Sync 实现 Writer.Sync，刷新所有目标。
+func (p *PerLevelWriter) Sync() error {
+	return p.each(Writer.Sync)
+}
+
+// Rotate 实现 Rotator，轮转所有支持轮转的目标。
+func (p *PerLevelWriter) Rotate() error {
+	return p.each(Rotate)
+}
+
+// Unwrap 实现 Wrapper，按最低级别从低到高返回所有目标。
+func (p *PerLevelWriter) Unwrap() []Writer {
+	out := make([]Writer, len(p.routes))
+	for i, r := range p.routes {
+		out[i] = r.w
+	}
+	return out
+}
+
+// Dropped 返回所有目标丢弃的日志条数之和。
+//
+// 仅统计实现了 Dropped() uint64 的目标（如 AsyncWriter）。
+func (p *PerLevelWriter) Dropped() uint64 {
+	var total uint64
+	for _, r := range p.routes {
+		if d, ok := r.w.(interface{ Dropped() uint64 }); ok {
+			total += d.Dropped()
+		}
+	}
+	return total
+}
+
+// each 对每个目标执行一次 fn
+func (p *PerLevelWriter) each(fn func(Writer) error) error {
+	var errs []error
+	for _, r := range p.routes {
+		errs = append(errs, fn(r.w))
+	}
+	return errors.Join(errs...)
+}
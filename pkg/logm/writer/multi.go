@@ -1,5 +1,13 @@
 package writer
 
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
 // MultiWriter 多目标 Writer。
 //
 // 将日志同时写入多个目标。
@@ -22,6 +30,14 @@ func (m *MultiWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
+// WriteLevel 实现 LevelWriter，将级别传递给子 Writer。
+func (m *MultiWriter) WriteLevel(level slog.Level, p []byte) (n int, err error) {
+	for _, w := range m.writers {
+		_, _ = WriteLevel(w, level, p)
+	}
+	return len(p), nil
+}
+
 // Close 实现 io.Closer。
 //
 // 关闭所有目标。
@@ -48,6 +64,65 @@ func (m *MultiWriter) Sync() error {
 	return firstErr
 }
 
+// CloseContext 在 ctx 结束前关闭所有目标。
+//
+// 各目标并发关闭，返回所有错误的合并结果。
+func (m *MultiWriter) CloseContext(ctx context.Context) error {
+	return m.each(func(w Writer) error { return CloseContext(ctx, w) })
+}
+
+// SyncContext 在 ctx 结束前刷新所有目标。
+func (m *MultiWriter) SyncContext(ctx context.Context) error {
+	return m.each(func(w Writer) error { return SyncContext(ctx, w) })
+}
+
+// Rotate 实现 Rotator，轮转所有支持轮转的目标。
+func (m *MultiWriter) Rotate() error {
+	return m.each(Rotate)
+}
+
+// Unwrap 实现 Wrapper，返回所有目标。
+func (m *MultiWriter) Unwrap() []Writer {
+	return slices.Clone(m.writers)
+}
+
+// each 并发对所有目标执行 fn
+func (m *MultiWriter) each(fn func(w Writer) error) error {
+	errs := make([]error, len(m.writers))
+	var wg sync.WaitGroup
+	for i, w := range m.writers {
+		wg.Go(func() { errs[i] = fn(w) })
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Dropped 返回所有子 Writer 丢弃的日志条数之和。
+//
+// 仅统计实现了 Dropped() uint64 的子 Writer（如 AsyncWriter）。
+func (m *MultiWriter) Dropped() uint64 {
+	var total uint64
+	for _, w := range m.writers {
+		if d, ok := w.(interface{ Dropped() uint64 }); ok {
+			total += d.Dropped()
+		}
+	}
+	return total
+}
+
+// Len 返回所有子 Writer 缓冲区中等待写入的日志条数之和。
+//
+// 仅统计实现了 Len() int 的子 Writer（如 AsyncWriter）。
+func (m *MultiWriter) Len() int {
+	total := 0
+	for _, w := range m.writers {
+		if l, ok := w.(interface{ Len() int }); ok {
+			total += l.Len()
+		}
+	}
+	return total
+}
+
 // Add 添加 Writer。
 func (m *MultiWriter) Add(w Writer) {
 	m.writers = append(m.writers, w)
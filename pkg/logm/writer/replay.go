@@ -0,0 +1,164 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
+)
+
+// ReplayOption ReplayFiles 配置选项
+type ReplayOption func(*replayConfig)
+
+// replayConfig 重放配置
+type replayConfig struct {
+	since, until time.Time
+	filter       func(e *formatter.Entry) bool
+}
+
+// WithReplayRange 只重放时间在 [since, until) 内的日志，零值表示不限制该端。
+//
+// 设置后无法解析时间的行被跳过。
+func WithReplayRange(since, until time.Time) ReplayOption {
+	return func(c *replayConfig) {
+		c.since, c.until = since, until
+	}
+}
+
+// WithReplayFilter 只重放 fn 返回 true 的日志，设置后无法解析的行被跳过。
+func WithReplayFilter(fn func(e *formatter.Entry) bool) ReplayOption {
+	return func(c *replayConfig) {
+		c.filter = fn
+	}
+}
+
+// Archives 返回该 Writer 轮转生成的备份文件，从旧到新排序，不含当前文件。
+//
+// 配合 ReplayFiles 重新投递归档日志；压缩未完成时同一备份的 .gz 不重复返回。
+func (f *FileWriter) Archives() ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.mu.Lock()
+	current := f.path
+	f.mu.Unlock()
+
+	files, err := f.backups(current)
+	if err != nil {
+		return nil, err
+	}
+	plain := make(map[string]bool, len(files))
+	for _, b := range files {
+		if !b.gzipped {
+			plain[b.path] = true
+		}
+	}
+	paths := make([]string, 0, len(files))
+	for _, b := range slices.Backward(files) {
+		if b.gzipped && plain[strings.TrimSuffix(b.path, gzipExt)] {
+			continue
+		}
+		paths = append(paths, b.path)
+	}
+	return paths, nil
+}
+
+// ReplayFiles 依次读取 files 中的日志并逐行写入 target，返回写入的行数。
+//
+// 用于采集端故障后回填归档日志等场景，.gz 文件自动解压：
+//
+//	fw := writer.File("/var/log/app.log")
+//	files, _ := fw.Archives()
+//	n, err := writer.ReplayFiles(ctx, append(files, "/var/log/app.log"), loki,
+//	    writer.WithReplayRange(outageStart, outageEnd))
+//
+// 每行通过 formatter.ParseLine 识别级别，以 WriteLevel 原样写入；无法解析的行按 INFO 写入。
+// ctx 结束或 target 写入失败时停止，错误中包含文件名和行号。
+func ReplayFiles(ctx context.Context, files []string, target Writer, opts ...ReplayOption) (n int, err error) {
+	var cfg replayConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	for _, path := range files {
+		written, err := replayFile(ctx, path, target, &cfg)
+		n += written
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// replayFile 重放单个文件
+func replayFile(ctx context.Context, path string, target Writer, cfg *replayConfig) (n int, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("writer: replay: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, gzipExt) {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return 0, fmt.Errorf("writer: replay %s: %w", path, err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if err := ctx.Err(); err != nil {
+				return n, err
+			}
+			if level, ok := cfg.match(line); ok {
+				if line[len(line)-1] != '\n' {
+					line = append(line, '\n')
+				}
+				if _, err := WriteLevel(target, level, line); err != nil {
+					return n, fmt.Errorf("writer: replay %s:%d: %w", path, lineNo, err)
+				}
+				n++
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			return n, nil
+		}
+		if readErr != nil {
+			return n, fmt.Errorf("writer: replay %s:%d: %w", path, lineNo, readErr)
+		}
+	}
+}
+
+// match 解析一行日志，返回级别和是否需要重放
+func (c *replayConfig) match(line []byte) (slog.Level, bool) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return 0, false
+	}
+	e, err := formatter.ParseLine(line)
+	if err != nil {
+		return slog.LevelInfo, c.since.IsZero() && c.until.IsZero() && c.filter == nil
+	}
+	if !c.since.IsZero() || !c.until.IsZero() {
+		if e.Time.IsZero() || e.Time.Before(c.since) || (!c.until.IsZero() && !e.Time.Before(c.until)) {
+			return 0, false
+		}
+	}
+	if c.filter != nil && !c.filter(e) {
+		return 0, false
+	}
+	return e.Level, true
+}
@@ -0,0 +1,97 @@
+package writer
+
+import (
+	"io"
+	"sync"
+)
+
+// RingWriter 内存环形缓冲 Writer。
+//
+// 保留最近写入的 N 条日志，超出后覆盖最旧的记录。
+// 适合与其他 Writer 并用，在崩溃或排障时取回最近的日志。
+type RingWriter struct {
+	mu    sync.Mutex
+	buf   [][]byte
+	next  int
+	count int
+}
+
+// Ring 创建环形缓冲 Writer。
+//
+// size 指定保留的日志条数，<= 0 时默认 1000。
+func Ring(size int) *RingWriter {
+	if size <= 0 {
+		size = 1000
+	}
+	return &RingWriter{buf: make([][]byte, size)}
+}
+
+// Write 实现 io.Writer。
+//
+// 每次调用视为一条日志，数据会被复制保存。
+func (r *RingWriter) Write(p []byte) (n int, err error) {
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	r.mu.Lock()
+	r.buf[r.next] = data
+	r.next = (r.next + 1) % len(r.buf)
+	if r.count < len(r.buf) {
+		r.count++
+	}
+	r.mu.Unlock()
+
+	return len(p), nil
+}
+
+// Records 按写入顺序（从旧到新）返回缓冲中的所有日志。
+func (r *RingWriter) Records() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records := make([][]byte, 0, r.count)
+	start := (r.next - r.count + len(r.buf)) % len(r.buf)
+	for i := range r.count {
+		records = append(records, r.buf[(start+i)%len(r.buf)])
+	}
+	return records
+}
+
+// Count 返回缓冲中的日志条数。
+func (r *RingWriter) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// WriteTo 实现 io.WriterTo，按写入顺序输出缓冲中的所有日志。
+func (r *RingWriter) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, rec := range r.Records() {
+		n, err := w.Write(rec)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Reset 清空缓冲。
+func (r *RingWriter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.buf)
+	r.next = 0
+	r.count = 0
+}
+
+// Close 实现 io.Closer（无操作，缓冲内容保留）。
+func (r *RingWriter) Close() error {
+	return nil
+}
+
+// Sync 实现 Writer.Sync（无操作）。
+func (r *RingWriter) Sync() error {
+	return nil
+}
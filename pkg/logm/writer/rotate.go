@@ -0,0 +1,16 @@
+package writer
+
+// Rotator 支持手动轮转的 Writer，如 FileWriter。
+//
+// Multi、Async、PerLevel、Sign 等包装 Writer 同样实现该接口，将轮转传递给内层。
+type Rotator interface {
+	Rotate() error
+}
+
+// Rotate 轮转 w，w 未实现 Rotator 时不做任何操作。
+func Rotate(w Writer) error {
+	if r, ok := w.(Rotator); ok {
+		return r.Rotate()
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package writer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig 网络连接共用的 TLS 配置。
+//
+// 配置一次，在各个需要建立 TLS 连接的组件间共享，如 logm.WithTLS 用于
+// AlertWebhook、HTTPSource 和 ConsulKV；同时设置 CertFile 和 KeyFile 时启用双向 TLS（mTLS）。
+type TLSConfig struct {
+	CAFile             string // PEM 格式 CA 证书，为空时使用系统证书池
+	CertFile           string // 客户端证书
+	KeyFile            string // 客户端私钥
+	ServerName         string // SNI 及证书校验使用的服务器名，为空时使用连接地址的主机名
+	InsecureSkipVerify bool   // 跳过服务器证书校验，仅用于测试
+	MinVersion         uint16 // 最低 TLS 版本，默认 TLS 1.2
+}
+
+// Build 加载证书并生成 tls.Config。
+func (c *TLSConfig) Build() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec // G402: 由调用方显式开启
+		MinVersion:         c.MinVersion,
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("writer: tls: read CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("writer: tls: no certificates found in %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	switch {
+	case c.CertFile != "" && c.KeyFile != "":
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("writer: tls: load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	case c.CertFile != "" || c.KeyFile != "":
+		return nil, errors.New("writer: tls: CertFile and KeyFile must be set together")
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,26 @@
+package writer
+
+// Wrapper 包装其他 Writer 的 Writer。
+//
+// Unwrap 返回被包装的 Writer，约定同 errors 的 Unwrap() []error；
+// Async、Multi、PerLevel、DLQ、WAL、Encrypt、Sign 均实现该接口，供 Walk 遍历包装链。
+type Wrapper interface {
+	Unwrap() []Writer
+}
+
+// Walk 按深度优先顺序对 w 及其包装的所有 Writer 调用 fn，fn 返回 false 时停止遍历。
+//
+// 遍历完成时返回 true，被 fn 中止时返回 false。
+func Walk(w Writer, fn func(Writer) bool) bool {
+	if !fn(w) {
+		return false
+	}
+	if u, ok := w.(Wrapper); ok {
+		for _, inner := range u.Unwrap() {
+			if !Walk(inner, fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,545 @@
+package writer
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/internal/selflog"
+)
+
+// ErrWALClosed WALWriter 已关闭
+var ErrWALClosed = errors.New("writer: wal closed")
+
+// WAL 的默认配置
+const (
+	DefaultWALSegmentSize = 64 * megabyte
+	walMaxBackoff         = 30 * time.Second
+	walInitialBackoff     = 100 * time.Millisecond
+	walCheckpointEvery    = 256 // 连续投递时每隔多少条保存一次确认位置
+)
+
+// WAL 文件布局：dir 下的 wal-<序号>.log 段文件和记录确认位置的 offset 文件。
+// 每条记录为 4 字节长度 + 4 字节级别 + 4 字节 CRC32（覆盖级别和数据）+ 数据。
+const (
+	walHeaderSize   = 12
+	walSegmentFmt   = "wal-%016d.log"
+	walOffsetFile   = "offset"
+	walSegmentGlob  = "wal-*.log"
+	walSegmentStart = len("wal-")
+)
+
+// walPosition 确认位置，之前的记录都已投递
+type walPosition struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+// WALWriter 至少一次投递的 Writer。
+//
+// 记录先追加到 dir 下的预写日志（WAL），写入即返回，后台协程再按顺序投递到下游 Writer，
+// 并在 offset 文件中记录已确认的位置。下游失败时以指数退避重试；进程重启后从确认位置
+// 重新投递，适合审计等不能丢失的日志流：
+//
+//	w, err := writer.WAL(collector, "/var/lib/app/audit-wal")
+//
+// 重启或重试可能导致重复投递，下游需要能够容忍。默认每次写入后 fsync，
+// 放在 Async 内层时会失去写入返回即落盘的保证。
+// 全部投递的段文件会被删除，下游长时间不可用时 WAL 会持续增长。
+type WALWriter struct {
+	w           Writer
+	dir         string
+	segmentSize int64
+	syncWrites  bool
+
+	mu      sync.Mutex // 保护写入端
+	seg     *os.File
+	segSeq  uint64
+	segSize int64
+	closed  bool
+	fsync   func(file *os.File) error // fsync 函数，测试时替换
+
+	wake   chan struct{}
+	done   chan struct{}
+	exited chan struct{}
+	abort  atomic.Bool
+
+	ack      walPosition // 仅后台协程访问
+	rf       *os.File    // 后台协程读取的段文件
+	rfSize   int64       // 读取的段文件大小，用于封存的段
+	shipped  atomic.Uint64
+	failures atomic.Uint64
+}
+
+// WALOption WALWriter 配置选项
+type WALOption func(*WALWriter)
+
+// WithWALSegmentSize 设置段文件大小（字节），默认 64MB。
+func WithWALSegmentSize(bytes int64) WALOption {
+	return func(w *WALWriter) {
+		if bytes > 0 {
+			w.segmentSize = bytes
+		}
+	}
+}
+
+// WithWALSyncWrites 设置是否每次写入后 fsync，默认开启。
+//
+// 关闭后由操作系统决定落盘时机，进程崩溃不会丢失数据，但主机掉电可能丢失最近的记录。
+func WithWALSyncWrites(enable bool) WALOption {
+	return func(w *WALWriter) {
+		w.syncWrites = enable
+	}
+}
+
+// WAL 打开或创建 dir 下的预写日志并开始投递，上次未投递的记录会被重新投递。
+//
+// 最后一个段文件末尾因崩溃写了一半的记录会被截断。
+func WAL(w Writer, dir string, opts ...WALOption) (*WALWriter, error) {
+	ww := &WALWriter{
+		w:           w,
+		dir:         dir,
+		segmentSize: DefaultWALSegmentSize,
+		syncWrites:  true,
+		fsync:       defaultFsync,
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		exited:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(ww)
+	}
+	if err := ww.open(); err != nil {
+		return nil, fmt.Errorf("writer: wal: %w", err)
+	}
+	go ww.run()
+	return ww, nil
+}
+
+// open 恢复确认位置并打开最后一个段文件
+func (w *WALWriter) open() error {
+	if err := os.MkdirAll(w.dir, 0o700); err != nil {
+		return err
+	}
+	segs, err := w.segments()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filepath.Join(w.dir, walOffsetFile))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &w.ack); err != nil {
+			return fmt.Errorf("read offset: %w", err)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		if len(segs) > 0 {
+			w.ack = walPosition{Segment: segs[0]}
+		}
+	default:
+		return err
+	}
+
+	// 清理已全部确认的段
+	for len(segs) > 0 && segs[0] < w.ack.Segment {
+		if err := os.Remove(w.segmentPath(segs[0])); err != nil {
+			return err
+		}
+		segs = segs[1:]
+	}
+
+	w.segSeq = max(w.ack.Segment, 1)
+	if len(segs) > 0 {
+		w.segSeq = max(w.segSeq, segs[len(segs)-1])
+	}
+	if w.ack.Segment == 0 {
+		w.ack.Segment = w.segSeq
+	}
+	f, err := os.OpenFile(w.segmentPath(w.segSeq), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	size, err := walValidLength(f)
+	if err == nil {
+		err = f.Truncate(size)
+	}
+	if err == nil {
+		_, err = f.Seek(size, io.SeekStart)
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.seg, w.segSize = f, size
+	if w.ack.Segment == w.segSeq && w.ack.Offset > size {
+		// 确认位置之后的数据已截断
+		w.ack.Offset = size
+	}
+	return nil
+}
+
+// segments 返回现有段文件的序号，按升序排列
+func (w *WALWriter) segments() ([]uint64, error) {
+	names, err := filepath.Glob(filepath.Join(w.dir, walSegmentGlob))
+	if err != nil {
+		return nil, err
+	}
+	var seqs []uint64
+	for _, name := range names {
+		base := strings.TrimSuffix(filepath.Base(name), ".log")
+		if seq, err := strconv.ParseUint(base[walSegmentStart:], 10, 64); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	slices.Sort(seqs)
+	return seqs, nil
+}
+
+// segmentPath 返回段文件路径
+func (w *WALWriter) segmentPath(seq uint64) string {
+	return filepath.Join(w.dir, fmt.Sprintf(walSegmentFmt, seq))
+}
+
+// Write 实现 io.Writer。
+func (w *WALWriter) Write(p []byte) (n int, err error) {
+	return w.WriteLevel(slog.LevelInfo, p)
+}
+
+// WriteLevel 实现 LevelWriter，记录写入 WAL 后返回，级别随记录保存并传递给下游。
+func (w *WALWriter) WriteLevel(level slog.Level, p []byte) (n int, err error) {
+	frame := make([]byte, walHeaderSize+len(p))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(p)))       //nolint:gosec // 单条日志不会超过 4GB
+	binary.BigEndian.PutUint32(frame[4:8], uint32(int32(level))) //nolint:gosec // 级别按位保存
+	copy(frame[walHeaderSize:], p)
+	binary.BigEndian.PutUint32(frame[8:12], walChecksum(frame[4:8], p))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrWALClosed
+	}
+	if w.segSize > 0 && w.segSize+int64(len(frame)) > w.segmentSize {
+		if err := w.rotateSegment(); err != nil {
+			return 0, fmt.Errorf("writer: wal: %w", err)
+		}
+	}
+	if _, err := w.seg.Write(frame); err != nil {
+		w.discardTail()
+		return 0, fmt.Errorf("writer: wal: %w", err)
+	}
+	if w.syncWrites {
+		if err := w.fsync(w.seg); err != nil {
+			// 返回错误的记录不能留在 WAL 中，否则调用方重试后会重复投递
+			w.discardTail()
+			return 0, fmt.Errorf("writer: wal: %w", err)
+		}
+	}
+	w.segSize += int64(len(frame))
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// discardTail 截断 segSize 之后写入失败的记录，避免读取端解析失败，调用方持有 mu
+func (w *WALWriter) discardTail() {
+	_ = w.seg.Truncate(w.segSize)
+	_, _ = w.seg.Seek(w.segSize, io.SeekStart)
+}
+
+// rotateSegment 封存当前段并创建下一个，调用方持有 mu
+func (w *WALWriter) rotateSegment() error {
+	if err := w.seg.Sync(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.segmentPath(w.segSeq+1), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	_ = w.seg.Close()
+	w.seg, w.segSeq, w.segSize = f, w.segSeq+1, 0
+	return nil
+}
+
+// run 后台投递协程
+func (w *WALWriter) run() {
+	defer close(w.exited)
+	defer w.closeReader()
+	defer w.checkpoint()
+
+	backoff := walInitialBackoff
+	sinceCheckpoint := 0
+	for !w.abort.Load() {
+		level, p, next, ok := w.next()
+		if !ok {
+			if sinceCheckpoint > 0 {
+				w.checkpoint()
+				sinceCheckpoint = 0
+			}
+			select {
+			case <-w.wake:
+			case <-w.done:
+				// 关闭前的写入可能尚未读到，确认没有剩余记录后退出
+				if _, _, _, ok := w.next(); !ok {
+					return
+				}
+			}
+			continue
+		}
+
+		if _, err := WriteLevel(w.w, level, p); err != nil {
+			w.failures.Add(1)
+			w.checkpoint()
+			sinceCheckpoint = 0
+			select {
+			case <-w.done:
+				// 关闭时不再重试，剩余记录留待下次打开时投递
+				selflog.Printf("wal", "ship failed on close, pending records kept: %v", err)
+				return
+			default:
+			}
+			selflog.Printf("wal", "ship failed, retry in %s: %v", backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-w.done:
+			}
+			backoff = min(backoff*2, walMaxBackoff)
+			continue
+		}
+		backoff = walInitialBackoff
+		w.ack = next
+		w.shipped.Add(1)
+		if sinceCheckpoint++; sinceCheckpoint >= walCheckpointEvery {
+			w.checkpoint()
+			sinceCheckpoint = 0
+		}
+	}
+}
+
+// next 读取确认位置之后的下一条记录，返回记录之后的位置
+func (w *WALWriter) next() (level slog.Level, p []byte, next walPosition, ok bool) {
+	var hdr [walHeaderSize]byte
+	for {
+		w.mu.Lock()
+		curSeq, curSize := w.segSeq, w.segSize
+		w.mu.Unlock()
+
+		if w.ack.Segment == curSeq && w.ack.Offset >= curSize {
+			return 0, nil, walPosition{}, false
+		}
+		if w.rf == nil {
+			f, err := os.Open(w.segmentPath(w.ack.Segment))
+			if err != nil {
+				selflog.Printf("wal", "open segment: %v", err)
+				if !w.skipSegment(curSeq) {
+					return 0, nil, walPosition{}, false
+				}
+				continue
+			}
+			fi, err := f.Stat()
+			if err != nil {
+				_ = f.Close()
+				selflog.Printf("wal", "open segment: %v", err)
+				return 0, nil, walPosition{}, false
+			}
+			w.rf, w.rfSize = f, fi.Size()
+		}
+		limit := w.rfSize
+		if w.ack.Segment == curSeq {
+			limit = curSize
+		}
+
+		p, err := walReadRecord(w.rf, w.ack.Offset, limit, &hdr)
+		if errors.Is(err, io.EOF) && w.ack.Segment != curSeq {
+			// 打开后该段才被封存，重新获取大小
+			if fi, statErr := w.rf.Stat(); statErr == nil && fi.Size() > w.rfSize {
+				w.rfSize = fi.Size()
+				continue
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				selflog.Printf("wal", "read segment %d at %d: %v, skipping rest of segment", w.ack.Segment, w.ack.Offset, err)
+			}
+			// 封存的段读完后进入下一个段
+			if !w.skipSegment(curSeq) {
+				return 0, nil, walPosition{}, false
+			}
+			continue
+		}
+
+		level = slog.Level(int32(binary.BigEndian.Uint32(hdr[4:8]))) //nolint:gosec // 与 WriteLevel 对应
+		next = walPosition{Segment: w.ack.Segment, Offset: w.ack.Offset + walHeaderSize + int64(len(p))}
+		return level, p, next, true
+	}
+}
+
+// skipSegment 结束当前读取的段并删除，当前段是写入中的段时返回 false
+func (w *WALWriter) skipSegment(curSeq uint64) bool {
+	if w.ack.Segment >= curSeq {
+		return false
+	}
+	old := w.ack.Segment
+	w.closeReader()
+	w.ack = walPosition{Segment: old + 1}
+	w.checkpoint()
+	if err := os.Remove(w.segmentPath(old)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		selflog.Printf("wal", "remove segment: %v", err)
+	}
+	return true
+}
+
+// checkpoint 原子地保存确认位置
+func (w *WALWriter) checkpoint() {
+	data, _ := json.Marshal(w.ack)
+	path := filepath.Join(w.dir, walOffsetFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		selflog.Printf("wal", "save offset: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		selflog.Printf("wal", "save offset: %v", err)
+	}
+}
+
+// closeReader 关闭读取中的段文件
+func (w *WALWriter) closeReader() {
+	if w.rf != nil {
+		_ = w.rf.Close()
+		w.rf = nil
+	}
+}
+
+// Shipped 返回本次运行投递成功的记录数。
+func (w *WALWriter) Shipped() uint64 {
+	return w.shipped.Load()
+}
+
+// Failures 返回投递失败的次数，每次重试失败都计数。
+func (w *WALWriter) Failures() uint64 {
+	return w.failures.Load()
+}
+
+// Sync 实现 Writer.Sync，将 WAL 刷入磁盘，不等待投递。
+func (w *WALWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	return w.seg.Sync()
+}
+
+// Rotate 实现 Rotator，轮转下游 Writer。
+func (w *WALWriter) Rotate() error {
+	return Rotate(w.w)
+}
+
+// Unwrap 实现 Wrapper，返回下游 Writer。
+func (w *WALWriter) Unwrap() []Writer {
+	return []Writer{w.w}
+}
+
+// Close 实现 io.Closer。
+//
+// 停止接收写入，尝试投递剩余记录（不再重试）后关闭 WAL 和下游 Writer，
+// 未投递的记录保留在 WAL 中，下次打开时重新投递。
+func (w *WALWriter) Close() error {
+	return w.CloseContext(context.Background())
+}
+
+// CloseContext 同 Close，ctx 结束时停止投递并返回 ctx.Err()，此时下游 Writer 不会被关闭。
+func (w *WALWriter) CloseContext(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	w.mu.Unlock()
+
+	var ctxErr error
+	select {
+	case <-w.exited:
+	case <-ctx.Done():
+		// 投递协程在当前写入返回后退出
+		w.abort.Store(true)
+		ctxErr = ctx.Err()
+	}
+
+	w.mu.Lock()
+	err := errors.Join(w.seg.Sync(), w.seg.Close())
+	w.mu.Unlock()
+	if ctxErr != nil {
+		return errors.Join(ctxErr, err)
+	}
+	return errors.Join(err, w.w.Close())
+}
+
+// walChecksum 计算级别和数据的 CRC32
+func walChecksum(level, p []byte) uint32 {
+	return crc32.Update(crc32.ChecksumIEEE(level), crc32.IEEETable, p)
+}
+
+// errWALCorrupt 记录不完整或校验失败
+var errWALCorrupt = errors.New("corrupt record")
+
+// walReadRecord 读取 off 处的记录，记录超出 limit 时返回 io.EOF 或 errWALCorrupt
+func walReadRecord(f *os.File, off, limit int64, hdr *[walHeaderSize]byte) ([]byte, error) {
+	if off+walHeaderSize > limit {
+		return nil, io.EOF
+	}
+	if _, err := f.ReadAt(hdr[:], off); err != nil {
+		return nil, err
+	}
+	size := int64(binary.BigEndian.Uint32(hdr[0:4]))
+	if off+walHeaderSize+size > limit {
+		return nil, errWALCorrupt
+	}
+	p := make([]byte, size)
+	if _, err := f.ReadAt(p, off+walHeaderSize); err != nil {
+		return nil, err
+	}
+	if walChecksum(hdr[4:8], p) != binary.BigEndian.Uint32(hdr[8:12]) {
+		return nil, errWALCorrupt
+	}
+	return p, nil
+}
+
+// walValidLength 返回段文件中完整记录的总长度
+func walValidLength(f *os.File) (int64, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	var off int64
+	var hdr [walHeaderSize]byte
+	for {
+		p, err := walReadRecord(f, off, fi.Size(), &hdr)
+		switch {
+		case errors.Is(err, io.EOF) || errors.Is(err, errWALCorrupt):
+			return off, nil
+		case err != nil:
+			return 0, err
+		}
+		off += walHeaderSize + int64(len(p))
+	}
+}
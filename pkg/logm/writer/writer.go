@@ -5,6 +5,12 @@
 //   - File: 文件输出，支持轮转
 //   - Async: 异步写入，提升性能
 //   - Multi: 多目标输出
+//   - Ring: 内存环形缓冲，保留最近 N 条日志
+//   - Encrypt: 信封加密，日志以密文落盘
+//   - Sign: 每行追加 HMAC 签名，发现伪造或注入的行
+//   - PerLevel: 按级别分发到不同目标
+//   - DLQ: 写入失败时重试，重试耗尽后保存到本地死信文件
+//   - WAL: 先写本地预写日志再投递，至少一次送达
 //
 // # 使用示例
 //
@@ -14,7 +20,10 @@
 //	logm.Init(logm.WithWriter(writer.File("/var/log/app.log", writer.WithRotation(100, 7))))
 package writer
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
 // Writer 日志输出目标接口。
 //
@@ -26,10 +35,82 @@ type Writer interface {
 	Sync() error
 }
 
+// CloseContext 在 ctx 结束前关闭 w。
+//
+// w 实现 CloseContext(context.Context) error 时直接调用（如 AsyncWriter 会放弃剩余数据）；
+// 否则在独立协程中调用 Close，ctx 结束时不再等待并返回 ctx.Err()，
+// 避免关闭阻塞的 Writer 时无限挂起。
+func CloseContext(ctx context.Context, w Writer) error {
+	if c, ok := w.(interface {
+		CloseContext(ctx context.Context) error
+	}); ok {
+		return c.CloseContext(ctx)
+	}
+	return waitContext(ctx, w.Close)
+}
+
+// SyncContext 在 ctx 结束前刷新 w。
+//
+// 规则与 CloseContext 相同，优先调用 SyncContext(context.Context) error。
+func SyncContext(ctx context.Context, w Writer) error {
+	if s, ok := w.(interface {
+		SyncContext(ctx context.Context) error
+	}); ok {
+		return s.SyncContext(ctx)
+	}
+	return waitContext(ctx, w.Sync)
+}
+
+// waitContext 在独立协程中执行 fn，ctx 结束时不再等待
+func waitContext(ctx context.Context, fn func() error) error {
+	if ctx.Done() == nil {
+		return fn()
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- fn() }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // 确保所有 Writer 实现接口
 var (
 	_ Writer = (*StdWriter)(nil)
 	_ Writer = (*FileWriter)(nil)
 	_ Writer = (*AsyncWriter)(nil)
 	_ Writer = (*MultiWriter)(nil)
+	_ Writer = (*RingWriter)(nil)
+	_ Writer = (*EncryptedWriter)(nil)
+	_ Writer = (*SignedWriter)(nil)
+	_ Writer = (*DLQWriter)(nil)
+	_ Writer = (*WALWriter)(nil)
+
+	_ LevelWriter = (*PerLevelWriter)(nil)
+	_ LevelWriter = (*MultiWriter)(nil)
+	_ LevelWriter = (*AsyncWriter)(nil)
+	_ LevelWriter = (*FileWriter)(nil)
+	_ LevelWriter = (*DLQWriter)(nil)
+	_ LevelWriter = (*WALWriter)(nil)
+	_ LevelWriter = (*EncryptedWriter)(nil)
+	_ LevelWriter = (*SignedWriter)(nil)
+
+	_ Rotator = (*FileWriter)(nil)
+	_ Rotator = (*AsyncWriter)(nil)
+	_ Rotator = (*MultiWriter)(nil)
+	_ Rotator = (*PerLevelWriter)(nil)
+	_ Rotator = (*EncryptedWriter)(nil)
+	_ Rotator = (*SignedWriter)(nil)
+	_ Rotator = (*DLQWriter)(nil)
+	_ Rotator = (*WALWriter)(nil)
+
+	_ Wrapper = (*AsyncWriter)(nil)
+	_ Wrapper = (*MultiWriter)(nil)
+	_ Wrapper = (*PerLevelWriter)(nil)
+	_ Wrapper = (*EncryptedWriter)(nil)
+	_ Wrapper = (*SignedWriter)(nil)
+	_ Wrapper = (*DLQWriter)(nil)
+	_ Wrapper = (*WALWriter)(nil)
 )
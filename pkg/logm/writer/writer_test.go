@@ -2,11 +2,32 @@ package writer
 
 import (
 	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/lwmacct/251219-go-pkg-logm/pkg/logm/formatter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -101,6 +122,511 @@ func TestFileWriter_Sync(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestFile_Permissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("文件权限仅适用于类 Unix 系统")
+	}
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	path := filepath.Join(dir, "app.log")
+
+	w := File(path, WithFileMode(0o640), WithDirMode(0o750), WithOwner(os.Getuid(), os.Getgid()))
+	_, err := w.Write([]byte("line\n"))
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	for _, d := range []string{dir, filepath.Dir(dir)} {
+		info, err = os.Stat(d)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o750), info.Mode().Perm(), d)
+	}
+
+	// 轮转后的新文件沿用权限
+	require.NoError(t, w.Rotate())
+	_, err = w.Write([]byte("after\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+}
+
+func TestFile_PermissionsExistingFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("文件权限仅适用于类 Unix 系统")
+	}
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+
+	w := File(path, WithFileMode(0o600))
+	_, err := w.Write([]byte("new\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	content, err := os.ReadFile(path) //nolint:gosec // G304: test file path is safe
+	require.NoError(t, err)
+	assert.Equal(t, "old\nnew\n", string(content))
+}
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+// listDir 返回目录中的文件名（已排序）
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestFile_IntervalRotationPattern(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clock := &fakeClock{t: time.Date(2024, 1, 15, 10, 20, 0, 0, time.UTC)}
+
+	w := File(path,
+		WithLocalTime(false),
+		WithInterval(time.Hour),
+		WithFilenamePattern("app-%Y%m%d-%H.log.gz"),
+		WithCompress(false),
+	)
+	w.now = clock.Now
+
+	_, err := w.Write([]byte("10:20\n"))
+	require.NoError(t, err)
+	clock.Add(30 * time.Minute) // 10:50，同一周期
+	_, err = w.Write([]byte("10:50\n"))
+	require.NoError(t, err)
+	clock.Add(15 * time.Minute) // 11:05，跨过整点
+	_, err = w.Write([]byte("11:05\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Rotate())
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-20240115-10.log", "app-20240115-11.log", "app.log"}, listDir(t, dir))
+	content, err := os.ReadFile(filepath.Join(dir, "app-20240115-10.log")) //nolint:gosec // G304: test file path is safe
+	require.NoError(t, err)
+	assert.Equal(t, "10:20\n10:50\n", string(content))
+
+	// 同名备份已存在时追加序号，已有文件按修改时间命名
+	require.NoError(t, os.Chtimes(path, clock.Now(), clock.Now()))
+	w = File(path, WithLocalTime(false), WithFilenamePattern("app-%Y%m%d-%H.log"), WithCompress(false))
+	w.now = clock.Now
+	_, err = w.Write([]byte("more\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Rotate())
+	require.NoError(t, w.Close())
+	assert.Contains(t, listDir(t, dir), "app-20240115-11-1.log")
+}
+
+func TestFile_IntervalRotatesStaleFileOnStart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("yesterday\n"), 0o600))
+	yesterday := time.Date(2024, 1, 14, 23, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, yesterday, yesterday))
+
+	w := File(path, WithLocalTime(false), WithInterval(24*time.Hour),
+		WithFilenamePattern("app-%Y-%m-%d.log"), WithCompress(false))
+	w.now = func() time.Time { return time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC) }
+	_, err := w.Write([]byte("today\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-2024-01-14.log", "app.log"}, listDir(t, dir))
+}
+
+func TestFile_DateDir(t *testing.T) {
+	root := t.TempDir()
+	stale := filepath.Join(root, "2024-01-01")
+	require.NoError(t, os.Mkdir(stale, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(stale, "app.log"), []byte("old\n"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(root, "archive"), 0o755))
+
+	clock := &fakeClock{t: time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC)}
+	var rotated []string
+	w := File(filepath.Join(root, "app.log"),
+		WithLocalTime(false),
+		WithDateDir("2006-01-02"),
+		WithMaxAge(7),
+		WithOnRotate(func(oldPath string) { rotated = append(rotated, oldPath) }),
+	)
+	w.now = clock.Now
+
+	_, err := w.Write([]byte("day 1\n"))
+	require.NoError(t, err)
+	clock.Add(time.Hour) // 次日 00:30
+	_, err = w.Write([]byte("day 2\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"2024-01-15", "2024-01-16", "archive"}, listDir(t, root))
+	content, err := os.ReadFile(filepath.Join(root, "2024-01-15", "app.log")) //nolint:gosec // G304: test file path is safe
+	require.NoError(t, err)
+	assert.Equal(t, "day 1\n", string(content))
+	content, err = os.ReadFile(filepath.Join(root, "2024-01-16", "app.log")) //nolint:gosec // G304: test file path is safe
+	require.NoError(t, err)
+	assert.Equal(t, "day 2\n", string(content))
+	assert.Equal(t, []string{filepath.Join(root, "2024-01-15", "app.log")}, rotated)
+}
+
+func TestFile_DateDirInvalidLayout(t *testing.T) {
+	w := File(filepath.Join(t.TempDir(), "app.log"), WithDateDir("2006/01/02"))
+	_, err := w.Write([]byte("x"))
+	require.Error(t, err)
+}
+
+func TestFile_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require extra privileges on Windows")
+	}
+	root := t.TempDir()
+	link := filepath.Join(root, "app.log")
+	clock := &fakeClock{t: time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC)}
+
+	w := File(link, WithLocalTime(false), WithDateDir("2006-01-02"), WithSymlink(link))
+	w.now = clock.Now
+
+	_, err := w.Write([]byte("day 1\n"))
+	require.NoError(t, err)
+	target, err := os.Readlink(link)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("2024-01-15", "app.log"), target)
+
+	clock.Add(time.Hour)
+	_, err = w.Write([]byte("day 2\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	target, err = os.Readlink(link)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("2024-01-16", "app.log"), target)
+	content, err := os.ReadFile(link) //nolint:gosec // G304: test file path is safe
+	require.NoError(t, err)
+	assert.Equal(t, "day 2\n", string(content))
+}
+
+func TestFile_SymlinkKeepsRegularFile(t *testing.T) {
+	root := t.TempDir()
+	link := filepath.Join(root, "current.log")
+	require.NoError(t, os.WriteFile(link, []byte("keep\n"), 0o600))
+
+	w := File(filepath.Join(root, "app.log"), WithSymlink(link))
+	_, err := w.Write([]byte("x\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	content, err := os.ReadFile(link) //nolint:gosec // G304: test file path is safe
+	require.NoError(t, err)
+	assert.Equal(t, "keep\n", string(content))
+
+	w = File(filepath.Join(root, "app.log"), WithSymlink(filepath.Join(root, "app.log")))
+	_, err = w.Write([]byte("x\n"))
+	require.Error(t, err)
+}
+
+// diskFullFile 模拟磁盘空间不足的写入函数
+func diskFullFile(full *atomic.Bool) func(*os.File, []byte) (int, error) {
+	return func(file *os.File, p []byte) (int, error) {
+		if full.Load() {
+			return 0, &os.PathError{Op: "write", Path: file.Name(), Err: syscall.ENOSPC}
+		}
+		return file.Write(p)
+	}
+}
+
+func TestFile_DiskFullPolicies(t *testing.T) {
+	var full atomic.Bool
+	full.Store(true)
+
+	// 默认返回错误
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := File(path)
+	w.write = diskFullFile(&full)
+	_, err := w.Write([]byte("x\n"))
+	require.ErrorIs(t, err, syscall.ENOSPC)
+	require.NoError(t, w.Close())
+
+	// 丢弃并计数，回调只在进入空间不足状态时调用一次
+	notified := make(chan error, 4)
+	w = File(path, WithDiskFullPolicy(DiskFullDrop), WithOnDiskFull(func(err error) { notified <- err }))
+	w.write = diskFullFile(&full)
+	for range 3 {
+		_, err = w.Write([]byte("x\n"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, uint64(3), w.Dropped())
+	select {
+	case err := <-notified:
+		require.ErrorIs(t, err, syscall.ENOSPC)
+	case <-time.After(time.Second):
+		t.Fatal("OnDiskFull not called")
+	}
+	require.NoError(t, w.Close())
+	assert.Empty(t, notified)
+}
+
+func TestFile_DiskFullBuffer(t *testing.T) {
+	var full atomic.Bool
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := File(path, WithDiskFullPolicy(DiskFullBuffer), WithDiskFullBuffer(8))
+	w.write = diskFullFile(&full)
+
+	_, err := w.Write([]byte("a\n"))
+	require.NoError(t, err)
+	full.Store(true)
+	for _, line := range []string{"b\n", "c\n", "d\n", "e\n", "f\n"} {
+		_, err = w.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	// 缓冲区只能容纳 4 条，最旧的一条被丢弃
+	assert.Equal(t, uint64(1), w.Dropped())
+
+	full.Store(false)
+	_, err = w.Write([]byte("g\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	content, err := os.ReadFile(path) //nolint:gosec // G304: test file path is safe
+	require.NoError(t, err)
+	assert.Equal(t, "a\nc\nd\ne\nf\ng\n", string(content))
+}
+
+func TestFile_DiskFullBlock(t *testing.T) {
+	var full atomic.Bool
+	full.Store(true)
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := File(path, WithDiskFullPolicy(DiskFullBlock))
+	w.write = diskFullFile(&full)
+	w.retry = time.Millisecond
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Write([]byte("blocked\n"))
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("write returned while disk is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+	full.Store(false)
+	require.NoError(t, <-done)
+	require.NoError(t, w.Close())
+
+	content, err := os.ReadFile(path) //nolint:gosec // G304: test file path is safe
+	require.NoError(t, err)
+	assert.Equal(t, "blocked\n", string(content))
+}
+
+func TestFile_DiskFullBlock_Close(t *testing.T) {
+	var full atomic.Bool
+	full.Store(true)
+	w := File(filepath.Join(t.TempDir(), "app.log"), WithDiskFullPolicy(DiskFullBlock))
+	w.write = diskFullFile(&full)
+	w.retry = time.Hour
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Write([]byte("blocked\n"))
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() { closed <- w.Close() }()
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, syscall.ENOSPC)
+	case <-time.After(time.Second):
+		t.Fatal("Close did not interrupt DiskFullBlock")
+	}
+	require.NoError(t, <-closed)
+	assert.Equal(t, uint64(1), w.Dropped())
+}
+
+func TestFile_FsyncLevel(t *testing.T) {
+	var syncs atomic.Int32
+	w := File(filepath.Join(t.TempDir(), "app.log"), WithFsyncLevel(slog.LevelError))
+	w.fsync = func(*os.File) error { syncs.Add(1); return nil }
+
+	_, err := w.WriteLevel(slog.LevelInfo, []byte("info\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("plain\n"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), syncs.Load())
+
+	_, err = w.WriteLevel(slog.LevelError, []byte("error\n"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), syncs.Load())
+
+	require.NoError(t, w.Sync())
+	assert.Equal(t, int32(2), syncs.Load())
+	require.NoError(t, w.Close())
+}
+
+func TestFile_FsyncLevel_ThroughWrappers(t *testing.T) {
+	dir := t.TempDir()
+	var syncs atomic.Int32
+	newFile := func(name string) *FileWriter {
+		f := File(filepath.Join(dir, name), WithFsyncLevel(slog.LevelError), WithCompress(false))
+		f.fsync = func(*os.File) error { syncs.Add(1); return nil }
+		return f
+	}
+	encrypted, err := Encrypt(newFile("enc.log"), "k1", testEncKey)
+	require.NoError(t, err)
+	signed, err := Sign(newFile("signed.log"), "s1", testSignKey)
+	require.NoError(t, err)
+	w := Multi(encrypted, signed)
+
+	_, err = WriteLevel(w, slog.LevelInfo, []byte("info\n"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), syncs.Load())
+
+	_, err = WriteLevel(w, slog.LevelError, []byte("error\n"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), syncs.Load())
+	require.NoError(t, w.Close())
+}
+
+func TestFile_FsyncInterval(t *testing.T) {
+	var syncs atomic.Int32
+	w := File(filepath.Join(t.TempDir(), "app.log"), WithFsyncInterval(10*time.Millisecond))
+	w.fsync = func(*os.File) error { syncs.Add(1); return nil }
+
+	for range 5 {
+		_, err := w.Write([]byte("x\n"))
+		require.NoError(t, err)
+	}
+	// 同一周期内的多次写入合并为一次 fsync
+	require.Eventually(t, func() bool { return syncs.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	_, err := w.Write([]byte("y\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, int32(2), syncs.Load())
+}
+
+func TestFile_RetentionAndCompress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clock := &fakeClock{t: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)}
+
+	w := File(path, WithRotation(1, 2), WithLocalTime(false))
+	w.now = clock.Now
+	for i := range 4 {
+		_, err := w.Write([]byte("line " + strconv.Itoa(i) + "\n"))
+		require.NoError(t, err)
+		clock.Add(time.Second)
+		require.NoError(t, w.Rotate())
+	}
+	require.NoError(t, w.Close())
+
+	names := listDir(t, dir)
+	require.Len(t, names, 3, "保留 2 个备份和当前文件: %v", names)
+	for _, name := range names[:2] {
+		assert.True(t, strings.HasSuffix(name, ".log.gz"), name)
+	}
+
+	// 默认备份名与 lumberjack 的格式兼容，升级前的备份同样参与清理
+	assert.Regexp(t, `^app-2024-01-15T10-00-0\d\.000\.log\.gz$`, names[0])
+}
+
+func TestFile_OnRotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	var (
+		mu      sync.Mutex
+		rotated []string
+	)
+	w := File(path, WithOnRotate(func(oldPath string) {
+		// 回调时文件已压缩完成，可以直接读取
+		data, err := os.ReadFile(oldPath) //nolint:gosec // G304: test file path is safe
+		assert.NoError(t, err)
+		assert.NotEmpty(t, data)
+		mu.Lock()
+		rotated = append(rotated, oldPath)
+		mu.Unlock()
+	}))
+
+	_, err := w.Write([]byte("first\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Rotate())
+	_, err = w.Write([]byte("second\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close()) // Close 等待回调完成
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, rotated, 1)
+	assert.Equal(t, dir, filepath.Dir(rotated[0]))
+	assert.True(t, strings.HasSuffix(rotated[0], ".log.gz"), rotated[0])
+}
+
+func TestFile_MaxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clock := &fakeClock{t: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)}
+
+	// 不限数量和天数，只按总大小清理
+	w := File(path, WithRotation(1, 0), WithMaxAge(0), WithCompress(false), WithMaxTotalSize(2500))
+	w.now = clock.Now
+	chunk := bytes.Repeat([]byte("x"), 1000)
+	for range 5 {
+		_, err := w.Write(chunk)
+		require.NoError(t, err)
+		clock.Add(time.Second)
+		require.NoError(t, w.Rotate())
+	}
+	require.NoError(t, w.Close())
+
+	// 最新的 2 个备份共 2000 字节，再保留一个就会超出上限
+	names := listDir(t, dir)
+	assert.Len(t, names, 3, "%v", names)
+	assert.Contains(t, names, "app.log")
+	assert.Contains(t, names, "app-2024-01-15T10-00-04.000.log")
+}
+
+func TestFile_InvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"logs/app-%Y.log", "app-%Q.log", "app-%"} {
+		w := File(filepath.Join(t.TempDir(), "app.log"), WithFilenamePattern(pattern))
+		_, err := w.Write([]byte("x\n"))
+		require.Error(t, err, pattern)
+	}
+}
+
+func TestFile_WriteTooLarge(t *testing.T) {
+	w := File(filepath.Join(t.TempDir(), "app.log"), WithRotation(1, 1))
+	_, err := w.Write(make([]byte, 2*1024*1024))
+	require.Error(t, err)
+	require.NoError(t, w.Close())
+}
+
 // ============ AsyncWriter Tests ============
 
 func TestAsync_Create(t *testing.T) {
@@ -209,6 +735,143 @@ func TestAsync_ConcurrentWrite(t *testing.T) {
 	assert.Len(t, result, 100)
 }
 
+func TestAsync_Dropped(t *testing.T) {
+	release := make(chan struct{})
+	inner := &blockingWriter{release: release}
+
+	w := Async(inner, 1)
+
+	// 第一条被后台协程取走并阻塞，第二条占满缓冲区，之后的全部丢弃
+	_, _ = w.Write([]byte("1"))
+	assert.Eventually(t, func() bool { return w.Len() == 0 }, time.Second, time.Millisecond)
+	_, _ = w.Write([]byte("2"))
+	_, _ = w.Write([]byte("3"))
+	_, _ = w.Write([]byte("4"))
+
+	assert.Equal(t, 1, w.Len())
+	assert.Equal(t, uint64(2), w.Dropped())
+
+	close(release)
+	require.NoError(t, w.Close())
+
+	// 关闭后写入也计入丢弃
+	_, _ = w.Write([]byte("5"))
+	assert.Equal(t, uint64(3), w.Dropped())
+}
+
+func TestAsync_Sync(t *testing.T) {
+	var buf bytes.Buffer
+	mu := &sync.Mutex{}
+	inner := &mockWriter{buf: &buf, mu: mu}
+
+	w := Async(inner, 100)
+	defer func() { _ = w.Close() }()
+
+	for range 10 {
+		_, _ = w.Write([]byte("x"))
+	}
+	require.NoError(t, w.Sync())
+
+	// Sync 返回时之前写入的数据必须已全部写出
+	mu.Lock()
+	assert.Equal(t, 10, buf.Len())
+	mu.Unlock()
+
+	// 多次 Sync 不应阻塞
+	require.NoError(t, w.Sync())
+}
+
+func TestAsync_CloseContext_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	w := Async(&blockingWriter{release: release}, 10)
+
+	for range 5 {
+		_, _ = w.Write([]byte("x"))
+	}
+	// 等待后台协程取走第一条并阻塞
+	assert.Eventually(t, func() bool { return w.Len() == 4 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, w.CloseContext(ctx), context.DeadlineExceeded)
+
+	// 解除阻塞后剩余数据被放弃并计入 Dropped
+	close(release)
+	w.wg.Wait()
+	assert.Equal(t, uint64(4), w.Dropped())
+}
+
+func TestAsync_SyncContext_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	w := Async(&blockingWriter{release: release}, 10)
+
+	_, _ = w.Write([]byte("x"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, w.SyncContext(ctx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, w.Close())
+}
+
+func TestCloseContext_Fallback(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	w := &blockingWriter{release: release, blockClose: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, CloseContext(ctx, w), context.DeadlineExceeded)
+
+	var buf bytes.Buffer
+	require.NoError(t, CloseContext(context.Background(), &mockWriter{buf: &buf}))
+}
+
+func TestAsync_Spill(t *testing.T) {
+	var buf bytes.Buffer
+	gate := &gateWriter{mockWriter: mockWriter{buf: &buf, mu: &sync.Mutex{}}, release: make(chan struct{})}
+	dir := t.TempDir()
+	w := Async(gate, 2, WithSpill(dir, 0))
+
+	var want strings.Builder
+	for i := range 20 {
+		line := "line " + strconv.Itoa(i) + "\n"
+		want.WriteString(line)
+		_, err := w.WriteLevel(slog.LevelWarn, []byte(line))
+		require.NoError(t, err)
+	}
+	assert.Positive(t, w.Spilled())
+	assert.Zero(t, w.Dropped())
+	files, _ := os.ReadDir(dir)
+	assert.Len(t, files, 1)
+
+	// 下游恢复后按顺序写回
+	close(gate.release)
+	require.NoError(t, w.Sync())
+	assert.Equal(t, want.String(), buf.String())
+	assert.Equal(t, []slog.Level{slog.LevelWarn}, slices.Compact(gate.levels()))
+
+	require.NoError(t, w.Close())
+	files, _ = os.ReadDir(dir)
+	assert.Empty(t, files)
+}
+
+func TestAsync_SpillMaxBytes(t *testing.T) {
+	gate := &gateWriter{mockWriter: mockWriter{buf: &bytes.Buffer{}, mu: &sync.Mutex{}}, release: make(chan struct{})}
+	w := Async(gate, 1, WithSpill(t.TempDir(), 3*(spillHeaderSize+4)))
+
+	for range 10 {
+		_, _ = w.Write([]byte("abc\n"))
+	}
+	assert.Equal(t, uint64(3), w.Spilled())
+	assert.Positive(t, w.Dropped())
+
+	close(gate.release)
+	require.NoError(t, w.Close())
+	assert.Equal(t, 10-int(w.Dropped()), strings.Count(gate.buf.String(), "abc"))
+}
+
 // ============ MultiWriter Tests ============
 
 func TestMulti_Create(t *testing.T) {
@@ -279,6 +942,809 @@ func TestMulti_Empty(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestMulti_Dropped(t *testing.T) {
+	release := make(chan struct{})
+	aw := Async(&blockingWriter{release: release}, 1)
+	mw := Multi(&mockWriter{buf: &bytes.Buffer{}}, aw)
+
+	_, _ = mw.Write([]byte("1"))
+	assert.Eventually(t, func() bool { return aw.Len() == 0 }, time.Second, time.Millisecond)
+	_, _ = mw.Write([]byte("2"))
+	_, _ = mw.Write([]byte("3"))
+
+	assert.Equal(t, uint64(1), mw.Dropped())
+
+	close(release)
+	require.NoError(t, mw.Close())
+}
+
+// ============ RingWriter Tests ============
+
+func TestRing_KeepsLatest(t *testing.T) {
+	w := Ring(3)
+
+	for _, s := range []string{"1", "2", "3", "4", "5"} {
+		n, err := w.Write([]byte(s))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	}
+
+	assert.Equal(t, 3, w.Count())
+	records := w.Records()
+	require.Len(t, records, 3)
+	assert.Equal(t, "3", string(records[0]))
+	assert.Equal(t, "5", string(records[2]))
+
+	var buf bytes.Buffer
+	_, err := w.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "345", buf.String())
+
+	w.Reset()
+	assert.Equal(t, 0, w.Count())
+	assert.Empty(t, w.Records())
+}
+
+func TestRing_CopiesData(t *testing.T) {
+	w := Ring(0)
+	assert.Len(t, w.buf, 1000)
+
+	p := []byte("abc")
+	_, _ = w.Write(p)
+	p[0] = 'x'
+	assert.Equal(t, "abc", string(w.Records()[0]))
+}
+
+// ============ EncryptedWriter Tests ============
+
+var testEncKey = bytes.Repeat([]byte{0x42}, 32)
+
+func testKeyLookup(id string) ([]byte, error) {
+	if id != "k1" {
+		return nil, os.ErrNotExist
+	}
+	return testEncKey, nil
+}
+
+func TestEncrypt_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := Encrypt(&mockWriter{buf: &buf}, "k1", testEncKey)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("line one\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("secret line two\n"))
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "secret")
+
+	// 同一文件中追加另一个 Writer 的块
+	w2, err := Encrypt(&mockWriter{buf: &buf}, "k1", testEncKey)
+	require.NoError(t, err)
+	_, err = w2.Write([]byte("line three\n"))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, Decrypt(&out, bytes.NewReader(buf.Bytes()), testKeyLookup))
+	assert.Equal(t, "line one\nsecret line two\nline three\n", out.String())
+}
+
+func TestEncrypt_ChunkSize(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := Encrypt(&mockWriter{buf: &buf}, "k1", testEncKey, WithChunkSize(1024))
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("buffered\n"))
+	require.NoError(t, err)
+	assert.Zero(t, buf.Len(), "未达到块大小时不写出")
+
+	require.NoError(t, w.Sync())
+	assert.NotZero(t, buf.Len())
+
+	_, err = w.Write([]byte("on close\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	var out bytes.Buffer
+	require.NoError(t, Decrypt(&out, &buf, testKeyLookup))
+	assert.Equal(t, "buffered\non close\n", out.String())
+}
+
+func TestEncrypt_SplitsLargeChunks(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := Encrypt(&mockWriter{buf: &buf}, "k1", testEncKey)
+	require.NoError(t, err)
+	w.maxChunk = 10
+
+	_, err = w.Write([]byte("0123456789abcdefghij-tail\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte(encMagic)))
+
+	var out bytes.Buffer
+	require.NoError(t, Decrypt(&out, &buf, testKeyLookup))
+	assert.Equal(t, "0123456789abcdefghij-tail\n", out.String())
+
+	big, err := Encrypt(&mockWriter{buf: &bytes.Buffer{}}, "k1", testEncKey, WithChunkSize(1<<30))
+	require.NoError(t, err)
+	assert.Equal(t, maxChunkPlain, big.chunkSize)
+}
+
+func TestEncrypt_WriteLevel(t *testing.T) {
+	target := &levelRecorder{}
+	w, err := Encrypt(target, "k1", testEncKey)
+	require.NoError(t, err)
+	_, err = WriteLevel(w, slog.LevelError, []byte("boom\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("plain\n"))
+	require.NoError(t, err)
+	require.Len(t, target.lines, 2)
+	assert.True(t, strings.HasPrefix(target.lines[0], "ERROR:"))
+	assert.True(t, strings.HasPrefix(target.lines[1], "INFO:"))
+
+	// 缓冲模式下块以其中最高的级别写出
+	target = &levelRecorder{}
+	w, err = Encrypt(target, "k1", testEncKey, WithChunkSize(1024))
+	require.NoError(t, err)
+	_, _ = WriteLevel(w, slog.LevelInfo, []byte("a\n"))
+	_, _ = WriteLevel(w, slog.LevelWarn, []byte("b\n"))
+	_, _ = WriteLevel(w, slog.LevelDebug, []byte("c\n"))
+	require.NoError(t, w.Sync())
+	require.Len(t, target.lines, 1)
+	assert.True(t, strings.HasPrefix(target.lines[0], "WARN:"))
+}
+
+func TestEncrypt_Errors(t *testing.T) {
+	_, err := Encrypt(&mockWriter{buf: &bytes.Buffer{}}, "k1", []byte("short"))
+	require.Error(t, err)
+	_, err = Encrypt(&mockWriter{buf: &bytes.Buffer{}}, "", testEncKey)
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	w, err := Encrypt(&mockWriter{buf: &buf}, "k1", testEncKey)
+	require.NoError(t, err)
+	_, _ = w.Write([]byte("hello\n"))
+	require.Error(t, w.Rotate(), "mockWriter 不支持轮转")
+
+	// 未知密钥
+	err = Decrypt(&bytes.Buffer{}, bytes.NewReader(buf.Bytes()), func(string) ([]byte, error) {
+		return nil, os.ErrNotExist
+	})
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// 错误密钥
+	err = Decrypt(&bytes.Buffer{}, bytes.NewReader(buf.Bytes()), func(string) ([]byte, error) {
+		return bytes.Repeat([]byte{1}, 32), nil
+	})
+	require.Error(t, err)
+
+	// 篡改密文
+	data := bytes.Clone(buf.Bytes())
+	data[len(data)-1] ^= 0xff
+	require.Error(t, Decrypt(&bytes.Buffer{}, bytes.NewReader(data), testKeyLookup))
+
+	// 截断
+	require.Error(t, Decrypt(&bytes.Buffer{}, bytes.NewReader(buf.Bytes()[:buf.Len()-3]), testKeyLookup))
+}
+
+func TestEncryptedFile_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := EncryptedFile(path, "k1", testEncKey, nil)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("before rotate\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Rotate())
+	_, err = w.Write([]byte("after rotate\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(path), "app-*.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	// 轮转后的文件和当前文件都可以独立解密
+	for file, want := range map[string]string{files[0]: "before rotate\n", path: "after rotate\n"} {
+		f, err := os.Open(file)
+		require.NoError(t, err)
+		var out bytes.Buffer
+		require.NoError(t, Decrypt(&out, f, testKeyLookup))
+		_ = f.Close()
+		assert.Equal(t, want, out.String())
+	}
+}
+
+func TestEncryptedFile_Options(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := EncryptedFile(path, "k1", testEncKey, []FileOption{WithRotation(100, 3)}, WithChunkSize(1024))
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("buffered\n"))
+	require.NoError(t, err)
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist, "WithChunkSize 经 EncryptedFile 生效，未达到块大小时不写出")
+	require.NoError(t, w.Close())
+
+	f, err := os.Open(path) //nolint:gosec // G304: test file path is safe
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	var out bytes.Buffer
+	require.NoError(t, Decrypt(&out, f, testKeyLookup))
+	assert.Equal(t, "buffered\n", out.String())
+}
+
+// ============ SignedWriter Tests ============
+
+var testSignKey = []byte("0123456789abcdef")
+
+func testSignLookup(id string) ([]byte, error) {
+	if id != "s1" {
+		return nil, os.ErrNotExist
+	}
+	return testSignKey, nil
+}
+
+func TestSign_JSONAndText(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := Sign(&mockWriter{buf: &buf}, "s1", testSignKey)
+	require.NoError(t, err)
+
+	jsonLine := `{"level":"INFO","msg":"hello"}` + "\n"
+	textLine := "time=now level=INFO msg=hello\n"
+	n, err := w.Write([]byte(jsonLine))
+	require.NoError(t, err)
+	assert.Equal(t, len(jsonLine), n)
+	_, err = w.Write([]byte(textLine))
+	require.NoError(t, err)
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+	assert.Regexp(t, `^\{"level":"INFO","msg":"hello","sig":"s1:[0-9a-f]{32}"\}\n$`, lines[0])
+	assert.Regexp(t, `^time=now level=INFO msg=hello sig=s1:[0-9a-f]{32}\n$`, lines[1])
+
+	body, err := VerifyLine([]byte(lines[0]), testSignLookup)
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSuffix(jsonLine, "\n"), string(body))
+	body, err = VerifyLine([]byte(lines[1]), testSignLookup)
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSuffix(textLine, "\n"), string(body))
+}
+
+func TestSign_EmptyJSONObject(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := Sign(&mockWriter{buf: &buf}, "s1", testSignKey)
+	require.NoError(t, err)
+
+	for _, line := range []string{"{}\n", "{ }\n"} {
+		buf.Reset()
+		_, err = w.Write([]byte(line))
+		require.NoError(t, err)
+		assert.Regexp(t, `^\{ ?"sig":"s1:[0-9a-f]{32}"\}\n$`, buf.String())
+		assert.True(t, json.Valid(buf.Bytes()), buf.String())
+
+		body, err := VerifyLine(buf.Bytes(), testSignLookup)
+		require.NoError(t, err)
+		assert.Equal(t, strings.TrimSuffix(line, "\n"), string(body))
+	}
+}
+
+func TestVerifyLines_DetectsInjection(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := Sign(&mockWriter{buf: &buf}, "s1", testSignKey)
+	require.NoError(t, err)
+	_, _ = w.Write([]byte(`{"msg":"ok"}` + "\n"))
+
+	forged, err := Sign(&mockWriter{buf: &buf}, "s1", []byte("attacker-key-0000"))
+	require.NoError(t, err)
+	_, _ = forged.Write([]byte(`{"msg":"forged"}` + "\n"))
+
+	buf.WriteString(`{"msg":"unsigned"}` + "\n")
+	_, _ = w.Write([]byte(`{"msg":"ok"}` + "\n"))
+	// 篡改第一行，最后一行保持不变
+	tampered := strings.Replace(buf.String(), `{"msg":"ok","sig"`, `{"msg":"OK","sig"`, 1)
+
+	bad, err := VerifyLines(strings.NewReader(tampered), testSignLookup)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, bad)
+
+	_, err = VerifyLine([]byte(`{"msg":"x","sig":"other:00000000000000000000000000000000"}`), testSignLookup)
+	require.ErrorIs(t, err, ErrBadSignature)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestSign_WriteLevel(t *testing.T) {
+	target := &levelRecorder{}
+	w, err := Sign(target, "s1", testSignKey)
+	require.NoError(t, err)
+	_, err = WriteLevel(w, slog.LevelError, []byte(`{"msg":"boom"}`+"\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("msg=plain\n"))
+	require.NoError(t, err)
+
+	require.Len(t, target.lines, 2)
+	assert.True(t, strings.HasPrefix(target.lines[0], `ERROR:{"msg":"boom","sig":"s1:`))
+	assert.True(t, strings.HasPrefix(target.lines[1], "INFO:msg=plain"))
+}
+
+func TestSign_InvalidArgs(t *testing.T) {
+	_, err := Sign(&mockWriter{buf: &bytes.Buffer{}}, "a:b", testSignKey)
+	require.Error(t, err)
+	_, err = Sign(&mockWriter{buf: &bytes.Buffer{}}, "s1", []byte("short"))
+	require.Error(t, err)
+}
+
+// ============ TLSConfig Tests ============
+
+// writeTestCert 生成自签名证书和私钥，返回文件路径
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "logm-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestTLSConfig_Build(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+
+	cfg, err := (&TLSConfig{}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	assert.Nil(t, cfg.RootCAs)
+
+	cfg, err = (&TLSConfig{CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "logs.example.com"}).Build()
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.RootCAs)
+	assert.Len(t, cfg.Certificates, 1)
+	assert.Equal(t, "logs.example.com", cfg.ServerName)
+
+	_, err = (&TLSConfig{CertFile: certFile}).Build()
+	require.Error(t, err)
+	_, err = (&TLSConfig{CAFile: keyFile}).Build()
+	require.Error(t, err)
+	_, err = (&TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}).Build()
+	require.Error(t, err)
+}
+
+// ============ Rotator Tests ============
+
+func TestRotate_ThroughWrappers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	var buf bytes.Buffer
+	w := Async(Multi(&mockWriter{buf: &buf}, File(path, WithCompress(false))), 16)
+
+	_, err := w.Write([]byte("before\n"))
+	require.NoError(t, err)
+	require.NoError(t, Rotate(w))
+	_, err = w.Write([]byte("after\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	names := listDir(t, dir)
+	require.Len(t, names, 2)
+	content, err := os.ReadFile(path) //nolint:gosec // G304: test file path is safe
+	require.NoError(t, err)
+	assert.Equal(t, "after\n", string(content))
+
+	// 不支持轮转的 Writer 忽略
+	require.NoError(t, Rotate(&mockWriter{buf: &buf}))
+}
+
+func TestWalk(t *testing.T) {
+	leaf1 := &mockWriter{buf: &bytes.Buffer{}}
+	leaf2 := &mockWriter{buf: &bytes.Buffer{}}
+	signed, err := Sign(leaf2, "s1", testSignKey)
+	require.NoError(t, err)
+	perLevel := PerLevel(map[slog.Level]Writer{slog.LevelInfo: leaf1, slog.LevelError: signed})
+	w := Async(Multi(perLevel), 16)
+	defer func() { _ = w.Close() }()
+
+	var seen []Writer
+	assert.True(t, Walk(w, func(w Writer) bool {
+		seen = append(seen, w)
+		return true
+	}))
+	require.Len(t, seen, 6)
+	assert.Same(t, perLevel, seen[2])
+	assert.Same(t, leaf1, seen[3])
+	assert.Same(t, leaf2, seen[5])
+
+	// fn 返回 false 时停止遍历
+	var n int
+	assert.False(t, Walk(w, func(w Writer) bool {
+		n++
+		return w != perLevel
+	}))
+	assert.Equal(t, 3, n)
+}
+
+// ============ PerLevelWriter Tests ============
+
+func TestPerLevel_Routes(t *testing.T) {
+	var all, errs bytes.Buffer
+	pw := PerLevel(map[slog.Level]Writer{
+		slog.LevelError: &mockWriter{buf: &errs},
+		slog.LevelDebug: &mockWriter{buf: &all},
+	})
+
+	for _, lvl := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		_, err := pw.WriteLevel(lvl, []byte(lvl.String()+"\n"))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "DEBUG\nINFO\nWARN\nERROR\n", all.String())
+	assert.Equal(t, "ERROR\n", errs.String())
+}
+
+func TestPerLevel_WriteAsInfo(t *testing.T) {
+	var info, warn bytes.Buffer
+	pw := PerLevel(map[slog.Level]Writer{
+		slog.LevelInfo: &mockWriter{buf: &info},
+		slog.LevelWarn: &mockWriter{buf: &warn},
+	})
+
+	n, err := pw.Write([]byte("plain"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, "plain", info.String())
+	assert.Empty(t, warn.String())
+}
+
+func TestPerLevel_ThroughAsyncAndMulti(t *testing.T) {
+	var all, errs bytes.Buffer
+	w := Async(Multi(PerLevel(map[slog.Level]Writer{
+		slog.LevelInfo:  &mockWriter{buf: &all},
+		slog.LevelError: &mockWriter{buf: &errs},
+	})), 16)
+
+	_, _ = WriteLevel(w, slog.LevelInfo, []byte("info\n"))
+	_, _ = WriteLevel(w, slog.LevelError, []byte("boom\n"))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "info\nboom\n", all.String())
+	assert.Equal(t, "boom\n", errs.String())
+}
+
+func TestPerLevel_Close(t *testing.T) {
+	w1 := &mockWriter{buf: &bytes.Buffer{}}
+	w2 := &mockWriter{buf: &bytes.Buffer{}}
+	pw := PerLevel(map[slog.Level]Writer{slog.LevelInfo: w1, slog.LevelError: w2})
+
+	require.NoError(t, pw.Sync())
+	require.NoError(t, pw.Close())
+	assert.True(t, w1.closed)
+	assert.True(t, w2.closed)
+}
+
+// ============ DLQWriter Tests ============
+
+func TestDLQ_RetryThenSucceed(t *testing.T) {
+	var buf bytes.Buffer
+	fw := &flakyWriter{mockWriter: mockWriter{buf: &buf}, failures: 2}
+	path := filepath.Join(t.TempDir(), "app.dlq")
+	w := DLQ(fw, path, WithDLQRetry(3, 0))
+
+	n, err := w.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+	assert.Equal(t, "hello\n", buf.String())
+	assert.Equal(t, uint64(0), w.DeadLettered())
+	assert.NoFileExists(t, path)
+}
+
+func TestDLQ_ReplayAfterOutage(t *testing.T) {
+	var buf bytes.Buffer
+	fw := &flakyWriter{mockWriter: mockWriter{buf: &buf}, failures: -1}
+	path := filepath.Join(t.TempDir(), "app.dlq")
+	w := DLQ(fw, path, WithDLQRetry(2, time.Millisecond))
+
+	_, err := w.WriteLevel(slog.LevelError, []byte("first\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("multi\nline\n"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), w.DeadLettered())
+	assert.Equal(t, 4, fw.calls)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// 采集端恢复
+	fw.failures = 0
+	target := &levelRecorder{}
+	n, err := ReplayDLQ(t.Context(), path, target)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"ERROR:first\n", "INFO:multi\nline\n"}, target.lines)
+	assert.NoFileExists(t, path)
+	assert.NoFileExists(t, path+dlqReplaySuffix)
+
+	n, err = ReplayDLQ(t.Context(), path, target)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestDLQ_ReplayStopsOnFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.dlq")
+	w := DLQ(&flakyWriter{mockWriter: mockWriter{buf: &bytes.Buffer{}}, failures: -1}, path, WithDLQRetry(1, 0))
+	for _, s := range []string{"a\n", "b\n", "c\n"} {
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+
+	// 第二条写入失败，b 和 c 保留
+	var buf bytes.Buffer
+	target := &flakyWriter{mockWriter: mockWriter{buf: &buf}, failAt: 2}
+	n, err := ReplayDLQ(t.Context(), path, target)
+	require.ErrorIs(t, err, errFlaky)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "a\n", buf.String())
+
+	n, err = ReplayDLQ(t.Context(), path, &mockWriter{buf: &buf})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "a\nb\nc\n", buf.String())
+}
+
+func TestDLQ_ReplayKeepsFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("文件权限仅适用于类 Unix 系统")
+	}
+	path := filepath.Join(t.TempDir(), "app.dlq")
+	w := DLQ(&flakyWriter{mockWriter: mockWriter{buf: &bytes.Buffer{}}, failures: -1}, path, WithDLQRetry(1, 0), WithDLQFileMode(0o640))
+	for _, s := range []string{"a\n", "b\n"} {
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+
+	_, err := ReplayDLQ(t.Context(), path, &flakyWriter{mockWriter: mockWriter{buf: &bytes.Buffer{}}, failAt: 1})
+	require.ErrorIs(t, err, errFlaky)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+}
+
+func TestDLQ_ReplayCanceled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.dlq")
+	w := DLQ(&flakyWriter{mockWriter: mockWriter{buf: &bytes.Buffer{}}, failures: -1}, path, WithDLQRetry(1, 0))
+	_, _ = w.Write([]byte("a\n"))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	n, err := ReplayDLQ(ctx, path, &mockWriter{buf: &bytes.Buffer{}})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, n)
+	assert.FileExists(t, path)
+}
+
+func TestDLQ_MaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.dlq")
+	w := DLQ(&flakyWriter{mockWriter: mockWriter{buf: &bytes.Buffer{}}, failures: -1}, path,
+		WithDLQRetry(1, 0), WithDLQMaxSize(150))
+
+	_, err := w.Write([]byte("a\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("b\n"))
+	require.ErrorIs(t, err, errFlaky)
+	assert.Equal(t, uint64(1), w.DeadLettered())
+	assert.Equal(t, uint64(1), w.Dropped())
+}
+
+// ============ WALWriter Tests ============
+
+func TestWAL_Ship(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	w, err := WAL(&mockWriter{buf: &buf, mu: &sync.Mutex{}}, dir)
+	require.NoError(t, err)
+
+	for _, s := range []string{"a\n", "b\n", "c\n"} {
+		n, err := w.Write([]byte(s))
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+	}
+	require.NoError(t, w.Sync())
+	require.NoError(t, w.Close())
+	assert.Equal(t, "a\nb\nc\n", buf.String())
+	assert.Equal(t, uint64(3), w.Shipped())
+
+	_, err = w.Write([]byte("late\n"))
+	require.ErrorIs(t, err, ErrWALClosed)
+	require.NoError(t, w.Close())
+}
+
+func TestWAL_RedeliverOnRestart(t *testing.T) {
+	dir := t.TempDir()
+	down := &flakyWriter{mockWriter: mockWriter{buf: &bytes.Buffer{}}, failures: -1}
+	w, err := WAL(down, dir)
+	require.NoError(t, err)
+	_, _ = w.WriteLevel(slog.LevelError, []byte("a\n"))
+	_, _ = w.WriteLevel(slog.LevelWarn, []byte("b\n"))
+	require.NoError(t, w.Close())
+	assert.Zero(t, w.Shipped())
+	assert.Positive(t, w.Failures())
+
+	target := &levelRecorder{mockWriter: mockWriter{buf: &bytes.Buffer{}}}
+	w, err = WAL(target, dir)
+	require.NoError(t, err)
+	_, _ = w.Write([]byte("c\n"))
+	require.NoError(t, w.Close())
+	assert.Equal(t, []string{"ERROR:a\n", "WARN:b\n", "INFO:c\n"}, target.lines)
+
+	// 已确认的记录不再投递
+	target.lines = nil
+	w, err = WAL(target, dir)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Empty(t, target.lines)
+}
+
+func TestWAL_Retry(t *testing.T) {
+	var buf bytes.Buffer
+	mu := &sync.Mutex{}
+	fw := &flakyWriter{mockWriter: mockWriter{buf: &buf, mu: mu}, failures: 2}
+	w, err := WAL(fw, t.TempDir(), WithWALSyncWrites(false))
+	require.NoError(t, err)
+
+	_, _ = w.Write([]byte("a\n"))
+	assert.Eventually(t, func() bool { return w.Shipped() == 1 }, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, w.Close())
+	assert.Equal(t, "a\n", buf.String())
+	assert.Equal(t, uint64(2), w.Failures())
+}
+
+func TestWAL_Segments(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	w, err := WAL(&mockWriter{buf: &buf, mu: &sync.Mutex{}}, dir, WithWALSegmentSize(3*(walHeaderSize+8)))
+	require.NoError(t, err)
+
+	var want strings.Builder
+	for i := range 20 {
+		line := fmt.Sprintf("rec %03d\n", i)
+		want.WriteString(line)
+		_, err := w.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	assert.Equal(t, want.String(), buf.String())
+
+	// 已投递的段被删除
+	segs, _ := filepath.Glob(filepath.Join(dir, walSegmentGlob))
+	assert.Len(t, segs, 1)
+}
+
+func TestWAL_TornTail(t *testing.T) {
+	dir := t.TempDir()
+	w, err := WAL(&flakyWriter{mockWriter: mockWriter{buf: &bytes.Buffer{}}, failures: -1}, dir)
+	require.NoError(t, err)
+	_, _ = w.Write([]byte("a\n"))
+	require.NoError(t, w.Close())
+
+	// 模拟写入一半时崩溃
+	segs, _ := filepath.Glob(filepath.Join(dir, walSegmentGlob))
+	require.Len(t, segs, 1)
+	f, err := os.OpenFile(segs[0], os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, _ = f.Write([]byte{0, 0, 0, 9, 0, 0})
+	require.NoError(t, f.Close())
+
+	var buf bytes.Buffer
+	w, err = WAL(&mockWriter{buf: &buf, mu: &sync.Mutex{}}, dir)
+	require.NoError(t, err)
+	_, _ = w.Write([]byte("b\n"))
+	require.NoError(t, w.Close())
+	assert.Equal(t, "a\nb\n", buf.String())
+}
+
+func TestWAL_SyncFailureDiscardsRecord(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	w, err := WAL(&mockWriter{buf: &buf, mu: &sync.Mutex{}}, dir)
+	require.NoError(t, err)
+	w.mu.Lock()
+	w.fsync = func(*os.File) error { return errors.New("fsync failed") }
+	w.mu.Unlock()
+
+	_, err = w.Write([]byte("lost\n"))
+	require.ErrorContains(t, err, "fsync failed")
+
+	w.mu.Lock()
+	w.fsync = defaultFsync
+	w.mu.Unlock()
+	_, err = w.Write([]byte("kept\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, "kept\n", buf.String())
+}
+
+// ============ Replay Tests ============
+
+func TestReplayFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clock := &fakeClock{t: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)}
+
+	w := File(path, WithLocalTime(false))
+	w.now = clock.Now
+	levels := []string{"INFO", "WARN", "ERROR"}
+	for i := range 3 {
+		line := fmt.Sprintf(`{"time":"2024-01-15T10:00:0%dZ","level":%q,"msg":"line %d"}`+"\n", i, levels[i], i)
+		_, err := w.Write([]byte(line))
+		require.NoError(t, err)
+		clock.Add(time.Second)
+		require.NoError(t, w.Rotate())
+	}
+	_, err := w.Write([]byte("time=2024-01-15T10:00:03Z level=DEBUG msg=current\nnot a log line"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// 备份按修改时间排序，按备份名中的时间固定修改时间，避免同一时刻轮转导致顺序不确定
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+	require.Len(t, backups, 3)
+	for i, f := range backups {
+		mt := time.Date(2024, 1, 15, 10, 0, i, 0, time.UTC)
+		require.NoError(t, os.Chtimes(f, mt, mt))
+	}
+
+	files, err := File(path, WithLocalTime(false)).Archives()
+	require.NoError(t, err)
+	assert.Equal(t, backups, files)
+
+	target := &levelRecorder{mockWriter: mockWriter{buf: &bytes.Buffer{}}}
+	n, err := ReplayFiles(t.Context(), append(files, path), target)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	require.Len(t, target.lines, 5)
+	assert.True(t, strings.HasPrefix(target.lines[0], `INFO:{"time":"2024-01-15T10:00:00Z"`), target.lines[0])
+	assert.True(t, strings.HasPrefix(target.lines[1], "WARN:"), target.lines[1])
+	assert.True(t, strings.HasPrefix(target.lines[2], "ERROR:"), target.lines[2])
+	assert.Equal(t, "DEBUG:time=2024-01-15T10:00:03Z level=DEBUG msg=current\n", target.lines[3])
+	assert.Equal(t, "INFO:not a log line\n", target.lines[4])
+
+	// 按时间范围和条件过滤，无法解析的行被跳过
+	target.lines = nil
+	n, err = ReplayFiles(t.Context(), append(files, path), target,
+		WithReplayRange(time.Date(2024, 1, 15, 10, 0, 1, 0, time.UTC), time.Date(2024, 1, 15, 10, 0, 3, 0, time.UTC)),
+		WithReplayFilter(func(e *formatter.Entry) bool { return e.Level >= slog.LevelError }))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Contains(t, target.lines[0], "line 2")
+}
+
+func TestReplayFiles_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("a\nb\n"), 0o600))
+
+	_, err := ReplayFiles(t.Context(), []string{path}, &flakyWriter{mockWriter: mockWriter{buf: &bytes.Buffer{}}, failAt: 2})
+	require.ErrorIs(t, err, errFlaky)
+	assert.Contains(t, err.Error(), "app.log:2")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err = ReplayFiles(ctx, []string{path}, &mockWriter{buf: &bytes.Buffer{}})
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = ReplayFiles(t.Context(), []string{path + ".missing"}, &mockWriter{buf: &bytes.Buffer{}})
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
 // ============ Helper: mockWriter ============
 
 type mockWriter struct {
@@ -303,3 +1769,73 @@ func (m *mockWriter) Close() error {
 func (m *mockWriter) Sync() error {
 	return nil
 }
+
+// blockingWriter 在 release 关闭前阻塞所有写入
+type blockingWriter struct {
+	release    chan struct{}
+	blockClose bool
+}
+
+func (b *blockingWriter) Write(p []byte) (n int, err error) {
+	<-b.release
+	return len(p), nil
+}
+
+func (b *blockingWriter) Close() error {
+	if b.blockClose {
+		<-b.release
+	}
+	return nil
+}
+
+func (b *blockingWriter) Sync() error { return nil }
+
+var errFlaky = errors.New("collector unavailable")
+
+// flakyWriter 前 failures 次写入失败（-1 表示一直失败），failAt 指定第几次写入失败
+type flakyWriter struct {
+	mockWriter
+	failures int
+	failAt   int
+	calls    int
+}
+
+func (f *flakyWriter) Write(p []byte) (n int, err error) {
+	f.calls++
+	if f.failures < 0 || f.calls <= f.failures || f.calls == f.failAt {
+		return 0, errFlaky
+	}
+	return f.mockWriter.Write(p)
+}
+
+// levelRecorder 记录 WriteLevel 收到的级别和数据
+type levelRecorder struct {
+	mockWriter
+	lines []string
+}
+
+func (l *levelRecorder) WriteLevel(level slog.Level, p []byte) (n int, err error) {
+	l.lines = append(l.lines, level.String()+":"+string(p))
+	return len(p), nil
+}
+
+// gateWriter 在 release 关闭前阻塞写入，之后写入 mockWriter 并记录级别
+type gateWriter struct {
+	mockWriter
+	release chan struct{}
+	lvls    []slog.Level
+}
+
+func (g *gateWriter) WriteLevel(level slog.Level, p []byte) (n int, err error) {
+	<-g.release
+	g.mu.Lock()
+	g.lvls = append(g.lvls, level)
+	g.mu.Unlock()
+	return g.mockWriter.Write(p)
+}
+
+func (g *gateWriter) levels() []slog.Level {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.lvls)
+}